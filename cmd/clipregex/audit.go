@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/audit"
+)
+
+// auditTimeLayouts are the formats accepted by --since and --until, besides
+// durations such as "2h" meaning that long ago.
+var auditTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02"}
+
+// runAuditCommand lists the records of the audit log, optionally only those
+// whose input or result was a given text.
+func runAuditCommand(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("audit", stderr)
+	since := fs.String("since", "", "only records from this time on: \"2006-01-02 15:04\", a date, or a duration ago such as \"2h\"")
+	until := fs.String("until", "", "only records up to this time, in the same formats as --since")
+	textFile := fs.String("text", "", "only records whose input or result was the text in this file (\"-\" reads stdin)")
+	asJSON := fs.Bool("json", false, "print the records as JSON Lines, like the log file")
+	configPath := configFlag(fs)
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 0 {
+		fmt.Fprintln(stderr, "Usage: clipregex audit [--since TIME] [--until TIME] [--text FILE|-] [--json] [--config FILE]")
+		fs.PrintDefaults()
+		return 2
+	}
+	from, err := parseAuditTime(*since)
+	if err != nil {
+		fmt.Fprintf(stderr, "Invalid --since: %v\n", err)
+		return 2
+	}
+	to, err := parseAuditTime(*until)
+	if err != nil {
+		fmt.Fprintf(stderr, "Invalid --until: %v\n", err)
+		return 2
+	}
+	if *until != "" && len(*until) == len("2006-01-02") {
+		to = to.Add(24 * time.Hour) // The whole day
+	}
+
+	// The pasted text may have other line breaks than the clipboard had
+	var hashes map[string]bool
+	if *textFile != "" {
+		var data []byte
+		if *textFile == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(*textFile)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "Failed to read --text: %v\n", err)
+			return 1
+		}
+		lf := strings.ReplaceAll(string(data), "\r\n", "\n")
+		hashes = make(map[string]bool)
+		for _, variant := range []string{string(data), lf, strings.ReplaceAll(lf, "\n", "\r\n"), strings.TrimRight(lf, "\n")} {
+			hashes[audit.Hash(variant)] = true
+		}
+	}
+
+	if !resolveConfigFlag(configPath, stderr) {
+		return 1
+	}
+	path := audit.PathForConfig(*configPath)
+	records, err := audit.Read(path)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	shown := 0
+	for _, rec := range records {
+		if (!from.IsZero() && rec.Time.Before(from)) || (!to.IsZero() && rec.Time.After(to)) {
+			continue
+		}
+		role := ""
+		if hashes != nil {
+			switch {
+			case hashes[rec.AfterSHA256] && rec.Changed:
+				role = "the text is the RESULT of this run"
+			case hashes[rec.BeforeSHA256] && rec.Changed:
+				role = "the text is the INPUT of this run, before its changes"
+			case hashes[rec.BeforeSHA256]:
+				role = "the text went through this run unchanged"
+			default:
+				continue
+			}
+		}
+		shown++
+		if *asJSON {
+			data, _ := json.Marshal(rec)
+			fmt.Fprintln(stdout, string(data))
+			continue
+		}
+		printAuditRecord(stdout, rec, role)
+	}
+
+	if *asJSON {
+		return 0
+	}
+	switch {
+	case len(records) == 0:
+		fmt.Fprintf(stdout, "The audit log %s has no records. Is \"audit_log\" enabled in the config?\n", path)
+	case shown == 0 && hashes != nil:
+		fmt.Fprintln(stdout, "No recorded run had this text as its input or result.")
+		return 1
+	case shown == 0:
+		fmt.Fprintln(stdout, "No records in this time range.")
+	}
+	return 0
+}
+
+// printAuditRecord writes one record in a readable form.
+func printAuditRecord(w io.Writer, rec audit.Record, role string) {
+	direction := ""
+	if rec.Reverse {
+		direction = " (reverse)"
+	}
+	trigger := rec.Hotkey
+	if trigger == "" {
+		trigger = "trigger"
+	}
+	outcome := "unchanged"
+	if rec.Changed {
+		outcome = fmt.Sprintf("%d replacement(s), %d -> %d bytes", rec.Replacements, rec.BeforeBytes, rec.AfterBytes)
+	}
+	fmt.Fprintf(w, "%s  %s%s  %s: %s\n", rec.Time.Local().Format("2006-01-02 15:04:05"), trigger, direction, strings.Join(rec.Profiles, ", "), outcome)
+	for _, rule := range rec.Rules {
+		description := ""
+		if rule.Description != "" {
+			description = " " + rule.Description
+		}
+		fmt.Fprintf(w, "    %s #%d%s: %d match(es) [%s]\n", rule.Profile, rule.Index, description, rule.Matches, rule.Fingerprint)
+	}
+	if role != "" {
+		fmt.Fprintf(w, "    -> %s\n", role)
+	}
+}
+
+// parseAuditTime parses a --since or --until value in local time. Empty
+// yields the zero time.
+func parseAuditTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	for _, layout := range auditTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("'%s' is neither a time like \"2006-01-02 15:04\" nor a duration like \"2h\"", value)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/clipboard"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+)
+
+// runBenchCommand measures the time and allocations of each rule of a
+// profile on a sample input, optionally writing CPU and heap profiles. It is
+// meant for checking performance before a release and not listed by "help".
+func runBenchCommand(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("bench", stderr)
+	profileName := fs.String("profile", "", "profile whose rules are measured (required)")
+	inputPath := fs.String("input", "", "file with the sample clipboard text, or - for stdin (required)")
+	iterations := fs.Int("iterations", 10, "number of times the rules are applied")
+	reverse := fs.Bool("reverse", false, "measure the reverse replacements")
+	cpuProfile := fs.String("cpuprofile", "", "write a CPU profile for 'go tool pprof' to this file")
+	memProfile := fs.String("memprofile", "", "write a heap allocation profile to this file")
+	configPath := configFlag(fs)
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 0 || *profileName == "" || *inputPath == "" || *iterations < 1 {
+		fmt.Fprintln(stderr, "Usage: clipregex bench --profile NAME --input FILE [flags]")
+		fs.PrintDefaults()
+		return 2
+	}
+
+	var input []byte
+	if *inputPath == "-" {
+		input, err = io.ReadAll(os.Stdin)
+	} else {
+		input, err = os.ReadFile(*inputPath)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Could not read the input: %v\n", err)
+		return 1
+	}
+
+	if !resolveConfigFlag(configPath, stderr) {
+		return 1
+	}
+	cfg, err := config.LoadWithoutSecrets(*configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s is invalid: %v\n", *configPath, err)
+		return 1
+	}
+	index := cfg.FindProfile(*profileName)
+	if index < 0 {
+		fmt.Fprintf(stderr, "Profile '%s' not found in %s.\n", *profileName, *configPath)
+		return 1
+	}
+	profile := cfg.ResolvedProfiles()[index]
+
+	if *cpuProfile != "" {
+		f, err := os.Create(*cpuProfile)
+		if err != nil {
+			fmt.Fprintf(stderr, "Could not create the CPU profile: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			fmt.Fprintf(stderr, "Could not start the CPU profile: %v\n", err)
+			return 1
+		}
+	}
+	manager := clipboard.NewManager(cfg, nil, nil)
+	manager.LoadPlugins()
+	defer manager.ClosePlugins()
+	result := manager.Benchmark(profile, string(input), *iterations, *reverse)
+	if *cpuProfile != "" {
+		pprof.StopCPUProfile()
+	}
+	if *memProfile != "" {
+		if err := writeHeapProfile(*memProfile); err != nil {
+			fmt.Fprintf(stderr, "Could not write the heap profile: %v\n", err)
+			return 1
+		}
+	}
+
+	fmt.Fprintf(stdout, "Profile '%s': %d rule(s) on %d bytes, %d iteration(s), %s/%s\n",
+		result.Profile, len(result.Rules), result.InputBytes, result.Iterations, runtime.GOOS, runtime.GOARCH)
+	failed := 0
+	for _, rule := range result.Rules {
+		fmt.Fprintf(stdout, "  %v\n", rule)
+		if rule.Err != nil {
+			failed++
+		}
+	}
+	fmt.Fprintf(stdout, "Total per iteration: %v, %d alloc(s), %d bytes. The input was %s.\n",
+		result.Time, result.Allocs, result.Bytes, map[bool]string{true: "changed", false: "left unchanged"}[result.Changed])
+
+	slowest := make([]clipboard.RuleBenchmark, len(result.Rules))
+	copy(slowest, result.Rules)
+	sort.SliceStable(slowest, func(i, j int) bool { return slowest[i].Time > slowest[j].Time })
+	if len(slowest) > 5 {
+		slowest = slowest[:5]
+	}
+	fmt.Fprintln(stdout, "Slowest rules:")
+	for _, rule := range slowest {
+		if rule.Time > 0 {
+			fmt.Fprintf(stdout, "  #%d %v (%.1f%%)\n", rule.Rule, rule.Time, 100*float64(rule.Time)/float64(result.Time))
+		}
+	}
+	if failed > 0 {
+		fmt.Fprintf(stdout, "%d rule(s) failed and were not measured (rules using secrets fail here, as secrets are not loaded).\n", failed)
+		return 1
+	}
+	return 0
+}
+
+// writeHeapProfile writes the allocations made so far to path.
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	runtime.GC() // Up-to-date statistics
+	if err := pprof.Lookup("allocs").WriteTo(f, 0); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
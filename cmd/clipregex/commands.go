@@ -0,0 +1,537 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/clipboard"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/doctor"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/importer"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/install"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/ipc"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/lint"
+)
+
+// command is a command line subcommand. Running the executable without a
+// subcommand starts the tray application as before.
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(args []string, stdout, stderr io.Writer) int
+	hidden  bool // Not listed by "help", e.g. tools for developers
+}
+
+// commands lists all subcommands in the order shown by "help".
+var commands []command
+
+func init() {
+	commands = []command{
+		{
+			name:    "import",
+			usage:   "import <espanso|ahk> <path> [--profile NAME] [--hotkey HOTKEY] [--merge] [--dry-run] [--config FILE]",
+			summary: "Convert Espanso match files or AutoHotkey hotstrings into a profile",
+			run:     runImportCommand,
+		},
+		{
+			name:    "validate",
+			usage:   "validate [--config FILE]",
+			summary: "Check the config file for errors, unsupported hotkeys and undeclared secrets",
+			run:     runValidateCommand,
+		},
+		{
+			name:    "lint",
+			usage:   "lint [--ignore CHECK,...] [--config FILE]",
+			summary: "Report unreachable, duplicate, shadowed and expensive rules",
+			run:     runLintCommand,
+		},
+		{
+			name:    "test",
+			usage:   "test [--profile NAME] [--verbose] [--config FILE]",
+			summary: "Run the test cases embedded in the rules (\"tests\": [{\"input\", \"expect\"}])",
+			run:     runTestCommand,
+		},
+		{
+			name:    "secrets",
+			usage:   "secrets list | audit [--resolve] | export <file> [--values] | import <file> [--config FILE]",
+			summary: "List secrets, report which rules use them, export their names (and encrypted values), or import many from a JSON or CSV file",
+			run:     runSecretsCommand,
+		},
+		{
+			name:    "stats",
+			usage:   "stats export [<file>|-] [--format csv|json] [--month YYYY-MM] [--config FILE]",
+			summary: "Export the per-rule and per-profile statistics of this month, or an archived month, as CSV or JSON",
+			run:     runStatsCommand,
+		},
+		{
+			name:    "audit",
+			usage:   "audit [--since TIME] [--until TIME] [--text FILE|-] [--json] [--config FILE]",
+			summary: "List the audit log of transformations, or find the run whose input or result was a given text",
+			run:     runAuditCommand,
+		},
+		{
+			name:    "locale",
+			usage:   "locale list | template <language> [<file>|-] [--config FILE]",
+			summary: "List the available languages, or write a catalog template to translate the application into a language",
+			run:     runLocaleCommand,
+		},
+		{
+			name:    "trigger",
+			usage:   "trigger <profile> [--reverse] [--config FILE]",
+			summary: "Make the running application apply a profile to the clipboard, e.g. from a window manager shortcut",
+			run:     runTriggerCommand,
+		},
+		{
+			name:    "pause",
+			usage:   "pause [--config FILE]",
+			summary: "Make the running application ignore hotkeys and triggers",
+			run:     controlCommand(ipc.CommandPause),
+		},
+		{
+			name:    "resume",
+			usage:   "resume [--config FILE]",
+			summary: "Handle hotkeys and triggers again after \"pause\"",
+			run:     controlCommand(ipc.CommandResume),
+		},
+		{
+			name:    "reload",
+			usage:   "reload [--config FILE]",
+			summary: "Make the running application reload the config file, secrets and hotkeys",
+			run:     controlCommand(ipc.CommandReload),
+		},
+		{
+			name:    "doctor",
+			usage:   "doctor [--config FILE]",
+			summary: "Check clipboard access, hotkeys, the paste tool, the secret store, notifications and the config file",
+			run:     runDoctorCommand,
+		},
+		{
+			name:    "install",
+			usage:   "install [--no-shortcut] [--autostart]",
+			summary: "Register for notifications and add a Start Menu shortcut or desktop entry for this executable",
+			run:     runInstallCommand,
+		},
+		{
+			name:    "uninstall",
+			usage:   "uninstall [--keep-secrets] [--purge] [--config FILE]",
+			summary: "Remove the shortcut, notification registration, autostart entry and keychain secrets",
+			run:     runUninstallCommand,
+		},
+		{
+			name:    "bench",
+			usage:   "bench --profile NAME --input FILE [--iterations N] [--reverse] [--cpuprofile FILE] [--memprofile FILE] [--config FILE]",
+			summary: "Measure the time and allocations of each rule of a profile on a sample input",
+			run:     runBenchCommand,
+			hidden:  true,
+		},
+		{
+			name:    "help",
+			usage:   "help",
+			summary: "Show this help",
+			run:     runHelpCommand,
+		},
+	}
+}
+
+// runCommand dispatches os.Args[1:] to a subcommand. handled is false when the
+// arguments do not name a subcommand and the tray application should start.
+func runCommand(args []string) (handled bool, exitCode int) {
+	if len(args) == 0 {
+		return false, 0
+	}
+	name := args[0]
+	if name == "-h" || name == "--help" {
+		name = "help"
+	}
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return true, cmd.run(args[1:], os.Stdout, os.Stderr)
+		}
+	}
+	if strings.HasPrefix(name, "-") {
+		return false, 0 // Flags for the tray application
+	}
+	fmt.Fprintf(os.Stderr, "Unknown command '%s'.\n\n", name)
+	runHelpCommand(nil, os.Stderr, os.Stderr)
+	return true, 2
+}
+
+func runHelpCommand(args []string, stdout, stderr io.Writer) int {
+	fmt.Fprintf(stdout, "Clipboard Regex Replace %s\n\n", version)
+	fmt.Fprintln(stdout, "Usage:")
+	fmt.Fprintln(stdout, "  clipregex [--config FILE]  Start the system tray application")
+	for _, cmd := range commands {
+		if cmd.hidden {
+			continue
+		}
+		fmt.Fprintf(stdout, "  clipregex %s\n      %s\n", cmd.usage, cmd.summary)
+	}
+	if path, err := config.DefaultConfigPath(); err == nil {
+		fmt.Fprintf(stdout, "\nWithout --config, the config file is %s\n", path)
+	}
+	return 0
+}
+
+// parseInterspersed parses flags that may appear before, between or after
+// positional arguments and returns the positional arguments.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// newFlagSet creates a flag set that reports errors instead of exiting.
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("clipregex "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
+
+// configFlag registers the --config flag shared by all subcommands.
+func configFlag(fs *flag.FlagSet) *string {
+	return fs.String("config", "", "path to config.json (default: the standard config directory, see \"clipregex help\")")
+}
+
+// resolveConfigFlag replaces an empty --config value with the standard config
+// location (or the portable one, if the marker file exists), migrating a
+// config from the working directory if needed.
+func resolveConfigFlag(configPath *string, stderr io.Writer) bool {
+	resolved, migratedFrom, err := config.ResolveConfigPath(*configPath, false)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to locate config file: %v\n", err)
+		return false
+	}
+	if migratedFrom != "" {
+		fmt.Fprintf(stderr, "Copied %s to %s (the standard config location).\n", migratedFrom, resolved)
+	}
+	*configPath = resolved
+	return true
+}
+
+// printSteps reports install or uninstall steps and returns the exit code:
+// 1 if a step failed, 0 otherwise. Unsupported steps are skipped.
+func printSteps(steps []install.Step, stdout, stderr io.Writer) int {
+	exitCode := 0
+	for _, step := range steps {
+		switch {
+		case step.Err == nil:
+			fmt.Fprintf(stdout, "  ok    %s\n", step.Name)
+		case errors.Is(step.Err, install.ErrUnsupported):
+			fmt.Fprintf(stdout, "  skip  %s (%v)\n", step.Name, step.Err)
+		default:
+			fmt.Fprintf(stderr, "  FAIL  %s: %v\n", step.Name, step.Err)
+			exitCode = 1
+		}
+	}
+	return exitCode
+}
+
+// runInstallCommand integrates the running executable with the desktop.
+// Package managers call it after copying the executable, e.g. from a Scoop
+// post_install hook; it only touches the current user's settings.
+func runInstallCommand(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("install", stderr)
+	noShortcut := fs.Bool("no-shortcut", false, "do not create the Start Menu shortcut or desktop entry")
+	startWithSystem := fs.Bool("autostart", false, "also start the application when you log in")
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 0 {
+		fmt.Fprintln(stderr, "Usage: clipregex install [flags]")
+		fs.PrintDefaults()
+		return 2
+	}
+
+	steps, err := install.Install(install.Options{Shortcut: !*noShortcut, Autostart: *startWithSystem})
+	if err != nil {
+		fmt.Fprintf(stderr, "Install failed: %v\n", err)
+		return 1
+	}
+	return printSteps(steps, stdout, stderr)
+}
+
+// runUninstallCommand undoes install and the "Start with System" toggle, and
+// removes the secrets declared in the config from the OS keychain. It never
+// migrates a config, unlike the other commands.
+func runUninstallCommand(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("uninstall", stderr)
+	keepSecrets := fs.Bool("keep-secrets", false, "leave the secrets in the OS keychain")
+	purge := fs.Bool("purge", false, "also delete the config folder (config, statistics and backups)")
+	configPath := configFlag(fs)
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 0 {
+		fmt.Fprintln(stderr, "Usage: clipregex uninstall [flags]")
+		fs.PrintDefaults()
+		return 2
+	}
+
+	if *configPath == "" {
+		if dir, portable := config.PortableDir(false); portable {
+			*configPath = filepath.Join(dir, config.FileName)
+		} else if *configPath, err = config.DefaultConfigPath(); err != nil {
+			fmt.Fprintf(stderr, "Failed to locate config file: %v\n", err)
+			return 1
+		}
+	}
+
+	steps := install.Uninstall(install.UninstallOptions{
+		KeepSecrets: *keepSecrets,
+		Purge:       *purge,
+		ConfigPath:  *configPath,
+	})
+	return printSteps(steps, stdout, stderr)
+}
+
+func runImportCommand(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("import", stderr)
+	profileName := fs.String("profile", "", "name of the profile to create (default: \"<Source> Import\")")
+	hotkey := fs.String("hotkey", "", "hotkey for the new profile (default: "+importer.DefaultHotkey+")")
+	merge := fs.Bool("merge", false, "append the rules to an existing profile with the same name")
+	dryRun := fs.Bool("dry-run", false, "print the converted rules instead of saving them")
+	configPath := configFlag(fs)
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 2 {
+		fmt.Fprintln(stderr, "Usage: clipregex import <espanso|ahk> <path> [flags]")
+		fs.PrintDefaults()
+		return 2
+	}
+	source, path := strings.ToLower(positional[0]), positional[1]
+
+	result, err := importer.Import(source, path)
+	if err != nil {
+		fmt.Fprintf(stderr, "Import failed: %v\n", err)
+		return 1
+	}
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(stderr, "Warning: %s\n", warning)
+	}
+	fmt.Fprintf(stdout, "Converted %d rule(s), skipped %d unsupported entries.\n", len(result.Replacements), result.Skipped)
+	if len(result.Replacements) == 0 {
+		return 1
+	}
+
+	if *profileName == "" {
+		*profileName = map[string]string{
+			importer.SourceEspanso:    "Espanso Import",
+			importer.SourceAutoHotkey: "AutoHotkey Import",
+			"autohotkey":              "AutoHotkey Import",
+		}[source]
+	}
+
+	if *dryRun {
+		for _, rep := range result.Replacements {
+			fmt.Fprintf(stdout, "  %q -> %q (preserve_case: %t)\n", rep.Regex, rep.ReplaceWith, rep.PreserveCase)
+		}
+		return 0
+	}
+
+	if !resolveConfigFlag(configPath, stderr) {
+		return 1
+	}
+	cfg, err := config.LoadWithoutSecrets(*configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to load config: %v\n", err)
+		return 1
+	}
+
+	if existing := cfg.FindProfile(*profileName); existing >= 0 && *merge {
+		cfg.Profiles[existing].Replacements = append(cfg.Profiles[existing].Replacements, result.Replacements...)
+		fmt.Fprintf(stdout, "Appended rules to existing profile '%s'.\n", *profileName)
+	} else {
+		profile := importer.NewProfile(cfg.UniqueProfileName(*profileName), *hotkey, result)
+		cfg.Profiles = append(cfg.Profiles, profile)
+		fmt.Fprintf(stdout, "Added disabled profile '%s' (hotkey %s). Enable it from the tray menu after reviewing the rules.\n", profile.Name, profile.Hotkey)
+	}
+
+	if validationErrors := config.ValidateProfiles(cfg.Profiles); len(validationErrors) > 0 {
+		fmt.Fprintf(stderr, "Imported rules are invalid, config not saved:\n  - %s\n", strings.Join(validationErrors, "\n  - "))
+		return 1
+	}
+	if err := cfg.Save(); err != nil {
+		fmt.Fprintf(stderr, "Failed to save config: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Saved %s. Reload the configuration or restart the application to apply.\n", *configPath)
+	return 0
+}
+
+// runValidateCommand checks what loading the config checks, plus hotkeys and
+// secret declarations. Like lint, it exits with 1 if problems were found and
+// 2 on usage errors, so both can gate config changes in CI or git hooks.
+func runValidateCommand(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("validate", stderr)
+	configPath := configFlag(fs)
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 0 {
+		fmt.Fprintln(stderr, "Usage: clipregex validate [flags]")
+		fs.PrintDefaults()
+		return 2
+	}
+
+	if !resolveConfigFlag(configPath, stderr) {
+		return 1
+	}
+	cfg, err := config.LoadWithoutSecrets(*configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s is invalid: %v\n", *configPath, err)
+		return 1
+	}
+
+	problems := doctor.ConfigProblems(cfg)
+	rules := 0
+	for _, profile := range cfg.ResolvedProfiles() {
+		rules += len(profile.Replacements)
+	}
+
+	if len(problems) > 0 {
+		fmt.Fprintf(stderr, "%s is invalid:\n  - %s\n", *configPath, strings.Join(problems, "\n  - "))
+		return 1
+	}
+	fmt.Fprintf(stdout, "%s is valid (%d profile(s), %d rule(s)).\n", *configPath, len(cfg.Profiles), rules)
+	return 0
+}
+
+// runLintCommand reports rules that are probably mistakes. See package lint.
+func runLintCommand(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("lint", stderr)
+	ignore := fs.String("ignore", "", "comma-separated checks to skip (e.g. "+lint.CheckNestedQuantifier+")")
+	configPath := configFlag(fs)
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 0 {
+		fmt.Fprintln(stderr, "Usage: clipregex lint [flags]")
+		fs.PrintDefaults()
+		return 2
+	}
+
+	ignored := make(map[string]bool)
+	for _, check := range strings.Split(*ignore, ",") {
+		if check = strings.TrimSpace(check); check != "" {
+			ignored[check] = true
+		}
+	}
+
+	if !resolveConfigFlag(configPath, stderr) {
+		return 1
+	}
+	cfg, err := config.LoadWithoutSecrets(*configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s is invalid: %v\n", *configPath, err)
+		return 1
+	}
+
+	count := 0
+	for _, finding := range lint.Profiles(cfg.ResolvedProfiles()) {
+		if ignored[finding.Check] {
+			continue
+		}
+		fmt.Fprintln(stdout, finding)
+		count++
+	}
+	if count > 0 {
+		fmt.Fprintf(stdout, "%d finding(s) in %s.\n", count, *configPath)
+		return 1
+	}
+	fmt.Fprintf(stdout, "No findings in %s.\n", *configPath)
+	return 0
+}
+
+// runTestCommand runs the rules' test cases through the replacement engine.
+// Secrets are not read from the keyring, so tests of rules using secrets are
+// skipped; "Run Rule Tests" in the tray runs them with the loaded secrets.
+func runTestCommand(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("test", stderr)
+	profileName := fs.String("profile", "", "only test the rules of this profile")
+	verbose := fs.Bool("verbose", false, "also list passed and skipped tests")
+	configPath := configFlag(fs)
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 0 {
+		fmt.Fprintln(stderr, "Usage: clipregex test [flags]")
+		fs.PrintDefaults()
+		return 2
+	}
+
+	if !resolveConfigFlag(configPath, stderr) {
+		return 1
+	}
+	cfg, err := config.LoadWithoutSecrets(*configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s is invalid: %v\n", *configPath, err)
+		return 1
+	}
+
+	profiles := cfg.ResolvedProfiles()
+	if *profileName != "" {
+		index := cfg.FindProfile(*profileName)
+		if index < 0 {
+			fmt.Fprintf(stderr, "Profile '%s' not found in %s.\n", *profileName, *configPath)
+			return 1
+		}
+		profiles = profiles[index : index+1]
+	}
+
+	manager := clipboard.NewManager(cfg, nil, nil)
+	manager.LoadPlugins()
+	defer manager.ClosePlugins()
+	results := manager.RunRuleTests(profiles)
+	if len(results) == 0 {
+		fmt.Fprintf(stdout, "No rule tests found in %s.\n", *configPath)
+		return 0
+	}
+
+	passed, failed, skipped := 0, 0, 0
+	for _, result := range results {
+		switch {
+		case result.Passed():
+			passed++
+		case result.Skipped():
+			skipped++
+		default:
+			failed++
+		}
+		if *verbose || (!result.Passed() && !result.Skipped()) {
+			fmt.Fprintln(stdout, result)
+		}
+	}
+	fmt.Fprintf(stdout, "%d passed, %d failed, %d skipped (rules using secrets).\n", passed, failed, skipped)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/ipc"
+)
+
+// runTriggerCommand makes the running instance apply a profile to the
+// clipboard, as if its hotkey was pressed, e.g. from a window manager
+// keybinding on Wayland.
+func runTriggerCommand(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("trigger", stderr)
+	reverse := fs.Bool("reverse", false, "apply the reverse replacements, like the profile's reverse_hotkey")
+	configPath := configFlag(fs)
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 1 || positional[0] == "" {
+		fmt.Fprintln(stderr, "Usage: clipregex trigger <profile> [flags]")
+		fs.PrintDefaults()
+		return 2
+	}
+	if !resolveConfigFlag(configPath, stderr) {
+		return 1
+	}
+	return sendCommand(*configPath, ipc.Request{Command: ipc.CommandTrigger, Profile: positional[0], Reverse: *reverse}, stdout, stderr)
+}
+
+// controlCommand returns the run function of a command without arguments
+// that is passed to the running instance, such as "pause".
+func controlCommand(command string) func(args []string, stdout, stderr io.Writer) int {
+	return func(args []string, stdout, stderr io.Writer) int {
+		fs := newFlagSet(command, stderr)
+		configPath := configFlag(fs)
+
+		positional, err := parseInterspersed(fs, args)
+		if err != nil {
+			return 2
+		}
+		if len(positional) != 0 {
+			fmt.Fprintf(stderr, "Usage: clipregex %s [flags]\n", command)
+			fs.PrintDefaults()
+			return 2
+		}
+		if !resolveConfigFlag(configPath, stderr) {
+			return 1
+		}
+		return sendCommand(*configPath, ipc.Request{Command: command}, stdout, stderr)
+	}
+}
+
+// sendCommand passes req to the instance running with the config file at
+// configPath and prints its answer.
+func sendCommand(configPath string, req ipc.Request, stdout, stderr io.Writer) int {
+	resp, err := ipc.Send(ipc.SocketPath(configPath), req)
+	if errors.Is(err, ipc.ErrNotRunning) {
+		fmt.Fprintf(stderr, "Clipboard Regex Replace is not running with %s. Start it first.\n", configPath)
+		return 1
+	} else if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+	if !resp.OK {
+		fmt.Fprintln(stderr, resp.Message)
+		return 1
+	}
+	if resp.Message != "" {
+		fmt.Fprintln(stdout, resp.Message)
+	}
+	return 0
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/doctor"
+)
+
+// runDoctorCommand runs the diagnostics of the tray's "Run Diagnostics" and
+// prints a line per check. It exits with 1 if a check failed; warnings, such
+// as pasting only in X11 windows on Wayland, do not count.
+func runDoctorCommand(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("doctor", stderr)
+	configPath := configFlag(fs)
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 0 {
+		fmt.Fprintln(stderr, "Usage: clipregex doctor [flags]")
+		fs.PrintDefaults()
+		return 2
+	}
+	if !resolveConfigFlag(configPath, stderr) {
+		return 1
+	}
+
+	checks := doctor.Run(*configPath)
+	fmt.Fprintf(stdout, "Clipboard Regex Replace %s diagnostics\n\n", version)
+	for _, check := range checks {
+		fmt.Fprintln(stdout, check)
+	}
+	passed, failed := doctor.Summary(checks)
+	fmt.Fprintf(stdout, "\n%d of %d check(s) passed, %d failed.\n", passed, len(checks), failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+)
+
+// runLocaleCommand lists the available languages or writes a catalog
+// template for translators.
+func runLocaleCommand(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("locale", stderr)
+	configPath := configFlag(fs)
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	usage := func() int {
+		fmt.Fprintln(stderr, "Usage: clipregex locale list | template <language> [<file>|-] [--config FILE]")
+		fs.PrintDefaults()
+		return 2
+	}
+	if len(positional) == 0 {
+		return usage()
+	}
+
+	switch positional[0] {
+	case "list":
+		if len(positional) != 1 {
+			return usage()
+		}
+		if !resolveConfigFlag(configPath, stderr) {
+			return 1
+		}
+		configDir := filepath.Dir(*configPath)
+		fmt.Fprintf(stdout, "Available languages: %s\n", listOrNone(i18n.Available(configDir)))
+		fmt.Fprintf(stdout, "System language: %s\n", i18n.DetectLanguage())
+		fmt.Fprintf(stdout, "Custom catalogs: %s\n", filepath.Join(configDir, i18n.DirName))
+		return 0
+	case "template":
+		if len(positional) < 2 || len(positional) > 3 {
+			return usage()
+		}
+		template, err := i18n.Template(positional[1])
+		if err != nil {
+			fmt.Fprintf(stderr, "Template failed: %v\n", err)
+			return 1
+		}
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(template); err != nil {
+			fmt.Fprintf(stderr, "Template failed: %v\n", err)
+			return 1
+		}
+		if len(positional) == 2 || positional[2] == "-" {
+			stdout.Write(buf.Bytes())
+			return 0
+		}
+		path := positional[2]
+		if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			fmt.Fprintf(stderr, "Template failed: %v\n", err)
+			return 1
+		}
+		missing := 0
+		for _, translation := range template {
+			if translation == "" {
+				missing++
+			}
+		}
+		fmt.Fprintf(stdout, "Wrote %d message(s), %d untranslated, to %s.\n", len(template), missing, path)
+		return 0
+	default:
+		return usage()
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// logFileName is the log written in portable mode, next to the executable.
+const logFileName = "clipregex.log"
+
+// maxLogBytes is the size from which the log is moved to clipregex.log.old
+// at startup, so it cannot grow without bounds on a USB stick.
+const maxLogBytes = 5 * 1024 * 1024
+
+// startLogFile copies the log output to dir/clipregex.log in addition to
+// stderr. The returned file must be closed on exit.
+func startLogFile(dir string) (*os.File, error) {
+	path := filepath.Join(dir, logFileName)
+	if info, err := os.Stat(path); err == nil && info.Size() > maxLogBytes {
+		if err := os.Rename(path, path+".old"); err != nil {
+			log.Printf("Warning: Could not rotate log file '%s': %v", path, err)
+		}
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	log.SetOutput(io.MultiWriter(os.Stderr, f))
+	return f, nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -8,6 +9,8 @@ import (
 
 	"github.com/TanaroSch/clipboard-regex-replace/internal/app"
 	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/crash"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
 	"github.com/TanaroSch/clipboard-regex-replace/internal/ui"
 	"github.com/TanaroSch/clipboard-regex-replace/internal/resources"
 )
@@ -15,19 +18,56 @@ import (
 const version = "v1.8.0"
 
 func main() {
+	// Command line subcommands (import, ...) run without starting the tray
+	if handled, exitCode := runCommand(os.Args[1:]); handled {
+		os.Exit(exitCode)
+	}
+
 	// Configure logging maybe? (e.g., write to file)
 	// log.SetOutput(...)
 
+	// Flags of the tray application
+	fs := flag.NewFlagSet("clipregex", flag.ContinueOnError)
+	configFlag := fs.String("config", "", "path to config.json (default: the standard config directory)")
+	portableFlag := fs.Bool("portable", false, "keep config, statistics, backups and logs next to the executable")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		os.Exit(2)
+	}
+
+	// Portable mode (--portable or a "portable" file next to the executable)
+	// also writes the log next to the executable
+	if dir, portable := config.PortableDir(*portableFlag); portable {
+		if logFile, err := startLogFile(dir); err != nil {
+			log.Printf("Warning: Could not open log file in '%s': %v", dir, err)
+		} else {
+			defer logFile.Close()
+		}
+		log.Printf("Portable mode: using '%s' for config and data.", dir)
+	}
+
 	log.Printf("Clipboard Regex Replace %s starting...", version)
 
+	// Use --config, the portable folder or the standard config directory
+	// (migrating a config.json from the working directory that earlier
+	// versions used)
+	configPath, migratedFrom, err := config.ResolveConfigPath(*configFlag, *portableFlag)
+	if err != nil {
+		log.Printf("Warning: %v. Falling back to %s in the working directory.", err, config.FileName)
+		configPath = config.FileName
+	}
+	log.Printf("Using config file: %s", configPath)
+
 	// Attempt to create default config if needed BEFORE loading
-	if err := config.CreateDefaultConfig("config.json"); err != nil {
+	if err := config.CreateDefaultConfig(configPath); err != nil {
 		// Log warning, but continue trying to load, as it might exist anyway
 		log.Printf("Warning: Failed to create default config (it might already exist or dir is not writable): %v", err)
 	}
 
+	// The encrypted secrets file, used where no OS keyring is available, asks for its passphrase
+	config.SetSecretPassphrasePrompt(app.PromptSecretPassphrase)
+
 	// Load configuration (this now includes loading secrets from keyring)
-	cfg, err := config.Load("config.json")
+	cfg, err := config.Load(configPath)
 	if err != nil {
 		// Provide more context if it's a keyring issue maybe? Difficult to tell generically.
 		errMsg := fmt.Sprintf("FATAL: Error loading config/secrets: %v. Check config.json and OS keychain/credential manager access.", err)
@@ -46,6 +86,9 @@ func main() {
 		os.Exit(1)
 	}
 
+	// The tray menu, notifications and dialogs use the configured language
+	app.ApplyLanguage(cfg)
+
 	// Initialize Notifications fully AFTER config is loaded successfully
 	// Retrieve icon data (error handled within New)
 	appIcon, iconErr := resources.GetIcon() // Call the function from resources package
@@ -54,6 +97,10 @@ func main() {
 		// appIcon will be nil or empty, InitGlobalNotifications should handle this
 	}
 	ui.InitGlobalNotifications(cfg, config.DefaultKeyringService, appIcon)
+	if migratedFrom != "" {
+		ui.ShowAdminNotification(ui.LevelInfo, "Configuration Moved",
+			i18n.Tf("Copied %s to %s. Edit the new file from now on.", migratedFrom, configPath))
+	}
 
 	// Create and run the application
 	application := app.New(cfg, version)
@@ -68,9 +115,18 @@ func main() {
 			// Also print to stderr for console visibility
 			fmt.Fprintf(os.Stderr, "%s\n", errMsg)
 
-			// Attempt to show a UI notification about the crash
+			// Save a crash report next to the config and offer to report it.
 			// UI might not be fully running, but worth a try
-			ui.ShowAdminNotification(ui.LevelError, "Application Error", "A critical error occurred. Please check logs.") // <<< CHANGED
+			report := crash.NewReport(r, stackTrace, version, cfg)
+			reportPath, err := crash.Write(crash.Dir(configPath), report)
+			if err != nil {
+				log.Printf("Could not save crash report: %v", err)
+				ui.ShowAdminNotification(ui.LevelError, "Application Error", "A critical error occurred. Please check logs.")
+			} else {
+				log.Printf("Crash report saved to %s. Report it at: %s", reportPath, report.IssueURL())
+				ui.ShowAdminNotificationWithLink(ui.LevelError, "Application Error",
+					i18n.Tf("A critical error occurred. A crash report was saved to %s.", reportPath), "Report issue", report.IssueURL())
+			}
 
 			// Consider exiting with non-zero status
 			os.Exit(1)
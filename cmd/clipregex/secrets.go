@@ -0,0 +1,176 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"golang.org/x/term"
+)
+
+// readPassphrase reads a passphrase from the terminal without echoing it.
+// CLIPREGEX_SECRETS_PASSPHRASE is used instead if it is set, for scripts.
+func readPassphrase(prompt string, confirm bool, stderr io.Writer) (string, error) {
+	if pass := os.Getenv(config.SecretsPassphraseEnv); pass != "" {
+		return pass, nil
+	}
+	read := func(prompt string) (string, error) {
+		fmt.Fprint(stderr, prompt)
+		pass, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(stderr)
+		if err != nil {
+			return "", fmt.Errorf("cannot read the passphrase (set %s when not running in a terminal): %w", config.SecretsPassphraseEnv, err)
+		}
+		return string(pass), nil
+	}
+	pass, err := read(prompt)
+	if err != nil {
+		return "", err
+	}
+	if pass == "" {
+		return "", errors.New("the passphrase cannot be empty")
+	}
+	if confirm {
+		again, err := read("Repeat the passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != pass {
+			return "", errors.New("the passphrases do not match")
+		}
+	}
+	return pass, nil
+}
+
+// runSecretsCommand lists and audits secrets, and exports and imports them in
+// bulk, e.g. to move them to another computer or to add many at once from a
+// CSV file. audit exits with 1 if rules reference secrets that are not
+// declared or (with --resolve) do not load, so it can gate config changes.
+func runSecretsCommand(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("secrets", stderr)
+	values := fs.Bool("values", false, "export: include the values of the managed secrets, encrypted with a passphrase")
+	resolve := fs.Bool("resolve", false, "audit: also load the secret values to report the ones that fail to load")
+	configPath := configFlag(fs)
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	action := ""
+	if len(positional) > 0 {
+		action = positional[0]
+	}
+	switch {
+	case (action == "list" || action == "audit") && len(positional) == 1:
+	case (action == "export" || action == "import") && len(positional) == 2:
+	default:
+		fmt.Fprintln(stderr, "Usage: clipregex secrets list | audit [--resolve] | export <file> [--values] | import <file> [flags]")
+		fs.PrintDefaults()
+		return 2
+	}
+
+	if !resolveConfigFlag(configPath, stderr) {
+		return 1
+	}
+	cfg, err := config.LoadWithoutSecrets(*configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to load config: %v\n", err)
+		return 1
+	}
+	// The encrypted secrets file asks for its passphrase on the terminal
+	config.SetSecretPassphrasePrompt(func(create bool) (string, error) {
+		if create {
+			return readPassphrase("Choose a passphrase for the encrypted secrets file: ", true, stderr)
+		}
+		return readPassphrase("Passphrase of the encrypted secrets file: ", false, stderr)
+	})
+
+	switch action {
+	case "list":
+		names := cfg.GetSecretNames()
+		for _, name := range names {
+			fmt.Fprintf(stdout, "%s\t%s\n", name, cfg.SecretProviderName(name))
+		}
+		if len(names) == 0 {
+			fmt.Fprintln(stdout, "No secrets are declared in config.json.")
+		}
+		return 0
+
+	case "audit":
+		var resolved map[string]string
+		if *resolve {
+			resolved = cfg.ResolveSecrets()
+		}
+		audit := cfg.AuditSecrets(resolved)
+		fmt.Fprint(stdout, audit.Report(cfg))
+		if len(audit.Undeclared) > 0 || len(audit.Unresolved) > 0 {
+			return 1
+		}
+		return 0
+
+	case "export":
+		path := positional[1]
+		passphrase := ""
+		if *values {
+			if passphrase, err = readPassphrase("Passphrase for the exported values: ", true, stderr); err != nil {
+				fmt.Fprintf(stderr, "Export failed: %v\n", err)
+				return 1
+			}
+		}
+		data, problems, err := cfg.ExportSecrets(passphrase)
+		if err == nil {
+			err = os.WriteFile(path, data, 0600)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "Export failed: %v\n", err)
+			return 1
+		}
+		for _, problem := range problems {
+			fmt.Fprintf(stderr, "Warning: value not exported: %s\n", problem)
+		}
+		fmt.Fprintf(stdout, "Exported %d secret(s) to %s (values included: %t).\n", len(cfg.Secrets), path, *values)
+		if len(problems) > 0 {
+			return 1
+		}
+		return 0
+
+	default: // import
+		path := positional[1]
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "Import failed: %v\n", err)
+			return 1
+		}
+		passphrase := ""
+		if config.SecretsImportEncrypted(data) {
+			if passphrase, err = readPassphrase("Passphrase of the exported values: ", false, stderr); err != nil {
+				fmt.Fprintf(stderr, "Import failed: %v\n", err)
+				return 1
+			}
+		}
+		entries, err := config.ParseSecretsImport(data, path, passphrase)
+		if err != nil {
+			fmt.Fprintf(stderr, "Import failed: %v\n", err)
+			return 1
+		}
+		result, err := cfg.ImportSecrets(entries)
+		for _, problem := range result.Problems {
+			fmt.Fprintf(stderr, "Skipped: %s\n", problem)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "Failed to save config: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Imported %d of %d secret(s): %s\n", len(result.Imported), len(entries), strings.Join(result.Imported, ", "))
+		if len(result.Imported) > 0 {
+			fmt.Fprintln(stdout, "Run 'clipregex reload' to load them into the running application.")
+		}
+		if len(result.Problems) > 0 {
+			return 1
+		}
+		return 0
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/stats"
+)
+
+// runStatsCommand exports the rule and profile statistics of the current
+// month, or of an archived one, as CSV or JSON.
+func runStatsCommand(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("stats", stderr)
+	format := fs.String("format", "", "csv or json (default: from the file extension, json on stdout)")
+	month := fs.String("month", "", "export the archived statistics of a month (YYYY-MM) instead of the current ones")
+	configPath := configFlag(fs)
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) < 1 || len(positional) > 2 || positional[0] != "export" {
+		fmt.Fprintln(stderr, "Usage: clipregex stats export [<file>|-] [--format csv|json] [--month YYYY-MM] [--config FILE]")
+		fs.PrintDefaults()
+		return 2
+	}
+	path := "-"
+	if len(positional) == 2 {
+		path = positional[1]
+	}
+	if *format == "" {
+		*format = stats.FormatJSON
+		if path != "-" {
+			*format = stats.FormatForPath(path)
+		}
+	}
+	*format = strings.ToLower(*format)
+	if *format != stats.FormatCSV && *format != stats.FormatJSON {
+		fmt.Fprintf(stderr, "Invalid --format '%s' (must be %s or %s).\n", *format, stats.FormatCSV, stats.FormatJSON)
+		return 2
+	}
+
+	if !resolveConfigFlag(configPath, stderr) {
+		return 1
+	}
+	statsPath := stats.PathForConfig(*configPath)
+	if *month != "" {
+		t, err := stats.ParseMonth(*month)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		statsPath = stats.ArchivePath(statsPath, t)
+		if _, err := os.Stat(statsPath); os.IsNotExist(err) {
+			months, _ := stats.ListArchives(stats.PathForConfig(*configPath))
+			fmt.Fprintf(stderr, "No statistics are archived for %s. Archived months: %s\n", *month, listOrNone(months))
+			return 1
+		}
+	}
+	// Read only: the running application rolls the counters over itself
+	store, err := stats.Load(statsPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Export failed: %v\n", err)
+		return 1
+	}
+	snapshot := store.Snapshot()
+
+	var buf bytes.Buffer
+	if err := snapshot.Export(&buf, *format); err != nil {
+		fmt.Fprintf(stderr, "Export failed: %v\n", err)
+		return 1
+	}
+	if path == "-" {
+		stdout.Write(buf.Bytes())
+		return 0
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		fmt.Fprintf(stderr, "Export failed: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Exported the statistics of %d profile(s) and %d rule(s) to %s.\n", len(snapshot.Profiles), len(snapshot.Rules), path)
+	return 0
+}
+
+// listOrNone joins items, or returns "none" for an empty list.
+func listOrNone(items []string) string {
+	if len(items) == 0 {
+		return "none"
+	}
+	return strings.Join(items, ", ")
+}
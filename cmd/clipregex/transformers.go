@@ -0,0 +1,14 @@
+package main
+
+// Custom transformers are compiled in by registering them here. Any type with
+// the methods of clipboard.Transformer is one, so its package doesn't have to
+// import this module, e.g.
+//
+//	import "example.com/acme/tokenizer"
+//
+//	func init() {
+//		clipboard.RegisterTransformer(tokenizer.New())
+//	}
+//
+// Rules then run it with "transformer": "<name>". See
+// docs/FEATURES.md#custom-transformers.
@@ -4,13 +4,17 @@ go 1.23.6
 
 require (
 	github.com/99designs/keyring v1.2.2
-	github.com/atotto/clipboard v0.1.4
+	github.com/dvsekhvalnov/jose2go v1.5.0
 	github.com/gen2brain/beeep v0.0.0-20240516210008-9c006672e7f4
 	github.com/getlantern/systray v1.2.2
 	github.com/go-toast/toast v0.0.0-20190211030409-01e6764cf0a4
 	github.com/ncruces/zenity v0.10.14
 	github.com/sergi/go-diff v1.3.1
+	github.com/yuin/gopher-lua v1.1.2
 	golang.design/x/hotkey v0.4.1
+	golang.org/x/term v0.3.0
+	golang.org/x/text v0.18.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -18,7 +22,6 @@ require (
 	github.com/akavel/rsrc v0.10.2 // indirect
 	github.com/danieljoos/wincred v1.1.2 // indirect
 	github.com/dchest/jsmin v0.0.0-20220218165748-59f39799265f // indirect
-	github.com/dvsekhvalnov/jose2go v1.5.0 // indirect
 	github.com/getlantern/context v0.0.0-20190109183933-c447772a6520 // indirect
 	github.com/getlantern/errors v0.0.0-20190325191628-abdb3e3e36f7 // indirect
 	github.com/getlantern/golog v0.0.0-20190830074920-4ef2e798c2d7 // indirect
@@ -37,5 +40,4 @@ require (
 	github.com/tadvi/systray v0.0.0-20190226123456-11a2b8fa57af // indirect
 	golang.org/x/image v0.20.0 // indirect
 	golang.org/x/sys v0.25.0 // indirect
-	golang.org/x/term v0.3.0 // indirect
 )
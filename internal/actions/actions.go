@@ -0,0 +1,259 @@
+// Package actions implements the built-in, non-regex transformations that a
+// rule can run with "action", such as trimming whitespace, sorting lines or
+// converting identifiers between naming styles.
+package actions
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Action transforms text. It returns an error if the text is not valid input
+// for the action.
+type Action func(text string) (string, error)
+
+// registry maps action names to their implementation.
+var registry = map[string]Action{
+	"trim":                 plain(strings.TrimSpace),
+	"trim_lines":           eachLine(strings.TrimSpace),
+	"trim_trailing":        eachLine(func(line string) string { return strings.TrimRightFunc(line, unicode.IsSpace) }),
+	"collapse_blank_lines": lines(collapseBlankLines),
+	"remove_blank_lines":   lines(removeBlankLines),
+	"sort_lines":           lines(sortLines),
+	"sort_lines_desc":      lines(sortLinesDesc),
+	"unique_lines":         lines(uniqueLines),
+	"reverse_lines":        lines(reverseLines),
+	"tabs_to_spaces":       eachLine(expandTabs),
+}
+
+func init() {
+	maps.Copy(registry, caseActions(Casing{})) // Unicode's default case mappings
+}
+
+// TabWidth is the distance between tab stops used by "tabs_to_spaces".
+const TabWidth = 4
+
+// Lookup returns the action registered under name.
+func Lookup(name string) (Action, bool) {
+	action, ok := registry[name]
+	return action, ok
+}
+
+// Names returns the names of all actions in alphabetical order.
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Apply runs the action called name on text.
+func Apply(name, text string) (string, error) {
+	action, ok := Lookup(name)
+	if !ok {
+		return text, fmt.Errorf("unknown action '%s'", name)
+	}
+	return action(text)
+}
+
+// plain wraps a transformation that cannot fail.
+func plain(transform func(string) string) Action {
+	return func(text string) (string, error) {
+		return transform(text), nil
+	}
+}
+
+// lines wraps a transformation of the list of lines. Windows line breaks and a
+// trailing line break are kept.
+func lines(transform func([]string) []string) Action {
+	return func(text string) (string, error) {
+		lineBreak := "\n"
+		if strings.Contains(text, "\r\n") {
+			lineBreak = "\r\n"
+		}
+		body := strings.TrimSuffix(text, lineBreak)
+		trailing := text[len(body):]
+		if body == "" {
+			return text, nil
+		}
+
+		result := transform(strings.Split(body, lineBreak))
+		return strings.Join(result, lineBreak) + trailing, nil
+	}
+}
+
+// eachLine wraps a transformation applied to every line on its own.
+func eachLine(transform func(string) string) Action {
+	return lines(func(in []string) []string {
+		out := make([]string, len(in))
+		for i, line := range in {
+			out[i] = transform(line)
+		}
+		return out
+	})
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// collapseBlankLines replaces runs of blank lines with a single empty line.
+func collapseBlankLines(in []string) []string {
+	var out []string
+	for i, line := range in {
+		if isBlank(line) {
+			if i > 0 && isBlank(in[i-1]) {
+				continue
+			}
+			line = ""
+		}
+		out = append(out, line)
+	}
+	return out
+}
+
+func removeBlankLines(in []string) []string {
+	var out []string
+	for _, line := range in {
+		if !isBlank(line) {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+func sortLines(in []string) []string {
+	out := append([]string(nil), in...)
+	sort.Strings(out)
+	return out
+}
+
+func sortLinesDesc(in []string) []string {
+	out := append([]string(nil), in...)
+	sort.Sort(sort.Reverse(sort.StringSlice(out)))
+	return out
+}
+
+// uniqueLines removes repeated lines, keeping the first occurrence in place.
+func uniqueLines(in []string) []string {
+	seen := make(map[string]bool, len(in))
+	var out []string
+	for _, line := range in {
+		if !seen[line] {
+			seen[line] = true
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+func reverseLines(in []string) []string {
+	out := make([]string, len(in))
+	for i, line := range in {
+		out[len(in)-1-i] = line
+	}
+	return out
+}
+
+// expandTabs replaces tabs with spaces up to the next tab stop.
+func expandTabs(line string) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+	var b strings.Builder
+	column := 0
+	for _, r := range line {
+		if r == '\t' {
+			spaces := TabWidth - column%TabWidth
+			b.WriteString(strings.Repeat(" ", spaces))
+			column += spaces
+			continue
+		}
+		b.WriteRune(r)
+		column++
+	}
+	return b.String()
+}
+
+// titleCase upper-cases the first letter of every word and lower-cases the rest.
+func titleCase(text string, c Casing) string {
+	var b strings.Builder
+	inWord := false
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'' && inWord {
+			if inWord {
+				b.WriteString(c.Lower(string(r)))
+			} else {
+				b.WriteString(c.Upper(string(r)))
+			}
+			inWord = true
+			continue
+		}
+		b.WriteRune(r)
+		inWord = false
+	}
+	return b.String()
+}
+
+// splitWords splits an identifier or phrase into words at separators and at
+// case changes: "parseHTTPResponse_code" yields parse, HTTP, Response, code.
+// Leading and trailing whitespace is returned separately so it can be kept.
+func splitWords(text string) (lead string, words []string, tail string) {
+	trimmed := strings.TrimLeftFunc(text, unicode.IsSpace)
+	lead = text[:len(text)-len(trimmed)]
+	body := strings.TrimRightFunc(trimmed, unicode.IsSpace)
+	tail = trimmed[len(body):]
+
+	runes := []rune(body)
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if len(word) > 0 && unicode.IsUpper(r) {
+			prev := word[len(word)-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush() // "fooBar" and the "R" in "HTTPResponse"
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+	return lead, words, tail
+}
+
+// joinWords converts the words of text with transform and joins them with sep.
+func joinWords(text, sep string, transform func(string) string) string {
+	lead, words, tail := splitWords(text)
+	for i, word := range words {
+		words[i] = transform(word)
+	}
+	return lead + strings.Join(words, sep) + tail
+}
+
+// camelCase joins the words of text as camelCase, or PascalCase if upperFirst.
+func camelCase(text string, upperFirst bool, c Casing) string {
+	lead, words, tail := splitWords(text)
+	var b strings.Builder
+	for i, word := range words {
+		word = c.Lower(word)
+		if i > 0 || upperFirst {
+			word = c.TitleWord(word)
+		}
+		b.WriteString(word)
+	}
+	return lead + b.String() + tail
+}
@@ -0,0 +1,203 @@
+package actions
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+func init() {
+	registry["strip_ansi"] = plain(StripANSI)
+}
+
+const (
+	esc = 0x1B
+	bel = 0x07
+)
+
+// StripANSI removes the escape sequences of terminal output: colors and
+// styles, cursor movement, window titles and hyperlinks (whose text is
+// kept), and other control characters. Carriage returns and backspaces
+// overwrite the line like in the terminal, so a progress bar redrawn in
+// place leaves only its final state, and erasing a line with ESC[K is
+// honored. Tabs and line breaks, including CRLF, are kept.
+func StripANSI(text string) string {
+	if !strings.ContainsFunc(text, func(r rune) bool { return r < ' ' && r != '\t' && r != '\n' || r >= 0x7F && r <= 0x9F }) {
+		return text
+	}
+
+	var out strings.Builder
+	out.Grow(len(text))
+	var line terminalLine
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case r == esc:
+			i += escapeLength(text[i:], &line)
+			continue
+		case r == 0x9B: // CSI as a single character
+			i += csiLength(text[i+size:], &line) + size
+			continue
+		case r == 0x9D: // OSC as a single character
+			i += stringLength(text[i+size:]) + size
+			continue
+		case r == '\n':
+			out.WriteString(line.String())
+			out.WriteByte('\n')
+			line.reset()
+		case r == '\r' && strings.HasPrefix(text[i+size:], "\n"):
+			out.WriteString(line.String())
+			out.WriteString("\r\n")
+			line.reset()
+			size++
+		case r == '\r':
+			line.col = 0
+		case r == '\b':
+			line.col = max(line.col-1, 0)
+		case r == '\t' || r >= ' ' && (r < 0x7F || r > 0x9F):
+			line.put(r)
+		}
+		i += size // Other control characters are dropped
+	}
+	out.WriteString(line.String())
+	return out.String()
+}
+
+// maxTerminalColumn is the farthest a control sequence moves the cursor
+// beyond the end of the line, so ESC[999999999C can't make put pad the line
+// with a billion spaces.
+const maxTerminalColumn = 1024
+
+// terminalLine is the line being written, with the cursor column.
+type terminalLine struct {
+	cells []rune
+	col   int
+}
+
+// moveTo puts the cursor at col, limited to the start of the line and to
+// maxTerminalColumn past the text.
+func (l *terminalLine) moveTo(col int) {
+	l.col = min(max(col, 0), len(l.cells)+maxTerminalColumn)
+}
+
+// put writes r at the cursor, overwriting what is there.
+func (l *terminalLine) put(r rune) {
+	for len(l.cells) < l.col {
+		l.cells = append(l.cells, ' ')
+	}
+	if l.col < len(l.cells) {
+		l.cells[l.col] = r
+	} else {
+		l.cells = append(l.cells, r)
+	}
+	l.col++
+}
+
+// erase clears the line from the cursor to the end (mode 0), from the start
+// to the cursor (mode 1) or entirely (mode 2), like ESC[<mode>K.
+func (l *terminalLine) erase(mode int) {
+	switch mode {
+	case 0:
+		if l.col < len(l.cells) {
+			l.cells = l.cells[:l.col]
+		}
+	case 1:
+		for i := 0; i <= l.col && i < len(l.cells); i++ {
+			l.cells[i] = ' '
+		}
+	case 2:
+		l.cells = l.cells[:0]
+	}
+}
+
+func (l *terminalLine) reset() {
+	l.cells, l.col = l.cells[:0], 0
+}
+
+func (l *terminalLine) String() string {
+	return string(l.cells)
+}
+
+// escapeLength returns the length of the escape sequence at the start of s,
+// which starts with ESC, and applies it to line if it erases or moves within
+// the line.
+func escapeLength(s string, line *terminalLine) int {
+	if len(s) < 2 {
+		return len(s)
+	}
+	switch s[1] {
+	case '[':
+		return 2 + csiLength(s[2:], line)
+	case ']', 'P', 'X', '^', '_': // OSC, DCS, SOS, PM and APC strings
+		return 2 + stringLength(s[2:])
+	}
+	// Other sequences: intermediate bytes, then one final byte
+	n := 1
+	for n < len(s) && s[n] >= 0x20 && s[n] <= 0x2F {
+		n++
+	}
+	if n < len(s) && s[n] >= 0x30 && s[n] <= 0x7E {
+		n++
+	}
+	return n
+}
+
+// csiLength returns the length of the parameters and final byte of a control
+// sequence, s starting after the introducer, and applies the sequence to line
+// if it erases or moves within the line.
+func csiLength(s string, line *terminalLine) int {
+	n := 0
+	for n < len(s) && s[n] >= 0x30 && s[n] <= 0x3F { // Parameter bytes
+		n++
+	}
+	params := s[:n]
+	for n < len(s) && s[n] >= 0x20 && s[n] <= 0x2F { // Intermediate bytes
+		n++
+	}
+	if n >= len(s) || s[n] < 0x40 || s[n] > 0x7E {
+		return n // Not terminated; what was read is dropped
+	}
+	applyCSI(line, s[n], params)
+	return n + 1
+}
+
+// applyCSI applies the control sequences that change the line's text or
+// cursor column: erase in line (K), cursor forward (C), back (D) and to a
+// column (G).
+func applyCSI(line *terminalLine, final byte, params string) {
+	arg := func(fallback int) int {
+		first, _, _ := strings.Cut(params, ";")
+		n, err := strconv.Atoi(first)
+		if err != nil {
+			return fallback
+		}
+		return n
+	}
+	switch final {
+	case 'K':
+		line.erase(arg(0))
+	case 'C':
+		line.moveTo(line.col + min(max(arg(1), 1), maxTerminalColumn))
+	case 'D':
+		line.moveTo(line.col - max(arg(1), 1))
+	case 'G':
+		line.moveTo(max(arg(1), 1) - 1)
+	}
+}
+
+// stringLength returns the length of a control string such as an OSC title
+// or hyperlink, s starting after the introducer, up to and including its
+// terminator: BEL, ESC \ or ST. An unterminated string runs to the end.
+func stringLength(s string) int {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == bel:
+			return i + 1
+		case s[i] == esc && i+1 < len(s) && s[i+1] == '\\':
+			return i + 2
+		case s[i] == 0xC2 && i+1 < len(s) && s[i+1] == 0x9C: // ST as a single character
+			return i + 2
+		}
+	}
+	return len(s)
+}
@@ -0,0 +1,54 @@
+package actions
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStripANSI(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain text", "hello\tworld\n", "hello\tworld\n"},
+		{"SGR colors", "\x1b[1;31mERROR\x1b[0m: \x1b[38;5;208mdisk\x1b[m full", "ERROR: disk full"},
+		{"8-bit CSI", "\u009b32mok\u009b0m", "ok"},
+		{"OSC 8 hyperlink with BEL", "see \x1b]8;;https://example.com\x07the docs\x1b]8;;\x07.", "see the docs."},
+		{"OSC 8 hyperlink with ST", "\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\", "link"},
+		{"window title", "\x1b]0;build\x07done", "done"},
+		{"progress bar redrawn with CR", "10%\r50%\r100%\n", "100%\n"},
+		{"CRLF kept", "a\r\nb\r\n", "a\r\nb\r\n"},
+		{"backspace overstrike", "_\bX_\bY", "XY"},
+		{"erase line", "downloading...\r\x1b[2Kdone", "done"},
+		{"erase to end of line", "abcdef\r\x1b[3C\x1b[K", "abc"},
+		{"cursor back", "abc\x1b[2Dx", "axc"},
+		{"cursor to column", "abc\x1b[1Gx", "xbc"},
+		{"unterminated CSI", "text\x1b[31", "text"},
+		{"unterminated OSC", "text\x1b]0;title", "text"},
+		{"lone ESC at the end", "text\x1b", "text"},
+		{"other control characters", "a\x00b\x07c\u0085d", "abcd"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StripANSI(tt.in); got != tt.want {
+				t.Errorf("StripANSI(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripANSIHugeCursorMoves(t *testing.T) {
+	for _, in := range []string{
+		"a\x1b[999999999Cb",
+		"a\x1b[999999999Gb",
+		"a\x1b[99999999999999999999Cb", // Overflows int, so the default of 1 applies
+		"a" + strings.Repeat("\x1b[999999999C", 1000) + "b",
+		"a\x1b[999999999Db",
+	} {
+		got := StripANSI(in)
+		if len(got) > 2+maxTerminalColumn || !strings.HasPrefix(got, "a") && !strings.HasPrefix(got, "b") || !strings.HasSuffix(got, "b") {
+			t.Errorf("StripANSI(%q) returned %d bytes, want at most %d ending in b", in[:min(len(in), 40)], len(got), 2+maxTerminalColumn)
+		}
+	}
+}
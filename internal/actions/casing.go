@@ -0,0 +1,74 @@
+package actions
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// Casing converts between upper and lower case, with Unicode's default
+// mappings or, if it has a locale, with the rules of that language: Turkish
+// and Azeri map "i" to "İ" and "I" to "ı", and German upper-cases "ß" as "SS".
+// The zero Casing uses the defaults.
+type Casing struct {
+	locale *language.Tag
+}
+
+// NewCasing returns the casing of a BCP 47 language tag such as "tr" or
+// "de-AT", or the default casing if locale is empty.
+func NewCasing(locale string) (Casing, error) {
+	if locale == "" {
+		return Casing{}, nil
+	}
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return Casing{}, fmt.Errorf("invalid locale '%s': %w", locale, err)
+	}
+	return Casing{locale: &tag}, nil
+}
+
+// Upper returns text in upper case.
+func (c Casing) Upper(text string) string {
+	if c.locale == nil {
+		return strings.ToUpper(text)
+	}
+	return cases.Upper(*c.locale).String(text) // A new Caser each time; they can't be shared between goroutines
+}
+
+// Lower returns text in lower case.
+func (c Casing) Lower(text string) string {
+	if c.locale == nil {
+		return strings.ToLower(text)
+	}
+	return cases.Lower(*c.locale).String(text)
+}
+
+// TitleWord upper-cases the first letter of word and leaves the rest alone.
+func (c Casing) TitleWord(word string) string {
+	_, size := utf8.DecodeRuneInString(word)
+	return c.Upper(word[:size]) + word[size:]
+}
+
+// caseActions returns the actions converting case or naming style with c.
+func caseActions(c Casing) map[string]Action {
+	return map[string]Action{
+		"upper":    plain(c.Upper),
+		"lower":    plain(c.Lower),
+		"title":    plain(func(text string) string { return titleCase(text, c) }),
+		"snake":    eachLine(func(line string) string { return joinWords(line, "_", c.Lower) }),
+		"kebab":    eachLine(func(line string) string { return joinWords(line, "-", c.Lower) }),
+		"constant": eachLine(func(line string) string { return joinWords(line, "_", c.Upper) }),
+		"camel":    eachLine(func(line string) string { return camelCase(line, false, c) }),
+		"pascal":   eachLine(func(line string) string { return camelCase(line, true, c) }),
+	}
+}
+
+// CaseAction returns the case or naming-style action called name converting
+// case with c, or false if name is not such an action.
+func CaseAction(name string, c Casing) (Action, bool) {
+	action, ok := caseActions(c)[name]
+	return action, ok
+}
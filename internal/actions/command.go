@@ -0,0 +1,86 @@
+package actions
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// commandStderrLimit is how much of a failing command's stderr is reported.
+const commandStderrLimit = 500
+
+// errOutputLimit stops a command whose output exceeds its limit.
+var errOutputLimit = errors.New("output limit exceeded")
+
+// limitedBuffer keeps at most max bytes. Further output is dropped; if stop
+// is set, the write fails instead so the program gets a broken pipe. The
+// buffer is not embedded, so io.Copy cannot bypass Write with ReadFrom.
+type limitedBuffer struct {
+	buf      bytes.Buffer
+	max      int
+	stop     bool
+	exceeded bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.buf.Len(); len(p) > room {
+		b.buf.Write(p[:room])
+		b.exceeded = true
+		if b.stop {
+			return room, errOutputLimit
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
+
+// Command returns an action that pipes text through an external program: args
+// are the executable and its arguments, the text is written to its stdin and
+// its stdout becomes the result. The program runs in dir and is killed after
+// timeout or once its output exceeds maxOutput bytes. A trailing line break
+// added by the program is removed if the input had none.
+func Command(args []string, dir string, timeout time.Duration, maxOutput int) Action {
+	return func(text string) (string, error) {
+		if len(args) == 0 {
+			return text, errors.New("no command configured")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Dir = dir
+		cmd.Stdin = strings.NewReader(text)
+		stdout := &limitedBuffer{max: maxOutput, stop: true}
+		stderr := &limitedBuffer{max: commandStderrLimit}
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		hideCommandWindow(cmd)
+
+		err := cmd.Run()
+		switch {
+		case stdout.exceeded:
+			return text, fmt.Errorf("more than %d bytes of output", maxOutput)
+		case ctx.Err() == context.DeadlineExceeded:
+			return text, fmt.Errorf("timed out after %v", timeout)
+		case err != nil:
+			if message := strings.TrimSpace(stderr.String()); message != "" {
+				return text, fmt.Errorf("%v: %s", err, message)
+			}
+			return text, err
+		}
+
+		result := stdout.String()
+		if !strings.HasSuffix(text, "\n") {
+			result = strings.TrimSuffix(strings.TrimSuffix(result, "\n"), "\r")
+		}
+		return result, nil
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !windows
+// +build !windows
+
+package actions
+
+import "os/exec"
+
+func hideCommandWindow(cmd *exec.Cmd) {}
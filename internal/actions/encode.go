@@ -0,0 +1,112 @@
+package actions
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"html"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+func init() {
+	registry["base64_encode"] = plain(func(text string) string { return base64.StdEncoding.EncodeToString([]byte(text)) })
+	registry["base64_decode"] = base64Decode
+	registry["base64url_encode"] = plain(func(text string) string { return base64.RawURLEncoding.EncodeToString([]byte(text)) })
+	registry["base64url_decode"] = base64Decode
+	registry["url_encode"] = plain(url.QueryEscape)
+	registry["url_decode"] = urlDecode
+	registry["html_escape"] = plain(html.EscapeString)
+	registry["html_unescape"] = plain(html.UnescapeString)
+	registry["hex_encode"] = plain(func(text string) string { return hex.EncodeToString([]byte(text)) })
+	registry["hex_decode"] = hexDecode
+	registry["jwt_decode"] = jwtDecode
+}
+
+// removeSpace drops all whitespace, e.g. line breaks in wrapped Base64.
+func removeSpace(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// decodeBase64 accepts the standard and the URL-safe alphabet, with or
+// without padding.
+func decodeBase64(text string) ([]byte, error) {
+	text = strings.TrimRight(removeSpace(text), "=")
+	if strings.ContainsAny(text, "-_") {
+		return base64.RawURLEncoding.DecodeString(text)
+	}
+	return base64.RawStdEncoding.DecodeString(text)
+}
+
+// decodedText checks that decoded bytes are text that can go to the clipboard.
+func decodedText(format string, data []byte) (string, error) {
+	if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+		return "", &InputError{Format: format, Err: errors.New("the decoded data is binary, not text")}
+	}
+	return string(data), nil
+}
+
+func base64Decode(text string) (string, error) {
+	data, err := decodeBase64(text)
+	if err != nil {
+		return text, &InputError{Format: "Base64", Err: err}
+	}
+	decoded, err := decodedText("Base64", data)
+	if err != nil {
+		return text, err
+	}
+	return decoded, nil
+}
+
+func urlDecode(text string) (string, error) {
+	decoded, err := url.QueryUnescape(text)
+	if err != nil {
+		return text, &InputError{Format: "URL-encoded text", Err: err}
+	}
+	return decoded, nil
+}
+
+func hexDecode(text string) (string, error) {
+	digits := removeSpace(text)
+	digits = strings.TrimPrefix(strings.TrimPrefix(digits, "0x"), "0X")
+	data, err := hex.DecodeString(digits)
+	if err != nil {
+		return text, &InputError{Format: "hex", Err: err}
+	}
+	decoded, err := decodedText("hex", data)
+	if err != nil {
+		return text, err
+	}
+	return decoded, nil
+}
+
+// jwtDecode shows the header and payload of a JSON Web Token as indented JSON.
+// The signature is not verified.
+func jwtDecode(text string) (string, error) {
+	parts := strings.Split(removeSpace(text), ".")
+	if len(parts) != 3 {
+		return text, &InputError{Format: "JWT", Err: errors.New("expected three dot-separated parts")}
+	}
+	var out []string
+	for i, name := range []string{"header", "payload"} {
+		data, err := decodeBase64(parts[i])
+		if err != nil {
+			return text, &InputError{Format: "JWT", Err: errors.New("the " + name + " is not Base64: " + err.Error())}
+		}
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, data, "", FormatIndent); err != nil {
+			return text, &InputError{Format: "JWT", Err: errors.New("the " + name + " is not JSON: " + err.Error())}
+		}
+		out = append(out, buf.String())
+	}
+	return strings.Join(out, "\n"), nil
+}
@@ -0,0 +1,456 @@
+package actions
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// InputError reports text an action cannot process, such as invalid JSON.
+// The text is left unchanged.
+type InputError struct {
+	Format string // "JSON", "XML" or "SQL"
+	Err    error
+}
+
+func (e *InputError) Error() string {
+	return fmt.Sprintf("not valid %s: %v", e.Format, e.Err)
+}
+
+func (e *InputError) Unwrap() error {
+	return e.Err
+}
+
+// FormatIndent is the indentation used by the pretty-print actions.
+const FormatIndent = "  "
+
+func init() {
+	registry["json_pretty"] = jsonPretty
+	registry["json_minify"] = jsonMinify
+	registry["xml_pretty"] = func(text string) (string, error) { return formatXML(text, true) }
+	registry["xml_minify"] = func(text string) (string, error) { return formatXML(text, false) }
+	registry["sql_pretty"] = sqlPretty
+	registry["sql_minify"] = sqlMinify
+}
+
+// --- JSON ---
+
+func jsonPretty(text string) (string, error) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(strings.TrimSpace(text)), "", FormatIndent); err != nil {
+		return text, jsonError(text, err)
+	}
+	return buf.String(), nil
+}
+
+func jsonMinify(text string) (string, error) {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(strings.TrimSpace(text))); err != nil {
+		return text, jsonError(text, err)
+	}
+	return buf.String(), nil
+}
+
+// jsonError adds the line and column to a JSON syntax error.
+func jsonError(text string, err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		trimmed := strings.TrimLeft(text, " \t\r\n")
+		offset := int(syntaxErr.Offset) + len(text) - len(trimmed)
+		if offset > len(text) {
+			offset = len(text)
+		}
+		line := strings.Count(text[:offset], "\n") + 1
+		column := offset - strings.LastIndex(text[:offset], "\n")
+		err = fmt.Errorf("line %d, column %d: %v", line, column, err)
+	}
+	return &InputError{Format: "JSON", Err: err}
+}
+
+// --- XML ---
+
+// formatXML re-indents XML (or removes the whitespace between elements if not
+// pretty). Prefixes, comments, processing instructions and text are kept.
+func formatXML(text string, pretty bool) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return text, &InputError{Format: "XML", Err: errors.New("the text is empty")}
+	}
+
+	// Token checks well-formedness; RawToken keeps prefixes as written
+	checker := xml.NewDecoder(strings.NewReader(text))
+	for {
+		if _, err := checker.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			return text, &InputError{Format: "XML", Err: err}
+		}
+	}
+
+	var tokens []xml.Token
+	decoder := xml.NewDecoder(strings.NewReader(text))
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return text, &InputError{Format: "XML", Err: err}
+		}
+		if data, ok := token.(xml.CharData); ok && len(bytes.TrimSpace(data)) == 0 {
+			continue // Indentation between elements
+		}
+		tokens = append(tokens, xml.CopyToken(token))
+	}
+
+	var b strings.Builder
+	depth := 0
+	newline := func() {
+		if pretty && b.Len() > 0 {
+			b.WriteString("\n" + strings.Repeat(FormatIndent, depth))
+		}
+	}
+	for i := 0; i < len(tokens); i++ {
+		switch token := tokens[i].(type) {
+		case xml.StartElement:
+			newline()
+			writeXMLStart(&b, token)
+			// Keep elements with nothing but text on one line
+			if i+1 < len(tokens) {
+				if _, ok := tokens[i+1].(xml.EndElement); ok {
+					b.WriteString("/>")
+					i++
+					continue
+				}
+			}
+			if i+2 < len(tokens) {
+				data, isText := tokens[i+1].(xml.CharData)
+				_, isEnd := tokens[i+2].(xml.EndElement)
+				if isText && isEnd {
+					b.WriteString(">")
+					xml.EscapeText(&b, xmlText(data, pretty))
+					b.WriteString("</" + xmlName(token.Name) + ">")
+					i += 2
+					continue
+				}
+			}
+			b.WriteString(">")
+			depth++
+		case xml.EndElement:
+			depth--
+			newline()
+			b.WriteString("</" + xmlName(token.Name) + ">")
+		case xml.CharData:
+			newline()
+			xml.EscapeText(&b, xmlText(token, pretty))
+		case xml.Comment:
+			newline()
+			b.WriteString("<!--" + string(token) + "-->")
+		case xml.ProcInst:
+			newline()
+			b.WriteString("<?" + token.Target)
+			if len(token.Inst) > 0 {
+				b.WriteString(" " + string(token.Inst))
+			}
+			b.WriteString("?>")
+		case xml.Directive:
+			newline()
+			b.WriteString("<!" + string(token) + ">")
+		}
+	}
+	return b.String(), nil
+}
+
+// xmlText trims text that is printed on its own lines when pretty-printing.
+func xmlText(data xml.CharData, pretty bool) []byte {
+	if pretty {
+		return bytes.TrimSpace(data)
+	}
+	return data
+}
+
+func xmlName(name xml.Name) string {
+	if name.Space != "" {
+		return name.Space + ":" + name.Local
+	}
+	return name.Local
+}
+
+func writeXMLStart(b *strings.Builder, start xml.StartElement) {
+	b.WriteString("<" + xmlName(start.Name))
+	for _, attr := range start.Attr {
+		b.WriteString(" " + xmlName(attr.Name) + `="`)
+		xml.EscapeText(b, []byte(attr.Value))
+		b.WriteString(`"`)
+	}
+}
+
+// --- SQL ---
+
+type sqlTokenKind int
+
+const (
+	sqlWord    sqlTokenKind = iota // Keyword, identifier or number
+	sqlQuoted                      // String literal or quoted identifier
+	sqlPunct                       // Parenthesis, comma, operator, ...
+	sqlComment                     // "-- ..." or "/* ... */"
+)
+
+type sqlToken struct {
+	kind sqlTokenKind
+	text string
+}
+
+// sqlKeywords are upper-cased by sql_pretty and separated from a following
+// parenthesis by a space, unlike function names.
+var sqlKeywords = toSet(`ADD ALL ALTER AND ANY AS ASC BETWEEN BY CASE CHECK COLUMN CONFLICT CONSTRAINT
+	CREATE CROSS DEFAULT DELETE DESC DISTINCT DO DROP ELSE END EXCEPT EXISTS FALSE FETCH FIRST FOR FOREIGN
+	FROM FULL GROUP HAVING IF IN INDEX INNER INSERT INTERSECT INTO IS JOIN KEY LEFT LIKE ILIKE LIMIT NATURAL
+	NEXT NOT NULL OFFSET ON ONLY OR ORDER OUTER OVER PARTITION PRIMARY RECURSIVE REFERENCES RETURNING RIGHT
+	ROWS SELECT SET TABLE THEN TOP TRUE UNION UNIQUE UPDATE USING VALUES VIEW WHEN WHERE WINDOW WITH`)
+
+// sqlClauses start a new line in sql_pretty.
+var sqlClauses = toSet(`SELECT FROM WHERE GROUP ORDER HAVING LIMIT OFFSET FETCH UNION INTERSECT EXCEPT
+	INSERT VALUES UPDATE SET DELETE WITH RETURNING JOIN INNER LEFT RIGHT FULL CROSS NATURAL WINDOW`)
+
+// sqlJoinModifiers precede JOIN on the same line.
+var sqlJoinModifiers = toSet(`INNER LEFT RIGHT FULL CROSS NATURAL OUTER`)
+
+func toSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+// tokenizeSQL splits SQL into tokens, checking that strings, quoted
+// identifiers, comments and parentheses are closed.
+func tokenizeSQL(text string) ([]sqlToken, error) {
+	var tokens []sqlToken
+	depth := 0
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case strings.HasPrefix(text[i:], "--"):
+			end := strings.IndexByte(text[i:], '\n')
+			if end < 0 {
+				end = len(text) - i
+			}
+			tokens = append(tokens, sqlToken{sqlComment, strings.TrimRight(text[i:i+end], " \t\r")})
+			i += end
+		case strings.HasPrefix(text[i:], "/*"):
+			end := strings.Index(text[i+2:], "*/")
+			if end < 0 {
+				return nil, &InputError{Format: "SQL", Err: errors.New("unterminated /* comment")}
+			}
+			tokens = append(tokens, sqlToken{sqlComment, text[i : i+end+4]})
+			i += end + 4
+		case c == '\'' || c == '"' || c == '`':
+			end := i + 1
+			for {
+				next := strings.IndexByte(text[end:], c)
+				if next < 0 {
+					return nil, &InputError{Format: "SQL", Err: fmt.Errorf("unterminated %c quote", c)}
+				}
+				end += next + 1
+				if end < len(text) && text[end] == c {
+					end++ // Doubled quote inside the literal
+					continue
+				}
+				break
+			}
+			tokens = append(tokens, sqlToken{sqlQuoted, text[i:end]})
+			i = end
+		case isSQLWordByte(c):
+			end := i
+			for end < len(text) && isSQLWordByte(text[end]) {
+				end++
+			}
+			tokens = append(tokens, sqlToken{sqlWord, text[i:end]})
+			i = end
+		case c == '(' || c == ')' || c == ',' || c == ';' || c == '.':
+			if c == '(' {
+				depth++
+			} else if c == ')' {
+				if depth == 0 {
+					return nil, &InputError{Format: "SQL", Err: errors.New("unbalanced ')'")}
+				}
+				depth--
+			}
+			tokens = append(tokens, sqlToken{sqlPunct, string(c)})
+			i++
+		default:
+			end := i + 1 // Operators such as "<=", "<>", "::" or "||"
+			for end < len(text) && strings.IndexByte("<>=!:|&+-*/%^~", text[end]) >= 0 &&
+				!strings.HasPrefix(text[end:], "--") && !strings.HasPrefix(text[end:], "/*") {
+				end++
+			}
+			tokens = append(tokens, sqlToken{sqlPunct, text[i:end]})
+			i = end
+		}
+	}
+	if depth > 0 {
+		return nil, &InputError{Format: "SQL", Err: errors.New("unclosed '('")}
+	}
+	return tokens, nil
+}
+
+func isSQLWordByte(c byte) bool {
+	return c == '_' || c == '$' || c == '@' || c == '#' || c >= '0' && c <= '9' ||
+		c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// sqlSpace reports whether a space separates token from the previous one.
+func sqlSpace(prev, token sqlToken) bool {
+	switch {
+	case prev.text == "(" || prev.text == ".":
+		return false
+	case token.text == ")" || token.text == "," || token.text == ";" || token.text == ".":
+		return false
+	case token.text == "(":
+		return prev.kind != sqlWord || sqlKeywords[strings.ToUpper(prev.text)] // "IN (", but "COUNT("
+	case prev.text == "::" || token.text == "::":
+		return false
+	}
+	return true
+}
+
+// sqlMinify puts the statement on one line. Line comments are removed; block
+// comments are kept only if they are optimizer hints ("/*+ ... */").
+func sqlMinify(text string) (string, error) {
+	tokens, err := tokenizeSQL(text)
+	if err != nil {
+		return text, err
+	}
+	var b strings.Builder
+	var prev sqlToken
+	for _, token := range tokens {
+		if token.kind == sqlComment && !strings.HasPrefix(token.text, "/*+") && !strings.HasPrefix(token.text, "/*!") {
+			continue
+		}
+		if b.Len() > 0 && sqlSpace(prev, token) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(token.text)
+		prev = token
+	}
+	return b.String(), nil
+}
+
+// sqlPretty upper-cases keywords and puts clauses on their own lines, with
+// one line per selected column and AND/OR conditions indented. Subqueries are
+// indented one level deeper.
+func sqlPretty(text string) (string, error) {
+	tokens, err := tokenizeSQL(text)
+	if err != nil {
+		return text, err
+	}
+
+	type block struct {
+		subquery    bool   // Opened by "(" followed by SELECT or WITH
+		clause      string // Current clause keyword, e.g. "SELECT"
+		between     bool   // Inside BETWEEN ... AND
+		indent      int    // Indentation of the block's clauses
+		closeIndent int    // Indentation of the closing parenthesis
+	}
+	blocks := []block{{}}
+	var b strings.Builder
+	var prev sqlToken
+	lineStart, lineIndent := true, 0
+	// newline starts a new line; the indentation is written with its first token
+	newline := func(indent int) {
+		if b.Len() > 0 && !lineStart {
+			b.WriteString("\n")
+		}
+		lineStart, lineIndent = true, indent
+	}
+	// current is the innermost block that lays out clauses
+	current := func() *block {
+		for i := len(blocks) - 1; i >= 0; i-- {
+			if i == 0 || blocks[i].subquery {
+				return &blocks[i]
+			}
+		}
+		return &blocks[0]
+	}
+	atClauseLevel := func() bool {
+		last := blocks[len(blocks)-1]
+		return len(blocks) == 1 || last.subquery
+	}
+
+	for i, token := range tokens {
+		upper := strings.ToUpper(token.text)
+		if token.kind == sqlWord && sqlKeywords[upper] {
+			token.text = upper
+		}
+		blk := current()
+
+		switch {
+		case token.kind == sqlWord && sqlClauses[upper] && atClauseLevel() &&
+			!(upper == "JOIN" && sqlJoinModifiers[strings.ToUpper(prev.text)]) &&
+			!(upper == "SET" && strings.ToUpper(prev.text) == "DO") &&
+			!(prev.kind == sqlWord && strings.ToUpper(prev.text) == "UNION" && upper == "ALL"):
+			newline(blk.indent)
+			blk.clause = upper
+			blk.between = false
+		case token.kind == sqlWord && (upper == "AND" || upper == "OR") && atClauseLevel():
+			if blk.between && upper == "AND" {
+				blk.between = false
+			} else {
+				newline(blk.indent + 1)
+			}
+		case token.kind == sqlWord && upper == "BETWEEN":
+			blk.between = true
+		case token.text == ")" && len(blocks) > 1:
+			last := blocks[len(blocks)-1]
+			blocks = blocks[:len(blocks)-1]
+			if last.subquery {
+				newline(last.closeIndent)
+			}
+		}
+
+		if lineStart {
+			b.WriteString(strings.Repeat(FormatIndent, lineIndent))
+		} else if sqlSpace(prev, token) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(token.text)
+		lineStart = false
+
+		switch {
+		case token.text == "(":
+			next := ""
+			if i+1 < len(tokens) {
+				next = strings.ToUpper(tokens[i+1].text)
+			}
+			if next == "SELECT" || next == "WITH" {
+				blocks = append(blocks, block{subquery: true, indent: lineIndent + 1, closeIndent: lineIndent})
+			} else {
+				blocks = append(blocks, block{indent: blk.indent})
+			}
+		case token.text == "," && atClauseLevel() && blk.clause == "SELECT":
+			newline(blk.indent + 1)
+		case upper == "SELECT" && token.kind == sqlWord && atClauseLevel():
+			if i+1 < len(tokens) && strings.ToUpper(tokens[i+1].text) == "DISTINCT" {
+				break // The column list starts after DISTINCT
+			}
+			newline(blk.indent + 1)
+		case upper == "DISTINCT" && strings.ToUpper(prev.text) == "SELECT" && atClauseLevel():
+			newline(blk.indent + 1)
+		case token.text == ";":
+			newline(0)
+			b.WriteString("\n") // Blank line between statements
+			blocks = []block{{}}
+		case token.kind == sqlComment && strings.HasPrefix(token.text, "--"):
+			newline(blk.indent)
+		}
+		prev = token
+	}
+	return strings.TrimRight(b.String(), " \n"), nil
+}
@@ -0,0 +1,111 @@
+package actions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// llmErrorLimit is how much of a failing response is read for its message.
+const llmErrorLimit = 4096
+
+// LLMRequest is where and how an llm rule asks a model, through the chat
+// completions API of OpenAI, which most other vendors and local servers
+// such as Ollama, llama.cpp and LM Studio offer as well.
+type LLMRequest struct {
+	Endpoint  string // Base URL of the API, e.g. "https://api.openai.com/v1"
+	APIKey    string // Sent as a bearer token if set
+	Model     string
+	System    string // The system message, if any
+	Timeout   time.Duration
+	MaxOutput int // Longest response accepted, in bytes
+}
+
+// chatMessage, chatRequest and chatResponse are the parts of the chat
+// completions API that are used.
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// LLM returns an action that sends the text as the user message of req and
+// returns the model's answer.
+func LLM(req LLMRequest) Action {
+	return func(text string) (string, error) {
+		messages := []chatMessage{{Role: "user", Content: text}}
+		if req.System != "" {
+			messages = append([]chatMessage{{Role: "system", Content: req.System}}, messages...)
+		}
+		body, err := json.Marshal(chatRequest{Model: req.Model, Messages: messages})
+		if err != nil {
+			return text, err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), req.Timeout)
+		defer cancel()
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(req.Endpoint, "/")+"/chat/completions", bytes.NewReader(body))
+		if err != nil {
+			return text, err
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		if req.APIKey != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+req.APIKey)
+		}
+		resp, err := http.DefaultClient.Do(httpReq)
+		if err != nil {
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return text, fmt.Errorf("no answer from %s within %v", req.Endpoint, req.Timeout)
+			}
+			return text, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			data, _ := io.ReadAll(io.LimitReader(resp.Body, llmErrorLimit))
+			var failed chatResponse
+			if json.Unmarshal(data, &failed) == nil && failed.Error != nil && failed.Error.Message != "" {
+				return text, fmt.Errorf("%s returned %s: %s", req.Endpoint, resp.Status, failed.Error.Message)
+			}
+			return text, fmt.Errorf("%s returned %s", req.Endpoint, resp.Status)
+		}
+		data, err := io.ReadAll(io.LimitReader(resp.Body, int64(req.MaxOutput)+1))
+		if err != nil {
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return text, fmt.Errorf("no answer from %s within %v", req.Endpoint, req.Timeout)
+			}
+			return text, err
+		}
+		if len(data) > req.MaxOutput {
+			return text, fmt.Errorf("more than %d bytes of output", req.MaxOutput)
+		}
+		var answer chatResponse
+		if err := json.Unmarshal(data, &answer); err != nil {
+			return text, fmt.Errorf("invalid response from %s: %v", req.Endpoint, err)
+		}
+		if len(answer.Choices) == 0 {
+			return text, fmt.Errorf("%s answered without a message", req.Endpoint)
+		}
+		return answer.Choices[0].Message.Content, nil
+	}
+}
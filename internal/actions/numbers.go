@@ -0,0 +1,393 @@
+package actions
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Names of the number and unit actions
+const (
+	GroupThousandsAction   = "group_thousands"
+	UngroupThousandsAction = "ungroup_thousands"
+	DecimalCommaAction     = "decimal_comma"
+	DecimalPointAction     = "decimal_point"
+	ConvertUnitsAction     = "convert_units"
+)
+
+// NoThousands as NumberFormat.Thousands writes numbers without grouping.
+const NoThousands = "none"
+
+// DefaultNumberPrecision is the number of decimal places convert_units writes
+// at most.
+const DefaultNumberPrecision = 2
+
+// NumberFormat holds the settings of the number and unit actions. Empty
+// separators take the defaults of the action, see NumberAction.
+type NumberFormat struct {
+	Decimal      string            // Decimal separator written: "." or ","
+	Thousands    string            // Thousands separator written, or NoThousands
+	InputDecimal string            // Decimal separator of the numbers read: "." or ","
+	Units        map[string]string // convert_units: unit symbol → symbol to convert it to
+	Precision    int               // convert_units: most decimal places written
+}
+
+// thousandsSeparators are the characters that group thousands in numbers read
+// and written: comma, point, apostrophes, underscore and the no-break and thin
+// spaces. A plain space is not among them, so "10 200" stays two numbers.
+var thousandsSeparators = []string{",", ".", "'", "’", "_", " ", " ", " "}
+
+func init() {
+	for _, name := range []string{GroupThousandsAction, UngroupThousandsAction, DecimalCommaAction, DecimalPointAction, ConvertUnitsAction} {
+		registry[name] = func(text string) (string, error) {
+			action, _, err := NumberAction(name, NumberFormat{Precision: DefaultNumberPrecision})
+			if err != nil {
+				return text, err
+			}
+			return action(text)
+		}
+	}
+}
+
+// NumberAction returns the number or unit action called name with the
+// settings of f, or false if name is not such an action. The error tells why
+// f does not work for the action.
+//
+// The actions read numbers with f.InputDecimal as decimal separator ("." by
+// default, "," for decimal_point) and any of the thousands separators:
+//
+//	group_thousands    groups every number, with f.Thousands ("," or "." by default, whichever isn't the decimal separator)
+//	ungroup_thousands  removes the grouping
+//	decimal_comma      writes the decimal separator as ",", and groups grouped numbers with f.Thousands ("." by default)
+//	decimal_point      writes it as ".", and groups grouped numbers with f.Thousands ("," by default)
+//	convert_units      converts quantities like "5 km" to the units of f.Units, rounded to f.Precision places
+//
+// The digits of numbers are kept as they are, only convert_units computes.
+func NumberAction(name string, f NumberFormat) (Action, bool, error) {
+	switch name {
+	case DecimalPointAction:
+		f.InputDecimal = orDefault(f.InputDecimal, ",")
+		f.Decimal = "."
+	case DecimalCommaAction:
+		f.InputDecimal = orDefault(f.InputDecimal, ".")
+		f.Decimal = ","
+	case GroupThousandsAction, UngroupThousandsAction, ConvertUnitsAction:
+		f.InputDecimal = orDefault(f.InputDecimal, ".")
+		f.Decimal = orDefault(f.Decimal, f.InputDecimal)
+	default:
+		return nil, false, nil
+	}
+	if f.Thousands == "" {
+		f.Thousands = map[string]string{".": ",", ",": "."}[f.Decimal]
+	}
+	if err := f.check(); err != nil {
+		return nil, true, err
+	}
+
+	switch name {
+	case GroupThousandsAction:
+		return rewriteNumbers(f.InputDecimal, func(n number) string { return n.format(f.Decimal, f.Thousands, true) }), true, nil
+	case UngroupThousandsAction:
+		return rewriteNumbers(f.InputDecimal, func(n number) string { return n.format(f.Decimal, NoThousands, false) }), true, nil
+	case ConvertUnitsAction:
+		action, err := convertUnits(f)
+		return action, true, err
+	}
+	return rewriteNumbers(f.InputDecimal, func(n number) string { return n.format(f.Decimal, f.Thousands, n.grouped) }), true, nil
+}
+
+// orDefault returns value, or fallback if it is empty.
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// check returns an error if the separators or units of f are invalid.
+func (f NumberFormat) check() error {
+	for _, decimal := range []string{f.InputDecimal, f.Decimal} {
+		if decimal != "." && decimal != "," {
+			return fmt.Errorf("invalid decimal separator '%s' (use . or ,)", decimal)
+		}
+	}
+	if f.Thousands != NoThousands && !contains(thousandsSeparators, f.Thousands) {
+		return fmt.Errorf("invalid thousands separator '%s' (use , . ' ’ _, a no-break or thin space, or %s)", f.Thousands, NoThousands)
+	}
+	if f.Thousands == f.Decimal {
+		return fmt.Errorf("the thousands separator '%s' is also the decimal separator", f.Thousands)
+	}
+	if f.Precision < 0 || f.Precision > 15 {
+		return fmt.Errorf("invalid precision %d (use 0 to 15)", f.Precision)
+	}
+	for from, to := range f.Units {
+		fromUnit, ok := units[from]
+		if !ok {
+			return fmt.Errorf("unknown unit '%s' (known: %s)", from, strings.Join(UnitNames(), ", "))
+		}
+		toUnit, ok := units[to]
+		if !ok {
+			return fmt.Errorf("unknown unit '%s' (known: %s)", to, strings.Join(UnitNames(), ", "))
+		}
+		if fromUnit.dimension != toUnit.dimension {
+			return fmt.Errorf("can't convert %s (%s) to %s (%s)", from, fromUnit.dimension, to, toUnit.dimension)
+		}
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// number is a number as written in the text.
+type number struct {
+	sign     string // "", "+", "-" or "−"
+	integer  string // Digits before the decimal separator, without grouping
+	fraction string // Digits after it
+	decimal  bool   // There is a decimal separator
+	grouped  bool   // The integer digits were grouped
+}
+
+// numberPattern returns the regex matching numbers with decimal as decimal
+// separator, grouped with one of the other separators or not.
+func numberPattern(decimal string) *regexp.Regexp {
+	var alternatives []string
+	for _, sep := range thousandsSeparators {
+		if sep != decimal {
+			alternatives = append(alternatives, `\d{1,3}(?:`+regexp.QuoteMeta(sep)+`\d{3})+`)
+		}
+	}
+	alternatives = append(alternatives, `\d+`)
+	return regexp.MustCompile(`[-+−]?(?:` + strings.Join(alternatives, "|") + `)(?:` + regexp.QuoteMeta(decimal) + `\d+)?`)
+}
+
+// parseNumber splits a match of numberPattern(decimal).
+func parseNumber(s, decimal string) number {
+	var n number
+	for _, sign := range []string{"+", "-", "−"} {
+		if strings.HasPrefix(s, sign) {
+			n.sign, s = sign, s[len(sign):]
+			break
+		}
+	}
+	integer, fraction, found := strings.Cut(s, decimal)
+	n.fraction, n.decimal = fraction, found
+	n.integer = strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		n.grouped = true
+		return -1
+	}, integer)
+	return n
+}
+
+// format writes n with the separators, grouping its thousands if group.
+func (n number) format(decimal, thousands string, group bool) string {
+	var b strings.Builder
+	b.WriteString(n.sign)
+	if !group || thousands == NoThousands {
+		b.WriteString(n.integer)
+	} else {
+		for i, digit := range n.integer {
+			if i > 0 && (len(n.integer)-i)%3 == 0 {
+				b.WriteString(thousands)
+			}
+			b.WriteRune(digit)
+		}
+	}
+	if n.decimal {
+		b.WriteString(decimal)
+		b.WriteString(n.fraction)
+	}
+	return b.String()
+}
+
+// startsAlone and endsAlone report whether a number starting or ending at
+// index i of text stands alone rather than being part of a word, version, IP
+// address, date or time, e.g. the "2" of "v2", "1.2.3" or "2024-01-02". A
+// sign glued to what comes before, like the dash of "2024-01", doesn't start
+// a number either.
+func startsAlone(text string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	before, size := utf8.DecodeLastRuneInString(text[:i])
+	beforeThat, _ := utf8.DecodeLastRuneInString(text[:i-size])
+	return !isWordRune(before) && !(joinsNumbers(before) && unicode.IsDigit(beforeThat))
+}
+
+func endsAlone(text string, i int) bool {
+	if i == len(text) {
+		return true
+	}
+	after, size := utf8.DecodeRuneInString(text[i:])
+	afterThat, _ := utf8.DecodeRuneInString(text[i+size:])
+	return !isWordRune(after) && !(joinsNumbers(after) && unicode.IsDigit(afterThat))
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// joinsNumbers reports whether r joins numbers to a larger one, like in
+// versions, dates and times.
+func joinsNumbers(r rune) bool {
+	return strings.ContainsRune(".,-/:", r)
+}
+
+// rewriteNumbers returns the action replacing each number standing alone, with
+// decimal as decimal separator, by rewrite's result.
+func rewriteNumbers(decimal string, rewrite func(number) string) Action {
+	numbers := numberPattern(decimal)
+	return func(text string) (string, error) {
+		var b strings.Builder
+		last := 0
+		for _, loc := range numbers.FindAllStringIndex(text, -1) {
+			if !startsAlone(text, loc[0]) || !endsAlone(text, loc[1]) {
+				continue
+			}
+			b.WriteString(text[last:loc[0]])
+			b.WriteString(rewrite(parseNumber(text[loc[0]:loc[1]], decimal)))
+			last = loc[1]
+		}
+		if last == 0 {
+			return text, nil
+		}
+		b.WriteString(text[last:])
+		return b.String(), nil
+	}
+}
+
+// unit is a unit convert_units knows: a value in it times factor, plus
+// offset, is the value in the base unit of its dimension.
+type unit struct {
+	dimension string
+	factor    float64
+	offset    float64
+}
+
+// units are the units of convert_units by symbol. Gallons and their parts
+// are US measures.
+var units = map[string]unit{}
+
+func init() {
+	add := func(dimension string, factors map[string]float64, aliases map[string]string) {
+		for symbol, factor := range factors {
+			units[symbol] = unit{dimension: dimension, factor: factor}
+		}
+		for alias, symbol := range aliases {
+			units[alias] = units[symbol]
+		}
+	}
+	add("length", map[string]float64{
+		"mm": 0.001, "cm": 0.01, "m": 1, "km": 1000,
+		"in": 0.0254, "ft": 0.3048, "yd": 0.9144, "mi": 1609.344, "nmi": 1852,
+	}, nil)
+	add("mass", map[string]float64{
+		"mg": 1e-6, "g": 0.001, "kg": 1, "t": 1000,
+		"oz": 0.028349523125, "lb": 0.45359237, "st": 6.35029318,
+	}, map[string]string{"lbs": "lb"})
+	add("volume", map[string]float64{
+		"ml": 0.001, "cl": 0.01, "dl": 0.1, "l": 1, "m³": 1000,
+		"gal": 3.785411784, "qt": 0.946352946, "pt": 0.473176473, "cup": 0.2365882365, "fl_oz": 0.0295735295625,
+	}, map[string]string{"mL": "ml", "cL": "cl", "dL": "dl", "L": "l", "m3": "m³"})
+	add("area", map[string]float64{
+		"mm²": 1e-6, "cm²": 1e-4, "m²": 1, "km²": 1e6, "ha": 1e4,
+		"in²": 0.00064516, "ft²": 0.09290304, "yd²": 0.83612736, "mi²": 2589988.110336, "ac": 4046.8564224,
+	}, map[string]string{"mm2": "mm²", "cm2": "cm²", "m2": "m²", "km2": "km²", "in2": "in²", "ft2": "ft²", "yd2": "yd²", "mi2": "mi²"})
+	add("speed", map[string]float64{
+		"m/s": 1, "km/h": 1 / 3.6, "mph": 0.44704, "kn": 1852.0 / 3600, "ft/s": 0.3048,
+	}, map[string]string{"kph": "km/h"})
+	units["°C"] = unit{dimension: "temperature", factor: 1, offset: 273.15}
+	units["°F"] = unit{dimension: "temperature", factor: 5.0 / 9, offset: 459.67 * 5 / 9}
+	units["K"] = unit{dimension: "temperature", factor: 1}
+	units["℃"], units["℉"] = units["°C"], units["°F"]
+}
+
+// UnitNames returns the symbols convert_units knows, in alphabetical order.
+func UnitNames() []string {
+	names := make([]string, 0, len(units))
+	for name := range units {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// convertUnits returns the convert_units action for f.
+func convertUnits(f NumberFormat) (Action, error) {
+	if len(f.Units) == 0 {
+		return nil, fmt.Errorf("%s needs the units to convert, e.g. \"units\": {\"km\": \"mi\"}", ConvertUnitsAction)
+	}
+	symbols := make([]string, 0, len(f.Units))
+	for symbol := range f.Units {
+		symbols = append(symbols, regexp.QuoteMeta(symbol))
+	}
+	sort.Slice(symbols, func(i, j int) bool { return len(symbols[i]) > len(symbols[j]) }) // "mm" before "m"
+	quantities := regexp.MustCompile(`(` + numberPattern(f.InputDecimal).String() + `)([ \x{00A0}\x{202F}]?)(` + strings.Join(symbols, "|") + `)`)
+
+	return func(text string) (string, error) {
+		var b strings.Builder
+		last := 0
+		for _, m := range quantities.FindAllStringSubmatchIndex(text, -1) {
+			if after, _ := utf8.DecodeRuneInString(text[m[1]:]); !startsAlone(text, m[0]) || isWordRune(after) || strings.ContainsRune("/²³", after) {
+				continue // Within a word, or a longer unit such as "min", "m/s" or "m²" for "m"
+			}
+			n := parseNumber(text[m[2]:m[3]], f.InputDecimal)
+			from, to := text[m[6]:m[7]], f.Units[text[m[6]:m[7]]]
+
+			value, err := strconv.ParseFloat(n.integer+"."+orDefault(n.fraction, "0"), 64)
+			if err != nil {
+				continue
+			}
+			if n.sign == "-" || n.sign == "−" {
+				value = -value
+			}
+			base := value*units[from].factor + units[from].offset
+			converted := (base - units[to].offset) / units[to].factor
+
+			b.WriteString(text[last:m[0]])
+			b.WriteString(formatFloat(converted, f.Precision, n.sign, f.Decimal, f.Thousands, n.grouped))
+			b.WriteString(text[m[4]:m[5]])
+			b.WriteString(to)
+			last = m[1]
+		}
+		if last == 0 {
+			return text, nil
+		}
+		b.WriteString(text[last:])
+		return b.String(), nil
+	}, nil
+}
+
+// formatFloat writes value rounded to precision places, without trailing
+// zeros. A positive value keeps an explicit "+" sign of the original.
+func formatFloat(value float64, precision int, originalSign, decimal, thousands string, group bool) string {
+	s := strconv.FormatFloat(math.Abs(value), 'f', precision, 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	n := parseNumber(s, ".")
+	switch {
+	case value < 0 && strings.Trim(s, "0.") != "":
+		n.sign = "-"
+		if originalSign == "−" {
+			n.sign = "−"
+		}
+	case originalSign == "+":
+		n.sign = "+"
+	}
+	return n.format(decimal, thousands, group)
+}
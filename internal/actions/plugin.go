@@ -0,0 +1,205 @@
+package actions
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Plugin is a transformer run by an external executable from the plugins
+// folder, so it can be added without recompiling the application. Like a
+// language server, the plugin keeps running and answers one request after
+// another, one JSON object per line on stdin and stdout:
+//
+//	→ {"id": 1, "transformer": "acme-tokenizer", "text": "...", "options": {"scheme": "v2"}}
+//	← {"id": 1, "text": "..."}
+//	← {"id": 1, "error": "what went wrong"}
+//
+// The plugin is started on first use and started again if it exited. Its
+// stderr goes to the log.
+type Plugin struct {
+	name      string
+	path      string
+	timeout   time.Duration // Per request; the plugin is stopped if it takes longer
+	maxOutput int           // Longest response line accepted
+
+	mu     sync.Mutex // Serializes requests and guards the fields below
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	lines  chan pluginLine // Response lines read from stdout; closed when it ends
+	nextID int
+}
+
+// pluginLine is a line read from a plugin's stdout, or why reading ended.
+type pluginLine struct {
+	data []byte
+	err  error
+}
+
+// pluginRequest and pluginResponse are the messages of the plugin protocol.
+type pluginRequest struct {
+	ID          int               `json:"id"`
+	Transformer string            `json:"transformer"`
+	Text        string            `json:"text"`
+	Options     map[string]string `json:"options,omitempty"`
+}
+
+type pluginResponse struct {
+	ID    int     `json:"id"`
+	Text  *string `json:"text"`
+	Error string  `json:"error"`
+}
+
+// NewPlugin returns the plugin at path, named name. Requests fail after
+// timeout and responses longer than maxOutput bytes are rejected.
+func NewPlugin(name, path string, timeout time.Duration, maxOutput int) *Plugin {
+	return &Plugin{name: name, path: path, timeout: timeout, maxOutput: maxOutput}
+}
+
+// Name returns the name rules use for the plugin.
+func (p *Plugin) Name() string {
+	return p.name
+}
+
+// Transform sends text to the plugin and returns its answer.
+func (p *Plugin) Transform(text string, options map[string]string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cmd == nil {
+		if err := p.startLocked(); err != nil {
+			return text, err
+		}
+	}
+	p.nextID++
+	request, err := json.Marshal(pluginRequest{ID: p.nextID, Transformer: p.name, Text: text, Options: options})
+	if err != nil {
+		return text, err
+	}
+
+	// The timeout covers writing the request too: a plugin that doesn't read
+	// stdin blocks the write once the pipe is full. Stopping the plugin
+	// closes stdin, which ends the write.
+	timer := time.NewTimer(p.timeout)
+	defer timer.Stop()
+	written := make(chan error, 1)
+	go func(stdin io.Writer) {
+		_, err := stdin.Write(append(request, '\n'))
+		written <- err
+	}(p.stdin)
+	for {
+		select {
+		case <-timer.C:
+			p.stopLocked()
+			return text, fmt.Errorf("plugin '%s' timed out after %v", p.name, p.timeout)
+		case err := <-written:
+			if err != nil {
+				p.stopLocked()
+				return text, fmt.Errorf("plugin '%s' is not accepting requests: %v", p.name, err)
+			}
+			written = nil // Written; a nil channel is never ready
+		case line, ok := <-p.lines:
+			if !ok || line.err != nil {
+				reason := "it exited"
+				if ok {
+					reason = line.err.Error()
+				}
+				p.stopLocked()
+				return text, fmt.Errorf("plugin '%s' stopped answering: %s", p.name, reason)
+			}
+			var response pluginResponse
+			if err := json.Unmarshal(line.data, &response); err != nil {
+				p.stopLocked()
+				return text, fmt.Errorf("plugin '%s' sent an invalid response: %v", p.name, err)
+			}
+			if response.ID != p.nextID {
+				continue // The late answer to a request that timed out
+			}
+			if response.Error != "" {
+				return text, errors.New(response.Error)
+			}
+			if response.Text == nil {
+				return text, fmt.Errorf("plugin '%s' answered without text", p.name)
+			}
+			return *response.Text, nil
+		}
+	}
+}
+
+// Close stops the plugin. It is started again by the next Transform.
+func (p *Plugin) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cmd != nil {
+		p.stopLocked()
+	}
+}
+
+// startLocked starts the plugin in its folder. The caller holds p.mu.
+func (p *Plugin) startLocked() error {
+	cmd := exec.Command(p.path)
+	cmd.Dir = filepath.Dir(p.path)
+	cmd.Stderr = &pluginLog{name: p.name}
+	hideCommandWindow(cmd)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start plugin '%s': %v", p.name, err)
+	}
+	log.Printf("Started plugin '%s' (%s).", p.name, p.path)
+
+	lines := make(chan pluginLine, 1)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), p.maxOutput)
+		for scanner.Scan() {
+			lines <- pluginLine{data: append([]byte(nil), scanner.Bytes()...)}
+		}
+		if err := scanner.Err(); err != nil {
+			lines <- pluginLine{err: err}
+		}
+	}()
+	p.cmd, p.stdin, p.lines = cmd, stdin, lines
+	return nil
+}
+
+// stopLocked ends the plugin process and forgets it. The caller holds p.mu.
+func (p *Plugin) stopLocked() {
+	p.stdin.Close()
+	p.cmd.Process.Kill()
+	for range p.lines {
+		// Drain stdout, so Wait doesn't close it while it is read
+	}
+	p.cmd.Wait()
+	p.cmd, p.stdin, p.lines = nil, nil, nil
+	log.Printf("Stopped plugin '%s'.", p.name)
+}
+
+// pluginLog writes a plugin's stderr to the log, line by line.
+type pluginLog struct {
+	name string
+}
+
+func (l *pluginLog) Write(data []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\r\n"), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			log.Printf("Plugin '%s': %s", l.name, line)
+		}
+	}
+	return len(data), nil
+}
@@ -0,0 +1,25 @@
+package actions
+
+import (
+	"errors"
+	"time"
+)
+
+// ScriptFunction is the global Lua function a script defines. It receives the
+// text and returns the transformed text, or nil to leave it unchanged.
+const ScriptFunction = "transform"
+
+// ScriptTimeout limits how long a script may run on one input.
+const ScriptTimeout = 2 * time.Second
+
+// ErrScriptingUnavailable is returned for script rules if the binary was
+// built without the "lua" build tag.
+var ErrScriptingUnavailable = errors.New("Lua scripting is not included in this build (build with -tags lua)")
+
+// Script returns an action that runs the Lua script at path. The file is read
+// on every run, so edits take effect without reloading the configuration.
+func Script(path string) Action {
+	return func(text string) (string, error) {
+		return runScript(path, text)
+	}
+}
@@ -0,0 +1,67 @@
+//go:build lua
+// +build lua
+
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// ScriptingAvailable reports whether script rules can run in this build.
+const ScriptingAvailable = true
+
+// runScript runs the script's transform function on text in a fresh Lua state
+// with only the base, string, table and math libraries. Files and the
+// operating system cannot be accessed.
+func runScript(path, text string) (string, error) {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+	defer L.Close()
+
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		if err := L.CallByParam(lua.P{Fn: L.NewFunction(lib.open), NRet: 0, Protect: true}, lua.LString(lib.name)); err != nil {
+			return text, fmt.Errorf("failed to open Lua library '%s': %w", lib.name, err)
+		}
+	}
+	for _, name := range []string{"dofile", "loadfile", "load", "loadstring"} {
+		L.SetGlobal(name, lua.LNil)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ScriptTimeout)
+	defer cancel()
+	L.SetContext(ctx)
+
+	if err := L.DoFile(path); err != nil {
+		return text, fmt.Errorf("script '%s' failed to load: %w", path, err)
+	}
+	fn, ok := L.GetGlobal(ScriptFunction).(*lua.LFunction)
+	if !ok {
+		return text, fmt.Errorf("script '%s' does not define a function '%s'", path, ScriptFunction)
+	}
+	if err := L.CallByParam(lua.P{Fn: fn, NRet: 1, Protect: true}, lua.LString(text)); err != nil {
+		if ctx.Err() != nil {
+			return text, fmt.Errorf("script '%s' timed out after %v", path, ScriptTimeout)
+		}
+		return text, fmt.Errorf("script '%s' failed: %w", path, err)
+	}
+	result := L.Get(-1)
+	L.Pop(1)
+
+	switch value := result.(type) {
+	case *lua.LNilType:
+		return text, nil
+	case lua.LString:
+		return string(value), nil
+	}
+	return text, fmt.Errorf("script '%s': %s must return a string or nil, not a %s", path, ScriptFunction, result.Type())
+}
@@ -0,0 +1,11 @@
+//go:build !lua
+// +build !lua
+
+package actions
+
+// ScriptingAvailable reports whether script rules can run in this build.
+const ScriptingAvailable = false
+
+func runScript(path, text string) (string, error) {
+	return text, ErrScriptingUnavailable
+}
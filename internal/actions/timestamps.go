@@ -0,0 +1,262 @@
+package actions
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Output formats of NormalizeTimestamps that are not Go layouts
+const (
+	TimestampUnix   = "unix"    // Seconds since 1970
+	TimestampUnixMs = "unix_ms" // Milliseconds since 1970
+)
+
+// TimestampLayouts are named output layouts of NormalizeTimestamps.
+var TimestampLayouts = map[string]string{
+	"rfc3339":    time.RFC3339,
+	"rfc3339_ms": "2006-01-02T15:04:05.000Z07:00",
+	"datetime":   "2006-01-02 15:04:05",
+	"time":       "15:04:05.000",
+}
+
+// Relative offsets NormalizeTimestamps can write instead of timestamps
+const (
+	RelativeToFirst    = "first"    // Time since the first timestamp of the text
+	RelativeToPrevious = "previous" // Time since the timestamp before
+)
+
+// TimestampOptions controls how NormalizeTimestamps rewrites timestamps.
+type TimestampOptions struct {
+	Layout   string         // Go layout of the output, or TimestampUnix / TimestampUnixMs
+	Relative string         // RelativeToFirst or RelativeToPrevious write offsets instead
+	Zone     *time.Location // Zone of the output; nil keeps each timestamp's own
+	Assume   *time.Location // Zone of timestamps without one
+	Epochs   bool           // Also rewrite Unix timestamps of 10 or 13 digits
+	Now      time.Time      // Tells the year of syslog timestamps, which have none
+}
+
+// timestampFormat is a timestamp format found in logs.
+type timestampFormat struct {
+	pattern string
+	parse   func(match string, opts TimestampOptions) (time.Time, error)
+}
+
+const months = `(?:Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec)`
+const weekdays = `(?:Mon|Tue|Wed|Thu|Fri|Sat|Sun)`
+
+// timestampFormats are tried in order at each position, so formats that
+// contain others come first: ctime before syslog, which is its tail.
+var timestampFormats = []timestampFormat{
+	{ // ISO 8601 and RFC 3339, also with a space and a decimal comma as Python logs them
+		pattern: `\b\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d{1,9})?(?:Z|[+-]\d{2}:?\d{2}\b)?`,
+		parse:   parseISO,
+	},
+	{ // Go's log package: 2006/01/02 15:04:05.000000
+		pattern: `\b\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}(?:\.\d{1,9})?`,
+		parse:   layoutParser("2006/01/02 15:04:05"),
+	},
+	{ // Apache and nginx access logs: 02/Jan/2006:15:04:05 -0700
+		pattern: `\b\d{2}/` + months + `/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}`,
+		parse:   layoutParser("02/Jan/2006:15:04:05 -0700"),
+	},
+	{ // RFC 1123 and RFC 2822, as in HTTP and mail headers: Mon, 02 Jan 2006 15:04:05 -0700
+		pattern: weekdays + `, \d{1,2} ` + months + ` \d{4} \d{2}:\d{2}:\d{2} (?:[+-]\d{4}|[A-Z]{2,5})`,
+		parse:   parseRFC1123,
+	},
+	{ // ctime and the date command: Mon Jan  2 15:04:05 [MST] 2006
+		pattern: weekdays + ` ` + months + ` [ \d]\d \d{2}:\d{2}:\d{2} (?:[A-Z]{2,5} )?\d{4}`,
+		parse:   parseCtime,
+	},
+	{ // Syslog (RFC 3164): Jan  2 15:04:05, without a year
+		pattern: `\b` + months + ` [ \d]\d \d{2}:\d{2}:\d{2}(?:\.\d{1,6})?`,
+		parse:   parseSyslog,
+	},
+}
+
+// epochFormat is a Unix timestamp in seconds or milliseconds from 2001 to
+// 2033, which is tried only with TimestampOptions.Epochs.
+var epochFormat = timestampFormat{
+	pattern: `\b1\d{9}(?:\d{3})?\b`,
+	parse: func(match string, _ TimestampOptions) (time.Time, error) {
+		n, err := strconv.ParseInt(match, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if len(match) == 13 {
+			return time.UnixMilli(n), nil
+		}
+		return time.Unix(n, 0), nil
+	},
+}
+
+var (
+	timestampFormatsWithEpochs  = append(timestampFormats[:len(timestampFormats):len(timestampFormats)], epochFormat)
+	timestampPatterns           = combineTimestampFormats(timestampFormats)
+	timestampPatternsWithEpochs = combineTimestampFormats(timestampFormatsWithEpochs)
+)
+
+// combineTimestampFormats returns a regex matching any of formats, with one
+// group per format.
+func combineTimestampFormats(formats []timestampFormat) *regexp.Regexp {
+	parts := make([]string, len(formats))
+	for i, format := range formats {
+		parts[i] = "(" + format.pattern + ")"
+	}
+	return regexp.MustCompile(strings.Join(parts, "|"))
+}
+
+// NormalizeTimestamps rewrites the timestamps of the common log formats in
+// text into one format, or into offsets from the first or previous
+// timestamp. It returns the number of timestamps rewritten.
+func NormalizeTimestamps(text string, opts TimestampOptions) (string, int) {
+	if opts.Assume == nil {
+		opts.Assume = time.Local
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	formats, re := timestampFormats, timestampPatterns
+	if opts.Epochs {
+		formats, re = timestampFormatsWithEpochs, timestampPatternsWithEpochs
+	}
+
+	var b strings.Builder
+	var first, previous time.Time
+	count, last := 0, 0
+	for _, loc := range re.FindAllStringSubmatchIndex(text, -1) {
+		match := text[loc[0]:loc[1]]
+		format := 0
+		for format < len(formats) && loc[2+2*format] < 0 {
+			format++
+		}
+		t, err := formats[format].parse(match, opts)
+		if err != nil {
+			continue // Looks like a timestamp, but isn't a valid one, e.g. month 13
+		}
+		if count == 0 {
+			first, previous = t, t
+		}
+
+		b.WriteString(text[last:loc[0]])
+		switch opts.Relative {
+		case RelativeToFirst:
+			b.WriteString(formatOffset(t.Sub(first)))
+		case RelativeToPrevious:
+			b.WriteString(formatOffset(t.Sub(previous)))
+		default:
+			b.WriteString(formatTimestamp(t, opts))
+		}
+		previous = t
+		last = loc[1]
+		count++
+	}
+	if count == 0 {
+		return text, 0
+	}
+	b.WriteString(text[last:])
+	return b.String(), count
+}
+
+// formatTimestamp writes t in the output zone and layout.
+func formatTimestamp(t time.Time, opts TimestampOptions) string {
+	if opts.Zone != nil {
+		t = t.In(opts.Zone)
+	}
+	switch opts.Layout {
+	case TimestampUnix:
+		return strconv.FormatInt(t.Unix(), 10)
+	case TimestampUnixMs:
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	}
+	return t.Format(opts.Layout)
+}
+
+// formatOffset writes d as +HH:MM:SS.mmm, or with a minus if it is negative,
+// e.g. for log lines out of order.
+func formatOffset(d time.Duration) string {
+	sign := "+"
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%s%02d:%02d:%02d.%03d", sign, ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// layoutParser parses timestamps of layout, with optional fractional
+// seconds, in the assumed zone unless they name their own.
+func layoutParser(layout string) func(string, TimestampOptions) (time.Time, error) {
+	return func(match string, opts TimestampOptions) (time.Time, error) {
+		return time.ParseInLocation(withFraction(layout, match), match, opts.Assume)
+	}
+}
+
+// withFraction adds the fractional seconds of match to layout, which has
+// none, after its seconds.
+func withFraction(layout, match string) string {
+	i := strings.Index(layout, ":05")
+	if i < 0 || !fractionPattern.MatchString(match) {
+		return layout
+	}
+	return layout[:i+3] + ".999999999" + layout[i+3:]
+}
+
+var fractionPattern = regexp.MustCompile(`:\d{2}\.\d`)
+
+// parseISO parses ISO 8601 timestamps, which may use a space instead of the
+// T, a decimal comma and a zone offset without a colon.
+func parseISO(match string, opts TimestampOptions) (time.Time, error) {
+	s := []byte(match)
+	s[10] = 'T'
+	normalized := strings.Replace(string(s), ",", ".", 1)
+	if zone := isoZonePattern.FindStringIndex(normalized); zone != nil {
+		offset := normalized[zone[0]:]
+		if !strings.Contains(offset, ":") {
+			offset = offset[:3] + ":" + offset[3:]
+		}
+		return time.Parse(time.RFC3339Nano, normalized[:zone[0]]+offset)
+	}
+	if strings.HasSuffix(normalized, "Z") {
+		return time.Parse(time.RFC3339Nano, normalized)
+	}
+	return time.ParseInLocation("2006-01-02T15:04:05.999999999", normalized, opts.Assume)
+}
+
+var isoZonePattern = regexp.MustCompile(`[+-]\d{2}:?\d{2}$`)
+
+// parseRFC1123 parses mail and HTTP dates, with a numeric or named zone. Of
+// zone names, only UTC, GMT and those of the assumed zone have a known
+// offset; others are taken as UTC.
+func parseRFC1123(match string, opts TimestampOptions) (time.Time, error) {
+	if t, err := time.Parse("Mon, 2 Jan 2006 15:04:05 -0700", match); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("Mon, 2 Jan 2006 15:04:05 MST", match, opts.Assume)
+}
+
+// parseCtime parses the output of ctime and date, with or without a zone
+// name, which is handled like in parseRFC1123.
+func parseCtime(match string, opts TimestampOptions) (time.Time, error) {
+	if t, err := time.ParseInLocation(time.ANSIC, match, opts.Assume); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(time.UnixDate, match, opts.Assume)
+}
+
+// parseSyslog parses syslog timestamps, which have no year: it is the year
+// of opts.Now, or the year before if that puts the timestamp more than a
+// day into the future, e.g. for December logs read in January.
+func parseSyslog(match string, opts TimestampOptions) (time.Time, error) {
+	t, err := time.ParseInLocation(withFraction("Jan _2 15:04:05", match), match, opts.Assume)
+	if err != nil {
+		return t, err
+	}
+	now := opts.Now.In(opts.Assume)
+	t = t.AddDate(now.Year(), 0, 0)
+	if t.After(now.Add(24 * time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t, nil
+}
@@ -0,0 +1,196 @@
+package actions
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// StripTrackingAction is the name of the action removing tracking parameters
+// from URLs.
+const StripTrackingAction = "strip_tracking"
+
+//go:embed tracking.json
+var bundledTrackingJSON []byte
+
+// TrackingRules lists the URL query parameters "strip_tracking" removes.
+// Names may contain "*" as a wildcard, e.g. "utm_*"; they are compared case
+// sensitively, like servers read them.
+type TrackingRules struct {
+	Version string              `json:"version,omitempty"` // Date of the list, e.g. "2026-10-01"
+	Params  []string            `json:"params,omitempty"`  // Removed from every URL
+	Domains map[string][]string `json:"domains,omitempty"` // Host (and its subdomains) -> parameters removed only there; "amazon.*" covers every top-level domain
+	Keep    []string            `json:"keep,omitempty"`    // Never removed, even if listed above
+}
+
+// BundledTrackingRules returns the list shipped with the application.
+func BundledTrackingRules() TrackingRules {
+	var rules TrackingRules
+	if err := json.Unmarshal(bundledTrackingJSON, &rules); err != nil {
+		panic(fmt.Sprintf("invalid bundled tracking.json: %v", err)) // Caught at build time by any run
+	}
+	return rules
+}
+
+// LoadTrackingRules reads a list in the format of the bundled one, e.g. a
+// newer copy of it.
+func LoadTrackingRules(file string) (TrackingRules, error) {
+	var rules TrackingRules
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return rules, err
+	}
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return rules, fmt.Errorf("invalid tracking parameter list '%s': %w", file, err)
+	}
+	return rules, rules.Check()
+}
+
+// Check reports the first name or domain that is not a valid pattern.
+func (r TrackingRules) Check() error {
+	patterns := slices.Concat(r.Params, r.Keep)
+	for domain, params := range r.Domains {
+		patterns = append(patterns, domain)
+		patterns = append(patterns, params...)
+	}
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return fmt.Errorf("invalid tracking parameter pattern '%s'", pattern)
+		}
+	}
+	return nil
+}
+
+// With returns the rules extended by more parameters, domains and exceptions.
+func (r TrackingRules) With(more TrackingRules) TrackingRules {
+	combined := TrackingRules{
+		Version: r.Version,
+		Params:  slices.Concat(r.Params, more.Params),
+		Domains: maps.Clone(r.Domains),
+		Keep:    slices.Concat(r.Keep, more.Keep),
+	}
+	if combined.Domains == nil {
+		combined.Domains = make(map[string][]string)
+	}
+	for domain, params := range more.Domains {
+		combined.Domains[domain] = slices.Concat(combined.Domains[domain], params)
+	}
+	return combined
+}
+
+func init() {
+	registry[StripTrackingAction] = StripTracking(BundledTrackingRules())
+}
+
+// urlRegex finds URLs in text. Trailing punctuation is trimmed separately.
+var urlRegex = regexp.MustCompile("(?i)\\bhttps?://[^\\s<>\"'`]+")
+
+// StripTracking returns the action removing the parameters of rules from the
+// query of every http(s) URL in the text. The rest of each URL is kept byte
+// for byte, and a query left empty is dropped with its "?".
+func StripTracking(rules TrackingRules) Action {
+	return plain(func(text string) string {
+		return urlRegex.ReplaceAllStringFunc(text, func(match string) string {
+			link, trailer := splitTrailingPunctuation(match)
+			return rules.strip(link) + trailer
+		})
+	})
+}
+
+// splitTrailingPunctuation separates punctuation that ends a sentence rather
+// than the URL, e.g. "https://example.com/x." or "(see https://example.com)".
+func splitTrailingPunctuation(link string) (string, string) {
+	end := len(link)
+	for end > 0 {
+		c := link[end-1]
+		if strings.IndexByte(".,;:!?", c) >= 0 || (c == ')' && strings.Count(link[:end], "(") < strings.Count(link[:end], ")")) {
+			end--
+			continue
+		}
+		break
+	}
+	return link[:end], link[end:]
+}
+
+// strip removes the tracking parameters from the query of link.
+func (r TrackingRules) strip(link string) string {
+	queryStart := strings.IndexByte(link, '?')
+	if queryStart < 0 {
+		return link
+	}
+	queryEnd := len(link)
+	if hash := strings.IndexByte(link, '#'); hash >= 0 {
+		if hash < queryStart {
+			return link // The "?" is part of the fragment
+		}
+		queryEnd = hash
+	}
+	host := ""
+	if parsed, err := url.Parse(link); err == nil {
+		host = strings.ToLower(parsed.Hostname())
+	}
+
+	var kept []string
+	for _, param := range strings.Split(link[queryStart+1:queryEnd], "&") {
+		if param == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(param, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if !r.tracks(host, name) {
+			kept = append(kept, param)
+		}
+	}
+	if len(kept) == 0 {
+		return link[:queryStart] + link[queryEnd:]
+	}
+	return link[:queryStart+1] + strings.Join(kept, "&") + link[queryEnd:]
+}
+
+// tracks reports whether the parameter name of a URL on host is removed.
+func (r TrackingRules) tracks(host, name string) bool {
+	if matchesAny(r.Keep, name) {
+		return false
+	}
+	if matchesAny(r.Params, name) {
+		return true
+	}
+	for domain, params := range r.Domains {
+		if onDomain(host, domain) && matchesAny(params, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// onDomain reports whether host is domain or one of its subdomains.
+func onDomain(host, domain string) bool {
+	for {
+		if ok, _ := path.Match(strings.ToLower(domain), host); ok {
+			return true
+		}
+		_, parent, found := strings.Cut(host, ".")
+		if !found {
+			return false
+		}
+		host = parent
+	}
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package actions
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+func init() {
+	registry["normalize_nfc"] = plain(norm.NFC.String)
+	registry["normalize_nfkc"] = plain(norm.NFKC.String)
+	registry["strip_zero_width"] = plain(stripZeroWidth)
+	registry["strip_bidi"] = plain(func(text string) string { return strings.Map(dropRunes(isBidiControl), text) })
+	registry["replace_nbsp"] = plain(replaceNBSP)
+	registry["strip_invisible"] = plain(func(text string) string {
+		return replaceNBSP(strings.Map(dropRunes(isBidiControl), stripZeroWidth(text)))
+	})
+}
+
+const zeroWidthJoiner = '\u200D'
+
+// isZeroWidth reports whether r is a character without width: zero-width
+// spaces and joiners, the word joiner, the byte order mark and soft hyphens.
+func isZeroWidth(r rune) bool {
+	switch r {
+	case '\u200B', '\u200C', zeroWidthJoiner, '\u2060', '\uFEFF', '\u00AD', '\u180E':
+		return true
+	}
+	return false
+}
+
+// isBidiControl reports whether r is an invisible mark or embedding that
+// changes the direction of text, e.g. in "Trojan Source" attacks.
+func isBidiControl(r rune) bool {
+	switch {
+	case r == '\u061C', r == '\u200E', r == '\u200F':
+		return true
+	case r >= '\u202A' && r <= '\u202E', r >= '\u2066' && r <= '\u2069':
+		return true
+	}
+	return false
+}
+
+// dropRunes returns a strings.Map function removing the runes drop matches.
+func dropRunes(drop func(rune) bool) func(rune) rune {
+	return func(r rune) rune {
+		if drop(r) {
+			return -1
+		}
+		return r
+	}
+}
+
+// stripZeroWidth removes zero-width characters, except a zero-width joiner
+// between two emoji, which combines them into one, e.g. a family emoji.
+func stripZeroWidth(text string) string {
+	var sb strings.Builder
+	sb.Grow(len(text))
+	prev := rune(-1)
+	for i, r := range text {
+		if r == zeroWidthJoiner && isEmojiPart(prev) {
+			if next, _ := utf8.DecodeRuneInString(text[i+utf8.RuneLen(r):]); unicode.Is(unicode.So, next) {
+				sb.WriteRune(r)
+				prev = r
+				continue
+			}
+		}
+		if !isZeroWidth(r) {
+			sb.WriteRune(r)
+		}
+		prev = r
+	}
+	return sb.String()
+}
+
+// isEmojiPart reports whether r can end an emoji: a symbol, a skin tone
+// modifier or the variation selector requesting emoji presentation.
+func isEmojiPart(r rune) bool {
+	return r == '\uFE0F' || unicode.Is(unicode.So, r) || unicode.Is(unicode.Sk, r)
+}
+
+// replaceNBSP replaces non-breaking spaces, including the narrow and figure
+// spaces, with ordinary spaces.
+func replaceNBSP(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '\u00A0', '\u202F', '\u2007':
+			return ' '
+		}
+		return r
+	}, text)
+}
@@ -0,0 +1,33 @@
+// Package activewindow tells which application window has the keyboard
+// focus, so profiles can leave certain applications alone.
+package activewindow
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnsupported is returned by Current where the focused window can't be
+// found out, e.g. on Wayland desktops without a compositor tool for it.
+var ErrUnsupported = errors.New("the focused window can't be detected on this system")
+
+// Window is the focused window.
+type Window struct {
+	Process string // Name of the program's executable, e.g. "KeePassXC.exe" or "keepassxc"
+	Title   string
+}
+
+// Current returns the focused window.
+func Current() (Window, error) {
+	return current()
+}
+
+// processName returns the file name of an executable path.
+func processName(path string) string {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return ""
+	}
+	return filepath.Base(strings.ReplaceAll(path, `\`, "/"))
+}
@@ -0,0 +1,32 @@
+//go:build darwin
+// +build darwin
+
+package activewindow
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// frontmostScript prints the frontmost application and the title of its
+// front window, which System Events only tells with the Accessibility
+// permission.
+const frontmostScript = `tell application "System Events"
+set frontApp to first application process whose frontmost is true
+set appName to name of frontApp
+set windowTitle to ""
+try
+set windowTitle to name of front window of frontApp
+end try
+return appName & linefeed & windowTitle
+end tell`
+
+func current() (Window, error) {
+	output, err := exec.Command("osascript", "-e", frontmostScript).Output()
+	if err != nil {
+		return Window{}, fmt.Errorf("osascript failed: %w", err)
+	}
+	name, title, _ := strings.Cut(strings.TrimRight(string(output), "\n"), "\n")
+	return Window{Process: strings.TrimSpace(name), Title: strings.TrimSpace(title)}, nil
+}
@@ -0,0 +1,113 @@
+//go:build !windows && !darwin
+// +build !windows,!darwin
+
+package activewindow
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// current asks the compositor on Hyprland and Sway, and xdotool on X11 and
+// in XWayland. Other Wayland desktops don't tell which window has the focus.
+func current() (Window, error) {
+	switch {
+	case os.Getenv("HYPRLAND_INSTANCE_SIGNATURE") != "":
+		return hyprlandWindow()
+	case os.Getenv("SWAYSOCK") != "":
+		return swayWindow()
+	case os.Getenv("DISPLAY") != "" && os.Getenv("XDG_SESSION_TYPE") != "wayland":
+		return x11Window()
+	}
+	return Window{}, ErrUnsupported
+}
+
+// x11Window reads the active window with xdotool, and its process from /proc.
+func x11Window() (Window, error) {
+	title, err := exec.Command("xdotool", "getactivewindow", "getwindowname").Output()
+	if err != nil {
+		return Window{}, fmt.Errorf("xdotool failed: %w", err)
+	}
+	window := Window{Title: strings.TrimSpace(string(title))}
+	if pid, err := exec.Command("xdotool", "getactivewindow", "getwindowpid").Output(); err == nil {
+		window.Process = pidProcess(strings.TrimSpace(string(pid)))
+	}
+	return window, nil
+}
+
+// hyprlandWindow asks hyprctl for the active window.
+func hyprlandWindow() (Window, error) {
+	output, err := exec.Command("hyprctl", "activewindow", "-j").Output()
+	if err != nil {
+		return Window{}, fmt.Errorf("hyprctl failed: %w", err)
+	}
+	var active struct {
+		Title string `json:"title"`
+		PID   int    `json:"pid"`
+	}
+	if err := json.Unmarshal(output, &active); err != nil {
+		return Window{}, fmt.Errorf("unexpected hyprctl output: %w", err)
+	}
+	return Window{Process: pidProcess(strconv.Itoa(active.PID)), Title: active.Title}, nil
+}
+
+// swayNode is the part of a node in the tree of "swaymsg -t get_tree" that
+// is needed to find the focused window.
+type swayNode struct {
+	Name          string     `json:"name"`
+	PID           int        `json:"pid"`
+	Focused       bool       `json:"focused"`
+	Nodes         []swayNode `json:"nodes"`
+	FloatingNodes []swayNode `json:"floating_nodes"`
+}
+
+// swayWindow searches the tree of swaymsg for the focused window.
+func swayWindow() (Window, error) {
+	output, err := exec.Command("swaymsg", "-t", "get_tree").Output()
+	if err != nil {
+		return Window{}, fmt.Errorf("swaymsg failed: %w", err)
+	}
+	var root swayNode
+	if err := json.Unmarshal(output, &root); err != nil {
+		return Window{}, fmt.Errorf("unexpected swaymsg output: %w", err)
+	}
+	var find func(node swayNode) *swayNode
+	find = func(node swayNode) *swayNode {
+		if node.Focused && node.PID != 0 {
+			return &node
+		}
+		for _, children := range [][]swayNode{node.Nodes, node.FloatingNodes} {
+			for _, child := range children {
+				if found := find(child); found != nil {
+					return found
+				}
+			}
+		}
+		return nil
+	}
+	focused := find(root)
+	if focused == nil {
+		return Window{}, fmt.Errorf("no window has the focus")
+	}
+	return Window{Process: pidProcess(strconv.Itoa(focused.PID)), Title: focused.Name}, nil
+}
+
+// pidProcess returns the executable name of process pid, or "" if it is
+// unknown.
+func pidProcess(pid string) string {
+	if pid == "" || pid == "0" {
+		return ""
+	}
+	if exe, err := os.Readlink("/proc/" + pid + "/exe"); err == nil {
+		return processName(exe)
+	}
+	comm, err := os.ReadFile("/proc/" + pid + "/comm")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(comm))
+}
@@ -0,0 +1,53 @@
+//go:build windows
+// +build windows
+
+package activewindow
+
+import (
+	"errors"
+	"syscall"
+	"unsafe"
+)
+
+const processQueryLimitedInformation = 0x1000
+
+var (
+	user32                         = syscall.NewLazyDLL("user32.dll")
+	procGetForegroundWindow        = user32.NewProc("GetForegroundWindow")
+	procGetWindowTextW             = user32.NewProc("GetWindowTextW")
+	procGetWindowThreadProcessID   = user32.NewProc("GetWindowThreadProcessId")
+	kernel32                       = syscall.NewLazyDLL("kernel32.dll")
+	procQueryFullProcessImageNameW = kernel32.NewProc("QueryFullProcessImageNameW")
+)
+
+// current asks the window manager for the foreground window and the image
+// of its process.
+func current() (Window, error) {
+	hwnd, _, _ := procGetForegroundWindow.Call()
+	if hwnd == 0 {
+		return Window{}, errors.New("no window has the focus")
+	}
+
+	var window Window
+	title := make([]uint16, 512)
+	if n, _, _ := procGetWindowTextW.Call(hwnd, uintptr(unsafe.Pointer(&title[0])), uintptr(len(title))); n > 0 {
+		window.Title = syscall.UTF16ToString(title[:n])
+	}
+
+	var pid uint32
+	procGetWindowThreadProcessID.Call(hwnd, uintptr(unsafe.Pointer(&pid)))
+	if pid == 0 {
+		return window, nil
+	}
+	process, err := syscall.OpenProcess(processQueryLimitedInformation, false, pid)
+	if err != nil {
+		return window, nil // Elevated processes can't be opened; the title still tells
+	}
+	defer syscall.CloseHandle(process)
+	image := make([]uint16, syscall.MAX_PATH)
+	size := uint32(len(image))
+	if ok, _, _ := procQueryFullProcessImageNameW.Call(uintptr(process), 0, uintptr(unsafe.Pointer(&image[0])), uintptr(unsafe.Pointer(&size))); ok != 0 {
+		window.Process = processName(syscall.UTF16ToString(image[:size]))
+	}
+	return window, nil
+}
@@ -2,6 +2,7 @@
 package app
 
 import (
+	"context"
 	"errors" // Needed for zenity error checking
 	"fmt"    // Needed for Sprintf
 	"log"
@@ -9,12 +10,20 @@ import (
 	"path/filepath"
 	"regexp" // <-- Import regexp
 	"strings"
+	"sync"
+	"sync/atomic"
 	//"time" // <-- Import time (Needed again for profile name generation)
 
+	"github.com/TanaroSch/clipboard-regex-replace/internal/audit"
 	"github.com/TanaroSch/clipboard-regex-replace/internal/clipboard"
 	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/history"
 	"github.com/TanaroSch/clipboard-regex-replace/internal/hotkey"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/importer"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/ipc"
 	"github.com/TanaroSch/clipboard-regex-replace/internal/resources"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/stats"
 	"github.com/TanaroSch/clipboard-regex-replace/internal/ui"
 	"github.com/ncruces/zenity" // Zenity import
 )
@@ -26,7 +35,18 @@ type Application struct {
 	clipboardManager *clipboard.Manager
 	hotkeyManager    *hotkey.Manager
 	systrayManager   *ui.SystrayManager
+	statistics       *stats.Store
+	history          *history.Store // Encrypted clipboard history (nil while disabled)
+	auditLog         *audit.Log     // Content-free log of transformations (nil while disabled)
+	scheduler        *profileScheduler
+	updates          *updateChecker
+	watcher          *clipboardWatcher // Processes copied text where hotkeys are unavailable
 	iconData         []byte
+	processing       atomic.Bool // Set while a hotkey press is processed
+	paused           atomic.Bool // Hotkeys and triggers are ignored, see ipc.go
+	commands         *ipc.Server // Command socket for "clipregex trigger" etc.; nil if it failed to start
+	cancelMu         sync.Mutex
+	cancelProcessing context.CancelFunc // Stops the hotkey press being processed; nil while idle
 }
 
 // New creates a new application instance
@@ -46,9 +66,33 @@ func New(cfg *config.Config, version string) *Application {
 
 	// Pass config reference and resolved secrets map to clipboard manager
 	app.clipboardManager = clipboard.NewManager(cfg, cfg.GetResolvedSecrets(), app.onRevertStatusChange)
+	app.clipboardManager.LoadPlugins()
+
+	// Per-rule statistics live next to config.json
+	statsPath := stats.PathForConfig(cfg.GetConfigPath())
+	app.statistics, err = stats.Load(statsPath)
+	if err != nil {
+		log.Printf("Warning: %v. Starting with empty statistics.", err)
+		app.statistics = stats.NewStore(statsPath)
+	}
+	app.applyStatsRollover()
+	app.clipboardManager.SetStatistics(app.statistics)
+	app.clipboardManager.SetOversizeConfirmation(app.confirmOversize)
+
+	// Missing paste permissions or tools are explained once
+	clipboard.SetPasteProblemHandler(app.onPasteProblem)
+
+	// Profiles with a schedule are switched on and off automatically
+	app.scheduler = newProfileScheduler(app)
+
+	// New releases are looked up in the background
+	app.updates = newUpdateChecker(app)
+
+	// Copied text is processed right away where hotkeys can't be pressed
+	app.watcher = newClipboardWatcher(app)
 
 	// Pass config reference to hotkey manager
-	app.hotkeyManager = hotkey.NewManager(cfg, app.onHotkeyTriggered, app.onRevertHotkey)
+	app.hotkeyManager = hotkey.NewManager(cfg, app.onHotkeyTriggered, app.onRevertHotkey, app.onTypeHotkey, app.onCycleProfileGroup, app.onClearHotkey, app.onUndoHotkey, app.onRedoHotkey)
 
 	// Add secret management and simple rule callbacks to systray manager
 	app.systrayManager = ui.NewSystrayManager(
@@ -64,34 +108,265 @@ func New(cfg *config.Config, version string) *Application {
 		app.onAddSecret,
 		app.onListSecrets,
 		app.onRemoveSecret,
+		app.onImportSecrets,
+		app.onExportSecrets,
+		app.onAuditSecrets,
 		app.onAddSimpleRule, // <-- Pass the new callback
+		app.onOpenRuleEditor,
+		app.onOpenPlayground,
+		app.onExportProfile,
+		app.onImportProfile,
+		app.onImportExternalRules,
+		app.onShowHotkeyStatus,
+		app.onShowStatistics,
+		app.onRunRuleTests,
+		app.onRestorePreviousConfig,
+		app.onCheckForUpdates,
+		app.onClearHistory,
+		app.onExportStatistics,
+		app.onRunDiagnostics,
+		app.onTogglePause,
+		app.onExplainHotkey,
+		app.onRestoreUndoState,
+		app.onRunProfile,
 	)
+	app.clipboardManager.SetUndoListener(app.onUndoChange)
+	ui.SetHotkeyRecorder(app.checkRecordedHotkey, app.suspendHotkeys)
+
+	// The clipboard history survives restarts if enabled
+	app.applyHistoryConfig(true)
+	app.applyAuditConfig()
 
 	return app
 }
 
 // Run starts the application
 func (a *Application) Run() {
-	if err := a.hotkeyManager.RegisterAll(); err != nil {
-		errMsg := fmt.Sprintf("Some hotkeys could not be registered: %v", err)
-		log.Printf("Warning: Failed to register some hotkeys: %v", err)
-		ui.ShowAdminNotification(ui.LevelWarn, "Hotkey Registration Issue", errMsg) // <<< CHANGED
-	}
+	a.scheduler.start()
+	a.updates.start()
+	clipboard.CheckPasteSupport()
+	a.registerHotkeys()
+	a.startCommands()
+	a.applyClipboardWatch(true)
+	a.reportSecretStore()
+	ui.UpdateJumpList(a.config)
 	// Start the systray manager (blocking call)
 	a.systrayManager.Run()
 }
 
+// registerHotkeys registers all hotkeys, reports problems in a notification and
+// the tray, and returns true if every hotkey works.
+func (a *Application) registerHotkeys() bool {
+	err := a.hotkeyManager.RegisterAll()
+
+	problemCount := 0
+	for _, status := range a.hotkeyManager.Status() {
+		if !status.OK() {
+			problemCount++
+		}
+	}
+	if a.systrayManager != nil {
+		a.systrayManager.UpdateHotkeyStatus(problemCount)
+	}
+
+	if err != nil {
+		log.Printf("Warning: Hotkey registration problems: %v", err)
+		errMsg := i18n.Tf("%d hotkey(s) are not working as configured. Open 'Hotkey Status' in the tray menu for details.", problemCount)
+		ui.ShowAdminNotification(ui.LevelWarn, "Hotkey Registration Issue", errMsg)
+		return false
+	}
+	return true
+}
+
+// reregisterHotkeys replaces the hotkey manager with one for the current
+// config. The old registrations must be released first, otherwise the new
+// ones collide with them.
+func (a *Application) reregisterHotkeys() bool {
+	if a.hotkeyManager != nil {
+		a.hotkeyManager.UnregisterAll()
+	}
+	a.hotkeyManager = hotkey.NewManager(a.config, a.onHotkeyTriggered, a.onRevertHotkey, a.onTypeHotkey, a.onCycleProfileGroup, a.onClearHotkey, a.onUndoHotkey, a.onRedoHotkey)
+	return a.registerHotkeys()
+}
+
+// onShowHotkeyStatus shows the registration state of every hotkey.
+func (a *Application) onShowHotkeyStatus() {
+	log.Println("Hotkey Status menu item clicked.")
+
+	statuses := a.hotkeyManager.Status()
+	if len(statuses) == 0 {
+		zenity.Info(i18n.T("No hotkeys are configured for enabled profiles."), zenity.Title(config.DefaultKeyringService+" - "+i18n.T("Hotkey Status")), zenity.InfoIcon)
+		return
+	}
+
+	var lines []string
+	problems := 0
+	for _, status := range statuses {
+		mark := "✓"
+		if !status.OK() {
+			mark = "✗"
+			problems++
+		}
+		lines = append(lines, fmt.Sprintf("%s %s — %s", mark, status.Hotkey, strings.Join(status.Bindings, ", ")))
+		if status.Error != "" {
+			lines = append(lines, i18n.Tf("      Registration failed (in use by another application?): %s", status.Error))
+		}
+		for _, conflict := range status.Conflicts {
+			lines = append(lines, "      Conflict: "+conflict)
+		}
+		if status.Shared && status.OK() {
+			lines = append(lines, "      Shared: all of these profiles run together.")
+		}
+	}
+
+	summary := i18n.T("All hotkeys are registered.")
+	icon := zenity.InfoIcon
+	if problems > 0 {
+		summary = i18n.Tf("%d hotkey(s) have problems.", problems)
+		icon = zenity.WarningIcon
+	}
+	log.Printf("Hotkey status:\n%s", strings.Join(lines, "\n"))
+	zenity.Info(summary+"\n\n"+strings.Join(lines, "\n"), zenity.Title(config.DefaultKeyringService+" - "+i18n.T("Hotkey Status")), icon)
+}
+
 // onHotkeyTriggered is called when a hotkey is pressed
 func (a *Application) onHotkeyTriggered(hotkeyStr string, isReverse bool) {
+	if a.paused.Load() {
+		log.Printf("Hotkey '%s' ignored: paused.", hotkeyStr)
+		return
+	}
+	// Processing runs on its own goroutine so a large clipboard doesn't block
+	// the hotkey listener. Presses during a run are dropped, not queued.
+	if !a.startProcessing(hotkeyStr, "", isReverse) {
+		log.Printf("Hotkey '%s' ignored: the previous clipboard is still being processed.", hotkeyStr)
+	}
+}
+
+// onRunProfile is called when "Run Now" of a profile is clicked in the tray
+// menu. Like 'clipregex trigger', it runs the profile whether or not it is
+// enabled or has a hotkey.
+func (a *Application) onRunProfile(name string, isReverse bool) {
+	if a.paused.Load() {
+		ui.ShowAdminNotification(ui.LevelInfo, "Paused", "Uncheck 'Pause Hotkeys' in the tray menu to run profiles.")
+		return
+	}
+	log.Printf("Running profile '%s' (reverse=%t) from the tray menu.", name, isReverse)
+	if !a.startProcessing("", name, isReverse) {
+		ui.ShowAdminNotification(ui.LevelInfo, "Busy", "The previous clipboard is still being processed.")
+	}
+}
+
+// startProcessing runs processClipboard on its own goroutine unless a run is
+// already in progress, in which case it returns false.
+func (a *Application) startProcessing(hotkeyStr, profileName string, isReverse bool) bool {
+	if !a.processing.CompareAndSwap(false, true) {
+		return false
+	}
+	go func() {
+		defer a.processing.Store(false)
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("RECOVERED FROM PANIC WHILE PROCESSING CLIPBOARD: %v", r)
+			}
+		}()
+		a.processClipboard(hotkeyStr, profileName, isReverse)
+	}()
+	return true
+}
+
+// processClipboard applies the profiles of a hotkey, or those named
+// profileName if it is set, to the clipboard and shows the resulting
+// notifications. It returns the replacement message and whether the
+// clipboard changed.
+func (a *Application) processClipboard(hotkeyStr, profileName string, isReverse bool) (string, bool) {
+	if a.systrayManager != nil {
+		a.systrayManager.SetProcessing(true)
+		defer a.systrayManager.SetProcessing(false)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancelMu.Lock()
+	a.cancelProcessing = cancel
+	a.cancelMu.Unlock()
+	defer func() {
+		a.cancelMu.Lock()
+		a.cancelProcessing = nil
+		a.cancelMu.Unlock()
+		cancel()
+	}()
+
 	// clipboardManager uses its internal config reference and resolved secrets
-	message, changedForDiff := a.clipboardManager.ProcessClipboard(hotkeyStr, isReverse)
+	var message string
+	var changedForDiff bool
+	if profileName != "" {
+		message, changedForDiff = a.clipboardManager.ProcessProfile(ctx, profileName, isReverse)
+	} else {
+		message, changedForDiff = a.clipboardManager.ProcessClipboard(ctx, hotkeyStr, isReverse)
+	}
+	if reason := a.clipboardManager.LastSkipReason(); reason != "" {
+		ui.ShowAdminNotification(ui.LevelInfo, "Clipboard Not Processed", reason)
+	}
+	if actionErrors := a.clipboardManager.LastActionErrors(); len(actionErrors) > 0 {
+		ui.ShowAdminNotification(ui.LevelWarn, "Transformation Skipped", strings.Join(actionErrors, "\n"))
+	}
+	if overruns := a.clipboardManager.LastBudgetOverruns(); len(overruns) > 0 {
+		ui.ShowAdminNotification(ui.LevelWarn, "Time Budget Exceeded", strings.Join(overruns, "\n"))
+	}
+	if reports := a.clipboardManager.LastTransformerReports(); len(reports) > 0 {
+		ui.ShowAdminNotification(ui.LevelInfo, "Transformer Report", strings.Join(reports, "\n"))
+	}
 	if message != "" {
 		// This is the specific replacement notification
-		ui.ShowReplacementNotification("Clipboard Updated", message) // <<< CHANGED
+		ui.ShowReplacementNotificationWithActions("Clipboard Updated", message, a.clipboardManager.LastReplacementCount(), a.replacementActions(changedForDiff))
 	}
+	a.playHotkeySound(hotkeyStr, profileName, isReverse, a.hotkeySoundEvent(message, changedForDiff))
 	if a.systrayManager != nil {
 		a.systrayManager.UpdateViewLastDiffStatus(changedForDiff)
 	}
+	return message, changedForDiff
+}
+
+// stopProcessing cancels the hotkey press being processed, if any. The
+// clipboard is left unchanged.
+func (a *Application) stopProcessing() {
+	a.cancelMu.Lock()
+	defer a.cancelMu.Unlock()
+	if a.cancelProcessing != nil {
+		log.Println("Canceling the clipboard processing in progress.")
+		a.cancelProcessing()
+	}
+}
+
+// replacementActions builds the "Undo" and "View changes" buttons for the
+// notification shown after a replacement.
+func (a *Application) replacementActions(changedForDiff bool) ui.ReplacementActions {
+	var actions ui.ReplacementActions
+	if changedForDiff {
+		if original, modified, ok := a.clipboardManager.GetLastDiff(); ok {
+			actions.ShowDiff = true
+			actions.Original = original
+			actions.Modified = modified
+			actions.ContextLines = a.config.GetDiffContextLines()
+			actions.Granularity = a.config.GetDiffGranularity()
+		}
+	}
+	// Undo is only possible while the original is kept and not reverted automatically
+	if a.clipboardManager.CanUndo() {
+		actions.Undo = a.undoFromNotification
+	}
+	return actions
+}
+
+// undoFromNotification reverts the clipboard for the notification's Undo
+// button and returns the message shown to the user.
+func (a *Application) undoFromNotification() string {
+	if !a.clipboardManager.RestoreOriginalClipboard() {
+		return "Nothing to undo: the original clipboard content is no longer available."
+	}
+	log.Println("Clipboard reverted from notification action.")
+	if a.systrayManager != nil {
+		a.systrayManager.UpdateViewLastDiffStatus(false)
+	}
+	return "The original clipboard content has been restored."
 }
 
 // onViewLastDiffTriggered is called when the "View Last Change Details" menu item is clicked
@@ -107,7 +382,7 @@ func (a *Application) onViewLastDiffTriggered() {
 	}
 	log.Println("View Last Change Details clicked, showing diff viewer.")
 	contextLines := a.config.GetDiffContextLines()
-	ui.ShowDiffViewer(original, modified, contextLines)
+	ui.ShowDiffViewer(original, modified, contextLines, a.config.GetDiffGranularity())
 }
 
 // onRevertHotkey is called when the revert hotkey is pressed
@@ -122,6 +397,31 @@ func (a *Application) onRevertHotkey() {
 	}
 }
 
+// onTypeHotkey is called when the type hotkey is pressed
+func (a *Application) onTypeHotkey() {
+	if a.systrayManager != nil {
+		a.systrayManager.SetProcessing(true)
+		defer a.systrayManager.SetProcessing(false)
+	}
+	if err := a.clipboardManager.TypeClipboard(); err != nil {
+		log.Printf("Failed to type clipboard content: %v", err)
+		ui.ShowAdminNotification(ui.LevelWarn, "Typing Failed", i18n.Tf("Could not type clipboard content: %v", err))
+	}
+}
+
+// onClearHotkey is called when the clear hotkey is pressed
+func (a *Application) onClearHotkey() {
+	if err := a.clipboardManager.ClearClipboard(); err != nil {
+		log.Printf("Failed to clear the clipboard: %v", err)
+		ui.ShowAdminNotification(ui.LevelWarn, "Clearing Failed", i18n.Tf("Could not clear the clipboard: %v", err))
+		return
+	}
+	ui.ShowAdminNotification(ui.LevelInfo, "Clipboard Cleared", "The clipboard is empty, and the stored original was forgotten.")
+	if a.systrayManager != nil {
+		a.systrayManager.UpdateViewLastDiffStatus(false)
+	}
+}
+
 // onRevertMenuItem is called when the revert menu item is clicked
 func (a *Application) onRevertMenuItem() {
 	a.onRevertHotkey()
@@ -137,6 +437,7 @@ func (a *Application) onRevertStatusChange(canRevert bool) {
 // onReloadConfig is called when the reload config menu item is clicked or triggered internally
 func (a *Application) onReloadConfig() {
 	log.Println("Reloading configuration and secrets...")
+	a.stopProcessing() // Its rules may have changed
 
 	// --- Preserve state across reload ---
 	enabledStatus := make(map[string]bool)
@@ -158,7 +459,7 @@ func (a *Application) onReloadConfig() {
 		configPath = "config.json"
 		log.Printf("Current config path is empty, attempting reload from default '%s'.", configPath)
 		if _, errStat := os.Stat(configPath); os.IsNotExist(errStat) {
-			errMsg := fmt.Sprintf("Config file '%s' not found.", configPath)
+			errMsg := i18n.Tf("Config file '%s' not found.", configPath)
 			log.Printf("Cannot reload config: %s", errMsg)
 			ui.ShowAdminNotification(ui.LevelError, "Configuration Error", errMsg) // <<< CHANGED (Error level)
 			return
@@ -167,7 +468,7 @@ func (a *Application) onReloadConfig() {
 
 	newConfig, err := config.Load(configPath)
 	if err != nil {
-		errMsg := fmt.Sprintf("Failed to reload configuration. Check %s and keychain access. Error: %v", configPath, err)
+		errMsg := i18n.Tf("Failed to reload configuration. Check %s and keychain access, or use 'Restore Previous Config'. Error: %v", configPath, err)
 		log.Printf("Error reloading configuration from '%s': %v", configPath, err)
 		ui.ShowAdminNotification(ui.LevelError, "Configuration Error", errMsg) // <<< CHANGED (Error level)
 		return
@@ -187,6 +488,7 @@ func (a *Application) onReloadConfig() {
 				log.Printf("Profile '%s' is new or renamed, keeping its default enabled status (%t)", profileName, a.config.Profiles[i].Enabled)
 			}
 		}
+		a.config.NormalizeProfileGroups() // A profile may have moved into a group
 	}
 
 	// Detect significant profile structure changes (additions/removals)
@@ -217,14 +519,11 @@ func (a *Application) onReloadConfig() {
 	log.Println("Configuration and secrets reloaded successfully.")
 
 	// Re-register hotkeys based on the new config
-	a.hotkeyManager = hotkey.NewManager(a.config, a.onHotkeyTriggered, a.onRevertHotkey)
-	if err := a.hotkeyManager.RegisterAll(); err != nil {
-		errMsg := fmt.Sprintf("Some hotkeys could not be registered after reload: %v", err)
-		log.Printf("Warning: Failed to register some hotkeys after reload: %v", err)
-		ui.ShowAdminNotification(ui.LevelWarn, "Hotkey Registration Issue", errMsg) // <<< CHANGED (Warn level)
-	} else {
+	if a.reregisterHotkeys() {
 		log.Println("Hotkeys re-registered successfully after config reload.")
 	}
+	a.applyClipboardWatch(false)
+	a.reportSecretStore()
 
 	// Update clipboard manager with new secrets and config reference
 	if a.clipboardManager != nil {
@@ -234,14 +533,23 @@ func (a *Application) onReloadConfig() {
 		// Should not happen normally, but handle defensively
 		a.clipboardManager = clipboard.NewManager(a.config, a.config.GetResolvedSecrets(), a.onRevertStatusChange)
 	}
+	a.clipboardManager.LoadPlugins() // Plugins may have been added or removed
+	a.applyHistoryConfig(false)
+	a.applyAuditConfig()
+	a.applyStatsRollover()
+
+	// Notification settings (levels, throttle window, language) come from the new config as well
+	ApplyLanguage(a.config)
+	ui.UpdateNotificationConfig(a.config)
+	ui.UpdateJumpList(a.config) // Profiles and the language may have changed
 
 	// Update systray manager with the new config reference
 	if a.systrayManager != nil {
 		a.systrayManager.UpdateConfig(a.config) // Update systray internal config ref
 		if profileStructureChanged {
-			msg := "Profile structure changed. Please use 'Restart Application' via menu to fully refresh UI."
-			log.Println("Profile structure changed significantly. Restarting application is recommended for full menu update.")
-			ui.ShowAdminNotification(ui.LevelWarn, "Configuration Reloaded", msg) // <<< CHANGED (Warn level)
+			msg := "Profiles were added, removed or renamed. The Profiles menu and hotkeys have been updated."
+			log.Println("Profile structure changed; the Profiles menu was rebuilt.")
+			ui.ShowAdminNotification(ui.LevelInfo, "Configuration Reloaded", msg)
 		} else {
 			msg := "Configuration and secrets updated successfully. Hotkeys have been refreshed."
 			ui.ShowAdminNotification(ui.LevelInfo, "Configuration Reloaded", msg) // <<< CHANGED (Info level)
@@ -252,17 +560,144 @@ func (a *Application) onReloadConfig() {
 	}
 }
 
+// onRestorePreviousConfig is called when the "Restore Previous Config" menu item is clicked.
+// It replaces config.json with its newest differing backup and reloads it.
+func (a *Application) onRestorePreviousConfig() {
+	configPath := ""
+	keep := config.DefaultConfigBackups
+	if a.config != nil {
+		configPath = a.config.GetConfigPath()
+		keep = a.config.GetConfigBackups()
+	}
+	if configPath == "" {
+		configPath = "config.json"
+	}
+
+	backups, err := config.ListBackups(configPath)
+	if err != nil || len(backups) == 0 {
+		if err != nil {
+			log.Printf("Error listing config backups: %v", err)
+		}
+		ui.ShowAdminNotification(ui.LevelWarn, "No Config Backup", i18n.Tf("There is no backup in '%s' to restore.", config.BackupDir(configPath)))
+		return
+	}
+
+	appName := config.DefaultKeyringService
+	err = zenity.Question(
+		i18n.Tf("Replace the current configuration with the previous version from the backups folder?\n\nThe current config.json is backed up first, so this can be undone the same way.\n\nBackups: %s", config.BackupDir(configPath)),
+		zenity.Title(appName+" - "+i18n.T("Restore Previous Config")),
+		zenity.QuestionIcon,
+		zenity.OKLabel(i18n.T("Restore")),
+		zenity.CancelLabel(i18n.T("Cancel")),
+	)
+	if err != nil {
+		if !errors.Is(err, zenity.ErrCanceled) {
+			log.Printf("Error displaying restore confirmation dialog: %v", err)
+			ui.ShowAdminNotification(ui.LevelWarn, "Dialog Error", "Failed to show confirmation dialog.")
+		}
+		return
+	}
+
+	backup, err := config.RestorePreviousBackup(configPath, keep)
+	if err != nil {
+		if errors.Is(err, config.ErrNoBackup) {
+			ui.ShowAdminNotification(ui.LevelWarn, "No Config Backup", "All backups match the current configuration.")
+			return
+		}
+		log.Printf("Error restoring config backup: %v", err)
+		ui.ShowAdminNotification(ui.LevelError, "Restore Failed", err.Error())
+		return
+	}
+
+	ui.ShowAdminNotification(ui.LevelInfo, "Config Restored", i18n.Tf("Restored %s.", filepath.Base(backup)))
+	a.onReloadConfig()
+}
+
 // onRestartApplication is called when the restart application menu item is clicked
 func (a *Application) onRestartApplication() {
+	if a.statistics != nil {
+		if err := a.statistics.Close(); err != nil {
+			log.Printf("Error saving statistics before restart: %v", err)
+		}
+	}
+	a.stopCommands()
 	ui.RestartApplication()
 }
 
 // onQuit is called when the quit menu item is clicked
 func (a *Application) onQuit() {
 	log.Println("Quit requested. Unregistering hotkeys.")
+	a.stopProcessing()
+	a.stopCommands()
+	if a.scheduler != nil {
+		a.scheduler.close()
+	}
+	if a.updates != nil {
+		a.updates.close()
+	}
+	if a.watcher != nil {
+		a.watcher.close()
+	}
 	if a.hotkeyManager != nil {
 		a.hotkeyManager.UnregisterAll()
 	}
+	if a.clipboardManager != nil {
+		a.clipboardManager.ClosePlugins()
+	}
+	if a.statistics != nil {
+		if err := a.statistics.Close(); err != nil {
+			log.Printf("Error saving statistics: %v", err)
+		}
+	}
+}
+
+// onShowStatistics is called when the "Statistics..." menu item is clicked
+func (a *Application) onShowStatistics() {
+	err := ui.ShowStatistics(ui.StatisticsCallbacks{
+		Snapshot: a.statistics.Snapshot,
+		Profiles: func() []config.ProfileConfig {
+			return a.config.ResolvedProfiles() // a.config is replaced on reload
+		},
+		Reset: a.statistics.Reset,
+	})
+	if err != nil {
+		log.Printf("Error opening statistics: %v", err)
+		ui.ShowAdminNotification(ui.LevelError, "Statistics Error", i18n.Tf("Could not open the statistics window: %v", err))
+	}
+}
+
+// onRunRuleTests is called when the "Run Rule Tests" menu item is clicked. It
+// runs the test cases embedded in the rules and lists the failures.
+func (a *Application) onRunRuleTests() {
+	results := a.clipboardManager.RunRuleTests(a.config.ResolvedProfiles())
+	title := zenity.Title(config.DefaultKeyringService + " - " + i18n.T("Rule Tests"))
+	if len(results) == 0 {
+		zenity.Info(i18n.T("No rule has test cases yet.\n\nAdd \"tests\": [{\"input\": \"...\", \"expect\": \"...\"}] to a rule in config.json."), title, zenity.InfoIcon)
+		return
+	}
+
+	var lines []string
+	passed := 0
+	for _, result := range results {
+		if result.Passed() {
+			passed++
+			continue
+		}
+		lines = append(lines, "✗ "+result.String())
+	}
+	summary := i18n.Tf("%d of %d rule test(s) passed.", passed, len(results))
+	log.Printf("Rule tests: %s", summary)
+	if len(lines) == 0 {
+		zenity.Info(summary, title, zenity.InfoIcon)
+		return
+	}
+
+	log.Printf("Rule test failures:\n%s", strings.Join(lines, "\n"))
+	const maxLines = 20
+	if len(lines) > maxLines {
+		lines = append(lines[:maxLines], i18n.Tf("... and %d more (see the log).", len(lines)-maxLines))
+	}
+	zenity.Info(summary+"\n\n"+strings.Join(lines, "\n"), title, zenity.WarningIcon)
 }
 
 // onOpenConfigFile is called when the open config menu item is clicked
@@ -287,12 +722,12 @@ func (a *Application) onOpenConfigFile() {
 	}
 
 	if _, err := os.Stat(absPath); os.IsNotExist(err) {
-		errMsg := fmt.Sprintf("Config file not found: %s", absPath)
+		errMsg := i18n.Tf("Config file not found: %s", absPath)
 		log.Printf("Error: %s", errMsg)
 		ui.ShowAdminNotification(ui.LevelWarn, "Error Opening File", errMsg) // <<< CHANGED (Warn level)
 		return
 	} else if err != nil {
-		errMsg := fmt.Sprintf("Error checking config file '%s': %v", absPath, err)
+		errMsg := i18n.Tf("Error checking config file '%s': %v", absPath, err)
 		log.Printf("Error checking config file status at path '%s': %v", absPath, err)
 		ui.ShowAdminNotification(ui.LevelWarn, "Error Opening File", errMsg) // <<< CHANGED (Warn level)
 		return
@@ -303,7 +738,7 @@ func (a *Application) onOpenConfigFile() {
 	log.Printf("Attempting to open config file using ui.OpenFileInDefaultApp with path: %s", absPath)
 	err = ui.OpenFileInDefaultApp(absPath)
 	if err != nil {
-		errMsg := fmt.Sprintf("Could not open config file '%s': %v", absPath, err)
+		errMsg := i18n.Tf("Could not open config file '%s': %v", absPath, err)
 		log.Printf("Final error after trying open methods for config file '%s': %v", absPath, err)
 		ui.ShowAdminNotification(ui.LevelWarn, "Error Opening File", errMsg) // <<< CHANGED (Warn level)
 	}
@@ -317,8 +752,8 @@ func (a *Application) onAddSecret() {
 	appName := config.DefaultKeyringService
 
 	// === Step 1: Get Logical Name ===
-	name, err := zenity.Entry("Step 1: Enter Logical Name\n(e.g., my_api_key, no spaces/special chars)",
-		zenity.Title(appName+" - Add/Update Secret"),
+	name, err := zenity.Entry(i18n.T("Step 1: Enter Logical Name\n(e.g., my_api_key, no spaces/special chars)"),
+		zenity.Title(appName+" - "+i18n.T("Add/Update Secret")),
 	)
 	if err != nil {
 		if errors.Is(err, zenity.ErrCanceled) {
@@ -331,16 +766,15 @@ func (a *Application) onAddSecret() {
 		return
 	}
 	name = strings.TrimSpace(name)
-	if name == "" || strings.ContainsAny(name, " {}[]()<>|=+*?^$\\./") {
-		errMsg := fmt.Sprintf("Invalid logical name (empty or contains spaces/special chars): '%s'. Aborted.", name)
-		log.Printf("Invalid logical name entered: '%s'", name)
-		ui.ShowAdminNotification(ui.LevelWarn, "Invalid Input", errMsg) // <<< CHANGED (Warn level)
+	if err := config.ValidateSecretName(name); err != nil {
+		log.Printf("Invalid logical name entered: %v", err)
+		ui.ShowAdminNotification(ui.LevelWarn, "Invalid Input", i18n.Tf("Aborted: %v.", err))
 		return
 	}
 
 	// === Step 2: Get Secret Value ===
 	_, value, err := zenity.Password(
-		zenity.Title(appName + " - Step 2: Enter Secret Value for '"+name+"'"),
+		zenity.Title(appName+" - "+i18n.Tf("Step 2: Enter Secret Value for '%s'", name)),
 		// No Username option needed here
 	)
 	if err != nil {
@@ -367,22 +801,23 @@ func (a *Application) onAddSecret() {
 	}
 	err = a.config.AddSecretReference(name, value)
 	if err != nil {
-		errMsg := fmt.Sprintf("Failed to store secret '%s'. See logs. Error: %v", name, err)
+		errMsg := i18n.Tf("Failed to store secret '%s'. See logs. Error: %v", name, err)
 		log.Printf("Error adding/updating secret '%s': %v", name, err)
 		ui.ShowAdminNotification(ui.LevelError, "Error", errMsg) // <<< CHANGED (Error level)
 		return // Stop here if storing the secret failed
 	} else {
 		log.Printf("Secret '%s' updated in keychain and config.json.", name)
+		a.applySecrets() // Active right away, whether or not a rule is added
 		// Don't notify yet, wait until optional steps are done or skipped
 	}
 
 	// === Step 3 (Optional): Ask to add replacement rule ===
 	err = zenity.Question(
-		fmt.Sprintf("Secret '%s' stored successfully.\n\nDo you want to create a basic replacement rule for it now?\n(Replaces occurrences of the secret with entered text)", name),
-		zenity.Title(appName+" - Optional Step 3: Add Replacement Rule?"),
+		i18n.Tf("Secret '%s' stored successfully.\n\nDo you want to create a basic replacement rule for it now?\n(Replaces occurrences of the secret with entered text)", name),
+		zenity.Title(appName+" - "+i18n.T("Optional Step 3: Add Replacement Rule?")),
 		zenity.InfoIcon, // Use Info or Question icon
-		zenity.OKLabel("Yes, Add Rule"),
-		zenity.CancelLabel("No, Just Store Secret"),
+		zenity.OKLabel(i18n.T("Yes, Add Rule")),
+		zenity.CancelLabel(i18n.T("No, Just Store Secret")),
 	)
 
 	addRule := (err == nil) // User clicked "Yes" if err is nil
@@ -395,14 +830,14 @@ func (a *Application) onAddSecret() {
 
 	if !addRule { // User chose No, or dialog was canceled/errored
 		log.Println("Skipping optional replacement rule creation.")
-		ui.ShowAdminNotification(ui.LevelInfo, "Secret Stored", fmt.Sprintf("Secret '%s' stored. Manual restart required to activate.", name)) // <<< CHANGED (Info level)
+		ui.ShowAdminNotification(ui.LevelInfo, "Secret Stored", i18n.Tf("Secret '%s' stored and active.", name)) // <<< CHANGED (Info level)
 		return // Finish workflow
 	}
 
 	// === Step 4 (Optional): Get Replacement String ===
 	replaceWithString, err := zenity.Entry(
-		fmt.Sprintf("Step 4: Enter text to replace '{{%s}}' with:\n(e.g., [REDACTED_KEY], MyPlaceholder)", name), // Corrected placeholder format in prompt
-		zenity.Title(appName+" - Add Replacement Rule"),
+		i18n.Tf("Step 4: Enter text to replace '{{%s}}' with:\n(e.g., [REDACTED_KEY], MyPlaceholder)", name), // Corrected placeholder format in prompt
+		zenity.Title(appName+" - "+i18n.T("Add Replacement Rule")),
 	)
 	if err != nil {
 		// Handle cancel/error for replacement string entry
@@ -411,7 +846,7 @@ func (a *Application) onAddSecret() {
 		} else {
 			log.Printf("Error getting replacement text via zenity: %v", err)
 		}
-		ui.ShowAdminNotification(ui.LevelInfo, "Rule Creation Canceled", fmt.Sprintf("Secret '%s' stored, but rule creation canceled. Manual restart required for secret.", name)) // <<< CHANGED (Info level)
+		ui.ShowAdminNotification(ui.LevelInfo, "Rule Creation Canceled", i18n.Tf("Secret '%s' stored and active, but rule creation canceled.", name)) // <<< CHANGED (Info level)
 		return
 	}
 	// Allow empty replacement string if desired
@@ -420,7 +855,7 @@ func (a *Application) onAddSecret() {
 	if a.config.Profiles == nil || len(a.config.Profiles) == 0 {
 		log.Println("Cannot add replacement rule: No profiles found in configuration.")
 		ui.ShowAdminNotification(ui.LevelWarn, "Cannot Add Rule", "No profiles exist. Please add a profile manually first.") // <<< CHANGED (Warn level)
-		ui.ShowAdminNotification(ui.LevelInfo, "Secret Stored", fmt.Sprintf("Secret '%s' stored. Manual restart required to activate.", name)) // Remind about secret // <<< CHANGED (Info level)
+		ui.ShowAdminNotification(ui.LevelInfo, "Secret Stored", i18n.Tf("Secret '%s' stored and active.", name)) // Remind about secret // <<< CHANGED (Info level)
 		return
 	}
 
@@ -432,9 +867,9 @@ func (a *Application) onAddSecret() {
 	}
 
 	selectedProfileName, err := zenity.List(
-		"Step 5: Select Profile to add the rule to:",
+		i18n.T("Step 5: Select Profile to add the rule to:"),
 		profileNames,
-		zenity.Title(appName+" - Add Replacement Rule"),
+		zenity.Title(appName+" - "+i18n.T("Add Replacement Rule")),
 	)
 	if err != nil {
 		if errors.Is(err, zenity.ErrCanceled) {
@@ -442,12 +877,12 @@ func (a *Application) onAddSecret() {
 		} else {
 			log.Printf("Error getting profile selection via zenity list: %v", err)
 		}
-		ui.ShowAdminNotification(ui.LevelInfo, "Rule Creation Canceled", fmt.Sprintf("Secret '%s' stored, but rule creation canceled. Manual restart required for secret.", name)) // <<< CHANGED (Info level)
+		ui.ShowAdminNotification(ui.LevelInfo, "Rule Creation Canceled", i18n.Tf("Secret '%s' stored and active, but rule creation canceled.", name)) // <<< CHANGED (Info level)
 		return
 	}
 	if selectedProfileName == "" { // Should not happen if list not empty, but check
 		log.Println("Rule creation aborted: No profile selected.")
-		ui.ShowAdminNotification(ui.LevelInfo, "Rule Creation Canceled", fmt.Sprintf("Secret '%s' stored, but rule creation canceled (no profile selected). Manual restart required for secret.", name)) // <<< CHANGED (Info level)
+		ui.ShowAdminNotification(ui.LevelInfo, "Rule Creation Canceled", i18n.Tf("Secret '%s' stored and active, but rule creation canceled (no profile selected).", name)) // <<< CHANGED (Info level)
 		return
 	}
 
@@ -464,7 +899,7 @@ func (a *Application) onAddSecret() {
 	if !found { // Should be impossible if List worked correctly
 		log.Printf("Internal Error: Selected profile '%s' not found in config map.", selectedProfileName)
 		ui.ShowAdminNotification(ui.LevelError, "Internal Error", "Selected profile not found.") // <<< CHANGED (Error level)
-		ui.ShowAdminNotification(ui.LevelInfo, "Secret Stored", fmt.Sprintf("Secret '%s' stored. Manual restart required to activate.", name)) // Remind about secret // <<< CHANGED (Info level)
+		ui.ShowAdminNotification(ui.LevelInfo, "Secret Stored", i18n.Tf("Secret '%s' stored and active.", name)) // Remind about secret // <<< CHANGED (Info level)
 		return
 	}
 
@@ -475,7 +910,7 @@ func (a *Application) onAddSecret() {
 	// Save the config again with the added rule
 	err = a.config.Save()
 	if err != nil {
-		errMsg := fmt.Sprintf("Failed to save config after adding replacement rule. Error: %v", err)
+		errMsg := i18n.Tf("Failed to save config after adding replacement rule. Error: %v", err)
 		log.Printf("Error saving config after adding replacement rule for secret '%s': %v", name, err)
 		ui.ShowAdminNotification(ui.LevelError, "Save Error", errMsg) // <<< CHANGED (Error level)
 		// Attempt to roll back in-memory change
@@ -486,7 +921,7 @@ func (a *Application) onAddSecret() {
 	} else {
 		log.Printf("Successfully added replacement rule for '%s' to profile '%s' and saved config.", name, selectedProfileName)
 		// Final success notification
-		ui.ShowAdminNotification(ui.LevelInfo, "Secret & Rule Added", fmt.Sprintf("Secret '%s' stored and rule added to profile '%s'. Manual restart required to activate.", name, selectedProfileName)) // <<< CHANGED (Info level)
+		ui.ShowAdminNotification(ui.LevelInfo, "Secret & Rule Added", i18n.Tf("Secret '%s' stored and rule added to profile '%s'. Both are active.", name, selectedProfileName)) // <<< CHANGED (Info level)
 	}
 }
 
@@ -502,19 +937,23 @@ func (a *Application) onListSecrets() {
 	var message string
 	var dialogMessage string
 	if len(names) == 0 {
-		message = "No secrets are currently managed in config.json."
+		message = i18n.T("No secrets are currently managed in config.json.")
 		dialogMessage = message
 		log.Println(message)
 	} else {
-		messageBody := "- " + strings.Join(names, "\n- ")
-		logMessage := fmt.Sprintf("Managed secrets (%d total):\n%s", len(names), messageBody) // Detailed for log
+		lines := make([]string, len(names))
+		for i, name := range names {
+			lines[i] = fmt.Sprintf("- %s (%s)", name, a.config.SecretProviderName(name))
+		}
+		messageBody := strings.Join(lines, "\n")
+		logMessage := i18n.Tf("Managed secrets (%d total):\n%s", len(names), messageBody) // Detailed for log
 		dialogMessage = logMessage                                                             // Show details in dialog too
-		message = fmt.Sprintf("Found %d managed secret(s).", len(names))                       // Brief for notification
+		message = i18n.Tf("Found %d managed secret(s).", len(names))                       // Brief for notification
 		log.Printf("Listing managed secrets:\n%s", logMessage)                                 // Log details
 	}
 	// Show brief notification first, then detailed dialog
 	ui.ShowAdminNotification(ui.LevelInfo, "Managed Secrets", message) // <<< CHANGED (Info level)
-	zenity.Info(dialogMessage, zenity.Title(config.DefaultKeyringService+" - Managed Secrets"), zenity.InfoIcon)
+	zenity.Info(dialogMessage, zenity.Title(config.DefaultKeyringService+" - "+i18n.T("Managed Secrets")), zenity.InfoIcon)
 }
 
 // onRemoveSecret is called when the Remove Secret menu item is clicked
@@ -531,16 +970,16 @@ func (a *Application) onRemoveSecret() {
 	names := a.config.GetSecretNames()
 	if len(names) == 0 {
 		log.Println("No secrets to remove.")
-		msg := "No secrets are currently managed."
+		msg := i18n.T("No secrets are currently managed.")
 		ui.ShowAdminNotification(ui.LevelInfo, "Remove Secret", msg) // <<< CHANGED (Info level)
-		zenity.Info(msg, zenity.Title(appName+" - Remove Secret"), zenity.InfoIcon)
+		zenity.Info(msg, zenity.Title(appName+" - "+i18n.T("Remove Secret")), zenity.InfoIcon)
 		return
 	}
 
 	nameToRemove, err := zenity.List(
-		"Select secret to remove:",
+		i18n.T("Select secret to remove:"),
 		names,
-		zenity.Title(appName+" - Remove Secret"),
+		zenity.Title(appName+" - "+i18n.T("Remove Secret")),
 	)
 	if err != nil {
 		if errors.Is(err, zenity.ErrCanceled) {
@@ -558,12 +997,16 @@ func (a *Application) onRemoveSecret() {
 		return
 	}
 
+	question := i18n.Tf("Are you sure you want to remove the secret '%s'?\n\nThis will remove it from the OS keychain and the application's config. This cannot be undone.", nameToRemove)
+	if !a.config.IsKeyringSecret(nameToRemove) {
+		question = i18n.Tf("Are you sure you want to remove the secret '%s' from the application's config?\n\nIts value stays in %s.", nameToRemove, a.config.SecretProviderName(nameToRemove))
+	}
 	err = zenity.Question(
-		fmt.Sprintf("Are you sure you want to remove the secret '%s'?\n\nThis will remove it from the OS keychain and the application's config. This cannot be undone.", nameToRemove),
-		zenity.Title(appName+" - Confirm Removal"),
+		question,
+		zenity.Title(appName+" - "+i18n.T("Confirm Removal")),
 		zenity.WarningIcon,
-		zenity.OKLabel("Remove"),
-		zenity.CancelLabel("Cancel"),
+		zenity.OKLabel(i18n.T("Remove")),
+		zenity.CancelLabel(i18n.T("Cancel")),
 	)
 
 	confirmed := (err == nil) // Confirmed if error is nil
@@ -583,12 +1026,13 @@ func (a *Application) onRemoveSecret() {
 	// Remove Secret
 	err = a.config.RemoveSecretReference(nameToRemove)
 	if err != nil {
-		errMsg := fmt.Sprintf("Failed to remove secret '%s'. See logs. Error: %v", nameToRemove, err)
+		errMsg := i18n.Tf("Failed to remove secret '%s'. See logs. Error: %v", nameToRemove, err)
 		log.Printf("Error removing secret '%s': %v", nameToRemove, err)
 		ui.ShowAdminNotification(ui.LevelError, "Error", errMsg) // <<< CHANGED (Error level)
 	} else {
 		log.Printf("Secret '%s' removed from config and potentially keyring.", nameToRemove)
-		ui.ShowAdminNotification(ui.LevelInfo, "Secret Removed", fmt.Sprintf("Secret '%s' removed. Manual restart required for change to take effect.", nameToRemove)) // <<< CHANGED (Info level)
+		a.applySecrets()
+		ui.ShowAdminNotification(ui.LevelInfo, "Secret Removed", i18n.Tf("Secret '%s' removed. Rules using it no longer match.", nameToRemove)) // <<< CHANGED (Info level)
 	}
 }
 
@@ -602,8 +1046,8 @@ func (a *Application) onAddSimpleRule() {
 	// === Step 1: Check for existing profiles ===
 	if a.config == nil || a.config.Profiles == nil || len(a.config.Profiles) == 0 {
 		log.Println("Error: Cannot add rule, no profiles found in configuration.")
-		zenity.Error("No profiles found. Please add a profile manually in config.json first.",
-			zenity.Title(appName+" - Error"),
+		zenity.Error(i18n.T("No profiles found. Please add a profile manually in config.json first."),
+			zenity.Title(appName+" - "+i18n.T("Error")),
 			zenity.ErrorIcon)
 		// No notification needed here as zenity shows the error directly
 		return
@@ -618,9 +1062,9 @@ func (a *Application) onAddSimpleRule() {
 	}
 
 	selectedProfileName, err := zenity.List(
-		"Step 1: Select Profile to add the rule to:", // Renamed step in title
+		i18n.T("Step 1: Select Profile to add the rule to:"), // Renamed step in title
 		profileNames,
-		zenity.Title(appName+" - Add Simple Rule"),
+		zenity.Title(appName+" - "+i18n.T("Add Simple Rule")),
 		zenity.Height(300), // Adjust height if needed
 	)
 	if err != nil {
@@ -641,8 +1085,8 @@ func (a *Application) onAddSimpleRule() {
 
 	// === Step 3: Get Source Text ===
 	sourceText, err := zenity.Entry(
-		"Step 2: Enter Source Text\n(Text to find and replace. Special characters will be treated literally.)",
-		zenity.Title(appName+" - Add Simple Rule"),
+		i18n.T("Step 2: Enter Source Text\n(Text to find and replace. Special characters will be treated literally.)"),
+		zenity.Title(appName+" - "+i18n.T("Add Simple Rule")),
 		zenity.DisallowEmpty(), // Ensure source text is not empty
 	)
 	if err != nil {
@@ -659,8 +1103,8 @@ func (a *Application) onAddSimpleRule() {
 
 	// === Step 4: Get Replacement Text ===
 	replacementText, err := zenity.Entry(
-		"Step 3: Enter Replacement Text\n(Text to replace the source text with. Can be empty.)",
-		zenity.Title(appName+" - Add Simple Rule"),
+		i18n.T("Step 3: Enter Replacement Text\n(Text to replace the source text with. Can be empty.)"),
+		zenity.Title(appName+" - "+i18n.T("Add Simple Rule")),
 		// Allow empty replacement text
 	)
 	if err != nil {
@@ -676,11 +1120,11 @@ func (a *Application) onAddSimpleRule() {
 
 	// === Step 5: Ask about Case Sensitivity ===
 	err = zenity.Question(
-		"Step 4: Make this rule case-insensitive?\n(Treat 'A' and 'a' as the same)",
-		zenity.Title(appName+" - Add Simple Rule"),
+		i18n.T("Step 4: Make this rule case-insensitive?\n(Treat 'A' and 'a' as the same)"),
+		zenity.Title(appName+" - "+i18n.T("Add Simple Rule")),
 		zenity.QuestionIcon,
-		zenity.OKLabel("Yes"),
-		zenity.CancelLabel("No"), // "No" corresponds to ErrCanceled
+		zenity.OKLabel(i18n.T("Yes")),
+		zenity.CancelLabel(i18n.T("No")), // "No" corresponds to ErrCanceled
 	)
 
 	caseInsensitive := false
@@ -700,17 +1144,12 @@ func (a *Application) onAddSimpleRule() {
 	}
 
 	// === Step 6: Construct Rule ===
-	escapedSourceText := regexp.QuoteMeta(sourceText)
-	regexString := escapedSourceText
-	if caseInsensitive {
-		regexString = "(?i)" + escapedSourceText
-	}
-
 	newRule := config.Replacement{
-		Regex:        regexString,
-		ReplaceWith:  replacementText,
-		PreserveCase: false, // Keep false for simple 1:1 rules
-		ReverseWith:  "",    // Not applicable
+		Regex:           regexp.QuoteMeta(sourceText),
+		ReplaceWith:     replacementText,
+		PreserveCase:    false, // Keep false for simple 1:1 rules
+		ReverseWith:     "",    // Not applicable
+		CaseInsensitive: caseInsensitive,
 	}
 
 	log.Printf("Constructed new rule: Regex='%s', ReplaceWith='%s'", newRule.Regex, newRule.ReplaceWith)
@@ -730,7 +1169,7 @@ func (a *Application) onAddSimpleRule() {
 	// Save the config
 	err = a.config.Save()
 	if err != nil {
-		errMsg := fmt.Sprintf("Failed to save config after adding rule. Error: %v", err)
+		errMsg := i18n.Tf("Failed to save config after adding rule. Error: %v", err)
 		log.Printf("Error saving config after adding simple rule to profile '%s': %v", selectedProfileName, err)
 		ui.ShowAdminNotification(ui.LevelError, "Save Error", errMsg) // <<< CHANGED (Error level)
 		// Attempt to roll back the change in memory
@@ -741,8 +1180,361 @@ func (a *Application) onAddSimpleRule() {
 	} else {
 		log.Printf("Successfully added simple rule to profile '%s' and saved config.", selectedProfileName)
 		// Final success notification
-		ui.ShowAdminNotification(ui.LevelInfo, "Rule Added", fmt.Sprintf("Rule added to profile '%s'. Use 'Reload Configuration' to apply.", selectedProfileName)) // <<< CHANGED (Info level)
+		ui.ShowAdminNotification(ui.LevelInfo, "Rule Added", i18n.Tf("Rule added to profile '%s'. Use 'Reload Configuration' to apply.", selectedProfileName)) // <<< CHANGED (Info level)
+	}
+}
+
+// --- End Add Simple Rule Handler ---
+
+// saveWithProfiles writes the configuration with profiles in place of its
+// own to config.json. The live configuration is left alone, as the clipboard
+// manager reads it from other goroutines; the caller reloads it to apply the
+// change.
+func (a *Application) saveWithProfiles(profiles []config.ProfileConfig) error {
+	updated := *a.config
+	updated.Profiles = profiles
+	return updated.Save()
+}
+
+// onOpenRuleEditor opens the browser-based rule editor. Saving from the editor
+// writes the profiles to config.json and reloads the configuration.
+func (a *Application) onOpenRuleEditor() {
+	log.Println("Edit Rules menu item clicked.")
+
+	getProfiles := func() []config.ProfileConfig {
+		if a.config == nil {
+			return nil
+		}
+		profiles := make([]config.ProfileConfig, len(a.config.Profiles))
+		copy(profiles, a.config.Profiles)
+		return profiles
+	}
+
+	saveProfiles := func(profiles []config.ProfileConfig) error {
+		if a.config == nil {
+			return fmt.Errorf("configuration is not loaded")
+		}
+		if err := a.saveWithProfiles(profiles); err != nil {
+			log.Printf("Error saving config from rule editor: %v", err)
+			ui.ShowAdminNotification(ui.LevelError, "Save Error", i18n.Tf("Failed to save rules: %v", err))
+			return err
+		}
+		log.Printf("Rule editor saved %d profile(s). Reloading configuration.", len(profiles))
+		a.onReloadConfig()
+		return nil
+	}
+
+	if err := ui.ShowRuleEditor(getProfiles, saveProfiles); err != nil {
+		log.Printf("Error opening rule editor: %v", err)
+		ui.ShowAdminNotification(ui.LevelError, "Rule Editor Error", i18n.Tf("Could not open rule editor: %v", err))
 	}
 }
 
-// --- End Add Simple Rule Handler ---
\ No newline at end of file
+// onOpenPlayground opens the regex playground with the current clipboard text.
+func (a *Application) onOpenPlayground() {
+	log.Println("Test Rules menu item clicked.")
+
+	callbacks := ui.PlaygroundCallbacks{
+		ReadClipboard: a.clipboardManager.ReadText,
+		Preview:       a.clipboardManager.PreviewReplacement,
+		ProfileNames: func() []string {
+			if a.config == nil {
+				return nil
+			}
+			names := make([]string, 0, len(a.config.Profiles))
+			for _, p := range a.config.Profiles {
+				names = append(names, p.Name)
+			}
+			return names
+		},
+		AddRule: func(profileName string, rep config.Replacement) error {
+			if a.config == nil {
+				return fmt.Errorf("configuration is not loaded")
+			}
+			if _, err := regexp.Compile(rep.Regex); err != nil {
+				return fmt.Errorf("invalid regex: %w", err)
+			}
+			targetIndex := -1
+			for i, p := range a.config.Profiles {
+				if p.Name == profileName {
+					targetIndex = i
+					break
+				}
+			}
+			if targetIndex < 0 {
+				return fmt.Errorf("profile '%s' not found", profileName)
+			}
+
+			prof := &a.config.Profiles[targetIndex]
+			prof.Replacements = append(prof.Replacements, rep)
+			if err := a.config.Save(); err != nil {
+				log.Printf("Error saving config after adding rule from playground: %v", err)
+				prof.Replacements = prof.Replacements[:len(prof.Replacements)-1] // Roll back
+				return fmt.Errorf("failed to save config: %w", err)
+			}
+			log.Printf("Added rule from playground to profile '%s'. Reloading configuration.", profileName)
+			a.onReloadConfig()
+			return nil
+		},
+	}
+
+	if err := ui.ShowRulePlayground(callbacks); err != nil {
+		log.Printf("Error opening rule playground: %v", err)
+		ui.ShowAdminNotification(ui.LevelError, "Test Rules Error", i18n.Tf("Could not open rule playground: %v", err))
+	}
+}
+
+// onExportProfile saves a selected profile to a shareable .json/.yaml file.
+func (a *Application) onExportProfile() {
+	log.Println("Export Profile menu item clicked.")
+	appName := config.DefaultKeyringService
+
+	if a.config == nil || len(a.config.Profiles) == 0 {
+		ui.ShowAdminNotification(ui.LevelWarn, "Export Profile", "No profiles available to export.")
+		return
+	}
+
+	profileNames := make([]string, len(a.config.Profiles))
+	for i, p := range a.config.Profiles {
+		profileNames[i] = p.Name
+	}
+	selectedProfileName, err := zenity.List(
+		i18n.T("Select the profile to export:"),
+		profileNames,
+		zenity.Title(appName+" - "+i18n.T("Export Profile")),
+		zenity.Height(300),
+	)
+	if err != nil || selectedProfileName == "" {
+		if err != nil && !errors.Is(err, zenity.ErrCanceled) {
+			log.Printf("Error getting profile selection via zenity list: %v", err)
+			ui.ShowAdminNotification(ui.LevelWarn, "Input Error", "Failed to get profile selection.")
+		} else {
+			log.Println("Export profile canceled by user.")
+		}
+		return
+	}
+	profileIndex := a.config.FindProfile(selectedProfileName)
+	if profileIndex < 0 {
+		ui.ShowAdminNotification(ui.LevelError, "Internal Error", "Selected profile could not be found.")
+		return
+	}
+
+	path, err := zenity.SelectFileSave(
+		zenity.Title(appName+" - "+i18n.T("Export Profile")),
+		zenity.Filename(selectedProfileName+".json"),
+		zenity.ConfirmOverwrite(),
+		zenity.FileFilters{
+			{Name: i18n.T("JSON files"), Patterns: []string{"*.json"}},
+			{Name: i18n.T("YAML files"), Patterns: []string{"*.yaml", "*.yml"}},
+		},
+	)
+	if err != nil || path == "" {
+		if err != nil && !errors.Is(err, zenity.ErrCanceled) {
+			log.Printf("Error selecting export file: %v", err)
+			ui.ShowAdminNotification(ui.LevelWarn, "Input Error", "Failed to select export file.")
+		} else {
+			log.Println("Export profile canceled by user (file selection).")
+		}
+		return
+	}
+
+	// Export the group rules with the profile so the file is self-contained;
+	// snippets stay snippets instead of the rules they expand to
+	profile := a.config.Profiles[profileIndex]
+	exported := a.config.ResolveProfile(profile)
+	resolved := exported.Replacements
+	exported.Snippets = profile.Snippets
+	exported.Replacements = nil
+	for _, rep := range resolved {
+		if !rep.IsSnippet() {
+			exported.Replacements = append(exported.Replacements, rep)
+		}
+	}
+	if err := config.ExportProfile(exported, path); err != nil {
+		log.Printf("Error exporting profile '%s' to '%s': %v", selectedProfileName, path, err)
+		ui.ShowAdminNotification(ui.LevelError, "Export Failed", i18n.Tf("Could not export profile: %v", err))
+		return
+	}
+	log.Printf("Exported profile '%s' to '%s'.", selectedProfileName, path)
+	ui.ShowAdminNotification(ui.LevelInfo, "Profile Exported", i18n.Tf("Profile '%s' exported to %s.", selectedProfileName, filepath.Base(path)))
+}
+
+// onImportProfile adds a profile from a shared .json/.yaml file, asking how to
+// resolve a name collision with an existing profile.
+func (a *Application) onImportProfile() {
+	log.Println("Import Profile menu item clicked.")
+	appName := config.DefaultKeyringService
+
+	if a.config == nil {
+		ui.ShowAdminNotification(ui.LevelError, "Import Profile", "Configuration is not loaded.")
+		return
+	}
+
+	path, err := zenity.SelectFile(
+		zenity.Title(appName+" - "+i18n.T("Import Profile")),
+		zenity.FileFilters{
+			{Name: i18n.T("Profile files"), Patterns: []string{"*.json", "*.yaml", "*.yml"}},
+		},
+	)
+	if err != nil || path == "" {
+		if err != nil && !errors.Is(err, zenity.ErrCanceled) {
+			log.Printf("Error selecting import file: %v", err)
+			ui.ShowAdminNotification(ui.LevelWarn, "Input Error", "Failed to select import file.")
+		} else {
+			log.Println("Import profile canceled by user.")
+		}
+		return
+	}
+
+	shared, err := config.ImportProfile(path)
+	if err != nil {
+		log.Printf("Error importing profile from '%s': %v", path, err)
+		ui.ShowAdminNotification(ui.LevelError, "Import Failed", i18n.Tf("Could not import profile: %v", err))
+		return
+	}
+	imported := shared.Profile
+
+	// Shared profiles are untrusted; command rules run programs on this machine
+	if commands := config.ExternalCommands(imported); len(commands) > 0 {
+		err = zenity.Question(
+			i18n.Tf("Profile '%s' runs the following external programs on your clipboard content:\n\n%s\n\nOnly import it if you trust its source.", imported.Name, strings.Join(commands, "\n")),
+			zenity.Title(appName+" - "+i18n.T("Import Profile")),
+			zenity.WarningIcon,
+			zenity.OKLabel(i18n.T("Import")),
+			zenity.CancelLabel(i18n.T("Cancel")),
+		)
+		if err != nil {
+			log.Println("Import profile canceled by user (external commands).")
+			return
+		}
+	}
+
+	// Handle name collisions
+	replaceIndex := -1
+	if existing := a.config.FindProfile(imported.Name); existing >= 0 {
+		err = zenity.Question(
+			i18n.Tf("A profile named '%s' already exists.\n\nReplace it, or keep both and import as '%s'?", imported.Name, a.config.UniqueProfileName(imported.Name)),
+			zenity.Title(appName+" - "+i18n.T("Import Profile")),
+			zenity.QuestionIcon,
+			zenity.OKLabel(i18n.T("Keep Both")),
+			zenity.ExtraButton(i18n.T("Replace")),
+			zenity.CancelLabel(i18n.T("Cancel")),
+		)
+		switch {
+		case err == nil:
+			imported.Name = a.config.UniqueProfileName(imported.Name)
+		case errors.Is(err, zenity.ErrExtraButton):
+			replaceIndex = existing
+		default:
+			log.Println("Import profile canceled by user (name collision).")
+			return
+		}
+	}
+
+	profiles := make([]config.ProfileConfig, len(a.config.Profiles), len(a.config.Profiles)+1)
+	copy(profiles, a.config.Profiles)
+	if replaceIndex >= 0 {
+		profiles[replaceIndex] = imported
+	} else {
+		profiles = append(profiles, imported)
+	}
+
+	if err := a.saveWithProfiles(profiles); err != nil {
+		log.Printf("Error saving config after importing profile '%s': %v", imported.Name, err)
+		ui.ShowAdminNotification(ui.LevelError, "Save Error", i18n.Tf("Failed to save config after import: %v", err))
+		return
+	}
+	log.Printf("Imported profile '%s' from '%s'.", imported.Name, path)
+
+	message := i18n.Tf("Profile '%s' imported and added to the Profiles menu.", imported.Name)
+	if missing := a.config.MissingSecrets(shared.RequiredSecrets); len(missing) > 0 {
+		message += " " + i18n.Tf("Missing secrets: %s (add them via Manage Secrets).", strings.Join(missing, ", "))
+	}
+	ui.ShowAdminNotification(ui.LevelInfo, "Profile Imported", message)
+	a.onReloadConfig()
+}
+
+// onImportExternalRules converts Espanso or AutoHotkey rules into a new,
+// disabled profile.
+func (a *Application) onImportExternalRules() {
+	log.Println("Import from Espanso/AutoHotkey menu item clicked.")
+	appName := config.DefaultKeyringService
+
+	if a.config == nil {
+		ui.ShowAdminNotification(ui.LevelError, "Import Rules", "Configuration is not loaded.")
+		return
+	}
+
+	var (
+		choiceEspansoDir  = i18n.T("Espanso match folder")
+		choiceEspansoFile = i18n.T("Espanso match file (.yml)")
+		choiceAHK         = i18n.T("AutoHotkey script (.ahk)")
+	)
+	choice, err := zenity.List(
+		i18n.T("What do you want to import?"),
+		[]string{choiceEspansoDir, choiceEspansoFile, choiceAHK},
+		zenity.Title(appName+" - "+i18n.T("Import Rules")),
+		zenity.Height(250),
+	)
+	if err != nil || choice == "" {
+		if err != nil && !errors.Is(err, zenity.ErrCanceled) {
+			log.Printf("Error getting import source via zenity list: %v", err)
+		}
+		log.Println("Import rules canceled by user.")
+		return
+	}
+
+	var source, profileName string
+	var options []zenity.Option
+	options = append(options, zenity.Title(appName+" - "+i18n.T("Import Rules")))
+	switch choice {
+	case choiceEspansoDir:
+		source, profileName = importer.SourceEspanso, "Espanso Import"
+		options = append(options, zenity.Directory())
+	case choiceEspansoFile:
+		source, profileName = importer.SourceEspanso, "Espanso Import"
+		options = append(options, zenity.FileFilters{{Name: i18n.T("Espanso match files"), Patterns: []string{"*.yml", "*.yaml"}}})
+	default:
+		source, profileName = importer.SourceAutoHotkey, "AutoHotkey Import"
+		options = append(options, zenity.FileFilters{{Name: i18n.T("AutoHotkey scripts"), Patterns: []string{"*.ahk"}}})
+	}
+
+	path, err := zenity.SelectFile(options...)
+	if err != nil || path == "" {
+		if err != nil && !errors.Is(err, zenity.ErrCanceled) {
+			log.Printf("Error selecting import path: %v", err)
+			ui.ShowAdminNotification(ui.LevelWarn, "Input Error", "Failed to select import file.")
+		}
+		return
+	}
+
+	result, err := importer.Import(source, path)
+	if err != nil {
+		log.Printf("Error importing %s rules from '%s': %v", source, path, err)
+		ui.ShowAdminNotification(ui.LevelError, "Import Failed", i18n.Tf("Could not import rules: %v", err))
+		return
+	}
+	for _, warning := range result.Warnings {
+		log.Printf("Import warning: %s", warning)
+	}
+	if len(result.Replacements) == 0 {
+		ui.ShowAdminNotification(ui.LevelWarn, "Import Rules", i18n.Tf("No convertible rules found (%d skipped, see log).", result.Skipped))
+		return
+	}
+
+	profile := importer.NewProfile(a.config.UniqueProfileName(profileName), "", result)
+	profiles := make([]config.ProfileConfig, len(a.config.Profiles), len(a.config.Profiles)+1)
+	copy(profiles, a.config.Profiles)
+	profiles = append(profiles, profile)
+	if err := a.saveWithProfiles(profiles); err != nil {
+		log.Printf("Error saving config after importing rules: %v", err)
+		ui.ShowAdminNotification(ui.LevelError, "Save Error", i18n.Tf("Failed to save imported rules: %v", err))
+		return
+	}
+
+	log.Printf("Imported %d rule(s) into disabled profile '%s' (%d skipped).", len(result.Replacements), profile.Name, result.Skipped)
+	ui.ShowAdminNotification(ui.LevelInfo, "Rules Imported", i18n.Tf(
+		"Imported %d rule(s) into disabled profile '%s' (%d skipped). Review them, set a hotkey, then enable the profile.",
+		len(result.Replacements), profile.Name, result.Skipped))
+	a.onReloadConfig()
+}
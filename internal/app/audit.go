@@ -0,0 +1,42 @@
+package app
+
+import (
+	"log"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/audit"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/ui"
+)
+
+// applyAuditConfig opens, adjusts or detaches the audit log after the config
+// was loaded. Disabling it keeps the file; its records are pruned the next
+// time it is enabled.
+func (a *Application) applyAuditConfig() {
+	if !a.config.AuditLogEnabled() {
+		if a.auditLog != nil {
+			log.Println("Audit log disabled. Clipboard transformations are no longer recorded.")
+			a.clipboardManager.SetAuditLog(nil)
+			a.auditLog = nil
+		}
+		return
+	}
+
+	retention := a.config.GetAuditRetention()
+	if a.auditLog != nil {
+		if err := a.auditLog.SetRetention(retention); err != nil {
+			log.Printf("Warning: failed to apply the audit log retention: %v", err)
+		}
+		return
+	}
+
+	path := audit.PathForConfig(a.config.GetConfigPath())
+	auditLog, err := audit.Open(path, retention)
+	if err != nil {
+		log.Printf("Error opening the audit log: %v", err)
+		ui.ShowAdminNotification(ui.LevelError, "Audit Log Unavailable", i18n.Tf("Clipboard transformations are not recorded: %v", err))
+		return
+	}
+	log.Printf("Recording clipboard transformations in the audit log %s", path)
+	a.auditLog = auditLog
+	a.clipboardManager.SetAuditLog(auditLog)
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"log"
+	"strings"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/doctor"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+	"github.com/ncruces/zenity"
+)
+
+// onRunDiagnostics is called when the "Run Diagnostics" menu item is clicked.
+// It shows the report of "clipregex doctor", plus whether the hotkeys of the
+// running instance are registered.
+func (a *Application) onRunDiagnostics() {
+	checks := doctor.Run(a.config.GetConfigPath())
+
+	registration := doctor.Check{Name: "Hotkey registration"}
+	statuses := a.hotkeyManager.Status()
+	problems := 0
+	for _, status := range statuses {
+		if !status.OK() {
+			problems++
+		}
+	}
+	switch {
+	case len(statuses) == 0:
+		registration.Status = doctor.Warn
+		registration.Detail = i18n.T("No hotkeys are configured for enabled profiles.")
+	case problems > 0:
+		registration.Status = doctor.Fail
+		registration.Detail = i18n.Tf("%d hotkey(s) are not working as configured. Open 'Hotkey Status' in the tray menu for details.", problems)
+	default:
+		registration.Detail = i18n.Tf("All %d hotkey(s) are registered.", len(statuses))
+	}
+	checks = append(checks, registration)
+
+	var lines []string
+	for _, check := range checks {
+		lines = append(lines, check.String())
+	}
+	passed, failed := doctor.Summary(checks)
+	summary := i18n.Tf("%d of %d check(s) passed, %d failed.", passed, len(checks), failed)
+	log.Printf("Diagnostics: %s\n%s", summary, strings.Join(lines, "\n"))
+
+	icon := zenity.InfoIcon
+	if passed < len(checks) {
+		icon = zenity.WarningIcon
+	}
+	zenity.Info(summary+"\n\n"+strings.Join(lines, "\n\n"), zenity.Title(config.DefaultKeyringService+" - "+i18n.T("Diagnostics")), icon)
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/clipboard"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/ui"
+	"github.com/ncruces/zenity"
+)
+
+// onExplainHotkey is called when the "Explain Hotkey..." menu item is
+// clicked. It runs the profiles of a hotkey on the clipboard without changing
+// it and shows which rule changes which part of the text.
+func (a *Application) onExplainHotkey() {
+	hotkeys := clipboard.ProfileHotkeys(a.config)
+	if len(hotkeys) == 0 {
+		ui.ShowAdminNotification(ui.LevelInfo, "Explain Hotkey", "No enabled profile has a hotkey.")
+		return
+	}
+
+	chosen := hotkeys[0]
+	if len(hotkeys) > 1 {
+		labels := make([]string, len(hotkeys))
+		for i, h := range hotkeys {
+			labels[i] = h.Hotkey + ": " + strings.Join(h.Profiles, ", ")
+			if h.Reverse {
+				labels[i] = h.Hotkey + " " + i18n.T("(reverse)") + ": " + strings.Join(h.Profiles, ", ")
+			}
+		}
+		choice, err := zenity.List(
+			i18n.T("Which hotkey should be explained for the current clipboard?"),
+			labels,
+			zenity.Title(config.DefaultKeyringService+" - "+i18n.T("Explain Hotkey")),
+			zenity.Height(350),
+		)
+		if err != nil || choice == "" {
+			if err != nil && !errors.Is(err, zenity.ErrCanceled) {
+				log.Printf("Error getting the hotkey to explain via zenity list: %v", err)
+			}
+			return
+		}
+		for i, label := range labels {
+			if label == choice {
+				chosen = hotkeys[i]
+			}
+		}
+	}
+
+	text, err := a.clipboardManager.ReadText()
+	if err != nil {
+		log.Printf("Explain Hotkey: failed to read the clipboard: %v", err)
+		ui.ShowAdminNotification(ui.LevelWarn, "Explain Hotkey", i18n.Tf("Could not read the clipboard: %v", err))
+		return
+	}
+	explanation := a.clipboardManager.Explain(text, chosen.Hotkey, chosen.Reverse)
+	log.Printf("Explain Hotkey: %s (reverse=%t) ran %d profile(s); %d rule(s) change the clipboard, %d rewrite another profile's output.",
+		chosen.Hotkey, chosen.Reverse, len(explanation.Profiles), len(explanation.Steps), explanation.Conflicts())
+	ui.ShowExplainViewer(explanation.Original, explanation.Result, explanation, a.config.GetDiffContextLines(), a.config.GetDiffGranularity())
+}
@@ -0,0 +1,104 @@
+package app
+
+import (
+	"errors"
+	"log"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/history"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/ui"
+	"github.com/ncruces/zenity"
+)
+
+// applyHistoryConfig opens, adjusts or detaches the clipboard history after
+// the config was loaded. With restoreLast, the newest entry becomes the last
+// change again, so it can be viewed and, if still in the clipboard, reverted.
+func (a *Application) applyHistoryConfig(restoreLast bool) {
+	if !a.config.HistoryEnabled() {
+		if a.history != nil {
+			log.Println("History disabled. New clipboard changes are no longer saved; use 'Clear History' to delete the saved ones.")
+			a.clipboardManager.SetHistory(nil)
+			a.history = nil
+		}
+		return
+	}
+
+	maxEntries, retention := a.config.GetHistoryMaxEntries(), a.config.GetHistoryRetention()
+	if a.history != nil {
+		if err := a.history.SetRetention(maxEntries, retention); err != nil {
+			log.Printf("Warning: failed to apply the history retention: %v", err)
+		}
+		return
+	}
+
+	key, err := a.config.HistoryKey()
+	if err != nil {
+		log.Printf("Error getting the history key: %v", err)
+		ui.ShowAdminNotification(ui.LevelError, "History Unavailable", i18n.Tf("The clipboard history is not saved: %v", err))
+		return
+	}
+	path := history.PathForConfig(a.config.GetConfigPath())
+	store, err := history.Open(path, key, maxEntries, retention)
+	if errors.Is(err, history.ErrUndecryptable) {
+		// The key is gone, e.g. the keyring was reset, so the entries are lost either way
+		log.Printf("Warning: %v. Starting a new history.", err)
+		ui.ShowAdminNotification(ui.LevelWarn, "History Reset", "The saved clipboard history could not be decrypted with the stored key and was replaced by a new one.")
+		if err = history.Remove(path); err == nil {
+			store, err = history.Open(path, key, maxEntries, retention)
+		}
+	}
+	if err != nil {
+		log.Printf("Error opening the clipboard history: %v", err)
+		ui.ShowAdminNotification(ui.LevelError, "History Unavailable", i18n.Tf("The clipboard history is not saved: %v", err))
+		return
+	}
+	a.history = store
+	a.clipboardManager.SetHistory(store)
+
+	if restoreLast {
+		if entry, ok := store.Last(); ok {
+			a.clipboardManager.RestoreLastChange(entry)
+			if a.systrayManager != nil {
+				a.systrayManager.UpdateViewLastDiffStatus(true)
+			}
+		}
+	}
+}
+
+// onClearHistory is called when the "Clear History" menu item is clicked. It
+// deletes the saved history and forgets the last change.
+func (a *Application) onClearHistory() {
+	appName := config.DefaultKeyringService
+	err := zenity.Question(
+		i18n.T("Delete the saved history of clipboard changes?\n\nThe last change can no longer be viewed or reverted."),
+		zenity.Title(appName+" - "+i18n.T("Clear History")),
+		zenity.QuestionIcon,
+		zenity.OKLabel(i18n.T("Clear")),
+		zenity.CancelLabel(i18n.T("Cancel")),
+	)
+	if err != nil {
+		if !errors.Is(err, zenity.ErrCanceled) {
+			log.Printf("Error displaying clear history confirmation dialog: %v", err)
+			ui.ShowAdminNotification(ui.LevelWarn, "Dialog Error", "Failed to show confirmation dialog.")
+		}
+		return
+	}
+
+	if a.history != nil {
+		err = a.history.Clear()
+	} else {
+		err = history.Remove(history.PathForConfig(a.config.GetConfigPath())) // Saved while history was enabled
+	}
+	if err != nil {
+		log.Printf("Error clearing the clipboard history: %v", err)
+		ui.ShowAdminNotification(ui.LevelError, "Clear History Failed", err.Error())
+		return
+	}
+
+	a.clipboardManager.ClearLastChange()
+	if a.systrayManager != nil {
+		a.systrayManager.UpdateViewLastDiffStatus(false)
+	}
+	ui.ShowAdminNotification(ui.LevelInfo, "History Cleared", "The saved clipboard history was deleted.")
+}
@@ -0,0 +1,36 @@
+package app
+
+import (
+	"log"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/hotkey"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/ui"
+)
+
+// checkRecordedHotkey tells the hotkey recorder whether hotkeyStr can be
+// registered and which bindings other than those of profile already use it.
+func (a *Application) checkRecordedHotkey(hotkeyStr, profile string) ui.HotkeyCheck {
+	var check ui.HotkeyCheck
+	if err := hotkey.ValidateHotkey(hotkeyStr); err != nil {
+		check.Error = err.Error()
+	}
+	if a.hotkeyManager != nil {
+		check.UsedBy = a.hotkeyManager.UsedBy(hotkeyStr, profile)
+	}
+	return check
+}
+
+// suspendHotkeys releases all hotkeys while the recorder is open, so pressing
+// one of them is recorded instead of running it. The returned function
+// registers them again.
+func (a *Application) suspendHotkeys() func() {
+	if a.hotkeyManager == nil {
+		return func() {}
+	}
+	log.Println("Suspending hotkeys while recording a hotkey.")
+	a.hotkeyManager.UnregisterAll()
+	return func() {
+		log.Println("Registering hotkeys again after recording a hotkey.")
+		a.registerHotkeys()
+	}
+}
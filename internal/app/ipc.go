@@ -0,0 +1,89 @@
+package app
+
+import (
+	"log"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/ipc"
+)
+
+// startCommands listens for "clipregex trigger/pause/resume/reload" from the
+// command line. Without the socket the application works as before.
+func (a *Application) startCommands() {
+	server, err := ipc.Listen(ipc.SocketPath(a.config.GetConfigPath()), a.handleCommand)
+	if err != nil {
+		log.Printf("Warning: command line control unavailable: %v", err)
+		return
+	}
+	a.commands = server
+}
+
+// stopCommands closes the command socket, so a restarted instance can take
+// it over.
+func (a *Application) stopCommands() {
+	if a.commands == nil {
+		return
+	}
+	if err := a.commands.Close(); err != nil {
+		log.Printf("Error closing the command socket: %v", err)
+	}
+	a.commands = nil
+}
+
+// handleCommand runs a request from the command line. Triggers wait for the
+// clipboard to be processed, so the caller sees the result.
+func (a *Application) handleCommand(req ipc.Request) ipc.Response {
+	switch req.Command {
+	case ipc.CommandTrigger:
+		return a.triggerProfile(req.Profile, req.Reverse)
+	case ipc.CommandPause:
+		a.setPaused(true)
+		return ipc.Response{OK: true, Message: "Paused. Hotkeys and triggers are ignored until 'clipregex resume'."}
+	case ipc.CommandResume:
+		a.setPaused(false)
+		return ipc.Response{OK: true, Message: "Resumed."}
+	case ipc.CommandReload:
+		a.onReloadConfig()
+		return ipc.Response{OK: true, Message: "Configuration reloaded."}
+	}
+	return ipc.Response{Message: "Unknown command '" + req.Command + "'."}
+}
+
+// triggerProfile applies the profiles named name to the clipboard like their
+// hotkey, whether or not they are enabled.
+func (a *Application) triggerProfile(name string, isReverse bool) ipc.Response {
+	if a.paused.Load() {
+		return ipc.Response{Message: "Paused: run 'clipregex resume' first."}
+	}
+	if a.config.FindProfile(name) < 0 {
+		return ipc.Response{Message: "Profile '" + name + "' not found."}
+	}
+	if !a.processing.CompareAndSwap(false, true) {
+		return ipc.Response{Message: "The previous clipboard is still being processed."}
+	}
+	defer a.processing.Store(false)
+
+	message, changed := a.processClipboard("", name, isReverse)
+	switch {
+	case message != "":
+		return ipc.Response{OK: true, Message: message}
+	case a.clipboardManager.LastSkipReason() != "":
+		return ipc.Response{OK: true, Message: a.clipboardManager.LastSkipReason()}
+	case !changed:
+		return ipc.Response{OK: true, Message: "No rule matched; the clipboard is unchanged."}
+	}
+	return ipc.Response{OK: true}
+}
+
+// setPaused makes hotkeys and triggers be ignored, or handled again.
+func (a *Application) setPaused(paused bool) {
+	a.paused.Store(paused)
+	log.Printf("Hotkeys paused=%t", paused)
+	if a.systrayManager != nil {
+		a.systrayManager.SetPaused(paused)
+	}
+}
+
+// onTogglePause is called when the "Pause Hotkeys" menu item is clicked.
+func (a *Application) onTogglePause() {
+	a.setPaused(!a.paused.Load())
+}
@@ -0,0 +1,22 @@
+package app
+
+import (
+	"log"
+	"path/filepath"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+)
+
+// ApplyLanguage activates the catalog for the configured language. Menu items
+// that already exist keep their titles until the application is restarted;
+// notifications and dialogs switch immediately.
+func ApplyLanguage(cfg *config.Config) {
+	configDir := ""
+	if path := cfg.GetConfigPath(); path != "" {
+		configDir = filepath.Dir(path)
+	}
+	if _, err := i18n.Load(cfg.Language, configDir); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+package app
+
+import (
+	"errors"
+	"log"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+	"github.com/ncruces/zenity"
+)
+
+// confirmOversize asks whether to process a clipboard over the size limits,
+// given why it is too large. It blocks the hotkey that triggered it until the
+// user answers; closing the dialog counts as declining.
+func (a *Application) confirmOversize(reason string) bool {
+	err := zenity.Question(
+		reason+"\n\n"+i18n.T("Process it anyway? This may take a while and slow down the application you paste into."),
+		zenity.Title(config.DefaultKeyringService+" - "+i18n.T("Large Clipboard")),
+		zenity.WarningIcon,
+		zenity.OKLabel(i18n.T("Process")),
+		zenity.CancelLabel(i18n.T("Cancel")),
+	)
+	if err != nil && !errors.Is(err, zenity.ErrCanceled) {
+		log.Printf("Error displaying oversize dialog: %v", err)
+	}
+	return err == nil
+}
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"errors"
+	"log"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/clipboard"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/ui"
+	"github.com/ncruces/zenity"
+)
+
+// onPasteProblem tells the user why the automatic paste does not work. If
+// system settings can fix it, a dialog offers to open them.
+func (a *Application) onPasteProblem(problem clipboard.PasteProblem) {
+	if problem.SettingsURL == "" {
+		ui.ShowAdminNotification(ui.LevelWarn, problem.Title, problem.Message)
+		return
+	}
+
+	// Called from the paste goroutine or at startup; don't block either
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("RECOVERED FROM PANIC IN PASTE PROBLEM DIALOG: %v", r)
+			}
+		}()
+		err := zenity.Question(
+			i18n.T(problem.Message),
+			zenity.Title(config.DefaultKeyringService+" - "+i18n.T(problem.Title)),
+			zenity.WarningIcon,
+			zenity.OKLabel(i18n.T("Open Settings")),
+			zenity.CancelLabel(i18n.T("Later")),
+		)
+		if err != nil {
+			if !errors.Is(err, zenity.ErrCanceled) {
+				log.Printf("Error displaying paste problem dialog: %v", err)
+				ui.ShowAdminNotification(ui.LevelWarn, problem.Title, problem.Message)
+			}
+			return
+		}
+		if err := ui.OpenFileInDefaultApp(problem.SettingsURL); err != nil {
+			log.Printf("Error opening settings: %v", err)
+		}
+	}()
+}
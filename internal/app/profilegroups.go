@@ -0,0 +1,31 @@
+package app
+
+import (
+	"log"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/ui"
+)
+
+// onCycleProfileGroup is called when the cycle hotkey of a profile group is
+// pressed. It enables the group's next profile, disables the others and saves
+// the choice, like selecting the profile in the tray.
+func (a *Application) onCycleProfileGroup(group string) {
+	name, err := a.config.CycleProfileGroup(group)
+	if err != nil {
+		log.Printf("Cannot cycle profile group: %v", err)
+		ui.ShowAdminNotification(ui.LevelWarn, "Profile Group", err.Error())
+		return
+	}
+	log.Printf("Group '%s': profile '%s' is now active.", group, name)
+
+	if err := a.config.Save(); err != nil {
+		log.Printf("Failed to save config after switching group '%s': %v", group, err)
+		ui.ShowAdminNotification(ui.LevelError, "Save Error", i18n.Tf("Profile '%s' is active until the next reload, but the config could not be saved: %v", name, err))
+	} else {
+		ui.ShowAdminNotification(ui.LevelInfo, "Profile Switched", i18n.Tf("Profile '%s' is now active (group '%s').", name, group))
+	}
+
+	// Not from this goroutine: re-registering stops the listener of the pressed hotkey
+	go a.applyScheduleChange()
+}
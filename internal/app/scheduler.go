@@ -0,0 +1,180 @@
+package app
+
+import (
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/ui"
+)
+
+// scheduleCheckInterval is how often profile schedules are evaluated.
+const scheduleCheckInterval = 30 * time.Second
+
+// profileScheduler enables and disables profiles that have a schedule. It only
+// acts when a schedule's state changes, so a profile toggled by hand in the
+// tray stays that way until the next change. The config file is not written.
+type profileScheduler struct {
+	app  *Application
+	mu   sync.Mutex
+	last map[string]bool // Schedule state per profile name at the last check
+	stop chan struct{}
+}
+
+func newProfileScheduler(app *Application) *profileScheduler {
+	return &profileScheduler{app: app, last: make(map[string]bool)}
+}
+
+// start applies the current schedule states and then checks them periodically.
+// Call it before registering hotkeys so they match the applied states.
+func (s *profileScheduler) start() {
+	s.check(time.Now())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+	s.stop = make(chan struct{})
+
+	go func(stop chan struct{}) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("RECOVERED FROM PANIC IN PROFILE SCHEDULER: %v", r)
+			}
+		}()
+		ticker := time.NewTicker(scheduleCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				if s.check(now) {
+					s.app.applyScheduleChange()
+				}
+			}
+		}
+	}(s.stop)
+}
+
+// close stops the periodic checks.
+func (s *profileScheduler) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// check evaluates every schedule at now and sets the enabled flag of profiles
+// whose schedule state changed since the last check. It returns true if a
+// profile was switched.
+func (s *profileScheduler) check(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cfg := s.app.config
+	if cfg == nil {
+		return false
+	}
+
+	changed := false
+	seen := make(map[string]bool)
+	for i := range cfg.Profiles {
+		profile := &cfg.Profiles[i]
+		if profile.Schedule == nil {
+			continue
+		}
+		seen[profile.Name] = true
+
+		active := scheduleActive(profile.Schedule, now, cfg.ResolvePath)
+		if previous, ok := s.last[profile.Name]; ok && previous == active {
+			continue
+		}
+		s.last[profile.Name] = active
+		if profile.Enabled == active {
+			continue
+		}
+
+		// The clipboard manager reads the profiles concurrently, so it switches them
+		disabled, ok := s.app.clipboardManager.SetProfileEnabled(profile.Name, active)
+		if !ok {
+			continue
+		}
+		for _, name := range disabled {
+			log.Printf("Schedule: profile '%s' disabled, it is in group '%s' of '%s'.", name, profile.Group, profile.Name)
+		}
+		changed = true
+		status := map[bool]string{true: i18n.T("enabled"), false: i18n.T("disabled")}[active]
+		log.Printf("Schedule: profile '%s' %s.", profile.Name, status)
+		ui.ShowAdminNotification(ui.LevelInfo, "Profile Schedule", i18n.Tf("Profile '%s' has been %s by its schedule.", profile.Name, status))
+	}
+
+	for name := range s.last {
+		if !seen[name] {
+			delete(s.last, name) // Schedule removed or profile renamed
+		}
+	}
+	return changed
+}
+
+// scheduleActive reports whether schedule enables its profile at now. Days and
+// the time window use local time; resolvePath locates a relative state file.
+func scheduleActive(schedule *config.ProfileSchedule, now time.Time, resolvePath func(string) string) bool {
+	days, err := config.ParseScheduleDays(schedule.Days)
+	if err != nil {
+		log.Printf("Schedule: %v", err)
+		return false
+	}
+
+	if schedule.From != "" && schedule.To != "" {
+		from, errFrom := config.ParseClock(schedule.From)
+		to, errTo := config.ParseClock(schedule.To)
+		if errFrom != nil || errTo != nil {
+			log.Printf("Schedule: invalid time window '%s'-'%s'", schedule.From, schedule.To)
+			return false
+		}
+		minute := now.Hour()*60 + now.Minute()
+		day := now.Weekday()
+		if from < to {
+			if minute < from || minute >= to {
+				return false
+			}
+		} else if minute < to {
+			day = (day + 6) % 7 // After midnight the window belongs to the day it started
+		} else if minute < from {
+			return false
+		}
+		if days != nil && !days[day] {
+			return false
+		}
+	} else if days != nil && !days[now.Weekday()] {
+		return false
+	}
+
+	if schedule.StateFile != "" {
+		data, err := os.ReadFile(resolvePath(schedule.StateFile))
+		if err != nil {
+			return false // A missing state file means "not in this state"
+		}
+		if !strings.EqualFold(strings.TrimSpace(string(data)), strings.TrimSpace(schedule.State)) {
+			return false
+		}
+	}
+	return true
+}
+
+// applyScheduleChange re-registers hotkeys and refreshes the tray after the
+// scheduler or a group's cycle hotkey switched profiles.
+func (a *Application) applyScheduleChange() {
+	a.reregisterHotkeys()
+	if a.systrayManager != nil {
+		a.systrayManager.UpdateConfig(a.config)
+	}
+}
@@ -0,0 +1,186 @@
+package app
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/ui"
+	"github.com/ncruces/zenity"
+)
+
+// onExportSecrets writes the names of all declared secrets, and optionally
+// the values of the managed ones encrypted with a passphrase, to a file.
+func (a *Application) onExportSecrets() {
+	log.Println("Export Secrets menu item clicked.")
+	appName := config.DefaultKeyringService
+
+	if a.config == nil || len(a.config.Secrets) == 0 {
+		ui.ShowAdminNotification(ui.LevelInfo, "Export Secrets", "No secrets are currently managed.")
+		return
+	}
+
+	err := zenity.Question(
+		i18n.Tf("Export the %d secret name(s) declared in config.json?\n\nValues can be included, encrypted with a passphrase you choose, to move them to another computer. References to 1Password, Bitwarden or Vault are exported as references.", len(a.config.Secrets)),
+		zenity.Title(appName+" - "+i18n.T("Export Secrets")),
+		zenity.QuestionIcon,
+		zenity.OKLabel(i18n.T("Names Only")),
+		zenity.ExtraButton(i18n.T("Include Values")),
+		zenity.CancelLabel(i18n.T("Cancel")),
+	)
+	includeValues := errors.Is(err, zenity.ErrExtraButton)
+	if err != nil && !includeValues {
+		if !errors.Is(err, zenity.ErrCanceled) {
+			log.Printf("Error displaying export dialog: %v", err)
+		}
+		log.Println("Export secrets canceled by user.")
+		return
+	}
+
+	passphrase := ""
+	if includeValues {
+		if passphrase, err = choosePassphrase(appName + " - Passphrase for the Exported Values"); err != nil {
+			log.Println("Export secrets canceled by user (passphrase).")
+			return
+		}
+	}
+
+	path, err := zenity.SelectFileSave(
+		zenity.Title(appName+" - "+i18n.T("Export Secrets")),
+		zenity.Filename("secrets.json"),
+		zenity.ConfirmOverwrite(),
+		zenity.FileFilters{{Name: i18n.T("JSON files"), Patterns: []string{"*.json"}}},
+	)
+	if err != nil || path == "" {
+		if err != nil && !errors.Is(err, zenity.ErrCanceled) {
+			log.Printf("Error selecting export file: %v", err)
+			ui.ShowAdminNotification(ui.LevelWarn, "Input Error", "Failed to select export file.")
+		} else {
+			log.Println("Export secrets canceled by user (file selection).")
+		}
+		return
+	}
+
+	data, problems, err := a.config.ExportSecrets(passphrase)
+	if err == nil {
+		err = os.WriteFile(path, data, 0600)
+	}
+	if err != nil {
+		log.Printf("Error exporting secrets to '%s': %v", path, err)
+		ui.ShowAdminNotification(ui.LevelError, "Export Failed", i18n.Tf("Could not export secrets: %v", err))
+		return
+	}
+	log.Printf("Exported %d secret(s) to '%s' (values included: %t).", len(a.config.Secrets), path, includeValues)
+	if len(problems) > 0 {
+		log.Printf("Secret values not exported:\n  - %s", strings.Join(problems, "\n  - "))
+		ui.ShowAdminNotification(ui.LevelWarn, "Secrets Exported", i18n.Tf("Exported to %s, but %d value(s) could not be read. See logs.", filepath.Base(path), len(problems)))
+		return
+	}
+	ui.ShowAdminNotification(ui.LevelInfo, "Secrets Exported", i18n.Tf("%d secret(s) exported to %s.", len(a.config.Secrets), filepath.Base(path)))
+}
+
+// onImportSecrets stores a batch of secrets from a JSON or CSV file, e.g. an
+// export from another computer or a list prepared by hand.
+func (a *Application) onImportSecrets() {
+	log.Println("Import Secrets menu item clicked.")
+	appName := config.DefaultKeyringService
+
+	if a.config == nil {
+		ui.ShowAdminNotification(ui.LevelError, "Import Secrets", "Configuration is not loaded.")
+		return
+	}
+
+	path, err := zenity.SelectFile(
+		zenity.Title(appName+" - "+i18n.T("Import Secrets")),
+		zenity.FileFilters{
+			{Name: i18n.T("Secrets files"), Patterns: []string{"*.json", "*.csv"}},
+		},
+	)
+	if err != nil || path == "" {
+		if err != nil && !errors.Is(err, zenity.ErrCanceled) {
+			log.Printf("Error selecting import file: %v", err)
+			ui.ShowAdminNotification(ui.LevelWarn, "Input Error", "Failed to select import file.")
+		} else {
+			log.Println("Import secrets canceled by user (file selection).")
+		}
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		ui.ShowAdminNotification(ui.LevelError, "Import Failed", i18n.Tf("Could not read %s: %v", filepath.Base(path), err))
+		return
+	}
+
+	passphrase := ""
+	if config.SecretsImportEncrypted(data) {
+		if _, passphrase, err = zenity.Password(zenity.Title(appName + " - " + i18n.T("Passphrase of the Exported Values"))); err != nil {
+			log.Println("Import secrets canceled by user (passphrase).")
+			return
+		}
+	}
+	entries, err := config.ParseSecretsImport(data, path, passphrase)
+	if err != nil {
+		log.Printf("Error parsing secrets import '%s': %v", path, err)
+		ui.ShowAdminNotification(ui.LevelError, "Import Failed", i18n.Tf("Could not import %s: %v", filepath.Base(path), err))
+		return
+	}
+	if len(entries) == 0 {
+		ui.ShowAdminNotification(ui.LevelWarn, "Import Secrets", i18n.Tf("%s contains no secrets.", filepath.Base(path)))
+		return
+	}
+
+	err = zenity.Question(
+		i18n.Tf("Import %d secret(s) from %s?\n\nValues are stored in the OS keychain (or the encrypted secrets file), and secrets with the same names are replaced.", len(entries), filepath.Base(path)),
+		zenity.Title(appName+" - "+i18n.T("Import Secrets")),
+		zenity.QuestionIcon,
+		zenity.OKLabel(i18n.T("Import")),
+		zenity.CancelLabel(i18n.T("Cancel")),
+	)
+	if err != nil {
+		log.Println("Import secrets canceled by user (confirmation).")
+		return
+	}
+
+	result, err := a.config.ImportSecrets(entries)
+	if err != nil {
+		log.Printf("Error saving imported secrets: %v", err)
+		ui.ShowAdminNotification(ui.LevelError, "Import Failed", i18n.Tf("Stored %d secret(s), but config.json could not be saved: %v", len(result.Imported), err))
+		return
+	}
+	log.Printf("Imported %d secret(s) from '%s': %s", len(result.Imported), path, strings.Join(result.Imported, ", "))
+	if len(result.Imported) > 0 {
+		a.applySecrets()
+	}
+	message := i18n.Tf("%d secret(s) imported and active.", len(result.Imported))
+	if len(result.Problems) > 0 {
+		log.Printf("Skipped secrets:\n  - %s", strings.Join(result.Problems, "\n  - "))
+		ui.ShowAdminNotification(ui.LevelWarn, "Secrets Imported", message)
+		zenity.Warning(i18n.Tf("%s\n\n%d entry(s) skipped:\n- %s", message, len(result.Problems), strings.Join(result.Problems, "\n- ")), zenity.Title(appName+" - "+i18n.T("Import Secrets")))
+		return
+	}
+	ui.ShowAdminNotification(ui.LevelInfo, "Secrets Imported", message)
+}
+
+// onAuditSecrets shows which rules reference which secrets, and the secrets
+// that are missing, not loaded or unused.
+func (a *Application) onAuditSecrets() {
+	log.Println("Secret Usage menu item clicked.")
+	if a.config == nil {
+		ui.ShowAdminNotification(ui.LevelError, "Secret Usage", "Configuration is not loaded.")
+		return
+	}
+	audit := a.config.AuditSecrets(a.config.GetResolvedSecrets())
+	report := audit.Report(a.config)
+	log.Printf("Secret usage:\n%s", report)
+
+	title := zenity.Title(config.DefaultKeyringService + " - " + i18n.T("Secret Usage"))
+	if audit.Problems() > 0 {
+		zenity.Warning(report, title, zenity.Width(640))
+		return
+	}
+	zenity.Info(report, title, zenity.InfoIcon, zenity.Width(640))
+}
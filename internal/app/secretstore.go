@@ -0,0 +1,77 @@
+package app
+
+import (
+	"log"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/ui"
+	"github.com/ncruces/zenity"
+)
+
+// PromptSecretPassphrase asks for the passphrase of the encrypted secrets
+// file, which replaces the OS keyring where none is available. For a new
+// store the passphrase is entered twice.
+func PromptSecretPassphrase(create bool) (string, error) {
+	appName := config.DefaultKeyringService
+	if !create {
+		_, pass, err := zenity.Password(zenity.Title(appName + " - " + i18n.T("Unlock Secrets")))
+		return pass, err
+	}
+	pass, err := choosePassphrase(appName + " - Choose a Passphrase for Your Secrets")
+	if err == nil {
+		log.Println("Passphrase for the new encrypted secrets file chosen.")
+	}
+	return pass, err
+}
+
+// choosePassphrase asks for a new passphrase twice until both entries match
+// and are not empty, or the dialog is canceled.
+func choosePassphrase(title string) (string, error) {
+	appName := config.DefaultKeyringService
+	for {
+		_, pass, err := zenity.Password(zenity.Title(title))
+		if err != nil {
+			return "", err
+		}
+		if pass == "" {
+			zenity.Error(i18n.T("The passphrase cannot be empty."), zenity.Title(appName+" - "+i18n.T("Secrets")), zenity.ErrorIcon)
+			continue
+		}
+		_, confirm, err := zenity.Password(zenity.Title(appName + " - " + i18n.T("Repeat the Passphrase")))
+		if err != nil {
+			return "", err
+		}
+		if confirm == pass {
+			return pass, nil
+		}
+		zenity.Error(i18n.T("The passphrases do not match. Please try again."), zenity.Title(appName+" - "+i18n.T("Secrets")), zenity.ErrorIcon)
+	}
+}
+
+// applySecrets re-resolves the declared secrets and hands them to the
+// clipboard manager, so added, removed or imported secrets take effect
+// without a restart.
+func (a *Application) applySecrets() {
+	if a.config == nil {
+		return
+	}
+	resolved := a.config.ResolveSecrets()
+	if a.clipboardManager != nil {
+		a.clipboardManager.UpdateResolvedSecrets(resolved)
+	}
+	log.Printf("Applied %d resolved secret(s) of %d declared.", len(resolved), len(a.config.Secrets))
+	a.reportSecretStore()
+}
+
+// reportSecretStore tells the user when secrets are declared but no store for
+// the managed ones could be opened, as every rule using them would otherwise
+// fail with nothing but a log line.
+func (a *Application) reportSecretStore() {
+	err := a.config.SecretStoreError()
+	if err == nil {
+		return
+	}
+	msg := i18n.Tf("The managed secrets are unavailable: no secret store could be opened (%v). Check keyring_backend and secret_store in config.json, or use 'Run Diagnostics'.", err)
+	ui.ShowAdminNotification(ui.LevelError, "Secrets Unavailable", msg)
+}
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"log"
+	"strings"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/sound"
+)
+
+// hotkeySoundEvent returns the sound event for the outcome of processing a
+// hotkey press: an error if a rule failed, success if the clipboard changed
+// and no match otherwise.
+func (a *Application) hotkeySoundEvent(message string, changed bool) string {
+	switch {
+	case len(a.clipboardManager.LastActionErrors()) > 0, len(a.clipboardManager.LastBudgetOverruns()) > 0, strings.HasPrefix(message, "Error"):
+		return config.SoundError
+	case changed:
+		return config.SoundSuccess
+	}
+	return config.SoundNoMatch
+}
+
+// playHotkeySound plays the sound for event if sounds are enabled for the
+// profiles of the hotkey, or for those named profileName if it is set.
+func (a *Application) playHotkeySound(hotkeyStr, profileName string, isReverse bool, event string) {
+	cfg := a.config
+	if cfg == nil {
+		return
+	}
+	if profileName != "" {
+		if !cfg.SoundsEnabledForProfile(profileName) {
+			return
+		}
+		hotkeyStr = profileName // For the log
+	} else if !cfg.SoundsEnabledForHotkey(hotkeyStr, isReverse) {
+		return
+	}
+	file, muted := cfg.SoundFile(event)
+	if muted {
+		return
+	}
+	log.Printf("Playing %s sound for hotkey '%s'.", event, hotkeyStr)
+	sound.Play(event, file)
+}
@@ -0,0 +1,60 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/stats"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/ui"
+	"github.com/ncruces/zenity"
+)
+
+// applyStatsRollover turns the monthly rollover of the statistics on or off
+// as configured.
+func (a *Application) applyStatsRollover() {
+	if a.statistics != nil {
+		a.statistics.SetMonthlyRollover(a.config.GetStatsRollover() == config.StatsRolloverMonthly)
+	}
+}
+
+// onExportStatistics is called when the "Export Statistics..." menu item is
+// clicked. It writes the current statistics to a CSV or JSON file.
+func (a *Application) onExportStatistics() {
+	appName := config.DefaultKeyringService
+	path, err := zenity.SelectFileSave(
+		zenity.Title(appName+" - "+i18n.T("Export Statistics")),
+		zenity.Filename("statistics.csv"),
+		zenity.ConfirmOverwrite(),
+		zenity.FileFilters{
+			{Name: i18n.T("CSV files"), Patterns: []string{"*.csv"}},
+			{Name: i18n.T("JSON files"), Patterns: []string{"*.json"}},
+		},
+	)
+	if err != nil || path == "" {
+		if err != nil && !errors.Is(err, zenity.ErrCanceled) {
+			log.Printf("Error selecting statistics export file: %v", err)
+			ui.ShowAdminNotification(ui.LevelWarn, "Input Error", "Failed to select export file.")
+		} else {
+			log.Println("Export statistics canceled by user (file selection).")
+		}
+		return
+	}
+
+	snapshot := a.statistics.Snapshot()
+	var buf bytes.Buffer
+	err = snapshot.Export(&buf, stats.FormatForPath(path))
+	if err == nil {
+		err = os.WriteFile(path, buf.Bytes(), 0600)
+	}
+	if err != nil {
+		log.Printf("Error exporting statistics to '%s': %v", path, err)
+		ui.ShowAdminNotification(ui.LevelError, "Export Failed", i18n.Tf("Could not export statistics: %v", err))
+		return
+	}
+	log.Printf("Exported statistics of %d profile(s) and %d rule(s) to '%s'.", len(snapshot.Profiles), len(snapshot.Rules), path)
+	ui.ShowAdminNotification(ui.LevelInfo, "Statistics Exported", i18n.Tf("Statistics since %s exported to %s.", snapshot.Since.Format("2006-01-02"), path))
+}
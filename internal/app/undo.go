@@ -0,0 +1,88 @@
+package app
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/clipboard"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/ui"
+)
+
+// undoPreviewLength is the number of characters of a state's text shown in
+// the Undo History submenu.
+const undoPreviewLength = 40
+
+// onUndoHotkey is called when the undo hotkey is pressed
+func (a *Application) onUndoHotkey() {
+	state, err := a.clipboardManager.Undo()
+	a.showUndoResult("Undo", state, err)
+}
+
+// onRedoHotkey is called when the redo hotkey is pressed
+func (a *Application) onRedoHotkey() {
+	state, err := a.clipboardManager.Redo()
+	a.showUndoResult("Redo", state, err)
+}
+
+// onRestoreUndoState is called when a state in the Undo History submenu is clicked
+func (a *Application) onRestoreUndoState(index int) {
+	state, err := a.clipboardManager.RestoreUndoState(index)
+	a.showUndoResult("Undo", state, err)
+}
+
+// showUndoResult tells the user which state the clipboard holds now, or why
+// it was left unchanged, in a notification called title.
+func (a *Application) showUndoResult(title string, state clipboard.UndoState, err error) {
+	switch {
+	case errors.Is(err, clipboard.ErrNothingToUndo):
+		ui.ShowAdminNotification(ui.LevelInfo, title, "Nothing to undo.")
+	case errors.Is(err, clipboard.ErrNothingToRedo):
+		ui.ShowAdminNotification(ui.LevelInfo, title, "Nothing to redo.")
+	case errors.Is(err, clipboard.ErrClipboardChanged):
+		ui.ShowAdminNotification(ui.LevelInfo, title, "Something else was copied since the last change, so it can't be undone.")
+	case err != nil:
+		log.Printf("Failed to undo or redo: %v", err)
+		ui.ShowAdminNotification(ui.LevelWarn, title+" Failed", i18n.Tf("Could not change the clipboard: %v", err))
+	case len(state.Profiles) == 0:
+		ui.ShowAdminNotification(ui.LevelInfo, title, "The clipboard holds the copied text again.")
+	default:
+		ui.ShowAdminNotification(ui.LevelInfo, title, i18n.Tf("The clipboard holds the result of %s again.", strings.Join(state.Profiles, ", ")))
+	}
+	if err == nil && a.systrayManager != nil {
+		_, _, diffAvailable := a.clipboardManager.GetLastDiff()
+		a.systrayManager.UpdateViewLastDiffStatus(diffAvailable)
+	}
+}
+
+// onUndoChange lists the recorded clipboard states in the tray menu, newest
+// first. It is called by the clipboard manager whenever they change.
+func (a *Application) onUndoChange() {
+	if a.systrayManager == nil {
+		return
+	}
+	states, current := a.clipboardManager.UndoStates()
+	var entries []ui.UndoMenuEntry
+	for i := len(states) - 1; i >= 0 && len(entries) < ui.UndoMenuSlots; i-- {
+		entries = append(entries, ui.UndoMenuEntry{Title: undoStateTitle(states[i]), Current: i == current, Index: i})
+	}
+	a.systrayManager.UpdateUndoHistory(entries)
+}
+
+// undoStateTitle describes a state in the Undo History submenu, e.g.
+// "14:03:12 Markdown to Plain Text: Release notes for…".
+func undoStateTitle(state clipboard.UndoState) string {
+	source := i18n.T("Copied text")
+	if len(state.Profiles) > 0 {
+		source = strings.Join(state.Profiles, ", ")
+		if state.Reverse {
+			source += " " + i18n.T("(reverse)")
+		}
+	}
+	preview := state.Preview(undoPreviewLength)
+	if state.Sensitive {
+		preview = i18n.T("(contains a secret)")
+	}
+	return state.Time.Format("15:04:05") + " " + source + ": " + preview
+}
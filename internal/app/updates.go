@@ -0,0 +1,211 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/ui"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/update"
+	"github.com/ncruces/zenity"
+)
+
+const (
+	updateCheckDelay    = 30 * time.Second // Wait after startup before the first check
+	updateCheckInterval = 24 * time.Hour
+	updateCheckTimeout  = 30 * time.Second
+	updateInstallTime   = 10 * time.Minute // Limit for downloading and installing a release
+)
+
+// updateChecker looks for new releases in the background and notifies once
+// per new version. Installing is only done on request from the tray menu.
+type updateChecker struct {
+	app        *Application
+	mu         sync.Mutex
+	notified   string // Tag of the release last notified about
+	stop       chan struct{}
+	installing atomic.Bool
+}
+
+func newUpdateChecker(app *Application) *updateChecker {
+	return &updateChecker{app: app}
+}
+
+// start removes the executable left by the last update and, unless
+// update_check is "off", checks for updates periodically.
+func (u *updateChecker) start() {
+	if exe, err := os.Executable(); err == nil {
+		if err := update.CleanupOld(exe); err != nil {
+			log.Printf("Warning: Could not remove the executable replaced by the last update: %v", err)
+		}
+	}
+
+	if u.app.config == nil || u.app.config.GetUpdateCheck() == config.UpdateCheckOff {
+		log.Println("Automatic update checks are disabled.")
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.stop != nil {
+		return
+	}
+	u.stop = make(chan struct{})
+
+	go func(stop chan struct{}) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("RECOVERED FROM PANIC IN UPDATE CHECKER: %v", r)
+			}
+		}()
+		timer := time.NewTimer(updateCheckDelay)
+		defer timer.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-timer.C:
+				u.checkInBackground()
+				timer.Reset(updateCheckInterval)
+			}
+		}
+	}(u.stop)
+}
+
+// close stops the periodic checks.
+func (u *updateChecker) close() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.stop != nil {
+		close(u.stop)
+		u.stop = nil
+	}
+}
+
+// checkInBackground notifies about a newer release, once per version.
+func (u *updateChecker) checkInBackground() {
+	ctx, cancel := context.WithTimeout(context.Background(), updateCheckTimeout)
+	defer cancel()
+	release, err := update.Latest(ctx)
+	if err != nil {
+		log.Printf("Update check failed: %v", err)
+		return
+	}
+	if !update.Newer(u.app.version, release.TagName) {
+		log.Printf("Update check: %s is up to date (latest release %s).", u.app.version, release.TagName)
+		return
+	}
+
+	u.mu.Lock()
+	alreadyNotified := u.notified == release.TagName
+	u.notified = release.TagName
+	u.mu.Unlock()
+
+	if u.app.systrayManager != nil {
+		u.app.systrayManager.SetUpdateAvailable(release.TagName)
+	}
+	if alreadyNotified {
+		return
+	}
+	log.Printf("Update available: %s (running %s).", release.TagName, u.app.version)
+	ui.ShowAdminNotification(ui.LevelWarn, "Update Available",
+		i18n.Tf("Clipboard Regex Replace %s is available (you have %s). Use 'Check for Updates...' in the tray menu to install it.", release.TagName, u.app.version))
+}
+
+// onCheckForUpdates is called when the "Check for Updates..." menu item is
+// clicked. It offers to install a newer release and restart.
+func (a *Application) onCheckForUpdates() {
+	u := a.updates
+	if !u.installing.CompareAndSwap(false, true) {
+		log.Println("Update already in progress, ignoring click.")
+		return
+	}
+	defer u.installing.Store(false)
+
+	title := zenity.Title(config.DefaultKeyringService + " - " + i18n.T("Updates"))
+	ctx, cancel := context.WithTimeout(context.Background(), updateCheckTimeout)
+	release, err := update.Latest(ctx)
+	cancel()
+	if err != nil {
+		log.Printf("Update check failed: %v", err)
+		ui.ShowAdminNotification(ui.LevelError, "Update Check Failed", err.Error())
+		return
+	}
+	if !update.Newer(a.version, release.TagName) {
+		zenity.Info(i18n.Tf("You are running the latest version (%s).", a.version), title, zenity.InfoIcon)
+		return
+	}
+	if a.systrayManager != nil {
+		a.systrayManager.SetUpdateAvailable(release.TagName)
+	}
+
+	releasePage := release.HTMLURL
+	if releasePage == "" {
+		releasePage = update.ReleasesPage
+	}
+	openReleasePage := func() {
+		if err := ui.OpenFileInDefaultApp(releasePage); err != nil {
+			log.Printf("Error opening release page: %v", err)
+			ui.ShowAdminNotification(ui.LevelError, "Error Opening Page", i18n.Tf("Open %s in your browser.", releasePage))
+		}
+	}
+
+	asset, assetErr := release.Asset()
+	exe, exeErr := os.Executable()
+	if exeErr == nil {
+		if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+			exe = resolved
+		}
+	}
+	if assetErr != nil || exeErr != nil || ui.IsDevMode() {
+		reason := i18n.T("It cannot be installed automatically on this system.")
+		if errors.Is(assetErr, update.ErrNoAsset) {
+			reason = i18n.T("The release has no executable for this platform.")
+		}
+		err := zenity.Question(
+			i18n.Tf("Version %s is available (you have %s).\n\n%s", release.TagName, a.version, reason),
+			title, zenity.InfoIcon, zenity.OKLabel(i18n.T("Open Release Page")), zenity.CancelLabel(i18n.T("Later")))
+		if err == nil {
+			openReleasePage()
+		}
+		return
+	}
+
+	err = zenity.Question(
+		i18n.Tf("Version %s is available (you have %s).\n\nDownload %s and restart the application now?", release.TagName, a.version, asset.Name),
+		title,
+		zenity.InfoIcon,
+		zenity.OKLabel(i18n.T("Install and Restart")),
+		zenity.ExtraButton(i18n.T("Open Release Page")),
+		zenity.CancelLabel(i18n.T("Later")),
+	)
+	switch {
+	case errors.Is(err, zenity.ErrExtraButton):
+		openReleasePage()
+		return
+	case err != nil:
+		if !errors.Is(err, zenity.ErrCanceled) {
+			log.Printf("Error displaying update dialog: %v", err)
+		}
+		return
+	}
+
+	log.Printf("Installing %s from %s to %s...", release.TagName, asset.URL, exe)
+	ui.ShowAdminNotification(ui.LevelInfo, "Downloading Update", i18n.Tf("Downloading %s...", release.TagName))
+	ctx, cancel = context.WithTimeout(context.Background(), updateInstallTime)
+	defer cancel()
+	if err := update.Install(ctx, release, asset, exe); err != nil {
+		log.Printf("Error installing update: %v", err)
+		ui.ShowAdminNotification(ui.LevelError, "Update Failed", i18n.Tf("%v. The current version is still installed.", err))
+		return
+	}
+	log.Printf("Installed %s, restarting.", release.TagName)
+	a.onRestartApplication()
+}
@@ -0,0 +1,199 @@
+package app
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/hotkey"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/ui"
+)
+
+// clipboardWatchInterval is how often the watched clipboard is read where
+// the clipboard backend doesn't report changes.
+const clipboardWatchInterval = time.Second
+
+// clipboardWatcher processes copied text with the enabled profiles, so the
+// application stays usable where hotkeys can't be registered, e.g. on Wayland
+// without the GlobalShortcuts portal. The results are not pasted.
+type clipboardWatcher struct {
+	app  *Application
+	mu   sync.Mutex
+	stop chan struct{} // nil while not watching
+}
+
+func newClipboardWatcher(app *Application) *clipboardWatcher {
+	return &clipboardWatcher{app: app}
+}
+
+// start reads the clipboard whenever the clipboard backend reports a change,
+// or periodically where it can't. Text that was on the clipboard before is
+// left alone.
+func (w *clipboardWatcher) start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stop != nil {
+		return
+	}
+	w.stop = make(chan struct{})
+	log.Println("Clipboard watcher started: copied text is processed by the enabled profiles.")
+
+	go func(stop chan struct{}) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("RECOVERED FROM PANIC IN CLIPBOARD WATCHER: %v", r)
+			}
+		}()
+		last, _ := w.app.clipboardManager.ReadText()
+
+		changes := make(chan struct{}, 1)
+		var tick <-chan time.Time
+		var watchDone <-chan struct{}
+		watch, err := w.app.clipboardManager.WatchChanges(func() {
+			select {
+			case changes <- struct{}{}:
+			default: // A read is pending anyway
+			}
+		})
+		if err == nil {
+			defer watch.Close()
+			watchDone = watch.Done()
+			log.Println("Clipboard watcher: reading the clipboard when it reports a change.")
+		} else {
+			log.Printf("Clipboard watcher: no change events (%v), reading the clipboard every %v.", err, clipboardWatchInterval)
+			ticker := time.NewTicker(clipboardWatchInterval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-watchDone:
+				log.Printf("Clipboard watcher: change events stopped (%v), reading the clipboard every %v.", watch.Err(), clipboardWatchInterval)
+				watchDone = nil
+				ticker := time.NewTicker(clipboardWatchInterval)
+				defer ticker.Stop()
+				tick = ticker.C
+				continue
+			case <-changes:
+			case <-tick:
+			}
+			text, err := w.app.clipboardManager.ReadText()
+			if err != nil || text == last {
+				continue // Errors are expected while the clipboard holds no text
+			}
+			last = text
+			w.app.processCopied()
+		}
+	}(w.stop)
+}
+
+// close stops watching.
+func (w *clipboardWatcher) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stop != nil {
+		close(w.stop)
+		w.stop = nil
+		log.Println("Clipboard watcher stopped.")
+	}
+}
+
+// running reports whether the clipboard is watched.
+func (w *clipboardWatcher) running() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.stop != nil
+}
+
+// applyClipboardWatch starts or stops the clipboard watcher as clipboard_watch
+// and the hotkey backend require. If announce is set, or the limited mode was
+// just entered, the user is told what works without hotkeys.
+func (a *Application) applyClipboardWatch(announce bool) {
+	unavailable := a.hotkeyManager != nil && a.hotkeyManager.Unavailable()
+	watch := false
+	switch a.config.GetClipboardWatch() {
+	case config.ClipboardWatchOn:
+		watch = true
+	case config.ClipboardWatchAuto:
+		watch = unavailable
+	}
+
+	wasRunning := a.watcher.running()
+	if watch {
+		a.watcher.start()
+	} else {
+		a.watcher.close()
+	}
+	if unavailable && (announce || watch != wasRunning) {
+		a.announceLimitedMode(watch)
+	}
+}
+
+// announceLimitedMode tells the user that global hotkeys are unavailable and
+// which ways of running profiles remain, instead of leaving them with hotkeys
+// that silently do nothing.
+func (a *Application) announceLimitedMode(watching bool) {
+	display := hotkey.DetectDisplayServer()
+	var ways []string
+	if watching {
+		ways = append(ways, i18n.T("copied text is processed by the enabled profiles right away; paste the result yourself"))
+	}
+	ways = append(ways, i18n.T("'Run Now' in a profile's submenu of the tray menu runs it"))
+	if a.commands != nil {
+		ways = append(ways, i18n.T("'clipregex trigger' runs a profile, e.g. from a keybinding of the compositor"))
+	}
+	log.Printf("No hotkey backend works with the %s display server. Limited mode: %s.", display, strings.Join(ways, "; "))
+	msg := i18n.Tf("Global hotkeys are not available on %s. Limited mode: %s.", display, strings.Join(ways, "; "))
+	ui.ShowAdminNotification(ui.LevelWarn, "Hotkeys Unavailable", msg)
+}
+
+// processCopied runs the enabled profiles on newly copied text. Unlike after
+// a hotkey press, skipped content goes unmentioned: most copies are not meant
+// for the profiles.
+func (a *Application) processCopied() {
+	if a.paused.Load() {
+		return
+	}
+	if !a.processing.CompareAndSwap(false, true) {
+		log.Println("Copied text ignored: the previous clipboard is still being processed.")
+		return
+	}
+	defer a.processing.Store(false)
+
+	if a.systrayManager != nil {
+		a.systrayManager.SetProcessing(true)
+		defer a.systrayManager.SetProcessing(false)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancelMu.Lock()
+	a.cancelProcessing = cancel
+	a.cancelMu.Unlock()
+	defer func() {
+		a.cancelMu.Lock()
+		a.cancelProcessing = nil
+		a.cancelMu.Unlock()
+		cancel()
+	}()
+
+	message, changedForDiff := a.clipboardManager.ProcessCopied(ctx)
+	if actionErrors := a.clipboardManager.LastActionErrors(); len(actionErrors) > 0 {
+		ui.ShowAdminNotification(ui.LevelWarn, "Transformation Skipped", strings.Join(actionErrors, "\n"))
+	}
+	if reports := a.clipboardManager.LastTransformerReports(); len(reports) > 0 {
+		ui.ShowAdminNotification(ui.LevelInfo, "Transformer Report", strings.Join(reports, "\n"))
+	}
+	if message != "" {
+		ui.ShowReplacementNotificationWithActions("Clipboard Updated", message, a.clipboardManager.LastReplacementCount(), a.replacementActions(changedForDiff))
+	}
+	if a.systrayManager != nil {
+		_, _, diffAvailable := a.clipboardManager.GetLastDiff()
+		a.systrayManager.UpdateViewLastDiffStatus(diffAvailable)
+	}
+}
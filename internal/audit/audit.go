@@ -0,0 +1,196 @@
+// Package audit keeps an append-only log of clipboard transformations that
+// contains no clipboard content: when a hotkey ran which profiles and rules,
+// how many replacements they made, and SHA-256 hashes of the text before and
+// after. Hashing a text later shows whether it was the input or the result of
+// a logged run, e.g. whether a redaction ran before something was pasted.
+// The log is a JSON Lines file, one record per line.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileName is the audit log stored next to config.json.
+const FileName = "audit.log"
+
+// pruneInterval is how often Append drops records past the retention period.
+const pruneInterval = 24 * time.Hour
+
+// Rule is a rule that changed the text in a logged run.
+type Rule struct {
+	Profile     string `json:"profile"`
+	Index       int    `json:"rule"`                  // 1-based position in the profile, after included rule groups
+	Description string `json:"description,omitempty"` // The rule's description, if it has one
+	Fingerprint string `json:"fingerprint"`           // Hash of the rule's definition; changes when the rule is edited
+	Matches     int    `json:"matches"`
+}
+
+// Record is one hotkey press or trigger that ran profiles on the clipboard.
+type Record struct {
+	Time         time.Time `json:"time"`
+	Hotkey       string    `json:"hotkey,omitempty"`
+	Reverse      bool      `json:"reverse,omitempty"`
+	Profiles     []string  `json:"profiles"` // Profiles that ran, whether or not they changed the text
+	Rules        []Rule    `json:"rules,omitempty"`
+	Replacements int       `json:"replacements"`
+	Changed      bool      `json:"changed"`
+	BeforeSHA256 string    `json:"before_sha256"`
+	AfterSHA256  string    `json:"after_sha256"`
+	BeforeBytes  int       `json:"before_bytes"`
+	AfterBytes   int       `json:"after_bytes"`
+}
+
+// Log appends records to the audit log file.
+type Log struct {
+	mu        sync.Mutex
+	path      string
+	maxAge    time.Duration // 0 keeps records forever
+	lastPrune time.Time
+}
+
+// PathForConfig returns the audit log path for a config file path.
+func PathForConfig(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), FileName)
+}
+
+// Hash returns the hex SHA-256 hash of text, as stored in records.
+func Hash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// Fingerprint returns a short hash of a rule's definition, e.g. a
+// config.Replacement, so records show whether a rule was edited since.
+func Fingerprint(rule any) string {
+	data, err := json.Marshal(rule)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+}
+
+// Open returns the log at path, dropping records older than maxAge (0 keeps
+// them forever). A missing file is created by the first Append.
+func Open(path string, maxAge time.Duration) (*Log, error) {
+	l := &Log{path: path, maxAge: maxAge}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.pruneLocked(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// SetRetention changes the retention period, e.g. after the config was
+// reloaded, and drops the records past it.
+func (l *Log) SetRetention(maxAge time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.maxAge = maxAge
+	return l.pruneLocked()
+}
+
+// Append adds a record at the end of the file.
+func (l *Log) Append(rec Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if time.Since(l.lastPrune) >= pruneInterval {
+		if err := l.pruneLocked(); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log '%s': %w", l.path, err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write audit log '%s': %w", l.path, err)
+	}
+	return f.Close()
+}
+
+// Read returns the records of the log at path, oldest first. Lines that are
+// not valid records, e.g. cut off by a crash, are skipped.
+func Read(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read audit log '%s': %w", path, err)
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16*1024*1024) // Records with many rules can be long
+	for scanner.Scan() {
+		var rec Record
+		if json.Unmarshal(scanner.Bytes(), &rec) == nil {
+			records = append(records, rec)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log '%s': %w", path, err)
+	}
+	return records, nil
+}
+
+// pruneLocked rewrites the file without the records past the retention
+// period. Lines that are not valid records are kept. The caller must hold
+// l.mu.
+func (l *Log) pruneLocked() error {
+	l.lastPrune = time.Now()
+	if l.maxAge <= 0 {
+		return nil
+	}
+	data, err := os.ReadFile(l.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read audit log '%s': %w", l.path, err)
+	}
+
+	cutoff := time.Now().Add(-l.maxAge)
+	var kept bytes.Buffer
+	dropped := 0
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		var rec struct {
+			Time time.Time `json:"time"`
+		}
+		if json.Unmarshal(line, &rec) == nil && rec.Time.Before(cutoff) {
+			dropped++
+			continue
+		}
+		kept.Write(line)
+	}
+	if dropped == 0 {
+		return nil
+	}
+
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, kept.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write audit log '%s': %w", tmp, err)
+	}
+	if err := os.Rename(tmp, l.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace audit log '%s': %w", l.path, err)
+	}
+	log.Printf("Dropped %d audit log record(s) older than %v.", dropped, l.maxAge)
+	return nil
+}
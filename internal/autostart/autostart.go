@@ -0,0 +1,47 @@
+// Package autostart registers the application to start when the user logs
+// in: a value under the Run registry key on Windows, a .desktop file in the
+// XDG autostart folder on Linux and a LaunchAgent on macOS.
+package autostart
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// entryName identifies the autostart entry (registry value, desktop file and
+// LaunchAgent label are derived from it).
+const entryName = "ClipboardRegexReplace"
+
+// executable returns the absolute path of the running executable, with
+// symlinks resolved so the entry survives e.g. a "go run" cache cleanup.
+func executable() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to get executable path: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	return exe, nil
+}
+
+// Enable creates or updates the autostart entry so the running executable
+// starts with args at login.
+func Enable(args []string) error {
+	exe, err := executable()
+	if err != nil {
+		return err
+	}
+	return enable(exe, args)
+}
+
+// Disable removes the autostart entry. It is not an error if there is none.
+func Disable() error {
+	return disable()
+}
+
+// Enabled reports whether an autostart entry exists.
+func Enabled() (bool, error) {
+	return enabled()
+}
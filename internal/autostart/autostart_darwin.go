@@ -0,0 +1,89 @@
+//go:build darwin
+// +build darwin
+
+package autostart
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// agentLabel is the LaunchAgent's label and file name.
+const agentLabel = "com.tanarosch.clipregex"
+
+// agentFile returns the path of the LaunchAgent,
+// ~/Library/LaunchAgents/com.tanarosch.clipregex.plist.
+func agentFile() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the home directory: %w", err)
+	}
+	return filepath.Join(home, "Library", "LaunchAgents", agentLabel+".plist"), nil
+}
+
+// plistString writes value as an escaped <string> element.
+func plistString(b *bytes.Buffer, indent, value string) {
+	b.WriteString(indent + "<string>")
+	xml.EscapeText(b, []byte(value))
+	b.WriteString("</string>\n")
+}
+
+func enable(exe string, args []string) error {
+	path, err := agentFile()
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	b.WriteString("<plist version=\"1.0\">\n<dict>\n")
+	b.WriteString("\t<key>Label</key>\n")
+	plistString(&b, "\t", agentLabel)
+	b.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	plistString(&b, "\t\t", exe)
+	for _, arg := range args {
+		plistString(&b, "\t\t", arg)
+	}
+	b.WriteString("\t</array>\n")
+	b.WriteString("\t<key>RunAtLoad</key>\n\t<true/>\n")
+	b.WriteString("\t<key>ProcessType</key>\n")
+	plistString(&b, "\t", "Interactive")
+	b.WriteString("</dict>\n</plist>\n")
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create LaunchAgents folder: %w", err)
+	}
+	if err := os.WriteFile(path, b.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write LaunchAgent '%s': %w", path, err)
+	}
+	return nil
+}
+
+func disable() error {
+	path, err := agentFile()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove LaunchAgent '%s': %w", path, err)
+	}
+	return nil
+}
+
+func enabled() (bool, error) {
+	path, err := agentFile()
+	if err != nil {
+		return false, err
+	}
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check LaunchAgent '%s': %w", path, err)
+	}
+	return true, nil
+}
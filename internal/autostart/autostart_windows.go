@@ -0,0 +1,126 @@
+//go:build windows
+// +build windows
+
+package autostart
+
+import (
+	"fmt"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32             = syscall.NewLazyDLL("advapi32.dll")
+	procRegCreateKeyExW  = advapi32.NewProc("RegCreateKeyExW")
+	procRegSetValueExW   = advapi32.NewProc("RegSetValueExW")
+	procRegDeleteValueW  = advapi32.NewProc("RegDeleteValueW")
+	procRegQueryValueExW = advapi32.NewProc("RegQueryValueExW")
+)
+
+const (
+	hkeyCurrentUser = 0x80000001
+	keyQueryValue   = 0x0001
+	keySetValue     = 0x0002
+	regSZ           = 1
+
+	errorFileNotFound = 2
+)
+
+// runKey is the per-user key whose values Windows runs at login.
+const runKey = `Software\Microsoft\Windows\CurrentVersion\Run`
+
+// openRunKey opens (or creates) the Run key of the current user.
+func openRunKey(access uint32) (syscall.Handle, error) {
+	path, err := syscall.UTF16PtrFromString(runKey)
+	if err != nil {
+		return 0, err
+	}
+	var key syscall.Handle
+	ret, _, _ := procRegCreateKeyExW.Call(
+		hkeyCurrentUser,
+		uintptr(unsafe.Pointer(path)),
+		0, 0, 0,
+		uintptr(access),
+		0,
+		uintptr(unsafe.Pointer(&key)),
+		0,
+	)
+	if ret != 0 {
+		return 0, fmt.Errorf("failed to open registry key HKCU\\%s: %w", runKey, syscall.Errno(ret))
+	}
+	return key, nil
+}
+
+func enable(exe string, args []string) error {
+	parts := []string{`"` + exe + `"`}
+	for _, arg := range args {
+		parts = append(parts, syscall.EscapeArg(arg))
+	}
+	command, err := syscall.UTF16FromString(strings.Join(parts, " "))
+	if err != nil {
+		return err
+	}
+	name, err := syscall.UTF16PtrFromString(entryName)
+	if err != nil {
+		return err
+	}
+
+	key, err := openRunKey(keySetValue)
+	if err != nil {
+		return err
+	}
+	defer syscall.RegCloseKey(key)
+
+	ret, _, _ := procRegSetValueExW.Call(
+		uintptr(key),
+		uintptr(unsafe.Pointer(name)),
+		0,
+		regSZ,
+		uintptr(unsafe.Pointer(&command[0])),
+		uintptr(len(command)*2), // Size in bytes, including the terminating zero
+	)
+	if ret != 0 {
+		return fmt.Errorf("failed to write registry value '%s': %w", entryName, syscall.Errno(ret))
+	}
+	return nil
+}
+
+func disable() error {
+	name, err := syscall.UTF16PtrFromString(entryName)
+	if err != nil {
+		return err
+	}
+	key, err := openRunKey(keySetValue)
+	if err != nil {
+		return err
+	}
+	defer syscall.RegCloseKey(key)
+
+	ret, _, _ := procRegDeleteValueW.Call(uintptr(key), uintptr(unsafe.Pointer(name)))
+	if ret != 0 && ret != errorFileNotFound {
+		return fmt.Errorf("failed to delete registry value '%s': %w", entryName, syscall.Errno(ret))
+	}
+	return nil
+}
+
+func enabled() (bool, error) {
+	name, err := syscall.UTF16PtrFromString(entryName)
+	if err != nil {
+		return false, err
+	}
+	key, err := openRunKey(keyQueryValue)
+	if err != nil {
+		return false, err
+	}
+	defer syscall.RegCloseKey(key)
+
+	ret, _, _ := procRegQueryValueExW.Call(uintptr(key), uintptr(unsafe.Pointer(name)), 0, 0, 0, 0)
+	switch ret {
+	case 0:
+		return true, nil
+	case errorFileNotFound:
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to read registry value '%s': %w", entryName, syscall.Errno(ret))
+}
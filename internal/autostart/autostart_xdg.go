@@ -0,0 +1,104 @@
+//go:build !windows && !darwin
+// +build !windows,!darwin
+
+package autostart
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// desktopFile returns the path of the XDG autostart entry,
+// $XDG_CONFIG_HOME/autostart/clipregex.desktop.
+func desktopFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the user config directory: %w", err)
+	}
+	return filepath.Join(dir, "autostart", "clipregex.desktop"), nil
+}
+
+// quoteExecArg quotes an argument for the Exec key of a desktop entry,
+// following the quoting rules of the Desktop Entry Specification.
+func quoteExecArg(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n\"'\\><~|&;$*?#()`%=") {
+		return arg
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`", `$`, `\$`, `%`, `%%`)
+	return `"` + replacer.Replace(arg) + `"`
+}
+
+// DesktopExec returns the value of the Exec key of a desktop entry running
+// exe with args, escaped for the desktop entry file.
+func DesktopExec(exe string, args []string) string {
+	parts := []string{quoteExecArg(exe)}
+	for _, arg := range args {
+		parts = append(parts, quoteExecArg(arg))
+	}
+	// The string escape rule applies before the quoting rule, so backslashes are doubled again
+	return strings.ReplaceAll(strings.Join(parts, " "), `\`, `\\`)
+}
+
+func enable(exe string, args []string) error {
+	path, err := desktopFile()
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	b.WriteString("[Desktop Entry]\n")
+	b.WriteString("Type=Application\n")
+	b.WriteString("Name=Clipboard Regex Replace\n")
+	b.WriteString("Comment=Apply regex replacements to the clipboard with global hotkeys\n")
+	b.WriteString("Exec=" + DesktopExec(exe, args) + "\n")
+	b.WriteString("Icon=clipboardregexreplace\n")
+	b.WriteString("Terminal=false\n")
+	b.WriteString("X-GNOME-Autostart-enabled=true\n")
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create autostart folder: %w", err)
+	}
+	if err := os.WriteFile(path, b.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write autostart entry '%s': %w", path, err)
+	}
+	return nil
+}
+
+func disable() error {
+	path, err := desktopFile()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove autostart entry '%s': %w", path, err)
+	}
+	return nil
+}
+
+// enabled reports whether the desktop file exists and is not hidden or
+// disabled, which desktop environments do instead of deleting it.
+func enabled() (bool, error) {
+	path, err := desktopFile()
+	if err != nil {
+		return false, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read autostart entry '%s': %w", path, err)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		switch strings.TrimSpace(scanner.Text()) {
+		case "Hidden=true", "X-GNOME-Autostart-enabled=false":
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,47 @@
+//go:build darwin && cgo
+// +build darwin,cgo
+
+package clipboard
+
+/*
+#cgo LDFLAGS: -framework ApplicationServices -framework CoreFoundation
+#include <ApplicationServices/ApplicationServices.h>
+
+static int clipregexAccessibilityTrusted(void) {
+	return AXIsProcessTrusted() ? 1 : 0;
+}
+
+// clipregexPostCommandV posts Cmd+V (virtual key 9, kVK_ANSI_V) down and up.
+static int clipregexPostCommandV(void) {
+	CGEventSourceRef source = CGEventSourceCreate(kCGEventSourceStateCombinedSessionState);
+	CGEventRef down = CGEventCreateKeyboardEvent(source, (CGKeyCode)9, true);
+	CGEventRef up = CGEventCreateKeyboardEvent(source, (CGKeyCode)9, false);
+	int ok = down != NULL && up != NULL;
+	if (ok) {
+		CGEventSetFlags(down, kCGEventFlagMaskCommand);
+		CGEventSetFlags(up, kCGEventFlagMaskCommand);
+		CGEventPost(kCGHIDEventTap, down);
+		CGEventPost(kCGHIDEventTap, up);
+	}
+	if (down != NULL) CFRelease(down);
+	if (up != NULL) CFRelease(up);
+	if (source != NULL) CFRelease(source);
+	return ok;
+}
+*/
+import "C"
+
+import "errors"
+
+// accessibilityTrusted reports whether the process may post keyboard events.
+func accessibilityTrusted() bool {
+	return C.clipregexAccessibilityTrusted() != 0
+}
+
+// postCommandV presses Cmd+V in the focused application.
+func postCommandV() error {
+	if C.clipregexPostCommandV() == 0 {
+		return errors.New("failed to create keyboard events")
+	}
+	return nil
+}
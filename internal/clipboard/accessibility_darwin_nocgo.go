@@ -0,0 +1,16 @@
+//go:build darwin && !cgo
+// +build darwin,!cgo
+
+package clipboard
+
+import "errors"
+
+// accessibilityTrusted cannot ask macOS without cgo. It returns true, and a
+// missing permission is detected from the osascript error instead.
+func accessibilityTrusted() bool {
+	return true
+}
+
+func postCommandV() error {
+	return errors.New("CGEvent paste requires a build with cgo")
+}
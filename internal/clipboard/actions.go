@@ -0,0 +1,131 @@
+package clipboard
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/actions"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+)
+
+// ruleAction returns the transformation of an action, script, command,
+// transformer or llm rule and how to name it in messages. Script paths, program paths containing a
+// directory and the command's working directory are relative to config.json.
+func (m *Manager) ruleAction(rep config.Replacement) (actions.Action, string, error) {
+	resolvePath := func(path string) string { return path }
+	m.mu.RLock()
+	if m.config != nil {
+		resolvePath = m.config.ResolvePath
+	}
+	m.mu.RUnlock()
+
+	if rep.Script != "" {
+		return actions.Script(resolvePath(rep.Script)), fmt.Sprintf("script '%s'", rep.Script), nil
+	}
+	if rep.Command != nil {
+		if len(rep.Command.Args) == 0 {
+			return nil, "", fmt.Errorf("command rule has no program")
+		}
+		args := append([]string(nil), rep.Command.Args...)
+		if strings.ContainsAny(args[0], `/\`) {
+			args[0] = resolvePath(args[0]) // "scripts/fix.sh"; bare names are looked up in PATH
+		}
+		action := actions.Command(args, resolvePath("."), rep.Command.GetTimeout(), rep.Command.GetMaxOutputBytes())
+		return action, fmt.Sprintf("command '%s'", rep.Command.Args[0]), nil
+	}
+	if rep.LLM != nil {
+		action, err := m.llmAction(rep)
+		if err != nil {
+			return nil, "", err
+		}
+		return action, "llm rule", nil
+	}
+	if rep.Transformer != "" {
+		t, ok := m.lookupTransformer(rep.Transformer)
+		if !ok {
+			return nil, "", fmt.Errorf("unknown transformer '%s'", rep.Transformer)
+		}
+		options := rep.TransformerOptions
+		action := func(text string) (string, error) { return t.Transform(text, options) }
+		if reporter, ok := t.(Reporter); ok {
+			action = func(text string) (string, error) {
+				result, report, err := reporter.TransformAndReport(text, options)
+				if err == nil && report != "" {
+					m.addTransformerReport(report)
+				}
+				return result, err
+			}
+		}
+		return action, fmt.Sprintf("transformer '%s'", rep.Transformer), nil
+	}
+	if rep.Action == actions.StripTrackingAction && m.config != nil {
+		m.mu.RLock()
+		rules := m.config.TrackingRules() // The bundled list with the config's additions
+		m.mu.RUnlock()
+		return actions.StripTracking(rules), fmt.Sprintf("action '%s'", rep.Action), nil
+	}
+	if action, ok, err := actions.NumberAction(rep.Action, rep.GetNumberFormat()); ok {
+		if err != nil {
+			return nil, "", err // Caught by validation
+		}
+		return action, fmt.Sprintf("action '%s'", rep.Action), nil // Follows numbers
+	}
+	if action, ok := actions.CaseAction(rep.Action, m.casing(rep)); ok {
+		return action, fmt.Sprintf("action '%s'", rep.Action), nil // Follows case_locale
+	}
+	action, ok := actions.Lookup(rep.Action)
+	if !ok {
+		return nil, "", fmt.Errorf("unknown action '%s'", rep.Action)
+	}
+	return action, fmt.Sprintf("action '%s'", rep.Action), nil
+}
+
+// applyAction runs the action or script of rep on the whole text if re is
+// nil, or on every match of re otherwise, honouring max_count. It returns the
+// number of changed matches, or 1 if the whole text changed.
+func (m *Manager) applyAction(text string, re *regexp.Regexp, rep config.Replacement, timeoutMs int) (string, int, error) {
+	action, name, err := m.ruleAction(rep)
+	if err != nil {
+		return text, 0, err
+	}
+
+	if re == nil {
+		result, err := action(text)
+		if err != nil {
+			return text, 0, fmt.Errorf("%s failed: %w", name, err)
+		}
+		if result == text {
+			return text, 0, nil
+		}
+		return result, 1, nil
+	}
+
+	var actionErr error
+	matches, changed := 0, 0
+	result, err := replaceAllStringFuncWithTimeout(re, text, func(match string) string {
+		if actionErr != nil || (rep.MaxCount > 0 && matches >= rep.MaxCount) {
+			return match // Keep matches beyond max_count
+		}
+		matches++
+		transformed, err := action(match)
+		if err != nil {
+			actionErr = err
+			return match
+		}
+		if transformed != match {
+			changed++
+		}
+		return transformed
+	}, timeoutMs)
+	if err != nil {
+		return text, 0, fmt.Errorf("%s failed: %w", name, err)
+	}
+	if actionErr != nil {
+		return text, 0, fmt.Errorf("%s failed on match #%d: %w", name, matches, actionErr)
+	}
+	if result == text {
+		return text, 0, nil
+	}
+	return result, changed, nil
+}
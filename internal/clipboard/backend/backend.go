@@ -0,0 +1,230 @@
+// Package backend abstracts the system clipboard. Backends read and write
+// its text, list the formats on it, such as images, rich text or copied
+// files, so non-text content is not processed as text, and save and restore
+// all formats where the platform allows it.
+package backend
+
+import (
+	"errors"
+	"log"
+	"strings"
+)
+
+// ErrBackendNotAvailable is returned when no clipboard backend can be used on
+// the current system.
+var ErrBackendNotAvailable = errors.New("no clipboard backend available on this system")
+
+// Backend is an interface that abstracts the clipboard implementations of the
+// different platforms: the Win32 clipboard API, wl-clipboard on Wayland,
+// xclip or xsel on X11 and pbcopy/pbpaste on macOS.
+type Backend interface {
+	// ReadText returns the text on the clipboard.
+	ReadText() (string, error)
+
+	// WriteText replaces the clipboard content with text.
+	WriteText(text string) error
+
+	// Formats lists the formats currently on the clipboard.
+	Formats() (Contents, error)
+
+	// Save copies all clipboard formats. It returns ErrUnsupported if the
+	// backend can only write text.
+	Save() (*Snapshot, error)
+
+	// Restore replaces the clipboard content with a snapshot taken by Save.
+	Restore(snapshot *Snapshot) error
+
+	// Name returns a human-readable name for this backend (for logging).
+	Name() string
+
+	// IsAvailable returns true if this backend can be used on the current system.
+	IsAvailable() bool
+}
+
+// ChangeWatcher is implemented by backends that the platform tells when the
+// clipboard changes, so it needn't be read periodically to find out: a
+// clipboard format listener on Windows, "wl-paste --watch" on Wayland and
+// clipnotify on X11.
+type ChangeWatcher interface {
+	// WatchChanges calls onChange after each change of the clipboard,
+	// including those by WriteText, until the Watch is closed. onChange is
+	// called from the watching goroutine and must not block.
+	WatchChanges(onChange func()) (*Watch, error)
+}
+
+// ChangeCounter is implemented by backends whose platform numbers the
+// clipboard changes, like the clipboard sequence number on Windows.
+type ChangeCounter interface {
+	// ChangeCount returns the number of the current clipboard content. ok is
+	// false if it is unknown.
+	ChangeCount() (count uint64, ok bool)
+}
+
+// ErrWatchUnsupported is returned by WatchChanges where the clipboard can't be
+// watched for changes, e.g. on macOS or on X11 without clipnotify.
+var ErrWatchUnsupported = errors.New("clipboard change events are not supported")
+
+// WatchChanges subscribes to the clipboard changes of b, see ChangeWatcher.
+func WatchChanges(b Backend, onChange func()) (*Watch, error) {
+	watcher, ok := b.(ChangeWatcher)
+	if !ok {
+		return nil, ErrWatchUnsupported
+	}
+	return watcher.WatchChanges(onChange)
+}
+
+// ChangeCount returns the number of the current clipboard content if b
+// counts changes, see ChangeCounter.
+func ChangeCount(b Backend) (uint64, bool) {
+	counter, ok := b.(ChangeCounter)
+	if !ok {
+		return 0, false
+	}
+	return counter.ChangeCount()
+}
+
+// Watch is a subscription to clipboard changes made by WatchChanges.
+type Watch struct {
+	cancel func()        // Ends the subscription; may be called more than once
+	done   chan struct{} // Closed when the subscription has ended
+	err    error         // Why it ended on its own, set before done is closed
+}
+
+func newWatch(cancel func()) *Watch {
+	return &Watch{cancel: cancel, done: make(chan struct{})}
+}
+
+// end marks the subscription as ended, with err if it failed.
+func (w *Watch) end(err error) {
+	w.err = err
+	close(w.done)
+}
+
+// Done is closed when no more changes are reported, after Close or because
+// watching failed, e.g. as the compositor lacks the data control protocol.
+func (w *Watch) Done() <-chan struct{} {
+	return w.done
+}
+
+// Err returns why watching failed once Done is closed, or nil after Close.
+func (w *Watch) Err() error {
+	<-w.done
+	return w.err
+}
+
+// Close ends the subscription and waits for it to end.
+func (w *Watch) Close() {
+	w.cancel()
+	<-w.done
+}
+
+// SelectBackend returns the first available backend for the current platform,
+// in order of preference. If none is available, the preferred backend is
+// returned anyway so that clipboard operations fail with its error, such as
+// a missing xclip, instead of silently doing nothing.
+func SelectBackend() Backend {
+	backends := candidates()
+	for _, backend := range backends {
+		if backend.IsAvailable() {
+			log.Printf("Selected clipboard backend: %s", backend.Name())
+			return backend
+		}
+	}
+	log.Printf("Warning: %v. Falling back to %s; clipboard operations will fail until it is installed.", ErrBackendNotAvailable, backends[0].Name())
+	return backends[0]
+}
+
+// Kind groups clipboard formats by what they hold.
+type Kind string
+
+const (
+	KindText     Kind = "text"
+	KindRichText Kind = "rich text"
+	KindImage    Kind = "image"
+	KindFiles    Kind = "files"
+	KindOther    Kind = "other"
+)
+
+// Format is one representation of the clipboard content, such as
+// "CF_UNICODETEXT" on Windows or "image/png" on Linux.
+type Format struct {
+	Name string
+	Kind Kind
+}
+
+// Contents lists the formats currently on the clipboard.
+type Contents []Format
+
+// Has reports whether the clipboard has a format of the given kind.
+func (c Contents) Has(kind Kind) bool {
+	for _, format := range c {
+		if format.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// HasText reports whether the clipboard has a plain text representation.
+func (c Contents) HasText() bool {
+	return c.Has(KindText)
+}
+
+// TextOnly reports whether the clipboard holds nothing but plain text, so
+// writing the text back loses nothing.
+func (c Contents) TextOnly() bool {
+	for _, format := range c {
+		if format.Kind != KindText {
+			return false
+		}
+	}
+	return true
+}
+
+// Describe names the most notable non-text content for messages, e.g.
+// "an image" or "copied files".
+func (c Contents) Describe() string {
+	switch {
+	case c.Has(KindImage):
+		return "an image"
+	case c.Has(KindFiles):
+		return "copied files"
+	case c.Has(KindRichText):
+		return "formatted text"
+	}
+	return "non-text data"
+}
+
+// String lists the format names, for logging.
+func (c Contents) String() string {
+	names := make([]string, len(c))
+	for i, format := range c {
+		names[i] = format.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// ErrUnsupported is returned by Save on backends that can only write the text
+// of the clipboard back.
+var ErrUnsupported = errors.New("saving clipboard formats other than text is not supported on this platform")
+
+// Snapshot is a copy of all clipboard formats taken by Save.
+type Snapshot struct {
+	items []item
+}
+
+// item is the data of one saved format.
+type item struct {
+	Format
+	id   uint32 // Platform format identifier
+	data []byte
+}
+
+// Formats returns the saved formats.
+func (s *Snapshot) Formats() Contents {
+	formats := make(Contents, len(s.items))
+	for i, it := range s.items {
+		formats[i] = it.Format
+	}
+	return formats
+}
@@ -0,0 +1,157 @@
+//go:build !windows
+// +build !windows
+
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// CommandBackend runs command line clipboard tools, such as wl-paste and
+// wl-copy on Wayland, xclip or xsel on X11 and pbpaste and pbcopy on macOS.
+type CommandBackend struct {
+	name         string
+	read         []string              // Prints the clipboard text
+	write        []string              // Reads the new clipboard text from stdin
+	list         []string              // Prints the clipboard formats, nil if the tool can't
+	parseFormats func([]byte) Contents // Parses the output of list
+	watch        []string              // Prints a line or exits after each clipboard change, nil if the tool can't
+	env          string                // Environment variable that must be set, e.g. DISPLAY
+}
+
+// Name returns the name of this backend.
+func (b *CommandBackend) Name() string {
+	return b.name
+}
+
+// IsAvailable checks that the tools are installed and, if the backend needs a
+// display server, that it is running.
+func (b *CommandBackend) IsAvailable() bool {
+	if b.env != "" && os.Getenv(b.env) == "" {
+		return false
+	}
+	for _, cmd := range [][]string{b.read, b.write, b.list} {
+		if len(cmd) == 0 {
+			continue
+		}
+		if _, err := exec.LookPath(cmd[0]); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// ReadText returns the text on the clipboard.
+func (b *CommandBackend) ReadText() (string, error) {
+	out, err := output(b.read)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// WriteText replaces the clipboard content with text. wl-copy and xclip keep
+// running in the background to serve the clipboard, so their output is not
+// captured: waiting for it would block until the clipboard changes again.
+func (b *CommandBackend) WriteText(text string) error {
+	cmd := exec.Command(b.write[0], b.write[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %v", b.write[0], err)
+	}
+	return nil
+}
+
+// Formats lists the formats currently on the clipboard.
+func (b *CommandBackend) Formats() (Contents, error) {
+	if b.list == nil {
+		return nil, fmt.Errorf("%s cannot list the clipboard formats", b.name)
+	}
+	out, err := output(b.list)
+	if err != nil {
+		return nil, err
+	}
+	return b.parseFormats(out), nil
+}
+
+// Save is not supported: the command line tools offer only one format at a
+// time, so only the text of the clipboard can be written back.
+func (b *CommandBackend) Save() (*Snapshot, error) {
+	return nil, ErrUnsupported
+}
+
+// Restore is not supported by command line tools.
+func (b *CommandBackend) Restore(s *Snapshot) error {
+	return ErrUnsupported
+}
+
+// WatchChanges runs the watch command, which prints a line after each change,
+// like "wl-paste --watch", or exits after a change and is started again, like
+// clipnotify. Watching ends with an error if the command fails, e.g. as the
+// compositor doesn't offer the data control protocol wl-paste needs.
+func (b *CommandBackend) WatchChanges(onChange func()) (*Watch, error) {
+	if len(b.watch) == 0 {
+		return nil, ErrWatchUnsupported
+	}
+	if _, err := exec.LookPath(b.watch[0]); err != nil {
+		return nil, fmt.Errorf("%w: %s is not installed", ErrWatchUnsupported, b.watch[0])
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := newWatch(cancel)
+	go func() {
+		for {
+			var stderr bytes.Buffer
+			cmd := exec.CommandContext(ctx, b.watch[0], b.watch[1:]...)
+			cmd.Stderr = &stderr
+			stdout, err := cmd.StdoutPipe()
+			if err == nil {
+				err = cmd.Start()
+			}
+			if err != nil {
+				w.end(fmt.Errorf("%s failed: %v", b.watch[0], err))
+				return
+			}
+			lines := bufio.NewScanner(stdout)
+			for lines.Scan() {
+				onChange()
+			}
+			err = cmd.Wait()
+			switch {
+			case ctx.Err() != nil:
+				w.end(nil)
+				return
+			case err != nil:
+				if msg := strings.TrimSpace(stderr.String()); msg != "" {
+					err = fmt.Errorf("%v: %s", err, msg)
+				}
+				w.end(fmt.Errorf("%s failed: %v", b.watch[0], err))
+				return
+			}
+			onChange()
+		}
+	}()
+	return w, nil
+}
+
+// output runs a command and returns its standard output. The error includes
+// the command's error message, e.g. "Nothing is copied" from wl-paste.
+func output(args []string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s failed: %v: %s", args[0], err, msg)
+		}
+		return nil, fmt.Errorf("%s failed: %v", args[0], err)
+	}
+	return out, nil
+}
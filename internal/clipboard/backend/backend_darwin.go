@@ -0,0 +1,71 @@
+//go:build darwin
+// +build darwin
+
+package backend
+
+import (
+	"regexp"
+	"strings"
+)
+
+// clipboardInfoRegex matches one {class, size} entry of AppleScript's
+// "clipboard info" in source form, e.g. {«class PNGf», 12345}.
+var clipboardInfoRegex = regexp.MustCompile(`\{([^{},]+), -?\d+\}`)
+
+// classKinds classifies the AppleScript classes of common pasteboard types.
+var classKinds = map[string]Kind{
+	"string":             KindText,
+	"Unicode text":       KindText,
+	"international text": KindText,
+	"styled text":        KindText,
+	"«class utf8»":       KindText,
+	"«class ut16»":       KindText,
+	"«class HTML»":       KindRichText,
+	"«class RTF »":       KindRichText,
+	"«class RTFD»":       KindRichText,
+	"«class PNGf»":       KindImage,
+	"«class TIFF»":       KindImage,
+	"«class 8BPS»":       KindImage,
+	"«class BMP »":       KindImage,
+	"«class jp2 »":       KindImage,
+	"«class PDF »":       KindImage,
+	"TIFF picture":       KindImage,
+	"JPEG picture":       KindImage,
+	"GIF picture":        KindImage,
+	"picture":            KindImage,
+	"«class furl»":       KindFiles,
+	"file URL":           KindFiles,
+	"«class fss »":       KindFiles,
+	"alias":              KindFiles,
+}
+
+// NewPasteboardBackend creates a backend using pbpaste and pbcopy, with
+// AppleScript's "clipboard info" listing the pasteboard types.
+func NewPasteboardBackend() *CommandBackend {
+	return &CommandBackend{
+		name:         "pbcopy/pbpaste (macOS)",
+		read:         []string{"pbpaste"},
+		write:        []string{"pbcopy"},
+		list:         []string{"osascript", "-s", "s", "-e", "clipboard info"},
+		parseFormats: parseClipboardInfo,
+	}
+}
+
+// candidates returns the backends for this platform in order of preference.
+func candidates() []Backend {
+	return []Backend{NewPasteboardBackend()}
+}
+
+// parseClipboardInfo parses the pasteboard types from "clipboard info".
+func parseClipboardInfo(out []byte) Contents {
+	var contents Contents
+	for _, match := range clipboardInfoRegex.FindAllStringSubmatch(string(out), -1) {
+		name := strings.TrimSpace(match[1])
+		kind, ok := classKinds[name]
+		if !ok {
+			kind = KindOther
+		}
+		contents = append(contents, Format{Name: name, Kind: kind})
+	}
+	return contents
+}
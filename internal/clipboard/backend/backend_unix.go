@@ -0,0 +1,95 @@
+//go:build !windows && !darwin
+// +build !windows,!darwin
+
+package backend
+
+import (
+	"os"
+	"strings"
+)
+
+// selectionTargets are X11 selection targets that describe the selection
+// rather than hold content.
+var selectionTargets = map[string]bool{
+	"TARGETS": true, "TIMESTAMP": true, "MULTIPLE": true, "SAVE_TARGETS": true,
+	"DELETE": true, "INSERT_PROPERTY": true, "INSERT_SELECTION": true,
+}
+
+// NewWaylandBackend creates a backend using wl-paste and wl-copy from wl-clipboard.
+func NewWaylandBackend() *CommandBackend {
+	return &CommandBackend{
+		name:         "wl-clipboard (Wayland)",
+		read:         []string{"wl-paste", "--no-newline", "--type", "text"},
+		write:        []string{"wl-copy"},
+		list:         []string{"wl-paste", "--list-types"},
+		parseFormats: parseTargets,
+		watch:        []string{"wl-paste", "--watch", "echo"},
+		env:          "WAYLAND_DISPLAY",
+	}
+}
+
+// NewXclipBackend creates a backend using xclip on X11. Changes are watched
+// with clipnotify, which uses the XFixes extension, if it is installed.
+func NewXclipBackend() *CommandBackend {
+	return &CommandBackend{
+		name:         "xclip (X11)",
+		read:         []string{"xclip", "-selection", "clipboard", "-out"},
+		write:        []string{"xclip", "-selection", "clipboard", "-in"},
+		list:         []string{"xclip", "-selection", "clipboard", "-target", "TARGETS", "-out"},
+		parseFormats: parseTargets,
+		watch:        []string{"clipnotify", "-s", "clipboard"},
+		env:          "DISPLAY",
+	}
+}
+
+// NewXselBackend creates a backend using xsel on X11. xsel cannot list the
+// clipboard formats.
+func NewXselBackend() *CommandBackend {
+	return &CommandBackend{
+		name:  "xsel (X11)",
+		read:  []string{"xsel", "--clipboard", "--output"},
+		write: []string{"xsel", "--clipboard", "--input"},
+		watch: []string{"clipnotify", "-s", "clipboard"},
+		env:   "DISPLAY",
+	}
+}
+
+// candidates returns the backends for this platform in order of preference,
+// preferring wl-clipboard in a Wayland session and xclip otherwise. XWayland
+// sessions can use the X11 tools if wl-clipboard is missing.
+func candidates() []Backend {
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		return []Backend{NewWaylandBackend(), NewXclipBackend(), NewXselBackend()}
+	}
+	return []Backend{NewXclipBackend(), NewXselBackend(), NewWaylandBackend()}
+}
+
+// parseTargets parses the MIME types or X11 targets listed by wl-paste or
+// xclip, one per line.
+func parseTargets(out []byte) Contents {
+	var contents Contents
+	for _, line := range strings.Split(string(out), "\n") {
+		name := strings.TrimSpace(line)
+		if name == "" || selectionTargets[name] {
+			continue
+		}
+		contents = append(contents, Format{Name: name, Kind: mimeKind(name)})
+	}
+	return contents
+}
+
+// mimeKind classifies a MIME type or X11 target.
+func mimeKind(name string) Kind {
+	base := strings.ToLower(strings.TrimSpace(strings.SplitN(name, ";", 2)[0]))
+	switch {
+	case base == "text/plain", name == "UTF8_STRING", name == "STRING", name == "TEXT", name == "COMPOUND_TEXT":
+		return KindText
+	case base == "text/html", base == "text/rtf", base == "application/rtf", base == "text/richtext":
+		return KindRichText
+	case strings.HasPrefix(base, "image/"):
+		return KindImage
+	case base == "text/uri-list", base == "x-special/gnome-copied-files":
+		return KindFiles
+	}
+	return KindOther
+}
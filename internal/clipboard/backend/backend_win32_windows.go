@@ -0,0 +1,293 @@
+//go:build windows
+// +build windows
+
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"runtime"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	user32                     = syscall.NewLazyDLL("user32.dll")
+	kernel32                   = syscall.NewLazyDLL("kernel32.dll")
+	procOpenClipboard          = user32.NewProc("OpenClipboard")
+	procCloseClipboard         = user32.NewProc("CloseClipboard")
+	procEmptyClipboard         = user32.NewProc("EmptyClipboard")
+	procEnumClipboardFormats   = user32.NewProc("EnumClipboardFormats")
+	procGetClipboardFormatName = user32.NewProc("GetClipboardFormatNameW")
+	procGetClipboardData       = user32.NewProc("GetClipboardData")
+	procSetClipboardData       = user32.NewProc("SetClipboardData")
+	procGlobalAlloc            = kernel32.NewProc("GlobalAlloc")
+	procGlobalFree             = kernel32.NewProc("GlobalFree")
+	procGlobalLock             = kernel32.NewProc("GlobalLock")
+	procGlobalUnlock           = kernel32.NewProc("GlobalUnlock")
+	procGlobalSize             = kernel32.NewProc("GlobalSize")
+	procRtlMoveMemory          = kernel32.NewProc("RtlMoveMemory")
+)
+
+const gmemMoveable = 0x0002
+
+// Predefined clipboard formats (winuser.h).
+var standardFormats = map[uint32]Format{
+	1:  {"CF_TEXT", KindText},
+	2:  {"CF_BITMAP", KindImage},
+	3:  {"CF_METAFILEPICT", KindImage},
+	4:  {"CF_SYLK", KindOther},
+	5:  {"CF_DIF", KindOther},
+	6:  {"CF_TIFF", KindImage},
+	7:  {"CF_OEMTEXT", KindText},
+	8:  {"CF_DIB", KindImage},
+	9:  {"CF_PALETTE", KindOther},
+	10: {"CF_PENDATA", KindOther},
+	11: {"CF_RIFF", KindOther},
+	12: {"CF_WAVE", KindOther},
+	13: {"CF_UNICODETEXT", KindText},
+	14: {"CF_ENHMETAFILE", KindImage},
+	15: {"CF_HDROP", KindFiles},
+	16: {"CF_LOCALE", KindOther},
+	17: {"CF_DIBV5", KindImage},
+}
+
+// registeredKinds classifies well-known registered formats by name.
+var registeredKinds = map[string]Kind{
+	"HTML Format":          KindRichText,
+	"Rich Text Format":     KindRichText,
+	"text/html":            KindRichText,
+	"PNG":                  KindImage,
+	"image/png":            KindImage,
+	"JFIF":                 KindImage,
+	"GIF":                  KindImage,
+	"FileNameW":            KindFiles,
+	"FileName":             KindFiles,
+	"FileGroupDescriptorW": KindFiles,
+	"Shell IDList Array":   KindFiles,
+}
+
+// handleFormats hold GDI handles instead of global memory and cannot be
+// copied as bytes. Windows synthesizes CF_BITMAP from CF_DIB on restore.
+var handleFormats = map[uint32]bool{2: true, 3: true, 9: true, 14: true}
+
+const cfUnicodeText = 13
+
+// OpenClipboard fails while another application has the clipboard open, which
+// clipboard managers and remote desktop clients do often and briefly, so it
+// is retried with a growing delay (about 1.2 seconds in total).
+const (
+	openAttempts = 15
+	openInterval = 10 * time.Millisecond
+)
+
+// Win32Backend uses the Win32 clipboard API. Unlike text-only libraries it
+// can list, save and restore every clipboard format.
+type Win32Backend struct{}
+
+// NewWin32Backend creates a new Win32 clipboard backend.
+func NewWin32Backend() *Win32Backend {
+	return &Win32Backend{}
+}
+
+// candidates returns the backends for this platform in order of preference.
+func candidates() []Backend {
+	return []Backend{NewWin32Backend()}
+}
+
+// Name returns the name of this backend.
+func (b *Win32Backend) Name() string {
+	return "Win32 clipboard API"
+}
+
+// IsAvailable checks if this backend can be used on the current system.
+func (b *Win32Backend) IsAvailable() bool {
+	return user32.Load() == nil && kernel32.Load() == nil
+}
+
+// withClipboard opens the clipboard on a locked OS thread, runs fn and
+// closes it again.
+func withClipboard(fn func() error) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var err error
+	for attempt := 0; attempt < openAttempts; attempt++ {
+		var ok uintptr
+		ok, _, err = procOpenClipboard.Call(0)
+		if ok != 0 {
+			if attempt > 0 {
+				log.Printf("Opened the clipboard after %d attempt(s).", attempt+1)
+			}
+			defer procCloseClipboard.Call()
+			return fn()
+		}
+		time.Sleep(time.Duration(attempt+1) * openInterval)
+	}
+	return fmt.Errorf("failed to open the clipboard, another application keeps it open: %v", err)
+}
+
+// ReadText returns the Unicode text on the clipboard.
+func (b *Win32Backend) ReadText() (string, error) {
+	var text string
+	err := withClipboard(func() error {
+		data, ok := readGlobal(cfUnicodeText)
+		if !ok {
+			return errors.New("the clipboard contains no text")
+		}
+		chars := make([]uint16, len(data)/2)
+		for i := range chars {
+			chars[i] = uint16(data[2*i]) | uint16(data[2*i+1])<<8
+		}
+		text = syscall.UTF16ToString(chars)
+		return nil
+	})
+	return text, err
+}
+
+// WriteText replaces the clipboard content with text.
+func (b *Win32Backend) WriteText(text string) error {
+	chars, err := syscall.UTF16FromString(text)
+	if err != nil {
+		return fmt.Errorf("text cannot be written to the clipboard: %v", err)
+	}
+	data := make([]byte, 2*len(chars))
+	for i, c := range chars {
+		data[2*i], data[2*i+1] = byte(c), byte(c>>8)
+	}
+	return withClipboard(func() error {
+		if ok, _, err := procEmptyClipboard.Call(); ok == 0 {
+			return fmt.Errorf("failed to empty the clipboard: %v", err)
+		}
+		return writeGlobal(cfUnicodeText, data)
+	})
+}
+
+// formatInfo names and classifies a clipboard format.
+func formatInfo(id uint32) Format {
+	if format, ok := standardFormats[id]; ok {
+		return format
+	}
+	buf := make([]uint16, 256)
+	n, _, _ := procGetClipboardFormatName.Call(uintptr(id), uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	if n == 0 {
+		return Format{Name: fmt.Sprintf("format %d", id), Kind: KindOther}
+	}
+	name := syscall.UTF16ToString(buf[:n])
+	kind, ok := registeredKinds[name]
+	if !ok {
+		kind = KindOther
+	}
+	return Format{Name: name, Kind: kind}
+}
+
+// enumFormats lists the format identifiers on the open clipboard.
+func enumFormats() []uint32 {
+	var ids []uint32
+	id := uintptr(0)
+	for {
+		id, _, _ = procEnumClipboardFormats.Call(id)
+		if id == 0 {
+			return ids
+		}
+		ids = append(ids, uint32(id))
+	}
+}
+
+// Formats lists the formats currently on the clipboard.
+func (b *Win32Backend) Formats() (Contents, error) {
+	var contents Contents
+	err := withClipboard(func() error {
+		for _, id := range enumFormats() {
+			contents = append(contents, formatInfo(id))
+		}
+		return nil
+	})
+	return contents, err
+}
+
+// Save copies all clipboard formats that are stored in global memory.
+func (b *Win32Backend) Save() (*Snapshot, error) {
+	snapshot := &Snapshot{}
+	err := withClipboard(func() error {
+		for _, id := range enumFormats() {
+			if handleFormats[id] {
+				continue
+			}
+			data, ok := readGlobal(id)
+			if !ok {
+				continue
+			}
+			snapshot.items = append(snapshot.items, item{Format: formatInfo(id), id: id, data: data})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// readGlobal copies the global memory block of a clipboard format.
+func readGlobal(id uint32) ([]byte, bool) {
+	handle, _, _ := procGetClipboardData.Call(uintptr(id))
+	if handle == 0 {
+		return nil, false
+	}
+	size, _, _ := procGlobalSize.Call(handle)
+	ptr, _, _ := procGlobalLock.Call(handle)
+	if ptr == 0 {
+		return nil, false
+	}
+	defer procGlobalUnlock.Call(handle)
+	data := make([]byte, size)
+	if size > 0 {
+		procRtlMoveMemory.Call(uintptr(unsafe.Pointer(&data[0])), ptr, size)
+	}
+	return data, true
+}
+
+// Restore replaces the clipboard content with the saved formats.
+func (b *Win32Backend) Restore(s *Snapshot) error {
+	return withClipboard(func() error {
+		if ok, _, err := procEmptyClipboard.Call(); ok == 0 {
+			return fmt.Errorf("failed to empty the clipboard: %v", err)
+		}
+		restored := 0
+		for _, it := range s.items {
+			if err := writeGlobal(it.id, it.data); err != nil {
+				continue
+			}
+			restored++
+		}
+		if restored == 0 && len(s.items) > 0 {
+			return fmt.Errorf("none of the %d saved formats could be restored", len(s.items))
+		}
+		return nil
+	})
+}
+
+// writeGlobal puts data on the open clipboard as format id. The clipboard
+// owns the memory once SetClipboardData succeeds.
+func writeGlobal(id uint32, data []byte) error {
+	handle, _, err := procGlobalAlloc.Call(gmemMoveable, uintptr(len(data)))
+	if handle == 0 {
+		return fmt.Errorf("GlobalAlloc failed: %v", err)
+	}
+	if len(data) > 0 {
+		ptr, _, err := procGlobalLock.Call(handle)
+		if ptr == 0 {
+			procGlobalFree.Call(handle)
+			return fmt.Errorf("GlobalLock failed: %v", err)
+		}
+		procRtlMoveMemory.Call(ptr, uintptr(unsafe.Pointer(&data[0])), uintptr(len(data)))
+		procGlobalUnlock.Call(handle)
+	}
+	if ok, _, err := procSetClipboardData.Call(uintptr(id), handle); ok == 0 {
+		procGlobalFree.Call(handle)
+		return fmt.Errorf("SetClipboardData failed: %v", err)
+	}
+	return nil
+}
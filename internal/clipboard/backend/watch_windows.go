@@ -0,0 +1,162 @@
+//go:build windows
+// +build windows
+
+package backend
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	procAddClipboardFormatListener    = user32.NewProc("AddClipboardFormatListener")
+	procRemoveClipboardFormatListener = user32.NewProc("RemoveClipboardFormatListener")
+	procGetClipboardSequenceNumber    = user32.NewProc("GetClipboardSequenceNumber")
+	procRegisterClassExW              = user32.NewProc("RegisterClassExW")
+	procCreateWindowExW               = user32.NewProc("CreateWindowExW")
+	procDestroyWindow                 = user32.NewProc("DestroyWindow")
+	procDefWindowProcW                = user32.NewProc("DefWindowProcW")
+	procGetMessageW                   = user32.NewProc("GetMessageW")
+	procDispatchMessageW              = user32.NewProc("DispatchMessageW")
+	procPostThreadMessageW            = user32.NewProc("PostThreadMessageW")
+	procGetModuleHandleW              = kernel32.NewProc("GetModuleHandleW")
+	procGetCurrentThreadId            = kernel32.NewProc("GetCurrentThreadId")
+
+	// The window class is registered once: its procedure is never freed
+	listenerClass     *uint16
+	listenerClassErr  error
+	listenerClassOnce sync.Once
+
+	// listeners maps the window of each watch to its onChange
+	listeners sync.Map
+)
+
+const (
+	wmQuit            = 0x0012
+	wmClipboardUpdate = 0x031D
+	hwndMessage       = ^uintptr(2) // HWND_MESSAGE (-3): a message-only window
+)
+
+// wndClassEx is WNDCLASSEXW.
+type wndClassEx struct {
+	size       uint32
+	style      uint32
+	wndProc    uintptr
+	clsExtra   int32
+	wndExtra   int32
+	instance   uintptr
+	icon       uintptr
+	cursor     uintptr
+	background uintptr
+	menuName   *uint16
+	className  *uint16
+	iconSm     uintptr
+}
+
+// winMsg is MSG, only used to run the listener thread's message loop.
+type winMsg struct {
+	hwnd     uintptr
+	message  uint32
+	wParam   uintptr
+	lParam   uintptr
+	time     uint32
+	x, y     int32
+	lPrivate uint32
+}
+
+// ChangeCount returns the clipboard sequence number, which Windows increments
+// whenever the clipboard content changes.
+func (b *Win32Backend) ChangeCount() (uint64, bool) {
+	seq, _, _ := procGetClipboardSequenceNumber.Call()
+	return uint64(seq), seq != 0 // 0 without access to the window station
+}
+
+// WatchChanges adds a clipboard format listener on a hidden message-only
+// window. It runs on its own locked thread with a message loop, as Windows
+// sends WM_CLIPBOARDUPDATE to the window.
+func (b *Win32Backend) WatchChanges(onChange func()) (*Watch, error) {
+	if err := registerListenerClass(); err != nil {
+		return nil, err
+	}
+
+	var threadID uintptr
+	started := make(chan error, 1)
+	w := newWatch(func() {
+		procPostThreadMessageW.Call(threadID, wmQuit, 0, 0)
+	})
+	go func() {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+
+		threadID, _, _ = procGetCurrentThreadId.Call()
+		module, _, _ := procGetModuleHandleW.Call(0)
+		hwnd, _, err := procCreateWindowExW.Call(0, uintptr(unsafe.Pointer(listenerClass)), 0, 0, 0, 0, 0, 0, hwndMessage, 0, module, 0)
+		if hwnd == 0 {
+			started <- fmt.Errorf("CreateWindowEx failed: %v", err)
+			return
+		}
+		defer procDestroyWindow.Call(hwnd)
+		listeners.Store(hwnd, onChange)
+		defer listeners.Delete(hwnd)
+		if ok, _, err := procAddClipboardFormatListener.Call(hwnd); ok == 0 {
+			started <- fmt.Errorf("AddClipboardFormatListener failed: %v", err)
+			return
+		}
+		defer procRemoveClipboardFormatListener.Call(hwnd)
+		started <- nil
+
+		var msg winMsg
+		for {
+			// 0 is WM_QUIT, -1 an error
+			if ret, _, _ := procGetMessageW.Call(uintptr(unsafe.Pointer(&msg)), 0, 0, 0); int32(ret) <= 0 {
+				break
+			}
+			procDispatchMessageW.Call(uintptr(unsafe.Pointer(&msg)))
+		}
+		w.end(nil)
+	}()
+
+	if err := <-started; err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// registerListenerClass registers the window class of the listener windows.
+func registerListenerClass() error {
+	listenerClassOnce.Do(func() {
+		name, err := syscall.UTF16PtrFromString("ClipboardRegexReplaceListener")
+		if err != nil {
+			listenerClassErr = err
+			return
+		}
+		module, _, _ := procGetModuleHandleW.Call(0)
+		class := wndClassEx{
+			wndProc:   syscall.NewCallback(listenerProc),
+			instance:  module,
+			className: name,
+		}
+		class.size = uint32(unsafe.Sizeof(class))
+		if atom, _, err := procRegisterClassExW.Call(uintptr(unsafe.Pointer(&class))); atom == 0 {
+			listenerClassErr = fmt.Errorf("RegisterClassEx failed: %v", err)
+			return
+		}
+		listenerClass = name
+	})
+	return listenerClassErr
+}
+
+// listenerProc is the window procedure of the listener windows.
+func listenerProc(hwnd, message, wParam, lParam uintptr) uintptr {
+	if message == wmClipboardUpdate {
+		if onChange, ok := listeners.Load(hwnd); ok {
+			onChange.(func())()
+		}
+		return 0
+	}
+	ret, _, _ := procDefWindowProcW.Call(hwnd, message, wParam, lParam)
+	return ret
+}
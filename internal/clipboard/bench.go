@@ -0,0 +1,119 @@
+package clipboard
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+)
+
+// RuleBenchmark is the cost of one rule, averaged over the iterations of a
+// benchmark.
+type RuleBenchmark struct {
+	Rule     int // 1-based position in the profile
+	Summary  string
+	Disabled bool          // Switched off, so it was not run
+	Matches  int           // Replacements made in the first iteration
+	Time     time.Duration // Per iteration
+	Allocs   uint64        // Heap allocations per iteration
+	Bytes    uint64        // Heap bytes allocated per iteration
+	Err      error         // Set if the rule could not be applied
+}
+
+// BenchmarkResult is the outcome of benchmarking a profile.
+type BenchmarkResult struct {
+	Profile    string
+	InputBytes int
+	Iterations int
+	Rules      []RuleBenchmark
+	Time       time.Duration // Per iteration, all rules
+	Allocs     uint64        // Per iteration, all rules
+	Bytes      uint64        // Per iteration, all rules
+	Changed    bool          // Whether the rules changed the input
+}
+
+func (r RuleBenchmark) String() string {
+	if r.Disabled {
+		return fmt.Sprintf("rule #%d (%s): disabled", r.Rule, r.Summary)
+	}
+	if r.Err != nil {
+		return fmt.Sprintf("rule #%d (%s): error: %v", r.Rule, r.Summary, r.Err)
+	}
+	return fmt.Sprintf("rule #%d (%s): %v, %d alloc(s), %d bytes, %d match(es)", r.Rule, r.Summary, r.Time, r.Allocs, r.Bytes, r.Matches)
+}
+
+// Benchmark applies the rules of profile to text iterations times, the way a
+// hotkey press would (chunking line_safe rules on large texts, but without
+// the match planner, which would skip rules), and measures the time and heap
+// allocations of each rule. Allocations are counted process-wide, so nothing
+// else should run meanwhile. It does not touch the clipboard.
+func (m *Manager) Benchmark(profile config.ProfileConfig, text string, iterations int, reverse bool) BenchmarkResult {
+	if iterations < 1 {
+		iterations = 1
+	}
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+	m.mu.RLock()
+	large := m.config != nil && len(text) >= m.config.GetLargeClipboardBytes()
+	m.mu.RUnlock()
+
+	result := BenchmarkResult{Profile: profile.Name, InputBytes: len(text), Iterations: iterations}
+	result.Rules = make([]RuleBenchmark, len(profile.Replacements))
+	for i, rep := range profile.Replacements {
+		result.Rules[i] = RuleBenchmark{Rule: i + 1, Summary: rep.Summary(), Disabled: rep.Disabled}
+	}
+
+	var before, after runtime.MemStats
+	for iteration := 0; iteration < iterations; iteration++ {
+		current := text
+		for i, rep := range profile.Replacements {
+			bench := &result.Rules[i]
+			if rep.Disabled || bench.Err != nil {
+				continue
+			}
+			runtime.ReadMemStats(&before)
+			start := time.Now()
+			var replaced string
+			var count int
+			var err error
+			switch {
+			case reverse:
+				replaced, count, err = m.applyReverseReplacement(current, rep)
+			case large && rep.LineSafe:
+				replaced, count, err = m.applyChunked(current, rep)
+			default:
+				replaced, count, err = m.applyForwardReplacement(current, rep)
+			}
+			elapsed := time.Since(start)
+			runtime.ReadMemStats(&after)
+
+			if err != nil {
+				bench.Err = err // Not retried in later iterations
+				continue
+			}
+			bench.Time += elapsed
+			bench.Allocs += after.Mallocs - before.Mallocs
+			bench.Bytes += after.TotalAlloc - before.TotalAlloc
+			if iteration == 0 {
+				bench.Matches = count
+			}
+			current = replaced
+		}
+		if iteration == 0 {
+			result.Changed = current != text
+		}
+	}
+
+	n := uint64(iterations)
+	for i := range result.Rules {
+		bench := &result.Rules[i]
+		bench.Time /= time.Duration(iterations)
+		bench.Allocs /= n
+		bench.Bytes /= n
+		result.Time += bench.Time
+		result.Allocs += bench.Allocs
+		result.Bytes += bench.Bytes
+	}
+	return result
+}
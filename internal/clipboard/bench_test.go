@@ -0,0 +1,79 @@
+package clipboard
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+)
+
+// benchInput is a sample clipboard of about 64 KB with names, addresses and
+// identifiers for the rules below to match.
+var benchInput = strings.Repeat("2024-05-01 INFO user jane.doe@example.com logged in from GitHub, token secret-token-42; see github.com/acme and GITHUB_TOKEN.\n", 512)
+
+// benchRuleSets are representative profiles: a literal, a regex with
+// capture groups, a case-preserving rule and a profile of many rules.
+func benchRuleSets() []struct {
+	name  string
+	rules []config.Replacement
+} {
+	large := make([]config.Replacement, 0, 200)
+	for i := 0; i < 200; i++ {
+		large = append(large, config.Replacement{Regex: fmt.Sprintf(`\bword%d\b`, i), ReplaceWith: fmt.Sprintf("w%d", i)})
+	}
+	large = append(large, config.Replacement{Regex: `secret-token-\d+`, ReplaceWith: "[TOKEN]"})
+	return []struct {
+		name  string
+		rules []config.Replacement
+	}{
+		{"literal", []config.Replacement{{Regex: `secret-token-42`, ReplaceWith: "[TOKEN]"}}},
+		{"capture_groups", []config.Replacement{{Regex: `([\w.]+)@([\w.]+)\.com`, ReplaceWith: "${1} at ${2}"}}},
+		{"preserve_case", []config.Replacement{{Regex: `(?i)github`, ReplaceWith: "gitlab", PreserveCase: true}}},
+		{"large_profile", large},
+	}
+}
+
+func BenchmarkApplyForwardReplacement(b *testing.B) {
+	for _, set := range benchRuleSets() {
+		b.Run(set.name, func(b *testing.B) {
+			m, _ := newTestManager(b, "")
+			b.SetBytes(int64(len(benchInput)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				text := benchInput
+				for _, rep := range set.rules {
+					var err error
+					if text, _, err = m.applyForwardReplacement(text, rep); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkProcessClipboard(b *testing.B) {
+	for _, set := range benchRuleSets() {
+		b.Run(set.name, func(b *testing.B) {
+			m, fake := newTestManager(b, benchInput)
+			m.config.TemporaryClipboard = false
+			m.config.AutomaticReversion = false
+			m.config.Profiles[0].Replacements = set.rules
+			if _, changed := m.ProcessClipboard(context.Background(), testHotkey, false); !changed {
+				b.Fatal("ProcessClipboard did not change the clipboard")
+			}
+			b.SetBytes(int64(len(benchInput)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				_ = fake.WriteText(benchInput)
+				b.StartTimer()
+				m.ProcessClipboard(context.Background(), testHotkey, false)
+			}
+		})
+	}
+}
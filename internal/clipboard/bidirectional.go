@@ -0,0 +1,155 @@
+package clipboard
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+)
+
+// bidirectionalMatcher compiles the pairs of a bidirectional rule into one
+// regex that matches the text to replace, with the texts it maps to: the
+// originals forward, the replacements with reverse set. Longer texts are
+// tried first, so "Acme Corp" wins over "Acme", and all pairs are replaced in
+// one pass, so no pair replaces the output of another.
+func (m *Manager) bidirectionalMatcher(rep config.Replacement, reverse bool) (*regexp.Regexp, map[string]string, error) {
+	secretsCopy := m.secretsFor(rep)
+
+	vars := m.previewTemplateVars(false)
+	fold := rep.CaseInsensitive || rep.PreserveCase
+	mapping := make(map[string]string, len(rep.Bidirectional))
+	froms := make([]string, 0, len(rep.Bidirectional))
+	for i, pair := range rep.Bidirectional {
+		original, err := resolvePlaceholders(pair.Original, secretsCopy, vars, false)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve placeholders in pair %d original '%s': %w", i, pair.Original, err)
+		}
+		replacement, err := resolvePlaceholders(pair.Replacement, secretsCopy, vars, false)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve placeholders in pair %d replacement '%s': %w", i, pair.Replacement, err)
+		}
+		from, to := original, replacement
+		if reverse {
+			from, to = replacement, original
+		}
+		if from == "" {
+			continue // A secret that resolved to nothing would match everywhere
+		}
+		key := from
+		if fold {
+			key = strings.ToLower(from)
+		}
+		if _, dup := mapping[key]; !dup {
+			froms = append(froms, from)
+		}
+		mapping[key] = to
+	}
+	if len(froms) == 0 {
+		return nil, nil, fmt.Errorf("bidirectional rule has no non-empty texts to match")
+	}
+
+	sort.SliceStable(froms, func(i, j int) bool { return len(froms[i]) > len(froms[j]) })
+	quoted := make([]string, len(froms))
+	for i, from := range froms {
+		quoted[i] = regexp.QuoteMeta(from)
+		if rep.MatchMode == config.MatchModeWord {
+			// \b only holds next to a word character, so "[NAME]" gets none
+			if isWordRune(firstRune(from)) {
+				quoted[i] = `\b` + quoted[i]
+			}
+			if isWordRune(lastRune(from)) {
+				quoted[i] += `\b`
+			}
+		}
+	}
+	options := rep
+	if options.MatchMode == config.MatchModeWord {
+		options.MatchMode = config.MatchModeAnywhere // The boundaries are set per text above
+	}
+	pattern := options.Pattern(strings.Join(quoted, "|"))
+	if fold && !rep.CaseInsensitive {
+		pattern = "(?i)" + pattern // preserve_case matches any case, like a reversed regex rule
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to compile bidirectional pairs: %w", err)
+	}
+	return re, mapping, nil
+}
+
+// applyBidirectional replaces the originals of a bidirectional rule with their
+// replacements, or with reverse set, the replacements with their originals.
+// record, if not nil, is called with every replaced text and its replacement.
+func (m *Manager) applyBidirectional(text string, rep config.Replacement, reverse bool, record func(original, replacement string)) (string, int, error) {
+	re, mapping, err := m.bidirectionalMatcher(rep, reverse)
+	if err != nil {
+		return text, 0, err
+	}
+
+	matchCount := len(re.FindAllStringIndex(text, -1))
+	if matchCount == 0 {
+		return text, 0, nil
+	}
+	limited := rep.MaxCount > 0 && rep.MaxCount < matchCount
+	if limited {
+		matchCount = rep.MaxCount
+	}
+
+	fold := rep.CaseInsensitive || rep.PreserveCase
+	m.mu.RLock()
+	timeoutMs := m.config.GetRegexTimeout()
+	m.mu.RUnlock()
+
+	replacedMatches := 0
+	var replaced [][2]string // Each match and its replacement
+	result, err := replaceAllStringFuncWithTimeout(re, text, func(match string) string {
+		if limited && replacedMatches >= rep.MaxCount {
+			return match // Keep matches beyond max_count
+		}
+		replacedMatches++
+		key := match
+		if fold {
+			key = strings.ToLower(match)
+		}
+		to, ok := mapping[key]
+		if !ok {
+			return match // (?i) also folds runes ToLower keeps, e.g. the Kelvin sign
+		}
+		if rep.PreserveCase {
+			to = m.matchCase(rep, match, to)
+		}
+		replaced = append(replaced, [2]string{match, to})
+		return to
+	}, timeoutMs)
+	if err != nil {
+		return text, 0, fmt.Errorf("bidirectional replacement failed: %w", err)
+	}
+	if record != nil {
+		for _, r := range replaced { // Recorded only now, a timed out replacement may still be running
+			record(r[0], r[1])
+		}
+	}
+	if result == text {
+		return text, 0, nil
+	}
+	return result, matchCount, nil
+}
+
+// isWordRune reports whether r is a word character for \b, which is ASCII
+// only in Go.
+func isWordRune(r rune) bool {
+	return r == '_' || ('0' <= r && r <= '9') || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z')
+}
+
+func firstRune(s string) rune {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r
+}
+
+func lastRune(s string) rune {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return r
+}
@@ -0,0 +1,56 @@
+package clipboard
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+)
+
+// ruleResult is the outcome of applying one rule.
+type ruleResult struct {
+	text  string
+	count int
+	err   error
+}
+
+// runWithBudget applies a rule on its own goroutine and waits for it until
+// ctx is done, so a profile's time budget or a canceled operation stops
+// waiting even for a rule that ignores them. An abandoned rule keeps running
+// in the background until its own timeout and its result is discarded.
+func runWithBudget(ctx context.Context, apply func() (string, int, error)) (string, int, error) {
+	if err := ctx.Err(); err != nil {
+		return "", 0, err
+	}
+	done := make(chan ruleResult, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- ruleResult{err: fmt.Errorf("panic while applying rule: %v", r)}
+			}
+		}()
+		text, count, err := apply()
+		done <- ruleResult{text: text, count: count, err: err}
+	}()
+	select {
+	case res := <-done:
+		return res.text, res.count, res.err
+	case <-ctx.Done():
+		return "", 0, ctx.Err()
+	}
+}
+
+// budgetOverrun describes a profile that ran out of its time budget.
+type budgetOverrun struct {
+	profile string
+	budget  time.Duration
+	slowest ruleTiming // The rule that took longest, including the one abandoned
+	skipped int        // Rules of the profile that did not run or were abandoned
+}
+
+// message returns the notification text for the overrun.
+func (o budgetOverrun) message() string {
+	return i18n.Tf("Profile '%s' exceeded its time budget of %v, so %d rule(s) were skipped. Slowest rule: #%d (%s), %v.",
+		o.profile, o.budget, o.skipped, o.slowest.index, o.slowest.summary, o.slowest.elapsed.Round(time.Millisecond))
+}
@@ -0,0 +1,206 @@
+package clipboard
+
+import (
+	"log"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/actions"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+)
+
+// casing returns the case conversions of rep, following its case_locale or
+// the global one.
+func (m *Manager) casing(rep config.Replacement) actions.Casing {
+	m.mu.RLock()
+	cfg := m.config
+	m.mu.RUnlock()
+	if cfg == nil {
+		return actions.Casing{}
+	}
+	c, err := actions.NewCasing(cfg.GetCaseLocale(rep))
+	if err != nil {
+		log.Printf("Warning: %v. Using the default case rules.", err) // Caught by validation
+	}
+	return c
+}
+
+// matchCase transfers the case of source, a match of the preserve_case rule
+// rep, onto target, its replacement, using the rule's case_strategy.
+func (m *Manager) matchCase(rep config.Replacement, source, target string) string {
+	if source == "" || target == "" {
+		return target
+	}
+	c := m.casing(rep)
+	switch rep.CaseStrategy {
+	case config.CaseStrategyExact:
+		return exactCase(source, target, c)
+	case config.CaseStrategyFirstLetter:
+		return firstLetterCase(source, target, c)
+	case config.CaseStrategyToken:
+		return tokenCase(source, target, c)
+	case config.CaseStrategyIdentifier:
+		return identifierCase(source, target, c, m.preserveCase)
+	default:
+		return m.preserveCase(source, target, c)
+	}
+}
+
+// exactCase gives every letter of target the case of the letter at the same
+// position in source; letters past the end of source continue the case of its
+// last letter. "GitHub" onto "gitlab" gives "GitLab".
+func exactCase(source, target string, c actions.Casing) string {
+	var sourceCases []bool // Whether each letter of source is upper case
+	for _, r := range source {
+		if unicode.IsLetter(r) {
+			sourceCases = append(sourceCases, unicode.IsUpper(r))
+		}
+	}
+	if len(sourceCases) == 0 {
+		return target
+	}
+	var b strings.Builder
+	letter := 0
+	for _, r := range target {
+		if !unicode.IsLetter(r) {
+			b.WriteRune(r)
+			continue
+		}
+		if sourceCases[min(letter, len(sourceCases)-1)] {
+			b.WriteString(c.Upper(string(r)))
+		} else {
+			b.WriteString(c.Lower(string(r)))
+		}
+		letter++
+	}
+	return b.String()
+}
+
+// firstLetterCase gives the first letter of target the case of the first
+// letter of source and leaves the rest of target as written.
+func firstLetterCase(source, target string, c actions.Casing) string {
+	first := strings.IndexFunc(source, unicode.IsLetter)
+	at := strings.IndexFunc(target, unicode.IsLetter)
+	if first < 0 || at < 0 {
+		return target
+	}
+	sourceLetter, _ := utf8.DecodeRuneInString(source[first:])
+	_, size := utf8.DecodeRuneInString(target[at:])
+	letter := c.Lower(target[at : at+size])
+	if unicode.IsUpper(sourceLetter) {
+		letter = c.Upper(target[at : at+size])
+	}
+	return target[:at] + letter + target[at+size:]
+}
+
+// tokenCase splits source and target into words, at separators and case
+// changes, and gives each word of target the case of the word at the same
+// position in source: lower, upper or title case. Extra words of target take
+// the case of the last word of source; words of mixed case are left alone.
+// "myGitHub" onto "my git lab" gives "my Git Lab".
+func tokenCase(source, target string, c actions.Casing) string {
+	var sourceWords []string
+	for _, span := range wordSpans(source) {
+		sourceWords = append(sourceWords, source[span[0]:span[1]])
+	}
+	if len(sourceWords) == 0 {
+		return target
+	}
+	var b strings.Builder
+	last := 0
+	for i, span := range wordSpans(target) {
+		b.WriteString(target[last:span[0]])
+		b.WriteString(applyWordCase(sourceWords[min(i, len(sourceWords)-1)], target[span[0]:span[1]], c))
+		last = span[1]
+	}
+	b.WriteString(target[last:])
+	return b.String()
+}
+
+// applyWordCase gives word the case of pattern if it is all lower, all upper
+// or title case.
+func applyWordCase(pattern, word string, c actions.Casing) string {
+	switch {
+	case strings.IndexFunc(pattern, unicode.IsLetter) < 0:
+		return word // Digits only
+	case pattern == strings.ToLower(pattern):
+		return c.Lower(word)
+	case pattern == strings.ToUpper(pattern) && len([]rune(pattern)) > 1:
+		return c.Upper(word)
+	case isTitleWord(pattern):
+		return c.TitleWord(c.Lower(word))
+	}
+	return word
+}
+
+// isTitleWord reports whether word starts with an upper case letter followed
+// only by lower case letters or digits.
+func isTitleWord(word string) bool {
+	runes := []rune(word)
+	return unicode.IsUpper(runes[0]) && string(runes[1:]) == strings.ToLower(string(runes[1:]))
+}
+
+// wordSpans returns the byte ranges of the words of text: runs of letters and
+// digits, split where a lower case letter or digit is followed by an upper
+// case one, and before the last capital of an acronym followed by a lower case
+// letter, like the naming-style actions do. "parseHTTPResponse" has the words
+// "parse", "HTTP" and "Response".
+func wordSpans(text string) [][2]int {
+	var spans [][2]int
+	runes := []rune(text)
+	start, pos := -1, 0
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if start >= 0 {
+				spans = append(spans, [2]int{start, pos})
+				start = -1
+			}
+		} else if start < 0 {
+			start = pos
+		} else if unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				spans = append(spans, [2]int{start, pos})
+				start = pos
+			}
+		}
+		pos += len(string(r))
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, pos})
+	}
+	return spans
+}
+
+// identifierCase writes target in the naming style of source: snake_case,
+// CONSTANT_CASE, kebab-case, camelCase or PascalCase, so "user_name" onto
+// "accountId" gives "account_id". If source is a single word, fallback
+// transfers its case instead.
+func identifierCase(source, target string, c actions.Casing, fallback func(source, target string, c actions.Casing) string) string {
+	style := ""
+	switch {
+	case len(wordSpans(source)) < 2:
+	case strings.Contains(source, "_") && source == strings.ToUpper(source):
+		style = "constant"
+	case strings.Contains(source, "_"):
+		style = "snake"
+	case strings.Contains(source, "-"):
+		style = "kebab"
+	case strings.IndexFunc(source, unicode.IsSpace) >= 0:
+	case unicode.IsUpper([]rune(source)[0]):
+		style = "pascal"
+	default:
+		style = "camel"
+	}
+	action, ok := actions.CaseAction(style, c)
+	if !ok {
+		return fallback(source, target, c)
+	}
+	result, err := action(target)
+	if err != nil {
+		return fallback(source, target, c)
+	}
+	return result
+}
@@ -0,0 +1,147 @@
+package clipboard
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/actions"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+)
+
+type caseTest struct {
+	source, target, want string
+}
+
+func runCaseTests(t *testing.T, name string, strategy func(source, target string, c actions.Casing) string, tests []caseTest) {
+	t.Helper()
+	for _, tt := range tests {
+		if got := strategy(tt.source, tt.target, actions.Casing{}); got != tt.want {
+			t.Errorf("%s(%q, %q) = %q, want %q", name, tt.source, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestExactCase(t *testing.T) {
+	runCaseTests(t, "exactCase", exactCase, []caseTest{
+		{"GitHub", "gitlab", "GitLab"},
+		{"GitHub", "gitLab", "GitLab"},
+		{"ABC", "defgh", "DEFGH"},
+		{"Ab", "cdef", "Cdef"},
+		{"aB-c", "x-yz", "x-Yz"},
+		{"123", "abc", "abc"},
+		{"user_name", "accountId", "accountid"},
+	})
+}
+
+func TestFirstLetterCase(t *testing.T) {
+	runCaseTests(t, "firstLetterCase", firstLetterCase, []caseTest{
+		{"GitHub", "gitlab", "Gitlab"},
+		{"user_name", "accountId", "accountId"},
+		{"Hello", "  world", "  World"},
+		{"hello", "World", "world"},
+		{"Éa", "élan", "Élan"},
+		{"123", "abc", "abc"},
+		{"Ab", "123", "123"},
+	})
+}
+
+func TestTokenCase(t *testing.T) {
+	runCaseTests(t, "tokenCase", tokenCase, []caseTest{
+		{"myGitHub", "my git lab", "my Git Lab"},
+		{"GitHub", "gitlab", "Gitlab"},
+		{"GitHub", "git lab", "Git Lab"},
+		{"user_name", "accountId", "accountid"},
+		{"HTTP_Server", "web_client", "WEB_Client"},
+		{"iPhone", "my phone", "my Phone"},
+		{"123", "abc", "abc"},
+	})
+}
+
+func TestIdentifierCase(t *testing.T) {
+	fallback := func(source, target string, c actions.Casing) string {
+		return "fallback"
+	}
+	identifier := func(source, target string, c actions.Casing) string {
+		return identifierCase(source, target, c, fallback)
+	}
+	runCaseTests(t, "identifierCase", identifier, []caseTest{
+		{"user_name", "accountId", "account_id"},
+		{"USER_NAME", "accountId", "ACCOUNT_ID"},
+		{"user-name", "accountId", "account-id"},
+		{"userName", "account id", "accountId"},
+		{"UserName", "account id", "AccountId"},
+		{"GitHub", "git lab", "GitLab"},
+		{"user", "accountId", "fallback"},
+		{"user name", "accountId", "fallback"},
+	})
+}
+
+func TestApplyWordCase(t *testing.T) {
+	tests := []struct {
+		pattern, word, want string
+	}{
+		{"abc", "WORD", "word"},
+		{"ABC", "word", "WORD"},
+		{"Abc", "wORD", "Word"},
+		{"a", "WORD", "word"},
+		{"A", "word", "Word"}, // A single capital is title case, not upper case
+		{"I", "you", "You"},
+		{"42", "wOrD", "wOrD"},
+		{"v2", "WORD", "word"},
+		{"V2", "word", "WORD"},
+		{"mIxEd", "wOrD", "wOrD"},
+	}
+	for _, tt := range tests {
+		if got := applyWordCase(tt.pattern, tt.word, actions.Casing{}); got != tt.want {
+			t.Errorf("applyWordCase(%q, %q) = %q, want %q", tt.pattern, tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestWordSpans(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"parseHTTPResponse", []string{"parse", "HTTP", "Response"}},
+		{"myGitHubUser", []string{"my", "Git", "Hub", "User"}},
+		{"user_name", []string{"user", "name"}},
+		{"USER_NAME", []string{"USER", "NAME"}},
+		{"my git-lab", []string{"my", "git", "lab"}},
+		{"v2Api", []string{"v2", "Api"}},
+		{"ÄpfelBirnen", []string{"Äpfel", "Birnen"}},
+		{"", nil},
+		{"--", nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, span := range wordSpans(tt.text) {
+			got = append(got, tt.text[span[0]:span[1]])
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("wordSpans(%q) gives %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+// TestCaseStrategyInIdentifier replaces a word within an identifier, which
+// the default heuristics mangle.
+func TestCaseStrategyInIdentifier(t *testing.T) {
+	for _, strategy := range []string{config.CaseStrategyExact, config.CaseStrategyFirstLetter, config.CaseStrategyToken, config.CaseStrategyIdentifier} {
+		t.Run(strategy, func(t *testing.T) {
+			m, fake := newTestManager(t, "myGitHubUser")
+			m.config.AutomaticReversion = false
+			m.config.Profiles[0].Replacements = []config.Replacement{{
+				Regex:        "(?i)github",
+				ReplaceWith:  "gitLab",
+				PreserveCase: true,
+				CaseStrategy: strategy,
+			}}
+			m.ProcessClipboard(context.Background(), testHotkey, false)
+			if text, _ := fake.ReadText(); text != "myGitLabUser" {
+				t.Errorf("clipboard = %q, want %q", text, "myGitLabUser")
+			}
+		})
+	}
+}
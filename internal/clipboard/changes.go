@@ -0,0 +1,36 @@
+package clipboard
+
+import (
+	"github.com/TanaroSch/clipboard-regex-replace/internal/clipboard/backend"
+)
+
+// WatchChanges subscribes to the changes of the system clipboard, where the
+// backend is told about them, see backend.ChangeWatcher. onChange must not
+// block.
+func (m *Manager) WatchChanges(onChange func()) (*backend.Watch, error) {
+	return backend.WatchChanges(m.clip, onChange)
+}
+
+// setLastTransformedLocked records text as what the manager last left on the
+// clipboard, along with the backend's change count where it has one. The
+// caller holds m.mu.
+func (m *Manager) setLastTransformedLocked(text string) {
+	m.lastTransformedClipboard = text
+	m.lastTransformedCount, _ = backend.ChangeCount(m.clip)
+}
+
+// isLastTransformedLocked reports whether the clipboard, holding text, is
+// still what the manager last left on it. Where the backend counts changes,
+// the count tells, so text copied again counts as new even if it is the same;
+// otherwise the text is compared. The caller holds m.mu.
+func (m *Manager) isLastTransformedLocked(text string) bool {
+	if m.lastTransformedClipboard == "" {
+		return false
+	}
+	if m.lastTransformedCount != 0 {
+		if count, ok := backend.ChangeCount(m.clip); ok {
+			return count == m.lastTransformedCount
+		}
+	}
+	return text == m.lastTransformedClipboard
+}
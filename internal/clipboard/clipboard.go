@@ -12,25 +12,73 @@ import (
 	"time"
 	"unicode"
 
-	"github.com/atotto/clipboard"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/actions"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/audit"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/clipboard/backend"
 	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/history"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/router"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/stats"
 )
 
+// pastePlatform presses the paste shortcut of the platform. Tests replace it
+// so that they don't send keys to the focused window.
+var pastePlatform = simulatePlatformPaste
+
 // Manager handles clipboard operations and transformations
+//
+// Locking: opMu serializes the operations that read the clipboard and then
+// write it (processing and reverting), so they cannot interleave. mu protects
+// the fields below it and is only held briefly, never while waiting for
+// another operation, running callbacks or sleeping. Take opMu before mu.
+// clip and onRevertStatusChange are set once by NewManager.
 type Manager struct {
-	mu                       sync.RWMutex      // Protects all fields below
+	clip                     backend.Backend // System clipboard access
+	onRevertStatusChange     func(bool)      // Called without holding mu
+	opMu                     sync.Mutex      // Serializes clipboard read-modify-write operations
+	mu                       sync.RWMutex    // Protects all fields below
+	generation               uint64          // Counts clipboard writes by ProcessClipboard; a pending automatic revert only runs if it is unchanged
 	previousClipboard        string
+	previousFormats          *backend.Snapshot // All formats of previousClipboard, if it held more than text and the platform can restore them
+	revertManual             bool              // previousClipboard is reverted by the user rather than automatically after the paste
+	revertSeq                uint64            // Counts stored originals; an expiry only clears the one it was armed for
+	wipeSeq                  uint64            // Counts scheduled clipboard wipes; only the latest one runs
 	lastTransformedClipboard string
+	lastTransformedCount     uint64         // Backend's change count when lastTransformedClipboard was set; 0 if it counts none
 	config                   *config.Config // Holds the overall config reference
-	onRevertStatusChange     func(bool)
 	lastOriginalForDiff      string
 	lastModifiedForDiff      string
+	lastReplacementCount     int               // Regex matches replaced by the last ProcessClipboard call
+	lastActionErrors         []string          // Action, script, command and transformer rules of the last ProcessClipboard call that failed
+	lastBudgetOverruns       []string          // Profiles of the last ProcessClipboard call that ran out of time
+	lastSkipReason           string            // Why the last ProcessClipboard call left the clipboard alone, if it did
+	stats                    *stats.Store      // Per-rule usage statistics (nil disables collection)
+	history                  *history.Store    // Encrypted history of clipboard changes (nil disables it)
+	auditLog                 *audit.Log        // Content-free log of the rules that ran (nil disables it)
 	resolvedSecrets          map[string]string // Added: Runtime secrets
+	counters                 map[string]int    // Last value of each {{counter:label}} template variable this session
+	captures                 map[string]string // Values captured by capture_as rules in the current run, for {{var:name}}
+	roundTrips               []roundTripPass   // Recent forward passes of round_trip profiles, oldest first; kept in memory only
+	tokens                   *tokenTable       // Numbered placeholders issued by "numbered" rules this session
+	undo                     undoChain         // Recent clipboard states for undo_hotkey and redo_hotkey; kept in memory only
+	recentCopies             copyHashes        // Hashes of the texts ProcessCopied took on recently; kept in memory only
+	onUndoChange             func()            // Called without holding mu when undo changes
+
+	// Asks whether to process a clipboard over the size limits; nil skips it
+	confirmOversize func(reason string) bool
+
+	// Transformers run by the executables in the plugins folder, by name
+	plugins map[string]*actions.Plugin
+
+	transformerReports     []string // Reports of transformer rules in the current run, see Reporter
+	lastTransformerReports []string // Reports of the last ProcessClipboard call
 }
 
 // NewManager creates a new clipboard manager
 func NewManager(cfg *config.Config, resolvedSecrets map[string]string, onRevertStatusChange func(bool)) *Manager { // Added resolvedSecrets param
 	return &Manager{
+		clip:                 backend.SelectBackend(),
 		config:               cfg, // Store the main config reference
 		resolvedSecrets:      resolvedSecrets, // Store secrets map
 		onRevertStatusChange: onRevertStatusChange,
@@ -45,19 +93,123 @@ func (m *Manager) UpdateResolvedSecrets(newSecrets map[string]string) { // Added
 	log.Println("Clipboard Manager: Updated resolved secrets.")
 }
 
+// SetStatistics sets the store that collects per-rule usage statistics.
+func (m *Manager) SetStatistics(store *stats.Store) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats = store
+}
+
+// SetHistory sets the store that keeps the clipboard changes across restarts.
+func (m *Manager) SetHistory(store *history.Store) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.history = store
+}
+
+// SetAuditLog sets the log recording which rules changed the clipboard when.
+func (m *Manager) SetAuditLog(auditLog *audit.Log) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.auditLog = auditLog
+}
+
 // UpdateConfig updates the config reference used by the manager. // <<< NEW METHOD ADDED
 func (m *Manager) UpdateConfig(newCfg *config.Config) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
 	m.config = newCfg
+	// Re-evaluate revert status based on whether temp clipboard is enabled
+	// and if something is actually stored
+	if !m.config.KeepsOriginals() {
+		// No profile keeps originals anymore
+		m.previousClipboard = ""
+		m.previousFormats = nil
+	}
+	m.armRevertExpiryLocked() // revert_timeout_seconds may have changed
+	m.trimUndoLocked(m.config.GetUndoDepth())
+	m.recentCopies.reset() // Changed rules may change the result of a copy seen before
+	canRevertNow := m.previousClipboard != ""
+	m.mu.Unlock()
 	log.Println("Clipboard Manager: Updated config reference.")
-	// Optionally, re-evaluate revert status based on new config
+
 	if m.onRevertStatusChange != nil {
-		// Re-trigger status update based on whether temp clipboard is enabled
-		// and if something is actually stored
-		canRevertNow := m.config.TemporaryClipboard && m.previousClipboard != ""
 		m.onRevertStatusChange(canRevertNow)
 	}
+	m.notifyUndoChange()
+}
+
+// SetProfileEnabled enables or disables the profile named name in the
+// configuration the manager reads, holding mu so a hotkey press never sees it
+// half-changed. Enabling a profile of a group disables the other profiles of
+// the group, whose names are returned. ok is false if there is no such
+// profile.
+func (m *Manager) SetProfileEnabled(name string, enabled bool) (disabled []string, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.config == nil {
+		return nil, false
+	}
+	for i := range m.config.Profiles {
+		if m.config.Profiles[i].Name != name {
+			continue
+		}
+		if enabled {
+			return m.config.EnableProfileExclusive(i), true
+		}
+		m.config.Profiles[i].Enabled = false
+		return nil, true
+	}
+	return nil, false
+}
+
+// CanUndo reports whether the original of the last change is kept until the
+// user reverts it, rather than restored automatically after the paste.
+func (m *Manager) CanUndo() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.previousClipboard != "" && m.revertManual
+}
+
+// LastReplacementCount returns how many regex matches the last clipboard
+// processing replaced (0 if it didn't change the clipboard).
+func (m *Manager) LastReplacementCount() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.lastReplacementCount
+}
+
+// LastActionErrors describes the action, script, command, transformer and
+// llm rules of the last clipboard processing that failed, e.g. a JSON
+// formatter given text that is not JSON. Those rules left the text unchanged.
+func (m *Manager) LastActionErrors() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.lastActionErrors
+}
+
+// LastBudgetOverruns describes the profiles of the last clipboard processing
+// that exceeded their time budget and the rule that took longest in each.
+// The rules they did not finish left the text unchanged.
+func (m *Manager) LastBudgetOverruns() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.lastBudgetOverruns
+}
+
+// LastTransformerReports returns what the transformer rules of the last
+// clipboard processing reported, e.g. how much text token_budget trimmed.
+func (m *Manager) LastTransformerReports() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.lastTransformerReports
+}
+
+// LastSkipReason explains why the last clipboard processing did not process
+// the clipboard, e.g. because it held an image. It is empty otherwise.
+func (m *Manager) LastSkipReason() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.lastSkipReason
 }
 
 // GetLastDiff returns the last pair of original/modified text for diffing.
@@ -75,9 +227,29 @@ func (m *Manager) GetLastDiff() (original string, modified string, ok bool) {
 var secretPlaceholderRegex = regexp.MustCompile(`\{\{([a-zA-Z0-9_]+)\}\}`)
 var ErrSecretNotFound = errors.New("secret placeholder not found in resolved secrets")
 
-// resolvePlaceholders replaces {{placeholder}} with actual secret values.
+// secretsFor returns a copy of the secrets rep may resolve: all of them, or
+// only those in the secrets_scope of its profile.
+func (m *Manager) secretsFor(rep config.Replacement) map[string]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return config.ScopeSecrets(m.resolvedSecrets, rep.SecretScope())
+}
+
+// resolvePlaceholders expands template variables such as {{date}} using vars
+// and then replaces {{placeholder}} with actual secret values. Variable names
+// are reserved, so a variable never reaches the secret lookup.
 // Returns the resolved string and an error if any placeholder could not be resolved.
-func resolvePlaceholders(text string, secrets map[string]string, escapeForRegex bool) (string, error) {
+func resolvePlaceholders(text string, secrets map[string]string, vars *templateVars, escapeForRegex bool) (string, error) {
+	if vars != nil && strings.Contains(text, "{{") {
+		text = templateVariableRegex.ReplaceAllStringFunc(text, func(match string) string {
+			value := vars.expand(match)
+			if escapeForRegex {
+				return regexp.QuoteMeta(value)
+			}
+			return value
+		})
+	}
+
 	var firstError error
 	result := secretPlaceholderRegex.ReplaceAllStringFunc(text, func(match string) string {
 		// If an error already occurred, stop trying to replace
@@ -113,17 +285,95 @@ func resolvePlaceholders(text string, secrets map[string]string, escapeForRegex
 
 // --- End Secret Placeholder Handling ---
 
-// ProcessClipboard reads, transforms, and pastes clipboard content
-func (m *Manager) ProcessClipboard(hotkeyStr string, isReverse bool) (message string, changedForDiff bool) {
-	origText, err := clipboard.ReadAll()
+// ProcessClipboard reads, transforms, and pastes clipboard content. Canceling
+// ctx stops processing and leaves the clipboard unchanged.
+func (m *Manager) ProcessClipboard(ctx context.Context, hotkeyStr string, isReverse bool) (message string, changedForDiff bool) {
+	return m.processClipboard(ctx, hotkeyStr, "", isReverse, false)
+}
+
+// ProcessProfile is ProcessClipboard for the profiles named profileName,
+// whether or not they are enabled, e.g. for "clipregex trigger".
+func (m *Manager) ProcessProfile(ctx context.Context, profileName string, isReverse bool) (message string, changedForDiff bool) {
+	return m.processClipboard(ctx, "", profileName, isReverse, false)
+}
+
+// processClipboard runs the profiles of hotkeyStr, or those named
+// profileName if it is set. If copied is set, it runs every enabled profile
+// on newly copied text and pastes nothing, see ProcessCopied.
+func (m *Manager) processClipboard(ctx context.Context, hotkeyStr, profileName string, isReverse, copied bool) (message string, changedForDiff bool) {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
+	m.mu.Lock()
+	m.lastSkipReason = ""
+	m.lastActionErrors = nil // Not reported again if the clipboard is skipped
+	m.lastBudgetOverruns = nil
+	m.lastTransformerReports = nil
+	m.transformerReports = nil
+	m.captures = nil // Capture variables only live for one run
+	m.mu.Unlock()
+
+	// exclude_windows keeps a press away from applications such as password
+	// managers: nothing is copied, transformed or pasted there
+	m.mu.RLock()
+	pressProfiles := m.pressProfilesLocked(hotkeyStr, profileName, isReverse, copied)
+	m.mu.RUnlock()
+	if profile, entry, window, excluded := excludingProfile(pressProfiles); excluded {
+		log.Printf("Profile '%s' excludes the focused window (%s, '%s') with '%s'. Nothing was processed or pasted.", profile.Name, window.Process, window.Title, entry)
+		if !copied {
+			m.mu.Lock()
+			m.lastSkipReason = i18n.Tf("Profile '%s' doesn't run in %s (exclude_windows). Nothing was transformed or pasted.", profile.Name, describeWindow(window))
+			m.lastReplacementCount = 0
+			m.mu.Unlock()
+		}
+		return "", false
+	}
+
+	// copy_selection profiles transform the selected text, not what was copied before
+	if timeout, ok := m.copiesSelection(hotkeyStr, profileName, isReverse); ok && !copied {
+		if err := m.copySelection(ctx, timeout); err != nil {
+			m.mu.Lock()
+			switch {
+			case errors.Is(err, errNothingCopied):
+				log.Printf("Copying the selection didn't change the clipboard within %v. Nothing was processed or pasted.", timeout)
+				m.lastSkipReason = i18n.T("Nothing was selected, so nothing was transformed or pasted.")
+			case ctx.Err() != nil:
+				log.Println("Clipboard processing canceled while copying the selection.")
+			default:
+				log.Printf("Failed to copy the selection: %v", err)
+				m.lastSkipReason = i18n.Tf("Could not copy the selection: %v. Nothing was pasted.", err)
+			}
+			m.lastReplacementCount = 0
+			m.mu.Unlock()
+			return "", false
+		}
+	}
+
+	contents, err := m.clip.Formats()
+	if err != nil {
+		log.Printf("Could not list the clipboard formats (%v). Processing the clipboard as text.", err)
+	} else if len(contents) > 0 && !contents.HasText() {
+		if !copied {
+			m.passThrough(contents)
+		}
+		return "", false
+	}
+
+	origText, err := m.clip.ReadText()
 	if err != nil {
 		log.Printf("Failed to read clipboard: %v", err)
 		return "", false
 	}
+	if copied && !m.isNewCopy(origText) {
+		return "", false
+	}
+	if !m.isText(origText) || !m.withinLimits(origText) {
+		return "", false
+	}
 
 	// Lock for reading initial state
 	m.mu.RLock()
-	isNewContent := m.lastTransformedClipboard == "" || origText != m.lastTransformedClipboard
+	isNewContent := !m.isLastTransformedLocked(origText)
 
 	// Check if config is loaded before proceeding
 	if m.config == nil || m.config.Profiles == nil {
@@ -132,41 +382,194 @@ func (m *Manager) ProcessClipboard(hotkeyStr string, isReverse bool) (message st
 		return "Error: Configuration not loaded.", false // Indicate error
 	}
 
-	// Make a copy of profiles to work with (to avoid holding lock during processing)
-	profilesCopy := make([]config.ProfileConfig, len(m.config.Profiles))
-	copy(profilesCopy, m.config.Profiles)
+	// Make a copy of profiles to work with (to avoid holding lock during processing),
+	// with the rules of included rule groups in front of each profile's own rules
+	profilesCopy := m.config.ResolvedProfiles()
+	statsStore := m.stats
+	largeClipboard := len(origText) >= m.config.GetLargeClipboardBytes()
+	smart := !isReverse && profileName == "" && m.config.SmartHotkey != "" && hotkeyStr == m.config.SmartHotkey
+	contentTypeRules := m.config.ContentTypeRules
+	budgets := make([]time.Duration, len(profilesCopy)) // Time each profile may take, 0 for no limit
+	for i, profile := range profilesCopy {
+		budgets[i] = m.config.GetProfileBudget(profile)
+	}
 	m.mu.RUnlock()
 
+	// The smart hotkey runs the profiles that handle the clipboard's content type
+	routedType := ""
+	if smart {
+		var detected []string
+		routedType, detected = router.Route(origText, profilesCopy, contentTypeRules)
+		if routedType == "" {
+			log.Printf("Smart hotkey: no enabled profile handles the detected content types (%s).", strings.Join(detected, ", "))
+			m.mu.Lock()
+			m.lastSkipReason = i18n.Tf("No profile handles the clipboard content (detected: %s). It was pasted unchanged.", strings.Join(detected, ", "))
+			m.mu.Unlock()
+		} else {
+			log.Printf("Smart hotkey: clipboard detected as %s, running the profiles for '%s'.", strings.Join(detected, ", "), routedType)
+		}
+	}
+
+	if largeClipboard {
+		log.Printf("Large clipboard (%d bytes): line_safe rules run in chunks of about %d bytes and rule timings are logged.", len(origText), chunkBytes)
+	}
+	processingStart := time.Now()
+	var timings []ruleTiming
+
 	newText := origText
 	totalReplacements := 0
 	var activeProfiles []string
-
-	// Apply replacements from all enabled profiles that match this hotkey
-	for _, profile := range profilesCopy { // Iterate using the copied profiles
-		if !profile.Enabled {
-			continue
+	var auditRules []audit.Rule // Rules that changed the text, for the audit log
+	var actionErrors []string
+	var budgetOverruns []string
+	canceled := false
+	typeOutput := false // True if any matching profile wants the result typed instead of pasted
+	plainText := false  // True if any matching profile wants the formatting dropped
+
+	triggered := func(profile config.ProfileConfig) bool {
+		if copied {
+			return profile.Enabled
+		}
+		if smart {
+			return routedType != "" && profile.Enabled && profile.HandlesContentType(routedType)
 		}
+		if profileName != "" {
+			return profile.Name == profileName
+		}
+		return profile.Enabled && ((profile.Hotkey == hotkeyStr && !isReverse) ||
+			(profile.ReverseHotkey == hotkeyStr && isReverse))
+	}
+	var planner *matchPlanner // Skips rules that can't match large texts; forward only
+	if !isReverse {
+		planner = m.newMatchPlanner(profilesCopy, triggered, len(origText))
+	}
 
-		if (profile.Hotkey == hotkeyStr && !isReverse) ||
-			(profile.ReverseHotkey == hotkeyStr && isReverse) {
+	// Apply replacements from all enabled profiles that match this hotkey
+	for profileIndex, profile := range profilesCopy { // Iterate using the copied profiles
+		if triggered(profile) {
+			if ok, err := profile.When.Holds(newText); err != nil {
+				log.Printf("Error evaluating 'when' of profile '%s': %v. Skipping profile.", profile.Name, err)
+				continue
+			} else if !ok {
+				log.Printf("Profile '%s' matched hotkey, but its 'when' condition does not match the clipboard content. Skipping profile.", profile.Name)
+				continue
+			}
 			activeProfiles = append(activeProfiles, profile.Name)
+			if profile.OutputMode == config.OutputModeType {
+				typeOutput = true
+			}
+			if profile.OutputMode == config.OutputModePlainText {
+				plainText = true
+			}
 			profileReplacements := 0
+			profileInput := newText
+
+			rules := profile.Replacements
+			var pass *roundTripPass // Records the forward replacements of a round_trip profile
+			if profile.RoundTrip && !isReverse {
+				pass = &roundTripPass{profile: profile.Name, input: newText}
+			} else if profile.RoundTrip {
+				if restored, count, ok := m.restoreRoundTrip(profile.Name, newText); ok {
+					newText = restored
+					profileReplacements += count
+					totalReplacements += count
+					rules = nil // Restored from the recording instead of reversing the rules
+				} else {
+					log.Printf("Round trip: nothing recorded by profile '%s' is in the clipboard. Reversing its rules instead.", profile.Name)
+				}
+			}
 
-			for ruleIndex, rep := range profile.Replacements { // Use index for better logging
+			profileCtx, cancelProfile := context.WithCancel(ctx)
+			if budgets[profileIndex] > 0 {
+				profileCtx, cancelProfile = context.WithTimeout(ctx, budgets[profileIndex])
+			}
+			var slowest ruleTiming
+			var overrun *budgetOverrun
+
+			if profile.Extract != "" && !isReverse {
+				input := newText
+				extracted, count, errExtract := runWithBudget(profileCtx, func() (string, int, error) { return m.extract(input, profile) })
+				switch {
+				case errExtract != nil && ctx.Err() != nil:
+					canceled = true
+				case errExtract != nil && profileCtx.Err() != nil:
+					log.Printf("Profile '%s' exceeded its time budget of %v while extracting.", profile.Name, budgets[profileIndex])
+					budgetOverruns = append(budgetOverruns, i18n.Tf("Profile '%s' exceeded its time budget of %v while extracting, so nothing was extracted.", profile.Name, budgets[profileIndex]))
+				case errExtract != nil:
+					log.Printf("Error extracting with profile '%s': %v. Skipping profile.", profile.Name, errExtract)
+				case count == 0:
+					log.Printf("Extract profile '%s' found no matches.", profile.Name)
+					m.mu.Lock()
+					m.lastSkipReason = i18n.Tf("Profile '%s' found nothing to extract.", profile.Name)
+					m.mu.Unlock()
+				default:
+					newText = extracted
+					profileReplacements += count
+					totalReplacements += count
+				}
+				rules = nil // The rules were matched, not applied
+			}
+
+			for ruleIndex, rep := range rules { // Use index for better logging
 				var replaced string
 				var replacedCount int
 				var errReplace error // Capture errors from replacement functions
 
-				if !isReverse {
-					// Pass manager's resolvedSecrets implicitly via method receiver
-					replaced, replacedCount, errReplace = m.applyForwardReplacement(newText, rep)
-				} else {
+				if rep.Disabled {
+					continue // Switched off in the tray
+				}
+				if planner.skip(profileIndex, ruleIndex, newText) {
+					continue // No match, nothing to apply
+				}
+				ruleStart := time.Now()
+				input := newText // The rule may be abandoned while still reading its input
+				var recorded []roundTripReplacement
+				replaced, replacedCount, errReplace = runWithBudget(profileCtx, func() (string, int, error) {
+					if pass != nil {
+						return m.applyForward(input, rep, func(original, replacement string) {
+							recorded = append(recorded, roundTripReplacement{original: original, replacement: replacement})
+						})
+					} else if !isReverse && largeClipboard && rep.LineSafe {
+						return m.applyChunked(input, rep)
+					} else if !isReverse {
+						// Pass manager's resolvedSecrets implicitly via method receiver
+						return m.applyForwardReplacement(input, rep)
+					}
 					// Pass manager's resolvedSecrets implicitly via method receiver
-					replaced, replacedCount, errReplace = m.applyReverseReplacement(newText, rep)
+					return m.applyReverseReplacement(input, rep)
+				})
+				if pass != nil && errReplace == nil && replaced != newText {
+					pass.rules = append(pass.rules, recorded)
+					pass.lossy = pass.lossy || rep.IsTransform()
+				}
+				elapsed := time.Since(ruleStart)
+				timing := ruleTiming{profile: profile.Name, index: ruleIndex + 1, summary: rep.Summary(), elapsed: elapsed}
+				if elapsed > slowest.elapsed {
+					slowest = timing
+				}
+				if largeClipboard {
+					timings = append(timings, timing)
+				} else if elapsed >= slowRuleThreshold {
+					log.Printf("Slow rule: profile '%s' rule #%d (%s) took %v on %d bytes.", profile.Name, ruleIndex+1, rep.Summary(), elapsed.Round(time.Millisecond), len(newText))
+				}
+
+				if errReplace != nil && profileCtx.Err() != nil {
+					if ctx.Err() != nil {
+						canceled = true
+					} else {
+						overrun = &budgetOverrun{profile: profile.Name, budget: budgets[profileIndex], skipped: len(rules) - ruleIndex}
+					}
+					break
 				}
 
 				if errReplace != nil {
-					log.Printf("Error applying replacement rule #%d (Profile: %s, Regex: %s): %v. Skipping rule.", ruleIndex+1, profile.Name, rep.Regex, errReplace)
+					var inputErr *actions.InputError
+					if errors.As(errReplace, &inputErr) {
+						actionErrors = append(actionErrors, i18n.Tf("Profile '%s' rule #%d (%s): the clipboard content is %v", profile.Name, ruleIndex+1, rep.Summary(), inputErr))
+					} else if rep.Script != "" || rep.Command != nil || rep.Transformer != "" || rep.LLM != nil {
+						actionErrors = append(actionErrors, i18n.Tf("Profile '%s' rule #%d: %v", profile.Name, ruleIndex+1, errReplace))
+					}
+					log.Printf("Error applying replacement rule #%d (Profile: %s, Regex: %s): %v. Skipping rule.", ruleIndex+1, profile.Name, rep.Summary(), errReplace)
 					continue // Skip this rule if secrets couldn't be resolved or regex invalid
 				}
 
@@ -182,9 +585,38 @@ func (m *Manager) ProcessClipboard(hotkeyStr string, isReverse bool) (message st
 						// if profileReplacements == 0, but that might be confusing.
 					}
 					totalReplacements += replacedCount // Accumulate total replacements counted by regex engine
-					newText = replaced                 // Update text only if changed
+					if statsStore != nil {
+						statsStore.RecordRule(profile.Name, rep.Summary(), replacedCount, newText, replaced)
+					}
+					auditRules = append(auditRules, audit.Rule{Profile: profile.Name, Index: ruleIndex + 1, Description: rep.Description, Fingerprint: audit.Fingerprint(rep), Matches: replacedCount})
+					newText = replaced // Update text only if changed
+
+					if rep.StopAfterMatch {
+						log.Printf("Rule #%d (Profile: %s) has stop_after_match set. Skipping the remaining rules of the profile.", ruleIndex+1, profile.Name)
+						break
+					}
 				}
 			} // End loop over replacements in profile
+			cancelProfile()
+
+			if canceled {
+				break
+			}
+			if overrun != nil {
+				overrun.slowest = slowest
+				log.Printf("Profile '%s' exceeded its time budget of %v; skipped its remaining %d rule(s). Slowest rule: #%d (%s), %v.",
+					profile.Name, overrun.budget, overrun.skipped, slowest.index, slowest.summary, slowest.elapsed.Round(time.Millisecond))
+				budgetOverruns = append(budgetOverruns, overrun.message())
+			}
+
+			if pass != nil && newText != pass.input {
+				pass.output = newText
+				m.recordRoundTrip(*pass)
+			}
+
+			if statsStore != nil && newText != profileInput {
+				statsStore.RecordProfile(profile.Name, profileInput, newText)
+			}
 
 			directionText := "forward"
 			if isReverse {
@@ -199,56 +631,91 @@ func (m *Manager) ProcessClipboard(hotkeyStr string, isReverse bool) (message st
 		} // End check for matching hotkey
 	} // End loop over profiles
 
+	if canceled {
+		log.Println("Clipboard processing canceled. The clipboard was left unchanged.")
+		m.mu.Lock()
+		m.lastReplacementCount = 0
+		m.lastActionErrors = nil
+		m.lastBudgetOverruns = nil
+		m.mu.Unlock()
+		return "", false
+	}
+
+	if planner != nil {
+		log.Printf("Match planner: %d of %d rule(s) skipped without a match, %d scan wave(s).", planner.skipped, len(planner.rules), planner.waves)
+	}
+	if largeClipboard && len(timings) > 0 {
+		logRuleTimings(timings, len(origText), time.Since(processingStart))
+	}
+
+	// The cursor is moved back to a snippet's {{cursor}} after pasting
+	newText, cursorBack := takeCursorMarker(newText)
+
 	// Lock for writing state changes
 	m.mu.Lock()
 
-	// Read config flags under lock
-	temporaryClipboard := m.config != nil && m.config.TemporaryClipboard
-	automaticReversion := m.config != nil && m.config.AutomaticReversion
+	// Read config flags under lock; the profiles that ran may override the revert settings
+	temporaryClipboard, automaticReversion := false, false
 	pasteDelayMs := config.DefaultPasteDelayMs
 	revertDelayMs := config.DefaultRevertDelayMs
+	typingDelayMs := config.DefaultTypingDelayMs
 	revertHotkey := ""
 	if m.config != nil {
+		temporaryClipboard, automaticReversion = m.config.RevertSettings(activeProfiles)
+		automaticReversion = automaticReversion && !copied // Nothing is pasted, so the result stays
 		revertHotkey = m.config.RevertHotkey
 		pasteDelayMs = m.config.GetPasteDelay()
 		revertDelayMs = m.config.GetRevertDelay()
+		typingDelayMs = m.config.GetTypingDelay()
 	}
 
+	// The clipboard is rewritten if the text changed, or as plain text to drop
+	// the formatting of its other formats
+	stripFormats := plainText && !contents.TextOnly()
+	changed := newText != origText || stripFormats
+
+	// The revert status callback runs once the lock is released
+	revertStatus, revertStatusSet := false, false
+	setRevertStatus := func(canRevert bool) { revertStatus, revertStatusSet = canRevert, true }
+
 	// --- Temporary clipboard logic ---
 	if temporaryClipboard {
-		if isNewContent && newText != origText {
+		m.revertManual = !automaticReversion
+		if isNewContent && changed {
 			m.previousClipboard = origText
-			if m.onRevertStatusChange != nil {
-				m.onRevertStatusChange(true) // Enable revert option
-			}
+			m.previousFormats = m.saveFormats(contents)
+			setRevertStatus(true) // Enable revert option
+			m.armRevertExpiryLocked()
 		} else if !isNewContent && m.previousClipboard != "" {
 			// If processing already transformed text, keep the existing previousClipboard and revert status active
-			if m.onRevertStatusChange != nil {
-				m.onRevertStatusChange(true)
-			}
+			setRevertStatus(true)
 		} else {
 			// Enable revert only if a change was made and nothing was stored previously
-			if newText != origText && m.previousClipboard == "" {
+			if changed && m.previousClipboard == "" {
 				m.previousClipboard = origText
-				if m.onRevertStatusChange != nil {
-					m.onRevertStatusChange(true)
-				}
-			} else if newText == origText && m.previousClipboard == "" { // No change and nothing stored
-				if m.onRevertStatusChange != nil {
-					m.onRevertStatusChange(false)
-				}
+				m.previousFormats = m.saveFormats(contents)
+				setRevertStatus(true)
+				m.armRevertExpiryLocked()
+			} else if !changed && m.previousClipboard == "" { // No change and nothing stored
+				setRevertStatus(false)
 			} // Otherwise, leave revert status as is
 		}
-	} else if m.previousClipboard != "" {
-		// If temporary clipboard got disabled externally (config reload), clear stored original and update UI
+	} else if m.previousClipboard != "" && isNewContent && changed {
+		// The profiles don't keep originals, and the stored one belongs to older content
 		m.previousClipboard = ""
-		if m.onRevertStatusChange != nil {
-			m.onRevertStatusChange(false)
-		}
+		m.previousFormats = nil
+		setRevertStatus(false)
 	}
 
 	// --- Store state for diff *if* changes were actually made ---
 	changedForDiff = (origText != newText) // The most reliable check
+	m.lastReplacementCount = 0
+	m.lastActionErrors = actionErrors
+	m.lastBudgetOverruns = budgetOverruns
+	m.lastTransformerReports = m.transformerReports
+	if changedForDiff {
+		m.lastReplacementCount = totalReplacements
+	}
 	if changedForDiff {
 		m.lastOriginalForDiff = origText
 		m.lastModifiedForDiff = newText
@@ -261,32 +728,82 @@ func (m *Manager) ProcessClipboard(hotkeyStr string, isReverse bool) (message st
 	}
 
 	// --- Update the clipboard with the replaced text only if it changed ---
-	if changedForDiff {
-		if err := clipboard.WriteAll(newText); err != nil {
+	if changed {
+		if stripFormats {
+			log.Printf("Writing the clipboard as plain text, dropping formats: %s", contents)
+		}
+		if err := m.clip.WriteText(newText); err != nil {
 			log.Printf("Failed to write to clipboard: %v", err)
 			m.lastOriginalForDiff = "" // Clear diff state on error
 			m.lastModifiedForDiff = ""
 			m.mu.Unlock()
+			if revertStatusSet && m.onRevertStatusChange != nil {
+				m.onRevertStatusChange(revertStatus)
+			}
 			return "", false // Return false for changedForDiff
 		}
 		// Track what was just placed in the clipboard
-		m.lastTransformedClipboard = newText
+		m.setLastTransformedLocked(newText)
+		m.generation++
+		if changedForDiff {
+			m.recordUndoLocked(origText, newText, activeProfiles, isReverse)
+		}
+		if m.containsSecretLocked(origText, newText) {
+			// Automatic reversion may put the original back, so it is wiped as well
+			m.armWipeLocked(origText, newText)
+		}
 	} else {
 		// If no change, ensure lastTransformed is same as original read
-		m.lastTransformedClipboard = origText
+		m.setLastTransformedLocked(origText)
 	}
 
 	// Capture previous clipboard value for goroutine
 	previousClipboardCopy := m.previousClipboard
+	previousFormatsCopy := m.previousFormats
+	generation := m.generation
+	historyStore := m.history
+	auditLog := m.auditLog
 
 	m.mu.Unlock()
 
+	if revertStatusSet && m.onRevertStatusChange != nil {
+		m.onRevertStatusChange(revertStatus)
+	}
+	if changedForDiff {
+		m.notifyUndoChange()
+	}
+
+	if changedForDiff && historyStore != nil {
+		entry := history.Entry{Time: time.Now(), Profiles: activeProfiles, Original: origText, Result: newText}
+		if err := historyStore.Add(entry); err != nil {
+			log.Printf("Warning: failed to save the clipboard history: %v", err)
+		}
+	}
+	if auditLog != nil && len(activeProfiles) > 0 {
+		record := audit.Record{
+			Time:         time.Now(),
+			Hotkey:       hotkeyStr,
+			Reverse:      isReverse,
+			Profiles:     activeProfiles,
+			Rules:        auditRules,
+			Replacements: totalReplacements,
+			Changed:      changedForDiff,
+			BeforeSHA256: audit.Hash(origText),
+			AfterSHA256:  audit.Hash(newText),
+			BeforeBytes:  len(origText),
+			AfterBytes:   len(newText),
+		}
+		if err := auditLog.Append(record); err != nil {
+			log.Printf("Warning: failed to write the audit log: %v", err)
+		}
+	}
+
 	// --- Generate notification message if replacements were made and text changed ---
 	var baseMessage string // Use a separate var for the core message
-	if changedForDiff {   // Only generate message if text actually changed
+	if changed { // Only generate message if text actually changed or lost its formatting
 		directionIndicator := ""
 		if isReverse {
-			directionIndicator = " (reverse)"
+			directionIndicator = " " + i18n.T("(reverse)")
 		}
 
 		log.Printf("Clipboard updated%s from profiles: %s. Total regex matches counted: %d",
@@ -295,38 +812,44 @@ func (m *Manager) ProcessClipboard(hotkeyStr string, isReverse bool) (message st
 		profileNames := strings.Join(activeProfiles, ", ")
 		profilePart := ""
 		if len(activeProfiles) > 1 {
-			profilePart = fmt.Sprintf(" from profiles: %s", profileNames)
+			profilePart = " " + i18n.Tf("from profiles: %s", profileNames)
 		} else if len(activeProfiles) == 1 {
-			profilePart = fmt.Sprintf(" from profile: %s", profileNames)
+			profilePart = " " + i18n.Tf("from profile: %s", profileNames)
 		}
 
-		if totalReplacements > 0 {
-			baseMessage = fmt.Sprintf("%d replacement(s)%s applied%s.",
+		if !changedForDiff {
+			baseMessage = i18n.Tf("Formatting removed%s.", profilePart)
+		} else if totalReplacements > 0 {
+			baseMessage = i18n.Tf("%d replacement(s)%s applied%s.",
 				totalReplacements, directionIndicator, profilePart)
 		} else {
 			// Changed, but count is 0 (e.g., empty match replacement)
-			baseMessage = fmt.Sprintf("Clipboard updated%s%s.",
+			baseMessage = i18n.Tf("Clipboard updated%s%s.",
 				directionIndicator, profilePart)
 		}
 
 		// Use captured config flags (from earlier when we had the lock)
 		if temporaryClipboard && previousClipboardCopy != "" { // Check if something is stored
 			if automaticReversion {
-				baseMessage += " Clipboard will be automatically reverted after paste."
+				baseMessage += " " + i18n.T("Clipboard will be automatically reverted after paste.")
 			} else if revertHotkey != "" {
-				baseMessage += fmt.Sprintf(" Press %s or use Systray Menu to revert.", revertHotkey)
+				baseMessage += " " + i18n.Tf("Press %s or use Systray Menu to revert.", revertHotkey)
 			} else {
-				baseMessage += " Use Systray Menu to revert."
+				baseMessage += " " + i18n.T("Use Systray Menu to revert.")
 			}
 		}
 		// Append note about viewing changes
-		message = baseMessage + " Use Systray Menu to view details."
+		message = baseMessage + " " + i18n.T("Use Systray Menu to view details.")
 
 	} else {
 		log.Println("No regex replacements applied or text did not change.")
 		message = "" // No message if no replacements/changes
 	}
 
+	if copied {
+		return message, changedForDiff
+	}
+
 	// --- Start paste goroutine regardless of replacements ---
 	go func() {
 		// Important: Recover from any panics so we don't crash
@@ -341,8 +864,21 @@ func (m *Manager) ProcessClipboard(hotkeyStr string, isReverse bool) (message st
 		// Delay before pasting to allow clipboard system and target app to be ready
 		time.Sleep(time.Duration(pasteDelayMs) * time.Millisecond)
 
-		// Try to paste the content *currently* in the clipboard (which is newText)
-		simulatePlatformPaste() // Call the platform-specific paste function
+		if typeOutput {
+			// Type the text key by key for fields that block pasting
+			if err := simulatePlatformTyping(newText, typingDelayMs); err != nil {
+				log.Printf("Typing simulation failed: %v. Falling back to paste.", err)
+				pastePlatform()
+			}
+		} else {
+			// Try to paste the content *currently* in the clipboard (which is newText)
+			pastePlatform() // Call the platform-specific paste function
+		}
+		if cursorBack > 0 {
+			if err := simulatePlatformCursorLeft(cursorBack); err != nil {
+				log.Printf("Failed to move the cursor to {{cursor}}: %v", err)
+			}
+		}
 
 		// Handle automatic reversion *after* paste attempt if enabled
 		// Use captured config flags (no lock needed, these are copies)
@@ -350,22 +886,33 @@ func (m *Manager) ProcessClipboard(hotkeyStr string, isReverse bool) (message st
 			// Delay *after* paste simulation
 			time.Sleep(time.Duration(revertDelayMs) * time.Millisecond)
 
-			// Restore original clipboard
-			if err := clipboard.WriteAll(previousClipboardCopy); err != nil {
+			// Restore original clipboard, unless it was processed or reverted meanwhile
+			m.opMu.Lock()
+			m.mu.RLock()
+			current := m.generation == generation && m.previousClipboard == previousClipboardCopy
+			m.mu.RUnlock()
+			if !current {
+				m.opMu.Unlock()
+				log.Println("Skipping automatic reversion: the clipboard was processed or reverted again in the meantime.")
+			} else if err := m.writePrevious(previousClipboardCopy, previousFormatsCopy); err != nil {
+				m.opMu.Unlock()
 				log.Printf("Failed to automatically restore original clipboard: %v", err)
 			} else {
 				log.Println("Original clipboard content automatically restored after paste.")
 
 				// Lock for state updates
 				m.mu.Lock()
-				currentStored := m.previousClipboard // Capture before clearing
 				// Clear the stored original and update UI status
 				m.previousClipboard = ""
-				m.lastTransformedClipboard = currentStored // Set last transformed to what was restored
+				m.previousFormats = nil
+				m.setLastTransformedLocked(previousClipboardCopy) // Set last transformed to what was restored
+				m.syncUndoLocked(previousClipboardCopy)
 				// Clear diff state too
 				m.lastOriginalForDiff = ""
 				m.lastModifiedForDiff = ""
 				m.mu.Unlock()
+				m.opMu.Unlock()
+				m.notifyUndoChange()
 
 				if m.onRevertStatusChange != nil {
 					// Run callback in a separate goroutine to avoid blocking paste thread if UI is slow
@@ -389,22 +936,52 @@ func (m *Manager) ProcessClipboard(hotkeyStr string, isReverse bool) (message st
 	return message, changedForDiff
 }
 
+// TypeClipboard types the current clipboard text into the focused window
+// instead of pasting it. Used by the dedicated type hotkey.
+func (m *Manager) TypeClipboard() error {
+	text, err := m.clip.ReadText()
+	if err != nil {
+		return fmt.Errorf("failed to read clipboard: %w", err)
+	}
+	if text == "" {
+		return errors.New("clipboard is empty")
+	}
+
+	m.mu.RLock()
+	pasteDelayMs := config.DefaultPasteDelayMs
+	typingDelayMs := config.DefaultTypingDelayMs
+	if m.config != nil {
+		pasteDelayMs = m.config.GetPasteDelay()
+		typingDelayMs = m.config.GetTypingDelay()
+	}
+	m.mu.RUnlock()
+
+	// Give the user time to release the hotkey modifiers before typing
+	time.Sleep(time.Duration(pasteDelayMs) * time.Millisecond)
+	return simulatePlatformTyping(text, typingDelayMs)
+}
+
 // RestoreOriginalClipboard reverts to the previous clipboard content
 func (m *Manager) RestoreOriginalClipboard() bool {
+	// Hold opMu so processing can't store a new original while reverting
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
 	m.mu.Lock()
 	previousClipboardCopy := m.previousClipboard
+	previousFormatsCopy := m.previousFormats
 	m.mu.Unlock()
 
 	if previousClipboardCopy != "" {
 		// Read current clipboard content (optional, for logging comparison)
-		_, errRead := clipboard.ReadAll()
+		_, errRead := m.clip.ReadText()
 		if errRead != nil {
 			log.Printf("Warning: Failed to read current clipboard before reverting: %v", errRead)
 			// Decide whether to proceed anyway or return false. Let's proceed.
 		}
 
 		// Write the stored original content back to the clipboard
-		if err := clipboard.WriteAll(previousClipboardCopy); err != nil {
+		if err := m.writePrevious(previousClipboardCopy, previousFormatsCopy); err != nil {
 			log.Printf("Failed to restore original clipboard: %v", err)
 			return false
 		}
@@ -414,11 +991,12 @@ func (m *Manager) RestoreOriginalClipboard() bool {
 		// Lock for state updates
 		m.mu.Lock()
 		// Clear the stored original clipboard content
-		originalRestored := m.previousClipboard
 		m.previousClipboard = ""
+		m.previousFormats = nil
 
 		// Update the 'last transformed' state to reflect the restored content
-		m.lastTransformedClipboard = originalRestored
+		m.setLastTransformedLocked(previousClipboardCopy)
+		m.syncUndoLocked(previousClipboardCopy) // Its changes can be redone
 
 		// Also clear the diff state as it's no longer relevant to the restored content
 		m.lastOriginalForDiff = ""
@@ -429,6 +1007,7 @@ func (m *Manager) RestoreOriginalClipboard() bool {
 		if m.onRevertStatusChange != nil {
 			m.onRevertStatusChange(false)
 		}
+		m.notifyUndoChange()
 		// Update UI status for diff option? Coordinated elsewhere for now.
 
 		return true
@@ -495,31 +1074,135 @@ func replaceAllStringFuncWithTimeout(re *regexp.Regexp, src string, repl func(st
 	}
 }
 
+// replaceFirstNWithTimeout replaces the first n matches of re in src with repl,
+// expanding $1-style references like ReplaceAllString, with timeout protection
+func replaceFirstNWithTimeout(re *regexp.Regexp, src, repl string, n int, timeoutMs int) (string, error) {
+	type result struct {
+		output string
+		err    error
+	}
+
+	resultCh := make(chan result, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutMs)*time.Millisecond)
+	defer cancel()
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				resultCh <- result{err: fmt.Errorf("panic during regex replacement: %v", r)}
+			}
+		}()
+		var output []byte
+		last := 0
+		for _, loc := range re.FindAllStringSubmatchIndex(src, n) {
+			output = append(output, src[last:loc[0]]...)
+			output = re.ExpandString(output, repl, src, loc)
+			last = loc[1]
+		}
+		output = append(output, src[last:]...)
+		resultCh <- result{output: string(output)}
+	}()
+
+	select {
+	case res := <-resultCh:
+		return res.output, res.err
+	case <-ctx.Done():
+		return src, fmt.Errorf("regex replacement timed out after %dms", timeoutMs)
+	}
+}
+
+// ReadText returns the current clipboard text without modifying it.
+func (m *Manager) ReadText() (string, error) {
+	return m.clip.ReadText()
+}
+
+// PreviewReplacement applies a single rule to text without touching the clipboard.
+// It returns the transformed text and the byte ranges of all matches in the input,
+// resolving secret placeholders exactly like a hotkey-triggered replacement would.
+func (m *Manager) PreviewReplacement(text string, rep config.Replacement) (string, [][]int, error) {
+	if ok, err := rep.When.Holds(text); err != nil || !ok {
+		return text, nil, err
+	}
+	if rep.IsTransform() && rep.Regex == "" {
+		result, count, err := m.applyForwardReplacement(text, rep)
+		if err != nil || count == 0 {
+			return text, nil, err
+		}
+		return result, [][]int{{0, len(text)}}, nil // The action rewrote the whole text
+	}
+
+	var re *regexp.Regexp
+	if rep.IsBidirectional() {
+		var err error
+		if re, _, err = m.bidirectionalMatcher(rep, false); err != nil {
+			return text, nil, err
+		}
+	} else {
+		secretsCopy := m.secretsFor(rep)
+
+		resolvedRegex, err := resolvePlaceholders(rep.Regex, secretsCopy, m.previewTemplateVars(true), true)
+		if err != nil {
+			return text, nil, fmt.Errorf("failed to resolve placeholders in regex '%s': %w", rep.Regex, err)
+		}
+		if re, err = regexp.Compile(rep.Pattern(resolvedRegex)); err != nil {
+			return text, nil, fmt.Errorf("invalid regex: %w", err)
+		}
+	}
+
+	matches := re.FindAllStringIndex(text, -1)
+	if len(matches) == 0 {
+		return text, nil, nil
+	}
+	if rep.MaxCount > 0 && rep.MaxCount < len(matches) {
+		matches = matches[:rep.MaxCount] // Only these are replaced
+	}
+
+	result, _, err := m.applyForwardReplacement(text, rep)
+	if err != nil {
+		return text, matches, err
+	}
+	return result, matches, nil
+}
+
 // applyForwardReplacement handles normal regex-based replacements, now resolving secrets.
 // Returns: replaced string, count, error (if secret resolution failed or regex invalid)
 func (m *Manager) applyForwardReplacement(text string, rep config.Replacement) (string, int, error) {
-	// Read secrets map under lock
-	m.mu.RLock()
-	secretsCopy := make(map[string]string, len(m.resolvedSecrets))
-	for k, v := range m.resolvedSecrets {
-		secretsCopy[k] = v
+	return m.applyForward(text, rep, nil)
+}
+
+// applyForward is applyForwardReplacement that calls record, if not nil, with
+// every match replaced and its replacement, in order. Actions, scripts and
+// commands are not recorded.
+func (m *Manager) applyForward(text string, rep config.Replacement, record func(original, replacement string)) (string, int, error) {
+	if ok, err := rep.When.Holds(text); err != nil || !ok {
+		return text, 0, err // The rule's 'when' condition does not match this text
+	}
+	if rep.MatchMode == config.MatchModeLine && strings.Contains(text, "\r\n") {
+		return withLFLineBreaks(text, func(lf string) (string, int, error) { return m.applyForward(lf, rep, record) })
+	}
+	if rep.IsTransform() && rep.Regex == "" {
+		return m.applyAction(text, nil, rep, 0)
+	}
+	if rep.IsBidirectional() {
+		return m.applyBidirectional(text, rep, false, record)
 	}
-	m.mu.RUnlock()
 
-	// Resolve secrets first using the copied map
-	resolvedRegex, errRegex := resolvePlaceholders(rep.Regex, secretsCopy, true)
-	resolvedReplaceWith, errReplace := resolvePlaceholders(rep.ReplaceWith, secretsCopy, false)
+	// Copy the secrets the rule may use under lock
+	secretsCopy := m.secretsFor(rep)
 
-	// If either resolution failed, return error immediately
+	// Resolve secrets first using the copied map. Template variables like
+	// {{counter}} count up, so replace_with is only resolved once the rule is
+	// known to match; the regex sees the values it will get.
+	vars := m.applyTemplateVars()
+	regexVars := m.previewTemplateVars(true)
+	regexVars.now = vars.now
+	resolvedRegex, errRegex := resolvePlaceholders(rep.Regex, secretsCopy, regexVars, true)
 	if errRegex != nil {
 		return text, 0, fmt.Errorf("failed to resolve placeholders in regex '%s': %w", rep.Regex, errRegex)
 	}
-	if errReplace != nil {
-		return text, 0, fmt.Errorf("failed to resolve placeholders in replace_with '%s': %w", rep.ReplaceWith, errReplace)
-	}
 
 	// Compile the resolved regex pattern
-	re, compileErr := regexp.Compile(resolvedRegex)
+	re, compileErr := regexp.Compile(rep.Pattern(resolvedRegex))
 	if compileErr != nil {
 		// Log the specific error
 		log.Printf("Invalid resolved regex '%s' (from original: '%s'): %v", resolvedRegex, rep.Regex, compileErr)
@@ -538,23 +1221,51 @@ func (m *Manager) applyForwardReplacement(text string, rep config.Replacement) (
 	if matchCount == 0 {
 		return text, 0, nil // No matches, no error
 	}
+	m.captureMatch(re, text, rep) // Before replace_with, which may use the value
+
+	resolvedReplaceWith, errReplace := resolvePlaceholders(rep.ReplaceWith, secretsCopy, vars, false)
+	if errReplace != nil {
+		return text, 0, fmt.Errorf("failed to resolve placeholders in replace_with '%s': %w", rep.ReplaceWith, errReplace)
+	}
+
+	// With max_count only the first N matches are replaced
+	limited := rep.MaxCount > 0 && rep.MaxCount < matchCount
+	if limited {
+		matchCount = rep.MaxCount
+	}
 
 	// Get regex timeout from config
 	m.mu.RLock()
 	timeoutMs := m.config.GetRegexTimeout()
 	m.mu.RUnlock()
 
+	if rep.IsTransform() {
+		return m.applyAction(text, re, rep, timeoutMs)
+	}
+
 	// Apply replacement with or without case preservation using resolvedReplaceWith
 	var result string
 	var err error
-	if rep.PreserveCase {
+	if record != nil || rep.Numbered {
+		result = m.replaceRecorded(re, text, resolvedReplaceWith, rep, record)
+	} else if rep.PreserveCase {
 		// Use ReplaceAllStringFunc for case preservation (with timeout)
+		replacedMatches := 0
 		result, err = replaceAllStringFuncWithTimeout(re, text, func(match string) string {
-			return m.preserveCase(match, resolvedReplaceWith)
+			if limited && replacedMatches >= rep.MaxCount {
+				return match // Keep matches beyond max_count
+			}
+			replacedMatches++
+			return m.matchCase(rep, match, resolvedReplaceWith)
 		}, timeoutMs)
 		if err != nil {
 			return text, 0, fmt.Errorf("case-preserving replacement failed: %w", err)
 		}
+	} else if limited {
+		result, err = replaceFirstNWithTimeout(re, text, resolvedReplaceWith, rep.MaxCount, timeoutMs)
+		if err != nil {
+			return text, 0, fmt.Errorf("limited replacement failed: %w", err)
+		}
 	} else {
 		// Use standard ReplaceAllString with timeout
 		result, err = replaceAllStringWithTimeout(re, text, resolvedReplaceWith, timeoutMs)
@@ -575,16 +1286,28 @@ func (m *Manager) applyForwardReplacement(text string, rep config.Replacement) (
 // applyReverseReplacement handles reverse replacements, now resolving secrets.
 // Returns: replaced string, count, error (if secret resolution failed, source invalid, or regex invalid)
 func (m *Manager) applyReverseReplacement(text string, rep config.Replacement) (string, int, error) {
-	// Read secrets map under lock
-	m.mu.RLock()
-	secretsCopy := make(map[string]string, len(m.resolvedSecrets))
-	for k, v := range m.resolvedSecrets {
-		secretsCopy[k] = v
+	if rep.IsTransform() || rep.IsSnippet() {
+		return text, 0, nil // Actions, scripts, commands and snippets only run forward
+	}
+	if ok, err := rep.When.Holds(text); err != nil || !ok {
+		return text, 0, err // The rule's 'when' condition does not match this text
+	}
+	if rep.MatchMode == config.MatchModeLine && strings.Contains(text, "\r\n") {
+		return withLFLineBreaks(text, func(lf string) (string, int, error) { return m.applyReverseReplacement(lf, rep) })
+	}
+	if rep.IsBidirectional() {
+		return m.applyBidirectional(text, rep, true, nil)
+	}
+	if rep.Numbered {
+		return m.restoreTokens(text, rep)
 	}
-	m.mu.RUnlock()
+
+	// Copy the secrets the rule may use under lock
+	secretsCopy := m.secretsFor(rep)
 
 	// --- Resolve Target Word (from replace_with) ---
-	resolvedTargetWord, errTarget := resolvePlaceholders(rep.ReplaceWith, secretsCopy, false)
+	vars := m.previewTemplateVars(false) // Search for what the last replacement produced
+	resolvedTargetWord, errTarget := resolvePlaceholders(rep.ReplaceWith, secretsCopy, vars, false)
 	if errTarget != nil {
 		return text, 0, fmt.Errorf("failed to resolve placeholders in replace_with for reverse target '%s': %w", rep.ReplaceWith, errTarget)
 	}
@@ -598,9 +1321,10 @@ func (m *Manager) applyReverseReplacement(text string, rep config.Replacement) (
 	var errSource error
 	if rep.ReverseWith != "" {
 		// Resolve placeholders in the specified reverse replacement
-		resolvedSourceWord, errSource = resolvePlaceholders(rep.ReverseWith, secretsCopy, false) // Source word isn't regex usually
+		resolvedSourceWord, errSource = resolvePlaceholders(rep.ReverseWith, secretsCopy, vars, false) // Source word isn't regex usually
 	} else {
-		// Fall back to extracting from the original forward regex
+		// Fall back to extracting from the original forward regex (deprecated)
+		log.Printf("Deprecated: rule '%s' has no reverse_with, guessing its original text from the regex. Make it a bidirectional rule or set reverse_with.", rep.Regex)
 		rawSourceWord := m.extractFirstAlternative(rep.Regex) // Extract before resolving
 		if rawSourceWord == "" {
 			log.Printf("Warning: Could not determine raw source word for reverse replacement from regex '%s'. Trying cleaned regex.", rep.Regex)
@@ -608,7 +1332,7 @@ func (m *Manager) applyReverseReplacement(text string, rep config.Replacement) (
 			rawSourceWord = strings.Trim(rawSourceWord, "()")
 		}
 		// Now resolve placeholders in the derived raw source word
-		resolvedSourceWord, errSource = resolvePlaceholders(rawSourceWord, secretsCopy, false)
+		resolvedSourceWord, errSource = resolvePlaceholders(rawSourceWord, secretsCopy, vars, false)
 
 		// Check if source determination failed or results in empty/same word after resolution
 		if resolvedSourceWord == "" {
@@ -636,8 +1360,14 @@ func (m *Manager) applyReverseReplacement(text string, rep config.Replacement) (
 	var findRe *regexp.Regexp
 	var err error
 	searchPattern := regexp.QuoteMeta(resolvedTargetWord) // Quote meta chars in the resolved target
+	switch rep.MatchMode { // Reverse only what the forward direction could have produced
+	case config.MatchModeWord:
+		searchPattern = `\b` + searchPattern + `\b`
+	case config.MatchModeLine:
+		searchPattern = `(?m)^` + searchPattern + `$`
+	}
 
-	if rep.PreserveCase {
+	if rep.PreserveCase || rep.CaseInsensitive {
 		findRe, err = regexp.Compile(`(?i)` + searchPattern)
 	} else {
 		findRe, err = regexp.Compile(searchPattern)
@@ -658,11 +1388,22 @@ func (m *Manager) applyReverseReplacement(text string, rep config.Replacement) (
 		return text, 0, nil // No matches found, no error
 	}
 
+	// With max_count only the first N occurrences are reversed
+	limited := rep.MaxCount > 0 && rep.MaxCount < matchCount
+	if limited {
+		matchCount = rep.MaxCount
+	}
+
 	// Perform replacement using ReplaceAllStringFunc to handle case preservation using resolvedSourceWord
+	replacedMatches := 0
 	replacedText := findRe.ReplaceAllStringFunc(text, func(match string) string {
+		if limited && replacedMatches >= rep.MaxCount {
+			return match // Keep occurrences beyond max_count
+		}
+		replacedMatches++
 		if rep.PreserveCase {
 			// Apply the case pattern of the matched text (targetWord instance) to the resolvedSourceWord
-			return m.preserveCase(match, resolvedSourceWord)
+			return m.matchCase(rep, match, resolvedSourceWord)
 		}
 		// If not preserving case, just return the resolvedSourceWord directly
 		return resolvedSourceWord
@@ -676,6 +1417,18 @@ func (m *Manager) applyReverseReplacement(text string, rep config.Replacement) (
 	return replacedText, matchCount, nil
 }
 
+// withLFLineBreaks runs apply on text with Windows line breaks converted to
+// "\n", so that $ in line mode matches at the end of every line, and converts
+// the result back. The original text is returned if nothing changed.
+func withLFLineBreaks(text string, apply func(string) (string, int, error)) (string, int, error) {
+	result, count, err := apply(strings.ReplaceAll(text, "\r\n", "\n"))
+	if err != nil || count == 0 {
+		return text, 0, err
+	}
+	result = strings.ReplaceAll(result, "\r\n", "\n") // Line breaks inserted by the replacement
+	return strings.ReplaceAll(result, "\n", "\r\n"), count, nil
+}
+
 // --- Helper methods below (no changes needed) ---
 
 // isWord checks if a token is primarily a word (alphanumeric + underscore)
@@ -693,6 +1446,10 @@ func (m *Manager) isWord(token string) bool {
 }
 
 // extractFirstAlternative attempts to extract the first pattern from an alternation `(a|b|c)` in a regex.
+//
+// Deprecated: the guess is only right for plain words and alternations of
+// them. It remains the reverse of regex rules without reverse_with; use
+// bidirectional rules for exact reversal.
 func (m *Manager) extractFirstAlternative(regex string) string {
 	// Remove common flags like (?i) at the start
 	if i := strings.Index(regex, ")"); i > 0 && strings.HasPrefix(regex, "(?") {
@@ -786,8 +1543,9 @@ func (m *Manager) extractFirstAlternative(regex string) string {
 	return strings.TrimSpace(groupContent)
 }
 
-// preserveCase applies the case pattern from source to target string.
-func (m *Manager) preserveCase(source, target string) string {
+// preserveCase applies the case pattern from source to target string. It is
+// the "auto" case_strategy.
+func (m *Manager) preserveCase(source, target string, c actions.Casing) string {
 	// If source or target is empty, nothing to base case on, return target.
 	if len(source) == 0 || len(target) == 0 {
 		return target
@@ -809,7 +1567,7 @@ func (m *Manager) preserveCase(source, target string) string {
 		}
 	}
 	if hasSourceLetter && isSourceLower {
-		return strings.ToLower(target)
+		return c.Lower(target)
 	}
 
 	// 2. All Uppercase: If source is all uppercase (considering only letters).
@@ -825,7 +1583,7 @@ func (m *Manager) preserveCase(source, target string) string {
 		}
 	}
 	if hasSourceLetter && isSourceUpper {
-		return strings.ToUpper(target)
+		return c.Upper(target)
 	}
 
 	// 3. Title Case / First Letter Upper: Source starts upper, rest lower (letters only).
@@ -855,9 +1613,9 @@ func (m *Manager) preserveCase(source, target string) string {
 			}
 
 			if hasTargetLetter {
-				res := string(unicode.ToUpper(targetRunes[0]))
+				res := c.Upper(string(targetRunes[0]))
 				if len(targetRunes) > 1 {
-					res += strings.ToLower(string(targetRunes[1:]))
+					res += c.Lower(string(targetRunes[1:]))
 				}
 				return res
 			}
@@ -872,18 +1630,18 @@ func (m *Manager) preserveCase(source, target string) string {
 
 		// Only change case if the first source character is a letter
 		if unicode.IsLetter(firstSourceRune) {
-			var newFirstTargetRune rune
+			var newFirstTarget string
 			if unicode.IsUpper(firstSourceRune) {
-				newFirstTargetRune = unicode.ToUpper(firstTargetRune)
+				newFirstTarget = c.Upper(string(firstTargetRune))
 			} else { // IsLower
-				newFirstTargetRune = unicode.ToLower(firstTargetRune)
+				newFirstTarget = c.Lower(string(firstTargetRune))
 			}
 
 			// Construct the result: new first letter + rest of target
 			if len(targetRunes) > 1 {
-				return string(newFirstTargetRune) + string(targetRunes[1:])
+				return newFirstTarget + string(targetRunes[1:])
 			}
-			return string(newFirstTargetRune)
+			return newFirstTarget
 		}
 		// If first source char is not a letter, maybe don't change target case?
 		// Let's return target as is in this case.
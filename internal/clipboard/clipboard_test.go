@@ -0,0 +1,169 @@
+package clipboard
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/clipboard/backend"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+)
+
+// fakeBackend is a text-only clipboard in memory.
+type fakeBackend struct {
+	mu   sync.Mutex
+	text string
+}
+
+func (f *fakeBackend) ReadText() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.text, nil
+}
+
+func (f *fakeBackend) WriteText(text string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.text = text
+	return nil
+}
+
+func (f *fakeBackend) Formats() (backend.Contents, error) {
+	return nil, nil
+}
+
+func (f *fakeBackend) Save() (*backend.Snapshot, error) {
+	return nil, backend.ErrUnsupported
+}
+
+func (f *fakeBackend) Restore(*backend.Snapshot) error {
+	return backend.ErrUnsupported
+}
+
+func (f *fakeBackend) Name() string {
+	return "fake"
+}
+
+func (f *fakeBackend) IsAvailable() bool {
+	return true
+}
+
+func init() {
+	pastePlatform = func() {} // Tests don't press keys
+}
+
+const (
+	testHotkey      = "ctrl+alt+v"
+	testRevertDelay = 50 * time.Millisecond
+)
+
+// newTestManager returns a Manager on a fake clipboard holding text, with a
+// profile on testHotkey replacing foo by bar that reverts the clipboard
+// automatically after the paste.
+func newTestManager(t testing.TB, text string) (*Manager, *fakeBackend) {
+	t.Helper()
+	cfg := &config.Config{
+		AdminNotificationLevel: "None",
+		TemporaryClipboard:     true,
+		AutomaticReversion:     true,
+		PasteDelayMs:           1,
+		RevertDelayMs:          int(testRevertDelay / time.Millisecond),
+		Profiles: []config.ProfileConfig{{
+			Name:         "test",
+			Enabled:      true,
+			Hotkey:       testHotkey,
+			Replacements: []config.Replacement{{Regex: "foo", ReplaceWith: "bar"}},
+		}},
+	}
+	fake := &fakeBackend{text: text}
+	m := NewManager(cfg, nil, nil)
+	m.clip = fake
+	return m, fake
+}
+
+// waitForRevert waits until a pending automatic revert has run or been
+// skipped.
+func waitForRevert() {
+	time.Sleep(4 * testRevertDelay)
+}
+
+func TestAutomaticRevert(t *testing.T) {
+	m, fake := newTestManager(t, "foo")
+	if _, changed := m.ProcessClipboard(context.Background(), testHotkey, false); !changed {
+		t.Fatal("ProcessClipboard did not change the clipboard")
+	}
+	if text, _ := fake.ReadText(); text != "bar" {
+		t.Fatalf("clipboard after processing = %q, want %q", text, "bar")
+	}
+	waitForRevert()
+	if text, _ := fake.ReadText(); text != "foo" {
+		t.Errorf("clipboard after the automatic revert = %q, want %q", text, "foo")
+	}
+}
+
+func TestStaleAutomaticRevertSkipped(t *testing.T) {
+	m, fake := newTestManager(t, "foo")
+	if _, changed := m.ProcessClipboard(context.Background(), testHotkey, false); !changed {
+		t.Fatal("ProcessClipboard did not change the clipboard")
+	}
+	// Another write before the revert is due makes it stale
+	m.mu.Lock()
+	m.generation++
+	m.mu.Unlock()
+	waitForRevert()
+	if text, _ := fake.ReadText(); text != "bar" {
+		t.Errorf("clipboard after a stale revert = %q, want %q", text, "bar")
+	}
+}
+
+// TestConcurrentOperations is meant to be run with -race.
+func TestConcurrentOperations(t *testing.T) {
+	m, fake := newTestManager(t, "foo")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			_ = fake.WriteText("foo")
+			m.ProcessClipboard(context.Background(), testHotkey, false)
+		}()
+		go func() {
+			defer wg.Done()
+			m.RestoreOriginalClipboard()
+		}()
+		go func() {
+			defer wg.Done()
+			m.mu.RLock()
+			cfg := *m.config
+			m.mu.RUnlock()
+			cfg.RevertDelayMs = 1 + i%2
+			m.UpdateConfig(&cfg)
+		}()
+	}
+	wg.Wait()
+	waitForRevert()
+	if text, _ := fake.ReadText(); text != "foo" && text != "bar" {
+		t.Errorf("clipboard after concurrent operations = %q, want foo or bar", text)
+	}
+}
+
+func TestSetProfileEnabled(t *testing.T) {
+	m, _ := newTestManager(t, "")
+	m.config.Profiles[0].Group = "mode"
+	m.config.Profiles = append(m.config.Profiles, config.ProfileConfig{Name: "other", Group: "mode"})
+
+	disabled, ok := m.SetProfileEnabled("other", true)
+	if !ok || len(disabled) != 1 || disabled[0] != "test" {
+		t.Errorf("SetProfileEnabled(other, true) = %q, %v, want [test], true", disabled, ok)
+	}
+	if m.config.Profiles[0].Enabled || !m.config.Profiles[1].Enabled {
+		t.Errorf("enabled = %v, %v, want false, true", m.config.Profiles[0].Enabled, m.config.Profiles[1].Enabled)
+	}
+	if _, ok := m.SetProfileEnabled("other", false); !ok || m.config.Profiles[1].Enabled {
+		t.Error("SetProfileEnabled(other, false) did not disable the profile")
+	}
+	if _, ok := m.SetProfileEnabled("missing", true); ok {
+		t.Error("SetProfileEnabled(missing, true) found a profile")
+	}
+}
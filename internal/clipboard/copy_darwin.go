@@ -0,0 +1,29 @@
+//go:build darwin
+// +build darwin
+
+package clipboard
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"time"
+)
+
+// simulatePlatformCopy presses Cmd+C with osascript. Like pasting, it needs
+// the Accessibility permission.
+func simulatePlatformCopy(deadline time.Time) error {
+	if !accessibilityTrusted() {
+		reportPasteProblem(accessibilityProblem)
+		return fmt.Errorf("the Accessibility permission is missing")
+	}
+	output, err := exec.Command("osascript", "-e", `tell application "System Events" to keystroke "c" using command down`).CombinedOutput()
+	if err != nil {
+		log.Printf("osascript copy failed: %v\nOutput: %s", err, string(output))
+		if isAccessibilityError(output) {
+			reportPasteProblem(accessibilityProblem)
+		}
+		return fmt.Errorf("osascript failed: %w", err)
+	}
+	return nil
+}
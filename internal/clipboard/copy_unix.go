@@ -0,0 +1,13 @@
+//go:build !windows && !darwin
+// +build !windows,!darwin
+
+package clipboard
+
+import "time"
+
+// simulatePlatformCopy presses Ctrl+C on Linux with the tool picked for the
+// session (xdotool, wtype or ydotool). xdotool releases the modifiers still
+// held from the hotkey first.
+func simulatePlatformCopy(deadline time.Time) error {
+	return runInputTool("copy", func(tool inputTool) []string { return tool.copyCmd })
+}
@@ -0,0 +1,51 @@
+//go:build windows
+// +build windows
+
+package clipboard
+
+import (
+	"log"
+	"syscall"
+	"time"
+)
+
+// Windows constant for copying
+const VK_C = 0x43
+
+// heldModifiers are the virtual keys of modifiers that turn Ctrl+C into
+// another shortcut while the user still holds them after the hotkey.
+var heldModifiers = []uintptr{
+	0x10, // VK_SHIFT
+	0x12, // VK_MENU (Alt)
+	0x5B, // VK_LWIN
+	0x5C, // VK_RWIN
+}
+
+// simulatePlatformCopy presses Ctrl+C with SendInput, after waiting until
+// the user released Shift, Alt and Win or the deadline passed.
+func simulatePlatformCopy(deadline time.Time) error {
+	getAsyncKeyState := syscall.NewLazyDLL("user32.dll").NewProc("GetAsyncKeyState")
+	for time.Now().Before(deadline) {
+		held := false
+		for _, vk := range heldModifiers {
+			if state, _, _ := getAsyncKeyState.Call(vk); state&0x8000 != 0 {
+				held = true
+				break
+			}
+		}
+		if !held {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	log.Println("Simulating Ctrl+C with SendInput to copy the selection...")
+	inputs := []keyboardInput{
+		{Type: INPUT_KEYBOARD, Ki: keyBdInput{WVk: VK_LCONTROL}},
+		{Type: INPUT_KEYBOARD, Ki: keyBdInput{WVk: VK_C}},
+		{Type: INPUT_KEYBOARD, Ki: keyBdInput{WVk: VK_C, DwFlags: KEYEVENTF_KEYUP}},
+		{Type: INPUT_KEYBOARD, Ki: keyBdInput{WVk: VK_LCONTROL, DwFlags: KEYEVENTF_KEYUP}},
+	}
+	_, err := sendInputs(inputs)
+	return err
+}
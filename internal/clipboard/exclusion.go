@@ -0,0 +1,69 @@
+package clipboard
+
+import (
+	"log"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/activewindow"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+)
+
+// pressProfilesLocked returns the profiles a hotkey press may run, known
+// before the clipboard is read. For the smart hotkey, which picks its
+// profiles by the content, these are all enabled profiles with
+// content_types. The caller holds m.mu.
+func (m *Manager) pressProfilesLocked(hotkeyStr, profileName string, isReverse, copied bool) []config.ProfileConfig {
+	if m.config == nil {
+		return nil
+	}
+	smart := !isReverse && profileName == "" && m.config.SmartHotkey != "" && hotkeyStr == m.config.SmartHotkey
+	var profiles []config.ProfileConfig
+	for _, profile := range m.config.Profiles {
+		var runs bool
+		switch {
+		case profileName != "":
+			runs = profile.Name == profileName
+		case copied:
+			runs = profile.Enabled
+		case smart:
+			runs = profile.Enabled && len(profile.ContentTypes) > 0
+		default:
+			runs = profile.Enabled && ((profile.Hotkey == hotkeyStr && !isReverse) || (profile.ReverseHotkey == hotkeyStr && isReverse))
+		}
+		if runs {
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles
+}
+
+// excludingProfile returns the first of profiles whose exclude_windows
+// matches the focused window, the matching entry and the window. ok is false
+// if none does, or if the focused window can't be told, which is logged.
+func excludingProfile(profiles []config.ProfileConfig) (profile config.ProfileConfig, entry string, window activewindow.Window, ok bool) {
+	checked := false
+	for _, profile := range profiles {
+		if len(profile.ExcludeWindows) == 0 {
+			continue
+		}
+		if !checked {
+			var err error
+			if window, err = activewindow.Current(); err != nil {
+				log.Printf("Could not detect the focused window (%v); exclude_windows of profile '%s' is not applied.", err, profile.Name)
+				return profile, "", window, false
+			}
+			checked = true
+		}
+		if entry := profile.ExcludedWindow(window.Process, window.Title); entry != "" {
+			return profile, entry, window, true
+		}
+	}
+	return config.ProfileConfig{}, "", window, false
+}
+
+// describeWindow names a window for messages: its program, or its title.
+func describeWindow(window activewindow.Window) string {
+	if window.Process != "" {
+		return window.Process
+	}
+	return "'" + window.Title + "'"
+}
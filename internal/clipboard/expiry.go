@@ -0,0 +1,53 @@
+package clipboard
+
+import (
+	"log"
+	"time"
+)
+
+// armRevertExpiryLocked makes the original stored by the current operation
+// be forgotten after revert_timeout_seconds, if set. The caller holds m.mu.
+func (m *Manager) armRevertExpiryLocked() {
+	m.revertSeq++ // An expiry armed for an earlier original does nothing
+	if m.previousClipboard == "" || m.config == nil {
+		return
+	}
+	ttl := m.config.GetRevertTimeout()
+	if ttl <= 0 {
+		return
+	}
+	seq := m.revertSeq
+	time.AfterFunc(ttl, func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("RECOVERED FROM PANIC IN REVERT EXPIRY: %v", r)
+			}
+		}()
+		m.expireOriginal(seq, ttl)
+	})
+}
+
+// expireOriginal forgets the stored original and the last change and undo
+// states, which hold it as well, unless another original was stored since seq.
+func (m *Manager) expireOriginal(seq uint64, ttl time.Duration) {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
+	m.mu.Lock()
+	if m.revertSeq != seq || m.previousClipboard == "" {
+		m.mu.Unlock()
+		return
+	}
+	m.previousClipboard = ""
+	m.previousFormats = nil
+	m.lastOriginalForDiff = ""
+	m.lastModifiedForDiff = ""
+	m.undo = undoChain{}
+	m.mu.Unlock()
+
+	log.Printf("Stored original clipboard content expired after %s and was cleared.", ttl)
+	if m.onRevertStatusChange != nil {
+		m.onRevertStatusChange(false)
+	}
+	m.notifyUndoChange()
+}
@@ -0,0 +1,289 @@
+package clipboard
+
+import (
+	"maps"
+	"sort"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/diffutil"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// ExplainStep is a rule that changed the text during an explanation.
+type ExplainStep struct {
+	Profile   string `json:"profile"`
+	Rule      int    `json:"rule"` // Position in the profile, from 1; 0 for an extract profile as a whole
+	Summary   string `json:"summary"`
+	Count     int    `json:"count"`               // Matches replaced
+	Overrides []int  `json:"overrides,omitempty"` // Earlier steps of other profiles whose output this rule rewrote
+}
+
+// ExplainSpan is a piece of text and the step that changed it, or -1 for
+// text no rule touched.
+type ExplainSpan struct {
+	Text string `json:"text"`
+	Step int    `json:"step"`
+}
+
+// Explanation describes what a hotkey would do to a text, rule by rule.
+type Explanation struct {
+	Hotkey        string        `json:"hotkey"`
+	Reverse       bool          `json:"reverse"`
+	Profiles      []string      `json:"profiles"` // Profiles that ran, in order
+	Original      string        `json:"original"`
+	Result        string        `json:"result"`
+	Steps         []ExplainStep `json:"steps"`
+	OriginalSpans []ExplainSpan `json:"originalSpans"` // The original, by the step that first rewrote each part
+	ResultSpans   []ExplainSpan `json:"resultSpans"`   // The result, by the step that produced each part
+	Notes         []string      `json:"notes"`         // Profiles and rules left out, and why
+}
+
+// Conflicts counts the steps that rewrote text produced by another profile.
+func (e Explanation) Conflicts() int {
+	conflicts := 0
+	for _, step := range e.Steps {
+		if len(step.Overrides) > 0 {
+			conflicts++
+		}
+	}
+	return conflicts
+}
+
+// Explain runs the enabled profiles of hotkeyStr on text like the hotkey
+// would, without touching the clipboard, and records which rule changed which
+// part of the text. Counters and numbered placeholders don't advance, and
+// external commands and plugins are not run, as they may have side effects.
+func (m *Manager) Explain(text, hotkeyStr string, isReverse bool) Explanation {
+	m.mu.RLock()
+	cfg := m.config
+	dry := &Manager{config: cfg, resolvedSecrets: m.resolvedSecrets, counters: maps.Clone(m.counters), tokens: m.tokens.clone()}
+	plugins := m.plugins
+	m.mu.RUnlock()
+
+	explanation := Explanation{Hotkey: hotkeyStr, Reverse: isReverse, Original: text, Result: text}
+	if cfg == nil {
+		return explanation
+	}
+	spans := []attributedSpan{{text: text, step: -1, orig: 0}}
+	var consumed []originalRange
+
+	for _, profile := range cfg.ResolvedProfiles() {
+		hotkey := profile.Hotkey
+		if isReverse {
+			hotkey = profile.ReverseHotkey
+		}
+		if !profile.Enabled || hotkey != hotkeyStr {
+			continue
+		}
+		if ok, err := profile.When.Holds(explanation.Result); err != nil || !ok {
+			explanation.Notes = append(explanation.Notes, i18n.Tf("Profile '%s' is skipped: its 'when' condition does not match.", profile.Name))
+			continue
+		}
+		explanation.Profiles = append(explanation.Profiles, profile.Name)
+		if profile.RoundTrip && isReverse {
+			explanation.Notes = append(explanation.Notes, i18n.Tf("Profile '%s' restores recorded originals when possible; shown is the reversal of its rules.", profile.Name))
+		}
+
+		rules := profile.Replacements
+		if profile.Extract != "" && !isReverse {
+			rules = nil // Its rules are matched together, not applied one by one
+			extracted, count, err := dry.extract(explanation.Result, profile)
+			if err != nil {
+				explanation.Notes = append(explanation.Notes, i18n.Tf("Profile '%s' failed to extract: %v", profile.Name, err))
+			} else if count > 0 {
+				step := len(explanation.Steps)
+				spans, _, consumed = attribute(spans, explanation.Result, extracted, step, consumed)
+				explanation.Steps = append(explanation.Steps, ExplainStep{Profile: profile.Name, Summary: "extract " + profile.Extract, Count: count})
+				explanation.Result = extracted
+			}
+		}
+
+		for ruleIndex, rep := range rules {
+			if rep.Disabled {
+				continue
+			}
+			if rep.Command != nil {
+				explanation.Notes = append(explanation.Notes, i18n.Tf("Profile '%s' rule #%d (%s) runs an external command and is not run here.", profile.Name, ruleIndex+1, rep.Summary()))
+				continue
+			}
+			if rep.LLM != nil {
+				explanation.Notes = append(explanation.Notes, i18n.Tf("Profile '%s' rule #%d (%s) sends the text to the LLM endpoint and is not run here.", profile.Name, ruleIndex+1, rep.Summary()))
+				continue
+			}
+			if _, plugin := plugins[rep.Transformer]; plugin {
+				explanation.Notes = append(explanation.Notes, i18n.Tf("Profile '%s' rule #%d (%s) runs a plugin and is not run here.", profile.Name, ruleIndex+1, rep.Summary()))
+				continue
+			}
+			var replaced string
+			var count int
+			var err error
+			if isReverse {
+				replaced, count, err = dry.applyReverseReplacement(explanation.Result, rep)
+			} else {
+				replaced, count, err = dry.applyForwardReplacement(explanation.Result, rep)
+			}
+			if err != nil {
+				explanation.Notes = append(explanation.Notes, i18n.Tf("Profile '%s' rule #%d (%s) failed: %v", profile.Name, ruleIndex+1, rep.Summary(), err))
+				continue
+			}
+			replaced, _ = takeCursorMarker(replaced) // Only the paste moves the cursor
+			if replaced == explanation.Result {
+				continue
+			}
+
+			step := len(explanation.Steps)
+			var rewritten []int
+			spans, rewritten, consumed = attribute(spans, explanation.Result, replaced, step, consumed)
+			explanation.Steps = append(explanation.Steps, ExplainStep{Profile: profile.Name, Rule: ruleIndex + 1, Summary: rep.Summary(), Count: count})
+			for _, earlier := range rewritten {
+				if explanation.Steps[earlier].Profile != profile.Name {
+					explanation.Steps[step].Overrides = append(explanation.Steps[step].Overrides, earlier)
+				}
+			}
+			explanation.Result = replaced
+
+			if rep.StopAfterMatch {
+				break
+			}
+		}
+	}
+
+	explanation.ResultSpans = mergeSpans(spans)
+	explanation.OriginalSpans = originalSpans(text, consumed)
+	return explanation
+}
+
+// attributedSpan is a piece of the current text and the step that produced
+// it. Text of the original (step -1) remembers its offset there.
+type attributedSpan struct {
+	text string
+	step int
+	orig int
+}
+
+// originalRange is a part of the original that a step rewrote.
+type originalRange struct {
+	start, end int
+	step       int
+}
+
+// attribute updates the spans of input for a step that turned it into
+// output. It returns the new spans, the earlier steps whose text the step
+// rewrote and consumed extended by the parts of the original it rewrote.
+func attribute(spans []attributedSpan, input, output string, step int, consumed []originalRange) ([]attributedSpan, []int, []originalRange) {
+	diffs := diffutil.WordDiffs(input, output)
+
+	var result []attributedSpan
+	rewritten := make(map[int]bool)
+	// take removes the first n bytes from spans
+	take := func(n int) []attributedSpan {
+		var taken []attributedSpan
+		for n > 0 && len(spans) > 0 {
+			head := spans[0]
+			if len(head.text) <= n {
+				taken = append(taken, head)
+				spans = spans[1:]
+				n -= len(head.text)
+				continue
+			}
+			taken = append(taken, attributedSpan{text: head.text[:n], step: head.step, orig: head.orig})
+			spans[0] = attributedSpan{text: head.text[n:], step: head.step, orig: head.orig + n}
+			n = 0
+		}
+		return taken
+	}
+	for _, diff := range diffs {
+		switch diff.Type {
+		case diffmatchpatch.DiffEqual:
+			result = append(result, take(len(diff.Text))...)
+		case diffmatchpatch.DiffDelete:
+			for _, span := range take(len(diff.Text)) {
+				if span.step < 0 {
+					consumed = append(consumed, originalRange{start: span.orig, end: span.orig + len(span.text), step: step})
+				} else {
+					rewritten[span.step] = true
+				}
+			}
+		case diffmatchpatch.DiffInsert:
+			result = append(result, attributedSpan{text: diff.Text, step: step})
+		}
+	}
+
+	steps := make([]int, 0, len(rewritten))
+	for earlier := range rewritten {
+		steps = append(steps, earlier)
+	}
+	sort.Ints(steps)
+	return result, steps, consumed
+}
+
+// mergeSpans joins neighboring spans of the same step.
+func mergeSpans(spans []attributedSpan) []ExplainSpan {
+	merged := []ExplainSpan{}
+	for _, span := range spans {
+		if span.text == "" {
+			continue
+		}
+		if last := len(merged) - 1; last >= 0 && merged[last].Step == span.step {
+			merged[last].Text += span.text
+			continue
+		}
+		merged = append(merged, ExplainSpan{Text: span.text, Step: span.step})
+	}
+	return merged
+}
+
+// originalSpans splits the original by the steps that rewrote its parts.
+func originalSpans(original string, consumed []originalRange) []ExplainSpan {
+	sort.Slice(consumed, func(i, j int) bool { return consumed[i].start < consumed[j].start })
+	var spans []attributedSpan
+	pos := 0
+	for _, r := range consumed {
+		if r.start > pos {
+			spans = append(spans, attributedSpan{text: original[pos:r.start], step: -1})
+		}
+		spans = append(spans, attributedSpan{text: original[r.start:r.end], step: r.step})
+		pos = r.end
+	}
+	if pos < len(original) {
+		spans = append(spans, attributedSpan{text: original[pos:], step: -1})
+	}
+	return mergeSpans(spans)
+}
+
+// HotkeyProfiles is a hotkey and the enabled profiles it runs.
+type HotkeyProfiles struct {
+	Hotkey   string
+	Reverse  bool
+	Profiles []string
+}
+
+// ProfileHotkeys lists the hotkeys of enabled profiles, forward ones first,
+// for choosing what to explain.
+func ProfileHotkeys(cfg *config.Config) []HotkeyProfiles {
+	var hotkeys []HotkeyProfiles
+	type key struct {
+		hotkey  string
+		reverse bool
+	}
+	index := make(map[key]int)
+	for _, isReverse := range []bool{false, true} {
+		for _, profile := range cfg.Profiles {
+			hotkey := profile.Hotkey
+			if isReverse {
+				hotkey = profile.ReverseHotkey
+			}
+			if !profile.Enabled || hotkey == "" {
+				continue
+			}
+			if i, ok := index[key{hotkey, isReverse}]; ok {
+				hotkeys[i].Profiles = append(hotkeys[i].Profiles, profile.Name)
+				continue
+			}
+			index[key{hotkey, isReverse}] = len(hotkeys)
+			hotkeys = append(hotkeys, HotkeyProfiles{Hotkey: hotkey, Reverse: isReverse, Profiles: []string{profile.Name}})
+		}
+	}
+	return hotkeys
+}
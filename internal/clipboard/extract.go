@@ -0,0 +1,100 @@
+package clipboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+)
+
+// extract runs an extract profile on text: it collects the matches of the
+// profile's rules, each distinct one once in the order found, and returns them
+// one per line or as a JSON array, depending on profile.Extract. A rule's
+// replace_with, if set, is the template for what is collected from each
+// match, e.g. "$1" for its first group. The count is the number of matches
+// collected; with none, text is returned unchanged.
+func (m *Manager) extract(text string, profile config.ProfileConfig) (string, int, error) {
+	m.mu.RLock()
+	secretsCopy := config.ScopeSecrets(m.resolvedSecrets, profile.SecretsScope)
+	m.mu.RUnlock()
+
+	// Match lines like applyForwardReplacement, and write the result with the
+	// text's line breaks
+	lineBreak := "\n"
+	if strings.Contains(text, "\r\n") {
+		lineBreak = "\r\n"
+	}
+	lfText := strings.ReplaceAll(text, "\r\n", "\n")
+
+	var found []string
+	seen := make(map[string]bool)
+	for ruleIndex, rep := range profile.Replacements {
+		if rep.Disabled {
+			continue
+		}
+		if rep.Regex == "" || rep.IsTransform() || rep.IsBidirectional() {
+			log.Printf("Extract profile '%s': rule #%d (%s) has no regex to match and is skipped.", profile.Name, ruleIndex+1, rep.Summary())
+			continue
+		}
+		input := text
+		if rep.MatchMode == config.MatchModeLine {
+			input = lfText
+		}
+		if ok, err := rep.When.Holds(input); err != nil {
+			return text, 0, err
+		} else if !ok {
+			continue
+		}
+
+		resolvedRegex, err := resolvePlaceholders(rep.Regex, secretsCopy, m.previewTemplateVars(true), true)
+		if err != nil {
+			return text, 0, fmt.Errorf("failed to resolve placeholders in regex '%s': %w", rep.Regex, err)
+		}
+		re, err := regexp.Compile(rep.Pattern(resolvedRegex))
+		if err != nil {
+			return text, 0, fmt.Errorf("invalid compiled regex from '%s': %w", rep.Regex, err)
+		}
+		limit := -1
+		if rep.MaxCount > 0 {
+			limit = rep.MaxCount
+		}
+		matches := re.FindAllStringSubmatchIndex(input, limit)
+		if len(matches) == 0 {
+			continue
+		}
+		m.captureMatch(re, input, rep)
+
+		template := ""
+		if rep.ReplaceWith != "" {
+			if template, err = resolvePlaceholders(rep.ReplaceWith, secretsCopy, m.applyTemplateVars(), false); err != nil {
+				return text, 0, fmt.Errorf("failed to resolve placeholders in replace_with '%s': %w", rep.ReplaceWith, err)
+			}
+		}
+		for _, match := range matches {
+			value := input[match[0]:match[1]]
+			if template != "" {
+				value = string(re.ExpandString(nil, template, input, match))
+			}
+			if value == "" || seen[value] {
+				continue
+			}
+			seen[value] = true
+			found = append(found, value)
+		}
+	}
+
+	if len(found) == 0 {
+		return text, 0, nil
+	}
+	if profile.Extract == config.ExtractJSON {
+		data, err := json.MarshalIndent(found, "", "  ")
+		if err != nil {
+			return text, 0, err
+		}
+		return string(data), len(found), nil
+	}
+	return strings.Join(found, lineBreak), len(found), nil
+}
@@ -0,0 +1,68 @@
+package clipboard
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/clipboard/backend"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+)
+
+// passThrough handles a hotkey press while the clipboard holds no text, e.g.
+// a copied image. The clipboard is left alone and pasted unchanged.
+func (m *Manager) passThrough(contents backend.Contents) {
+	log.Printf("Clipboard holds no text (formats: %s). Skipping replacements and pasting it unchanged.", contents)
+
+	m.mu.Lock()
+	m.lastSkipReason = i18n.Tf("The clipboard contains %s and no text, so no rules were applied. It was pasted unchanged.", contents.Describe())
+	m.lastActionErrors = nil
+	m.lastReplacementCount = 0
+	pasteDelayMs := config.DefaultPasteDelayMs
+	if m.config != nil {
+		pasteDelayMs = m.config.GetPasteDelay()
+	}
+	m.mu.Unlock()
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("RECOVERED FROM PANIC IN PASTE GOROUTINE: %v", r)
+			}
+		}()
+		time.Sleep(time.Duration(pasteDelayMs) * time.Millisecond)
+		pastePlatform()
+	}()
+}
+
+// saveFormats copies the clipboard formats besides plain text, such as the
+// HTML of formatted text, so a revert can restore them. It returns nil if the
+// clipboard holds only text or the platform cannot restore other formats.
+func (m *Manager) saveFormats(contents backend.Contents) *backend.Snapshot {
+	if contents.TextOnly() {
+		return nil
+	}
+	snapshot, err := m.clip.Save()
+	if err != nil {
+		if !errors.Is(err, backend.ErrUnsupported) {
+			log.Printf("Failed to save the clipboard formats (%v). Reverting will restore the text only.", err)
+		}
+		return nil
+	}
+	log.Printf("Saved %d clipboard format(s) for reverting: %s", len(snapshot.Formats()), snapshot.Formats())
+	return snapshot
+}
+
+// writePrevious puts the original clipboard content back, with all its
+// formats if they were saved and plain text otherwise.
+func (m *Manager) writePrevious(text string, formats *backend.Snapshot) error {
+	if formats != nil {
+		err := m.clip.Restore(formats)
+		if err == nil {
+			return nil
+		}
+		log.Printf("Failed to restore all clipboard formats (%v). Restoring the text only.", err)
+	}
+	return m.clip.WriteText(text)
+}
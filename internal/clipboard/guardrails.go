@@ -0,0 +1,130 @@
+package clipboard
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+)
+
+// SetOversizeConfirmation sets the function that asks whether to process a
+// clipboard over max_clipboard_bytes or max_clipboard_lines, given why it is
+// too large. Without one, such clipboards are skipped.
+func (m *Manager) SetOversizeConfirmation(confirm func(reason string) bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.confirmOversize = confirm
+}
+
+// oversizeReason explains why text exceeds the limits of cfg, or returns ""
+// if it doesn't.
+func oversizeReason(cfg *config.Config, text string) string {
+	if limit := cfg.GetMaxClipboardBytes(); limit > 0 && len(text) > limit {
+		return i18n.Tf("The clipboard holds %s of text, more than the limit of %s.", formatSize(len(text)), formatSize(limit))
+	}
+	if limit := cfg.GetMaxClipboardLines(); limit > 0 {
+		if lines := strings.Count(text, "\n") + 1; lines > limit {
+			return i18n.Tf("The clipboard holds %d lines of text, more than the limit of %d.", lines, limit)
+		}
+	}
+	return ""
+}
+
+// withinLimits reports whether text may be processed: it is within the size
+// limits, or the user confirmed processing it anyway. A skipped clipboard is
+// neither transformed nor pasted.
+func (m *Manager) withinLimits(text string) bool {
+	m.mu.RLock()
+	cfg, confirm := m.config, m.confirmOversize
+	m.mu.RUnlock()
+	if cfg == nil {
+		return true
+	}
+	reason := oversizeReason(cfg, text)
+	if reason == "" {
+		return true
+	}
+
+	if cfg.GetOversizeAction() == config.OversizeAsk && confirm != nil {
+		if confirm(reason) {
+			log.Printf("Oversized clipboard (%d bytes) processed after confirmation.", len(text))
+			return true
+		}
+		log.Printf("Oversized clipboard (%d bytes) left alone: processing declined.", len(text))
+		return false
+	}
+	log.Printf("Oversized clipboard (%d bytes) skipped: %s", len(text), reason)
+	m.mu.Lock()
+	m.lastSkipReason = reason + " " + i18n.T("It was not processed or pasted.")
+	m.mu.Unlock()
+	return false
+}
+
+// binarySampleBytes is how much of the clipboard binaryReason inspects.
+const binarySampleBytes = 64 * 1024
+
+// binaryReason explains why text looks like binary data or mojibake rather
+// than text, or returns "" if it doesn't. Text counts as binary if it contains
+// a null byte, or if more than a tenth of its first 64 KiB are invalid UTF-8,
+// control characters or U+FFFD replacement characters. Tabs, line breaks and
+// the escape character of terminal colors are ordinary text.
+func binaryReason(text string) string {
+	if strings.IndexByte(text, 0) >= 0 {
+		return i18n.T("The clipboard text contains null bytes, so it is probably binary data.")
+	}
+	sample := text[:min(len(text), binarySampleBytes)]
+	runes, suspicious := 0, 0
+	for i := 0; i < len(sample); {
+		if len(sample) < len(text) && !utf8.FullRuneInString(sample[i:]) {
+			break // A character cut in half by the sample's end
+		}
+		r, size := utf8.DecodeRuneInString(sample[i:])
+		i += size
+		runes++
+		switch {
+		case r == utf8.RuneError: // Invalid UTF-8 or U+FFFD
+			suspicious++
+		case r == '\t', r == '\n', r == '\r', r == '\f', r == '\v', r == 0x1b:
+		case unicode.IsControl(r):
+			suspicious++
+		}
+	}
+	if suspicious > 0 && suspicious*10 > runes {
+		return i18n.Tf("%d of the first %d characters on the clipboard are unreadable or control characters, so it is probably binary data or garbled text.", suspicious, runes)
+	}
+	return ""
+}
+
+// isText reports whether text may be processed as text. Binary data is left
+// alone, because the rules could corrupt it, e.g. by normalizing line breaks.
+func (m *Manager) isText(text string) bool {
+	reason := binaryReason(text)
+	if reason == "" {
+		return true
+	}
+	log.Printf("Clipboard (%d bytes) skipped as binary: %s", len(text), reason)
+	m.mu.Lock()
+	m.lastSkipReason = reason + " " + i18n.T("It was not processed or pasted.")
+	m.mu.Unlock()
+	return false
+}
+
+// formatSize returns a byte count in KiB, MiB or GiB.
+func formatSize(bytes int) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	value, suffix := float64(bytes)/unit, "KiB"
+	for _, next := range []string{"MiB", "GiB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, next
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
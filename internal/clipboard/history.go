@@ -0,0 +1,62 @@
+package clipboard
+
+import (
+	"log"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/history"
+)
+
+// RestoreLastChange makes entry, a change kept in the history by an earlier
+// run, the last change again, so "View Last Change Details" shows it. If the
+// clipboard still holds its result and temporary_clipboard is on for its profiles, the change
+// can be reverted as well. It reports whether reverting is possible.
+func (m *Manager) RestoreLastChange(entry history.Entry) bool {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
+	current, err := m.clip.ReadText()
+	if err != nil {
+		log.Printf("Warning: failed to read the clipboard while restoring the last change: %v", err)
+	}
+
+	m.mu.Lock()
+	m.lastOriginalForDiff = entry.Original
+	m.lastModifiedForDiff = entry.Result
+	temporary, _ := m.config.RevertSettings(entry.Profiles)
+	canRevert := temporary && err == nil && current == entry.Result && entry.Original != ""
+	if canRevert {
+		m.previousClipboard = entry.Original
+		m.previousFormats = nil
+		m.revertManual = true // Nothing is pasted, so nothing reverts it automatically
+		m.setLastTransformedLocked(entry.Result)
+		m.armRevertExpiryLocked()
+	}
+	m.mu.Unlock()
+
+	log.Printf("Restored the last change from the history (%s). Revert available: %t", entry.Time.Format("2006-01-02 15:04:05"), canRevert)
+	if canRevert && m.onRevertStatusChange != nil {
+		m.onRevertStatusChange(true)
+	}
+	return canRevert
+}
+
+// ClearLastChange forgets the last change, its original and the undo states,
+// e.g. after the history was cleared, so none can be shown or restored anymore.
+func (m *Manager) ClearLastChange() {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
+	m.mu.Lock()
+	hadOriginal := m.previousClipboard != ""
+	m.lastOriginalForDiff = ""
+	m.lastModifiedForDiff = ""
+	m.previousClipboard = ""
+	m.previousFormats = nil
+	m.undo = undoChain{}
+	m.mu.Unlock()
+
+	if hadOriginal && m.onRevertStatusChange != nil {
+		m.onRevertStatusChange(false)
+	}
+	m.notifyUndoChange()
+}
@@ -0,0 +1,228 @@
+//go:build !windows && !darwin
+// +build !windows,!darwin
+
+package clipboard
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+)
+
+// inputTool is an external program that presses keys in the focused window.
+type inputTool struct {
+	name     string
+	pasteCmd []string                                // Presses Ctrl+V
+	copyCmd  []string                                // Presses Ctrl+C
+	typeCmd  func(text string, delayMs int) []string // Types text
+	leftCmd  func(n int) []string                    // Presses Left n times
+}
+
+var (
+	xdotoolTool = inputTool{
+		name:     "xdotool",
+		pasteCmd: []string{"xdotool", "key", "--clearmodifiers", "ctrl+v"},
+		copyCmd:  []string{"xdotool", "key", "--clearmodifiers", "ctrl+c"},
+		typeCmd: func(text string, delayMs int) []string {
+			return []string{"xdotool", "type", "--clearmodifiers", "--delay", fmt.Sprint(delayMs), "--", text}
+		},
+		leftCmd: func(n int) []string {
+			return []string{"xdotool", "key", "--clearmodifiers", "--repeat", fmt.Sprint(n), "Left"}
+		},
+	}
+	wtypeTool = inputTool{
+		name:     "wtype",
+		pasteCmd: []string{"wtype", "-M", "ctrl", "-k", "v", "-m", "ctrl"},
+		copyCmd:  []string{"wtype", "-M", "ctrl", "-k", "c", "-m", "ctrl"},
+		typeCmd: func(text string, delayMs int) []string {
+			return []string{"wtype", "-d", fmt.Sprint(delayMs), "--", text}
+		},
+		leftCmd: func(n int) []string {
+			args := []string{"wtype"}
+			for i := 0; i < n; i++ {
+				args = append(args, "-k", "Left")
+			}
+			return args
+		},
+	}
+	// ydotool works in any session through /dev/uinput, but needs its daemon.
+	// Key codes are Linux input event codes: 29 is left Ctrl, 46 is C, 47 is
+	// V, 105 is Left.
+	ydotoolTool = inputTool{
+		name:     "ydotool",
+		pasteCmd: []string{"ydotool", "key", "29:1", "47:1", "47:0", "29:0"},
+		copyCmd:  []string{"ydotool", "key", "29:1", "46:1", "46:0", "29:0"},
+		typeCmd: func(text string, delayMs int) []string {
+			return []string{"ydotool", "type", "--key-delay", fmt.Sprint(delayMs), "--", text}
+		},
+		leftCmd: func(n int) []string {
+			args := []string{"ydotool", "key"}
+			for i := 0; i < n; i++ {
+				args = append(args, "105:1", "105:0")
+			}
+			return args
+		},
+	}
+)
+
+// inputTools holds the result of detectInputTools, which runs once.
+var (
+	inputToolsOnce sync.Once
+	inputTools     []inputTool
+	inputProblem   *PasteProblem // Why no tool is usable, if none is
+)
+
+// sessionType returns "wayland", "x11" or "" if neither is detected.
+func sessionType() string {
+	if os.Getenv("WAYLAND_DISPLAY") != "" || os.Getenv("XDG_SESSION_TYPE") == "wayland" {
+		return "wayland"
+	}
+	if os.Getenv("DISPLAY") != "" || os.Getenv("XDG_SESSION_TYPE") == "x11" {
+		return "x11"
+	}
+	return ""
+}
+
+// isGNOME reports whether the desktop is GNOME, whose Wayland compositor
+// does not support the virtual keyboard protocol used by wtype.
+func isGNOME() bool {
+	return strings.Contains(strings.ToUpper(os.Getenv("XDG_CURRENT_DESKTOP")), "GNOME")
+}
+
+// ydotoolDaemonRunning looks for the socket of ydotoold in the locations
+// used by ydotool 1.x.
+func ydotoolDaemonRunning() bool {
+	candidates := []string{os.Getenv("YDOTOOL_SOCKET")}
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		candidates = append(candidates, filepath.Join(runtimeDir, ".ydotool_socket"))
+	}
+	candidates = append(candidates, "/tmp/.ydotool_socket")
+	for _, socket := range candidates {
+		if socket == "" {
+			continue
+		}
+		if info, err := os.Stat(socket); err == nil && info.Mode()&os.ModeSocket != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// installCommand returns the command installing packages with the system's
+// package manager, or a generic hint if it is unknown.
+func installCommand(packages ...string) string {
+	list := strings.Join(packages, " ")
+	for _, manager := range []struct{ binary, command string }{
+		{"apt", "sudo apt install -y "},
+		{"dnf", "sudo dnf install -y "},
+		{"pacman", "sudo pacman -S "},
+		{"zypper", "sudo zypper install "},
+	} {
+		if _, err := exec.LookPath(manager.binary); err == nil {
+			return manager.command + list
+		}
+	}
+	return "install " + list + " with your package manager"
+}
+
+// detectInputTools picks the tools that can press keys in this session, in
+// order of preference: xdotool on X11; wtype (not on GNOME), then ydotool on
+// Wayland. xdotool is the last resort on Wayland, as it only reaches XWayland
+// windows. If nothing is usable, inputProblem says what to install.
+func detectInputTools() {
+	installed := func(name string) bool {
+		_, err := exec.LookPath(name)
+		return err == nil
+	}
+	hasXdotool, hasWtype, hasYdotool := installed("xdotool"), installed("wtype"), installed("ydotool")
+	ydotoolReady := hasYdotool && ydotoolDaemonRunning()
+
+	session := sessionType()
+	switch session {
+	case "wayland":
+		if hasWtype && !isGNOME() {
+			inputTools = append(inputTools, wtypeTool)
+		}
+		if ydotoolReady {
+			inputTools = append(inputTools, ydotoolTool)
+		}
+		if hasXdotool {
+			inputTools = append(inputTools, xdotoolTool)
+		}
+	default:
+		if hasXdotool {
+			inputTools = append(inputTools, xdotoolTool)
+		}
+		if ydotoolReady {
+			inputTools = append(inputTools, ydotoolTool)
+		}
+	}
+
+	var names []string
+	for _, tool := range inputTools {
+		names = append(names, tool.name)
+	}
+	log.Printf("Paste simulation: session %q, installed: xdotool=%t wtype=%t ydotool=%t (daemon running: %t), using: %v",
+		session, hasXdotool, hasWtype, hasYdotool, ydotoolReady, names)
+
+	// A Wayland session with only xdotool works for XWayland windows, but
+	// not for native ones, so the user is still told what to install
+	if len(inputTools) > 0 && (session != "wayland" || inputTools[0].name != "xdotool") {
+		return
+	}
+
+	problem := PasteProblem{Title: "Automatic Paste Unavailable"}
+	switch {
+	case hasYdotool && !ydotoolReady:
+		problem.Message = i18n.T("ydotool is installed, but its daemon ydotoold is not running. Start it (e.g. 'systemctl --user enable --now ydotool') and restart the application.")
+	case session == "wayland" && isGNOME():
+		problem.Message = i18n.Tf("GNOME on Wayland needs ydotool to paste: run '%s', start its daemon ydotoold and restart the application.", installCommand("ydotool"))
+	case session == "wayland":
+		problem.Message = i18n.Tf("Pasting on Wayland needs wtype: run '%s' and restart the application.", installCommand("wtype"))
+	default:
+		problem.Message = i18n.Tf("Pasting on X11 needs xdotool: run '%s' and restart the application.", installCommand("xdotool"))
+	}
+	if len(inputTools) > 0 {
+		problem.Message += " " + i18n.T("Until then, pasting only works in X11 applications.")
+	} else {
+		problem.Message += " " + i18n.T("Until then, the result is only copied to the clipboard.")
+	}
+	inputProblem = &problem
+}
+
+// usableInputTools returns the detected tools and reports the detection
+// problem, if any.
+func usableInputTools() []inputTool {
+	inputToolsOnce.Do(detectInputTools)
+	if inputProblem != nil {
+		reportPasteProblem(*inputProblem)
+	}
+	return inputTools
+}
+
+// runInputTool runs the tools' commands built by args in order until one
+// succeeds.
+func runInputTool(action string, args func(inputTool) []string) error {
+	tools := usableInputTools()
+	if len(tools) == 0 {
+		return fmt.Errorf("no tool available for %s (install xdotool, wtype or ydotool)", action)
+	}
+	var failures []string
+	for _, tool := range tools {
+		cmdArgs := args(tool)
+		output, err := exec.Command(cmdArgs[0], cmdArgs[1:]...).CombinedOutput()
+		if err == nil {
+			log.Printf("Simulated %s with %s", action, tool.name)
+			return nil
+		}
+		log.Printf("Simulating %s with %s failed: %v: %s", action, tool.name, err, strings.TrimSpace(string(output)))
+		failures = append(failures, tool.name)
+	}
+	return fmt.Errorf("%s failed with %s", action, strings.Join(failures, ", "))
+}
@@ -0,0 +1,107 @@
+package clipboard
+
+import (
+	"log"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+)
+
+// chunkBytes is the approximate size of the chunks that line_safe rules
+// process large clipboards in. Chunks end at line breaks.
+const chunkBytes = 256 * 1024
+
+// slowRuleThreshold is the run time from which a rule is logged as slow,
+// whatever the clipboard size.
+const slowRuleThreshold = 500 * time.Millisecond
+
+// ruleTiming records how long one rule took.
+type ruleTiming struct {
+	profile string
+	index   int // 1-based position in the profile
+	summary string
+	elapsed time.Duration
+}
+
+// splitChunks splits text into chunks of about size bytes. Every chunk but
+// the last ends with a line break, so joining them yields text again.
+func splitChunks(text string, size int) []string {
+	var chunks []string
+	for len(text) > size {
+		end := strings.IndexByte(text[size:], '\n')
+		if end < 0 {
+			break // The rest is a single line
+		}
+		end += size + 1
+		chunks = append(chunks, text[:end])
+		text = text[end:]
+	}
+	if text == "" && len(chunks) > 0 {
+		return chunks // No empty chunk after a final line break
+	}
+	return append(chunks, text)
+}
+
+// applyChunked applies a line_safe rule to the chunks of a large text in
+// parallel and joins the results in order. The rule's 'when' condition is
+// checked once against the whole text.
+func (m *Manager) applyChunked(text string, rep config.Replacement) (string, int, error) {
+	if ok, err := rep.When.Holds(text); err != nil || !ok {
+		return text, 0, err
+	}
+	rep.When = nil
+
+	chunks := splitChunks(text, chunkBytes)
+	results := make([]string, len(chunks))
+	counts := make([]int, len(chunks))
+	errs := make([]error, len(chunks))
+
+	workers := runtime.NumCPU()
+	if workers > len(chunks) {
+		workers = len(chunks)
+	}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i], counts[i], errs[i] = m.applyForwardReplacement(chunks[i], rep)
+			}
+		}()
+	}
+	for i := range chunks {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	total := 0
+	for i := range chunks {
+		if errs[i] != nil {
+			return text, 0, errs[i]
+		}
+		total += counts[i]
+	}
+	if total == 0 {
+		return text, 0, nil
+	}
+	return strings.Join(results, ""), total, nil
+}
+
+// logRuleTimings logs the slowest rules of a run on a large clipboard.
+func logRuleTimings(timings []ruleTiming, size int, total time.Duration) {
+	sort.SliceStable(timings, func(i, j int) bool { return timings[i].elapsed > timings[j].elapsed })
+	if len(timings) > 5 {
+		timings = timings[:5]
+	}
+	log.Printf("Large clipboard (%d bytes) processed in %v. Slowest rules:", size, total.Round(time.Millisecond))
+	for _, t := range timings {
+		log.Printf("  %v  profile '%s' rule #%d (%s)", t.elapsed.Round(time.Millisecond), t.profile, t.index, t.summary)
+	}
+}
@@ -0,0 +1,70 @@
+package clipboard
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/actions"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+)
+
+// llmAction returns the transformation of an llm rule. The text is first
+// redacted by the rules of llm.redact_group, whatever the order of the
+// profile's rules, and is not sent if a secret is still in it afterwards.
+// The prompt then gets the redacted text in place of {{text}}.
+func (m *Manager) llmAction(rep config.Replacement) (actions.Action, error) {
+	m.mu.RLock()
+	cfg := m.config
+	secrets := m.resolvedSecrets
+	m.mu.RUnlock()
+	if cfg == nil || !cfg.LLMEnabled() {
+		return nil, errors.New("llm rules are off; set llm.enabled in config.json to allow sending the clipboard")
+	}
+	settings := cfg.LLM
+	redaction, ok := cfg.RuleGroups[settings.RedactGroup]
+	if !ok {
+		return nil, fmt.Errorf("llm.redact_group '%s' is not defined, nothing was sent", settings.RedactGroup)
+	}
+	var apiKey string
+	if settings.APIKeySecret != "" {
+		if apiKey = secrets[settings.APIKeySecret]; apiKey == "" {
+			return nil, fmt.Errorf("the API key secret '%s' is not available", settings.APIKeySecret)
+		}
+	}
+	model := cfg.GetLLMModel(rep.LLM)
+	send := actions.LLM(actions.LLMRequest{
+		Endpoint:  settings.Endpoint,
+		APIKey:    apiKey,
+		Model:     model,
+		System:    rep.LLM.System,
+		Timeout:   cfg.GetLLMTimeout(),
+		MaxOutput: config.DefaultCommandMaxOutputBytes,
+	})
+
+	return func(text string) (string, error) {
+		redacted := text
+		for i, redact := range redaction {
+			var err error
+			if redacted, _, err = m.applyForwardReplacement(redacted, redact); err != nil {
+				return text, fmt.Errorf("rule #%d of redact_group '%s' failed, nothing was sent: %w", i+1, settings.RedactGroup, err)
+			}
+		}
+		m.mu.RLock()
+		leaked := m.containsSecretLocked(redacted)
+		m.mu.RUnlock()
+		if leaked {
+			return text, fmt.Errorf("the text still contains a secret after redact_group '%s', nothing was sent", settings.RedactGroup)
+		}
+
+		prompt := m.applyTemplateVars().expand(rep.LLM.Prompt)
+		if strings.Contains(prompt, config.LLMTextPlaceholder) {
+			prompt = strings.ReplaceAll(prompt, config.LLMTextPlaceholder, redacted)
+		} else {
+			prompt += "\n\n" + redacted
+		}
+		log.Printf("Sending %d bytes of redacted text to %s (model %s).", len(redacted), settings.Endpoint, model)
+		return send(prompt)
+	}, nil
+}
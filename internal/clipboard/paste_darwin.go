@@ -0,0 +1,75 @@
+//go:build darwin
+// +build darwin
+
+package clipboard
+
+import (
+	"log"
+	"os/exec"
+	"strings"
+)
+
+// accessibilitySettingsURL opens Privacy & Security > Accessibility in System
+// Settings (System Preferences before macOS 13).
+const accessibilitySettingsURL = "x-apple.systempreferences:com.apple.preference.security?Privacy_Accessibility"
+
+// accessibilityProblem is reported when macOS blocks simulated key presses.
+var accessibilityProblem = PasteProblem{
+	Title: "Accessibility Permission Needed",
+	Message: "macOS blocks the automatic paste until you allow it. Open System Settings > Privacy & Security > Accessibility " +
+		"and turn on Clipboard Regex Replace (use + to add it if it isn't listed), then press the hotkey again.",
+	SettingsURL: accessibilitySettingsURL,
+}
+
+func checkPlatformPaste() {
+	if !accessibilityTrusted() {
+		reportPasteProblem(accessibilityProblem)
+	}
+}
+
+// platformPasteSupport reports CGEvent, which needs the Accessibility
+// permission.
+func platformPasteSupport() (string, *PasteProblem) {
+	if !accessibilityTrusted() {
+		return "CGEvent", &accessibilityProblem
+	}
+	return "CGEvent", nil
+}
+
+// simulatePlatformPaste presses Cmd+V by posting CGEvents. Without the
+// Accessibility permission macOS drops the events silently, so the permission
+// is checked first. osascript is the fallback for builds without cgo.
+func simulatePlatformPaste() {
+	log.Println("Attempting to paste on macOS")
+
+	if !accessibilityTrusted() {
+		log.Println("Paste simulation skipped: the Accessibility permission is missing.")
+		reportPasteProblem(accessibilityProblem)
+		return
+	}
+
+	err := postCommandV()
+	if err == nil {
+		log.Println("Paste simulation with CGEvent successful")
+		return
+	}
+	log.Printf("CGEvent paste failed: %v", err)
+
+	macScript := `tell application "System Events" to keystroke "v" using command down`
+	output, err := exec.Command("osascript", "-e", macScript).CombinedOutput()
+	if err == nil {
+		log.Println("Paste simulation with osascript successful")
+		return
+	}
+	log.Printf("osascript paste failed: %v\nOutput: %s", err, string(output))
+
+	if isAccessibilityError(output) {
+		reportPasteProblem(accessibilityProblem)
+	}
+}
+
+// isAccessibilityError reports whether osascript failed for lack of the
+// Accessibility permission (error 1002, "not allowed to send keystrokes").
+func isAccessibilityError(output []byte) bool {
+	return strings.Contains(string(output), "1002") || strings.Contains(string(output), "not allowed")
+}
@@ -1,48 +1,36 @@
-//go:build !windows
-// +build !windows
+//go:build !windows && !darwin
+// +build !windows,!darwin
 
 package clipboard
 
 import (
 	"log"
-	"os/exec"
+	"strings"
 )
 
-// simulatePlatformPaste tries to paste on Linux, macOS, etc.
-// This function provides the implementation for non-Windows builds.
-func simulatePlatformPaste() {
-	log.Println("Attempting to paste on non-Windows platform")
-
-	// Try xdotool first (Common on Linux X11)
-	cmdXdotool := exec.Command("xdotool", "key", "ctrl+v")
-	if err := cmdXdotool.Run(); err == nil {
-		log.Println("Paste simulation with xdotool successful")
-		return // Success
-	} else {
-		// Log failure, but don't return yet, try other methods
-		log.Printf("xdotool paste failed (is it installed?): %v", err)
-	}
+// checkPlatformPaste detects the paste tools at startup, so a missing tool is
+// reported before the first hotkey press.
+func checkPlatformPaste() {
+	usableInputTools()
+}
 
-	// Try wtype (Common on Linux Wayland)
-	cmdWtype := exec.Command("wtype", "-M", "ctrl", "-P", "v", "-m", "ctrl")
-	if err := cmdWtype.Run(); err == nil {
-		log.Println("Paste simulation with wtype successful")
-		return // Success
-	} else {
-		log.Printf("wtype paste failed (is it installed?): %v", err)
+// platformPasteSupport reports the detected paste tools and, if none or only
+// xdotool on Wayland is usable, what to install.
+func platformPasteSupport() (string, *PasteProblem) {
+	inputToolsOnce.Do(detectInputTools)
+	var names []string
+	for _, tool := range inputTools {
+		names = append(names, tool.name)
 	}
+	return strings.Join(names, ", "), inputProblem
+}
 
-	// Try osascript (macOS)
-	macScript := `tell application "System Events" to keystroke "v" using command down`
-	cmdOsascript := exec.Command("osascript", "-e", macScript)
-	// Use Output or CombinedOutput to potentially capture errors reported by osascript itself
-	if output, err := cmdOsascript.CombinedOutput(); err == nil {
-		log.Println("Paste simulation with osascript successful")
-		return // Success
-	} else {
-		log.Printf("osascript paste failed: %v\nOutput: %s", err, string(output))
+// simulatePlatformPaste presses Ctrl+V on Linux and other Unix systems with
+// the tool picked for the session (xdotool, wtype or ydotool).
+// macOS is handled in paste_darwin.go.
+func simulatePlatformPaste() {
+	log.Println("Attempting to paste on Linux/Unix platform")
+	if err := runInputTool("paste", func(tool inputTool) []string { return tool.pasteCmd }); err != nil {
+		log.Printf("Paste simulation failed: %v. The result is on the clipboard.", err)
 	}
-
-	// If all methods failed
-	log.Println("All non-Windows paste simulation methods failed. Automatic paste might not be supported or require specific tools (xdotool, wtype, osascript).")
-}
\ No newline at end of file
+}
@@ -184,6 +184,14 @@ Add-Type -AssemblyName System.Windows.Forms
 	return true
 }
 
+// checkPlatformPaste has nothing to check; SendInput needs no permission.
+func checkPlatformPaste() {}
+
+// platformPasteSupport reports SendInput, which is always available.
+func platformPasteSupport() (string, *PasteProblem) {
+	return "SendInput", nil
+}
+
 // simulatePlatformPaste tries multiple methods to simulate Ctrl+V in Windows.
 // This function provides the implementation for Windows builds.
 func simulatePlatformPaste() {
@@ -0,0 +1,59 @@
+package clipboard
+
+import (
+	"log"
+	"sync"
+)
+
+// PasteProblem describes why pasting or typing cannot work on this system in
+// a way the user can fix, such as a missing permission or tool.
+type PasteProblem struct {
+	Title       string
+	Message     string
+	SettingsURL string // Opens the system settings that fix the problem, if any
+}
+
+var (
+	pasteProblemMu       sync.Mutex
+	pasteProblemHandler  func(PasteProblem)
+	reportedPasteProblem = make(map[string]bool) // Titles already reported
+)
+
+// SetPasteProblemHandler sets the function that tells the user about paste
+// problems. It is called at most once per problem and process.
+func SetPasteProblemHandler(handler func(PasteProblem)) {
+	pasteProblemMu.Lock()
+	defer pasteProblemMu.Unlock()
+	pasteProblemHandler = handler
+}
+
+// reportPasteProblem logs the problem and passes it to the handler, unless it
+// was reported before.
+func reportPasteProblem(problem PasteProblem) {
+	pasteProblemMu.Lock()
+	handler := pasteProblemHandler
+	alreadyReported := reportedPasteProblem[problem.Title]
+	reportedPasteProblem[problem.Title] = true
+	pasteProblemMu.Unlock()
+
+	log.Printf("Paste problem: %s: %s", problem.Title, problem.Message)
+	if alreadyReported || handler == nil {
+		return
+	}
+	handler(problem)
+}
+
+// PasteSupport returns how the automatic paste presses Ctrl+V or Cmd+V on
+// this system, such as "SendInput" or the names of the Linux tools, and the
+// problem keeping it from working, if any. Unlike CheckPasteSupport, it does
+// not report the problem. method is empty if nothing can paste.
+func PasteSupport() (method string, problem *PasteProblem) {
+	return platformPasteSupport()
+}
+
+// CheckPasteSupport looks for problems that keep the automatic paste from
+// working, like a missing permission, and reports them through the handler
+// set with SetPasteProblemHandler. Call it once at startup.
+func CheckPasteSupport() {
+	checkPlatformPaste()
+}
@@ -0,0 +1,127 @@
+package clipboard
+
+import (
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+)
+
+// planMinBytes is the clipboard size from which the rules triggered by a
+// hotkey are scanned for matches in parallel before they are applied.
+const planMinBytes = 64 * 1024
+
+// matchPlanner scans the rules of all profiles triggered by a hotkey for
+// matches in parallel, in waves of one rule per CPU, so rules that cannot
+// match are skipped without running them. Rules are still applied one after
+// another in order, and a wave is only trusted for the exact text it scanned:
+// once a rule changes the text, the next rule starts a new wave. The result is
+// therefore the same as applying every rule.
+type matchPlanner struct {
+	m       *Manager
+	rules   []config.Replacement // Forward rules of the triggered profiles, in order
+	offset  map[int]int          // Profile index -> position of its first rule in rules
+	workers int
+
+	text       string // The text the current wave scanned
+	start, end int    // The current wave covers rules[start:end]
+	noMatch    []bool // Rules known not to match text
+
+	skipped, waves int // For logging
+}
+
+// newMatchPlanner prepares a planner for the rules of the profiles that
+// triggered reports true for. It returns nil if planning isn't worthwhile.
+func (m *Manager) newMatchPlanner(profiles []config.ProfileConfig, triggered func(config.ProfileConfig) bool, textLen int) *matchPlanner {
+	workers := runtime.NumCPU()
+	if textLen < planMinBytes || workers < 2 {
+		return nil
+	}
+	p := &matchPlanner{m: m, offset: make(map[int]int), workers: workers, start: -1}
+	for i, profile := range profiles {
+		if triggered(profile) {
+			p.offset[i] = len(p.rules)
+			p.rules = append(p.rules, profile.Replacements...)
+		}
+	}
+	if len(p.rules) < 2 {
+		return nil
+	}
+	p.noMatch = make([]bool, len(p.rules))
+	return p
+}
+
+// skip reports whether rule ruleIndex of profile profileIndex cannot match
+// text, scanning a new wave of rules if needed. A nil planner skips nothing.
+func (p *matchPlanner) skip(profileIndex, ruleIndex int, text string) bool {
+	if p == nil {
+		return false
+	}
+	i := p.offset[profileIndex] + ruleIndex
+	if i < p.start || i >= p.end || p.text != text {
+		p.scan(i, text)
+	}
+	if p.noMatch[i] {
+		p.skipped++
+		return true
+	}
+	return false
+}
+
+// scan checks the rules of a wave starting at rule from in parallel.
+func (p *matchPlanner) scan(from int, text string) {
+	p.waves++
+	p.text, p.start, p.end = text, from, from+p.workers
+	if p.end > len(p.rules) {
+		p.end = len(p.rules)
+	}
+	var wg sync.WaitGroup
+	for i := p.start; i < p.end; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			p.noMatch[i] = !p.m.mayMatch(p.rules[i], text)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// mayMatch reports whether a forward rule may change text. It is
+// conservative: rules that can't be checked without running them, such as
+// whole-text actions or regexes with template variables, which resolve
+// differently from one moment to the next, always may.
+func (m *Manager) mayMatch(rep config.Replacement, text string) bool {
+	if rep.IsTransform() && rep.Regex == "" {
+		return true
+	}
+	if templateVariableRegex.MatchString(rep.Regex) {
+		return true
+	}
+	if ok, err := rep.When.Holds(text); err != nil {
+		return true // The error is reported when the rule is applied
+	} else if !ok {
+		return false
+	}
+
+	var re *regexp.Regexp
+	if rep.IsBidirectional() {
+		var err error
+		if re, _, err = m.bidirectionalMatcher(rep, false); err != nil {
+			return true
+		}
+	} else {
+		resolvedRegex, err := resolvePlaceholders(rep.Regex, m.secretsFor(rep), nil, true)
+		if err != nil {
+			return true
+		}
+		if re, err = regexp.Compile(rep.Pattern(resolvedRegex)); err != nil {
+			return true
+		}
+	}
+	if rep.MatchMode == config.MatchModeLine && strings.Contains(text, "\r\n") {
+		text = strings.ReplaceAll(text, "\r\n", "\n") // Like applyForwardReplacement
+	}
+	return re.MatchString(text)
+}
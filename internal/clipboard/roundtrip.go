@@ -0,0 +1,175 @@
+package clipboard
+
+import (
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+)
+
+// maxRoundTripPasses is how many forward passes of round-trip profiles are
+// kept. Older ones are dropped, so their placeholders are no longer restored.
+const maxRoundTripPasses = 20
+
+// roundTripReplacement is one match a rule replaced, and what it became.
+type roundTripReplacement struct {
+	original    string
+	replacement string
+}
+
+// roundTripPass records one forward pass of a round-trip profile: the text
+// before and after its rules, and the matches each rule replaced.
+type roundTripPass struct {
+	profile string
+	input   string
+	output  string
+	rules   [][]roundTripReplacement // Per rule that changed the text, in order
+	lossy   bool                     // An action, script or command rewrote the text; it is only restored from an unchanged output
+}
+
+// count returns the number of recorded replacements, at least 1.
+func (p *roundTripPass) count() int {
+	n := 0
+	for _, rule := range p.rules {
+		n += len(rule)
+	}
+	if n == 0 {
+		return 1
+	}
+	return n
+}
+
+// replaceRecorded replaces the matches of re like ReplaceAllString, or with
+// preserve_case like applyForwardReplacement, up to max_count. Numbered rules
+// replace each distinct match with its own numbered placeholder. record, if
+// not nil, is called with every match and its replacement.
+func (m *Manager) replaceRecorded(re *regexp.Regexp, text, template string, rep config.Replacement, record func(original, replacement string)) string {
+	var b strings.Builder
+	last := 0
+	for i, loc := range re.FindAllStringSubmatchIndex(text, -1) {
+		if rep.MaxCount > 0 && i >= rep.MaxCount {
+			break
+		}
+		match := text[loc[0]:loc[1]]
+		var replacement string
+		if rep.PreserveCase {
+			replacement = m.matchCase(rep, match, template)
+		} else {
+			replacement = string(re.ExpandString(nil, template, text, loc))
+		}
+		if rep.Numbered {
+			replacement = m.tokenFor(rep, replacement, match)
+		}
+		b.WriteString(text[last:loc[0]])
+		b.WriteString(replacement)
+		last = loc[1]
+		if match != replacement && record != nil {
+			record(match, replacement)
+		}
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// recordRoundTrip keeps a forward pass of a round-trip profile in memory.
+func (m *Manager) recordRoundTrip(pass roundTripPass) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.roundTrips = append(m.roundTrips, pass)
+	if len(m.roundTrips) > maxRoundTripPasses {
+		m.roundTrips = m.roundTrips[len(m.roundTrips)-maxRoundTripPasses:]
+	}
+	log.Printf("Round trip: recorded %d replacement(s) of profile '%s' (%d pass(es) kept).", pass.count(), pass.profile, len(m.roundTrips))
+}
+
+// restoreRoundTrip restores text from the recorded passes of a profile. Text
+// that is exactly the output of a pass gets that pass's input back. Otherwise,
+// e.g. after an LLM answered with parts of it, every recorded replacement
+// found in the text is turned back into its original, newest pass and last
+// rule first. ok is false if nothing was restored, so the rules are reversed
+// instead.
+func (m *Manager) restoreRoundTrip(profile, text string) (restored string, count int, ok bool) {
+	m.mu.RLock()
+	var passes []roundTripPass
+	for i := len(m.roundTrips) - 1; i >= 0; i-- { // Newest first
+		if m.roundTrips[i].profile == profile {
+			passes = append(passes, m.roundTrips[i])
+		}
+	}
+	m.mu.RUnlock()
+
+	for _, pass := range passes {
+		if pass.output == text {
+			log.Printf("Round trip: the clipboard is the unchanged output of profile '%s'. Restoring its input exactly.", profile)
+			return pass.input, pass.count(), true
+		}
+	}
+
+	restored = text
+	for _, pass := range passes {
+		if pass.lossy {
+			log.Printf("Round trip: a pass of profile '%s' ran an action, script or command, which is only undone for its unchanged output.", profile)
+		}
+		for i := len(pass.rules) - 1; i >= 0; i-- {
+			var n int
+			restored, n = undoReplacements(restored, pass.rules[i])
+			count += n
+		}
+	}
+	return restored, count, count > 0
+}
+
+// undoReplacements turns the replacements of one rule found in text back
+// into their originals. A replacement that stood for several originals, like
+// a generic "[EMAIL]", is restored in the order the originals were replaced.
+func undoReplacements(text string, replaced []roundTripReplacement) (string, int) {
+	originals := make(map[string][]string)
+	var found []string
+	for _, r := range replaced {
+		if r.replacement == "" {
+			continue // Deleted text can't be found again
+		}
+		if _, seen := originals[r.replacement]; !seen {
+			found = append(found, r.replacement)
+		}
+		originals[r.replacement] = append(originals[r.replacement], r.original)
+	}
+	if len(found) == 0 {
+		return text, 0
+	}
+
+	sort.SliceStable(found, func(i, j int) bool { return len(found[i]) > len(found[j]) })
+	quoted := make([]string, len(found))
+	for i, f := range found {
+		quoted[i] = regexp.QuoteMeta(f)
+	}
+	re := regexp.MustCompile(strings.Join(quoted, "|")) // Quoted literals always compile
+
+	used := make(map[string]int)
+	count := 0
+	result := re.ReplaceAllStringFunc(text, func(match string) string {
+		list := originals[match]
+		i := used[match]
+		used[match]++
+		if i >= len(list) {
+			if i == len(list) && !allEqual(list) {
+				log.Printf("Round trip: '%s' occurs more often than it was inserted and stood for different texts; restoring the extra occurrences as the last one.", match)
+			}
+			i = len(list) - 1
+		}
+		count++
+		return list[i]
+	})
+	return result, count
+}
+
+func allEqual(list []string) bool {
+	for _, s := range list[1:] {
+		if s != list[0] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package clipboard
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+)
+
+// RuleTestResult is the outcome of one test case of a rule.
+type RuleTestResult struct {
+	Profile string
+	Rule    int // 1-based position in the profile
+	Regex   string
+	Case    int // 1-based position in the rule's tests
+	Input   string
+	Expect  string
+	Got     string
+	Err     error // Set if the rule could not be applied
+}
+
+// Passed reports whether the rule turned Input into Expect.
+func (r RuleTestResult) Passed() bool {
+	return r.Err == nil && r.Got == r.Expect
+}
+
+// Skipped reports whether the test could not run because a secret used by
+// the rule is not loaded, e.g. on the command line.
+func (r RuleTestResult) Skipped() bool {
+	return errors.Is(r.Err, ErrSecretNotFound)
+}
+
+func (r RuleTestResult) String() string {
+	prefix := fmt.Sprintf("profile '%s' rule #%d ('%s') test #%d", r.Profile, r.Rule, r.Regex, r.Case)
+	switch {
+	case r.Skipped():
+		return fmt.Sprintf("%s: skipped, %v", prefix, r.Err)
+	case r.Err != nil:
+		return fmt.Sprintf("%s: error: %v", prefix, r.Err)
+	case r.Passed():
+		return fmt.Sprintf("%s: passed", prefix)
+	}
+	return fmt.Sprintf("%s: input %q, expected %q, got %q", prefix, r.Input, r.Expect, r.Got)
+}
+
+// RunRuleTests applies every rule to its test inputs with the same code as a
+// hotkey-triggered replacement, including secrets, preserve_case and the
+// regex timeout, and returns one result per test case. Rules are tested on
+// their own, in all profiles whether enabled or not. {{counter}} variables
+// start at 1 for the tests and keep their session values afterwards. As a
+// rule is tested alone, {{var:name}} is empty unless the rule captures it.
+func (m *Manager) RunRuleTests(profiles []config.ProfileConfig) []RuleTestResult {
+	m.opMu.Lock() // Processing must not count up the swapped-out counters
+	defer m.opMu.Unlock()
+
+	m.mu.Lock()
+	sessionCounters := m.counters
+	m.counters = nil
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		m.counters = sessionCounters
+		m.mu.Unlock()
+	}()
+
+	var results []RuleTestResult
+	for _, profile := range profiles {
+		for i, rep := range profile.Replacements {
+			for j, test := range rep.Tests {
+				m.mu.Lock()
+				m.captures = nil
+				m.mu.Unlock()
+				got, _, err := m.applyForwardReplacement(test.Input, rep)
+				got, _ = takeCursorMarker(got) // Expectations leave out {{cursor}}
+				results = append(results, RuleTestResult{
+					Profile: profile.Name,
+					Rule:    i + 1,
+					Regex:   rep.Summary(),
+					Case:    j + 1,
+					Input:   test.Input,
+					Expect:  test.Expect,
+					Got:     got,
+					Err:     err,
+				})
+			}
+		}
+	}
+	return results
+}
@@ -0,0 +1,101 @@
+package clipboard
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/clipboard/backend"
+)
+
+// selectionPlaceholder is put on the clipboard before copying the selection
+// where the backend doesn't count clipboard changes, so the copy can be told
+// apart from what was on the clipboard before. It is a noncharacter, like
+// cursorMarker, which copied text doesn't contain.
+const selectionPlaceholder = "\uFDD1"
+
+// selectionPollInterval is how often the clipboard is checked for the copy.
+const selectionPollInterval = 20 * time.Millisecond
+
+// errNothingCopied is returned by copySelection if the clipboard didn't
+// change in time, usually because nothing was selected.
+var errNothingCopied = errors.New("nothing was copied")
+
+// copiesSelection reports whether the profiles run by this hotkey press
+// include a copy_selection profile, and how long to wait for the copy. For
+// the smart hotkey, which picks its profiles by the content it hasn't seen
+// yet, any enabled profile with content_types counts. Profiles run by name,
+// from the tray or "clipregex trigger", don't copy: the focused window is
+// the menu or terminal, where Ctrl+C means something else.
+func (m *Manager) copiesSelection(hotkeyStr, profileName string, isReverse bool) (time.Duration, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if profileName != "" {
+		return 0, false
+	}
+	for _, profile := range m.pressProfilesLocked(hotkeyStr, profileName, isReverse, false) {
+		if profile.CopySelection {
+			return time.Duration(m.config.GetCopyTimeout()) * time.Millisecond, true
+		}
+	}
+	return 0, false
+}
+
+// copySelection presses Ctrl+C (Cmd+C on macOS) and waits up to timeout for
+// the selection to reach the clipboard. If nothing arrives, or ctx is
+// canceled first, the clipboard is left as it was.
+func (m *Manager) copySelection(ctx context.Context, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	before, counted := backend.ChangeCount(m.clip)
+
+	var previous string
+	var previousFormats *backend.Snapshot
+	if !counted {
+		contents, err := m.clip.Formats()
+		if err != nil {
+			contents = nil
+		}
+		if previous, err = m.clip.ReadText(); err != nil && contents.HasText() {
+			return err // Not replaced with the placeholder, which would lose it
+		}
+		previousFormats = m.saveFormats(contents)
+		if err := m.clip.WriteText(selectionPlaceholder); err != nil {
+			return err
+		}
+	}
+	restore := func() {
+		if counted {
+			return
+		}
+		if err := m.writePrevious(previous, previousFormats); err != nil {
+			log.Printf("Failed to restore the clipboard after copying nothing: %v", err)
+		}
+	}
+
+	if err := simulatePlatformCopy(deadline); err != nil {
+		restore()
+		return err
+	}
+	ticker := time.NewTicker(selectionPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			restore()
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		if counted {
+			if count, ok := backend.ChangeCount(m.clip); ok && count != before {
+				return nil
+			}
+		} else if text, err := m.clip.ReadText(); err != nil || text != selectionPlaceholder {
+			return nil // Something else is on the clipboard now, if not text
+		}
+		if time.Now().After(deadline) {
+			restore()
+			return errNothingCopied
+		}
+	}
+}
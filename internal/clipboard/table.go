@@ -0,0 +1,126 @@
+package clipboard
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/table"
+)
+
+// TableTransformer is the name of the built-in transformer that converts
+// tables between CSV, TSV, Markdown and HTML, see package table. Its options:
+//
+//	to         csv, tsv, markdown or html
+//	from       the same, or empty to detect the format
+//	delimiter  of CSV: , or ; (default: detected when reading, the source's or , when writing)
+//	header     false if the first row of a CSV or TSV table holds data, not column names
+//	align      auto (default), left, right, center or none
+//	pad        false to not pad Markdown cells to the column width
+const TableTransformer = "convert_table"
+
+func init() {
+	RegisterTransformer(convertTable{})
+}
+
+// convertTable is the convert_table transformer.
+type convertTable struct{}
+
+func (convertTable) Name() string {
+	return TableTransformer
+}
+
+func (convertTable) Transform(text string, options map[string]string) (string, error) {
+	option := func(name string) string {
+		return strings.ToLower(strings.TrimSpace(options[name]))
+	}
+	boolOption := func(name string, fallback bool) (bool, error) {
+		if option(name) == "" {
+			return fallback, nil
+		}
+		value, err := strconv.ParseBool(option(name))
+		if err != nil {
+			return false, fmt.Errorf("invalid %s '%s' (use true or false)", name, options[name])
+		}
+		return value, nil
+	}
+
+	to, from := option("to"), option("from")
+	if to == "" {
+		return text, fmt.Errorf("convert_table needs the option to (%s)", strings.Join(table.Formats(), ", "))
+	}
+	for _, format := range []string{to, from} {
+		if format != "" && !slices.Contains(table.Formats(), format) {
+			return text, fmt.Errorf("unknown table format '%s' (use %s)", format, strings.Join(table.Formats(), ", "))
+		}
+	}
+	var comma rune
+	switch delimiter := option("delimiter"); delimiter {
+	case "":
+	case ",", ";":
+		comma, _ = utf8.DecodeRuneInString(delimiter)
+	default:
+		return text, fmt.Errorf("invalid delimiter '%s' (use , or ;)", options["delimiter"])
+	}
+	header, err := boolOption("header", true)
+	if err != nil {
+		return text, err
+	}
+	pad, err := boolOption("pad", true)
+	if err != nil {
+		return text, err
+	}
+
+	t, format, err := table.Parse(text, from, comma)
+	if err != nil {
+		if errors.Is(err, table.ErrNoTable) {
+			return text, fmt.Errorf("the text is not a %s table", orTableFormats(from))
+		}
+		return text, fmt.Errorf("could not read the %s table: %w", format, err)
+	}
+	if format == table.CSV || format == table.TSV {
+		t.Header = header
+	}
+	switch align := option("align"); align {
+	case "", "auto":
+		t.AutoAlign()
+	case "left":
+		t.SetAlign(table.AlignLeft)
+	case "right":
+		t.SetAlign(table.AlignRight)
+	case "center":
+		t.SetAlign(table.AlignCenter)
+	case "none":
+		t.SetAlign(table.AlignNone)
+	default:
+		return text, fmt.Errorf("invalid align '%s' (use auto, left, right, center or none)", options["align"])
+	}
+
+	opts := table.Options{Comma: comma, Pad: pad, LineBreak: "\n"}
+	if opts.Comma == 0 {
+		opts.Comma = t.Comma // Keeps the delimiter of a CSV source
+	}
+	if strings.Contains(text, "\r\n") {
+		opts.LineBreak = "\r\n"
+	}
+	result, err := t.Write(to, opts)
+	if err != nil {
+		return text, err
+	}
+	if strings.HasSuffix(text, "\n") {
+		result += opts.LineBreak
+	}
+	return result, nil
+}
+
+// orTableFormats names format, or all formats if it is empty.
+func orTableFormats(format string) string {
+	if format != "" {
+		return format
+	}
+	formats := table.Formats()
+	return strings.Join(formats[:len(formats)-1], ", ") + " or " + formats[len(formats)-1]
+}
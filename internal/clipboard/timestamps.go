@@ -0,0 +1,107 @@
+package clipboard
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // IANA zone names also on Windows, which has no zoneinfo
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/actions"
+)
+
+// TimestampsTransformer is the name of the built-in transformer that rewrites
+// the timestamps of copied logs into one format, see
+// actions.NormalizeTimestamps. Its options:
+//
+//	format          a preset of actions.TimestampLayouts, unix, unix_ms or a Go layout (default rfc3339_ms)
+//	relative        first or previous, to write offsets instead of timestamps
+//	timezone        zone of the output: local, utc or an IANA name (default: each timestamp's own)
+//	input_timezone  zone of timestamps without one (default local)
+//	epochs          true to also rewrite Unix timestamps
+const TimestampsTransformer = "normalize_timestamps"
+
+func init() {
+	RegisterTransformer(normalizeTimestamps{})
+}
+
+// normalizeTimestamps is the normalize_timestamps transformer.
+type normalizeTimestamps struct{}
+
+func (normalizeTimestamps) Name() string {
+	return TimestampsTransformer
+}
+
+func (normalizeTimestamps) Transform(text string, options map[string]string) (string, error) {
+	opts, err := timestampOptionsOf(options)
+	if err != nil {
+		return text, err
+	}
+	result, _ := actions.NormalizeTimestamps(text, opts)
+	return result, nil
+}
+
+// timestampOptionsOf returns the settings of a normalize_timestamps rule.
+func timestampOptionsOf(options map[string]string) (actions.TimestampOptions, error) {
+	var opts actions.TimestampOptions
+	option := func(name string) string {
+		return strings.TrimSpace(options[name])
+	}
+
+	format := option("format")
+	switch {
+	case format == "":
+		opts.Layout = actions.TimestampLayouts["rfc3339_ms"]
+	case actions.TimestampLayouts[strings.ToLower(format)] != "":
+		opts.Layout = actions.TimestampLayouts[strings.ToLower(format)]
+	case strings.EqualFold(format, actions.TimestampUnix), strings.EqualFold(format, actions.TimestampUnixMs):
+		opts.Layout = strings.ToLower(format)
+	case time.Unix(0, 0).Format(format) == format:
+		presets := []string{actions.TimestampUnix, actions.TimestampUnixMs}
+		for name := range actions.TimestampLayouts {
+			presets = append(presets, name)
+		}
+		sort.Strings(presets)
+		return opts, fmt.Errorf("invalid format '%s' (use %s or a Go layout like 2006-01-02 15:04:05)", format, strings.Join(presets, ", "))
+	default:
+		opts.Layout = format
+	}
+
+	switch relative := strings.ToLower(option("relative")); relative {
+	case "", actions.RelativeToFirst, actions.RelativeToPrevious:
+		opts.Relative = relative
+	default:
+		return opts, fmt.Errorf("invalid relative '%s' (use %s or %s)", option("relative"), actions.RelativeToFirst, actions.RelativeToPrevious)
+	}
+
+	var err error
+	if zone := option("timezone"); zone != "" {
+		if opts.Zone, err = loadZone(zone); err != nil {
+			return opts, fmt.Errorf("invalid timezone: %w", err)
+		}
+	}
+	if zone := option("input_timezone"); zone != "" {
+		if opts.Assume, err = loadZone(zone); err != nil {
+			return opts, fmt.Errorf("invalid input_timezone: %w", err)
+		}
+	}
+	if epochs := option("epochs"); epochs != "" {
+		if opts.Epochs, err = strconv.ParseBool(epochs); err != nil {
+			return opts, fmt.Errorf("invalid epochs '%s' (use true or false)", epochs)
+		}
+	}
+	return opts, nil
+}
+
+// loadZone returns the zone named local, utc or by its IANA name, such as
+// Europe/Berlin.
+func loadZone(name string) (*time.Location, error) {
+	switch strings.ToLower(name) {
+	case "local":
+		return time.Local, nil
+	case "utc":
+		return time.UTC, nil
+	}
+	return time.LoadLocation(name)
+}
@@ -0,0 +1,93 @@
+package clipboard
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/tokenizer"
+)
+
+// TokenBudgetTransformer is the name of the built-in transformer that fits
+// the text to a language model's token budget, see tokenizer.Fit. Its
+// options:
+//
+//	model           a preset of tokenizer.Models, whose context window is the budget
+//	max_tokens      the budget, instead of the model's
+//	reserve_tokens  tokens left free for the rest of the prompt and the answer
+//	strategy        head (default), tail, ends or compact
+//	marker          put in place of the trimmed text, {tokens} is their number
+const TokenBudgetTransformer = "token_budget"
+
+func init() {
+	RegisterTransformer(tokenBudget{})
+}
+
+// tokenBudget is the token_budget transformer.
+type tokenBudget struct{}
+
+func (tokenBudget) Name() string {
+	return TokenBudgetTransformer
+}
+
+func (t tokenBudget) Transform(text string, options map[string]string) (string, error) {
+	result, _, err := t.TransformAndReport(text, options)
+	return result, err
+}
+
+// TransformAndReport fits text to the budget and reports how much it trimmed.
+func (tokenBudget) TransformAndReport(text string, options map[string]string) (string, string, error) {
+	budget, err := tokenBudgetOf(options)
+	if err != nil {
+		return text, "", err
+	}
+	fitted, err := tokenizer.Fit(text, budget, strings.ToLower(strings.TrimSpace(options["strategy"])), options["marker"])
+	if err != nil {
+		return text, "", err
+	}
+	if fitted.Text == text {
+		return text, "", nil
+	}
+	report := i18n.Tf("Trimmed about %d of %d tokens (%d%%) to fit the budget of %d tokens.",
+		fitted.Trimmed, fitted.Original, fitted.Trimmed*100/fitted.Original, budget)
+	return fitted.Text, report, nil
+}
+
+// tokenBudgetOf returns the budget set by the options of a token_budget rule.
+func tokenBudgetOf(options map[string]string) (int, error) {
+	intOption := func(name string) (int, bool, error) {
+		value := strings.TrimSpace(options[name])
+		if value == "" {
+			return 0, false, nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return 0, false, fmt.Errorf("invalid %s '%s' (must be a whole number of tokens)", name, value)
+		}
+		return n, true, nil
+	}
+
+	budget, explicit, err := intOption("max_tokens")
+	if err != nil {
+		return 0, err
+	}
+	if !explicit {
+		model := strings.ToLower(strings.TrimSpace(options["model"]))
+		if model == "" {
+			return 0, fmt.Errorf("token_budget needs the option model or max_tokens")
+		}
+		var ok bool
+		if budget, ok = tokenizer.ModelBudget(model); !ok {
+			return 0, fmt.Errorf("unknown model '%s' (known: %s)", options["model"], strings.Join(tokenizer.Models(), ", "))
+		}
+	}
+	reserve, _, err := intOption("reserve_tokens")
+	if err != nil {
+		return 0, err
+	}
+	if budget-reserve < 1 {
+		return 0, fmt.Errorf("reserve_tokens %d leaves no room in the budget of %d tokens", reserve, budget)
+	}
+	return budget - reserve, nil
+}
@@ -0,0 +1,132 @@
+package clipboard
+
+import (
+	"log"
+	"maps"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+)
+
+// issuedToken is a numbered placeholder handed out this session.
+type issuedToken struct {
+	rule     string // tokenRuleKey of the rule that issued it
+	original string
+}
+
+// tokenTable maps the numbered placeholders of "numbered" rules to the texts
+// they replaced. It lives for the session, so the same text gets the same
+// placeholder on every hotkey press and each one can be restored.
+type tokenTable struct {
+	numbers map[string]string      // rule key, base and original -> token
+	last    map[string]int         // rule key and base -> last number issued
+	tokens  map[string]issuedToken // token -> rule and original
+}
+
+// tokenRuleKey identifies a rule in the token table.
+func tokenRuleKey(rep config.Replacement) string {
+	return rep.Regex + "\x00" + rep.ReplaceWith
+}
+
+// numberedToken inserts "_n" into base before trailing closing brackets, so
+// "[EMAIL]" becomes "[EMAIL_2]" and "REDACTED" becomes "REDACTED_2".
+func numberedToken(base string, n int) string {
+	end := len(base)
+	for end > 0 && strings.ContainsRune("])}>", rune(base[end-1])) {
+		end--
+	}
+	return base[:end] + "_" + strconv.Itoa(n) + base[end:]
+}
+
+// tokenFor returns the numbered placeholder for original, issuing the next
+// number of base if original has none yet.
+func (m *Manager) tokenFor(rep config.Replacement, base, original string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.tokens == nil {
+		m.tokens = &tokenTable{numbers: make(map[string]string), last: make(map[string]int), tokens: make(map[string]issuedToken)}
+	}
+	rule := tokenRuleKey(rep)
+	key := rule + "\x00" + base + "\x00" + original
+	if token, ok := m.tokens.numbers[key]; ok {
+		return token
+	}
+	for {
+		m.tokens.last[rule+"\x00"+base]++
+		token := numberedToken(base, m.tokens.last[rule+"\x00"+base])
+		if _, taken := m.tokens.tokens[token]; taken {
+			continue // Another rule issued it, e.g. one with the same replace_with
+		}
+		m.tokens.numbers[key] = token
+		m.tokens.tokens[token] = issuedToken{rule: rule, original: original}
+		return token
+	}
+}
+
+// restoreTokens replaces the numbered placeholders a rule issued this session
+// with the texts they stand for.
+func (m *Manager) restoreTokens(text string, rep config.Replacement) (string, int, error) {
+	rule := tokenRuleKey(rep)
+	m.mu.RLock()
+	originals := make(map[string]string)
+	if m.tokens != nil {
+		for token, issued := range m.tokens.tokens {
+			if issued.rule == rule {
+				originals[token] = issued.original
+			}
+		}
+	}
+	m.mu.RUnlock()
+	if len(originals) == 0 {
+		log.Printf("Numbered rule '%s' issued no placeholders this session. Nothing to restore.", rep.Regex)
+		return text, 0, nil
+	}
+
+	tokens := make([]string, 0, len(originals))
+	for token := range originals {
+		tokens = append(tokens, token)
+	}
+	sort.Slice(tokens, func(i, j int) bool { return len(tokens[i]) > len(tokens[j]) }) // "[X_12]" before "[X_1]"
+	for i, token := range tokens {
+		tokens[i] = regexp.QuoteMeta(token)
+	}
+	re := regexp.MustCompile(strings.Join(tokens, "|")) // Quoted literals always compile
+
+	var b strings.Builder
+	count, last := 0, 0
+	for _, loc := range re.FindAllStringIndex(text, -1) {
+		token := text[loc[0]:loc[1]]
+		if continuesNumber(token, text[loc[1]:]) {
+			continue
+		}
+		b.WriteString(text[last:loc[0]])
+		b.WriteString(originals[token])
+		last = loc[1]
+		count++
+	}
+	if count == 0 {
+		return text, 0, nil
+	}
+	b.WriteString(text[last:])
+	return b.String(), count, nil
+}
+
+// continuesNumber reports whether rest continues the number token ends with,
+// so "REDACTED_1" is not found in "REDACTED_12".
+func continuesNumber(token, rest string) bool {
+	return rest != "" && token != "" &&
+		token[len(token)-1] >= '0' && token[len(token)-1] <= '9' &&
+		rest[0] >= '0' && rest[0] <= '9'
+}
+
+// clone returns a copy of the table that can issue tokens without changing
+// the original, or nil for nil.
+func (t *tokenTable) clone() *tokenTable {
+	if t == nil {
+		return nil
+	}
+	return &tokenTable{numbers: maps.Clone(t.numbers), last: maps.Clone(t.last), tokens: maps.Clone(t.tokens)}
+}
@@ -0,0 +1,145 @@
+package clipboard
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/actions"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+)
+
+// Transformer is a custom transformation compiled into the application, such
+// as a company-internal tokenizer. Rules run it with "transformer": "<name>",
+// on the whole text or, if they have a regex, on each match, like an action.
+// It runs wherever the rule stands among the profile's rules, so every
+// profile decides the order of its transformers and other rules.
+//
+// Transformers are registered with RegisterTransformer in an init function of
+// the main package, see cmd/clipregex/transformers.go. The interface only
+// uses standard types, so their packages don't need to import this module.
+// Transformers that are not compiled in can be plugins, see LoadPlugins.
+type Transformer interface {
+	// Name is how rules refer to the transformer. It must be unique.
+	Name() string
+
+	// Transform returns the transformed text. options are the rule's
+	// transformer_options, nil if it has none. An error skips the rule and
+	// is shown like the errors of scripts and commands.
+	Transform(text string, options map[string]string) (string, error)
+}
+
+// Reporter is implemented by transformers that describe what they did, such
+// as how much text they dropped to fit a limit. The report is shown in a
+// notification after the clipboard was processed. It is empty if there is
+// nothing worth telling.
+type Reporter interface {
+	TransformAndReport(text string, options map[string]string) (result, report string, err error)
+}
+
+var (
+	transformersMu sync.RWMutex
+	transformers   = map[string]Transformer{}
+)
+
+func init() {
+	config.TransformerNames = TransformerNames // For validating the rules
+}
+
+// RegisterTransformer makes t available to rules under its name. It panics if
+// the name is empty or already taken, as that is a mistake in the build.
+func RegisterTransformer(t Transformer) {
+	transformersMu.Lock()
+	defer transformersMu.Unlock()
+	name := t.Name()
+	if name == "" {
+		panic("clipboard: RegisterTransformer with an empty name")
+	}
+	if _, taken := transformers[name]; taken {
+		panic(fmt.Sprintf("clipboard: transformer '%s' registered twice", name))
+	}
+	transformers[name] = t
+}
+
+// TransformerNames returns the names of the registered transformers in
+// alphabetical order.
+func TransformerNames() []string {
+	transformersMu.RLock()
+	defer transformersMu.RUnlock()
+	names := make([]string, 0, len(transformers))
+	for name := range transformers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupRegistered returns the compiled-in transformer registered under name.
+func lookupRegistered(name string) (Transformer, bool) {
+	transformersMu.RLock()
+	defer transformersMu.RUnlock()
+	t, ok := transformers[name]
+	return t, ok
+}
+
+// lookupTransformer returns the transformer registered under name, or else
+// the plugin of that name.
+func (m *Manager) lookupTransformer(name string) (Transformer, bool) {
+	if t, ok := lookupRegistered(name); ok {
+		return t, true
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if plugin, ok := m.plugins[name]; ok {
+		return plugin, true
+	}
+	return nil, false
+}
+
+// LoadPlugins finds the plugins in the plugins folder next to config.json,
+// see actions.Plugin, and stops those found before. They are started on
+// first use. A plugin named like a compiled-in transformer is ignored.
+func (m *Manager) LoadPlugins() {
+	m.mu.Lock()
+	old := m.plugins
+	m.plugins = make(map[string]*actions.Plugin)
+	if m.config != nil {
+		for name, path := range m.config.Plugins() {
+			if _, ok := lookupRegistered(name); ok {
+				log.Printf("Plugin '%s' ignored: a compiled-in transformer has the same name.", path)
+				continue
+			}
+			m.plugins[name] = actions.NewPlugin(name, path, time.Duration(config.DefaultCommandTimeoutMs)*time.Millisecond, config.DefaultCommandMaxOutputBytes)
+		}
+	}
+	count := len(m.plugins)
+	m.mu.Unlock()
+
+	for _, plugin := range old {
+		plugin.Close()
+	}
+	if count > 0 {
+		log.Printf("Found %d plugin(s) in the plugins folder.", count)
+	}
+}
+
+// ClosePlugins stops the running plugins.
+func (m *Manager) ClosePlugins() {
+	m.mu.RLock()
+	plugins := m.plugins
+	m.mu.RUnlock()
+	for _, plugin := range plugins {
+		plugin.Close()
+	}
+}
+
+// addTransformerReport keeps the report of a transformer rule for
+// LastTransformerReports.
+func (m *Manager) addTransformerReport(report string) {
+	log.Printf("Transformer report: %s", report)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.transformerReports = append(m.transformerReports, report)
+}
@@ -0,0 +1,50 @@
+//go:build darwin
+// +build darwin
+
+package clipboard
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+)
+
+// simulatePlatformTyping types text into the focused window with osascript.
+// Like pasting, it needs the Accessibility permission.
+func simulatePlatformTyping(text string, delayMs int) error {
+	log.Printf("Attempting to type %d characters on macOS", len([]rune(text)))
+
+	if !accessibilityTrusted() {
+		reportPasteProblem(accessibilityProblem)
+		return fmt.Errorf("the Accessibility permission is missing")
+	}
+
+	escaped := strings.ReplaceAll(text, `\`, `\\`)
+	escaped = strings.ReplaceAll(escaped, `"`, `\"`)
+	macScript := fmt.Sprintf(`tell application "System Events" to keystroke "%s"`, escaped)
+	output, err := exec.Command("osascript", "-e", macScript).CombinedOutput()
+	if err != nil {
+		log.Printf("osascript type failed: %v\nOutput: %s", err, string(output))
+		if isAccessibilityError(output) {
+			reportPasteProblem(accessibilityProblem)
+		}
+		return fmt.Errorf("osascript failed: %w", err)
+	}
+	log.Println("Typing simulation with osascript successful")
+	return nil
+}
+
+// simulatePlatformCursorLeft presses Left (key code 123) n times in the
+// focused window with osascript.
+func simulatePlatformCursorLeft(n int) error {
+	macScript := fmt.Sprintf(`tell application "System Events" to repeat %d times
+key code 123
+end repeat`, n)
+	output, err := exec.Command("osascript", "-e", macScript).CombinedOutput()
+	if err != nil {
+		log.Printf("osascript cursor movement failed: %v\nOutput: %s", err, string(output))
+		return fmt.Errorf("osascript failed: %w", err)
+	}
+	return nil
+}
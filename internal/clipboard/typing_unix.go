@@ -0,0 +1,19 @@
+//go:build !windows && !darwin
+// +build !windows,!darwin
+
+package clipboard
+
+import "log"
+
+// simulatePlatformTyping types text into the focused window on Linux with the
+// tool picked for the session (xdotool, wtype or ydotool).
+func simulatePlatformTyping(text string, delayMs int) error {
+	log.Printf("Attempting to type %d characters on Linux/Unix platform", len([]rune(text)))
+	return runInputTool("typing", func(tool inputTool) []string { return tool.typeCmd(text, delayMs) })
+}
+
+// simulatePlatformCursorLeft presses Left n times in the focused window, with
+// the same tools as typing.
+func simulatePlatformCursorLeft(n int) error {
+	return runInputTool("cursor movement", func(tool inputTool) []string { return tool.leftCmd(n) })
+}
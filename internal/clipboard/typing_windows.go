@@ -0,0 +1,85 @@
+//go:build windows
+// +build windows
+
+package clipboard
+
+import (
+	"fmt"
+	"log"
+	"time"
+	"unicode/utf16"
+)
+
+// Additional Windows constants for unicode typing
+const (
+	KEYEVENTF_UNICODE = 0x0004
+	VK_RETURN         = 0x0D
+	VK_TAB            = 0x09
+	VK_LEFT           = 0x25
+)
+
+// virtualKeyInputs returns key down/up inputs for a virtual key code.
+func virtualKeyInputs(vk uint16) []keyboardInput {
+	return []keyboardInput{
+		{Type: INPUT_KEYBOARD, Ki: keyBdInput{WVk: vk}},
+		{Type: INPUT_KEYBOARD, Ki: keyBdInput{WVk: vk, DwFlags: KEYEVENTF_KEYUP}},
+	}
+}
+
+// unicodeInputs returns key down/up inputs injecting a rune as UTF-16 code units.
+func unicodeInputs(r rune) []keyboardInput {
+	var inputs []keyboardInput
+	for _, unit := range utf16.Encode([]rune{r}) {
+		inputs = append(inputs,
+			keyboardInput{Type: INPUT_KEYBOARD, Ki: keyBdInput{WScan: unit, DwFlags: KEYEVENTF_UNICODE}},
+			keyboardInput{Type: INPUT_KEYBOARD, Ki: keyBdInput{WScan: unit, DwFlags: KEYEVENTF_UNICODE | KEYEVENTF_KEYUP}},
+		)
+	}
+	return inputs
+}
+
+// simulatePlatformTyping types text into the focused window using SendInput
+// unicode injection, so it also works in fields that block pasting.
+func simulatePlatformTyping(text string, delayMs int) error {
+	log.Printf("Attempting to type %d characters on Windows using SendInput...", len([]rune(text)))
+
+	runes := []rune(text)
+	for i, r := range runes {
+		var inputs []keyboardInput
+		switch r {
+		case '\r':
+			if i+1 < len(runes) && runes[i+1] == '\n' {
+				continue // Treat CRLF as a single Enter
+			}
+			inputs = virtualKeyInputs(VK_RETURN)
+		case '\n':
+			inputs = virtualKeyInputs(VK_RETURN)
+		case '\t':
+			inputs = virtualKeyInputs(VK_TAB)
+		default:
+			inputs = unicodeInputs(r)
+		}
+
+		if _, err := sendInputs(inputs); err != nil {
+			return fmt.Errorf("SendInput failed at character %d: %w", i, err)
+		}
+		if delayMs > 0 {
+			time.Sleep(time.Duration(delayMs) * time.Millisecond)
+		}
+	}
+
+	log.Println("Typing simulation via SendInput completed.")
+	return nil
+}
+
+// simulatePlatformCursorLeft presses Left n times in the focused window.
+func simulatePlatformCursorLeft(n int) error {
+	var inputs []keyboardInput
+	for i := 0; i < n; i++ {
+		inputs = append(inputs, virtualKeyInputs(VK_LEFT)...)
+	}
+	if _, err := sendInputs(inputs); err != nil {
+		return fmt.Errorf("SendInput failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package clipboard
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/clipboard/backend"
+)
+
+// Errors of Undo, Redo and RestoreUndoState that leave the clipboard unchanged.
+var (
+	ErrNothingToUndo    = errors.New("nothing to undo")
+	ErrNothingToRedo    = errors.New("nothing to redo")
+	ErrClipboardChanged = errors.New("the clipboard no longer holds a recorded state")
+)
+
+// UndoState is a clipboard text the undo and redo hotkeys can return to: the
+// copied text a series of changes started from, or the result of one of them.
+type UndoState struct {
+	Time      time.Time
+	Profiles  []string // Profiles whose change produced the text; empty for the copied text
+	Reverse   bool     // The change was a reverse replacement
+	Text      string
+	Sensitive bool // The text contains the value of a secret, so Preview doesn't show it
+
+	formats *backend.Snapshot // All formats of the copied text, if a revert stored them
+}
+
+// Preview returns the first line of the state's text, cut to at most limit
+// characters, or "" if the text contains a secret.
+func (s UndoState) Preview(limit int) string {
+	if s.Sensitive {
+		return ""
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(s.Text), "\n")
+	line = strings.TrimSpace(line)
+	if utf8.RuneCountInString(line) <= limit {
+		return line
+	}
+	return string([]rune(line)[:limit-1]) + "…"
+}
+
+// undoChain holds the states of the clipboard since text was copied, oldest
+// first. Each change by a hotkey, forward, reverse or by another profile,
+// adds a state after the current one and drops those that were undone.
+type undoChain struct {
+	states []UndoState
+	pos    int // Index of the state the clipboard was last set to
+}
+
+// find returns the index of the state holding text, preferring the current
+// one, or -1.
+func (c *undoChain) find(text string) int {
+	if c.pos < len(c.states) && c.states[c.pos].Text == text {
+		return c.pos
+	}
+	for i := len(c.states) - 1; i >= 0; i-- {
+		if c.states[i].Text == text {
+			return i
+		}
+	}
+	return -1
+}
+
+// syncUndoLocked marks the state holding text as the current one, e.g. after
+// a revert restored the copied text. The caller holds m.mu.
+func (m *Manager) syncUndoLocked(text string) {
+	if pos := m.undo.find(text); pos >= 0 {
+		m.undo.pos = pos
+	}
+}
+
+// SetUndoListener sets the function called whenever the states returned by
+// UndoStates change, e.g. to update the tray menu. It is called without
+// holding any lock.
+func (m *Manager) SetUndoListener(onChange func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onUndoChange = onChange
+}
+
+// notifyUndoChange calls the undo listener, if set. The caller must not hold
+// m.mu.
+func (m *Manager) notifyUndoChange() {
+	m.mu.RLock()
+	onChange := m.onUndoChange
+	m.mu.RUnlock()
+	if onChange != nil {
+		onChange()
+	}
+}
+
+// UndoStates returns the recorded states, oldest first, and the index of the
+// one the clipboard was last set to.
+func (m *Manager) UndoStates() ([]UndoState, int) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.Clone(m.undo.states), m.undo.pos
+}
+
+// recordUndoLocked adds the change of the clipboard from before to after.
+// If before is not a recorded state, i.e. new text was copied, the earlier
+// states are dropped and before becomes the first state. The caller holds
+// m.mu.
+func (m *Manager) recordUndoLocked(before, after string, profiles []string, reverse bool) {
+	depth := 0
+	if m.config != nil {
+		depth = m.config.GetUndoDepth()
+	}
+	if depth == 0 {
+		m.undo = undoChain{}
+		return
+	}
+
+	now := time.Now()
+	pos := m.undo.find(before)
+	if pos < 0 {
+		first := UndoState{Time: now, Text: before, Sensitive: m.containsSecretLocked(before)}
+		if m.previousClipboard == before {
+			first.formats = m.previousFormats
+		}
+		m.undo = undoChain{states: []UndoState{first}}
+		pos = 0
+	}
+	// A change after undoing replaces the undone states, like in an editor
+	m.undo.states = append(m.undo.states[:pos+1], UndoState{
+		Time:      now,
+		Profiles:  slices.Clone(profiles),
+		Reverse:   reverse,
+		Text:      after,
+		Sensitive: m.containsSecretLocked(after),
+	})
+	m.undo.pos = len(m.undo.states) - 1
+	m.trimUndoLocked(depth)
+}
+
+// trimUndoLocked drops the oldest states beyond depth changes. The caller
+// holds m.mu.
+func (m *Manager) trimUndoLocked(depth int) {
+	if depth == 0 {
+		m.undo = undoChain{}
+		return
+	}
+	if excess := len(m.undo.states) - (depth + 1); excess > 0 {
+		m.undo.states = slices.Clone(m.undo.states[excess:]) // Releases the dropped texts
+		m.undo.pos = max(m.undo.pos-excess, 0)
+	}
+}
+
+// Undo sets the clipboard to the state before the current one.
+func (m *Manager) Undo() (UndoState, error) {
+	return m.moveUndo(func(pos int) int { return pos - 1 }, ErrNothingToUndo)
+}
+
+// Redo sets the clipboard to the state after the current one again.
+func (m *Manager) Redo() (UndoState, error) {
+	return m.moveUndo(func(pos int) int { return pos + 1 }, ErrNothingToRedo)
+}
+
+// RestoreUndoState sets the clipboard to the state at index, as returned by
+// UndoStates. The states after it can be redone until the next change.
+func (m *Manager) RestoreUndoState(index int) (UndoState, error) {
+	return m.moveUndo(func(int) int { return index }, ErrNothingToUndo)
+}
+
+// moveUndo writes the state target picks, given the index of the state the
+// clipboard holds, or returns outOfRange if there is no such state.
+func (m *Manager) moveUndo(target func(pos int) int, outOfRange error) (UndoState, error) {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
+	current, err := m.clip.ReadText()
+	if err != nil {
+		return UndoState{}, fmt.Errorf("failed to read clipboard: %w", err)
+	}
+
+	m.mu.RLock()
+	pos := m.undo.find(current)
+	recorded := len(m.undo.states)
+	to := target(pos)
+	var state UndoState
+	if pos >= 0 && to >= 0 && to < recorded {
+		state = m.undo.states[to]
+	}
+	m.mu.RUnlock()
+
+	switch {
+	case recorded == 0:
+		return UndoState{}, outOfRange
+	case pos < 0:
+		return UndoState{}, ErrClipboardChanged
+	case to < 0 || to >= recorded || to == pos:
+		return UndoState{}, outOfRange
+	}
+	if err := m.writePrevious(state.Text, state.formats); err != nil {
+		return UndoState{}, fmt.Errorf("failed to write clipboard: %w", err)
+	}
+
+	m.mu.Lock()
+	m.setLastTransformedLocked(state.Text)
+	m.generation++ // A pending automatic revert must not overwrite the state
+	if to >= len(m.undo.states) || m.undo.states[to].Text != state.Text {
+		// A config reload dropped the state meanwhile
+		m.undo = undoChain{}
+		m.mu.Unlock()
+		m.notifyUndoChange()
+		return state, nil
+	}
+	m.undo.pos = to
+	if to > 0 {
+		// The change that led to the state is the last change again
+		m.lastOriginalForDiff = m.undo.states[to-1].Text
+		m.lastModifiedForDiff = state.Text
+	} else {
+		m.lastOriginalForDiff = ""
+		m.lastModifiedForDiff = ""
+	}
+	// The stored original is the first state: reverting is done when back
+	// there, and possible again after redoing a change that keeps originals
+	first := m.undo.states[0]
+	if to == 0 {
+		m.previousClipboard = ""
+		m.previousFormats = nil
+	} else if m.previousClipboard == "" && m.config != nil {
+		if temporary, _ := m.config.RevertSettings(m.undo.states[1].Profiles); temporary {
+			m.previousClipboard = first.Text
+			m.previousFormats = first.formats
+			m.revertManual = true // Nothing is pasted, so nothing reverts it automatically
+			m.armRevertExpiryLocked()
+		}
+	}
+	canRevert := m.previousClipboard != ""
+	if m.containsSecretLocked(state.Text) {
+		m.armWipeLocked(state.Text)
+	}
+	m.mu.Unlock()
+
+	log.Printf("Clipboard set to undo state %d of %d (was %d).", to+1, recorded, pos+1)
+	if m.onRevertStatusChange != nil {
+		m.onRevertStatusChange(canRevert)
+	}
+	m.notifyUndoChange()
+	return state, nil
+}
@@ -0,0 +1,160 @@
+package clipboard
+
+import (
+	"crypto/rand"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+)
+
+// Default layouts of the date and time variables.
+const (
+	defaultDateLayout     = "2006-01-02"
+	defaultTimeLayout     = "15:04:05"
+	defaultDateTimeLayout = "2006-01-02 15:04:05"
+)
+
+// templateVariableRegex matches {{name}} and {{name:argument}} for the
+// template variables. The argument may not contain braces.
+var templateVariableRegex = regexp.MustCompile(`\{\{(` + strings.Join(config.TemplateVariables, "|") + `)(?::([^{}]*))?\}\}`)
+
+// templateVars supplies the values of template variables for one rule
+// application. All variables of the application see the same time.
+type templateVars struct {
+	now     time.Time
+	counter func(label string) int   // Value of {{counter:label}}
+	capture func(name string) string // Value of {{var:name}}
+}
+
+// expand replaces the template variables in text.
+func (v *templateVars) expand(text string) string {
+	return templateVariableRegex.ReplaceAllStringFunc(text, func(match string) string {
+		parts := templateVariableRegex.FindStringSubmatch(match)
+		name, arg := parts[1], parts[2]
+		switch name {
+		case config.VariableDate:
+			return v.now.Format(layoutOr(arg, defaultDateLayout))
+		case config.VariableTime:
+			return v.now.Format(layoutOr(arg, defaultTimeLayout))
+		case config.VariableDateTime:
+			return v.now.Format(layoutOr(arg, defaultDateTimeLayout))
+		case config.VariableUUID:
+			return newUUID()
+		case config.VariableCounter:
+			return strconv.Itoa(v.counter(arg))
+		case config.VariableCapture:
+			return v.capture(arg)
+		case config.VariableCursor:
+			return cursorMarker
+		}
+		return match
+	})
+}
+
+// cursorMarker stands for {{cursor}} in the text until it is pasted. It is a
+// noncharacter, which copied text doesn't contain.
+const cursorMarker = "\uFDD0"
+
+// maxCursorBack is the farthest the cursor is moved back after pasting; a
+// {{cursor}} farther from the end is ignored rather than pressing Left for
+// seconds.
+const maxCursorBack = 2000
+
+// takeCursorMarker removes the {{cursor}} markers from text and returns how
+// many characters the cursor has to move left from the end of the pasted
+// text to reach the first one, with a CRLF line break counting as one.
+func takeCursorMarker(text string) (string, int) {
+	i := strings.Index(text, cursorMarker)
+	if i < 0 {
+		return text, 0
+	}
+	after := strings.ReplaceAll(text[i+len(cursorMarker):], cursorMarker, "")
+	back := utf8.RuneCountInString(after) - strings.Count(after, "\r\n")
+	if back > maxCursorBack {
+		back = 0
+	}
+	return text[:i] + after, back
+}
+
+func layoutOr(layout, fallback string) string {
+	if layout == "" {
+		return fallback
+	}
+	return layout
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "00000000-0000-4000-8000-000000000000" // crypto/rand does not fail on supported platforms
+	}
+	b[6] = b[6]&0x0f | 0x40 // Version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// applyTemplateVars returns the variables for a replacement: every
+// {{counter:label}} counts up and the new value is kept for the session.
+func (m *Manager) applyTemplateVars() *templateVars {
+	return &templateVars{now: time.Now(), counter: func(label string) int {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.counters == nil {
+			m.counters = make(map[string]int)
+		}
+		m.counters[label]++
+		return m.counters[label]
+	}, capture: m.captured}
+}
+
+// previewTemplateVars returns the variables a replacement would use now,
+// without counting up. With next set, counters show the value the next
+// replacement produces; otherwise the value the last one produced, which is
+// what a reverse replacement searches for.
+func (m *Manager) previewTemplateVars(next bool) *templateVars {
+	return &templateVars{now: time.Now(), counter: func(label string) int {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+		if next {
+			return m.counters[label] + 1
+		}
+		return m.counters[label]
+	}, capture: m.captured}
+}
+
+// captured returns the value a rule of the current run captured as name, or
+// "" if none did.
+func (m *Manager) captured(name string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.captures[name]
+}
+
+// captureMatch stores the first match of re in text as the rule's capture_as
+// variable: the group named like the variable if there is one, else the first
+// group, else the whole match. Groups that did not take part store "".
+func (m *Manager) captureMatch(re *regexp.Regexp, text string, rep config.Replacement) {
+	if rep.CaptureAs == "" {
+		return
+	}
+	match := re.FindStringSubmatch(text)
+	if match == nil {
+		return
+	}
+	group := re.SubexpIndex(rep.CaptureAs)
+	if group < 0 {
+		group = min(1, re.NumSubexp())
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.captures == nil {
+		m.captures = make(map[string]string)
+	}
+	m.captures[rep.CaptureAs] = match[group]
+}
@@ -0,0 +1,78 @@
+package clipboard
+
+import (
+	"context"
+	"crypto/sha256"
+	"log"
+)
+
+// recentCopyLimit is how many copied texts are remembered, so the same text
+// set on the clipboard again is not processed again.
+const recentCopyLimit = 32
+
+// ProcessCopied applies every enabled profile to text that was just copied,
+// for watching the clipboard where hotkeys are unavailable. Nothing is pasted:
+// the result waits on the clipboard for the user to paste it. Text the manager
+// wrote itself, such as an earlier result or a reverted original, is left
+// alone, so results are not processed again.
+func (m *Manager) ProcessCopied(ctx context.Context) (message string, changedForDiff bool) {
+	return m.processClipboard(ctx, "", "", false, true)
+}
+
+// isNewCopy reports whether text is worth processing as newly copied: it is
+// not empty, not written by the manager, not taken on recently, and within
+// the size limits, as asking about oversized text on every copy would get in
+// the way.
+func (m *Manager) isNewCopy(text string) bool {
+	if text == "" {
+		return false
+	}
+	m.mu.Lock()
+	own := m.isLastTransformedLocked(text)
+	seen := !own && m.recentCopies.seen(sha256.Sum256([]byte(text)))
+	cfg := m.config
+	m.mu.Unlock()
+	if own {
+		return false
+	}
+	if seen {
+		log.Printf("Copied text (%d bytes) not processed: the same text was copied recently.", len(text))
+		return false
+	}
+	if cfg != nil {
+		if reason := oversizeReason(cfg, text); reason != "" {
+			log.Printf("Copied text (%d bytes) not processed: %s", len(text), reason)
+			return false
+		}
+	}
+	return true
+}
+
+// copyHashes remembers the SHA-256 of recently copied texts, least recently
+// copied first. Applications like Office set identical data on the clipboard
+// again and again; without it, each time would be processed and notified as
+// a new copy. Only hashes are kept, so the copies don't linger in memory.
+type copyHashes struct {
+	hashes [][sha256.Size]byte
+}
+
+// seen reports whether hash was copied recently and marks it as the most
+// recent copy.
+func (c *copyHashes) seen(hash [sha256.Size]byte) bool {
+	for i, h := range c.hashes {
+		if h == hash {
+			c.hashes = append(append(c.hashes[:i:i], c.hashes[i+1:]...), hash)
+			return true
+		}
+	}
+	if len(c.hashes) >= recentCopyLimit {
+		c.hashes = c.hashes[1:]
+	}
+	c.hashes = append(c.hashes, hash)
+	return false
+}
+
+// reset forgets all copies.
+func (c *copyHashes) reset() {
+	c.hashes = nil
+}
@@ -0,0 +1,108 @@
+package clipboard
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ClearClipboard empties the clipboard and forgets the stored original and
+// the last change, so the application keeps no copy of the text either.
+func (m *Manager) ClearClipboard() error {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+	return m.clearClipboardOp()
+}
+
+// clearClipboardOp is ClearClipboard for callers holding opMu.
+func (m *Manager) clearClipboardOp() error {
+	if err := m.clip.WriteText(""); err != nil {
+		return fmt.Errorf("failed to clear the clipboard: %w", err)
+	}
+
+	m.mu.Lock()
+	hadOriginal := m.previousClipboard != ""
+	m.previousClipboard = ""
+	m.previousFormats = nil
+	m.lastOriginalForDiff = ""
+	m.lastModifiedForDiff = ""
+	m.setLastTransformedLocked("")
+	m.undo = undoChain{}
+	m.generation++ // A pending automatic revert must not restore the original
+	m.wipeSeq++
+	m.mu.Unlock()
+
+	log.Println("Clipboard cleared.")
+	if hadOriginal && m.onRevertStatusChange != nil {
+		m.onRevertStatusChange(false)
+	}
+	m.notifyUndoChange()
+	return nil
+}
+
+// containsSecretLocked reports whether any of texts contains the value of a
+// secret. The caller holds m.mu.
+func (m *Manager) containsSecretLocked(texts ...string) bool {
+	for _, value := range m.resolvedSecrets {
+		if value == "" {
+			continue
+		}
+		for _, text := range texts {
+			if strings.Contains(text, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// armWipeLocked empties the clipboard after clear_after_secret_seconds, if
+// set, unless it no longer holds one of texts by then, i.e. something else
+// was copied. The caller holds m.mu.
+func (m *Manager) armWipeLocked(texts ...string) {
+	if m.config == nil {
+		return
+	}
+	delay := m.config.GetClearAfterSecret()
+	if delay <= 0 {
+		return
+	}
+	m.wipeSeq++ // Only the wipe for the latest transformation runs
+	seq := m.wipeSeq
+	log.Printf("The clipboard contains a secret and will be cleared in %s.", delay)
+	time.AfterFunc(delay, func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("RECOVERED FROM PANIC IN CLIPBOARD WIPE: %v", r)
+			}
+		}()
+		m.wipe(seq, texts)
+	})
+}
+
+// wipe is the scheduled part of armWipeLocked.
+func (m *Manager) wipe(seq uint64, texts []string) {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
+	m.mu.RLock()
+	current := m.wipeSeq == seq
+	m.mu.RUnlock()
+	if !current {
+		return
+	}
+	text, err := m.clip.ReadText()
+	if err != nil {
+		log.Printf("Scheduled clipboard wipe skipped: failed to read the clipboard: %v", err)
+		return
+	}
+	if !slices.Contains(texts, text) {
+		log.Println("Scheduled clipboard wipe skipped: something else was copied in the meantime.")
+		return
+	}
+	if err := m.clearClipboardOp(); err != nil {
+		log.Printf("Scheduled clipboard wipe failed: %v", err)
+	}
+}
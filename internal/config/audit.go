@@ -0,0 +1,30 @@
+package config
+
+import "time"
+
+const DefaultAuditRetentionDays = 90 // Default age after which audit log records are dropped
+
+// AuditLogConfig controls the audit log of clipboard transformations. It
+// holds no clipboard content, only which rules ran and hashes of the text.
+type AuditLogConfig struct {
+	Enabled       bool `json:"enabled"`
+	RetentionDays int  `json:"retention_days,omitempty"` // Days a record is kept (default: 90, negative keeps them forever)
+}
+
+// AuditLogEnabled reports whether the audit log is written.
+func (c *Config) AuditLogEnabled() bool {
+	return c.AuditLog != nil && c.AuditLog.Enabled
+}
+
+// GetAuditRetention returns how long audit log records are kept,
+// the default if not set, or 0 if there is no limit (negative value)
+func (c *Config) GetAuditRetention() time.Duration {
+	days := DefaultAuditRetentionDays
+	if c.AuditLog != nil && c.AuditLog.RetentionDays != 0 {
+		days = c.AuditLog.RetentionDays
+	}
+	if days < 0 {
+		return 0
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BackupDirName is the folder next to config.json that holds its backups.
+const BackupDirName = "backups"
+
+// ErrNoBackup is returned by RestorePreviousBackup if there is no backup that
+// differs from the current config file.
+var ErrNoBackup = errors.New("no config backup differs from the current file")
+
+// backupTimeFormat names backups so that they sort by age.
+const backupTimeFormat = "20060102-150405.000"
+
+// BackupDir returns the folder holding the backups of the config file at configPath.
+func BackupDir(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), BackupDirName)
+}
+
+// writeFileAtomic replaces path with data without ever leaving a partly
+// written file behind: the data goes to a temporary file in the same folder,
+// which is then renamed over path.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// backupConfigFile copies the config file at configPath into the backups
+// folder before it is replaced with data, and deletes the oldest backups
+// beyond keep. Nothing is copied if the file does not exist yet or already
+// contains data.
+func backupConfigFile(configPath string, data []byte, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+	current, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if bytes.Equal(current, data) {
+		return nil
+	}
+
+	dir := BackupDir(configPath)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	name := backupPrefix(configPath) + time.Now().Format(backupTimeFormat) + filepath.Ext(configPath)
+	if err := writeFileAtomic(filepath.Join(dir, name), current, 0600); err != nil {
+		return err
+	}
+
+	backups, err := ListBackups(configPath)
+	if err != nil {
+		return err
+	}
+	for len(backups) > keep {
+		oldest := backups[len(backups)-1]
+		if err := os.Remove(oldest); err != nil {
+			log.Printf("Warning: Could not delete old config backup '%s': %v", oldest, err)
+		}
+		backups = backups[:len(backups)-1]
+	}
+	return nil
+}
+
+// backupPrefix is the start of the backup file names of configPath, e.g.
+// "config-" for config.json.
+func backupPrefix(configPath string) string {
+	base := filepath.Base(configPath)
+	return strings.TrimSuffix(base, filepath.Ext(base)) + "-"
+}
+
+// ListBackups returns the paths of the backups of the config file at
+// configPath, newest first.
+func ListBackups(configPath string) ([]string, error) {
+	dir := BackupDir(configPath)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	prefix, ext := backupPrefix(configPath), filepath.Ext(configPath)
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		if _, err := time.Parse(backupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)); err != nil {
+			continue // Not one of our backups
+		}
+		backups = append(backups, filepath.Join(dir, name))
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	return backups, nil
+}
+
+// RestorePreviousBackup replaces the config file at configPath with its
+// newest backup that differs from it and returns the path of that backup.
+// Unless backups are disabled (keep <= 0), the current file is backed up
+// first, so restoring can itself be undone. keep is the number of backups to
+// keep, as returned by GetConfigBackups.
+func RestorePreviousBackup(configPath string, keep int) (string, error) {
+	backups, err := ListBackups(configPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to list config backups: %w", err)
+	}
+	current, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read config file '%s': %w", configPath, err)
+	}
+
+	for _, backup := range backups {
+		data, err := os.ReadFile(backup)
+		if err != nil {
+			log.Printf("Warning: Could not read config backup '%s': %v", backup, err)
+			continue
+		}
+		if bytes.Equal(data, current) {
+			continue
+		}
+		// Keep one backup more than usual, so the backup being restored is not
+		// rotated out by the copy of the current file.
+		if keep > 0 {
+			if err := backupConfigFile(configPath, data, keep+1); err != nil {
+				return "", fmt.Errorf("failed to back up the current config: %w", err)
+			}
+		}
+		if err := writeFileAtomic(configPath, data, 0600); err != nil {
+			return "", fmt.Errorf("failed to restore config backup '%s': %w", backup, err)
+		}
+		log.Printf("Restored config file '%s' from backup '%s'.", configPath, backup)
+		return backup, nil
+	}
+	return "", ErrNoBackup
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BidirectionalPair is one exact mapping of a bidirectional rule: the hotkey
+// replaces Original with Replacement, the reverse hotkey Replacement with
+// Original. Both are literal text and may contain {{secret}} placeholders.
+type BidirectionalPair struct {
+	Original    string `json:"original"`
+	Replacement string `json:"replacement"`
+}
+
+// IsBidirectional reports whether the rule is a list of bidirectional pairs
+// instead of a regex.
+func (r Replacement) IsBidirectional() bool {
+	return len(r.Bidirectional) > 0
+}
+
+// ReversesByGuess reports whether the reverse hotkey has to derive the
+// original text from the rule's regex, because the rule is neither
+// bidirectional nor numbered, nor has a reverse_with.
+//
+// Deprecated behavior: the guess (the first alternative of the regex) is
+// wrong for anything but plain words and alternations. It is kept for
+// existing configs; new rules should be bidirectional or set reverse_with.
+func (r Replacement) ReversesByGuess() bool {
+	return !r.IsTransform() && !r.IsBidirectional() && !r.Numbered && r.ReverseWith == "" && !r.snippet
+}
+
+// validateBidirectional checks the pairs of a bidirectional rule. Originals
+// and replacements must be unique, so both directions are unambiguous.
+func validateBidirectional(rulePrefix string, replacement Replacement) []string {
+	var validationErrors []string
+	if replacement.Regex != "" || replacement.ReplaceWith != "" || replacement.ReverseWith != "" || replacement.IsTransform() {
+		validationErrors = append(validationErrors, fmt.Sprintf("%s: a bidirectional rule cannot use regex, replace_with, reverse_with, action, script, command, transformer or llm", rulePrefix))
+	}
+
+	originals := make(map[string]int)
+	replacements := make(map[string]int)
+	fold := func(s string) string {
+		if replacement.CaseInsensitive || replacement.PreserveCase {
+			return strings.ToLower(s) // Matched case-insensitively
+		}
+		return s
+	}
+	for i, pair := range replacement.Bidirectional {
+		pairPrefix := fmt.Sprintf("%s.bidirectional[%d]", rulePrefix, i)
+		if pair.Original == "" || pair.Replacement == "" {
+			validationErrors = append(validationErrors, fmt.Sprintf("%s: original and replacement cannot be empty", pairPrefix))
+			continue
+		}
+		if j, dup := originals[fold(pair.Original)]; dup {
+			validationErrors = append(validationErrors, fmt.Sprintf("%s: original '%s' is already mapped by pair %d", pairPrefix, pair.Original, j))
+		} else {
+			originals[fold(pair.Original)] = i
+		}
+		if j, dup := replacements[fold(pair.Replacement)]; dup {
+			validationErrors = append(validationErrors, fmt.Sprintf("%s: replacement '%s' is already used by pair %d, so the reverse hotkey could not tell them apart", pairPrefix, pair.Replacement, j))
+		} else {
+			replacements[fold(pair.Replacement)] = i
+		}
+	}
+	return validationErrors
+}
@@ -0,0 +1,8 @@
+//go:build !windows
+// +build !windows
+
+package config
+
+import "os/exec"
+
+func hideCommandWindow(cmd *exec.Cmd) {}
@@ -0,0 +1,16 @@
+//go:build windows
+// +build windows
+
+package config
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// createNoWindow keeps console programs from flashing a console window.
+const createNoWindow = 0x08000000
+
+func hideCommandWindow(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true, CreationFlags: createNoWindow}
+}
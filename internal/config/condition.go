@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Condition gates a profile or rule on the clipboard content, e.g. to run a
+// SQL formatting profile only on text that looks like SQL. All fields that are
+// set must hold.
+type Condition struct {
+	Contains    string `json:"contains,omitempty"`     // Text that must occur
+	NotContains string `json:"not_contains,omitempty"` // Text that must not occur
+	Matches     string `json:"matches,omitempty"`      // Regex that must match somewhere
+	NotMatches  string `json:"not_matches,omitempty"`  // Regex that must not match anywhere
+}
+
+// Holds reports whether text satisfies the condition. A nil condition always
+// holds.
+func (c *Condition) Holds(text string) (bool, error) {
+	if c == nil {
+		return true, nil
+	}
+	if c.Contains != "" && !strings.Contains(text, c.Contains) {
+		return false, nil
+	}
+	if c.NotContains != "" && strings.Contains(text, c.NotContains) {
+		return false, nil
+	}
+	if c.Matches != "" {
+		re, err := regexp.Compile(c.Matches)
+		if err != nil {
+			return false, fmt.Errorf("invalid 'matches' regex '%s': %w", c.Matches, err)
+		}
+		if !re.MatchString(text) {
+			return false, nil
+		}
+	}
+	if c.NotMatches != "" {
+		re, err := regexp.Compile(c.NotMatches)
+		if err != nil {
+			return false, fmt.Errorf("invalid 'not_matches' regex '%s': %w", c.NotMatches, err)
+		}
+		if re.MatchString(text) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// validateCondition checks that a condition is not empty and its regexes compile.
+func validateCondition(c *Condition) error {
+	if c.Contains == "" && c.NotContains == "" && c.Matches == "" && c.NotMatches == "" {
+		return fmt.Errorf("set at least one of 'contains', 'not_contains', 'matches' or 'not_matches'")
+	}
+	for field, pattern := range map[string]string{"matches": c.Matches, "not_matches": c.NotMatches} {
+		if pattern == "" {
+			continue
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid '%s' regex '%s': %v", field, pattern, err)
+		}
+	}
+	return nil
+}
@@ -2,40 +2,105 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
 	"strings" // Needed for level comparison
+	"time"
 
-	"github.com/99designs/keyring"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/actions"
 )
 
 // ProfileConfig represents a single regex replacement profile
 type ProfileConfig struct {
-	Name          string        `json:"name"`
-	Enabled       bool          `json:"enabled"`
-	Hotkey        string        `json:"hotkey"`
-	ReverseHotkey string        `json:"reverse_hotkey,omitempty"`
-	Replacements  []Replacement `json:"replacements"`
+	Name          string           `json:"name"`
+	Enabled       bool             `json:"enabled"`
+	Hotkey        string           `json:"hotkey"`
+	ReverseHotkey string           `json:"reverse_hotkey,omitempty"`
+	OutputMode    string           `json:"output_mode,omitempty"`    // "paste" (default), "type" or "plain_text"
+	IncludeGroups []string         `json:"include_groups,omitempty"` // Names of rule_groups whose rules run before the profile's own rules
+	Schedule      *ProfileSchedule `json:"schedule,omitempty"`       // Enables the profile only at certain times
+	When          *Condition       `json:"when,omitempty"`           // Runs the profile only on matching clipboard content
+	Sounds        *bool            `json:"sounds,omitempty"`         // Overrides sounds.enabled for this profile's hotkeys
+	ContentTypes  []string         `json:"content_types,omitempty"`  // Content types the profile handles when smart_hotkey is pressed
+	RoundTrip     bool             `json:"round_trip,omitempty"`     // Records the forward replacements so reverse_hotkey restores the original exactly
+	Group         string           `json:"group,omitempty"`          // Only one profile of a group is enabled at a time
+	BudgetMs      int              `json:"budget_ms,omitempty"`      // Overrides profile_budget_ms for this profile
+	Extract       string           `json:"extract,omitempty"`        // "lines" or "json": collect the matches of the rules instead of replacing them
+	SecretsScope  []string         `json:"secrets_scope,omitempty"`  // Names of the only secrets the profile's rules may use; all if empty
+	CopySelection bool             `json:"copy_selection,omitempty"` // Presses Ctrl+C first, so the hotkey transforms the selected text
+	// Short triggers such as ";addr" and the templates they expand to before the profile's rules run
+	Snippets map[string]string `json:"snippets,omitempty"`
+	// Applications the profile never transforms or pastes into, as "process:", "title:" or plain patterns
+	ExcludeWindows []string `json:"exclude_windows,omitempty"`
+	// Override temporary_clipboard and automatic_reversion for this profile
+	TemporaryClipboard *bool         `json:"temporary_clipboard,omitempty"`
+	AutomaticReversion *bool         `json:"automatic_reversion,omitempty"`
+	Replacements       []Replacement `json:"replacements"`
 }
 
 // Config holds the application configuration
 type Config struct {
 	// UseNotifications   bool              `json:"use_notifications"` // DEPRECATED: Use new fields below
-	AdminNotificationLevel string            `json:"admin_notification_level"` // NEW: Controls verbosity ("None", "Error", "Warn", "Info")
-	NotifyOnReplacement    bool              `json:"notify_on_replacement"`    // NEW: Toggle for replacement success notifications
-	TemporaryClipboard     bool              `json:"temporary_clipboard"`
-	AutomaticReversion     bool              `json:"automatic_reversion"`
-	RevertHotkey           string            `json:"revert_hotkey"`
-	Profiles               []ProfileConfig   `json:"profiles"`
-	Secrets                map[string]string `json:"secrets,omitempty"` // Maps logical name -> "managed"
+	AdminNotificationLevel string                   `json:"admin_notification_level"` // NEW: Controls verbosity ("None", "Error", "Warn", "Info")
+	NotifyOnReplacement    bool                     `json:"notify_on_replacement"`    // NEW: Toggle for replacement success notifications
+	TemporaryClipboard     bool                     `json:"temporary_clipboard"`
+	AutomaticReversion     bool                     `json:"automatic_reversion"`
+	RevertHotkey           string                   `json:"revert_hotkey"`
+	TypeHotkey             string                   `json:"type_hotkey,omitempty"`      // Types the current clipboard text instead of pasting it
+	SmartHotkey            string                   `json:"smart_hotkey,omitempty"`     // Runs the profiles handling the detected content type of the clipboard
+	ClearHotkey            string                   `json:"clear_hotkey,omitempty"`     // Empties the clipboard and forgets the stored original
+	UndoHotkey             string                   `json:"undo_hotkey,omitempty"`      // Steps back through the recent changes of the clipboard
+	RedoHotkey             string                   `json:"redo_hotkey,omitempty"`      // Steps forward again after undo_hotkey
+	GroupHotkeys           map[string]string        `json:"group_hotkeys,omitempty"`    // Profile group -> hotkey enabling the group's next profile
+	LockKeys               string                   `json:"lock_keys,omitempty"`        // Whether hotkeys trigger while NumLock or CapsLock is on: "ignore" (default), "strict", "numlock" or "capslock"
+	HotkeyLockKeys         map[string]string        `json:"hotkey_lock_keys,omitempty"` // Hotkey -> lock_keys for that hotkey only
+	Profiles               []ProfileConfig          `json:"profiles"`
+	RuleGroups             map[string][]Replacement `json:"rule_groups,omitempty"`        // Shared rules, included by profiles by name
+	Secrets                map[string]string        `json:"secrets,omitempty"`            // Maps logical name -> "managed" (OS keyring) or an op://, bw:// or vault:// reference
+	ContentTypeRules       []ContentTypeRule        `json:"content_type_rules,omitempty"` // Custom content types for smart_hotkey, checked before the built-in ones
 
 	// Performance and behavior settings
-	PasteDelayMs          int `json:"paste_delay_ms,omitempty"`           // Delay before pasting (default: 400ms)
-	RevertDelayMs         int `json:"revert_delay_ms,omitempty"`          // Delay before reverting (default: 300ms)
-	RegexTimeoutMs        int `json:"regex_timeout_ms,omitempty"`         // Timeout for regex operations (default: 5000ms)
-	DiffContextLines      int `json:"diff_context_lines,omitempty"`       // Context lines in diff viewer (default: 3)
+	PasteDelayMs                int    `json:"paste_delay_ms,omitempty"`                // Delay before pasting (default: 400ms)
+	RevertDelayMs               int    `json:"revert_delay_ms,omitempty"`               // Delay before reverting (default: 300ms)
+	RevertTimeoutSeconds        int    `json:"revert_timeout_seconds,omitempty"`        // Time after which a stored original is cleared and can't be reverted (default: 0, kept until replaced)
+	ClearAfterSecretSeconds     int    `json:"clear_after_secret_seconds,omitempty"`    // Time after which the clipboard is emptied when a transformation involved a secret (default: 0, off)
+	RegexTimeoutMs              int    `json:"regex_timeout_ms,omitempty"`              // Timeout for regex operations (default: 5000ms)
+	ProfileBudgetMs             int    `json:"profile_budget_ms,omitempty"`             // Time a profile may take per hotkey press before its remaining rules are skipped (default: 10000ms, negative disables)
+	DiffContextLines            int    `json:"diff_context_lines,omitempty"`            // Context lines in diff viewer (default: 3)
+	DiffGranularity             string `json:"diff_granularity,omitempty"`              // Highlighting within changed lines: "line", "word" (default) or "char"
+	TypingDelayMs               int    `json:"typing_delay_ms,omitempty"`               // Delay between typed characters (default: 5ms)
+	CopyTimeoutMs               int    `json:"copy_timeout_ms,omitempty"`               // Time copy_selection profiles wait for the selection to reach the clipboard (default: 1000ms)
+	SequenceTimeoutMs           int    `json:"sequence_timeout_ms,omitempty"`           // Time to press the second key of a hotkey sequence (default: 2000ms)
+	DoublePressMs               int    `json:"double_press_ms,omitempty"`               // Max time between the presses of a double-press hotkey (default: 400ms)
+	LongPressMs                 int    `json:"long_press_ms,omitempty"`                 // Hold time of a long-press hotkey (default: 600ms)
+	NotificationThrottleSeconds int    `json:"notification_throttle_seconds,omitempty"` // Window for aggregating replacement notifications (default: 30s, negative disables)
+	LargeClipboardBytes         int    `json:"large_clipboard_bytes,omitempty"`         // Clipboard size from which line_safe rules run in chunks and rule timings are logged (default: 1 MiB)
+	MaxClipboardBytes           int    `json:"max_clipboard_bytes,omitempty"`           // Clipboard size above which processing asks first or is skipped (default: 50 MiB, negative disables)
+	MaxClipboardLines           int    `json:"max_clipboard_lines,omitempty"`           // Line count above which processing asks first or is skipped (default: 0, no limit)
+	OversizeAction              string `json:"oversize_action,omitempty"`               // What happens above the limits: "ask" (default) or "skip"
+	ClipboardWatch              string `json:"clipboard_watch,omitempty"`               // Processes copied text without a hotkey: "auto" (default) where no hotkey backend works, "on" or "off"
+	UndoDepth                   int    `json:"undo_depth,omitempty"`                    // Changes undo_hotkey can step back through (default: 20, negative disables)
+	ConfigBackups               int    `json:"config_backups,omitempty"`                // Number of config.json backups kept in the backups folder (default: 10, negative disables)
+	UpdateCheck                 string `json:"update_check,omitempty"`                  // "notify" (default): check GitHub for new releases daily, or "off"
+	SecretStore                 string `json:"secret_store,omitempty"`                  // Where "managed" secrets live: "auto" (default), "keyring" or "file"
+	KeyringBackend              string `json:"keyring_backend,omitempty"`               // OS keyring of "managed" secrets: "auto" (default), "wincred", "secret-service", "keychain", "pass" or "file"
+	StatsRollover               string `json:"stats_rollover,omitempty"`                // "monthly" (default): archive and reset the statistics each month, or "off"
+	Language                    string `json:"language,omitempty"`                      // Language of the tray menu, notifications and dialogs: "auto" (default, the system language), "en", "de", ...
+	CaseLocale                  string `json:"case_locale,omitempty"`                   // Language whose case rules preserve_case and the case actions use, e.g. "tr" or "de" (default: Unicode's)
+
+	Sounds   *SoundConfig    `json:"sounds,omitempty"`    // Audio cues after a hotkey press (off by default)
+	History  *HistoryConfig  `json:"history,omitempty"`   // Encrypted history of clipboard changes kept across restarts (off by default)
+	AuditLog *AuditLogConfig `json:"audit_log,omitempty"` // Content-free log of which rules ran when (off by default)
+
+	TrackingParams *TrackingParams `json:"tracking_params,omitempty"` // Additions to the URL tracking parameters removed by the strip_tracking action
+
+	LLM *LLMConfig `json:"llm,omitempty"` // OpenAI-compatible API that llm rules send the redacted clipboard to (off by default)
 
 	// Legacy support fields (for backward compatibility)
 	Hotkey       string        `json:"hotkey,omitempty"`
@@ -43,16 +108,151 @@ type Config struct {
 
 	// Non-JSON fields (runtime state)
 	configPath      string
-	keyringService  string            // e.g., "Clipboard Regex Replace"
-	resolvedSecrets map[string]string // Runtime map {"logicalName": "actualValue"}
+	keyringService  string                 // e.g., "Clipboard Regex Replace"
+	resolvedSecrets map[string]string      // Runtime map {"logicalName": "actualValue"}
+	secretStoreErr  error                  // Why the store of "managed" secrets couldn't be opened by loadSecrets
+	trackingRules   *actions.TrackingRules // Parameters strip_tracking removes, set by validation
 }
 
 // Replacement represents one regex replacement rule
 type Replacement struct {
-	Regex        string `json:"regex"`
-	ReplaceWith  string `json:"replace_with"`
-	PreserveCase bool   `json:"preserve_case,omitempty"`
-	ReverseWith  string `json:"reverse_with,omitempty"`
+	Regex           string           `json:"regex"`
+	ReplaceWith     string           `json:"replace_with"`
+	PreserveCase    bool             `json:"preserve_case,omitempty"`
+	CaseStrategy    string           `json:"case_strategy,omitempty"` // How preserve_case transfers the case: "auto" (default), "exact", "first_letter", "token" or "identifier"
+	CaseLocale      string           `json:"case_locale,omitempty"`   // Overrides the global case_locale for this rule
+	ReverseWith     string           `json:"reverse_with,omitempty"`
+	Action          string           `json:"action,omitempty"`           // Built-in transformation applied to the whole text, or to each match if regex is set
+	Script          string           `json:"script,omitempty"`           // Lua script used like an action; relative to config.json
+	Command         *ExternalCommand `json:"command,omitempty"`          // External program used like an action
+	CaseInsensitive bool             `json:"case_insensitive,omitempty"` // Adds the (?i) flag to the pattern
+	Multiline       bool             `json:"multiline,omitempty"`        // Adds the (?m) flag: ^ and $ match at line breaks
+	DotAll          bool             `json:"dotall,omitempty"`           // Adds the (?s) flag: . matches line breaks
+	MatchMode       string           `json:"match_mode,omitempty"`       // "anywhere" (default), "word" or "line"
+	When            *Condition       `json:"when,omitempty"`             // Applies the rule only to matching text
+	MaxCount        int              `json:"max_count,omitempty"`        // Replace only the first N matches (0 = all)
+	StopAfterMatch  bool             `json:"stop_after_match,omitempty"` // Skip the profile's remaining rules once this rule changed the text
+	LineSafe        bool             `json:"line_safe,omitempty"`        // The rule never matches across line breaks, so large clipboards may be processed in chunks of lines
+	CaptureAs       string           `json:"capture_as,omitempty"`       // Stores the first match in a variable later rules use as {{var:name}}
+	Tests           []RuleTest       `json:"tests,omitempty"`            // Examples checked by "clipregex test" and "Run Rule Tests"
+
+	Bidirectional []BidirectionalPair `json:"bidirectional,omitempty"` // Exact original <-> replacement pairs used instead of regex, reversed by the reverse hotkey
+	Numbered      bool                `json:"numbered,omitempty"`      // Numbers replace_with per distinct match, e.g. [EMAIL_1], [EMAIL_2], so the reverse hotkey restores each
+
+	Transformer        string            `json:"transformer,omitempty"`         // Custom transformer compiled in with clipboard.RegisterTransformer, used like an action
+	TransformerOptions map[string]string `json:"transformer_options,omitempty"` // Settings passed to the transformer
+
+	LLM *LLMStep `json:"llm,omitempty"` // Asks the model at llm.endpoint and uses its answer, like an action
+
+	Numbers *NumberFormat `json:"numbers,omitempty"` // Separators and units of the number and unit actions
+
+	Disabled    bool   `json:"disabled,omitempty"`    // The rule is skipped; toggled in the tray's Profiles submenu
+	Description string `json:"description,omitempty"` // Shown in the tray instead of the regex
+
+	secretScope []string // The secrets_scope of the profile the rule was resolved for, see ResolveProfile
+	snippet     bool     // Generated from the profile's snippets by ResolveProfile
+}
+
+// Pattern returns regex with the rule's options applied: the match mode wraps
+// it in word boundaries or line anchors, and the flag options become an inline
+// flag group, e.g. "(?is)" for case_insensitive and dotall. regex is the
+// rule's pattern, with or without secret placeholders resolved.
+func (r Replacement) Pattern(regex string) string {
+	switch r.MatchMode {
+	case MatchModeWord:
+		regex = `\b(?:` + regex + `)\b`
+	case MatchModeLine:
+		regex = `^(?:` + regex + `)$`
+	}
+
+	flags := ""
+	if r.CaseInsensitive {
+		flags += "i"
+	}
+	if r.Multiline || r.MatchMode == MatchModeLine {
+		flags += "m"
+	}
+	if r.DotAll {
+		flags += "s"
+	}
+	if flags == "" {
+		return regex
+	}
+	return "(?" + flags + ")" + regex
+}
+
+// Summary describes the rule in logs and statistics: its regex, or its action
+// for action rules that apply to the whole text.
+func (r Replacement) Summary() string {
+	if r.IsBidirectional() {
+		pairs := make([]string, len(r.Bidirectional))
+		for i, pair := range r.Bidirectional {
+			pairs[i] = pair.Original + " <-> " + pair.Replacement
+		}
+		return "bidirectional: " + strings.Join(pairs, ", ")
+	}
+	transform := "action:" + r.Action
+	if r.Script != "" {
+		transform = "script:" + r.Script
+	} else if r.Command != nil && len(r.Command.Args) > 0 {
+		transform = "command:" + r.Command.Args[0]
+	} else if r.Transformer != "" {
+		transform = "transformer:" + r.Transformer
+	} else if r.LLM != nil {
+		transform = "llm"
+		if r.LLM.Model != "" {
+			transform += ":" + r.LLM.Model
+		}
+	}
+	if !r.IsTransform() {
+		return r.Regex
+	}
+	if r.Regex == "" {
+		return transform
+	}
+	return r.Regex + " (" + transform + ")"
+}
+
+// IsTransform reports whether the rule runs an action, script, command,
+// transformer or llm step instead of replacing its matches with replace_with.
+func (r Replacement) IsTransform() bool {
+	return r.Action != "" || r.Script != "" || r.Command != nil || r.Transformer != "" || r.LLM != nil
+}
+
+// TransformerNames returns the names of the transformers compiled into the
+// build, which transformer rules may use besides plugins. It is set by the
+// clipboard package, where transformers are registered; nil skips checking
+// the names.
+var TransformerNames func() []string
+
+// ExternalCommand pipes the text through a program such as jq, prettier or
+// pandoc: the text is written to its stdin and its stdout is the result.
+type ExternalCommand struct {
+	Args           []string `json:"args"`                       // Program and arguments, e.g. ["jq", "."]
+	TimeoutMs      int      `json:"timeout_ms,omitempty"`       // Kill the program after this time
+	MaxOutputBytes int      `json:"max_output_bytes,omitempty"` // Fail if the program prints more than this
+}
+
+// GetTimeout returns the configured timeout or the default if not set.
+func (c *ExternalCommand) GetTimeout() time.Duration {
+	if c.TimeoutMs <= 0 {
+		return DefaultCommandTimeoutMs * time.Millisecond
+	}
+	return time.Duration(c.TimeoutMs) * time.Millisecond
+}
+
+// GetMaxOutputBytes returns the configured output limit or the default if not set.
+func (c *ExternalCommand) GetMaxOutputBytes() int {
+	if c.MaxOutputBytes <= 0 {
+		return DefaultCommandMaxOutputBytes
+	}
+	return c.MaxOutputBytes
+}
+
+// RuleTest is an example input of a rule and the text the rule must turn it into.
+type RuleTest struct {
+	Input  string `json:"input"`
+	Expect string `json:"expect"`
 }
 
 const DefaultKeyringService = "Clipboard Regex Replace" // Define AppName constant
@@ -60,13 +260,108 @@ const DefaultAdminNotificationLevel = "Warn"            // Define default level
 const DefaultPasteDelayMs = 400                         // Default delay before pasting
 const DefaultRevertDelayMs = 300                        // Default delay before reverting
 const DefaultRegexTimeoutMs = 5000                      // Default regex timeout (5 seconds)
+const DefaultProfileBudgetMs = 10000                    // Default time a profile may take per hotkey press (10 seconds)
 const DefaultDiffContextLines = 3                       // Default context lines in diff viewer
+const DefaultDiffGranularity = "word"                   // Default highlighting within changed lines
+const DefaultTypingDelayMs = 5                          // Default delay between simulated keystrokes
+const DefaultCopyTimeoutMs = 1000                       // Default time to wait for a copied selection
+const DefaultSequenceTimeoutMs = 2000                   // Default time to complete a hotkey sequence
+const DefaultDoublePressMs = 400                        // Default window for double-press hotkeys
+const DefaultLongPressMs = 600                          // Default hold time for long-press hotkeys
+const DefaultNotificationThrottleSeconds = 30           // Default window for aggregating replacement notifications
+const DefaultCommandTimeoutMs = 5000                    // Default time limit for external command rules
+const DefaultCommandMaxOutputBytes = 10 * 1024 * 1024   // Default output limit for external command rules (10 MiB)
+const DefaultLargeClipboardBytes = 1024 * 1024          // Default size from which a clipboard counts as large (1 MiB)
+const DefaultMaxClipboardBytes = 50 * 1024 * 1024       // Default size above which processing asks first (50 MiB)
+const DefaultOversizeAction = OversizeAsk               // Default handling of clipboards over the limits
+const DefaultUndoDepth = 20                             // Default number of changes that can be undone
+const DefaultConfigBackups = 10                         // Default number of config backups kept
+const DefaultUpdateCheck = UpdateCheckNotify            // Default update check mode
+const DefaultSecretStore = SecretStoreAuto              // Default store for "managed" secrets
+const DefaultKeyringBackend = KeyringBackendAuto        // Default OS keyring backend for "managed" secrets
+const DefaultStatsRollover = StatsRolloverMonthly       // Default statistics rollover
+const DefaultLockKeys = LockKeysIgnore                  // Default handling of NumLock and CapsLock by hotkeys
+const DefaultClipboardWatch = ClipboardWatchAuto        // Default clipboard watching
+
+// Output modes for delivering transformed text to the focused application
+const (
+	OutputModePaste     = "paste"      // Write to clipboard and simulate Ctrl+V (default)
+	OutputModeType      = "type"       // Write to clipboard and type the text key by key
+	OutputModePlainText = "plain_text" // Write only the text to the clipboard, dropping its formatting, and paste
+)
+
+// Extract formats of profiles that collect matches instead of replacing them
+const (
+	ExtractLines = "lines" // One match per line
+	ExtractJSON  = "json"  // A JSON array of the matches
+)
+
+// Update check modes
+const (
+	UpdateCheckNotify = "notify" // Check for new releases at startup and daily, and notify
+	UpdateCheckOff    = "off"    // Only check when "Check for Updates..." is clicked
+)
+
+// Handling of clipboards over max_clipboard_bytes or max_clipboard_lines
+const (
+	OversizeAsk  = "ask"  // Ask whether to process it anyway
+	OversizeSkip = "skip" // Leave it alone and warn
+)
+
+// Clipboard watching modes: whether copied text is processed by the enabled
+// profiles right away, without pasting
+const (
+	ClipboardWatchAuto = "auto" // Only while no hotkey backend works, e.g. on Wayland (default)
+	ClipboardWatchOn   = "on"   // Always
+	ClipboardWatchOff  = "off"  // Never
+)
+
+// Statistics rollover modes
+const (
+	StatsRolloverMonthly = "monthly" // Archive the statistics of each month and start over
+	StatsRolloverOff     = "off"     // Keep counting until the statistics are reset
+)
+
+// Lock key handling of hotkeys: which of NumLock and CapsLock may be on
+// for a hotkey to trigger
+const (
+	LockKeysIgnore   = "ignore"   // Either or both (default)
+	LockKeysStrict   = "strict"   // Neither
+	LockKeysNumLock  = "numlock"  // Only NumLock
+	LockKeysCapsLock = "capslock" // Only CapsLock
+)
+
+// Match modes restricting where a rule's pattern may match
+const (
+	MatchModeAnywhere = "anywhere" // Anywhere in the text (default)
+	MatchModeWord     = "word"     // Only whole words, the pattern is wrapped in \b
+	MatchModeLine     = "line"     // Only whole lines, the pattern is anchored with ^...$ in multiline mode
+)
+
+// Case strategies of preserve_case, transferring the case of a match onto its
+// replacement
+const (
+	CaseStrategyAuto        = "auto"         // All lower, all upper or title case, otherwise the first letter (default)
+	CaseStrategyExact       = "exact"        // Letter by letter, the last letter's case continuing
+	CaseStrategyFirstLetter = "first_letter" // Only the first letter, the rest as written in replace_with
+	CaseStrategyToken       = "token"        // Word by word, e.g. "GitHub" onto "git lab" gives "Git Lab"
+	CaseStrategyIdentifier  = "identifier"   // The naming style, e.g. snake_case, kebab-case or camelCase
+)
 
 // GetConfigPath returns the path to the configuration file
 func (c *Config) GetConfigPath() string {
 	return c.configPath
 }
 
+// ResolvePath returns path relative to the directory of the configuration
+// file, unless it is absolute.
+func (c *Config) ResolvePath(path string) string {
+	if filepath.IsAbs(path) || c.configPath == "" {
+		return path
+	}
+	return filepath.Join(filepath.Dir(c.configPath), path)
+}
+
 // GetResolvedSecrets returns the map of loaded secrets.
 func (c *Config) GetResolvedSecrets() map[string]string {
 	if c.resolvedSecrets == nil {
@@ -91,6 +386,84 @@ func (c *Config) GetRevertDelay() int {
 	return c.RevertDelayMs
 }
 
+// GetRevertTimeout returns how long a stored original can be reverted, or 0
+// if it is kept until the next change
+func (c *Config) GetRevertTimeout() time.Duration {
+	if c.RevertTimeoutSeconds <= 0 {
+		return 0
+	}
+	return time.Duration(c.RevertTimeoutSeconds) * time.Second
+}
+
+// GetClearAfterSecret returns how long after a transformation involving a
+// secret the clipboard is emptied, or 0 if it is not
+func (c *Config) GetClearAfterSecret() time.Duration {
+	if c.ClearAfterSecretSeconds <= 0 {
+		return 0
+	}
+	return time.Duration(c.ClearAfterSecretSeconds) * time.Second
+}
+
+// RevertSettingsForProfile returns whether profile keeps the original
+// clipboard for reverting and whether the original is restored automatically
+// after the paste: its own settings, or the global ones.
+func (c *Config) RevertSettingsForProfile(profile ProfileConfig) (temporary, automatic bool) {
+	temporary, automatic = c.TemporaryClipboard, c.AutomaticReversion
+	if profile.TemporaryClipboard != nil {
+		temporary = *profile.TemporaryClipboard
+	}
+	if profile.AutomaticReversion != nil {
+		automatic = *profile.AutomaticReversion
+	}
+	return temporary, automatic
+}
+
+// RevertSettings merges the revert settings of the profiles named names,
+// which changed the clipboard together: a setting is on if it is on for any
+// of them. Without names, the global settings apply.
+func (c *Config) RevertSettings(names []string) (temporary, automatic bool) {
+	if len(names) == 0 {
+		return c.TemporaryClipboard, c.AutomaticReversion
+	}
+	for _, profile := range c.Profiles {
+		if !slices.Contains(names, profile.Name) {
+			continue
+		}
+		t, a := c.RevertSettingsForProfile(profile)
+		temporary = temporary || t
+		automatic = automatic || a
+	}
+	return temporary, automatic
+}
+
+// KeepsOriginals reports whether any profile keeps the original clipboard,
+// so "Revert to Original" is shown.
+func (c *Config) KeepsOriginals() bool {
+	if len(c.Profiles) == 0 {
+		return c.TemporaryClipboard
+	}
+	for _, profile := range c.Profiles {
+		if temporary, _ := c.RevertSettingsForProfile(profile); temporary {
+			return true
+		}
+	}
+	return false
+}
+
+// ManualRevertPossible reports whether any profile keeps the original
+// clipboard without restoring it automatically, so revert_hotkey is needed.
+func (c *Config) ManualRevertPossible() bool {
+	if len(c.Profiles) == 0 {
+		return c.TemporaryClipboard && !c.AutomaticReversion
+	}
+	for _, profile := range c.Profiles {
+		if temporary, automatic := c.RevertSettingsForProfile(profile); temporary && !automatic {
+			return true
+		}
+	}
+	return false
+}
+
 // GetRegexTimeout returns the configured regex timeout or default if not set
 func (c *Config) GetRegexTimeout() int {
 	if c.RegexTimeoutMs <= 0 {
@@ -99,6 +472,72 @@ func (c *Config) GetRegexTimeout() int {
 	return c.RegexTimeoutMs
 }
 
+// GetProfileBudget returns the time profile may take per hotkey press, or 0
+// if its time is not limited. llm rules wait for a server, so their timeout
+// is added.
+func (c *Config) GetProfileBudget(profile ProfileConfig) time.Duration {
+	ms := c.ProfileBudgetMs
+	if profile.BudgetMs != 0 {
+		ms = profile.BudgetMs
+	}
+	if ms == 0 {
+		ms = DefaultProfileBudgetMs
+	}
+	if ms < 0 {
+		return 0
+	}
+	budget := time.Duration(ms) * time.Millisecond
+	for _, rep := range c.ResolveProfile(profile).Replacements {
+		if rep.LLM != nil {
+			budget += c.GetLLMTimeout()
+		}
+	}
+	return budget
+}
+
+// GetLargeClipboardBytes returns the configured large clipboard threshold or default if not set
+func (c *Config) GetLargeClipboardBytes() int {
+	if c.LargeClipboardBytes <= 0 {
+		return DefaultLargeClipboardBytes
+	}
+	return c.LargeClipboardBytes
+}
+
+// GetMaxClipboardBytes returns the configured clipboard size limit, the
+// default if not set, or 0 if disabled.
+func (c *Config) GetMaxClipboardBytes() int {
+	if c.MaxClipboardBytes < 0 {
+		return 0
+	}
+	if c.MaxClipboardBytes == 0 {
+		return DefaultMaxClipboardBytes
+	}
+	return c.MaxClipboardBytes
+}
+
+// GetMaxClipboardLines returns the configured line limit, or 0 for none.
+func (c *Config) GetMaxClipboardLines() int {
+	return max(c.MaxClipboardLines, 0)
+}
+
+// GetOversizeAction returns the configured handling of oversized clipboards or default if not set
+func (c *Config) GetOversizeAction() string {
+	action := strings.ToLower(strings.TrimSpace(c.OversizeAction))
+	if action == "" {
+		return DefaultOversizeAction
+	}
+	return action
+}
+
+// GetClipboardWatch returns the configured clipboard watching or default if not set
+func (c *Config) GetClipboardWatch() string {
+	mode := strings.ToLower(strings.TrimSpace(c.ClipboardWatch))
+	if mode == "" {
+		return DefaultClipboardWatch
+	}
+	return mode
+}
+
 // GetDiffContextLines returns the configured diff context lines or default if not set
 func (c *Config) GetDiffContextLines() int {
 	if c.DiffContextLines <= 0 {
@@ -107,6 +546,143 @@ func (c *Config) GetDiffContextLines() int {
 	return c.DiffContextLines
 }
 
+// GetDiffGranularity returns the configured diff granularity or default if not set
+func (c *Config) GetDiffGranularity() string {
+	granularity := strings.ToLower(strings.TrimSpace(c.DiffGranularity))
+	if granularity == "" {
+		return DefaultDiffGranularity
+	}
+	return granularity
+}
+
+// GetUpdateCheck returns the configured update check mode or default if not set
+func (c *Config) GetUpdateCheck() string {
+	mode := strings.ToLower(strings.TrimSpace(c.UpdateCheck))
+	if mode == "" {
+		return DefaultUpdateCheck
+	}
+	return mode
+}
+
+// GetCaseLocale returns the locale whose case rules rep uses: its own
+// case_locale, else the global one, else "" for Unicode's defaults.
+func (c *Config) GetCaseLocale(rep Replacement) string {
+	if locale := strings.TrimSpace(rep.CaseLocale); locale != "" {
+		return locale
+	}
+	return strings.TrimSpace(c.CaseLocale)
+}
+
+// GetStatsRollover returns the configured statistics rollover or default if not set
+func (c *Config) GetStatsRollover() string {
+	mode := strings.ToLower(strings.TrimSpace(c.StatsRollover))
+	if mode == "" {
+		return DefaultStatsRollover
+	}
+	return mode
+}
+
+// GetSecretStore returns the configured store for "managed" secrets or default if not set
+func (c *Config) GetSecretStore() string {
+	mode := strings.ToLower(strings.TrimSpace(c.SecretStore))
+	if mode == "" {
+		return DefaultSecretStore
+	}
+	return mode
+}
+
+// GetTypingDelay returns the configured per-character typing delay or default if not set
+func (c *Config) GetTypingDelay() int {
+	if c.TypingDelayMs <= 0 {
+		return DefaultTypingDelayMs
+	}
+	return c.TypingDelayMs
+}
+
+// GetCopyTimeout returns how long copy_selection waits for the selection or default if not set
+func (c *Config) GetCopyTimeout() int {
+	if c.CopyTimeoutMs <= 0 {
+		return DefaultCopyTimeoutMs
+	}
+	return c.CopyTimeoutMs
+}
+
+// GetSequenceTimeout returns how long a hotkey sequence waits for its second key or default if not set
+func (c *Config) GetSequenceTimeout() int {
+	if c.SequenceTimeoutMs <= 0 {
+		return DefaultSequenceTimeoutMs
+	}
+	return c.SequenceTimeoutMs
+}
+
+// GetDoublePressWindow returns the max time between the presses of a double-press hotkey or default if not set
+func (c *Config) GetDoublePressWindow() int {
+	if c.DoublePressMs <= 0 {
+		return DefaultDoublePressMs
+	}
+	return c.DoublePressMs
+}
+
+// GetLongPressTime returns how long a long-press hotkey must be held or default if not set
+func (c *Config) GetLongPressTime() int {
+	if c.LongPressMs <= 0 {
+		return DefaultLongPressMs
+	}
+	return c.LongPressMs
+}
+
+// GetLockKeys returns the lock key handling of hotkeyStr, written as in its
+// hotkey option, from hotkey_lock_keys or else lock_keys. same reports
+// whether two hotkey strings are the same combination.
+func (c *Config) GetLockKeys(hotkeyStr string, same func(a, b string) bool) string {
+	for key, mode := range c.HotkeyLockKeys {
+		if same(key, hotkeyStr) {
+			return strings.ToLower(strings.TrimSpace(mode))
+		}
+	}
+	mode := strings.ToLower(strings.TrimSpace(c.LockKeys))
+	if mode == "" {
+		return DefaultLockKeys
+	}
+	return mode
+}
+
+// GetNotificationThrottle returns the replacement notification throttle window in seconds,
+// the default if not set, or 0 if throttling is disabled (negative value)
+func (c *Config) GetNotificationThrottle() int {
+	if c.NotificationThrottleSeconds < 0 {
+		return 0
+	}
+	if c.NotificationThrottleSeconds == 0 {
+		return DefaultNotificationThrottleSeconds
+	}
+	return c.NotificationThrottleSeconds
+}
+
+// GetUndoDepth returns the number of changes that can be undone, the default
+// if not set, or 0 if undoing is disabled (negative value)
+func (c *Config) GetUndoDepth() int {
+	if c.UndoDepth < 0 {
+		return 0
+	}
+	if c.UndoDepth == 0 {
+		return DefaultUndoDepth
+	}
+	return c.UndoDepth
+}
+
+// GetConfigBackups returns the number of config backups to keep,
+// the default if not set, or 0 if backups are disabled (negative value)
+func (c *Config) GetConfigBackups() int {
+	if c.ConfigBackups < 0 {
+		return 0
+	}
+	if c.ConfigBackups == 0 {
+		return DefaultConfigBackups
+	}
+	return c.ConfigBackups
+}
+
 // Load reads and parses the configuration file with backward compatibility and loads secrets
 func Load(configPath string) (*Config, error) {
 	var config Config
@@ -131,75 +707,19 @@ func Load(configPath string) (*Config, error) {
 	}
 
 	// First unmarshal into the new structure
-	err = json.Unmarshal(data, &config)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse config file '%s': %w", configPath, err)
+	if err := decodeConfig(data, configPath, &config); err != nil {
+		return nil, err
 	}
 
-	// Set default notification level if missing or empty after load
-	if strings.TrimSpace(config.AdminNotificationLevel) == "" {
-		log.Printf("AdminNotificationLevel not found or empty in config, setting default to '%s'", DefaultAdminNotificationLevel)
-		config.AdminNotificationLevel = DefaultAdminNotificationLevel
-		// Note: NotifyOnReplacement defaults to `false` (its zero value) if missing.
-		// This requires users to explicitly add `"notify_on_replacement": true`
-		// to their config if upgrading to re-enable replacement notifications.
-	}
-
-	// Store config path for future saves
-	config.configPath = configPath
-	config.keyringService = DefaultKeyringService // Assign keyring service name
-
 	// --- Load Secrets ---
-	config.resolvedSecrets = make(map[string]string)
-	if config.Secrets != nil && len(config.Secrets) > 0 { // Check if map exists and is not empty
-		log.Printf("Loading secrets from keyring for service '%s'...", config.keyringService)
-		allowedBackends := []keyring.BackendType{ // Explicitly allow backends (optional but good practice)
-			keyring.KeychainBackend,
-			keyring.SecretServiceBackend,
-			keyring.WinCredBackend,
-			// keyring.KWalletBackend, // Enable if needed
-			// keyring.PassBackend, // Enable if needed
-		}
-		kr, err := keyring.Open(keyring.Config{
-			ServiceName:              config.keyringService,
-			AllowedBackends:          allowedBackends,
-			LibSecretCollectionName:  "login",               // Common on Linux, adjust if needed
-			PassDir:                  "",                    // Path to pass directory if using PassBackend
-			PassCmd:                  "",                    // Path to pass command if using PassBackend
-			PassPrefix:               config.keyringService, // Prefix for pass entries
-			WinCredPrefix:            config.keyringService, // Prefix for Windows Credential Manager entries
-			KeychainName:             "",                    // Specific keychain name on macOS (usually empty)
-			KeychainTrustApplication: true,                  // Allow access without prompt if app is trusted
-			// KWalletAppID:             config.keyringService, // Set if using KWallet
-			// KWalletFolder:            "", // Set if using KWallet
-		})
-
-		if err != nil {
-			log.Printf("Warning: Failed to open keyring for service '%s': %v. Secrets will not be loaded.", config.keyringService, err)
-			// Continue without secrets? Or return error? For now, continue with warning.
-		} else {
-			for name := range config.Secrets { // We only need the name from config
-				item, err := kr.Get(name) // Get the Item struct
-				if err == nil {
-					config.resolvedSecrets[name] = string(item.Data) // Convert []byte to string
-					log.Printf("Successfully loaded secret '%s'.", name)
-				} else if err == keyring.ErrKeyNotFound {
-					log.Printf("Warning: Secret '%s' not found in keychain for service '%s'. Rules using it may fail.", name, config.keyringService)
-				} else {
-					log.Printf("Error retrieving secret '%s' from keychain: %v", name, err)
-					// Potentially return error here? Or just warn? Warn for now.
-				}
-			}
-		}
-	} else {
-		log.Println("No secrets defined in config.json, skipping keyring load.")
-	}
+	config.resolvedSecrets = config.loadSecrets()
 	// --- End Load Secrets ---
 
 	// --- Validate Configuration ---
 	if err := validateConfig(&config); err != nil {
 		return nil, fmt.Errorf("config validation failed: %w", err)
 	}
+	config.NormalizeProfileGroups()
 	// --- End Validate Configuration ---
 
 	// Handle backward compatibility - migrate from legacy format to profiles
@@ -230,6 +750,62 @@ func Load(configPath string) (*Config, error) {
 	return &config, nil
 }
 
+// decodeConfig unmarshals config data and applies load-time defaults.
+func decodeConfig(data []byte, configPath string, config *Config) error {
+	if err := json.Unmarshal(data, config); err != nil {
+		return fmt.Errorf("failed to parse config file '%s': %w", configPath, err)
+	}
+
+	// Set default notification level if missing or empty after load
+	if strings.TrimSpace(config.AdminNotificationLevel) == "" {
+		log.Printf("AdminNotificationLevel not found or empty in config, setting default to '%s'", DefaultAdminNotificationLevel)
+		config.AdminNotificationLevel = DefaultAdminNotificationLevel
+		// Note: NotifyOnReplacement defaults to `false` (its zero value) if missing.
+		// This requires users to explicitly add `"notify_on_replacement": true`
+		// to their config if upgrading to re-enable replacement notifications.
+	}
+
+	// Store config path for future saves
+	config.configPath = configPath
+	config.keyringService = DefaultKeyringService // Assign keyring service name
+	config.resolvedSecrets = make(map[string]string)
+	return nil
+}
+
+// LoadWithoutSecrets reads and validates the configuration file without
+// touching the OS keyring or creating a default file. Used by the command
+// line tools, which must not trigger keyring prompts.
+func LoadWithoutSecrets(configPath string) (*Config, error) {
+	var config Config
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file '%s': %w", configPath, err)
+	}
+	if err := decodeConfig(data, configPath, &config); err != nil {
+		return nil, err
+	}
+	if err := validateConfig(&config); err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+
+	// Present legacy configs as a single profile, but leave the file untouched
+	if config.Hotkey != "" && len(config.Replacements) > 0 && len(config.Profiles) == 0 {
+		config.Profiles = []ProfileConfig{
+			{
+				Name:         "Default",
+				Enabled:      true,
+				Hotkey:       config.Hotkey,
+				Replacements: config.Replacements,
+			},
+		}
+		config.Hotkey = ""
+		config.Replacements = nil
+	}
+
+	return &config, nil
+}
+
 // Save writes the current configuration back to the config.json file
 func (c *Config) Save() error {
 	// Ensure Secrets map exists even if empty for consistent JSON output
@@ -247,67 +823,50 @@ func (c *Config) Save() error {
 		return err
 	}
 
+	// Keep the previous version, then replace the file in one step so a crash
+	// never leaves a half-written config.json behind
+	if err := backupConfigFile(c.configPath, data, c.GetConfigBackups()); err != nil {
+		log.Printf("Warning: Could not back up config file '%s': %v", c.configPath, err)
+	}
+
 	// Use 0600 permissions for potentially sensitive config file?
 	// 0644 is readable by everyone, 0600 is only owner. Let's use 0600.
-	return os.WriteFile(c.configPath, data, 0600)
+	return writeFileAtomic(c.configPath, data, 0600)
 }
 
 // AddSecretReference adds/updates a secret reference in config and stores the value in keyring
 func (c *Config) AddSecretReference(name, value string) error {
-	// Use specific config matching Load for keyring access
-	kr, err := keyring.Open(keyring.Config{
-		ServiceName:              c.keyringService,
-		LibSecretCollectionName:  "login",
-		PassPrefix:               c.keyringService,
-		WinCredPrefix:            c.keyringService,
-		KeychainTrustApplication: true,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to open keyring for service '%s': %w", c.keyringService, err)
-	}
-
-	err = kr.Set(keyring.Item{
-		Key:         name, // Use logical name as the Key/Username
-		Data:        []byte(value),
-		Label:       fmt.Sprintf("Secret for %s used by %s", name, c.keyringService),
-		Description: "Managed by Clipboard Regex Replace", // Updated description
-	})
-	if err != nil {
-		return fmt.Errorf("failed to store secret '%s' in keyring: %w", name, err)
+	store := c.newKeyringProvider()
+	if err := store.Store(name, value); err != nil {
+		return fmt.Errorf("failed to store secret '%s' in %s: %w", name, store.Name(), err)
 	}
 
 	if c.Secrets == nil {
 		c.Secrets = make(map[string]string)
 	}
-	c.Secrets[name] = "managed" // Mark as managed in config
+	c.Secrets[name] = SecretManaged // Mark as managed in config
 
 	// Only save the updated config (with the "managed" entry)
-	// The actual secret value will be loaded during the next config reload.
+	// The actual secret value is loaded by the next ResolveSecrets or config reload.
 	return c.Save()
 }
 
-// RemoveSecretReference removes a secret from config and keyring
+// RemoveSecretReference removes a secret from config and, if it is stored
+// there, from the keyring. Secrets of external managers are left untouched.
 func (c *Config) RemoveSecretReference(name string) error {
-	// Use specific config matching Load for keyring access
-	kr, err := keyring.Open(keyring.Config{
-		ServiceName:              c.keyringService,
-		LibSecretCollectionName:  "login",
-		PassPrefix:               c.keyringService,
-		WinCredPrefix:            c.keyringService,
-		KeychainTrustApplication: true,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to open keyring for service '%s': %w", c.keyringService, err)
-	}
-
-	err = kr.Remove(name)
-	// Log warning if not found, but proceed to remove from config anyway
-	if err != nil && err != keyring.ErrKeyNotFound {
-		log.Printf("Warning: Failed to delete secret '%s' from keyring (it might not exist or access denied): %v", name, err)
-	} else if err == nil {
-		log.Printf("Successfully deleted secret '%s' from keyring.", name)
-	} else if err == keyring.ErrKeyNotFound {
-		log.Printf("Secret '%s' was not found in keyring (already deleted or never existed). Removing from config.", name)
+	provider, err := c.secretProvider(c.Secrets[name])
+	if store, ok := provider.(SecretStore); err == nil && ok {
+		err := store.Delete(name)
+		// Log warning if not found, but proceed to remove from config anyway
+		if errors.Is(err, ErrSecretNotFound) {
+			log.Printf("Secret '%s' was not found in keyring (already deleted or never existed). Removing from config.", name)
+		} else if err != nil {
+			log.Printf("Warning: Failed to delete secret '%s' from keyring (it might not exist or access denied): %v", name, err)
+		} else {
+			log.Printf("Successfully deleted secret '%s' from keyring.", name)
+		}
+	} else {
+		log.Printf("Secret '%s' is not stored in the keyring (%s). Removing only the reference from config.", name, c.Secrets[name])
 	}
 
 	if c.Secrets != nil {
@@ -342,6 +901,9 @@ func CreateDefaultConfig(configPath string) error {
 	}
 
 	log.Printf("Creating default configuration file at: %s", configPath)
+	if err := os.MkdirAll(filepath.Dir(configPath), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory for '%s': %w", configPath, err)
+	}
 
 	// Create default config
 	defaultConfig := &Config{
@@ -371,6 +933,14 @@ func CreateDefaultConfig(configPath string) error {
 					},
 				},
 			},
+			// Strips formatting without any rules
+			{
+				Name:         "Paste as Plain Text",
+				Enabled:      true,
+				Hotkey:       "ctrl+shift+alt+v",
+				OutputMode:   OutputModePlainText,
+				Replacements: []Replacement{},
+			},
 			// Add an example using a secret placeholder
 			{
 				Name:    "Example Secret Redaction",
@@ -393,7 +963,7 @@ func CreateDefaultConfig(configPath string) error {
 	}
 
 	// Write to file using more restrictive permissions
-	err = os.WriteFile(configPath, data, 0600) // Use 0600 permissions
+	err = writeFileAtomic(configPath, data, 0600) // Use 0600 permissions
 	if err != nil {
 		return fmt.Errorf("failed to write default config file '%s': %w", configPath, err)
 	}
@@ -412,71 +982,344 @@ func validateConfig(cfg *Config) error {
 		validationErrors = append(validationErrors, fmt.Sprintf("invalid AdminNotificationLevel '%s' (must be None, Error, Warn, or Info)", cfg.AdminNotificationLevel))
 	}
 
+	// Validate diff granularity
+	switch cfg.GetDiffGranularity() {
+	case "line", "word", "char":
+	default:
+		validationErrors = append(validationErrors, fmt.Sprintf("invalid diff_granularity '%s' (must be line, word, or char)", cfg.DiffGranularity))
+	}
+
+	// Validate update check mode
+	switch cfg.GetUpdateCheck() {
+	case UpdateCheckNotify, UpdateCheckOff:
+	default:
+		validationErrors = append(validationErrors, fmt.Sprintf("invalid update_check '%s' (must be %s or %s)", cfg.UpdateCheck, UpdateCheckNotify, UpdateCheckOff))
+	}
+
+	// Validate statistics rollover
+	switch cfg.GetStatsRollover() {
+	case StatsRolloverMonthly, StatsRolloverOff:
+	default:
+		validationErrors = append(validationErrors, fmt.Sprintf("invalid stats_rollover '%s' (must be %s or %s)", cfg.StatsRollover, StatsRolloverMonthly, StatsRolloverOff))
+	}
+
+	// Validate the locale of case conversions
+	if _, err := actions.NewCasing(strings.TrimSpace(cfg.CaseLocale)); err != nil {
+		validationErrors = append(validationErrors, fmt.Sprintf("case_locale: %v", err))
+	}
+
+	// Validate the lock key handling of hotkeys
+	lockModes := fmt.Sprintf("must be %s, %s, %s or %s", LockKeysIgnore, LockKeysStrict, LockKeysNumLock, LockKeysCapsLock)
+	validLockKeys := func(mode string) bool {
+		switch strings.ToLower(strings.TrimSpace(mode)) {
+		case LockKeysIgnore, LockKeysStrict, LockKeysNumLock, LockKeysCapsLock:
+			return true
+		}
+		return false
+	}
+	if cfg.LockKeys != "" && !validLockKeys(cfg.LockKeys) {
+		validationErrors = append(validationErrors, fmt.Sprintf("invalid lock_keys '%s' (%s)", cfg.LockKeys, lockModes))
+	}
+	lockHotkeys := make([]string, 0, len(cfg.HotkeyLockKeys))
+	for key := range cfg.HotkeyLockKeys {
+		lockHotkeys = append(lockHotkeys, key)
+	}
+	sort.Strings(lockHotkeys)
+	for _, key := range lockHotkeys {
+		switch {
+		case strings.TrimSpace(key) == "":
+			validationErrors = append(validationErrors, "hotkey_lock_keys: empty hotkey")
+		case !validLockKeys(cfg.HotkeyLockKeys[key]):
+			validationErrors = append(validationErrors, fmt.Sprintf("hotkey_lock_keys: invalid value '%s' for '%s' (%s)", cfg.HotkeyLockKeys[key], key, lockModes))
+		}
+	}
+
+	// Validate the handling of oversized clipboards
+	switch cfg.GetOversizeAction() {
+	case OversizeAsk, OversizeSkip:
+	default:
+		validationErrors = append(validationErrors, fmt.Sprintf("invalid oversize_action '%s' (must be %s or %s)", cfg.OversizeAction, OversizeAsk, OversizeSkip))
+	}
+
+	// Validate clipboard watching
+	switch cfg.GetClipboardWatch() {
+	case ClipboardWatchAuto, ClipboardWatchOn, ClipboardWatchOff:
+	default:
+		validationErrors = append(validationErrors, fmt.Sprintf("invalid clipboard_watch '%s' (must be %s, %s or %s)", cfg.ClipboardWatch, ClipboardWatchAuto, ClipboardWatchOn, ClipboardWatchOff))
+	}
+
+	// Validate secret store and sources
+	switch cfg.GetSecretStore() {
+	case SecretStoreAuto, SecretStoreKeyring, SecretStoreFile:
+	default:
+		validationErrors = append(validationErrors, fmt.Sprintf("invalid secret_store '%s' (must be %s, %s or %s)", cfg.SecretStore, SecretStoreAuto, SecretStoreKeyring, SecretStoreFile))
+	}
+	validationErrors = append(validationErrors, validateKeyringBackend(cfg)...)
+	validationErrors = append(validationErrors, validateSecretRefs(cfg)...)
+	validationErrors = append(validationErrors, validateSecretScopes(cfg)...)
+	validationErrors = append(validationErrors, validateTransformers(cfg)...)
+	validationErrors = append(validationErrors, validateLLM(cfg)...)
+	validationErrors = append(validationErrors, validateSnippets(cfg)...)
+	validationErrors = append(validationErrors, validateExcludeWindows(cfg)...)
+
 	// Validate profiles
-	if cfg.Profiles != nil {
-		profileNames := make(map[string]bool)
-		profileHotkeys := make(map[string][]string) // Track which profiles use which hotkeys
+	validationErrors = append(validationErrors, ValidateProfiles(cfg.Profiles)...)
 
-		for i, profile := range cfg.Profiles {
-			profilePrefix := fmt.Sprintf("Profile[%d](%s)", i, profile.Name)
+	// Validate rule groups and the profiles' references to them
+	validationErrors = append(validationErrors, validateRuleGroups(cfg)...)
 
-			// Check for empty profile name
-			if strings.TrimSpace(profile.Name) == "" {
-				validationErrors = append(validationErrors, fmt.Sprintf("%s: profile name cannot be empty", profilePrefix))
-			}
+	// Validate content type rules and the profiles' content types
+	validationErrors = append(validationErrors, validateContentTypes(cfg)...)
+
+	// Validate profile groups and their cycle hotkeys
+	validationErrors = append(validationErrors, validateProfileGroups(cfg)...)
 
-			// Check for duplicate profile names
-			if profileNames[profile.Name] {
-				validationErrors = append(validationErrors, fmt.Sprintf("%s: duplicate profile name", profilePrefix))
+	// Validate the tracking parameters of strip_tracking
+	validationErrors = append(validationErrors, validateTrackingParams(cfg)...)
+
+	// Return aggregated errors
+	if len(validationErrors) > 0 {
+		return fmt.Errorf("configuration validation errors:\n  - %s", strings.Join(validationErrors, "\n  - "))
+	}
+
+	log.Println("Configuration validation passed.")
+	return nil
+}
+
+// ValidateProfiles checks profile names, hotkeys and rule patterns and returns
+// one message per problem found. Duplicate hotkeys are only logged as warnings.
+func ValidateProfiles(profiles []ProfileConfig) []string {
+	var validationErrors []string
+
+	profileNames := make(map[string]bool)
+	profileHotkeys := make(map[string][]string) // Track which profiles use which hotkeys
+
+	for i, profile := range profiles {
+		profilePrefix := fmt.Sprintf("Profile[%d](%s)", i, profile.Name)
+
+		// Check for empty profile name
+		if strings.TrimSpace(profile.Name) == "" {
+			validationErrors = append(validationErrors, fmt.Sprintf("%s: profile name cannot be empty", profilePrefix))
+		}
+
+		// Check for duplicate profile names
+		if profileNames[profile.Name] {
+			validationErrors = append(validationErrors, fmt.Sprintf("%s: duplicate profile name", profilePrefix))
+		}
+		profileNames[profile.Name] = true
+
+		// Check schedule
+		if profile.Schedule != nil {
+			if err := validateSchedule(profile.Schedule); err != nil {
+				validationErrors = append(validationErrors, fmt.Sprintf("%s: invalid schedule: %v", profilePrefix, err))
 			}
-			profileNames[profile.Name] = true
-
-			// Check for empty hotkey
-			if strings.TrimSpace(profile.Hotkey) == "" {
-				validationErrors = append(validationErrors, fmt.Sprintf("%s: hotkey cannot be empty", profilePrefix))
-			} else {
-				// Track hotkey usage
-				profileHotkeys[profile.Hotkey] = append(profileHotkeys[profile.Hotkey], profile.Name)
+		}
+
+		// Check content condition
+		if profile.When != nil {
+			if err := validateCondition(profile.When); err != nil {
+				validationErrors = append(validationErrors, fmt.Sprintf("%s: invalid when: %v", profilePrefix, err))
 			}
+		}
 
-			// Validate regex patterns in replacements
+		// Check output mode
+		switch profile.OutputMode {
+		case "", OutputModePaste, OutputModeType, OutputModePlainText:
+		default:
+			validationErrors = append(validationErrors, fmt.Sprintf("%s: invalid output_mode '%s' (must be '%s', '%s' or '%s')", profilePrefix, profile.OutputMode, OutputModePaste, OutputModeType, OutputModePlainText))
+		}
+
+		// Check extract mode
+		switch profile.Extract {
+		case "":
+		case ExtractLines, ExtractJSON:
+			if profile.RoundTrip || strings.TrimSpace(profile.ReverseHotkey) != "" {
+				validationErrors = append(validationErrors, fmt.Sprintf("%s: extract profiles cannot have a reverse_hotkey or round_trip", profilePrefix))
+			}
 			for j, replacement := range profile.Replacements {
-				rulePrefix := fmt.Sprintf("%s.Replacement[%d]", profilePrefix, j)
-
-				// Validate regex pattern
-				if replacement.Regex != "" {
-					// Try to compile the regex (without resolving placeholders)
-					_, err := regexp.Compile(replacement.Regex)
-					if err != nil {
-						validationErrors = append(validationErrors, fmt.Sprintf("%s: invalid regex '%s': %v", rulePrefix, replacement.Regex, err))
-					}
+				if replacement.Regex == "" || replacement.IsTransform() || replacement.IsBidirectional() || replacement.Numbered {
+					validationErrors = append(validationErrors, fmt.Sprintf("%s.Replacement[%d]: rules of extract profiles need a regex and cannot use action, script, command, bidirectional or numbered", profilePrefix, j))
 				}
+			}
+		default:
+			validationErrors = append(validationErrors, fmt.Sprintf("%s: invalid extract '%s' (must be '%s' or '%s')", profilePrefix, profile.Extract, ExtractLines, ExtractJSON))
+		}
 
-				// Validate reverse_with if present
-				if replacement.ReverseWith != "" {
-					// Check if it's a valid regex (if used as regex in reverse mode)
-					_, err := regexp.Compile(replacement.ReverseWith)
-					if err != nil {
-						// It's okay if reverse_with is not a valid regex (it might be a literal string)
-						log.Printf("Note: %s.reverse_with '%s' is not a valid regex pattern (will be treated as literal): %v", rulePrefix, replacement.ReverseWith, err)
-					}
-				}
+		// Check for empty hotkey; profiles only run by smart_hotkey need none
+		if strings.TrimSpace(profile.Hotkey) == "" {
+			if len(profile.ContentTypes) == 0 {
+				validationErrors = append(validationErrors, fmt.Sprintf("%s: hotkey cannot be empty (unless the profile sets content_types)", profilePrefix))
 			}
+		} else {
+			// Track hotkey usage
+			profileHotkeys[profile.Hotkey] = append(profileHotkeys[profile.Hotkey], profile.Name)
+		}
+
+		if profile.RoundTrip && strings.TrimSpace(profile.ReverseHotkey) == "" {
+			log.Printf("Warning: %s sets round_trip, but has no reverse_hotkey to restore the original text with.", profilePrefix)
 		}
 
-		// Warn about duplicate hotkeys (not an error, just a warning)
-		for hotkey, profiles := range profileHotkeys {
-			if len(profiles) > 1 {
-				log.Printf("Warning: Hotkey '%s' is used by multiple profiles: %v. All matching profiles will be triggered.", hotkey, profiles)
+		// Validate regex patterns and options in replacements
+		for j, replacement := range profile.Replacements {
+			rulePrefix := fmt.Sprintf("%s.Replacement[%d]", profilePrefix, j)
+			validationErrors = append(validationErrors, validateReplacement(rulePrefix, replacement)...)
+			if strings.TrimSpace(profile.ReverseHotkey) != "" && replacement.Regex != "" && replacement.ReversesByGuess() {
+				log.Printf("Deprecated: %s has no reverse_with, so the reverse hotkey guesses the original text from regex '%s'. Make it a bidirectional rule or set reverse_with.", rulePrefix, replacement.Regex)
 			}
 		}
 	}
 
-	// Return aggregated errors
-	if len(validationErrors) > 0 {
-		return fmt.Errorf("configuration validation errors:\n  - %s", strings.Join(validationErrors, "\n  - "))
+	// Warn about duplicate hotkeys (not an error, just a warning)
+	for hotkey, profiles := range profileHotkeys {
+		if len(profiles) > 1 {
+			log.Printf("Warning: Hotkey '%s' is used by multiple profiles: %v. All matching profiles will be triggered.", hotkey, profiles)
+		}
 	}
 
-	log.Println("Configuration validation passed.")
-	return nil
-}
\ No newline at end of file
+	return validationErrors
+}
+
+// validateReplacement checks a rule's regex pattern and options and returns one
+// message per problem found.
+func validateReplacement(rulePrefix string, replacement Replacement) []string {
+	var validationErrors []string
+
+	// Validate the pairs of a bidirectional rule
+	if replacement.IsBidirectional() {
+		validationErrors = append(validationErrors, validateBidirectional(rulePrefix, replacement)...)
+	}
+
+	// Validate regex pattern
+	if replacement.Regex != "" {
+		// Try to compile the regex (without resolving placeholders)
+		_, err := regexp.Compile(replacement.Pattern(replacement.Regex))
+		if err != nil {
+			validationErrors = append(validationErrors, fmt.Sprintf("%s: invalid regex '%s': %v", rulePrefix, replacement.Regex, err))
+		}
+	}
+
+	// Validate reverse_with if present
+	if replacement.ReverseWith != "" {
+		// Check if it's a valid regex (if used as regex in reverse mode)
+		_, err := regexp.Compile(replacement.ReverseWith)
+		if err != nil {
+			// It's okay if reverse_with is not a valid regex (it might be a literal string)
+			log.Printf("Note: %s.reverse_with '%s' is not a valid regex pattern (will be treated as literal): %v", rulePrefix, replacement.ReverseWith, err)
+		}
+	}
+
+	// Validate the action
+	if replacement.Action != "" {
+		if _, ok := actions.Lookup(replacement.Action); !ok {
+			validationErrors = append(validationErrors, fmt.Sprintf("%s: unknown action '%s' (available: %s)", rulePrefix, replacement.Action, strings.Join(actions.Names(), ", ")))
+		}
+	}
+	if len(replacement.TransformerOptions) > 0 && replacement.Transformer == "" {
+		validationErrors = append(validationErrors, fmt.Sprintf("%s: transformer_options needs a transformer", rulePrefix))
+	}
+	transforms := 0
+	for _, set := range []bool{replacement.Action != "", replacement.Script != "", replacement.Command != nil, replacement.Transformer != "", replacement.LLM != nil} {
+		if set {
+			transforms++
+		}
+	}
+	if transforms > 1 {
+		validationErrors = append(validationErrors, fmt.Sprintf("%s: a rule can have only one of action, script, command, transformer and llm", rulePrefix))
+	}
+	if replacement.Command != nil {
+		if len(replacement.Command.Args) == 0 || strings.TrimSpace(replacement.Command.Args[0]) == "" {
+			validationErrors = append(validationErrors, fmt.Sprintf("%s: command.args must name the program to run", rulePrefix))
+		}
+		if replacement.Command.TimeoutMs < 0 || replacement.Command.MaxOutputBytes < 0 {
+			validationErrors = append(validationErrors, fmt.Sprintf("%s: command.timeout_ms and command.max_output_bytes cannot be negative", rulePrefix))
+		}
+	}
+	if replacement.LLM != nil && strings.TrimSpace(replacement.LLM.Prompt) == "" {
+		validationErrors = append(validationErrors, fmt.Sprintf("%s: llm.prompt must say what to do with the text", rulePrefix))
+	}
+	if replacement.IsTransform() {
+		if replacement.ReplaceWith != "" || replacement.PreserveCase || replacement.ReverseWith != "" {
+			validationErrors = append(validationErrors, fmt.Sprintf("%s: action, script, command, transformer and llm rules cannot use replace_with, preserve_case or reverse_with", rulePrefix))
+		}
+	}
+	if replacement.Script != "" && !actions.ScriptingAvailable {
+		log.Printf("Warning: %s uses script '%s', but this build has no Lua support. The rule will be skipped.", rulePrefix, replacement.Script)
+	}
+
+	// Numbered placeholders are issued per match of a regex
+	if replacement.Numbered {
+		if replacement.Regex == "" || replacement.ReplaceWith == "" || replacement.IsTransform() || replacement.IsBidirectional() {
+			validationErrors = append(validationErrors, fmt.Sprintf("%s: numbered needs a regex and a replace_with, and cannot be combined with action, script, command, transformer, llm or bidirectional", rulePrefix))
+		}
+		if replacement.PreserveCase || replacement.ReverseWith != "" || replacement.LineSafe {
+			validationErrors = append(validationErrors, fmt.Sprintf("%s: numbered cannot be combined with preserve_case, reverse_with or line_safe", rulePrefix))
+		}
+	}
+
+	// Validate the case strategy
+	switch replacement.CaseStrategy {
+	case "", CaseStrategyAuto, CaseStrategyExact, CaseStrategyFirstLetter, CaseStrategyToken, CaseStrategyIdentifier:
+		if replacement.CaseStrategy != "" && !replacement.PreserveCase {
+			validationErrors = append(validationErrors, fmt.Sprintf("%s: case_strategy needs preserve_case", rulePrefix))
+		}
+	default:
+		validationErrors = append(validationErrors, fmt.Sprintf("%s: invalid case_strategy '%s' (must be '%s', '%s', '%s', '%s' or '%s')", rulePrefix, replacement.CaseStrategy, CaseStrategyAuto, CaseStrategyExact, CaseStrategyFirstLetter, CaseStrategyToken, CaseStrategyIdentifier))
+	}
+
+	// Validate the case locale
+	if _, err := actions.NewCasing(strings.TrimSpace(replacement.CaseLocale)); err != nil {
+		validationErrors = append(validationErrors, fmt.Sprintf("%s: case_locale: %v", rulePrefix, err))
+	}
+
+	// Validate the number format
+	if _, ok, err := actions.NumberAction(replacement.Action, replacement.GetNumberFormat()); ok && err != nil {
+		validationErrors = append(validationErrors, fmt.Sprintf("%s: numbers: %v", rulePrefix, err))
+	} else if !ok && replacement.Numbers != nil {
+		validationErrors = append(validationErrors, fmt.Sprintf("%s: numbers needs the action %s, %s, %s, %s or %s", rulePrefix,
+			actions.GroupThousandsAction, actions.UngroupThousandsAction, actions.DecimalCommaAction, actions.DecimalPointAction, actions.ConvertUnitsAction))
+	}
+
+	// Validate the match mode
+	switch replacement.MatchMode {
+	case "", MatchModeAnywhere, MatchModeWord, MatchModeLine:
+	default:
+		validationErrors = append(validationErrors, fmt.Sprintf("%s: invalid match_mode '%s' (must be '%s', '%s' or '%s')", rulePrefix, replacement.MatchMode, MatchModeAnywhere, MatchModeWord, MatchModeLine))
+	}
+
+	// Validate the content condition
+	if replacement.When != nil {
+		if err := validateCondition(replacement.When); err != nil {
+			validationErrors = append(validationErrors, fmt.Sprintf("%s: invalid when: %v", rulePrefix, err))
+		}
+	}
+
+	// Validate the replacement limit
+	if replacement.MaxCount < 0 {
+		validationErrors = append(validationErrors, fmt.Sprintf("%s: max_count cannot be negative", rulePrefix))
+	}
+
+	// Chunks of a large clipboard are processed independently
+	if replacement.LineSafe {
+		if replacement.MaxCount > 0 {
+			validationErrors = append(validationErrors, fmt.Sprintf("%s: line_safe cannot be combined with max_count, which counts matches across the whole text", rulePrefix))
+		}
+		if strings.Contains(replacement.ReplaceWith, "{{"+VariableCounter) {
+			validationErrors = append(validationErrors, fmt.Sprintf("%s: line_safe cannot be combined with {{%s}}, which would count once per chunk", rulePrefix, VariableCounter))
+		}
+		if replacement.CaptureAs != "" {
+			validationErrors = append(validationErrors, fmt.Sprintf("%s: line_safe cannot be combined with capture_as, which captures the first match of the whole text", rulePrefix))
+		}
+	}
+
+	// Capture variables hold the first match of a regex
+	if replacement.CaptureAs != "" {
+		if !captureNameRegex.MatchString(replacement.CaptureAs) {
+			validationErrors = append(validationErrors, fmt.Sprintf("%s: capture_as '%s' must start with a letter or underscore and contain only letters, digits and underscores", rulePrefix, replacement.CaptureAs))
+		}
+		if replacement.Regex == "" || replacement.IsBidirectional() {
+			validationErrors = append(validationErrors, fmt.Sprintf("%s: capture_as needs a regex and cannot be combined with bidirectional", rulePrefix))
+		}
+	}
+
+	return validationErrors
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Built-in content types detected for the smart hotkey
+const (
+	ContentTypeJSON     = "json"
+	ContentTypeURL      = "url"
+	ContentTypeEmail    = "email"
+	ContentTypePath     = "path"
+	ContentTypeMarkdown = "markdown"
+	ContentTypeCode     = "code"
+	ContentTypeTable    = "table"
+	ContentTypeText     = "text" // Any text; matches when nothing more specific does
+)
+
+// BuiltinContentTypes lists the built-in content types in detection order.
+var BuiltinContentTypes = []string{
+	ContentTypeJSON, ContentTypeURL, ContentTypeEmail, ContentTypePath,
+	ContentTypeMarkdown, ContentTypeCode, ContentTypeTable, ContentTypeText,
+}
+
+// ContentTypeRule defines a content type for the smart hotkey with the fields
+// of a condition, e.g. {"name": "jira", "matches": "^[A-Z]+-\\d+$"}. Rules are
+// checked before the built-in types; a rule named like a built-in type
+// replaces its detection.
+type ContentTypeRule struct {
+	Name string `json:"name"`
+	Condition
+}
+
+// HandlesContentType reports whether the profile runs for contentType when
+// the smart hotkey is pressed.
+func (p ProfileConfig) HandlesContentType(contentType string) bool {
+	for _, name := range p.ContentTypes {
+		if strings.EqualFold(strings.TrimSpace(name), contentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsBuiltinContentType reports whether name is a built-in content type.
+func IsBuiltinContentType(name string) bool {
+	for _, builtin := range BuiltinContentTypes {
+		if name == builtin {
+			return true
+		}
+	}
+	return false
+}
+
+// validateContentTypes checks the content type rules and the content types
+// the profiles handle.
+func validateContentTypes(cfg *Config) []string {
+	var validationErrors []string
+
+	known := make(map[string]bool)
+	for _, name := range BuiltinContentTypes {
+		known[name] = true
+	}
+	defined := make(map[string]bool)
+	for i, rule := range cfg.ContentTypeRules {
+		prefix := fmt.Sprintf("content_type_rules[%d](%s)", i, rule.Name)
+		name := strings.ToLower(strings.TrimSpace(rule.Name))
+		if name == "" {
+			validationErrors = append(validationErrors, fmt.Sprintf("%s: name cannot be empty", prefix))
+			continue
+		}
+		if defined[name] {
+			validationErrors = append(validationErrors, fmt.Sprintf("%s: duplicate content type name", prefix))
+		}
+		defined[name] = true
+		known[name] = true
+		if err := validateCondition(&rule.Condition); err != nil {
+			validationErrors = append(validationErrors, fmt.Sprintf("%s: %v", prefix, err))
+		}
+	}
+
+	routed := false
+	for i, profile := range cfg.Profiles {
+		for _, name := range profile.ContentTypes {
+			routed = true
+			if !known[strings.ToLower(strings.TrimSpace(name))] {
+				validationErrors = append(validationErrors, fmt.Sprintf("Profile[%d](%s): unknown content type '%s' (built-in: %s, or define it in content_type_rules)", i, profile.Name, name, strings.Join(BuiltinContentTypes, ", ")))
+			}
+		}
+	}
+	if routed && strings.TrimSpace(cfg.SmartHotkey) == "" {
+		log.Println("Warning: Profiles set content_types, but smart_hotkey is not set, so they are never routed to.")
+	}
+	return validationErrors
+}
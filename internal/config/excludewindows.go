@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Prefixes of exclude_windows entries. An entry without one matches the
+// process name or the window title.
+const (
+	WindowProcessPrefix = "process:" // Matches the executable name, e.g. "process:keepassxc"
+	WindowTitlePrefix   = "title:"   // Matches the window title, e.g. "title:password"
+)
+
+// windowPattern is a parsed exclude_windows entry.
+type windowPattern struct {
+	process, title bool // What the pattern is matched against
+	re             *regexp.Regexp
+}
+
+// parseWindowPattern compiles an exclude_windows entry. Patterns are regular
+// expressions, matched case-insensitively anywhere in the name or title.
+func parseWindowPattern(entry string) (windowPattern, error) {
+	pattern := windowPattern{process: true, title: true}
+	expr := strings.TrimSpace(entry)
+	lower := strings.ToLower(expr)
+	switch {
+	case strings.HasPrefix(lower, WindowProcessPrefix):
+		pattern.title, expr = false, expr[len(WindowProcessPrefix):]
+	case strings.HasPrefix(lower, WindowTitlePrefix):
+		pattern.process, expr = false, expr[len(WindowTitlePrefix):]
+	}
+	if strings.TrimSpace(expr) == "" {
+		return pattern, fmt.Errorf("empty pattern")
+	}
+	re, err := regexp.Compile("(?i)" + expr)
+	if err != nil {
+		return pattern, err
+	}
+	pattern.re = re
+	return pattern, nil
+}
+
+// ExcludedWindow returns the exclude_windows entry of the profile that
+// matches the focused window, with the given process name and title, or ""
+// if none does. Invalid entries, which validation reports, match nothing.
+func (p ProfileConfig) ExcludedWindow(process, title string) string {
+	for _, entry := range p.ExcludeWindows {
+		pattern, err := parseWindowPattern(entry)
+		if err != nil {
+			continue
+		}
+		if pattern.process && process != "" && pattern.re.MatchString(process) ||
+			pattern.title && title != "" && pattern.re.MatchString(title) {
+			return entry
+		}
+	}
+	return ""
+}
+
+// validateExcludeWindows checks the patterns of the profiles' exclude_windows.
+func validateExcludeWindows(cfg *Config) []string {
+	var validationErrors []string
+	for i, profile := range cfg.Profiles {
+		for j, entry := range profile.ExcludeWindows {
+			if _, err := parseWindowPattern(entry); err != nil {
+				validationErrors = append(validationErrors, fmt.Sprintf("Profile[%d](%s).ExcludeWindows[%d]: invalid pattern '%s': %v", i, profile.Name, j, entry, err))
+			}
+		}
+	}
+	return validationErrors
+}
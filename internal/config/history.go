@@ -0,0 +1,83 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// HistoryKeyName is the secret store entry holding the key of the history
+// file. Secret names cannot contain dots, so it never clashes with a secret.
+const HistoryKeyName = "clipregex.history-key"
+
+const DefaultHistoryMaxEntries = 50   // Default number of history entries kept
+const DefaultHistoryRetentionDays = 7 // Default age after which history entries are dropped
+
+// HistoryConfig controls the history of clipboard changes. It holds the
+// original clipboard texts, so it is off by default and encrypted on disk.
+type HistoryConfig struct {
+	Enabled       bool `json:"enabled"`
+	MaxEntries    int  `json:"max_entries,omitempty"`    // Number of changes kept (default: 50, negative keeps all)
+	RetentionDays int  `json:"retention_days,omitempty"` // Days a change is kept (default: 7, negative keeps them until max_entries)
+}
+
+// HistoryEnabled reports whether the history is kept.
+func (c *Config) HistoryEnabled() bool {
+	return c.History != nil && c.History.Enabled
+}
+
+// GetHistoryMaxEntries returns the number of history entries to keep,
+// the default if not set, or 0 if there is no limit (negative value)
+func (c *Config) GetHistoryMaxEntries() int {
+	if c.History == nil || c.History.MaxEntries == 0 {
+		return DefaultHistoryMaxEntries
+	}
+	if c.History.MaxEntries < 0 {
+		return 0
+	}
+	return c.History.MaxEntries
+}
+
+// GetHistoryRetention returns how long history entries are kept,
+// the default if not set, or 0 if there is no limit (negative value)
+func (c *Config) GetHistoryRetention() time.Duration {
+	days := DefaultHistoryRetentionDays
+	if c.History != nil && c.History.RetentionDays != 0 {
+		days = c.History.RetentionDays
+	}
+	if days < 0 {
+		return 0
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// HistoryKey returns the key of the history file from the secret store
+// (the OS keyring or the encrypted secrets file), creating a random one on
+// first use.
+func (c *Config) HistoryKey() ([]byte, error) {
+	store := c.newKeyringProvider()
+	encoded, err := store.Lookup(HistoryKeyName, "")
+	if err == nil {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("history key in the %s is invalid: %w", store.Name(), err)
+		}
+		return key, nil
+	}
+	if !errors.Is(err, ErrSecretNotFound) {
+		return nil, fmt.Errorf("failed to read the history key from the %s: %w", store.Name(), err)
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to create a history key: %w", err)
+	}
+	if err := store.Store(HistoryKeyName, base64.StdEncoding.EncodeToString(key)); err != nil {
+		return nil, fmt.Errorf("failed to store the history key in the %s: %w", store.Name(), err)
+	}
+	log.Printf("Created a new history key in the %s.", store.Name())
+	return key, nil
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/99designs/keyring"
+)
+
+// OS keyring backends for "managed" secrets, as named in keyring_backend
+const (
+	KeyringBackendAuto          = "auto"           // The platform's credential store (default)
+	KeyringBackendWinCred       = "wincred"        // Windows Credential Manager
+	KeyringBackendSecretService = "secret-service" // Secret Service (GNOME Keyring, KeePassXC, KWallet 5.97+)
+	KeyringBackendKeychain      = "keychain"       // macOS Keychain
+	KeyringBackendPass          = "pass"           // The pass password manager, with its GPG key
+	KeyringBackendFile          = "file"           // The encrypted secrets file, like secret_store "file"
+)
+
+// keyringBackendNames are the names of the backends in logs and the
+// diagnostics.
+var keyringBackendNames = map[string]string{
+	KeyringBackendWinCred:       "Windows Credential Manager",
+	KeyringBackendSecretService: "Secret Service",
+	KeyringBackendKeychain:      "macOS Keychain",
+	KeyringBackendPass:          "pass",
+}
+
+// GetKeyringBackend returns the configured keyring backend or default if not set
+func (c *Config) GetKeyringBackend() string {
+	backend := strings.ToLower(strings.TrimSpace(c.KeyringBackend))
+	if backend == "" {
+		return DefaultKeyringBackend
+	}
+	return backend
+}
+
+// keyringBackendCandidates returns the backends tried for preference, in
+// order: the platform's credential stores for "auto", otherwise only the
+// preferred one.
+func keyringBackendCandidates(preference string) []keyring.BackendType {
+	if preference == KeyringBackendAuto {
+		return []keyring.BackendType{
+			keyring.KeychainBackend,
+			keyring.SecretServiceBackend,
+			keyring.WinCredBackend,
+		}
+	}
+	return []keyring.BackendType{keyring.BackendType(preference)}
+}
+
+// keyringBackendSupported reports whether backend is built into this
+// platform's keyring library, e.g. not "wincred" on Linux.
+func keyringBackendSupported(backend keyring.BackendType) bool {
+	for _, available := range keyring.AvailableBackends() {
+		if available == backend {
+			return true
+		}
+	}
+	return false
+}
+
+// validateKeyringBackend checks keyring_backend and that it doesn't
+// contradict secret_store.
+func validateKeyringBackend(cfg *Config) []string {
+	backend := cfg.GetKeyringBackend()
+	switch backend {
+	case KeyringBackendAuto, KeyringBackendFile:
+		return nil
+	case KeyringBackendWinCred, KeyringBackendSecretService, KeyringBackendKeychain, KeyringBackendPass:
+		if cfg.GetSecretStore() == SecretStoreFile {
+			return []string{fmt.Sprintf("keyring_backend '%s' is never used with secret_store '%s'", cfg.KeyringBackend, SecretStoreFile)}
+		}
+		return nil
+	}
+	return []string{fmt.Sprintf("invalid keyring_backend '%s' (must be %s, %s, %s, %s, %s or %s)", cfg.KeyringBackend,
+		KeyringBackendAuto, KeyringBackendWinCred, KeyringBackendSecretService, KeyringBackendKeychain, KeyringBackendPass, KeyringBackendFile)}
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const DefaultLLMTimeoutMs = 30000 // Default time an llm rule waits for the answer
+
+// LLMTextPlaceholder is replaced with the redacted text in the prompt of an
+// llm rule.
+const LLMTextPlaceholder = "{{text}}"
+
+// LLMConfig is the OpenAI-compatible chat completions API that llm rules
+// send the clipboard to. Sending clipboard content to a server is an explicit
+// opt-in, and the text is always redacted locally first.
+type LLMConfig struct {
+	Enabled      bool   `json:"enabled"`
+	Endpoint     string `json:"endpoint"`                 // Base URL of the API, e.g. "https://api.openai.com/v1" or "http://localhost:11434/v1"
+	Model        string `json:"model,omitempty"`          // Model of the rules that don't name one
+	APIKeySecret string `json:"api_key_secret,omitempty"` // Name of the secret holding the API key; none for servers without keys
+	RedactGroup  string `json:"redact_group"`             // Rule group applied to the text before it is sent
+	TimeoutMs    int    `json:"timeout_ms,omitempty"`     // Time to wait for the answer (default: 30000ms)
+}
+
+// LLMStep is what an llm rule asks the model.
+type LLMStep struct {
+	Prompt string `json:"prompt"`           // The request; {{text}} is replaced with the redacted text, which is appended if it is missing
+	System string `json:"system,omitempty"` // Instructions sent as the system message
+	Model  string `json:"model,omitempty"`  // Overrides llm.model
+}
+
+// LLMEnabled reports whether llm rules may send text to llm.endpoint.
+func (c *Config) LLMEnabled() bool {
+	return c.LLM != nil && c.LLM.Enabled
+}
+
+// GetLLMTimeout returns how long llm rules wait for the answer.
+func (c *Config) GetLLMTimeout() time.Duration {
+	if c.LLM == nil || c.LLM.TimeoutMs <= 0 {
+		return DefaultLLMTimeoutMs * time.Millisecond
+	}
+	return time.Duration(c.LLM.TimeoutMs) * time.Millisecond
+}
+
+// GetLLMModel returns the model an llm rule asks: its own or llm.model.
+func (c *Config) GetLLMModel(step *LLMStep) string {
+	if step != nil && strings.TrimSpace(step.Model) != "" {
+		return strings.TrimSpace(step.Model)
+	}
+	if c.LLM == nil {
+		return ""
+	}
+	return strings.TrimSpace(c.LLM.Model)
+}
+
+// llmRules returns where the llm rules of the profiles and rule groups are,
+// as prefixes for validation errors.
+func llmRules(cfg *Config) (prefixes []string, steps []*LLMStep) {
+	for i, profile := range cfg.Profiles {
+		for j, rep := range profile.Replacements {
+			if rep.LLM != nil {
+				prefixes = append(prefixes, fmt.Sprintf("Profile[%d](%s).Replacement[%d]", i, profile.Name, j))
+				steps = append(steps, rep.LLM)
+			}
+		}
+	}
+	groupNames := make([]string, 0, len(cfg.RuleGroups))
+	for name := range cfg.RuleGroups {
+		groupNames = append(groupNames, name)
+	}
+	sort.Strings(groupNames)
+	for _, name := range groupNames {
+		for j, rep := range cfg.RuleGroups[name] {
+			if rep.LLM != nil {
+				prefixes = append(prefixes, fmt.Sprintf("RuleGroup(%s).Replacement[%d]", name, j))
+				steps = append(steps, rep.LLM)
+			}
+		}
+	}
+	return prefixes, steps
+}
+
+// validateLLM checks the llm settings and that llm rules are only used once
+// they are enabled, with a model and a rule group redacting the text.
+func validateLLM(cfg *Config) []string {
+	prefixes, steps := llmRules(cfg)
+	if !cfg.LLMEnabled() {
+		if len(prefixes) > 0 {
+			return []string{fmt.Sprintf("%s: llm rules send the clipboard to a server and need llm.enabled set to true", prefixes[0])}
+		}
+		return nil
+	}
+
+	var validationErrors []string
+	llm := cfg.LLM
+	if endpoint, err := url.Parse(strings.TrimSpace(llm.Endpoint)); err != nil || endpoint.Host == "" || (endpoint.Scheme != "https" && endpoint.Scheme != "http") {
+		validationErrors = append(validationErrors, fmt.Sprintf("invalid llm.endpoint '%s' (must be an http or https URL such as https://api.openai.com/v1)", llm.Endpoint))
+	} else if endpoint.Scheme == "http" && !isLoopbackHost(endpoint.Hostname()) {
+		validationErrors = append(validationErrors, fmt.Sprintf("llm.endpoint '%s' must use https, unless the server runs on this computer", llm.Endpoint))
+	}
+	if llm.RedactGroup == "" {
+		validationErrors = append(validationErrors, "llm.redact_group must name the rule group that redacts the text before it is sent")
+	} else if group, ok := cfg.RuleGroups[llm.RedactGroup]; !ok {
+		validationErrors = append(validationErrors, fmt.Sprintf("llm.redact_group '%s' is not defined in rule_groups", llm.RedactGroup))
+	} else {
+		for j, rep := range group {
+			if rep.LLM != nil {
+				validationErrors = append(validationErrors, fmt.Sprintf("RuleGroup(%s).Replacement[%d]: the llm.redact_group cannot contain llm rules", llm.RedactGroup, j))
+			}
+		}
+	}
+	if llm.APIKeySecret != "" {
+		if _, declared := cfg.Secrets[llm.APIKeySecret]; !declared {
+			validationErrors = append(validationErrors, fmt.Sprintf("llm.api_key_secret '%s' is not declared in secrets", llm.APIKeySecret))
+		}
+	}
+	if llm.TimeoutMs < 0 {
+		validationErrors = append(validationErrors, "llm.timeout_ms cannot be negative")
+	}
+	for i, step := range steps {
+		if cfg.GetLLMModel(step) == "" {
+			validationErrors = append(validationErrors, fmt.Sprintf("%s: llm rule has no model; set llm.model, or model in the rule's llm", prefixes[i]))
+		}
+	}
+	return validationErrors
+}
+
+// isLoopbackHost reports whether host is this computer.
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,27 @@
+package config
+
+import "github.com/TanaroSch/clipboard-regex-replace/internal/actions"
+
+// NumberFormat sets the separators and units of a rule's number and unit
+// actions, such as group_thousands and convert_units.
+type NumberFormat struct {
+	Decimal      string            `json:"decimal,omitempty"`       // Decimal separator written: "." or ","
+	Thousands    string            `json:"thousands,omitempty"`     // Thousands separator written: ",", ".", "'", "’", "_", a no-break or thin space, or "none"
+	InputDecimal string            `json:"input_decimal,omitempty"` // Decimal separator of the copied numbers: "." or ","
+	Units        map[string]string `json:"units,omitempty"`         // convert_units: unit → unit to convert it to, e.g. "km": "mi"
+	Precision    *int              `json:"precision,omitempty"`     // convert_units: most decimal places written (default 2)
+}
+
+// GetNumberFormat returns the settings of the rule's number or unit action;
+// unset separators are left to the action's defaults.
+func (r Replacement) GetNumberFormat() actions.NumberFormat {
+	f := actions.NumberFormat{Precision: actions.DefaultNumberPrecision}
+	if r.Numbers == nil {
+		return f
+	}
+	f.Decimal, f.Thousands, f.InputDecimal, f.Units = r.Numbers.Decimal, r.Numbers.Thousands, r.Numbers.InputDecimal, r.Numbers.Units
+	if r.Numbers.Precision != nil {
+		f.Precision = *r.Numbers.Precision
+	}
+	return f
+}
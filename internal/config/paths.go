@@ -0,0 +1,218 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// FileName is the name of the configuration file.
+const FileName = "config.json"
+
+// PortableMarker is the file next to the executable that enables portable
+// mode, like the --portable flag.
+const PortableMarker = "portable"
+
+// appDirName returns the name of the application's folder in the user's
+// config directory: "clipregex" on Linux and other Unix systems (following
+// XDG naming), "ClipboardRegexReplace" on Windows and macOS.
+func appDirName() string {
+	switch runtime.GOOS {
+	case "windows", "darwin":
+		return "ClipboardRegexReplace"
+	}
+	return "clipregex"
+}
+
+// DefaultConfigPath returns the standard location of config.json:
+// %APPDATA%\ClipboardRegexReplace on Windows, ~/Library/Application Support/
+// ClipboardRegexReplace on macOS and $XDG_CONFIG_HOME/clipregex (usually
+// ~/.config/clipregex) elsewhere.
+func DefaultConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, appDirName(), FileName), nil
+}
+
+// executableDir returns the folder of the running executable, with symlinks
+// resolved.
+func executableDir() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	return filepath.Dir(exe), nil
+}
+
+// PortableDir returns the folder of the executable if the application runs
+// in portable mode, because force (--portable) is set or a PortableMarker
+// file exists next to the executable. In portable mode the config file and
+// everything stored next to it stay in that folder.
+func PortableDir(force bool) (string, bool) {
+	dir, err := executableDir()
+	if err != nil {
+		if force {
+			log.Printf("Warning: Portable mode requested, but the executable's folder is unknown: %v", err)
+		}
+		return "", false
+	}
+	if force {
+		return dir, true
+	}
+	if _, err := os.Stat(filepath.Join(dir, PortableMarker)); err == nil {
+		return dir, true
+	}
+	return "", false
+}
+
+// ResolveConfigPath returns the config file to use. An explicit path (from
+// --config) is used as is. In portable mode (see PortableDir) config.json
+// next to the executable is used. Otherwise the standard location is used,
+// and a config.json found in the working directory or next to the
+// executable, where earlier versions kept it, is copied there first.
+// migratedFrom is the path of the copied file, or "" if nothing was migrated.
+func ResolveConfigPath(explicit string, portable bool) (configPath, migratedFrom string, err error) {
+	if strings.TrimSpace(explicit) != "" {
+		configPath, err = filepath.Abs(explicit)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid config path '%s': %w", explicit, err)
+		}
+		return configPath, "", nil
+	}
+
+	if dir, ok := PortableDir(portable); ok {
+		return filepath.Join(dir, FileName), "", nil
+	}
+
+	configPath, err = DefaultConfigPath()
+	if err != nil {
+		log.Printf("Warning: Could not determine the user config directory (%v). Using %s in the working directory.", err, FileName)
+		configPath, err = filepath.Abs(FileName)
+		return configPath, "", err
+	}
+
+	migratedFrom, err = migrateLegacyConfig(configPath)
+	if err != nil {
+		return "", "", err
+	}
+	return configPath, migratedFrom, nil
+}
+
+// legacyConfigPaths lists where earlier versions looked for config.json.
+func legacyConfigPaths() []string {
+	var paths []string
+	if wd, err := os.Getwd(); err == nil {
+		paths = append(paths, filepath.Join(wd, FileName))
+	}
+	if dir, err := executableDir(); err == nil {
+		paths = append(paths, filepath.Join(dir, FileName))
+	}
+	return paths
+}
+
+// migrateLegacyConfig copies the first legacy config.json to configPath if
+// there is no file at configPath yet, together with the statistics and the
+// Lua scripts its rules reference by relative path. The legacy files are left
+// in place. It returns the path of the copied config, or "".
+func migrateLegacyConfig(configPath string) (string, error) {
+	if _, err := os.Stat(configPath); err == nil || !os.IsNotExist(err) {
+		return "", nil // Already there, or not accessible; Load reports the latter
+	}
+
+	for _, legacy := range legacyConfigPaths() {
+		if filepath.Clean(legacy) == filepath.Clean(configPath) {
+			continue
+		}
+		data, err := os.ReadFile(legacy)
+		if err != nil {
+			continue
+		}
+
+		log.Printf("Migrating configuration from '%s' to '%s'.", legacy, configPath)
+		if err := os.MkdirAll(filepath.Dir(configPath), 0700); err != nil {
+			return "", fmt.Errorf("failed to create config directory for '%s': %w", configPath, err)
+		}
+		if err := writeFileAtomic(configPath, data, 0600); err != nil {
+			return "", fmt.Errorf("failed to migrate config file '%s' to '%s': %w", legacy, configPath, err)
+		}
+
+		var cfg Config
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			log.Printf("Warning: Could not parse migrated config to find referenced files: %v", err)
+		}
+		oldDir, newDir := filepath.Dir(legacy), filepath.Dir(configPath)
+		for _, rel := range append(cfg.relativeScripts(), "stats.json") { // stats.FileName
+			copyMigratedFile(oldDir, newDir, rel)
+		}
+		for _, profile := range cfg.Profiles {
+			if profile.Schedule != nil && profile.Schedule.StateFile != "" && !filepath.IsAbs(profile.Schedule.StateFile) {
+				// Written by another program, so copying it would only freeze its state
+				log.Printf("Warning: Profile '%s' reads state_file '%s' relative to the config file, which now resolves to '%s'. Use an absolute path.",
+					profile.Name, profile.Schedule.StateFile, filepath.Join(newDir, profile.Schedule.StateFile))
+			}
+		}
+		return legacy, nil
+	}
+	return "", nil
+}
+
+// relativeScripts returns the relative script paths used by the rules, which
+// are resolved against the folder of config.json.
+func (c *Config) relativeScripts() []string {
+	var files []string
+	addRules := func(rules []Replacement) {
+		for _, rule := range rules {
+			if rule.Script != "" && !filepath.IsAbs(rule.Script) {
+				files = append(files, rule.Script)
+			}
+		}
+	}
+	addRules(c.Replacements)
+	for _, rules := range c.RuleGroups {
+		addRules(rules)
+	}
+	for _, profile := range c.Profiles {
+		addRules(profile.Replacements)
+	}
+	return files
+}
+
+// copyMigratedFile copies oldDir/rel to newDir/rel unless it is missing,
+// outside oldDir or already exists at the destination. Failures are logged,
+// since the config itself has been migrated already.
+func copyMigratedFile(oldDir, newDir, rel string) {
+	rel = filepath.Clean(rel)
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		log.Printf("Warning: '%s' is outside the old config folder and was not migrated. Use an absolute path in config.json.", rel)
+		return
+	}
+	src, dst := filepath.Join(oldDir, rel), filepath.Join(newDir, rel)
+	if _, err := os.Stat(dst); err == nil {
+		return
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: Could not read '%s' for migration: %v", src, err)
+		}
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		log.Printf("Warning: Could not create folder for '%s': %v", dst, err)
+		return
+	}
+	if err := writeFileAtomic(dst, data, 0600); err != nil {
+		log.Printf("Warning: Could not migrate '%s' to '%s': %v", src, dst, err)
+		return
+	}
+	log.Printf("Migrated '%s' to '%s'.", src, dst)
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// PluginsDirName is the folder next to config.json holding plugin executables.
+const PluginsDirName = "plugins"
+
+// PluginsDir returns the plugins folder of the config at configPath.
+func PluginsDir(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), PluginsDirName)
+}
+
+// Plugins maps the names of the transformers in the plugins folder to their
+// executables. A plugin is named after its file without the extension, e.g.
+// "acme-tokenizer" for plugins/acme-tokenizer.exe. Hidden files and files
+// that can't be run are ignored.
+func (c *Config) Plugins() map[string]string {
+	plugins := make(map[string]string)
+	if c.configPath == "" {
+		return plugins
+	}
+	dir := PluginsDir(c.configPath)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return plugins // Usually there is no plugins folder
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !isExecutable(info) {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		plugins[name] = filepath.Join(dir, entry.Name())
+	}
+	return plugins
+}
+
+// isExecutable reports whether the file can be run as a plugin: by its
+// extension on Windows and its permissions elsewhere.
+func isExecutable(info os.FileInfo) bool {
+	if runtime.GOOS == "windows" {
+		switch strings.ToLower(filepath.Ext(info.Name())) {
+		case ".exe", ".com", ".bat", ".cmd":
+			return true
+		}
+		return false
+	}
+	return info.Mode().IsRegular() && info.Mode().Perm()&0o111 != 0
+}
+
+// validateTransformers checks that the transformer rules of the profiles and
+// rule groups name a transformer compiled into the build or a plugin.
+func validateTransformers(cfg *Config) []string {
+	if TransformerNames == nil {
+		return nil // Only the clipboard package knows the compiled-in transformers
+	}
+	known := make(map[string]bool)
+	for _, name := range TransformerNames() {
+		known[name] = true
+	}
+	for name := range cfg.Plugins() {
+		known[name] = true
+	}
+
+	var validationErrors []string
+	check := func(rulePrefix string, rules []Replacement) {
+		for j, rep := range rules {
+			if rep.Transformer == "" || known[rep.Transformer] {
+				continue
+			}
+			available := "none are compiled in or in the plugins folder"
+			if len(known) > 0 {
+				names := make([]string, 0, len(known))
+				for name := range known {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				available = "available: " + strings.Join(names, ", ")
+			}
+			validationErrors = append(validationErrors, fmt.Sprintf("%s.Replacement[%d]: unknown transformer '%s' (%s)", rulePrefix, j, rep.Transformer, available))
+		}
+	}
+	for i, profile := range cfg.Profiles {
+		check(fmt.Sprintf("Profile[%d](%s)", i, profile.Name), profile.Replacements)
+	}
+	groupNames := make([]string, 0, len(cfg.RuleGroups))
+	for name := range cfg.RuleGroups {
+		groupNames = append(groupNames, name)
+	}
+	sort.Strings(groupNames)
+	for _, name := range groupNames {
+		check(fmt.Sprintf("RuleGroup(%s)", name), cfg.RuleGroups[name])
+	}
+	return validationErrors
+}
@@ -0,0 +1,135 @@
+package config
+
+import "strings"
+
+// Preset is a ready-made profile shipped with the application. Adding it from
+// the tray's "Presets" submenu copies the profile into config.json, where it
+// is edited like any other profile.
+type Preset struct {
+	Description string // Shown as the tooltip of the preset's menu item
+	Profile     ProfileConfig
+}
+
+// Presets returns the built-in presets. Each call returns new profiles, so
+// callers may change them.
+func Presets() []Preset {
+	return []Preset{
+		{
+			Description: "Replace typographic quotes, dashes, ellipses and non-breaking spaces with plain ASCII",
+			Profile: ProfileConfig{
+				Name:    "Normalize Smart Quotes",
+				Enabled: true,
+				Hotkey:  "ctrl+shift+alt+q",
+				Replacements: []Replacement{
+					{Regex: "[“”„‟″]", ReplaceWith: `"`, Description: "Double quotes", Tests: []RuleTest{{Input: "“quoted”", Expect: `"quoted"`}}},
+					{Regex: "[‘’‚‛′]", ReplaceWith: "'", Description: "Single quotes and apostrophes", Tests: []RuleTest{{Input: "it’s", Expect: "it's"}}},
+					{Regex: "[–—]", ReplaceWith: "-", Description: "En and em dashes"},
+					{Regex: "…", ReplaceWith: "...", Description: "Ellipses"},
+					{Regex: `[\x{00A0}\x{202F}]`, ReplaceWith: " ", Description: "Non-breaking spaces"},
+				},
+			},
+		},
+		{
+			Description: "Remove Markdown syntax: headings, emphasis, code, quotes and links become plain text",
+			Profile: ProfileConfig{
+				Name:    "Markdown to Plain Text",
+				Enabled: true,
+				Hotkey:  "ctrl+shift+alt+m",
+				Replacements: []Replacement{
+					{Regex: "(?m)^[ \\t]*(?:```|~~~).*\\n?", ReplaceWith: "", Description: "Code fences"},
+					{Regex: `(?m)^[ \t]{0,3}#{1,6}[ \t]+(.*?)[ \t#]*$`, ReplaceWith: "$1", Description: "Headings", Tests: []RuleTest{{Input: "## Setup ##", Expect: "Setup"}}},
+					{Regex: `(?m)^[ \t]{0,3}(?:-{3,}|\*{3,}|_{3,})[ \t]*\n?`, ReplaceWith: "", Description: "Horizontal rules"},
+					{Regex: `(?m)^[ \t]{0,3}>[ \t]?`, ReplaceWith: "", Description: "Block quotes"},
+					{Regex: `(?m)^([ \t]*)[-*+][ \t]+`, ReplaceWith: "${1}• ", Description: "List bullets", Tests: []RuleTest{{Input: "  * item", Expect: "  • item"}}},
+					{Regex: `!\[([^\]]*)\]\([^)]*\)`, ReplaceWith: "$1", Description: "Images"},
+					{Regex: `\[([^\]]+)\]\(([^)\s]+)(?:[ \t]+"[^"]*")?\)`, ReplaceWith: "$1 ($2)", Description: "Links", Tests: []RuleTest{{Input: "[docs](https://example.com)", Expect: "docs (https://example.com)"}}},
+					{Regex: `\*\*([^*\n]+)\*\*|__([^_\n]+)__`, ReplaceWith: "$1$2", Description: "Bold"},
+					{Regex: `\*([^*\n]+)\*|\b_([^_\n]+)_\b`, ReplaceWith: "$1$2", Description: "Italics", Tests: []RuleTest{{Input: "*very* _much_ snake_case_name", Expect: "very much snake_case_name"}}},
+					{Regex: `~~([^~\n]+)~~`, ReplaceWith: "$1", Description: "Strikethrough"},
+					{Regex: "`([^`\\n]+)`", ReplaceWith: "$1", Description: "Inline code"},
+				},
+			},
+		},
+		{
+			Description: "Remove tracking parameters such as utm_source, fbclid and gclid from URLs",
+			Profile: ProfileConfig{
+				Name:    "Strip URL Tracking",
+				Enabled: true,
+				Hotkey:  "ctrl+shift+alt+u",
+				Replacements: []Replacement{
+					{
+						Action: "strip_tracking",
+						Tests: []RuleTest{
+							{Input: "https://example.com/?utm_source=mail&id=3", Expect: "https://example.com/?id=3"},
+							{Input: "(https://example.com/a?fbclid=1&utm_medium=x)", Expect: "(https://example.com/a)"},
+						},
+					},
+				},
+			},
+		},
+		{
+			Description: "Collapse runs of spaces and blank lines and remove trailing whitespace, keeping indentation",
+			Profile: ProfileConfig{
+				Name:    "Collapse Whitespace",
+				Enabled: true,
+				Hotkey:  "ctrl+shift+alt+w",
+				Replacements: []Replacement{
+					{Action: "trim_trailing"},
+					{Regex: `(\S)[ \t\x{00A0}]{2,}`, ReplaceWith: "$1 ", Description: "Runs of spaces", Tests: []RuleTest{{Input: "  a   b\tc", Expect: "  a b\tc"}}},
+					{Action: "collapse_blank_lines"},
+					{Action: "trim"},
+				},
+			},
+		},
+		{
+			Description: "Replace keys, email addresses and IP addresses with placeholders and trim the text to 8000 tokens before pasting into an AI chat",
+			Profile: ProfileConfig{
+				Name:    "LLM Prompt Sanitizer",
+				Enabled: true,
+				Hotkey:  "ctrl+shift+alt+l",
+				Replacements: []Replacement{
+					{Regex: `(?s)-----BEGIN [A-Z ]*PRIVATE KEY-----.*?-----END [A-Z ]*PRIVATE KEY-----`, ReplaceWith: "[PRIVATE_KEY]", Numbered: true, Description: "Private keys"},
+					{Regex: `\b(?:sk-[A-Za-z0-9_-]{20,}|AKIA[0-9A-Z]{16}|gh[pousr]_[A-Za-z0-9]{36}|xox[abprs]-[A-Za-z0-9-]{10,})\b`, ReplaceWith: "[API_KEY]", Numbered: true, Description: "API keys and access tokens"},
+					{Regex: `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`, ReplaceWith: "[EMAIL]", Numbered: true, Description: "Email addresses"},
+					{Regex: `\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`, ReplaceWith: "[IP]", Numbered: true, Description: "IPv4 addresses"},
+					{
+						Transformer:        "token_budget",
+						TransformerOptions: map[string]string{"max_tokens": "8000", "strategy": "compact"},
+						Description:        "Fit to 8000 tokens",
+						Tests:              []RuleTest{{Input: "Short prompts are kept as they are.", Expect: "Short prompts are kept as they are."}},
+					},
+				},
+			},
+		},
+	}
+}
+
+// FindPreset returns the preset whose profile is called name.
+func FindPreset(name string) (Preset, bool) {
+	for _, preset := range Presets() {
+		if preset.Profile.Name == name {
+			return preset, true
+		}
+	}
+	return Preset{}, false
+}
+
+// HotkeyInUse reports whether an enabled profile or a global setting already
+// uses hotkey.
+func (c *Config) HotkeyInUse(hotkey string) bool {
+	used := []string{c.RevertHotkey, c.TypeHotkey, c.SmartHotkey, c.ClearHotkey, c.UndoHotkey, c.RedoHotkey}
+	for _, groupHotkey := range c.GroupHotkeys {
+		used = append(used, groupHotkey)
+	}
+	for _, profile := range c.Profiles {
+		if profile.Enabled {
+			used = append(used, profile.Hotkey, profile.ReverseHotkey)
+		}
+	}
+	for _, other := range used {
+		if other != "" && strings.EqualFold(other, hotkey) {
+			return true
+		}
+	}
+	return false
+}
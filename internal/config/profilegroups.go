@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// ProfileGroupNames returns the names of the profile groups, sorted.
+func (c *Config) ProfileGroupNames() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, profile := range c.Profiles {
+		if profile.Group != "" && !seen[profile.Group] {
+			seen[profile.Group] = true
+			names = append(names, profile.Group)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// EnableProfileExclusive enables the profile at index and disables the other
+// profiles of its group. It returns the names of the profiles it disabled.
+func (c *Config) EnableProfileExclusive(index int) []string {
+	if index < 0 || index >= len(c.Profiles) {
+		return nil
+	}
+	c.Profiles[index].Enabled = true
+	group := c.Profiles[index].Group
+	if group == "" {
+		return nil
+	}
+	var disabled []string
+	for i := range c.Profiles {
+		if i != index && c.Profiles[i].Group == group && c.Profiles[i].Enabled {
+			c.Profiles[i].Enabled = false
+			disabled = append(disabled, c.Profiles[i].Name)
+		}
+	}
+	return disabled
+}
+
+// CycleProfileGroup enables the profile of group after the enabled one, in
+// config order and wrapping around, and disables the others. If none is
+// enabled, the first profile of the group is. It returns the enabled profile.
+func (c *Config) CycleProfileGroup(group string) (string, error) {
+	var members []int
+	current := -1
+	for i, profile := range c.Profiles {
+		if profile.Group != group {
+			continue
+		}
+		if profile.Enabled && current < 0 {
+			current = len(members)
+		}
+		members = append(members, i)
+	}
+	if len(members) == 0 {
+		return "", fmt.Errorf("no profile is in group '%s'", group)
+	}
+	next := members[(current+1)%len(members)]
+	c.EnableProfileExclusive(next)
+	return c.Profiles[next].Name, nil
+}
+
+// NormalizeProfileGroups keeps only the first enabled profile of each group
+// enabled, e.g. after a profile was moved into a group by hand.
+func (c *Config) NormalizeProfileGroups() {
+	enabled := make(map[string]string) // Group -> enabled profile
+	for i := range c.Profiles {
+		profile := &c.Profiles[i]
+		if profile.Group == "" || !profile.Enabled {
+			continue
+		}
+		if first, ok := enabled[profile.Group]; ok {
+			log.Printf("Warning: profiles '%s' and '%s' of group '%s' are both enabled. Only one profile of a group can be active; disabling '%s'.", first, profile.Name, profile.Group, profile.Name)
+			profile.Enabled = false
+			continue
+		}
+		enabled[profile.Group] = profile.Name
+	}
+}
+
+// validateProfileGroups checks that every group_hotkeys entry names a group
+// of at least one profile and has a hotkey.
+func validateProfileGroups(cfg *Config) []string {
+	var validationErrors []string
+	groups := make(map[string]int)
+	for _, profile := range cfg.Profiles {
+		if profile.Group != "" {
+			groups[profile.Group]++
+		}
+		if profile.Group != strings.TrimSpace(profile.Group) {
+			validationErrors = append(validationErrors, fmt.Sprintf("Profile '%s': group '%s' cannot start or end with spaces", profile.Name, profile.Group))
+		}
+	}
+	for group, hotkey := range cfg.GroupHotkeys {
+		if groups[group] == 0 {
+			validationErrors = append(validationErrors, fmt.Sprintf("group_hotkeys: no profile is in group '%s'", group))
+		}
+		if strings.TrimSpace(hotkey) == "" {
+			validationErrors = append(validationErrors, fmt.Sprintf("group_hotkeys: the hotkey of group '%s' is empty", group))
+		}
+	}
+	for group, count := range groups {
+		if count == 1 {
+			log.Printf("Warning: group '%s' has only one profile, so it switches nothing.", group)
+		}
+	}
+	return validationErrors
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// ResolveProfile returns a copy of profile whose rules start with the rules of
+// its snippets, then the rules of its include_groups, in the listed order,
+// followed by its own rules. Profiles in c.Profiles keep only their own rules,
+// so saving the config never copies group or snippet rules into them. With a
+// secrets_scope, every rule carries it, so it only resolves the secrets the
+// profile lists.
+func (c *Config) ResolveProfile(profile ProfileConfig) ProfileConfig {
+	if len(profile.IncludeGroups) == 0 && len(profile.SecretsScope) == 0 && len(profile.Snippets) == 0 {
+		return profile
+	}
+	rules := snippetRules(profile)
+	for _, name := range profile.IncludeGroups {
+		rules = append(rules, c.RuleGroups[name]...)
+	}
+	rules = append(rules, profile.Replacements...)
+	for i := range rules {
+		rules[i].secretScope = profile.SecretsScope
+	}
+	profile.Replacements = rules
+	profile.IncludeGroups = nil
+	profile.Snippets = nil
+	return profile
+}
+
+// ResolvedProfiles returns copies of all profiles with their rule groups
+// resolved. This is the rule list replacements, rule tests and lint run on.
+func (c *Config) ResolvedProfiles() []ProfileConfig {
+	profiles := make([]ProfileConfig, len(c.Profiles))
+	for i, profile := range c.Profiles {
+		profiles[i] = c.ResolveProfile(profile)
+	}
+	return profiles
+}
+
+// validateRuleGroups checks group names and rule patterns and that every
+// group included by a profile exists. Unused groups are only logged.
+func validateRuleGroups(cfg *Config) []string {
+	var validationErrors []string
+
+	names := make([]string, 0, len(cfg.RuleGroups))
+	for name := range cfg.RuleGroups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		groupPrefix := fmt.Sprintf("RuleGroup(%s)", name)
+		if strings.TrimSpace(name) == "" {
+			validationErrors = append(validationErrors, fmt.Sprintf("%s: group name cannot be empty", groupPrefix))
+		}
+		for j, replacement := range cfg.RuleGroups[name] {
+			validationErrors = append(validationErrors, validateReplacement(fmt.Sprintf("%s.Replacement[%d]", groupPrefix, j), replacement)...)
+		}
+	}
+
+	used := make(map[string]bool)
+	for i, profile := range cfg.Profiles {
+		profilePrefix := fmt.Sprintf("Profile[%d](%s)", i, profile.Name)
+		included := make(map[string]bool)
+		for _, name := range profile.IncludeGroups {
+			if _, ok := cfg.RuleGroups[name]; !ok {
+				validationErrors = append(validationErrors, fmt.Sprintf("%s: include_groups references unknown rule group '%s'", profilePrefix, name))
+			}
+			if included[name] {
+				validationErrors = append(validationErrors, fmt.Sprintf("%s: rule group '%s' is included more than once", profilePrefix, name))
+			}
+			included[name] = true
+			used[name] = true
+		}
+	}
+
+	if cfg.LLMEnabled() {
+		used[cfg.LLM.RedactGroup] = true // Applied by llm rules
+	}
+	for _, name := range names {
+		if !used[name] {
+			log.Printf("Warning: Rule group '%s' is not included by any profile.", name)
+		}
+	}
+
+	return validationErrors
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ProfileSchedule enables a profile only on certain days, during a daily time
+// window or while a state file has a certain content. All conditions that are
+// set must hold. The profile's "enabled" flag is switched when the schedule
+// changes, so toggling it by hand lasts until the next change.
+type ProfileSchedule struct {
+	Days      []string `json:"days,omitempty"`       // "mon".."sun", "weekdays" or "weekend"; empty means every day
+	From      string   `json:"from,omitempty"`       // Start of the daily window, "HH:MM"
+	To        string   `json:"to,omitempty"`         // End of the daily window (exclusive), "HH:MM"; before From to span midnight
+	StateFile string   `json:"state_file,omitempty"` // File written by e.g. a calendar script; relative to config.json
+	State     string   `json:"state,omitempty"`      // Content of state_file (trimmed, case-insensitive) that enables the profile
+}
+
+var scheduleDayNames = map[string][]time.Weekday{
+	"mon":      {time.Monday},
+	"tue":      {time.Tuesday},
+	"wed":      {time.Wednesday},
+	"thu":      {time.Thursday},
+	"fri":      {time.Friday},
+	"sat":      {time.Saturday},
+	"sun":      {time.Sunday},
+	"weekdays": {time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	"weekend":  {time.Saturday, time.Sunday},
+}
+
+// ParseScheduleDays converts day names ("mon", "monday", "weekdays", ...) into
+// a set of weekdays. An empty list yields nil, meaning every day.
+func ParseScheduleDays(days []string) (map[time.Weekday]bool, error) {
+	if len(days) == 0 {
+		return nil, nil
+	}
+	set := make(map[time.Weekday]bool)
+	for _, day := range days {
+		name := strings.ToLower(strings.TrimSpace(day))
+		weekdays, ok := scheduleDayNames[name]
+		if !ok {
+			for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+				if strings.ToLower(weekday.String()) == name { // "monday"
+					weekdays, ok = []time.Weekday{weekday}, true
+				}
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("unknown day '%s' (use mon..sun, weekdays or weekend)", day)
+		}
+		for _, weekday := range weekdays {
+			set[weekday] = true
+		}
+	}
+	return set, nil
+}
+
+// ParseClock parses "HH:MM" (24-hour) into minutes since midnight.
+func ParseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time '%s' (use HH:MM, e.g. 09:00)", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// validateSchedule checks that a schedule's fields parse and are complete.
+func validateSchedule(schedule *ProfileSchedule) error {
+	if _, err := ParseScheduleDays(schedule.Days); err != nil {
+		return err
+	}
+	if (schedule.From == "") != (schedule.To == "") {
+		return fmt.Errorf("'from' and 'to' must be set together")
+	}
+	if schedule.From != "" {
+		from, err := ParseClock(schedule.From)
+		if err != nil {
+			return err
+		}
+		to, err := ParseClock(schedule.To)
+		if err != nil {
+			return err
+		}
+		if from == to {
+			return fmt.Errorf("'from' and 'to' must differ")
+		}
+	}
+	if (schedule.StateFile == "") != (schedule.State == "") {
+		return fmt.Errorf("'state_file' and 'state' must be set together")
+	}
+	if len(schedule.Days) == 0 && schedule.From == "" && schedule.StateFile == "" {
+		return fmt.Errorf("set at least one of 'days', 'from'/'to' or 'state_file'/'state'")
+	}
+	return nil
+}
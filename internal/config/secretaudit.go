@@ -0,0 +1,132 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SecretAudit reports how the declared secrets are used by the rules.
+type SecretAudit struct {
+	Usages     map[string][]string // Secret name -> rules referencing it, e.g. "Profile 'Work' rule 2 (regex)"
+	Undeclared []string            // Referenced, but not declared in "secrets"
+	Unresolved []string            // Declared and referenced, but their value is not loaded
+	Unused     []string            // Declared, but referenced by no rule
+	Resolved   bool                // Unresolved was checked against the loaded values
+}
+
+// ruleSecretFields returns the secret names a rule references in each field.
+func ruleSecretFields(rep Replacement) map[string][]string {
+	fields := make(map[string][]string)
+	all := []struct{ name, value string }{
+		{"regex", rep.Regex},
+		{"replace_with", rep.ReplaceWith},
+		{"reverse_with", rep.ReverseWith},
+	}
+	for _, pair := range rep.Bidirectional {
+		all = append(all, struct{ name, value string }{"bidirectional", pair.Original + "\n" + pair.Replacement})
+	}
+	for _, field := range all {
+		for _, m := range sharedSecretPlaceholderRegex.FindAllStringSubmatch(field.value, -1) {
+			if !IsTemplateVariable(m[1]) {
+				fields[m[1]] = appendUnique(fields[m[1]], field.name)
+			}
+		}
+	}
+	return fields
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, existing := range list {
+		if existing == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+// AuditSecrets lists which rules of the profiles and rule groups reference
+// which secrets. resolved is the map of loaded secret values; with nil, the
+// values are not checked and Unresolved stays empty.
+func (c *Config) AuditSecrets(resolved map[string]string) SecretAudit {
+	audit := SecretAudit{Usages: make(map[string][]string), Resolved: resolved != nil}
+	record := func(owner string, reps []Replacement) {
+		for i, rep := range reps {
+			for name, fields := range ruleSecretFields(rep) {
+				audit.Usages[name] = append(audit.Usages[name], fmt.Sprintf("%s rule %d (%s): %s", owner, i+1, strings.Join(fields, ", "), rep.Summary()))
+			}
+		}
+	}
+	for _, profile := range c.Profiles {
+		record(fmt.Sprintf("Profile '%s'", profile.Name), profile.Replacements)
+	}
+	groupNames := make([]string, 0, len(c.RuleGroups))
+	for name := range c.RuleGroups {
+		groupNames = append(groupNames, name)
+	}
+	sort.Strings(groupNames)
+	for _, name := range groupNames {
+		record(fmt.Sprintf("Rule group '%s'", name), c.RuleGroups[name])
+	}
+
+	for name := range audit.Usages {
+		if _, declared := c.Secrets[name]; !declared {
+			audit.Undeclared = append(audit.Undeclared, name)
+		} else if resolved != nil {
+			if _, ok := resolved[name]; !ok {
+				audit.Unresolved = append(audit.Unresolved, name)
+			}
+		}
+	}
+	for name := range c.Secrets {
+		if _, used := audit.Usages[name]; !used {
+			audit.Unused = append(audit.Unused, name)
+		}
+	}
+	sort.Strings(audit.Undeclared)
+	sort.Strings(audit.Unresolved)
+	sort.Strings(audit.Unused)
+	return audit
+}
+
+// Problems returns the number of undeclared, unresolved and unused secrets.
+func (a SecretAudit) Problems() int {
+	return len(a.Undeclared) + len(a.Unresolved) + len(a.Unused)
+}
+
+// Report formats the audit as text for the tray dialog and the command line.
+func (a SecretAudit) Report(c *Config) string {
+	var b strings.Builder
+	names := make([]string, 0, len(a.Usages))
+	for name := range a.Usages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if len(names) == 0 {
+		b.WriteString("No rule references a secret.\n")
+	}
+	for _, name := range names {
+		source := "not declared"
+		if _, declared := c.Secrets[name]; declared {
+			source = c.SecretProviderName(name)
+		}
+		fmt.Fprintf(&b, "{{%s}} (%s), used by %d rule(s):\n", name, source, len(a.Usages[name]))
+		for _, usage := range a.Usages[name] {
+			fmt.Fprintf(&b, "  - %s\n", usage)
+		}
+	}
+
+	section := func(title string, list []string) {
+		if len(list) > 0 {
+			fmt.Fprintf(&b, "\n%s:\n  - %s\n", title, strings.Join(list, "\n  - "))
+		}
+	}
+	section("Referenced but not declared in \"secrets\" (the rules fail)", a.Undeclared)
+	section("Declared but not loaded (check the keychain or secret manager; the rules fail)", a.Unresolved)
+	section("Declared but referenced by no rule", a.Unused)
+	if !a.Resolved {
+		b.WriteString("\nSecret values were not loaded, so unresolved secrets are not reported.\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,260 @@
+package config
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	jose "github.com/dvsekhvalnov/jose2go"
+)
+
+// SecretEntry is one secret in a bulk import or export. Source is "managed"
+// for a value kept in the OS keyring (or encrypted secrets file), or an
+// external reference such as "op://vault/item/field".
+type SecretEntry struct {
+	Name   string `json:"name"`
+	Source string `json:"source,omitempty"`
+	Value  string `json:"value,omitempty"`
+}
+
+// secretsExport is the file written by ExportSecrets. Values holds the values
+// of the managed secrets as a passphrase-encrypted JSON object, if exported.
+type secretsExport struct {
+	Secrets []SecretEntry `json:"secrets"`
+	Values  string        `json:"values,omitempty"`
+}
+
+// Errors of ParseSecretsImport for a file with encrypted values
+var (
+	ErrPassphraseRequired    = errors.New("the file contains encrypted values; a passphrase is required")
+	ErrWrongExportPassphrase = errors.New("wrong passphrase for the encrypted values")
+)
+
+// ValidateSecretName checks a logical secret name as used in {{...}}
+// placeholders.
+func ValidateSecretName(name string) error {
+	if name == "" || strings.ContainsAny(name, " \t\r\n{}[]()<>|=+*?^$\\./") {
+		return fmt.Errorf("invalid secret name '%s' (empty or contains spaces/special chars)", name)
+	}
+	if IsTemplateVariable(name) {
+		return fmt.Errorf("secret name '%s' is reserved for the {{%s}} template variable", name, name)
+	}
+	return nil
+}
+
+// ExportSecrets returns the declared secrets as JSON. With a passphrase, the
+// values of the managed secrets are included, encrypted with it; external
+// references are always exported as references. problems lists the values
+// that could not be read.
+func (c *Config) ExportSecrets(passphrase string) (data []byte, problems []string, err error) {
+	export := secretsExport{Secrets: []SecretEntry{}}
+	values := make(map[string]string)
+	store := c.newKeyringProvider() // Shared so the keyring is opened once
+	for _, name := range c.GetSecretNames() {
+		source := c.Secrets[name]
+		if source == "" {
+			source = SecretManaged
+		}
+		export.Secrets = append(export.Secrets, SecretEntry{Name: name, Source: source})
+		if passphrase == "" || source != SecretManaged {
+			continue
+		}
+		value, err := store.Lookup(name, source)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		values[name] = value
+	}
+	sort.Slice(export.Secrets, func(i, j int) bool { return export.Secrets[i].Name < export.Secrets[j].Name })
+
+	if passphrase != "" {
+		plain, err := json.Marshal(values)
+		if err != nil {
+			return nil, problems, err
+		}
+		export.Values, err = jose.Encrypt(string(plain), jose.PBES2_HS256_A128KW, jose.A256GCM, passphrase)
+		if err != nil {
+			return nil, problems, fmt.Errorf("failed to encrypt the secret values: %w", err)
+		}
+	}
+	data, err = json.MarshalIndent(export, "", "  ")
+	return data, problems, err
+}
+
+// SecretsImportEncrypted reports whether data is an export with encrypted
+// values, so a passphrase must be asked for before ParseSecretsImport.
+func SecretsImportEncrypted(data []byte) bool {
+	var export secretsExport
+	return json.Unmarshal(data, &export) == nil && export.Values != ""
+}
+
+// ParseSecretsImport reads secrets for a bulk import. Supported formats:
+//
+//   - a file written by ExportSecrets (passphrase decrypts its values),
+//   - a JSON object mapping names to values, or a JSON array of entries with
+//     "name" and "value" or "source",
+//   - CSV (".csv") with the columns name,value or a header row naming the
+//     columns name, value and source.
+func ParseSecretsImport(data []byte, filename, passphrase string) ([]SecretEntry, error) {
+	if strings.EqualFold(filepath.Ext(filename), ".csv") {
+		return parseSecretsCSV(data)
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		var entries []SecretEntry
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		return entries, nil
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(trimmed, &raw); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if _, isExport := raw["secrets"]; !isExport {
+		values := make(map[string]string)
+		if err := json.Unmarshal(trimmed, &values); err != nil {
+			return nil, fmt.Errorf("expected an object mapping secret names to string values: %w", err)
+		}
+		entries := make([]SecretEntry, 0, len(values))
+		for name, value := range values {
+			entries = append(entries, SecretEntry{Name: name, Value: value})
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+		return entries, nil
+	}
+
+	var export secretsExport
+	if err := json.Unmarshal(trimmed, &export); err != nil {
+		return nil, fmt.Errorf("invalid secrets export: %w", err)
+	}
+	if export.Values == "" {
+		return export.Secrets, nil
+	}
+	if passphrase == "" {
+		return nil, ErrPassphraseRequired
+	}
+	plain, _, err := jose.Decode(export.Values, passphrase)
+	if err != nil {
+		return nil, ErrWrongExportPassphrase
+	}
+	values := make(map[string]string)
+	if err := json.Unmarshal([]byte(plain), &values); err != nil {
+		return nil, fmt.Errorf("invalid encrypted values: %w", err)
+	}
+	for i, entry := range export.Secrets {
+		if value, ok := values[entry.Name]; ok {
+			export.Secrets[i].Value = value
+		}
+	}
+	return export.Secrets, nil
+}
+
+func parseSecretsCSV(data []byte) ([]SecretEntry, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	columns := map[string]int{"name": 0, "value": 1, "source": -1}
+	var entries []SecretEntry
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		if line == 1 && len(record) > 0 && strings.EqualFold(strings.TrimSpace(record[0]), "name") {
+			columns = map[string]int{"name": -1, "value": -1, "source": -1}
+			for i, header := range record {
+				if _, known := columns[strings.ToLower(strings.TrimSpace(header))]; known {
+					columns[strings.ToLower(strings.TrimSpace(header))] = i
+				}
+			}
+			continue
+		}
+		field := func(column string) string {
+			if i := columns[column]; i >= 0 && i < len(record) {
+				return record[i]
+			}
+			return ""
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue // Blank line
+		}
+		entries = append(entries, SecretEntry{
+			Name:   strings.TrimSpace(field("name")),
+			Value:  field("value"),
+			Source: strings.TrimSpace(field("source")),
+		})
+	}
+}
+
+// SecretImportResult reports what ImportSecrets did.
+type SecretImportResult struct {
+	Imported []string // Names stored or referenced
+	Problems []string // Entries that were skipped, with the reason
+}
+
+// ImportSecrets stores a batch of secrets: values in the keyring (or
+// encrypted secrets file) as managed secrets, external references as is.
+// Entries without a value or reference are skipped. config.json is saved once
+// at the end; existing secrets with the same name are replaced.
+func (c *Config) ImportSecrets(entries []SecretEntry) (SecretImportResult, error) {
+	var result SecretImportResult
+	store := c.newKeyringProvider() // Shared so the keyring is opened once
+	if c.Secrets == nil {
+		c.Secrets = make(map[string]string)
+	}
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		name := strings.TrimSpace(entry.Name)
+		if err := ValidateSecretName(name); err != nil {
+			result.Problems = append(result.Problems, err.Error())
+			continue
+		}
+		if seen[name] {
+			result.Problems = append(result.Problems, fmt.Sprintf("%s: listed more than once, only the first entry was imported", name))
+			continue
+		}
+		seen[name] = true
+
+		source := strings.TrimSpace(entry.Source)
+		if source != "" && source != SecretManaged {
+			if err := c.checkSecretRef(source); err != nil {
+				result.Problems = append(result.Problems, fmt.Sprintf("%s: %v", name, err))
+				continue
+			}
+			c.Secrets[name] = source
+			result.Imported = append(result.Imported, name)
+			continue
+		}
+		if entry.Value == "" {
+			result.Problems = append(result.Problems, fmt.Sprintf("%s: no value (export with values to include them)", name))
+			continue
+		}
+		if err := store.Store(name, entry.Value); err != nil {
+			result.Problems = append(result.Problems, fmt.Sprintf("%s: failed to store in %s: %v", name, store.Name(), err))
+			if store.openErr != nil {
+				break // The store can't be opened, so the other entries would fail alike
+			}
+			continue
+		}
+		c.Secrets[name] = SecretManaged
+		result.Imported = append(result.Imported, name)
+	}
+
+	if len(result.Imported) == 0 {
+		return result, nil
+	}
+	return result, c.Save()
+}
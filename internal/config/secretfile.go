@@ -0,0 +1,103 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/99designs/keyring"
+)
+
+// Stores for "managed" secrets
+const (
+	SecretStoreAuto    = "auto"    // OS keyring, or the encrypted file if no keyring is available
+	SecretStoreKeyring = "keyring" // Only the OS keyring
+	SecretStoreFile    = "file"    // Only the encrypted file, e.g. where the keyring is blocked by policy
+)
+
+// SecretsPassphraseEnv supplies the passphrase of the encrypted secrets file
+// without a prompt, e.g. on headless systems.
+const SecretsPassphraseEnv = "CLIPREGEX_SECRETS_PASSPHRASE"
+
+// SecretsDirName is the directory next to config.json that holds the
+// encrypted secrets file store, one JWE-encrypted file per secret.
+const SecretsDirName = "secrets"
+
+// ErrWrongPassphrase is returned when the encrypted secrets file cannot be
+// decrypted with the entered passphrase.
+var ErrWrongPassphrase = errors.New("wrong passphrase for the encrypted secrets file")
+
+var (
+	passphraseMu     sync.Mutex
+	passphrasePrompt func(create bool) (string, error)
+	passphrase       string // Verified passphrase, kept for the session
+)
+
+// SetSecretPassphrasePrompt sets the function that asks for the passphrase
+// of the encrypted secrets file. create is true if the store is new, so the
+// passphrase should be chosen and confirmed.
+func SetSecretPassphrasePrompt(prompt func(create bool) (string, error)) {
+	passphraseMu.Lock()
+	defer passphraseMu.Unlock()
+	passphrasePrompt = prompt
+}
+
+// SecretsFileDir returns the directory of the encrypted secrets file store
+// for the config at configPath.
+func SecretsFileDir(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), SecretsDirName)
+}
+
+// openSecretsFile opens the encrypted file store in dir. The passphrase is
+// asked for once per session and checked against an existing secret, so a
+// mistyped passphrase can't encrypt new secrets with a different key.
+func openSecretsFile(dir string) (keyring.Keyring, error) {
+	passphraseMu.Lock()
+	defer passphraseMu.Unlock()
+
+	entries, _ := os.ReadDir(dir)
+	create := len(entries) == 0
+
+	pass := passphrase
+	if pass == "" {
+		pass = os.Getenv(SecretsPassphraseEnv)
+	}
+	if pass == "" {
+		if passphrasePrompt == nil {
+			return nil, fmt.Errorf("the encrypted secrets file needs a passphrase: set %s", SecretsPassphraseEnv)
+		}
+		var err error
+		if pass, err = passphrasePrompt(create); err != nil {
+			return nil, fmt.Errorf("no passphrase for the encrypted secrets file: %w", err)
+		}
+		if pass == "" {
+			return nil, errors.New("the passphrase of the encrypted secrets file cannot be empty")
+		}
+	}
+
+	kr, err := keyring.Open(keyring.Config{
+		AllowedBackends: []keyring.BackendType{keyring.FileBackend},
+		FileDir:         dir,
+		FilePasswordFunc: func(string) (string, error) {
+			return pass, nil
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the encrypted secrets file in %s: %w", dir, err)
+	}
+	if !create {
+		keys, err := kr.Keys()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the encrypted secrets file in %s: %w", dir, err)
+		}
+		if len(keys) > 0 {
+			if _, err := kr.Get(keys[0]); err != nil && !errors.Is(err, keyring.ErrKeyNotFound) {
+				return nil, ErrWrongPassphrase
+			}
+		}
+	}
+	passphrase = pass
+	return kr, nil
+}
@@ -0,0 +1,536 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/99designs/keyring"
+)
+
+// SecretManaged is the "secrets" value of a secret stored in the OS keyring.
+const SecretManaged = "managed"
+
+// Prefixes of "secrets" values that load a secret from an external manager
+const (
+	SecretPrefix1Password = "op://"    // op://vault/item/field, read with the 1Password CLI
+	SecretPrefixBitwarden = "bw://"    // bw://item#field, read with the Bitwarden CLI
+	SecretPrefixVault     = "vault://" // vault://mount/path#key, read from the HashiCorp Vault HTTP API
+)
+
+// secretCommandTimeout bounds a secret manager CLI call. It is generous, as
+// the CLI may wait for the user to unlock it, e.g. with biometrics.
+const secretCommandTimeout = 60 * time.Second
+
+// ErrSecretNotFound is returned by a SecretProvider for an unknown secret.
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretProvider loads secret values from one backend. name is the logical
+// name used in {{...}} placeholders and ref the secret's value in the
+// "secrets" map of config.json.
+type SecretProvider interface {
+	Name() string
+	Lookup(name, ref string) (string, error)
+}
+
+// SecretStore is a SecretProvider the application can also write to.
+type SecretStore interface {
+	SecretProvider
+	Store(name, value string) error
+	Delete(name string) error
+}
+
+// secretProvider returns the provider for a "secrets" value.
+func (c *Config) secretProvider(ref string) (SecretProvider, error) {
+	switch {
+	case ref == SecretManaged || ref == "":
+		return c.newKeyringProvider(), nil
+	case strings.HasPrefix(ref, SecretPrefix1Password):
+		return onePasswordProvider{}, nil
+	case strings.HasPrefix(ref, SecretPrefixBitwarden):
+		return bitwardenProvider{}, nil
+	case strings.HasPrefix(ref, SecretPrefixVault):
+		return vaultProvider{}, nil
+	}
+	return nil, fmt.Errorf("unknown secret source '%s' (must be \"%s\" or start with %s, %s or %s)", ref, SecretManaged, SecretPrefix1Password, SecretPrefixBitwarden, SecretPrefixVault)
+}
+
+// SecretProviderName returns the name of the backend that holds the secret
+// name, e.g. "OS keyring" or "1Password".
+func (c *Config) SecretProviderName(name string) string {
+	provider, err := c.secretProvider(c.Secrets[name])
+	if err != nil {
+		return "unknown"
+	}
+	return provider.Name()
+}
+
+// IsKeyringSecret reports whether the secret name is stored in the OS keyring
+// rather than an external secret manager.
+func (c *Config) IsKeyringSecret(name string) bool {
+	provider, err := c.secretProvider(c.Secrets[name])
+	if err != nil {
+		return false
+	}
+	_, ok := provider.(SecretStore)
+	return ok
+}
+
+// splitSecretRef splits "prefix" + "location#field" into location and field.
+func splitSecretRef(ref, prefix string) (location, field string) {
+	location = strings.TrimPrefix(ref, prefix)
+	if i := strings.LastIndex(location, "#"); i >= 0 {
+		return location[:i], location[i+1:]
+	}
+	return location, ""
+}
+
+// checkSecretRef checks that a "secrets" value names a known source in the
+// form the source expects.
+func (c *Config) checkSecretRef(ref string) error {
+	if _, err := c.secretProvider(ref); err != nil {
+		return err
+	}
+	switch {
+	case strings.HasPrefix(ref, SecretPrefix1Password) && strings.Count(strings.TrimPrefix(ref, SecretPrefix1Password), "/") < 2:
+		return fmt.Errorf("'%s' must have the form op://vault/item/field", ref)
+	case strings.HasPrefix(ref, SecretPrefixBitwarden):
+		if item, _ := splitSecretRef(ref, SecretPrefixBitwarden); item == "" {
+			return fmt.Errorf("'%s' must have the form bw://item or bw://item#field", ref)
+		}
+	case strings.HasPrefix(ref, SecretPrefixVault):
+		if path, key := splitSecretRef(ref, SecretPrefixVault); path == "" || key == "" {
+			return fmt.Errorf("'%s' must have the form vault://mount/path#key", ref)
+		}
+	}
+	return nil
+}
+
+// validateSecretRefs checks every "secrets" value with checkSecretRef.
+func validateSecretRefs(cfg *Config) []string {
+	var validationErrors []string
+	for name, ref := range cfg.Secrets {
+		if err := cfg.checkSecretRef(ref); err != nil {
+			validationErrors = append(validationErrors, fmt.Sprintf("secrets.%s: %v", name, err))
+		}
+	}
+	return validationErrors
+}
+
+// --- OS keyring ---
+
+// keyringProvider stores secrets in the OS credential store (Windows
+// Credential Manager, macOS Keychain, Secret Service), or in the encrypted
+// secrets file where there is none (see secretfile.go).
+type keyringProvider struct {
+	service string
+	store   string          // SecretStoreAuto, SecretStoreKeyring or SecretStoreFile
+	backend string          // keyring_backend: the OS keyring backends tried
+	fileDir string          // Directory of the encrypted secrets file store
+	kr      keyring.Keyring // Opened on first use
+	openErr error           // Why opening failed, so a rejected passphrase is asked for only once
+	file    bool            // kr is the encrypted file store
+	opened  string          // Name of the OS keyring backend kr uses, e.g. "Secret Service"
+}
+
+func (c *Config) newKeyringProvider() *keyringProvider {
+	return &keyringProvider{
+		service: c.keyringService,
+		store:   c.GetSecretStore(),
+		backend: c.GetKeyringBackend(),
+		fileDir: SecretsFileDir(c.configPath),
+		file:    c.GetSecretStore() == SecretStoreFile || c.GetKeyringBackend() == KeyringBackendFile,
+	}
+}
+
+func (p *keyringProvider) Name() string {
+	if p.file {
+		return "encrypted secrets file"
+	}
+	if p.opened != "" {
+		return "OS keyring (" + p.opened + ")"
+	}
+	return "OS keyring"
+}
+
+func (p *keyringProvider) open() (keyring.Keyring, error) {
+	if p.kr != nil || p.openErr != nil {
+		return p.kr, p.openErr
+	}
+	if !p.file {
+		kr, opened, err := openOSKeyring(p.service, p.backend)
+		if err == nil {
+			p.kr, p.opened = kr, opened
+			log.Printf("Managed secrets are kept in the %s.", p.Name())
+			return kr, nil
+		}
+		if p.store == SecretStoreKeyring || p.backend != KeyringBackendAuto {
+			p.openErr = fmt.Errorf("failed to open keyring for service '%s': %w", p.service, err)
+			return nil, p.openErr
+		}
+		log.Printf("No OS keyring available (%v). Using the encrypted secrets file in %s.", err, p.fileDir)
+	}
+	p.file = true
+	kr, err := openSecretsFile(p.fileDir)
+	if err != nil {
+		p.openErr = err
+		return nil, err
+	}
+	p.kr = kr
+	log.Printf("Managed secrets are kept in the %s in %s.", p.Name(), p.fileDir)
+	return kr, nil
+}
+
+// openOSKeyring opens the OS credential store for service with the backends
+// of preference, see keyring_backend. It returns the name of the backend
+// that opened.
+func openOSKeyring(service, preference string) (keyring.Keyring, string, error) {
+	var failures []string
+	for _, backend := range keyringBackendCandidates(preference) {
+		name := keyringBackendNames[string(backend)]
+		if !keyringBackendSupported(backend) {
+			if preference != KeyringBackendAuto {
+				return nil, "", fmt.Errorf("keyring_backend '%s' (%s) is not available on this platform", preference, name)
+			}
+			continue
+		}
+		kr, err := keyring.Open(keyring.Config{
+			ServiceName:              service,
+			AllowedBackends:          []keyring.BackendType{backend},
+			LibSecretCollectionName:  "login", // Common on Linux
+			PassPrefix:               service, // Prefix for pass entries
+			WinCredPrefix:            service, // Prefix for Windows Credential Manager entries
+			KeychainTrustApplication: true,    // Allow access without prompt if app is trusted
+		})
+		if err == nil {
+			return kr, name, nil
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+	}
+	if len(failures) == 0 {
+		return nil, "", keyring.ErrNoAvailImpl
+	}
+	return nil, "", errors.New(strings.Join(failures, "; "))
+}
+
+// CheckSecretStore opens the store of "managed" secrets and lists its
+// entries, to check that it is accessible. It returns the store's name and
+// the number of secrets in it. An empty encrypted secrets file is not opened,
+// as that would ask for a new passphrase.
+func (c *Config) CheckSecretStore() (store string, count int, err error) {
+	p := c.newKeyringProvider()
+	if !p.file {
+		kr, opened, err := openOSKeyring(p.service, p.backend)
+		if err == nil {
+			p.opened = opened
+			keys, err := kr.Keys()
+			if err != nil {
+				return p.Name(), 0, fmt.Errorf("failed to list the keyring entries: %w", err)
+			}
+			return p.Name(), len(keys), nil
+		}
+		if p.store == SecretStoreKeyring || p.backend != KeyringBackendAuto {
+			return p.Name(), 0, fmt.Errorf("failed to open keyring for service '%s': %w", p.service, err)
+		}
+		p.file = true // "auto" falls back to the encrypted file
+	}
+	if entries, _ := os.ReadDir(p.fileDir); len(entries) == 0 {
+		return p.Name(), 0, nil
+	}
+	kr, err := openSecretsFile(p.fileDir)
+	if err != nil {
+		return p.Name(), 0, err
+	}
+	keys, err := kr.Keys()
+	if err != nil {
+		return p.Name(), 0, fmt.Errorf("failed to read the encrypted secrets file: %w", err)
+	}
+	return p.Name(), len(keys), nil
+}
+
+func (p *keyringProvider) Lookup(name, _ string) (string, error) {
+	kr, err := p.open()
+	if err != nil {
+		return "", err
+	}
+	item, err := kr.Get(name)
+	if errors.Is(err, keyring.ErrKeyNotFound) {
+		return "", ErrSecretNotFound
+	} else if err != nil {
+		return "", err
+	}
+	return string(item.Data), nil
+}
+
+func (p *keyringProvider) Store(name, value string) error {
+	kr, err := p.open()
+	if err != nil {
+		return err
+	}
+	return kr.Set(keyring.Item{
+		Key:         name, // Use logical name as the Key/Username
+		Data:        []byte(value),
+		Label:       fmt.Sprintf("Secret for %s used by %s", name, p.service),
+		Description: "Managed by Clipboard Regex Replace",
+	})
+}
+
+func (p *keyringProvider) Delete(name string) error {
+	kr, err := p.open()
+	if err != nil {
+		return err
+	}
+	if err := kr.Remove(name); errors.Is(err, keyring.ErrKeyNotFound) {
+		return ErrSecretNotFound
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// --- Secret manager CLIs ---
+
+// runSecretCommand runs a secret manager CLI and returns its output. The CLI
+// uses the session of the environment, e.g. a signed-in 1Password app or
+// BW_SESSION.
+func runSecretCommand(tool, install string, args ...string) (string, error) {
+	if _, err := exec.LookPath(tool); err != nil {
+		return "", fmt.Errorf("'%s' not found in PATH (install %s)", tool, install)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), secretCommandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, tool, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	hideCommandWindow(cmd)
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("%s timed out after %v", tool, secretCommandTimeout)
+		}
+		return "", fmt.Errorf("%s failed: %v: %s", tool, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// onePasswordProvider reads op://vault/item/field references with the
+// 1Password CLI.
+type onePasswordProvider struct{}
+
+func (onePasswordProvider) Name() string { return "1Password" }
+
+func (onePasswordProvider) Lookup(_, ref string) (string, error) {
+	output, err := runSecretCommand("op", "the 1Password CLI and sign in with 'op signin' or the desktop app integration", "read", "--no-newline", ref)
+	if err != nil {
+		if strings.Contains(err.Error(), "isn't an item") || strings.Contains(err.Error(), "could not find") {
+			return "", fmt.Errorf("%w: %v", ErrSecretNotFound, err)
+		}
+		return "", err
+	}
+	return output, nil
+}
+
+// bitwardenProvider reads bw://item#field references with the Bitwarden CLI.
+// The field defaults to the password; username, notes, totp and uri are read
+// directly, other names from the item's custom fields.
+type bitwardenProvider struct{}
+
+const bitwardenInstall = "the Bitwarden CLI and export BW_SESSION from 'bw unlock'"
+
+func (bitwardenProvider) Name() string { return "Bitwarden" }
+
+func (bitwardenProvider) Lookup(_, ref string) (string, error) {
+	item, field := splitSecretRef(ref, SecretPrefixBitwarden)
+	if field == "" {
+		field = "password"
+	}
+	switch field {
+	case "password", "username", "notes", "totp", "uri":
+		output, err := runSecretCommand("bw", bitwardenInstall, "get", field, item)
+		if err != nil {
+			if strings.Contains(err.Error(), "Not found") {
+				return "", fmt.Errorf("%w: %v", ErrSecretNotFound, err)
+			}
+			return "", err
+		}
+		return strings.TrimRight(output, "\r\n"), nil
+	}
+
+	output, err := runSecretCommand("bw", bitwardenInstall, "get", "item", item)
+	if err != nil {
+		if strings.Contains(err.Error(), "Not found") {
+			return "", fmt.Errorf("%w: %v", ErrSecretNotFound, err)
+		}
+		return "", err
+	}
+	var parsed struct {
+		Fields []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal([]byte(output), &parsed); err != nil {
+		return "", fmt.Errorf("cannot parse the output of 'bw get item': %w", err)
+	}
+	for _, f := range parsed.Fields {
+		if f.Name == field {
+			return f.Value, nil
+		}
+	}
+	return "", fmt.Errorf("%w: item '%s' has no field '%s'", ErrSecretNotFound, item, field)
+}
+
+// --- HashiCorp Vault ---
+
+// vaultProvider reads vault://mount/path#key references from the Vault HTTP
+// API, configured like the vault CLI: VAULT_ADDR, VAULT_TOKEN (or
+// ~/.vault-token) and VAULT_NAMESPACE. Both KV version 1 and 2 are supported;
+// for KV version 2 the path includes "data", e.g. vault://secret/data/app#key.
+type vaultProvider struct{}
+
+const defaultVaultAddr = "https://127.0.0.1:8200"
+
+func (vaultProvider) Name() string { return "HashiCorp Vault" }
+
+func vaultToken() (string, error) {
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.New("VAULT_TOKEN is not set")
+	}
+	data, err := os.ReadFile(filepath.Join(home, ".vault-token"))
+	if err != nil {
+		return "", errors.New("VAULT_TOKEN is not set and ~/.vault-token does not exist (run 'vault login')")
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func (vaultProvider) Lookup(_, ref string) (string, error) {
+	path, key := splitSecretRef(ref, SecretPrefixVault)
+	addr := strings.TrimRight(os.Getenv("VAULT_ADDR"), "/")
+	if addr == "" {
+		addr = defaultVaultAddr
+	}
+	token, err := vaultToken()
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, addr+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid Vault address '%s': %w", addr, err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("cannot reach Vault at %s: %w", addr, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("cannot read the Vault response: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", fmt.Errorf("%w: no Vault secret at '%s'", ErrSecretNotFound, path)
+	case resp.StatusCode == http.StatusForbidden:
+		return "", fmt.Errorf("Vault denied access to '%s' (token expired or missing policy)", path)
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("Vault returned %s for '%s': %s", resp.Status, path, strings.TrimSpace(string(body)))
+	}
+
+	var parsed struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return "", fmt.Errorf("cannot parse the Vault response: %w", err)
+	}
+	data := parsed.Data
+	// KV version 2 nests the secret in data.data next to data.metadata
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, hasMetadata := data["metadata"]; hasMetadata {
+			data = nested
+		}
+	}
+	value, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("%w: Vault secret '%s' has no key '%s'", ErrSecretNotFound, path, key)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// ResolveSecrets loads the values of all declared secrets like Load does,
+// keeps them for GetResolvedSecrets and returns them.
+func (c *Config) ResolveSecrets() map[string]string {
+	c.resolvedSecrets = c.loadSecrets()
+	return c.resolvedSecrets
+}
+
+// SecretStoreError returns why the last load of the secrets couldn't open a
+// store for the "managed" secrets, or nil. Rules using them fail until the
+// keyring_backend or secret_store is fixed.
+func (c *Config) SecretStoreError() error {
+	return c.secretStoreErr
+}
+
+// loadSecrets resolves every declared secret with its provider. Secrets that
+// cannot be loaded are logged and left out, so rules using them fail visibly
+// instead of the whole config.
+func (c *Config) loadSecrets() map[string]string {
+	resolved := make(map[string]string)
+	c.secretStoreErr = nil
+	if len(c.Secrets) == 0 {
+		log.Println("No secrets defined in config.json, skipping keyring load.")
+		return resolved
+	}
+	log.Printf("Loading %d secret(s)...", len(c.Secrets))
+	keyringSecrets := c.newKeyringProvider() // Shared so the keyring is opened once
+	for name, ref := range c.Secrets {
+		provider, err := c.secretProvider(ref)
+		if err != nil {
+			log.Printf("Error loading secret '%s': %v", name, err)
+			continue
+		}
+		if _, ok := provider.(*keyringProvider); ok {
+			provider = keyringSecrets
+		}
+		value, err := provider.Lookup(name, ref)
+		switch {
+		case errors.Is(err, ErrSecretNotFound):
+			log.Printf("Warning: Secret '%s' not found in %s. Rules using it may fail. (%v)", name, provider.Name(), err)
+		case err != nil:
+			log.Printf("Error retrieving secret '%s' from %s: %v", name, provider.Name(), err)
+		default:
+			resolved[name] = value
+			log.Printf("Successfully loaded secret '%s' from %s.", name, provider.Name())
+		}
+	}
+	if keyringSecrets.openErr != nil {
+		c.secretStoreErr = keyringSecrets.openErr
+		log.Printf("ERROR: No secret store is usable, the managed secrets are unavailable: %v", keyringSecrets.openErr)
+	}
+	return resolved
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+)
+
+// SecretScope returns the secrets_scope of the profile the rule was resolved
+// for by ResolveProfile, or nil if the rule may use every secret.
+func (r Replacement) SecretScope() []string {
+	return r.secretScope
+}
+
+// ScopeSecrets returns a copy of secrets holding only the names in scope, or
+// all of them if scope is empty. Rules are evaluated with this copy, so a
+// profile's rules can't reach secrets it doesn't list, even if they were
+// shared or imported from elsewhere.
+func ScopeSecrets(secrets map[string]string, scope []string) map[string]string {
+	if len(scope) == 0 {
+		scoped := make(map[string]string, len(secrets))
+		for name, value := range secrets {
+			scoped[name] = value
+		}
+		return scoped
+	}
+	scoped := make(map[string]string, len(scope))
+	for _, name := range scope {
+		if value, ok := secrets[name]; ok {
+			scoped[name] = value
+		}
+	}
+	return scoped
+}
+
+// validateSecretScopes checks that the secrets_scope of every profile lists
+// declared secrets and covers all secrets its rules, including those of its
+// rule groups, reference.
+func validateSecretScopes(cfg *Config) []string {
+	var validationErrors []string
+	for i, profile := range cfg.Profiles {
+		if len(profile.SecretsScope) == 0 {
+			continue
+		}
+		profilePrefix := fmt.Sprintf("Profile[%d](%s)", i, profile.Name)
+		inScope := make(map[string]bool, len(profile.SecretsScope))
+		for _, name := range profile.SecretsScope {
+			if _, declared := cfg.Secrets[name]; !declared {
+				validationErrors = append(validationErrors, fmt.Sprintf("%s: secrets_scope lists '%s', which is not declared in secrets", profilePrefix, name))
+			}
+			inScope[name] = true
+		}
+		for _, name := range ReferencedSecrets(cfg.ResolveProfile(profile)) {
+			if !inScope[name] {
+				validationErrors = append(validationErrors, fmt.Sprintf("%s: rules reference secret '%s', which is not in its secrets_scope", profilePrefix, name))
+			}
+		}
+	}
+	return validationErrors
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProfileShareFormatVersion is the version written to exported profile files.
+const ProfileShareFormatVersion = 1
+
+// SharedProfile is the portable file format used to exchange a single profile.
+// Secret values are never part of it; only the names of the secrets the
+// rules reference are listed so the importer knows what to set up.
+type SharedProfile struct {
+	FormatVersion   int           `json:"format_version"`
+	Profile         ProfileConfig `json:"profile"`
+	RequiredSecrets []string      `json:"required_secrets,omitempty"`
+}
+
+var sharedSecretPlaceholderRegex = regexp.MustCompile(`\{\{([a-zA-Z0-9_]+)\}\}`)
+
+// isYAMLPath reports whether the file extension selects the YAML format.
+func isYAMLPath(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// ReferencedSecrets returns the sorted secret names used by a profile's rules.
+func ReferencedSecrets(profile ProfileConfig) []string {
+	seen := make(map[string]bool)
+	for _, rep := range profile.Replacements {
+		fields := []string{rep.Regex, rep.ReplaceWith, rep.ReverseWith}
+		for _, pair := range rep.Bidirectional {
+			fields = append(fields, pair.Original, pair.Replacement)
+		}
+		for _, field := range fields {
+			for _, m := range sharedSecretPlaceholderRegex.FindAllStringSubmatch(field, -1) {
+				if !IsTemplateVariable(m[1]) {
+					seen[m[1]] = true
+				}
+			}
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ExportProfile writes a profile to path as JSON, or as YAML if the file
+// ends in .yaml/.yml. Secret placeholders are kept as-is.
+func ExportProfile(profile ProfileConfig, path string) error {
+	shared := SharedProfile{
+		FormatVersion:   ProfileShareFormatVersion,
+		Profile:         profile,
+		RequiredSecrets: ReferencedSecrets(profile),
+	}
+
+	data, err := json.MarshalIndent(shared, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode profile: %w", err)
+	}
+
+	if isYAMLPath(path) {
+		// Go through the JSON representation so YAML uses the same field names
+		var generic interface{}
+		if err := json.Unmarshal(data, &generic); err != nil {
+			return fmt.Errorf("failed to convert profile for YAML: %w", err)
+		}
+		data, err = yaml.Marshal(generic)
+		if err != nil {
+			return fmt.Errorf("failed to encode profile as YAML: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write profile file: %w", err)
+	}
+	return nil
+}
+
+// ImportProfile reads a profile exported by ExportProfile and validates it.
+// A bare profile object (as found in config.json) is accepted as well.
+func ImportProfile(path string) (*SharedProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile file: %w", err)
+	}
+
+	if isYAMLPath(path) {
+		var generic interface{}
+		if err := yaml.Unmarshal(data, &generic); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML profile file: %w", err)
+		}
+		data, err = json.Marshal(generic)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert YAML profile file: %w", err)
+		}
+	}
+
+	var shared SharedProfile
+	if err := json.Unmarshal(data, &shared); err != nil {
+		return nil, fmt.Errorf("failed to parse profile file: %w", err)
+	}
+	if shared.Profile.Name == "" && shared.FormatVersion == 0 {
+		// Not wrapped; try a bare profile object
+		if err := json.Unmarshal(data, &shared.Profile); err != nil {
+			return nil, fmt.Errorf("failed to parse profile file: %w", err)
+		}
+	}
+	if shared.FormatVersion > ProfileShareFormatVersion {
+		return nil, fmt.Errorf("profile file format version %d is newer than supported version %d", shared.FormatVersion, ProfileShareFormatVersion)
+	}
+
+	if validationErrors := ValidateProfiles([]ProfileConfig{shared.Profile}); len(validationErrors) > 0 {
+		return nil, fmt.Errorf("invalid profile:\n  - %s", strings.Join(validationErrors, "\n  - "))
+	}
+
+	// Recompute from the rules rather than trusting the file
+	shared.RequiredSecrets = ReferencedSecrets(shared.Profile)
+	return &shared, nil
+}
+
+// ExternalCommands lists the programs run by the command rules of profile, so
+// that importing a shared profile can ask before accepting them.
+func ExternalCommands(profile ProfileConfig) []string {
+	var commands []string
+	for _, rep := range profile.Replacements {
+		if rep.Command != nil {
+			commands = append(commands, strings.Join(rep.Command.Args, " "))
+		}
+	}
+	return commands
+}
+
+// FindProfile returns the index of the profile with the given name, or -1.
+func (c *Config) FindProfile(name string) int {
+	for i, p := range c.Profiles {
+		if p.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// UniqueProfileName returns name, or name with a numbered " (imported)" suffix
+// if a profile with that name already exists.
+func (c *Config) UniqueProfileName(name string) string {
+	if c.FindProfile(name) < 0 {
+		return name
+	}
+	candidate := name + " (imported)"
+	for i := 2; c.FindProfile(candidate) >= 0; i++ {
+		candidate = fmt.Sprintf("%s (imported %d)", name, i)
+	}
+	return candidate
+}
+
+// MissingSecrets returns the names from required that are not declared in the
+// config's secrets map.
+func (c *Config) MissingSecrets(required []string) []string {
+	var missing []string
+	for _, name := range required {
+		if _, ok := c.Secrets[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// snippetRules returns the rules expanding the snippets of profile, longest
+// trigger first, so ";addr" doesn't take the start of ";addr2". A trigger
+// starting or ending with a letter, digit or underscore only matches as a
+// whole word there, so "sig" expands, but not inside "signal".
+func snippetRules(profile ProfileConfig) []Replacement {
+	triggers := make([]string, 0, len(profile.Snippets))
+	for trigger := range profile.Snippets {
+		triggers = append(triggers, trigger)
+	}
+	sort.Slice(triggers, func(i, j int) bool {
+		if len(triggers[i]) != len(triggers[j]) {
+			return len(triggers[i]) > len(triggers[j])
+		}
+		return triggers[i] < triggers[j]
+	})
+
+	rules := make([]Replacement, 0, len(triggers))
+	for _, trigger := range triggers {
+		regex := regexp.QuoteMeta(trigger)
+		if first, _ := utf8.DecodeRuneInString(trigger); isWordRune(first) {
+			regex = `\b` + regex
+		}
+		if last, _ := utf8.DecodeLastRuneInString(trigger); isWordRune(last) {
+			regex += `\b`
+		}
+		rules = append(rules, Replacement{
+			Regex:       regex,
+			ReplaceWith: strings.ReplaceAll(profile.Snippets[trigger], "$", "$$"), // The template is literal text
+			Description: "Snippet " + trigger,
+			snippet:     true,
+		})
+	}
+	return rules
+}
+
+// IsSnippet reports whether the rule expands a snippet of its profile. The
+// reverse hotkey leaves these rules out.
+func (r Replacement) IsSnippet() bool {
+	return r.snippet
+}
+
+// isWordRune reports whether \b treats r as part of a word.
+func isWordRune(r rune) bool {
+	return r < utf8.RuneSelf && (r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// validateSnippets checks the triggers of the profiles' snippets.
+func validateSnippets(cfg *Config) []string {
+	var validationErrors []string
+	for i, profile := range cfg.Profiles {
+		triggers := make([]string, 0, len(profile.Snippets))
+		for trigger := range profile.Snippets {
+			triggers = append(triggers, trigger)
+		}
+		sort.Strings(triggers)
+		for _, trigger := range triggers {
+			prefix := fmt.Sprintf("Profile[%d](%s).Snippets[%q]", i, profile.Name, trigger)
+			switch {
+			case trigger == "":
+				validationErrors = append(validationErrors, fmt.Sprintf("%s: trigger cannot be empty", prefix))
+			case strings.IndexFunc(trigger, unicode.IsSpace) >= 0:
+				validationErrors = append(validationErrors, fmt.Sprintf("%s: trigger cannot contain whitespace", prefix))
+			case strings.Contains(trigger, "{{"):
+				validationErrors = append(validationErrors, fmt.Sprintf("%s: trigger cannot contain placeholders", prefix))
+			}
+		}
+	}
+	return validationErrors
+}
@@ -0,0 +1,106 @@
+package config
+
+import "strings"
+
+// Sound events, played after a hotkey press
+const (
+	SoundSuccess = "success"  // The clipboard was changed
+	SoundNoMatch = "no_match" // No rule matched, or the clipboard was not processed
+	SoundError   = "error"    // A rule failed
+)
+
+// SoundNone mutes a single event in SoundConfig.
+const SoundNone = "none"
+
+// SoundConfig controls the audio cues played after a hotkey press, for when
+// notifications are hidden, e.g. behind full-screen applications.
+type SoundConfig struct {
+	Enabled bool   `json:"enabled"`
+	Success string `json:"success,omitempty"`  // Sound file; "" plays the system sound, "none" nothing
+	NoMatch string `json:"no_match,omitempty"` // Sound file; "" plays the system sound, "none" nothing
+	Error   string `json:"error,omitempty"`    // Sound file; "" plays the system sound, "none" nothing
+}
+
+// SoundsEnabled reports whether sounds are enabled globally.
+func (c *Config) SoundsEnabled() bool {
+	return c.Sounds != nil && c.Sounds.Enabled
+}
+
+// SetSoundsEnabled turns sounds on or off globally, keeping the sound files.
+func (c *Config) SetSoundsEnabled(enabled bool) {
+	if c.Sounds == nil {
+		c.Sounds = &SoundConfig{}
+	}
+	c.Sounds.Enabled = enabled
+}
+
+// SoundsEnabledForHotkey reports whether a press of hotkey plays a sound. A
+// profile's "sounds" setting overrides the global one; if several profiles
+// share the hotkey, a sound plays if any of them wants one. The smart hotkey
+// counts as the hotkey of every profile with content_types.
+func (c *Config) SoundsEnabledForHotkey(hotkey string, isReverse bool) bool {
+	smart := !isReverse && c.SmartHotkey != "" && hotkey == c.SmartHotkey
+	for _, profile := range c.Profiles {
+		if !profile.Enabled {
+			continue
+		}
+		switch {
+		case isReverse && profile.ReverseHotkey == hotkey:
+		case !isReverse && profile.Hotkey == hotkey:
+		case smart && len(profile.ContentTypes) > 0:
+		default:
+			continue
+		}
+		enabled := c.SoundsEnabled()
+		if profile.Sounds != nil {
+			enabled = *profile.Sounds
+		}
+		if enabled {
+			return true
+		}
+	}
+	return false
+}
+
+// SoundsEnabledForProfile reports whether sounds play when the profiles named
+// name are run by name, e.g. by "clipregex trigger".
+func (c *Config) SoundsEnabledForProfile(name string) bool {
+	for _, profile := range c.Profiles {
+		if profile.Name != name {
+			continue
+		}
+		enabled := c.SoundsEnabled()
+		if profile.Sounds != nil {
+			enabled = *profile.Sounds
+		}
+		if enabled {
+			return true
+		}
+	}
+	return false
+}
+
+// SoundFile returns the sound file configured for event, resolved relative to
+// config.json. It returns "" for the system sound and muted for "none".
+func (c *Config) SoundFile(event string) (path string, muted bool) {
+	if c.Sounds == nil {
+		return "", false
+	}
+	var file string
+	switch event {
+	case SoundSuccess:
+		file = c.Sounds.Success
+	case SoundNoMatch:
+		file = c.Sounds.NoMatch
+	case SoundError:
+		file = c.Sounds.Error
+	}
+	file = strings.TrimSpace(file)
+	if strings.EqualFold(file, SoundNone) {
+		return "", true
+	}
+	if file == "" {
+		return "", false
+	}
+	return c.ResolvePath(file), false
+}
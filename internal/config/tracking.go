@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/actions"
+)
+
+// TrackingParams adjusts the parameters the strip_tracking action removes:
+// params, domains and keep extend the bundled list, or the list in file.
+type TrackingParams struct {
+	File string `json:"file,omitempty"` // A list in the bundled format replacing the bundled one, e.g. a newer copy; relative to config.json
+	actions.TrackingRules
+}
+
+// TrackingRules returns the parameters the strip_tracking action removes with
+// this config. If the file can't be read, the bundled list is used.
+func (c *Config) TrackingRules() actions.TrackingRules {
+	if c.trackingRules != nil {
+		return *c.trackingRules
+	}
+	rules, _ := c.loadTrackingRules()
+	return rules
+}
+
+// loadTrackingRules combines the bundled list, or tracking_params.file, with
+// the additions of tracking_params.
+func (c *Config) loadTrackingRules() (actions.TrackingRules, error) {
+	rules := actions.BundledTrackingRules()
+	if c.TrackingParams == nil {
+		return rules, nil
+	}
+	var err error
+	if c.TrackingParams.File != "" {
+		var loaded actions.TrackingRules
+		if loaded, err = actions.LoadTrackingRules(c.ResolvePath(c.TrackingParams.File)); err == nil {
+			rules = loaded
+		}
+	}
+	return rules.With(c.TrackingParams.TrackingRules), err
+}
+
+// validateTrackingParams checks tracking_params and keeps the resulting list
+// for TrackingRules.
+func validateTrackingParams(cfg *Config) []string {
+	rules, err := cfg.loadTrackingRules()
+	if err != nil {
+		return []string{fmt.Sprintf("tracking_params.file: %v", err)}
+	}
+	if err := rules.Check(); err != nil {
+		return []string{fmt.Sprintf("tracking_params: %v", err)}
+	}
+	cfg.trackingRules = &rules
+	return nil
+}
@@ -0,0 +1,32 @@
+package config
+
+import "regexp"
+
+// Template variables are expanded in regex, replace_with and reverse_with
+// when a rule is applied, e.g. {{date:2006-01-02}}. Their names cannot be
+// used for secrets.
+const (
+	VariableDate     = "date"     // {{date}} or {{date:<Go time layout>}}
+	VariableTime     = "time"     // {{time}} or {{time:<Go time layout>}}
+	VariableDateTime = "datetime" // {{datetime}} or {{datetime:<Go time layout>}}
+	VariableUUID     = "uuid"     // A random UUID (version 4)
+	VariableCounter  = "counter"  // {{counter}} or {{counter:label}}, counting up per application
+	VariableCapture  = "var"      // {{var:name}}, the value an earlier rule captured with capture_as
+	VariableCursor   = "cursor"   // Where the cursor is put after pasting, e.g. inside a snippet
+)
+
+// TemplateVariables lists the names of all template variables.
+var TemplateVariables = []string{VariableDate, VariableTime, VariableDateTime, VariableUUID, VariableCounter, VariableCapture, VariableCursor}
+
+// captureNameRegex matches valid capture_as names.
+var captureNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// IsTemplateVariable reports whether name is reserved for a template variable.
+func IsTemplateVariable(name string) bool {
+	for _, variable := range TemplateVariables {
+		if name == variable {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+// Package crash writes a report to the crashes folder next to config.json
+// when the application panics, and builds a prefilled GitHub issue for it.
+// Reports describe the configuration without rule contents or secrets.
+package crash
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/update"
+)
+
+// DirName is the folder next to config.json holding crash reports.
+const DirName = "crashes"
+
+// maxReports is the number of crash reports kept; older ones are deleted.
+const maxReports = 20
+
+// issueStackBytes limits the stack included in an issue URL, which browsers
+// and GitHub cap at a few kilobytes.
+const issueStackBytes = 3000
+
+// Dir returns the crash report folder for the config file at configPath.
+func Dir(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), DirName)
+}
+
+// Report describes a panic.
+type Report struct {
+	Time    time.Time
+	Version string
+	Panic   string // The value passed to panic
+	Stack   string
+	Config  string // Summary without rule contents or secrets; empty if no config was loaded
+}
+
+// NewReport describes the panic value r with its stack. cfg may be nil.
+func NewReport(r any, stack, version string, cfg *config.Config) Report {
+	report := Report{Time: time.Now(), Version: version, Panic: fmt.Sprint(r), Stack: stack}
+	if cfg != nil {
+		report.Config = Summary(cfg)
+	}
+	return report
+}
+
+// platform returns the operating system and architecture of the build.
+func platform() string {
+	return fmt.Sprintf("%s/%s, %s", runtime.GOOS, runtime.GOARCH, runtime.Version())
+}
+
+// String formats the report as written to the crash file.
+func (r Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Clipboard Regex Replace %s crash report\n", r.Version)
+	fmt.Fprintf(&b, "Time: %s\n", r.Time.Format(time.RFC3339))
+	fmt.Fprintf(&b, "Platform: %s\n", platform())
+	fmt.Fprintf(&b, "Panic: %s\n\n", r.Panic)
+	fmt.Fprintf(&b, "Stack:\n%s\n", strings.TrimRight(r.Stack, "\n"))
+	if r.Config != "" {
+		fmt.Fprintf(&b, "\nConfiguration (rule contents and secrets omitted):\n%s", r.Config)
+	}
+	fmt.Fprintf(&b, "\nTo report this crash, open:\n%s\n", r.IssueURL())
+	return b.String()
+}
+
+// IssueURL returns the URL of a new GitHub issue prefilled with the version,
+// platform, panic and the start of the stack. The configuration summary is
+// left out; the issue asks to attach the report after checking it.
+func (r Report) IssueURL() string {
+	title := r.Panic
+	if runes := []rune(title); len(runes) > 80 {
+		title = string(runes[:79]) + "…"
+	}
+	stack := r.Stack
+	if len(stack) > issueStackBytes {
+		stack = stack[:issueStackBytes] + "\n..."
+	}
+	var body strings.Builder
+	fmt.Fprintf(&body, "**Version:** %s\n**Platform:** %s\n**Panic:** `%s`\n\n", r.Version, platform(), r.Panic)
+	fmt.Fprintf(&body, "**What were you doing when it crashed?**\n\n\n")
+	fmt.Fprintf(&body, "<details><summary>Stack</summary>\n\n```\n%s\n```\n</details>\n\n", strings.TrimRight(stack, "\n"))
+	fmt.Fprintf(&body, "The full report is in the `%s` folder next to config.json. Please attach it after checking that it contains nothing private.\n", DirName)
+
+	query := url.Values{}
+	query.Set("title", "Crash: "+title)
+	query.Set("body", body.String())
+	return "https://github.com/" + update.Repository + "/issues/new?" + query.Encode()
+}
+
+// Write saves report to dir as crash-<time>.txt and deletes the oldest
+// reports beyond maxReports. It returns the path of the new report.
+func Write(dir string, report Report) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create crash report folder: %w", err)
+	}
+	path := filepath.Join(dir, "crash-"+report.Time.Format("20060102-150405")+".txt")
+	if err := os.WriteFile(path, []byte(report.String()), 0600); err != nil {
+		return "", fmt.Errorf("failed to write crash report: %w", err)
+	}
+	prune(dir)
+	return path, nil
+}
+
+// prune deletes the oldest crash reports in dir beyond maxReports. The
+// timestamped names sort by age.
+func prune(dir string) {
+	reports, err := filepath.Glob(filepath.Join(dir, "crash-*.txt"))
+	if err != nil || len(reports) <= maxReports {
+		return
+	}
+	sort.Strings(reports)
+	for _, old := range reports[:len(reports)-maxReports] {
+		os.Remove(old)
+	}
+}
+
+// Summary describes the settings and profiles of cfg for a crash report:
+// profile names, hotkeys and rule counts, but no regexes, replacement texts,
+// secret names or secret values.
+func Summary(cfg *config.Config) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Admin notifications: %s, replacement notifications: %t\n", cfg.AdminNotificationLevel, cfg.NotifyOnReplacement)
+	fmt.Fprintf(&b, "Temporary clipboard: %t, automatic reversion: %t, revert hotkey set: %t\n", cfg.TemporaryClipboard, cfg.AutomaticReversion, cfg.RevertHotkey != "")
+	fmt.Fprintf(&b, "Type hotkey set: %t, smart hotkey set: %t, group hotkeys: %d\n", cfg.TypeHotkey != "", cfg.SmartHotkey != "", len(cfg.GroupHotkeys))
+	fmt.Fprintf(&b, "Secrets: %d, secret store: %s, rule groups: %d, content type rules: %d\n", len(cfg.Secrets), cfg.SecretStore, len(cfg.RuleGroups), len(cfg.ContentTypeRules))
+	fmt.Fprintf(&b, "Language: %s, history: %t, sounds: %t, llm: %t\n", cfg.Language, cfg.HistoryEnabled(), cfg.Sounds != nil && cfg.Sounds.Enabled, cfg.LLMEnabled())
+	fmt.Fprintf(&b, "Profiles: %d\n", len(cfg.Profiles))
+	for i, profile := range cfg.Profiles {
+		actions, disabled := 0, 0
+		for _, rep := range profile.Replacements {
+			if rep.IsTransform() {
+				actions++
+			}
+			if rep.Disabled {
+				disabled++
+			}
+		}
+		fmt.Fprintf(&b, "  #%d '%s': enabled %t, hotkey %q, reverse hotkey %q, %d rule(s) (%d action/script/command, %d disabled)",
+			i+1, profile.Name, profile.Enabled, profile.Hotkey, profile.ReverseHotkey, len(profile.Replacements), actions, disabled)
+		if profile.OutputMode != "" {
+			fmt.Fprintf(&b, ", output %s", profile.OutputMode)
+		}
+		if len(profile.IncludeGroups) > 0 {
+			fmt.Fprintf(&b, ", %d included group(s)", len(profile.IncludeGroups))
+		}
+		if profile.RoundTrip {
+			b.WriteString(", round trip")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
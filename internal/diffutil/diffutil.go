@@ -12,27 +12,7 @@ import (
 
 // GenerateDiffAndSummary builds a pure line‑based diff and a short summary.
 func GenerateDiffAndSummary(original, modified string) (diffs []diffmatchpatch.Diff, summary string) {
-	dmp := diffmatchpatch.New()
-	dmp.DiffTimeout = 5 * time.Second
-
-	// ------------------------------------------------------------------
-	// 1. Collapse every *physical* line into a single rune.
-	// ------------------------------------------------------------------
-	a, b, lineArray := dmp.DiffLinesToRunes(original, modified)
-
-	// ------------------------------------------------------------------
-	// 2. Run the diff on those rune slices – checklines MUST be false
-	//    because we already chunked input by line.
-	// ------------------------------------------------------------------
-	diffs = dmp.DiffMainRunes(a, b, false)
-
-	// ------------------------------------------------------------------
-	// 3. Expand the runes back to the original lines.
-	// ------------------------------------------------------------------
-	diffs = dmp.DiffCharsToLines(diffs, lineArray)
-
-	// Optional, but makes the output a bit cleaner (splits huge replace blocks).
-	dmp.DiffCleanupSemanticLossless(diffs)
+	diffs = lineDiffs(original, modified)
 
 	// ------------------------------------------------------------------
 	// Build a simple human‑readable summary
@@ -60,6 +40,33 @@ func GenerateDiffAndSummary(original, modified string) (diffs []diffmatchpatch.D
 	return diffs, buf.String()
 }
 
+// lineDiffs returns the line-based diff of original and modified.
+func lineDiffs(original, modified string) []diffmatchpatch.Diff {
+	dmp := diffmatchpatch.New()
+	dmp.DiffTimeout = 5 * time.Second
+
+	// ------------------------------------------------------------------
+	// 1. Collapse every *physical* line into a single rune.
+	// ------------------------------------------------------------------
+	a, b, lineArray := dmp.DiffLinesToRunes(original, modified)
+
+	// ------------------------------------------------------------------
+	// 2. Run the diff on those rune slices – checklines MUST be false
+	//    because we already chunked input by line.
+	// ------------------------------------------------------------------
+	diffs := dmp.DiffMainRunes(a, b, false)
+
+	// ------------------------------------------------------------------
+	// 3. Expand the runes back to the original lines.
+	// ------------------------------------------------------------------
+	diffs = dmp.DiffCharsToLines(diffs, lineArray)
+
+	// Optional, but makes the output a bit cleaner (splits huge replace blocks).
+	dmp.DiffCleanupSemanticLossless(diffs)
+
+	return diffs
+}
+
 // lineCount returns the number of *physical* lines in the snippet.
 func lineCount(s string) int {
 	if s == "" {
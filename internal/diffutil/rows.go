@@ -0,0 +1,272 @@
+// internal/diffutil/rows.go
+package diffutil
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// Granularity controls how finely changed lines are compared.
+type Granularity int
+
+const (
+	GranularityLine Granularity = iota // Whole lines are marked as changed
+	GranularityWord                    // Changed words within a line are highlighted
+	GranularityChar                    // Changed characters within a line are highlighted
+)
+
+// ParseGranularity parses "line", "word" or "char" (case-insensitive).
+// Anything else yields word granularity and ok = false.
+func ParseGranularity(s string) (g Granularity, ok bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "line":
+		return GranularityLine, true
+	case "word":
+		return GranularityWord, true
+	case "char":
+		return GranularityChar, true
+	}
+	return GranularityWord, false
+}
+
+// String returns the name ParseGranularity accepts.
+func (g Granularity) String() string {
+	switch g {
+	case GranularityLine:
+		return "line"
+	case GranularityChar:
+		return "char"
+	default:
+		return "word"
+	}
+}
+
+// Row kinds of a diff row.
+const (
+	RowEqual  = "equal"  // Line is unchanged
+	RowChange = "change" // Original line was replaced by the modified line
+	RowDelete = "delete" // Line only exists in the original
+	RowInsert = "insert" // Line only exists in the modified text
+)
+
+// Segment is a piece of a line, flagged when it differs from the other side.
+type Segment struct {
+	Text    string `json:"text"`
+	Changed bool   `json:"changed,omitempty"`
+}
+
+// Row is one line pair of a side-by-side diff. Changed lines are paired up
+// and, at word or character granularity, carry segments that mark the
+// changed parts, so a small substitution inside a long line is highlighted
+// on its own.
+type Row struct {
+	Kind    string    `json:"kind"`
+	OrigNum int       `json:"origNum,omitempty"` // 1-based line number in the original, 0 if none
+	ModNum  int       `json:"modNum,omitempty"`  // 1-based line number in the modified text, 0 if none
+	Orig    []Segment `json:"orig"`              // nil if the line only exists in the modified text
+	Mod     []Segment `json:"mod"`               // nil if the line only exists in the original
+}
+
+// BuildRows returns the line pairs of the diff between original and modified.
+func BuildRows(original, modified string, granularity Granularity) []Row {
+	var rows []Row
+	origNum, modNum := 1, 1
+	var deleted, inserted []string
+
+	// flush pairs pending deleted and inserted lines into change rows and
+	// emits the rest as plain deletes or inserts.
+	flush := func() {
+		for i := 0; i < len(deleted) || i < len(inserted); i++ {
+			switch {
+			case i < len(deleted) && i < len(inserted):
+				orig, mod := lineSegments(deleted[i], inserted[i], granularity)
+				rows = append(rows, Row{Kind: RowChange, OrigNum: origNum, ModNum: modNum, Orig: orig, Mod: mod})
+				origNum++
+				modNum++
+			case i < len(deleted):
+				rows = append(rows, Row{Kind: RowDelete, OrigNum: origNum, Orig: []Segment{{Text: deleted[i], Changed: true}}})
+				origNum++
+			default:
+				rows = append(rows, Row{Kind: RowInsert, ModNum: modNum, Mod: []Segment{{Text: inserted[i], Changed: true}}})
+				modNum++
+			}
+		}
+		deleted, inserted = nil, nil
+	}
+
+	for _, d := range lineDiffs(original, modified) {
+		lines := splitLines(d.Text)
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			deleted = append(deleted, lines...)
+		case diffmatchpatch.DiffInsert:
+			inserted = append(inserted, lines...)
+		case diffmatchpatch.DiffEqual:
+			flush()
+			for _, line := range lines {
+				segment := []Segment{{Text: line}}
+				rows = append(rows, Row{Kind: RowEqual, OrigNum: origNum, ModNum: modNum, Orig: segment, Mod: segment})
+				origNum++
+				modNum++
+			}
+		}
+	}
+	flush()
+
+	return rows
+}
+
+// splitLines splits text into lines without their line breaks.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+// lineSegments compares two lines at the given granularity and returns the
+// segments of each side.
+func lineSegments(original, modified string, granularity Granularity) (orig, mod []Segment) {
+	var diffs []diffmatchpatch.Diff
+	switch granularity {
+	case GranularityLine:
+		return []Segment{{Text: original, Changed: true}}, []Segment{{Text: modified, Changed: true}}
+	case GranularityChar:
+		dmp := diffmatchpatch.New()
+		dmp.DiffTimeout = time.Second
+		diffs = dmp.DiffMain(original, modified, false)
+		diffs = dmp.DiffCleanupSemanticLossless(diffs)
+	default:
+		diffs = tokenDiff(splitWords(original), splitWords(modified))
+	}
+
+	orig, mod = []Segment{}, []Segment{} // Non-nil even for empty lines
+	for _, d := range diffs {
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			orig = appendSegment(orig, d.Text, false)
+			mod = appendSegment(mod, d.Text, false)
+		case diffmatchpatch.DiffDelete:
+			orig = appendSegment(orig, d.Text, true)
+		case diffmatchpatch.DiffInsert:
+			mod = appendSegment(mod, d.Text, true)
+		}
+	}
+	return orig, mod
+}
+
+// WordDiffs compares two texts word by word, like the "word" granularity.
+func WordDiffs(original, modified string) []diffmatchpatch.Diff {
+	return tokenDiff(splitWords(original), splitWords(modified))
+}
+
+// appendSegment adds text to segments, merging it with the last segment if
+// both have the same state.
+func appendSegment(segments []Segment, text string, changed bool) []Segment {
+	if text == "" {
+		return segments
+	}
+	if n := len(segments); n > 0 && segments[n-1].Changed == changed {
+		segments[n-1].Text += text
+		return segments
+	}
+	return append(segments, Segment{Text: text, Changed: changed})
+}
+
+// splitWords cuts a line into words (letters, digits and underscores),
+// whitespace runs and single punctuation characters.
+func splitWords(line string) []string {
+	var tokens []string
+	start := -1
+	class := 0
+	for i, r := range line {
+		c := runeClass(r)
+		if start >= 0 && (c != class || c == classOther) {
+			tokens = append(tokens, line[start:i])
+			start = -1
+		}
+		if start < 0 {
+			start = i
+			class = c
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, line[start:])
+	}
+	return tokens
+}
+
+const (
+	classWord = iota + 1
+	classSpace
+	classOther
+)
+
+func runeClass(r rune) int {
+	switch {
+	case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+		return classWord
+	case unicode.IsSpace(r):
+		return classSpace
+	default:
+		return classOther
+	}
+}
+
+// tokenDiff diffs two token lists by mapping every distinct token to a
+// single rune, the same trick DiffLinesToRunes uses for lines.
+func tokenDiff(a, b []string) []diffmatchpatch.Diff {
+	index := make(map[string]rune)
+	var tokens []string
+	toRunes := func(list []string) []rune {
+		runes := make([]rune, len(list))
+		for i, token := range list {
+			r, ok := index[token]
+			if !ok {
+				r = tokenRune(len(tokens))
+				index[token] = r
+				tokens = append(tokens, token)
+			}
+			runes[i] = r
+		}
+		return runes
+	}
+	runesA, runesB := toRunes(a), toRunes(b)
+
+	dmp := diffmatchpatch.New()
+	dmp.DiffTimeout = time.Second
+	diffs := dmp.DiffMainRunes(runesA, runesB, false)
+
+	for i, d := range diffs {
+		var text strings.Builder
+		for _, r := range d.Text {
+			text.WriteString(tokens[tokenIndex(r)])
+		}
+		diffs[i].Text = text.String()
+	}
+	return diffs
+}
+
+// tokenRune maps a token index to a rune, skipping the surrogate range that
+// can't survive the conversion to a string.
+func tokenRune(i int) rune {
+	if i >= 0xD800 {
+		return rune(i + 0x800)
+	}
+	return rune(i)
+}
+
+// tokenIndex is the inverse of tokenRune.
+func tokenIndex(r rune) int {
+	if r >= 0xE000 {
+		return int(r) - 0x800
+	}
+	return int(r)
+}
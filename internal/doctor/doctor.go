@@ -0,0 +1,232 @@
+// Package doctor checks whether the application can work on this system:
+// clipboard access, global hotkeys, the automatic paste, the secret store,
+// notifications and the config file. "clipregex doctor" and the tray's "Run
+// Diagnostics" show the same report.
+package doctor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/clipboard"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/clipboard/backend"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/hotkey"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/ui"
+)
+
+// Status is the outcome of a check.
+type Status int
+
+const (
+	Pass Status = iota
+	Warn        // Works with limitations
+	Fail
+)
+
+// Mark returns the symbol printed before a check with this status.
+func (s Status) Mark() string {
+	switch s {
+	case Pass:
+		return "✓"
+	case Warn:
+		return "!"
+	default:
+		return "✗"
+	}
+}
+
+// Check is the result of one diagnostic.
+type Check struct {
+	Name   string // English; translated by String
+	Status Status
+	Detail string // Already translated
+}
+
+func (c Check) String() string {
+	return fmt.Sprintf("%s %s: %s", c.Status.Mark(), i18n.T(c.Name), c.Detail)
+}
+
+// Run checks the system and the config file at configPath. It reads the
+// clipboard without changing it and shows a test notification.
+func Run(configPath string) []Check {
+	cfg, configCheck := checkConfig(configPath)
+	return []Check{
+		checkClipboard(),
+		checkHotkeys(),
+		checkPaste(),
+		checkSecretStore(cfg),
+		checkNotifications(),
+		configCheck,
+	}
+}
+
+// Summary counts the checks that passed and failed.
+func Summary(checks []Check) (passed, failed int) {
+	for _, check := range checks {
+		switch check.Status {
+		case Pass:
+			passed++
+		case Fail:
+			failed++
+		}
+	}
+	return passed, failed
+}
+
+// ConfigProblems returns what loading a config does not check: hotkeys
+// the platform cannot register and secrets used by rules but not declared.
+func ConfigProblems(cfg *config.Config) []string {
+	var problems []string
+	checkHotkey := func(owner, field, value string) {
+		if strings.TrimSpace(value) == "" {
+			return
+		}
+		if err := hotkey.ValidateHotkey(value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s '%s': %v", owner, field, value, err))
+		}
+	}
+	checkHotkey("Global settings", "revert_hotkey", cfg.RevertHotkey)
+	checkHotkey("Global settings", "type_hotkey", cfg.TypeHotkey)
+	checkHotkey("Global settings", "smart_hotkey", cfg.SmartHotkey)
+	checkHotkey("Global settings", "clear_hotkey", cfg.ClearHotkey)
+	checkHotkey("Global settings", "undo_hotkey", cfg.UndoHotkey)
+	checkHotkey("Global settings", "redo_hotkey", cfg.RedoHotkey)
+	for _, group := range cfg.ProfileGroupNames() {
+		checkHotkey(fmt.Sprintf("Group '%s'", group), "group_hotkeys", cfg.GroupHotkeys[group])
+	}
+	lockHotkeys := make([]string, 0, len(cfg.HotkeyLockKeys))
+	for lockHotkey := range cfg.HotkeyLockKeys {
+		lockHotkeys = append(lockHotkeys, lockHotkey)
+	}
+	sort.Strings(lockHotkeys)
+	for _, lockHotkey := range lockHotkeys {
+		checkHotkey("Global settings", "hotkey_lock_keys", lockHotkey)
+	}
+
+	for _, profile := range cfg.ResolvedProfiles() {
+		owner := fmt.Sprintf("Profile '%s'", profile.Name)
+		checkHotkey(owner, "hotkey", profile.Hotkey)
+		checkHotkey(owner, "reverse_hotkey", profile.ReverseHotkey)
+		for _, name := range cfg.MissingSecrets(config.ReferencedSecrets(profile)) {
+			problems = append(problems, fmt.Sprintf("%s: secret '{{%s}}' is not declared in \"secrets\"", owner, name))
+		}
+	}
+	return problems
+}
+
+// checkConfig loads the config without secrets, so the secret store is
+// checked separately. cfg is nil if the file cannot be loaded.
+func checkConfig(configPath string) (*config.Config, Check) {
+	check := Check{Name: "Configuration"}
+	cfg, err := config.LoadWithoutSecrets(configPath)
+	if err != nil {
+		check.Status = Fail
+		check.Detail = i18n.Tf("%s is invalid: %v", configPath, err)
+		return nil, check
+	}
+	if problems := ConfigProblems(cfg); len(problems) > 0 {
+		check.Status = Fail
+		check.Detail = i18n.Tf("%s has %d problem(s): %s", configPath, len(problems), strings.Join(problems, "; "))
+		return cfg, check
+	}
+	rules := 0
+	for _, profile := range cfg.ResolvedProfiles() {
+		rules += len(profile.Replacements)
+	}
+	check.Detail = i18n.Tf("%s is valid (%d profile(s), %d rule(s)).", configPath, len(cfg.Profiles), rules)
+	return cfg, check
+}
+
+// checkClipboard reads the clipboard with the backend the application uses.
+func checkClipboard() Check {
+	check := Check{Name: "Clipboard access"}
+	b := backend.SelectBackend()
+	if !b.IsAvailable() {
+		check.Status = Fail
+		check.Detail = i18n.Tf("%s is not available, and no other clipboard backend is.", b.Name())
+		return check
+	}
+	text, err := b.ReadText()
+	if err != nil {
+		check.Status = Fail
+		check.Detail = i18n.Tf("%s could not read the clipboard: %v", b.Name(), err)
+		return check
+	}
+	check.Detail = i18n.Tf("%s can read the clipboard (%d characters).", b.Name(), len([]rune(text)))
+	return check
+}
+
+// checkHotkeys looks for a backend that can register global hotkeys.
+func checkHotkeys() Check {
+	check := Check{Name: "Global hotkeys"}
+	display := hotkey.DetectDisplayServer()
+	b := hotkey.SelectBackend()
+	if b == nil {
+		check.Status = Fail
+		check.Detail = i18n.Tf("No hotkey backend works with the %s display server, so hotkeys cannot be registered. The app runs in limited mode: it watches the clipboard (clipboard_watch) and profiles can be run from the tray menu.", display)
+		return check
+	}
+	check.Detail = i18n.Tf("%s on %s.", b.Name(), display)
+	return check
+}
+
+// checkPaste reports the tool or API that simulates the paste keystroke.
+func checkPaste() Check {
+	check := Check{Name: "Automatic paste"}
+	method, problem := clipboard.PasteSupport()
+	switch {
+	case problem == nil:
+		check.Detail = i18n.Tf("Uses %s.", method)
+	case method != "":
+		check.Status = Warn
+		check.Detail = i18n.Tf("Uses %s, with limitations: %s", method, i18n.T(problem.Message))
+	default:
+		check.Status = Fail
+		check.Detail = i18n.T(problem.Message)
+	}
+	return check
+}
+
+// checkSecretStore opens the store of "managed" secrets. A store that can't
+// be opened only fails the check if the config declares secrets kept there.
+func checkSecretStore(cfg *config.Config) Check {
+	check := Check{Name: "Secret store"}
+	if cfg == nil {
+		check.Status = Warn
+		check.Detail = i18n.T("Skipped, as the configuration could not be loaded.")
+		return check
+	}
+	store, count, err := cfg.CheckSecretStore()
+	if err != nil {
+		check.Status = Warn
+		managed := 0
+		for _, name := range cfg.GetSecretNames() {
+			if cfg.IsKeyringSecret(name) {
+				managed++
+			}
+		}
+		if managed > 0 {
+			check.Status = Fail
+		}
+		check.Detail = i18n.Tf("The %s is not accessible (%d managed secret(s) declared): %v", store, managed, err)
+		return check
+	}
+	check.Detail = i18n.Tf("The %s is accessible (%d secret(s) stored).", store, count)
+	return check
+}
+
+// checkNotifications shows a test notification. Whether it appears can only
+// be seen by the user; an error means it was not delivered.
+func checkNotifications() Check {
+	check := Check{Name: "Notifications"}
+	if err := ui.SendTestNotification("Diagnostics", "This is a test notification from the diagnostics."); err != nil {
+		check.Status = Fail
+		check.Detail = i18n.Tf("Could not show a notification: %v", err)
+		return check
+	}
+	check.Detail = i18n.T("A test notification was sent. If it did not appear, check the system's notification settings.")
+	return check
+}
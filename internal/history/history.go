@@ -0,0 +1,209 @@
+// Package history keeps the recent clipboard transformations, the original
+// text and the result, so "View Last Change Details" and reverting survive a
+// restart. The file is encrypted with AES-256-GCM; the key is kept in the
+// secret store, not next to the file.
+package history
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileName is the history file stored next to config.json.
+const FileName = "history.enc"
+
+// KeySize is the length of the encryption key in bytes.
+const KeySize = 32
+
+// fileMagic starts every history file, followed by the nonce and the sealed
+// JSON list of entries.
+var fileMagic = []byte("CRRHIST1")
+
+// ErrUndecryptable is returned by Open if the file was encrypted with another
+// key, e.g. after the secret store was reset.
+var ErrUndecryptable = errors.New("the history file cannot be decrypted with the stored key")
+
+// Entry is one transformation of the clipboard.
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Profiles []string  `json:"profiles,omitempty"`
+	Original string    `json:"original"`
+	Result   string    `json:"result"`
+}
+
+// Store holds the entries and writes them to the encrypted file.
+type Store struct {
+	mu         sync.Mutex
+	path       string
+	aead       cipher.AEAD
+	maxEntries int
+	maxAge     time.Duration
+	entries    []Entry // Oldest first
+}
+
+// PathForConfig returns the history file path for a config file path.
+func PathForConfig(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), FileName)
+}
+
+// Open reads the history file at path with key. A missing file yields an
+// empty store. Entries beyond maxEntries or older than maxAge are dropped.
+func Open(path string, key []byte, maxEntries int, maxAge time.Duration) (*Store, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("the history key must be %d bytes, not %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{path: path, aead: aead, maxEntries: maxEntries, maxAge: maxAge}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read history file '%s': %w", path, err)
+	}
+	plain, err := s.open(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(plain, &s.entries); err != nil {
+		return nil, fmt.Errorf("failed to parse history file '%s': %w", path, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pruneLocked() {
+		if err := s.saveLocked(); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	log.Printf("Loaded %d history entr(ies) from %s", len(s.entries), path)
+	return s, nil
+}
+
+// Remove deletes the history file at path, e.g. while history is disabled.
+func Remove(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete history file '%s': %w", path, err)
+	}
+	return nil
+}
+
+// SetRetention changes the limits, e.g. after the config was reloaded, and
+// drops the entries beyond them.
+func (s *Store) SetRetention(maxEntries int, maxAge time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxEntries, s.maxAge = maxEntries, maxAge
+	if s.pruneLocked() {
+		return s.saveLocked()
+	}
+	return nil
+}
+
+// Add appends an entry and saves the file.
+func (s *Store) Add(entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry)
+	s.pruneLocked()
+	return s.saveLocked()
+}
+
+// Last returns the newest entry.
+func (s *Store) Last() (Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.entries) == 0 {
+		return Entry{}, false
+	}
+	return s.entries[len(s.entries)-1], true
+}
+
+// Entries returns a copy of the entries, oldest first.
+func (s *Store) Entries() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Entry(nil), s.entries...)
+}
+
+// Clear drops all entries and deletes the file.
+func (s *Store) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = nil
+	log.Println("History cleared.")
+	return Remove(s.path)
+}
+
+// pruneLocked drops entries beyond the limits and reports whether any were
+// dropped. The caller must hold s.mu.
+func (s *Store) pruneLocked() bool {
+	before := len(s.entries)
+	if s.maxAge > 0 {
+		cutoff := time.Now().Add(-s.maxAge)
+		i := 0
+		for i < len(s.entries) && s.entries[i].Time.Before(cutoff) {
+			i++
+		}
+		s.entries = s.entries[i:]
+	}
+	if s.maxEntries > 0 && len(s.entries) > s.maxEntries {
+		s.entries = s.entries[len(s.entries)-s.maxEntries:]
+	}
+	return len(s.entries) != before
+}
+
+// saveLocked encrypts the entries and replaces the file. The caller must
+// hold s.mu.
+func (s *Store) saveLocked() error {
+	if len(s.entries) == 0 {
+		return Remove(s.path)
+	}
+	plain, err := json.Marshal(s.entries)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to create a nonce for the history file: %w", err)
+	}
+	data := append(append(append([]byte(nil), fileMagic...), nonce...), s.aead.Seal(nil, nonce, plain, fileMagic)...)
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write history file '%s': %w", tmp, err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace history file '%s': %w", s.path, err)
+	}
+	return nil
+}
+
+// open decrypts the contents of a history file.
+func (s *Store) open(data []byte) ([]byte, error) {
+	header := len(fileMagic) + s.aead.NonceSize()
+	if len(data) < header || string(data[:len(fileMagic)]) != string(fileMagic) {
+		return nil, fmt.Errorf("'%s' is not a history file", s.path)
+	}
+	plain, err := s.aead.Open(nil, data[len(fileMagic):header], data[header:], fileMagic)
+	if err != nil {
+		return nil, ErrUndecryptable
+	}
+	return plain, nil
+}
@@ -32,6 +32,10 @@ type RegisteredHotkey interface {
 	// Keydown returns a channel that receives events when the key is pressed.
 	Keydown() <-chan struct{}
 
+	// Keyup returns a channel that receives events when the key is released.
+	// Events are dropped while nobody is receiving.
+	Keyup() <-chan struct{}
+
 	// Close cleans up resources associated with this hotkey.
 	// After calling Close, the Keydown channel should not be used.
 	Close() error
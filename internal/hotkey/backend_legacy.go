@@ -16,6 +16,7 @@ type LegacyBackend struct {
 	mu               sync.RWMutex
 	registeredKeys   map[string]*legacyHotkey
 	displayServer    DisplayServer
+	lockKeys         func(combo string) lockKeys // Lock keys each hotkey tolerates; all if nil
 }
 
 // NewLegacyBackend creates a new legacy backend using golang.design/x/hotkey.
@@ -29,6 +30,14 @@ func NewLegacyBackend() *LegacyBackend {
 	}
 }
 
+// setLockKeys sets how the lock keys a hotkey tolerates are looked up when
+// it is registered.
+func (b *LegacyBackend) setLockKeys(lookup func(combo string) lockKeys) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lockKeys = lookup
+}
+
 // Name returns the name of this backend.
 func (b *LegacyBackend) Name() string {
 	return "Legacy (golang.design/x/hotkey)"
@@ -69,21 +78,36 @@ func (b *LegacyBackend) Register(hotkeyStr string) (RegisteredHotkey, error) {
 		return nil, fmt.Errorf("failed to parse hotkey '%s': %w", hotkeyStr, err)
 	}
 
-	// Create and register the hotkey using the existing library
-	hk := hotkey.New(modifiers, key)
-	if err := hk.Register(); err != nil {
-		return nil, fmt.Errorf("failed to register hotkey '%s': %w", hotkeyStr, err)
+	// Register every tolerated lock-modifier variant (NumLock/CapsLock on
+	// X11) so the hotkey still triggers while those are active
+	locks := anyLockKeys
+	if b.lockKeys != nil {
+		locks = b.lockKeys(hotkeyStr)
+	}
+	var hks []*hotkey.Hotkey
+	for _, mods := range expandModifiers(modifiers, locks) {
+		hk := hotkey.New(mods, key)
+		if err := hk.Register(); err != nil {
+			// Best-effort rollback for any already registered variants.
+			for _, registered := range hks {
+				_ = registered.Unregister()
+			}
+			return nil, fmt.Errorf("failed to register hotkey '%s': %w", hotkeyStr, err)
+		}
+		hks = append(hks, hk)
 	}
 
 	// Wrap in our interface
 	wrapped := &legacyHotkey{
-		hotkey:    hk,
+		hotkeys:   hks,
 		hotkeyStr: hotkeyStr,
+		locks:     locks,
 		keydownCh: make(chan struct{}),
+		keyupCh:   make(chan struct{}, 1),
 		stopCh:    make(chan struct{}),
 	}
 
-	// Start the event converter goroutine
+	// Start the event converter goroutines
 	wrapped.startEventConverter()
 
 	b.registeredKeys[hotkeyStr] = wrapped
@@ -130,12 +154,16 @@ func (b *LegacyBackend) UnregisterAll() error {
 	return nil
 }
 
-// legacyHotkey wraps golang.design/x/hotkey.Hotkey to implement RegisteredHotkey interface.
+// legacyHotkey wraps the golang.design/x/hotkey.Hotkey variants of one
+// hotkey string to implement RegisteredHotkey interface.
 type legacyHotkey struct {
-	hotkey    *hotkey.Hotkey
+	hotkeys   []*hotkey.Hotkey
 	hotkeyStr string
+	locks     lockKeys      // Checked on keydown where the registration can't match the lock state
 	keydownCh chan struct{} // Converted channel for interface compatibility
-	stopCh    chan struct{} // Signal to stop the converter goroutine
+	keyupCh   chan struct{} // Buffered; releases are dropped if nobody listens
+	stopCh    chan struct{} // Signal to stop the converter goroutines
+	closeOnce sync.Once
 }
 
 // Keydown returns the channel that receives keydown events.
@@ -143,49 +171,74 @@ func (lh *legacyHotkey) Keydown() <-chan struct{} {
 	return lh.keydownCh
 }
 
-// startEventConverter converts hotkey.Event channel to struct{} channel.
-// This bridges the golang.design/x/hotkey API with our Backend interface.
+// Keyup returns the channel that receives keyup events.
+func (lh *legacyHotkey) Keyup() <-chan struct{} {
+	return lh.keyupCh
+}
+
+// startEventConverter converts the hotkey.Event channels of all variants to
+// one struct{} channel per event type. This bridges the golang.design/x/hotkey API with our
+// Backend interface.
 func (lh *legacyHotkey) startEventConverter() {
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				log.Printf("RECOVERED FROM PANIC IN LEGACY HOTKEY CONVERTER (%s): %v", lh.hotkeyStr, r)
-			}
-		}()
-
-		for {
-			select {
-			case <-lh.stopCh:
-				close(lh.keydownCh)
-				return
-			case <-lh.hotkey.Keydown():
-				// Convert Event to struct{} by just signaling on our channel
+	var wg sync.WaitGroup
+	for idx, hk := range lh.hotkeys {
+		wg.Add(1)
+		go func(hk *hotkey.Hotkey, variantIndex int) {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("RECOVERED FROM PANIC IN LEGACY HOTKEY CONVERTER (%s, variant %d): %v", lh.hotkeyStr, variantIndex, r)
+				}
+			}()
+
+			for {
 				select {
-				case lh.keydownCh <- struct{}{}:
 				case <-lh.stopCh:
-					close(lh.keydownCh)
 					return
+				case <-hk.Keydown():
+					if !lh.locks.allows() {
+						log.Printf("Legacy backend: Ignoring '%s', NumLock or CapsLock is on (lock_keys)", lh.hotkeyStr)
+						continue
+					}
+					// Convert Event to struct{} by just signaling on our channel
+					select {
+					case lh.keydownCh <- struct{}{}:
+					case <-lh.stopCh:
+						return
+					}
+				case <-hk.Keyup():
+					// Always drain the library's channel, but never block on ours
+					select {
+					case lh.keyupCh <- struct{}{}:
+					default:
+					}
 				}
 			}
-		}
+		}(hk, idx)
+	}
+
+	// Close the shared channels once every converter has stopped
+	go func() {
+		wg.Wait()
+		close(lh.keydownCh)
+		close(lh.keyupCh)
 	}()
 }
 
-// Close unregisters the hotkey and cleans up resources.
+// Close unregisters all variants of the hotkey and cleans up resources.
 func (lh *legacyHotkey) Close() error {
-	if lh.hotkey == nil {
-		return nil
-	}
-
-	// Signal the converter goroutine to stop
-	close(lh.stopCh)
-
-	// Unregister the hotkey
-	if err := lh.hotkey.Unregister(); err != nil {
-		return fmt.Errorf("failed to unregister hotkey '%s': %w", lh.hotkeyStr, err)
-	}
-
-	return nil
+	var firstErr error
+	lh.closeOnce.Do(func() {
+		// Signal the converter goroutines to stop
+		close(lh.stopCh)
+
+		for _, hk := range lh.hotkeys {
+			if err := hk.Unregister(); err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("failed to unregister hotkey '%s': %w", lh.hotkeyStr, err)
+			}
+		}
+	})
+	return firstErr
 }
 
 // SelectBackend chooses the appropriate backend based on the current environment.
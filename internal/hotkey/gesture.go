@@ -0,0 +1,276 @@
+package hotkey
+
+import (
+	"log"
+	"strings"
+	"time"
+)
+
+// Gesture hotkeys bind an action to the way a combination is pressed instead
+// of the combination alone:
+//
+//	"ctrl+c ctrl+c" or "double:ctrl+c"  pressed twice within double_press_ms
+//	"long:ctrl+c"                       held for at least long_press_ms
+//
+// A single (short) press is passed through to the focused application, so a
+// gesture on ctrl+c leaves normal copying untouched.
+const (
+	doublePressPrefix = "double:"
+	longPressPrefix   = "long:"
+)
+
+// Timing used by the gesture layer.
+const (
+	// autoRepeatGap separates a real release from the release/press pairs X11
+	// generates while a key is held down.
+	autoRepeatGap = 50 * time.Millisecond
+	// replayDelay gives the system time to process the replayed keystroke
+	// before the hotkey is registered again.
+	replayDelay = 30 * time.Millisecond
+	// doublePressSettle lets the target application handle the replayed
+	// second press (e.g. finish copying) before the action runs.
+	doublePressSettle = 150 * time.Millisecond
+	// releaseTimeout stops waiting for a key release that never arrives.
+	releaseTimeout = 30 * time.Second
+)
+
+type gestureKind int
+
+const (
+	gestureNone gestureKind = iota
+	gestureDouble
+	gestureLong
+)
+
+// parseGesture returns the gesture kind and the underlying combination of a
+// hotkey string, or gestureNone for plain hotkeys and sequences.
+func parseGesture(hotkeyStr string) (gestureKind, string) {
+	trimmed := strings.TrimSpace(hotkeyStr)
+	lower := strings.ToLower(trimmed)
+	switch {
+	case strings.HasPrefix(lower, doublePressPrefix):
+		return gestureDouble, strings.TrimSpace(trimmed[len(doublePressPrefix):])
+	case strings.HasPrefix(lower, longPressPrefix):
+		return gestureLong, strings.TrimSpace(trimmed[len(longPressPrefix):])
+	}
+
+	// The same combination twice is a double press, not a sequence
+	steps := splitSequence(trimmed)
+	if len(steps) == 2 && normalizeStep(steps[0]) == normalizeStep(steps[1]) {
+		return gestureDouble, steps[0]
+	}
+	return gestureNone, ""
+}
+
+// gestureGroup collects the gestures bound to one combination.
+type gestureGroup struct {
+	combo  string // Combination as written by the first gesture using it
+	double *gestureAction
+	long   *gestureAction
+}
+
+// gestureAction is what a gesture triggers.
+type gestureAction struct {
+	hotkey string // Hotkey string as written in the config
+	label  string // Binding description for logging
+	action func()
+}
+
+func (a *gestureAction) run() {
+	log.Printf("Gesture hotkey '%s' triggered for %s", a.hotkey, a.label)
+	if a.action != nil {
+		a.action()
+	}
+}
+
+// listenGesture registers the combination of a gesture group and runs the
+// gesture state machine on its events until UnregisterAll is called.
+func (m *Manager) listenGesture(group *gestureGroup) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.registered[group.combo]; exists {
+		log.Printf("Warning: gesture hotkey '%s' is already registered. Skipping.", group.combo)
+		return nil
+	}
+
+	registered, err := m.backend.Register(group.combo)
+	if err != nil {
+		return err
+	}
+
+	quitCh := make(chan struct{})
+	m.registered[group.combo] = registered
+	m.quitChannels[group.combo] = quitCh
+
+	go m.runGesture(group, registered, quitCh)
+
+	log.Printf("Registered gesture hotkey '%s' (double press: %t, long press: %t)", group.combo, group.double != nil, group.long != nil)
+	return nil
+}
+
+// runGesture classifies each press of the group's combination as a long
+// press, the second press of a double press, or a single press that is
+// passed through to the focused application.
+func (m *Manager) runGesture(group *gestureGroup, registered RegisteredHotkey, quitCh chan struct{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("RECOVERED FROM PANIC IN GESTURE HOTKEY LISTENER (%s): %v", group.combo, r)
+		}
+	}()
+
+	doubleWindow := time.Duration(m.config.GetDoublePressWindow()) * time.Millisecond
+	holdTime := time.Duration(m.config.GetLongPressTime()) * time.Millisecond
+	var lastTap time.Time
+
+	for {
+		select {
+		case <-quitCh:
+			log.Printf("Gesture hotkey listener for '%s' stopping", group.combo)
+			return
+		case _, ok := <-registered.Keydown():
+			if !ok {
+				return
+			}
+		}
+		drain(registered.Keyup()) // A buffered release belongs to an earlier press
+
+		if group.long != nil {
+			held, quit := waitForHold(registered, holdTime, quitCh)
+			if quit {
+				return
+			}
+			if held {
+				lastTap = time.Time{}
+				group.long.run()
+				if waitForRelease(registered, quitCh) {
+					return
+				}
+				continue
+			}
+		}
+
+		// Short press: let the combination through so e.g. copy keeps working
+		next, ok := m.replayGesture(group.combo, quitCh)
+		if !ok {
+			return
+		}
+		registered = next
+
+		now := time.Now()
+		if group.double != nil && !lastTap.IsZero() && now.Sub(lastTap) <= doubleWindow {
+			lastTap = time.Time{}
+			time.Sleep(doublePressSettle)
+			group.double.run()
+			continue
+		}
+		lastTap = now
+	}
+}
+
+// replayGesture passes a press through to the focused application. The
+// combination is unregistered while the keystroke is replayed so it doesn't
+// trigger itself, then registered again. ok is false if the listener has to
+// stop.
+func (m *Manager) replayGesture(combo string, quitCh chan struct{}) (RegisteredHotkey, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	select {
+	case <-quitCh:
+		return nil, false // UnregisterAll already ran
+	default:
+	}
+
+	if err := m.backend.Unregister(combo); err != nil {
+		log.Printf("Gesture hotkey '%s': failed to unregister for pass-through: %v", combo, err)
+	}
+	if err := replayKeystroke(combo); err != nil {
+		log.Printf("Gesture hotkey '%s': could not pass the keystroke through: %v", combo, err)
+	}
+	time.Sleep(replayDelay)
+
+	registered, err := m.backend.Register(combo)
+	if err != nil {
+		log.Printf("ERROR: Gesture hotkey '%s' could not be registered again: %v", combo, err)
+		delete(m.registered, combo)
+		return nil, false
+	}
+	m.registered[combo] = registered
+	return registered, true
+}
+
+// waitForHold waits until the key is released (held = false) or held for
+// holdTime (held = true). quit is true if the listener has to stop.
+func waitForHold(registered RegisteredHotkey, holdTime time.Duration, quitCh chan struct{}) (held bool, quit bool) {
+	timer := time.NewTimer(holdTime)
+	defer timer.Stop()
+	for {
+		select {
+		case <-quitCh:
+			return false, true
+		case <-timer.C:
+			return true, false
+		case _, ok := <-registered.Keydown():
+			if !ok {
+				return false, true
+			}
+			// Auto-repeat while the key is held
+		case _, ok := <-registered.Keyup():
+			if !ok {
+				return false, true
+			}
+			if released(registered, quitCh) {
+				return false, false
+			}
+		}
+	}
+}
+
+// waitForRelease blocks until the key is released. It returns true if the
+// listener has to stop.
+func waitForRelease(registered RegisteredHotkey, quitCh chan struct{}) bool {
+	timeout := time.NewTimer(releaseTimeout)
+	defer timeout.Stop()
+	for {
+		select {
+		case <-quitCh:
+			return true
+		case <-timeout.C:
+			return false
+		case _, ok := <-registered.Keydown():
+			if !ok {
+				return true
+			}
+			// Auto-repeat while the key is held
+		case _, ok := <-registered.Keyup():
+			if !ok {
+				return true
+			}
+			if released(registered, quitCh) {
+				return false
+			}
+		}
+	}
+}
+
+// released reports whether a keyup is a real release rather than part of an
+// auto-repeat release/press pair.
+func released(registered RegisteredHotkey, quitCh chan struct{}) bool {
+	select {
+	case _, ok := <-registered.Keydown():
+		return !ok
+	case <-quitCh:
+		return true
+	case <-time.After(autoRepeatGap):
+		return true
+	}
+}
+
+// drain discards a pending event.
+func drain(ch <-chan struct{}) {
+	select {
+	case <-ch:
+	default:
+	}
+}
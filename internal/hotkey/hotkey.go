@@ -3,243 +3,476 @@ package hotkey
 import (
 	"fmt"
 	"log"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
-	"golang.design/x/hotkey"
 )
 
 // Manager handles registration and lifecycle of global hotkeys
 type Manager struct {
-	mu                sync.RWMutex // Protects registeredHotkeys and quitChannels
-	config            *config.Config
-	registeredHotkeys map[string][]*hotkey.Hotkey
-	quitChannels      map[string]chan struct{} // Channels to signal goroutines to stop
-	onTrigger         func(string, bool)       // hotkeyStr, isReverse
-	onRevert          func()
+	mu           sync.RWMutex // Protects registered, quitChannels and statuses
+	config       *config.Config
+	backend      Backend
+	fallback     bool          // No backend suits the display server; backend is the X11 one tried anyway
+	mouse        *MouseBackend // Registers mouse triggers such as "ctrl+mousemiddle"
+	registered   map[string]RegisteredHotkey
+	quitChannels map[string]chan struct{} // Channels to signal goroutines to stop
+	sequences    *sequenceMachine
+	onTrigger    func(string, bool) // hotkeyStr, isReverse
+	onRevert     func()
+	onType       func()
+	onCycle      func(string) // Profile group
+	onClear      func()
+	onUndo       func()
+	onRedo       func()
+	statuses     []HotkeyStatus // Result of the last RegisterAll
 }
 
 // NewManager creates a new hotkey manager
-func NewManager(cfg *config.Config, onTrigger func(string, bool), onRevert func()) *Manager {
-	return &Manager{
-		config:            cfg,
-		registeredHotkeys: make(map[string][]*hotkey.Hotkey),
-		quitChannels:      make(map[string]chan struct{}),
-		onTrigger:         onTrigger,
-		onRevert:          onRevert,
+func NewManager(cfg *config.Config, onTrigger func(string, bool), onRevert func(), onType func(), onCycle func(string), onClear func(), onUndo func(), onRedo func()) *Manager {
+	backend := SelectBackend()
+	fallback := backend == nil
+	if fallback {
+		// Keep trying the X11 path (e.g. through XWayland); registration
+		// errors are then reported per hotkey
+		backend = NewLegacyBackend()
 	}
+	m := &Manager{
+		config:       cfg,
+		backend:      backend,
+		fallback:     fallback,
+		mouse:        NewMouseBackend(),
+		registered:   make(map[string]RegisteredHotkey),
+		quitChannels: make(map[string]chan struct{}),
+		sequences:    newSequenceMachine(backend, time.Duration(cfg.GetSequenceTimeout())*time.Millisecond),
+		onTrigger:    onTrigger,
+		onRevert:     onRevert,
+		onType:       onType,
+		onCycle:      onCycle,
+		onClear:      onClear,
+		onUndo:       onUndo,
+		onRedo:       onRedo,
+	}
+	if legacy, ok := backend.(*LegacyBackend); ok {
+		legacy.setLockKeys(m.lockKeysFor)
+	}
+	m.mouse.setLockKeys(m.lockKeysFor)
+	return m
+}
+
+// backendFor returns the backend registering hotkeyStr: the mouse backend
+// for mouse triggers, otherwise the keyboard one.
+func (m *Manager) backendFor(hotkeyStr string) Backend {
+	if isMouseTrigger(hotkeyStr) {
+		return m.mouse
+	}
+	return m.backend
 }
 
-// RegisterAll registers all hotkeys for enabled profiles
+// Unavailable reports whether no hotkey backend suits the display server,
+// e.g. on Wayland without the GlobalShortcuts portal. Hotkeys then only work
+// where the X11 fallback happens to reach, such as XWayland windows.
+func (m *Manager) Unavailable() bool {
+	return m.fallback
+}
+
+// RegisterAll registers all hotkeys for enabled profiles. It keeps going when
+// a hotkey fails or conflicts with another binding and returns a single error
+// summarizing every problem; per-hotkey details are available from Status.
 func (m *Manager) RegisterAll() error {
 	// Clean up existing hotkeys
 	m.UnregisterAll()
 
-	// Track which profiles use which hotkeys for logging
-	hotkeyProfiles := make(map[string][]string)
-
-	// Register hotkeys for all enabled profiles
-	for _, profile := range m.config.Profiles {
-		if !profile.Enabled {
+	bindings := m.collectBindings()
+	statuses := make(map[string]*HotkeyStatus) // Keyed by normalized hotkey
+	var order []string
+	firstKind := make(map[string]bindingKind)
+	var registrations []hotkeyBinding // First binding of every distinct combination
+
+	for _, b := range bindings {
+		key := normalizeHotkey(b.hotkey)
+		status, exists := statuses[key]
+		if !exists {
+			status = &HotkeyStatus{Hotkey: b.hotkey}
+			statuses[key] = status
+			order = append(order, key)
+		}
+		status.Bindings = append(status.Bindings, b.describe())
+
+		if exists {
+			// Same combination already handled by an earlier binding
+			switch {
+			case firstKind[key] != b.kind:
+				status.Conflicts = append(status.Conflicts, fmt.Sprintf("%s is ignored because %s already uses this hotkey", b.describe(), status.Bindings[0]))
+			case b.hotkey != status.Hotkey:
+				status.Conflicts = append(status.Conflicts, fmt.Sprintf("%s writes the hotkey as '%s' but '%s' is registered; write both the same way", b.describe(), b.hotkey, status.Hotkey))
+			case b.kind == bindingProfile || b.kind == bindingReverse:
+				// Sharing a hotkey between profiles is supported: all of them run.
+				status.Shared = true
+			}
 			continue
 		}
+		firstKind[key] = b.kind
+		registrations = append(registrations, b)
+	}
 
-		// Add profile to the list for this hotkey
-		hotkeyProfiles[profile.Hotkey] = append(hotkeyProfiles[profile.Hotkey], profile.Name)
+	m.registerBindings(registrations, statuses)
 
-		// Add to reverse hotkey tracking if it exists
-		if profile.ReverseHotkey != "" {
-			hotkeyProfiles[profile.ReverseHotkey] = append(
-				hotkeyProfiles[profile.ReverseHotkey], profile.Name+" (reverse)")
+	result := make([]HotkeyStatus, 0, len(order))
+	var problems []string
+	for _, key := range order {
+		status := statuses[key]
+		result = append(result, *status)
+		if status.Error != "" {
+			problems = append(problems, fmt.Sprintf("'%s' (%s): %s", status.Hotkey, strings.Join(status.Bindings, ", "), status.Error))
 		}
-
-		// Register standard hotkey
-		if err := m.registerProfileHotkey(profile, profile.Hotkey, false); err != nil {
-			return fmt.Errorf("failed to register hotkey '%s' for profile '%s': %v",
-				profile.Hotkey, profile.Name, err)
+		for _, conflict := range status.Conflicts {
+			problems = append(problems, fmt.Sprintf("'%s': %s", status.Hotkey, conflict))
 		}
-
-		// Register reverse hotkey if specified
-		if profile.ReverseHotkey != "" {
-			if err := m.registerProfileHotkey(profile, profile.ReverseHotkey, true); err != nil {
-				return fmt.Errorf("failed to register reverse hotkey '%s' for profile '%s': %v",
-					profile.ReverseHotkey, profile.Name, err)
-			}
+		if status.Shared {
+			log.Printf("Hotkey '%s' is shared by %s. All of them will be triggered.", status.Hotkey, strings.Join(status.Bindings, ", "))
 		}
 	}
 
-	// Register the global revert hotkey if configured and applicable
-	if m.config.RevertHotkey != "" && m.config.TemporaryClipboard && !m.config.AutomaticReversion {
-		if err := m.registerRevertHotkey(m.config.RevertHotkey); err != nil {
-			return fmt.Errorf("failed to register revert hotkey '%s': %v",
-				m.config.RevertHotkey, err)
-		}
-	}
+	m.mu.Lock()
+	m.statuses = result
+	m.mu.Unlock()
 
+	if len(problems) > 0 {
+		return fmt.Errorf("%d hotkey problem(s):\n  - %s", len(problems), strings.Join(problems, "\n  - "))
+	}
 	return nil
 }
 
-// UnregisterAll unregisters all currently registered hotkeys
-func (m *Manager) UnregisterAll() {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// registerBindings registers plain hotkeys directly, groups gestures by
+// their combination and sequences by their leader key, of which only the
+// leader is registered. Results are recorded in statuses (keyed by
+// normalized hotkey).
+func (m *Manager) registerBindings(registrations []hotkeyBinding, statuses map[string]*HotkeyStatus) {
+	plain := make(map[string]string) // Normalized -> as written
+	leaders := make(map[string]bool)
+	gestureCombos := make(map[string]bool)
+	for _, b := range registrations {
+		steps := splitSequence(b.hotkey)
+		if kind, combo := parseGesture(b.hotkey); kind != gestureNone {
+			gestureCombos[normalizeStep(combo)] = true
+		} else if len(steps) > 1 {
+			leaders[normalizeHotkey(steps[0])] = true
+		} else {
+			plain[normalizeHotkey(b.hotkey)] = b.hotkey
+		}
+	}
 
-	// Signal all goroutines to stop
-	for key, quitCh := range m.quitChannels {
-		close(quitCh)
-		log.Printf("Signaled goroutine to stop for hotkey: %s", key)
+	groups := make(map[string]*leaderGroup)
+	groupStatuses := make(map[string][]*HotkeyStatus)
+	var groupOrder []string
+	gestures := make(map[string]*gestureGroup)
+	gestureStatuses := make(map[string][]*HotkeyStatus)
+	var gestureOrder []string
+
+	for _, b := range registrations {
+		status := statuses[normalizeHotkey(b.hotkey)]
+		steps := splitSequence(b.hotkey)
+
+		if kind, combo := parseGesture(b.hotkey); kind != gestureNone {
+			comboKey := normalizeStep(combo)
+			if isSequence(combo) || combo == "" {
+				status.Error = "a double or long press needs a single combination (e.g. 'double:ctrl+c' or 'long:ctrl+c')"
+				continue
+			}
+			if other, ok := plain[comboKey]; ok {
+				status.Conflicts = append(status.Conflicts, fmt.Sprintf("%s is ignored because '%s' is also a hotkey on its own ('%s')", b.describe(), combo, other))
+				continue
+			}
+			if leaders[comboKey] {
+				status.Conflicts = append(status.Conflicts, fmt.Sprintf("%s is ignored because '%s' starts a sequence", b.describe(), combo))
+				continue
+			}
+
+			group, exists := gestures[comboKey]
+			if !exists {
+				group = &gestureGroup{combo: combo}
+				gestures[comboKey] = group
+				gestureOrder = append(gestureOrder, comboKey)
+			}
+			slot, press := &group.double, "double press"
+			if kind == gestureLong {
+				slot, press = &group.long, "long press"
+			}
+			if *slot != nil {
+				status.Conflicts = append(status.Conflicts, fmt.Sprintf("%s is ignored because %s already uses the %s of '%s'", b.describe(), (*slot).label, press, combo))
+				continue
+			}
+			*slot = &gestureAction{hotkey: b.hotkey, label: b.describe(), action: m.bindingAction(b)}
+			gestureStatuses[comboKey] = append(gestureStatuses[comboKey], status)
+			continue
+		}
+
+		if len(steps) <= 1 {
+			m.recordResult(status, b.hotkey, b.describe(), m.listen(b.hotkey, b.describe(), m.bindingAction(b)))
+			continue
+		}
+		if len(steps) > 2 {
+			status.Error = "only sequences of two keys are supported (e.g. 'ctrl+alt+r 1')"
+			continue
+		}
+
+		leaderKey, secondKey := normalizeHotkey(steps[0]), normalizeHotkey(steps[1])
+		if other, ok := plain[leaderKey]; ok {
+			status.Conflicts = append(status.Conflicts, fmt.Sprintf("%s is ignored because its first key '%s' is also a hotkey on its own ('%s')", b.describe(), steps[0], other))
+			continue
+		}
+		if other, ok := plain[secondKey]; ok {
+			status.Conflicts = append(status.Conflicts, fmt.Sprintf("%s is ignored because its second key '%s' is also a hotkey on its own ('%s')", b.describe(), steps[1], other))
+			continue
+		}
+		if leaders[secondKey] {
+			status.Conflicts = append(status.Conflicts, fmt.Sprintf("%s is ignored because its second key '%s' starts another sequence", b.describe(), steps[1]))
+			continue
+		}
+		if gestureCombos[leaderKey] || gestureCombos[secondKey] {
+			status.Conflicts = append(status.Conflicts, fmt.Sprintf("%s is ignored because one of its keys is used for a double or long press", b.describe()))
+			continue
+		}
+
+		group, exists := groups[leaderKey]
+		if !exists {
+			group = &leaderGroup{leader: steps[0]}
+			groups[leaderKey] = group
+			groupOrder = append(groupOrder, leaderKey)
+		}
+		group.steps = append(group.steps, sequenceStep{
+			key:      steps[1],
+			sequence: b.hotkey,
+			label:    b.describe(),
+			action:   m.bindingAction(b),
+		})
+		groupStatuses[leaderKey] = append(groupStatuses[leaderKey], status)
 	}
 
-	// Unregister all hotkeys
-	for _, hks := range m.registeredHotkeys {
-		for _, hk := range hks {
-			_ = hk.Unregister()
+	for _, leaderKey := range groupOrder {
+		group := groups[leaderKey]
+		label := fmt.Sprintf("sequence leader of %d sequence(s)", len(group.steps))
+		err := m.listen(group.leader, label, func() { m.sequences.start(group) })
+		for i, status := range groupStatuses[leaderKey] {
+			m.recordResult(status, group.steps[i].sequence, group.steps[i].label, err)
 		}
 	}
 
-	// Clear maps
-	m.registeredHotkeys = make(map[string][]*hotkey.Hotkey)
-	m.quitChannels = make(map[string]chan struct{})
+	for _, comboKey := range gestureOrder {
+		group := gestures[comboKey]
+		err := m.listenGesture(group)
+		for _, status := range gestureStatuses[comboKey] {
+			m.recordResult(status, status.Hotkey, strings.Join(status.Bindings, ", "), err)
+		}
+	}
 }
 
-// registerProfileHotkey registers a hotkey for a profile
-func (m *Manager) registerProfileHotkey(profile config.ProfileConfig, hotkeyStr string, isReverse bool) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// recordResult stores the outcome of a registration in status.
+func (m *Manager) recordResult(status *HotkeyStatus, hotkeyStr, label string, err error) {
+	if err != nil {
+		status.Error = err.Error()
+		log.Printf("Failed to register hotkey '%s' for %s: %v", hotkeyStr, label, err)
+		return
+	}
+	status.Registered = true
+}
 
-	// Skip if already registered
-	if _, exists := m.registeredHotkeys[hotkeyStr]; exists {
+// bindingAction returns the callback that runs when a binding's hotkey (or
+// sequence) is pressed.
+func (m *Manager) bindingAction(b hotkeyBinding) func() {
+	switch b.kind {
+	case bindingProfile, bindingReverse, bindingSmart:
+		hotkeyStr, isReverse := b.hotkey, b.kind == bindingReverse
+		return func() {
+			if m.onTrigger != nil {
+				m.onTrigger(hotkeyStr, isReverse)
+			}
+		}
+	case bindingRevert:
+		return m.onRevert
+	case bindingType:
+		return m.onType
+	case bindingClear:
+		return m.onClear
+	case bindingUndo:
+		return m.onUndo
+	case bindingRedo:
+		return m.onRedo
+	case bindingCycle:
+		group := b.group
+		return func() {
+			if m.onCycle != nil {
+				m.onCycle(group)
+			}
+		}
+	default:
 		return nil
 	}
+}
 
-	// Parse and register the hotkey
-	modifiers, key, err := parseHotkey(hotkeyStr)
-	if err != nil {
-		return err
+// collectBindings lists every hotkey the current config asks for, in
+// registration order.
+func (m *Manager) collectBindings() []hotkeyBinding {
+	var bindings []hotkeyBinding
+	for _, profile := range m.config.Profiles {
+		if !profile.Enabled {
+			continue
+		}
+		// Profiles for the smart hotkey may have no hotkey of their own
+		if profile.Hotkey != "" {
+			bindings = append(bindings, hotkeyBinding{hotkey: profile.Hotkey, kind: bindingProfile, profile: profile})
+		}
+		if profile.ReverseHotkey != "" {
+			bindings = append(bindings, hotkeyBinding{hotkey: profile.ReverseHotkey, kind: bindingReverse, profile: profile})
+		}
 	}
 
-	modifierSets := expandModifiers(modifiers)
-	var hks []*hotkey.Hotkey
-	for _, mods := range modifierSets {
-		hk := hotkey.New(mods, key)
-		if err := hk.Register(); err != nil {
-			// Best-effort rollback for any already registered variants.
-			for _, registered := range hks {
-				_ = registered.Unregister()
-			}
-			return err
+	// The global revert hotkey is only active if reverting is manual
+	if m.config.RevertHotkey != "" && m.config.ManualRevertPossible() {
+		bindings = append(bindings, hotkeyBinding{hotkey: m.config.RevertHotkey, kind: bindingRevert})
+	}
+	if m.config.TypeHotkey != "" {
+		bindings = append(bindings, hotkeyBinding{hotkey: m.config.TypeHotkey, kind: bindingType})
+	}
+	if m.config.ClearHotkey != "" {
+		bindings = append(bindings, hotkeyBinding{hotkey: m.config.ClearHotkey, kind: bindingClear})
+	}
+	// Undoing is off with a negative undo_depth
+	if m.config.UndoHotkey != "" && m.config.GetUndoDepth() > 0 {
+		bindings = append(bindings, hotkeyBinding{hotkey: m.config.UndoHotkey, kind: bindingUndo})
+	}
+	if m.config.RedoHotkey != "" && m.config.GetUndoDepth() > 0 {
+		bindings = append(bindings, hotkeyBinding{hotkey: m.config.RedoHotkey, kind: bindingRedo})
+	}
+	if m.config.SmartHotkey != "" {
+		bindings = append(bindings, hotkeyBinding{hotkey: m.config.SmartHotkey, kind: bindingSmart})
+	}
+	for _, group := range m.config.ProfileGroupNames() { // Sorted, so conflicts are reported the same way every time
+		if hotkey := m.config.GroupHotkeys[group]; hotkey != "" {
+			bindings = append(bindings, hotkeyBinding{hotkey: hotkey, kind: bindingCycle, group: group})
 		}
-		hks = append(hks, hk)
 	}
+	return bindings
+}
 
-	// Create quit channel for this hotkey's goroutine
-	quitCh := make(chan struct{})
+// UsedBy describes the bindings of the current config that use hotkeyStr,
+// written in any way, e.g. "profile 'Privacy'", including sequences and
+// double or long presses of it. Bindings of the profile named exceptProfile are left out, so a
+// profile's own hotkey isn't reported when it is recorded again.
+func (m *Manager) UsedBy(hotkeyStr, exceptProfile string) []string {
+	key := normalizeHotkey(hotkeyStr)
+	var users []string
+	for _, b := range m.collectBindings() {
+		if exceptProfile != "" && (b.kind == bindingProfile || b.kind == bindingReverse) && b.profile.Name == exceptProfile {
+			continue
+		}
+		// A sequence registers its first key, a double or long press its combination
+		registered := b.hotkey
+		if kind, combo := parseGesture(b.hotkey); kind != gestureNone {
+			registered = combo
+		} else if steps := splitSequence(b.hotkey); len(steps) > 1 {
+			registered = steps[0]
+		}
+		switch {
+		case normalizeHotkey(b.hotkey) == key:
+			users = append(users, b.describe())
+		case normalizeHotkey(registered) == key:
+			users = append(users, fmt.Sprintf("%s, as part of '%s'", b.describe(), b.hotkey))
+		}
+	}
+	return users
+}
 
-	// Store in our tracking maps
-	m.registeredHotkeys[hotkeyStr] = hks
-	m.quitChannels[hotkeyStr] = quitCh
+// Status returns the outcome of the last RegisterAll call, one entry per
+// distinct key combination.
+func (m *Manager) Status() []HotkeyStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	result := make([]HotkeyStatus, len(m.statuses))
+	copy(result, m.statuses)
+	return result
+}
 
-	// Direction suffix for logging
-	directionSuffix := ""
-	if isReverse {
-		directionSuffix = " (reverse)"
-	}
+// UnregisterAll unregisters all currently registered hotkeys
+func (m *Manager) UnregisterAll() {
+	// Drop a sequence waiting for its second key first
+	m.sequences.stop()
 
-	// Create listeners for all registered variants of this hotkey.
-	for idx, hk := range hks {
-		go func(hotkeyStr string, isReverse bool, hk *hotkey.Hotkey, quitCh chan struct{}, profileName string, directionSuffix string, variantIndex int) {
-			defer func() {
-				if r := recover(); r != nil {
-					log.Printf("RECOVERED FROM PANIC IN HOTKEY LISTENER (%s, variant %d): %v", hotkeyStr, variantIndex, r)
-				}
-			}()
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-			for {
-				select {
-				case <-quitCh:
-					log.Printf("Hotkey listener for '%s' (variant %d) stopping", hotkeyStr, variantIndex)
-					return
-				case <-hk.Keydown():
-					log.Printf("Hotkey '%s' pressed (variant %d). Processing clipboard using profile: %s%s",
-						hotkeyStr, variantIndex, profileName, directionSuffix)
-
-					// Call the callback function
-					if m.onTrigger != nil {
-						m.onTrigger(hotkeyStr, isReverse)
-					}
-				}
-			}
-		}(hotkeyStr, isReverse, hk, quitCh, profile.Name, directionSuffix, idx)
+	// Signal all goroutines to stop
+	for key, quitCh := range m.quitChannels {
+		close(quitCh)
+		log.Printf("Signaled goroutine to stop for hotkey: %s", key)
 	}
 
-	log.Printf("Registered hotkey '%s' for profile: %s%s",
-		hotkeyStr, profile.Name, directionSuffix)
+	// Unregister all hotkeys
+	for hotkeyStr := range m.registered {
+		if err := m.backendFor(hotkeyStr).Unregister(hotkeyStr); err != nil {
+			log.Printf("Error unregistering hotkey '%s': %v", hotkeyStr, err)
+		}
+	}
 
-	return nil
+	// Clear maps
+	m.registered = make(map[string]RegisteredHotkey)
+	m.quitChannels = make(map[string]chan struct{})
 }
 
-// registerRevertHotkey registers a global hotkey for reverting the clipboard
-func (m *Manager) registerRevertHotkey(hotkeyStr string) error {
+// listen registers a hotkey with the backend and runs action every time it
+// is pressed until UnregisterAll is called. label describes the binding for
+// logging.
+func (m *Manager) listen(hotkeyStr string, label string, action func()) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	// Skip if already registered
-	if _, exists := m.registeredHotkeys[hotkeyStr]; exists {
+	if _, exists := m.registered[hotkeyStr]; exists {
+		log.Printf("Warning: hotkey '%s' for %s is already registered. Skipping.", hotkeyStr, label)
 		return nil
 	}
 
-	// Parse the hotkey
-	modifiers, key, err := parseHotkey(hotkeyStr)
+	registered, err := m.backendFor(hotkeyStr).Register(hotkeyStr)
 	if err != nil {
 		return err
 	}
 
-	modifierSets := expandModifiers(modifiers)
-	var hks []*hotkey.Hotkey
-	for _, mods := range modifierSets {
-		hk := hotkey.New(mods, key)
-		if err := hk.Register(); err != nil {
-			for _, registered := range hks {
-				_ = registered.Unregister()
-			}
-			return err
-		}
-		hks = append(hks, hk)
-	}
-
 	// Create quit channel for this hotkey's goroutine
 	quitCh := make(chan struct{})
 
 	// Store in our tracking maps
-	m.registeredHotkeys[hotkeyStr] = hks
+	m.registered[hotkeyStr] = registered
 	m.quitChannels[hotkeyStr] = quitCh
 
-	// Create listeners for all registered variants of this hotkey.
-	for idx, hk := range hks {
-		go func(hotkeyStr string, hk *hotkey.Hotkey, quitCh chan struct{}, variantIndex int) {
-			defer func() {
-				if r := recover(); r != nil {
-					log.Printf("RECOVERED FROM PANIC IN REVERT HOTKEY LISTENER (%s, variant %d): %v", hotkeyStr, variantIndex, r)
-				}
-			}()
-
-			for {
-				select {
-				case <-quitCh:
-					log.Printf("Revert hotkey listener for '%s' (variant %d) stopping", hotkeyStr, variantIndex)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("RECOVERED FROM PANIC IN HOTKEY LISTENER (%s): %v", hotkeyStr, r)
+			}
+		}()
+
+		for {
+			select {
+			case <-quitCh:
+				log.Printf("Hotkey listener for '%s' stopping", hotkeyStr)
+				return
+			case _, ok := <-registered.Keydown():
+				if !ok {
 					return
-				case <-hk.Keydown():
-					log.Printf("Revert hotkey '%s' pressed (variant %d). Restoring original clipboard.", hotkeyStr, variantIndex)
+				}
+				log.Printf("Hotkey '%s' pressed (%s).", hotkeyStr, label)
 
-					// Call the revert callback
-					if m.onRevert != nil {
-						m.onRevert()
-					}
+				// Call the callback function
+				if action != nil {
+					action()
 				}
 			}
-		}(hotkeyStr, hk, quitCh, idx)
-	}
+		}
+	}()
 
-	log.Printf("Registered revert hotkey: %s", hotkeyStr)
+	log.Printf("Registered hotkey '%s' for %s", hotkeyStr, label)
 	return nil
 }
@@ -72,7 +72,79 @@ var KeyMap = map[string]hotkey.Key{
 	"left":  hotkey.KeyLeft,
 	"right": hotkey.KeyRight,
 
-	// Note: Additional keys like Home, End, Delete, Insert, PageUp, PageDown
-	// and punctuation keys are not available in golang.design/x/hotkey v0.4.1.
-	// Users can still use letter and number keys with modifiers for hotkeys.
+	// Navigation, punctuation, numpad and media keys have no constants in
+	// golang.design/x/hotkey v0.4.1. Their platform key codes are added from
+	// platformKeys in init().
+}
+
+// keyAliases maps alternative key names to the canonical name used in
+// KeyMap. Punctuation can be written as the character itself, except '+',
+// which separates the parts of a hotkey; use "equals" (the =/+ key) or
+// "numpadadd" instead.
+var keyAliases = map[string]string{
+	"pgup":         "pageup",
+	"pgdn":         "pagedown",
+	"ins":          "insert",
+	"del":          "delete",
+	"`":            "backtick",
+	"grave":        "backtick",
+	"-":            "minus",
+	"=":            "equals",
+	"equal":        "equals",
+	"[":            "leftbracket",
+	"]":            "rightbracket",
+	";":            "semicolon",
+	"'":            "quote",
+	"apostrophe":   "quote",
+	",":            "comma",
+	".":            "period",
+	"/":            "slash",
+	"\\":           "backslash",
+	"num0":         "numpad0",
+	"num1":         "numpad1",
+	"num2":         "numpad2",
+	"num3":         "numpad3",
+	"num4":         "numpad4",
+	"num5":         "numpad5",
+	"num6":         "numpad6",
+	"num7":         "numpad7",
+	"num8":         "numpad8",
+	"num9":         "numpad9",
+	"numpadplus":   "numpadadd",
+	"numpadminus":  "numpadsubtract",
+	"numpadstar":   "numpadmultiply",
+	"numpadslash":  "numpaddivide",
+	"numpadperiod": "numpaddecimal",
+	"playpause":    "mediaplaypause",
+	"mediaplay":    "mediaplaypause",
+	"mute":         "volumemute",
+	"next":         "medianext",
+	"prev":         "mediaprev",
+	"previous":     "mediaprev",
+
+	// Mouse triggers, see mouse.go
+	"middleclick":  "mousemiddle",
+	"mouseback":    "mouse4",
+	"mouseforward": "mouse5",
+	"scrollup":     "wheelup",
+	"scrolldown":   "wheeldown",
+}
+
+func init() {
+	for name, key := range platformKeys {
+		KeyMap[name] = key
+	}
+	for alias, name := range keyAliases {
+		if key, ok := KeyMap[name]; ok {
+			KeyMap[alias] = key
+		}
+	}
+}
+
+// canonicalKeyName resolves an alias such as "pgup" to its canonical name.
+func canonicalKeyName(name string) string {
+	if canonical, ok := keyAliases[name]; ok {
+		return canonical
+	}
+	return name
 }
@@ -0,0 +1,49 @@
+//go:build linux
+
+package hotkey
+
+import "golang.design/x/hotkey"
+
+// platformKeys holds X11 keysyms for keys that have no constant in
+// golang.design/x/hotkey. Media keys (XF86Audio*) are not listed: their
+// keysyms do not fit into the 16-bit hotkey.Key used on Linux.
+var platformKeys = map[string]hotkey.Key{
+	// Navigation
+	"home":     hotkey.Key(0xff50), // XK_Home
+	"end":      hotkey.Key(0xff57), // XK_End
+	"pageup":   hotkey.Key(0xff55), // XK_Prior
+	"pagedown": hotkey.Key(0xff56), // XK_Next
+	"insert":   hotkey.Key(0xff63), // XK_Insert
+	"delete":   hotkey.Key(0xffff), // XK_Delete
+
+	// Punctuation
+	"backtick":     hotkey.Key(0x0060), // XK_grave
+	"minus":        hotkey.Key(0x002d), // XK_minus
+	"equals":       hotkey.Key(0x003d), // XK_equal
+	"leftbracket":  hotkey.Key(0x005b), // XK_bracketleft
+	"rightbracket": hotkey.Key(0x005d), // XK_bracketright
+	"semicolon":    hotkey.Key(0x003b), // XK_semicolon
+	"quote":        hotkey.Key(0x0027), // XK_apostrophe
+	"comma":        hotkey.Key(0x002c), // XK_comma
+	"period":       hotkey.Key(0x002e), // XK_period
+	"slash":        hotkey.Key(0x002f), // XK_slash
+	"backslash":    hotkey.Key(0x005c), // XK_backslash
+
+	// Numpad (the grab is per keycode, so these also fire with NumLock off)
+	"numpad0":        hotkey.Key(0xffb0), // XK_KP_0
+	"numpad1":        hotkey.Key(0xffb1),
+	"numpad2":        hotkey.Key(0xffb2),
+	"numpad3":        hotkey.Key(0xffb3),
+	"numpad4":        hotkey.Key(0xffb4),
+	"numpad5":        hotkey.Key(0xffb5),
+	"numpad6":        hotkey.Key(0xffb6),
+	"numpad7":        hotkey.Key(0xffb7),
+	"numpad8":        hotkey.Key(0xffb8),
+	"numpad9":        hotkey.Key(0xffb9),
+	"numpadmultiply": hotkey.Key(0xffaa), // XK_KP_Multiply
+	"numpadadd":      hotkey.Key(0xffab), // XK_KP_Add
+	"numpadsubtract": hotkey.Key(0xffad), // XK_KP_Subtract
+	"numpaddecimal":  hotkey.Key(0xffae), // XK_KP_Decimal
+	"numpaddivide":   hotkey.Key(0xffaf), // XK_KP_Divide
+	"numpadenter":    hotkey.Key(0xff8d), // XK_KP_Enter
+}
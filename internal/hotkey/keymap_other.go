@@ -0,0 +1,8 @@
+//go:build !windows && !linux
+
+package hotkey
+
+import "golang.design/x/hotkey"
+
+// platformKeys is empty here; parseHotkey is not supported on this OS.
+var platformKeys = map[string]hotkey.Key{}
@@ -0,0 +1,57 @@
+//go:build windows
+
+package hotkey
+
+import "golang.design/x/hotkey"
+
+// platformKeys holds Windows virtual-key codes for keys that have no
+// constant in golang.design/x/hotkey. Punctuation uses the US layout
+// positions (VK_OEM_*); other layouts may put different characters there.
+var platformKeys = map[string]hotkey.Key{
+	// Navigation
+	"home":     hotkey.Key(0x24), // VK_HOME
+	"end":      hotkey.Key(0x23), // VK_END
+	"pageup":   hotkey.Key(0x21), // VK_PRIOR
+	"pagedown": hotkey.Key(0x22), // VK_NEXT
+	"insert":   hotkey.Key(0x2D), // VK_INSERT
+	"delete":   hotkey.Key(0x2E), // VK_DELETE
+
+	// Punctuation
+	"semicolon":    hotkey.Key(0xBA), // VK_OEM_1
+	"equals":       hotkey.Key(0xBB), // VK_OEM_PLUS
+	"comma":        hotkey.Key(0xBC), // VK_OEM_COMMA
+	"minus":        hotkey.Key(0xBD), // VK_OEM_MINUS
+	"period":       hotkey.Key(0xBE), // VK_OEM_PERIOD
+	"slash":        hotkey.Key(0xBF), // VK_OEM_2
+	"backtick":     hotkey.Key(0xC0), // VK_OEM_3
+	"leftbracket":  hotkey.Key(0xDB), // VK_OEM_4
+	"backslash":    hotkey.Key(0xDC), // VK_OEM_5
+	"rightbracket": hotkey.Key(0xDD), // VK_OEM_6
+	"quote":        hotkey.Key(0xDE), // VK_OEM_7
+
+	// Numpad
+	"numpad0":        hotkey.Key(0x60),
+	"numpad1":        hotkey.Key(0x61),
+	"numpad2":        hotkey.Key(0x62),
+	"numpad3":        hotkey.Key(0x63),
+	"numpad4":        hotkey.Key(0x64),
+	"numpad5":        hotkey.Key(0x65),
+	"numpad6":        hotkey.Key(0x66),
+	"numpad7":        hotkey.Key(0x67),
+	"numpad8":        hotkey.Key(0x68),
+	"numpad9":        hotkey.Key(0x69),
+	"numpadmultiply": hotkey.Key(0x6A),
+	"numpadadd":      hotkey.Key(0x6B),
+	"numpadsubtract": hotkey.Key(0x6D),
+	"numpaddecimal":  hotkey.Key(0x6E),
+	"numpaddivide":   hotkey.Key(0x6F),
+
+	// Media
+	"volumemute":     hotkey.Key(0xAD),
+	"volumedown":     hotkey.Key(0xAE),
+	"volumeup":       hotkey.Key(0xAF),
+	"medianext":      hotkey.Key(0xB0),
+	"mediaprev":      hotkey.Key(0xB1),
+	"mediastop":      hotkey.Key(0xB2),
+	"mediaplaypause": hotkey.Key(0xB3),
+}
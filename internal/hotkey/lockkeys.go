@@ -0,0 +1,62 @@
+package hotkey
+
+import "github.com/TanaroSch/clipboard-regex-replace/internal/config"
+
+// lockKeys is which of NumLock and CapsLock may be on for a hotkey to
+// trigger, as set by lock_keys and hotkey_lock_keys.
+type lockKeys struct {
+	numLock  bool
+	capsLock bool
+}
+
+// anyLockKeys lets a hotkey trigger whatever the lock state.
+var anyLockKeys = lockKeys{numLock: true, capsLock: true}
+
+// parseLockKeys converts a validated lock_keys value.
+func parseLockKeys(mode string) lockKeys {
+	switch mode {
+	case config.LockKeysStrict:
+		return lockKeys{}
+	case config.LockKeysNumLock:
+		return lockKeys{numLock: true}
+	case config.LockKeysCapsLock:
+		return lockKeys{capsLock: true}
+	}
+	return anyLockKeys
+}
+
+// allows reports whether the current lock state lets the hotkey trigger.
+// Where the state can't be read, the registration has to match it instead.
+func (l lockKeys) allows() bool {
+	numLock, capsLock, ok := lockState()
+	if !ok {
+		return true
+	}
+	return (l.numLock || !numLock) && (l.capsLock || !capsLock)
+}
+
+// lockKeysFor returns the lock keys tolerated by combo, a single combination
+// registered with the backend. A hotkey_lock_keys entry for a sequence or a
+// double or long press applies to each of its combinations.
+func (m *Manager) lockKeysFor(combo string) lockKeys {
+	if m.config == nil {
+		return anyLockKeys
+	}
+	return parseLockKeys(m.config.GetLockKeys(combo, func(configured, combo string) bool {
+		for _, part := range hotkeyCombos(configured) {
+			if normalizeStep(part) == normalizeStep(combo) {
+				return true
+			}
+		}
+		return false
+	}))
+}
+
+// hotkeyCombos returns the combinations a hotkey string registers: the steps
+// of a sequence, the combination of a double or long press, or itself.
+func hotkeyCombos(hotkeyStr string) []string {
+	if kind, combo := parseGesture(hotkeyStr); kind != gestureNone {
+		return []string{combo}
+	}
+	return splitSequence(hotkeyStr)
+}
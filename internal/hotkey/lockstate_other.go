@@ -0,0 +1,9 @@
+//go:build !windows
+
+package hotkey
+
+// lockState can't tell the lock state here. On X11 the grab itself matches
+// it, see expandModifiers; macOS ignores NumLock and CapsLock for hotkeys.
+func lockState() (numLock, capsLock, ok bool) {
+	return false, false, false
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package hotkey
+
+var procGetKeyState = user32.NewProc("GetKeyState")
+
+const (
+	vkNumLock  = 0x90
+	vkCapsLock = 0x14
+)
+
+// lockState returns whether NumLock and CapsLock are on. RegisterHotKey
+// ignores them, so lock_keys is applied when a hotkey is pressed.
+func lockState() (numLock, capsLock, ok bool) {
+	num, _, _ := procGetKeyState.Call(vkNumLock)
+	caps, _, _ := procGetKeyState.Call(vkCapsLock)
+	return num&1 != 0, caps&1 != 0, true // The low bit is the toggle state
+}
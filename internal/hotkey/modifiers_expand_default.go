@@ -4,6 +4,9 @@ package hotkey
 
 import "golang.design/x/hotkey"
 
-func expandModifiers(modifiers []hotkey.Modifier) [][]hotkey.Modifier {
+// expandModifiers registers a single variant: Windows and macOS match hotkeys
+// whatever the lock state, so on Windows lock_keys is checked when one is
+// pressed.
+func expandModifiers(modifiers []hotkey.Modifier, locks lockKeys) [][]hotkey.Modifier {
 	return [][]hotkey.Modifier{modifiers}
 }
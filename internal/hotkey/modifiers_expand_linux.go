@@ -10,13 +10,20 @@ const (
 	linuxCapsLockMask hotkey.Modifier = 1 << 1
 )
 
-func expandModifiers(modifiers []hotkey.Modifier) [][]hotkey.Modifier {
-	// Register the same hotkey for common lock-modifier states so it still
-	// triggers when NumLock/CapsLock are enabled.
+func expandModifiers(modifiers []hotkey.Modifier, locks lockKeys) [][]hotkey.Modifier {
+	// Register the same hotkey for the lock-modifier states it tolerates so
+	// it still triggers when NumLock/CapsLock are enabled. XGrabKey matches
+	// the modifiers exactly, so a state without a variant doesn't trigger.
 	base := append([]hotkey.Modifier(nil), modifiers...)
-	withNum := append(append([]hotkey.Modifier(nil), modifiers...), hotkey.Mod2)
-	withCaps := append(append([]hotkey.Modifier(nil), modifiers...), linuxCapsLockMask)
-	withBoth := append(append([]hotkey.Modifier(nil), modifiers...), hotkey.Mod2, linuxCapsLockMask)
-
-	return [][]hotkey.Modifier{base, withNum, withCaps, withBoth}
+	variants := [][]hotkey.Modifier{base}
+	if locks.numLock {
+		variants = append(variants, append(append([]hotkey.Modifier(nil), modifiers...), hotkey.Mod2))
+	}
+	if locks.capsLock {
+		variants = append(variants, append(append([]hotkey.Modifier(nil), modifiers...), linuxCapsLockMask))
+	}
+	if locks.numLock && locks.capsLock {
+		variants = append(variants, append(append([]hotkey.Modifier(nil), modifiers...), hotkey.Mod2, linuxCapsLockMask))
+	}
+	return variants
 }
@@ -0,0 +1,286 @@
+package hotkey
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// mouseButton is a mouse button or wheel direction usable as a trigger.
+type mouseButton int
+
+const (
+	mouseMiddle  mouseButton = iota + 1 // Middle button (wheel click)
+	mouseBack                           // Button 4, "back" on most mice
+	mouseForward                        // Button 5, "forward" on most mice
+	wheelUp
+	wheelDown
+)
+
+// mouseButtons maps the canonical trigger names to buttons. Aliases such as
+// "middleclick" are resolved through keyAliases.
+var mouseButtons = map[string]mouseButton{
+	"mousemiddle": mouseMiddle,
+	"mouse4":      mouseBack,
+	"mouse5":      mouseForward,
+	"wheelup":     wheelUp,
+	"wheeldown":   wheelDown,
+}
+
+// mouseModifiers is a set of modifiers held during a mouse trigger.
+type mouseModifiers uint8
+
+const (
+	mouseCtrl mouseModifiers = 1 << iota
+	mouseShift
+	mouseAlt
+	mouseSuper
+)
+
+// mouseTrigger is a mouse button or wheel direction with the modifiers that
+// must be held, e.g. "ctrl+mousemiddle".
+type mouseTrigger struct {
+	button    mouseButton
+	modifiers mouseModifiers
+}
+
+// isMouseTrigger reports whether hotkeyStr is a single mouse trigger rather
+// than a keyboard combination.
+func isMouseTrigger(hotkeyStr string) bool {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(hotkeyStr)), "+")
+	_, ok := mouseButtons[canonicalKeyName(strings.TrimSpace(parts[len(parts)-1]))]
+	return ok
+}
+
+// parseMouseTrigger converts a string such as "ctrl+wheelup" into a trigger.
+// The middle button and the wheel need a modifier, otherwise every
+// middle-click or scroll would be taken from the focused application.
+func parseMouseTrigger(hotkeyStr string) (mouseTrigger, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(hotkeyStr)), "+")
+	name := canonicalKeyName(strings.TrimSpace(parts[len(parts)-1]))
+	button, ok := mouseButtons[name]
+	if !ok {
+		return mouseTrigger{}, fmt.Errorf("unsupported mouse button: %s", name)
+	}
+
+	trigger := mouseTrigger{button: button}
+	for _, part := range parts[:len(parts)-1] {
+		switch strings.TrimSpace(part) {
+		case "ctrl":
+			trigger.modifiers |= mouseCtrl
+		case "shift":
+			trigger.modifiers |= mouseShift
+		case "alt":
+			trigger.modifiers |= mouseAlt
+		case "super", "win", "cmd":
+			trigger.modifiers |= mouseSuper
+		default:
+			return mouseTrigger{}, fmt.Errorf("unsupported modifier: %s", part)
+		}
+	}
+	if trigger.modifiers == 0 && button != mouseBack && button != mouseForward {
+		return mouseTrigger{}, fmt.Errorf("'%s' needs a modifier such as ctrl, otherwise it would replace every normal click or scroll", name)
+	}
+	return trigger, nil
+}
+
+// mouseHook watches the mouse system-wide and reports the triggers it was
+// told about, keeping them from the focused application.
+type mouseHook interface {
+	// watch replaces the set of triggers reported, with the lock keys each
+	// tolerates. It fails if another application already took one of them.
+	watch(triggers map[mouseTrigger]lockKeys) error
+
+	// close stops watching and releases the hook.
+	close()
+}
+
+// MouseBackend registers mouse triggers such as "ctrl+mousemiddle" or
+// "mouse4" like keyboard hotkeys. Its hook is only installed while at least
+// one trigger is registered.
+type MouseBackend struct {
+	mu       sync.Mutex
+	hook     mouseHook
+	handles  map[string]*mouseHandle // Keyed by the hotkey string
+	lockKeys func(combo string) lockKeys
+
+	// active holds handles by trigger for dispatch, which must not wait for
+	// mu: the hook may report a click while watch waits for the hook
+	active atomic.Pointer[map[mouseTrigger]*mouseHandle]
+}
+
+// NewMouseBackend creates a mouse backend. Nothing is hooked until a trigger
+// is registered.
+func NewMouseBackend() *MouseBackend {
+	return &MouseBackend{handles: make(map[string]*mouseHandle)}
+}
+
+// setLockKeys sets how the lock keys a trigger tolerates are looked up.
+func (b *MouseBackend) setLockKeys(lookup func(combo string) lockKeys) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lockKeys = lookup
+}
+
+// Name returns the name of this backend.
+func (b *MouseBackend) Name() string {
+	return "Mouse"
+}
+
+// IsAvailable reports whether mouse triggers can be hooked on this system.
+func (b *MouseBackend) IsAvailable() bool {
+	return mouseHookSupported()
+}
+
+// Register starts reporting the mouse trigger hotkeyStr.
+func (b *MouseBackend) Register(hotkeyStr string) (RegisteredHotkey, error) {
+	trigger, err := parseMouseTrigger(hotkeyStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse mouse trigger '%s': %w", hotkeyStr, err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if existing, exists := b.handles[hotkeyStr]; exists {
+		return existing, nil
+	}
+	for str, other := range b.handles {
+		if other.trigger == trigger {
+			return nil, fmt.Errorf("mouse trigger '%s' is already registered as '%s'", hotkeyStr, str)
+		}
+	}
+
+	if b.hook == nil {
+		hook, err := startMouseHook(b.dispatch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hook the mouse for '%s': %w", hotkeyStr, err)
+		}
+		b.hook = hook
+	}
+
+	locks := anyLockKeys
+	if b.lockKeys != nil {
+		locks = b.lockKeys(hotkeyStr)
+	}
+	handle := &mouseHandle{
+		backend:   b,
+		hotkeyStr: hotkeyStr,
+		trigger:   trigger,
+		locks:     locks,
+		keydownCh: make(chan struct{}, 1),
+		keyupCh:   make(chan struct{}),
+	}
+	b.handles[hotkeyStr] = handle
+	b.publishLocked()
+	if err := b.hook.watch(b.triggersLocked()); err != nil {
+		delete(b.handles, hotkeyStr)
+		b.publishLocked()
+		_ = b.hook.watch(b.triggersLocked())
+		b.releaseIfIdleLocked()
+		return nil, fmt.Errorf("failed to register mouse trigger '%s': %w", hotkeyStr, err)
+	}
+	log.Printf("Mouse backend: Registered trigger '%s'", hotkeyStr)
+	return handle, nil
+}
+
+// Unregister stops reporting the mouse trigger hotkeyStr.
+func (b *MouseBackend) Unregister(hotkeyStr string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, exists := b.handles[hotkeyStr]; !exists {
+		return nil
+	}
+	delete(b.handles, hotkeyStr)
+	b.publishLocked()
+	var err error
+	if b.hook != nil {
+		err = b.hook.watch(b.triggersLocked())
+	}
+	b.releaseIfIdleLocked()
+	log.Printf("Mouse backend: Unregistered trigger '%s'", hotkeyStr)
+	return err
+}
+
+// UnregisterAll stops reporting all mouse triggers and releases the hook.
+func (b *MouseBackend) UnregisterAll() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handles = make(map[string]*mouseHandle)
+	b.publishLocked()
+	b.releaseIfIdleLocked()
+	return nil
+}
+
+// publishLocked makes the registered handles visible to dispatch. b.mu must
+// be held.
+func (b *MouseBackend) publishLocked() {
+	active := make(map[mouseTrigger]*mouseHandle, len(b.handles))
+	for _, handle := range b.handles {
+		active[handle.trigger] = handle
+	}
+	b.active.Store(&active)
+}
+
+// triggersLocked returns the registered triggers. b.mu must be held.
+func (b *MouseBackend) triggersLocked() map[mouseTrigger]lockKeys {
+	triggers := make(map[mouseTrigger]lockKeys, len(b.handles))
+	for _, handle := range b.handles {
+		triggers[handle.trigger] = handle.locks
+	}
+	return triggers
+}
+
+// releaseIfIdleLocked removes the hook once no trigger is registered, so
+// the mouse isn't watched needlessly. b.mu must be held.
+func (b *MouseBackend) releaseIfIdleLocked() {
+	if len(b.handles) == 0 && b.hook != nil {
+		b.hook.close()
+		b.hook = nil
+	}
+}
+
+// dispatch is called by the hook for every button press or wheel step with
+// the modifiers held. It reports whether a trigger matched, in which case
+// the hook keeps the event from the focused application.
+func (b *MouseBackend) dispatch(trigger mouseTrigger) bool {
+	active := b.active.Load()
+	if active == nil {
+		return false
+	}
+	handle, ok := (*active)[trigger]
+	if !ok || !handle.locks.allows() {
+		return false
+	}
+	select {
+	case handle.keydownCh <- struct{}{}:
+	default: // A press is still being handled; wheel steps come in bursts
+	}
+	return true
+}
+
+// mouseHandle is a registered mouse trigger.
+type mouseHandle struct {
+	backend   *MouseBackend
+	hotkeyStr string
+	trigger   mouseTrigger
+	locks     lockKeys
+	keydownCh chan struct{} // Buffered; the hook must never block
+	keyupCh   chan struct{} // Never signaled, mouse triggers have no release
+}
+
+// Keydown returns the channel that receives an event per trigger.
+func (h *mouseHandle) Keydown() <-chan struct{} {
+	return h.keydownCh
+}
+
+// Keyup returns a channel that never receives events.
+func (h *mouseHandle) Keyup() <-chan struct{} {
+	return h.keyupCh
+}
+
+// Close stops reporting the trigger.
+func (h *mouseHandle) Close() error {
+	return h.backend.Unregister(h.hotkeyStr)
+}
@@ -0,0 +1,246 @@
+//go:build linux && cgo
+
+package hotkey
+
+/*
+#cgo LDFLAGS: -lX11
+#include <X11/Xlib.h>
+
+static int grabFailed;
+
+static int onGrabError(Display *display, XErrorEvent *event) {
+	if (event->error_code == BadAccess) {
+		grabFailed = 1;
+	}
+	return 0;
+}
+
+// grabButtons grabs button with each of the modifier masks on the root
+// window and returns 0 if another client already grabbed one of them.
+static int grabButtons(Display *display, unsigned int button, unsigned int *masks, int count) {
+	Window root = DefaultRootWindow(display);
+	XSync(display, False);
+	grabFailed = 0;
+	int (*previous)(Display *, XErrorEvent *) = XSetErrorHandler(onGrabError);
+	for (int i = 0; i < count; i++) {
+		XGrabButton(display, button, masks[i], root, False, ButtonPressMask | ButtonReleaseMask,
+			GrabModeAsync, GrabModeAsync, None, None);
+	}
+	XSync(display, False);
+	XSetErrorHandler(previous);
+	return !grabFailed;
+}
+
+static void ungrabAll(Display *display) {
+	XUngrabButton(display, AnyButton, AnyModifier, DefaultRootWindow(display));
+	XSync(display, False);
+}
+
+// nextButtonPress reads the next event and returns 1 with its button and
+// modifier state if it is a button press.
+static int nextButtonPress(Display *display, unsigned int *button, unsigned int *state) {
+	XEvent event;
+	XNextEvent(display, &event);
+	if (event.type != ButtonPress) {
+		return 0;
+	}
+	*button = event.xbutton.button;
+	*state = event.xbutton.state;
+	return 1;
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"runtime"
+	"time"
+)
+
+// X11 buttons and modifier masks
+const (
+	x11ButtonMiddle  = 2
+	x11ButtonWheelUp = 4
+	x11ButtonWheelDn = 5
+	x11ButtonBack    = 8
+	x11ButtonForward = 9
+	x11ShiftMask     = 1 << 0
+	x11LockMask      = 1 << 1
+	x11ControlMask   = 1 << 2
+	x11Mod1Mask      = 1 << 3 // Alt
+	x11Mod2Mask      = 1 << 4 // NumLock
+	x11Mod4Mask      = 1 << 6 // Super
+)
+
+// x11MouseButtons maps triggers to X11 buttons.
+var x11MouseButtons = map[mouseButton]uint{
+	mouseMiddle:  x11ButtonMiddle,
+	mouseBack:    x11ButtonBack,
+	mouseForward: x11ButtonForward,
+	wheelUp:      x11ButtonWheelUp,
+	wheelDown:    x11ButtonWheelDn,
+}
+
+// x11MouseHook grabs the trigger buttons on the root window through its own
+// display connection. All X calls are made by one goroutine, so Xlib needs
+// no locking.
+type x11MouseHook struct {
+	dispatch func(mouseTrigger) bool
+	requests chan x11WatchRequest
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+type x11WatchRequest struct {
+	triggers map[mouseTrigger]lockKeys
+	result   chan error
+}
+
+// mouseHookSupported reports whether the session runs on X11.
+func mouseHookSupported() bool {
+	return DetectDisplayServer() == DisplayServerX11
+}
+
+// startMouseHook opens a display connection for the grabs.
+func startMouseHook(dispatch func(mouseTrigger) bool) (mouseHook, error) {
+	if !mouseHookSupported() {
+		return nil, errors.New("mouse triggers need an X11 session")
+	}
+	h := &x11MouseHook{
+		dispatch: dispatch,
+		requests: make(chan x11WatchRequest),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	started := make(chan error, 1)
+	go h.run(started)
+	if err := <-started; err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// run owns the display connection until close is called.
+func (h *x11MouseHook) run(started chan<- error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	defer close(h.done)
+
+	display := C.XOpenDisplay(nil)
+	if display == nil {
+		started <- errors.New("cannot open the X display")
+		return
+	}
+	defer C.XCloseDisplay(display)
+	started <- nil
+
+	// Xlib can't wait for events and requests at once, so events are polled
+	poll := time.NewTicker(20 * time.Millisecond)
+	defer poll.Stop()
+	for {
+		select {
+		case <-h.stop:
+			C.ungrabAll(display)
+			return
+		case req := <-h.requests:
+			req.result <- h.grab(display, req.triggers)
+		case <-poll.C:
+			for C.XPending(display) > 0 {
+				var button, state C.uint
+				if C.nextButtonPress(display, &button, &state) == 0 {
+					continue
+				}
+				if trigger, ok := x11Trigger(uint(button), uint(state)); ok {
+					h.dispatch(trigger)
+				}
+			}
+		}
+	}
+}
+
+// grab replaces all grabs with those of triggers, one per tolerated lock
+// state, since X11 matches the modifier state exactly.
+func (h *x11MouseHook) grab(display *C.Display, triggers map[mouseTrigger]lockKeys) error {
+	C.ungrabAll(display)
+	var failed []string
+	for trigger, locks := range triggers {
+		base := x11Modifiers(trigger.modifiers)
+		masks := []C.uint{C.uint(base)}
+		if locks.numLock {
+			masks = append(masks, C.uint(base|x11Mod2Mask))
+		}
+		if locks.capsLock {
+			masks = append(masks, C.uint(base|x11LockMask))
+		}
+		if locks.numLock && locks.capsLock {
+			masks = append(masks, C.uint(base|x11Mod2Mask|x11LockMask))
+		}
+		if C.grabButtons(display, C.uint(x11MouseButtons[trigger.button]), &masks[0], C.int(len(masks))) == 0 {
+			failed = append(failed, fmt.Sprintf("button %d", x11MouseButtons[trigger.button]))
+		}
+	}
+	if len(failed) > 0 {
+		log.Printf("Mouse backend: X11 grab failed for %v", failed)
+		return fmt.Errorf("another application already grabbed %v with these modifiers", failed)
+	}
+	return nil
+}
+
+func (h *x11MouseHook) watch(triggers map[mouseTrigger]lockKeys) error {
+	req := x11WatchRequest{triggers: triggers, result: make(chan error, 1)}
+	select {
+	case h.requests <- req:
+		return <-req.result
+	case <-h.done:
+		return errors.New("the mouse hook has stopped")
+	}
+}
+
+func (h *x11MouseHook) close() {
+	close(h.stop)
+	<-h.done
+}
+
+// x11Modifiers converts trigger modifiers to an X11 modifier mask.
+func x11Modifiers(mods mouseModifiers) uint {
+	var mask uint
+	if mods&mouseCtrl != 0 {
+		mask |= x11ControlMask
+	}
+	if mods&mouseShift != 0 {
+		mask |= x11ShiftMask
+	}
+	if mods&mouseAlt != 0 {
+		mask |= x11Mod1Mask
+	}
+	if mods&mouseSuper != 0 {
+		mask |= x11Mod4Mask
+	}
+	return mask
+}
+
+// x11Trigger converts a grabbed button press to a trigger.
+func x11Trigger(button, state uint) (mouseTrigger, bool) {
+	for trigger, x11Button := range x11MouseButtons {
+		if x11Button != button {
+			continue
+		}
+		var mods mouseModifiers
+		if state&x11ControlMask != 0 {
+			mods |= mouseCtrl
+		}
+		if state&x11ShiftMask != 0 {
+			mods |= mouseShift
+		}
+		if state&x11Mod1Mask != 0 {
+			mods |= mouseAlt
+		}
+		if state&x11Mod4Mask != 0 {
+			mods |= mouseSuper
+		}
+		return mouseTrigger{button: trigger, modifiers: mods}, true
+	}
+	return mouseTrigger{}, false
+}
@@ -0,0 +1,15 @@
+//go:build (linux && !cgo) || (!linux && !windows)
+
+package hotkey
+
+import "errors"
+
+// mouseHookSupported reports that this build can't hook the mouse: macOS
+// would need an event tap, and Linux builds without cgo have no Xlib.
+func mouseHookSupported() bool {
+	return false
+}
+
+func startMouseHook(dispatch func(mouseTrigger) bool) (mouseHook, error) {
+	return nil, errors.New("mouse triggers are only supported on Windows and Linux (X11)")
+}
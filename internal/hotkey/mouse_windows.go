@@ -0,0 +1,208 @@
+//go:build windows
+
+package hotkey
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	whMouseLL      = 14
+	wmQuit         = 0x0012
+	wmMButtonDown  = 0x0207
+	wmMButtonUp    = 0x0208
+	wmMouseWheel   = 0x020A
+	wmXButtonDown  = 0x020B
+	wmXButtonUp    = 0x020C
+	xButton1       = 0x0001
+	llmhfInjected  = 0x00000001
+	vkShift        = 0x10
+	vkControl      = 0x11
+	vkMenu         = 0x12
+	vkLWin         = 0x5B
+	vkRWin         = 0x5C
+	keyStateDownFl = 0x8000
+)
+
+var (
+	kernel32                = syscall.NewLazyDLL("kernel32.dll")
+	procGetModuleHandleW    = kernel32.NewProc("GetModuleHandleW")
+	procGetCurrentThreadId  = kernel32.NewProc("GetCurrentThreadId")
+	procRtlMoveMemory       = kernel32.NewProc("RtlMoveMemory")
+	procSetWindowsHookExW   = user32.NewProc("SetWindowsHookExW")
+	procUnhookWindowsHookEx = user32.NewProc("UnhookWindowsHookEx")
+	procCallNextHookEx      = user32.NewProc("CallNextHookEx")
+	procGetMessageW         = user32.NewProc("GetMessageW")
+	procPostThreadMessageW  = user32.NewProc("PostThreadMessageW")
+
+	// The callback is created once: Windows callbacks are never freed
+	mouseHookCallback     uintptr
+	mouseHookCallbackOnce sync.Once
+
+	// activeMouseHook is the hook the callback reports to
+	activeMouseHook atomic.Pointer[windowsMouseHook]
+)
+
+// msllHookStruct is MSLLHOOKSTRUCT, describing a low-level mouse event.
+type msllHookStruct struct {
+	x, y        int32
+	mouseData   uint32
+	flags       uint32
+	time        uint32
+	dwExtraInfo uintptr
+}
+
+// winMsg is MSG, only used to run the hook thread's message loop.
+type winMsg struct {
+	hwnd     uintptr
+	message  uint32
+	wParam   uintptr
+	lParam   uintptr
+	time     uint32
+	x, y     int32
+	lPrivate uint32
+}
+
+// windowsMouseHook is a WH_MOUSE_LL hook. It runs on its own locked thread,
+// which needs a message loop for Windows to call the hook.
+type windowsMouseHook struct {
+	dispatch func(mouseTrigger) bool
+	threadID uintptr
+	done     chan struct{}
+	swallow  [mouseForward + 1]bool // Buttons whose press was a trigger, so their release is dropped too
+}
+
+// mouseHookSupported reports that low-level mouse hooks are available.
+func mouseHookSupported() bool {
+	return true
+}
+
+// startMouseHook installs the hook. Only one can be active at a time.
+func startMouseHook(dispatch func(mouseTrigger) bool) (mouseHook, error) {
+	mouseHookCallbackOnce.Do(func() {
+		mouseHookCallback = syscall.NewCallback(mouseHookProc)
+	})
+
+	h := &windowsMouseHook{dispatch: dispatch, done: make(chan struct{})}
+	started := make(chan error, 1)
+	go func() {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		defer close(h.done)
+
+		h.threadID, _, _ = procGetCurrentThreadId.Call()
+		module, _, _ := procGetModuleHandleW.Call(0)
+		activeMouseHook.Store(h)
+		hook, _, err := procSetWindowsHookExW.Call(whMouseLL, mouseHookCallback, module, 0)
+		if hook == 0 {
+			activeMouseHook.CompareAndSwap(h, nil)
+			started <- fmt.Errorf("SetWindowsHookEx failed: %v", err)
+			return
+		}
+		started <- nil
+
+		var msg winMsg
+		for {
+			// 0 is WM_QUIT, -1 an error
+			if ret, _, _ := procGetMessageW.Call(uintptr(unsafe.Pointer(&msg)), 0, 0, 0); int32(ret) <= 0 {
+				break
+			}
+		}
+		procUnhookWindowsHookEx.Call(hook)
+		activeMouseHook.CompareAndSwap(h, nil)
+	}()
+
+	if err := <-started; err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// watch has nothing to do: the hook sees every event and dispatch decides.
+func (h *windowsMouseHook) watch(triggers map[mouseTrigger]lockKeys) error {
+	return nil
+}
+
+// close ends the message loop, which removes the hook.
+func (h *windowsMouseHook) close() {
+	procPostThreadMessageW.Call(h.threadID, wmQuit, 0, 0)
+	<-h.done
+}
+
+// mouseHookProc is the LowLevelMouseProc. Returning 1 drops the event.
+func mouseHookProc(nCode, wParam, lParam uintptr) uintptr {
+	if int32(nCode) >= 0 {
+		if h := activeMouseHook.Load(); h != nil {
+			var event msllHookStruct
+			procRtlMoveMemory.Call(uintptr(unsafe.Pointer(&event)), lParam, unsafe.Sizeof(event))
+			if h.handle(wParam, event) {
+				return 1
+			}
+		}
+	}
+	ret, _, _ := procCallNextHookEx.Call(0, nCode, wParam, lParam)
+	return ret
+}
+
+// handle reports whether the event was a trigger, or the release of one.
+func (h *windowsMouseHook) handle(message uintptr, event msllHookStruct) bool {
+	if event.flags&llmhfInjected != 0 {
+		return false // Clicks sent by other tools, or replayed by us
+	}
+
+	var button mouseButton
+	switch message {
+	case wmMButtonDown, wmMButtonUp:
+		button = mouseMiddle
+	case wmXButtonDown, wmXButtonUp:
+		button = mouseForward
+		if event.mouseData>>16 == xButton1 {
+			button = mouseBack
+		}
+	case wmMouseWheel:
+		button = wheelDown
+		if int16(event.mouseData>>16) > 0 {
+			button = wheelUp
+		}
+	default:
+		return false
+	}
+
+	if message == wmMButtonUp || message == wmXButtonUp {
+		swallowed := h.swallow[button]
+		h.swallow[button] = false
+		return swallowed
+	}
+	matched := h.dispatch(mouseTrigger{button: button, modifiers: heldMouseModifiers()})
+	if button != wheelUp && button != wheelDown {
+		h.swallow[button] = matched
+	}
+	return matched
+}
+
+// heldMouseModifiers returns the modifiers currently held down.
+func heldMouseModifiers() mouseModifiers {
+	held := func(vk uintptr) bool {
+		state, _, _ := procGetAsyncKeyState.Call(vk)
+		return state&keyStateDownFl != 0
+	}
+	var mods mouseModifiers
+	if held(vkControl) {
+		mods |= mouseCtrl
+	}
+	if held(vkShift) {
+		mods |= mouseShift
+	}
+	if held(vkMenu) {
+		mods |= mouseAlt
+	}
+	if held(vkLWin) || held(vkRWin) {
+		mods |= mouseSuper
+	}
+	return mods
+}
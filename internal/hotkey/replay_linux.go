@@ -0,0 +1,43 @@
+//go:build linux
+
+package hotkey
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// replayKeystroke sends hotkeyStr to the focused application with xdotool
+// (X11 only). --clearmodifiers releases modifiers the user is still holding
+// for the duration of the keystroke and restores them afterwards. The hotkey
+// must be unregistered first, otherwise the grab swallows the keystroke.
+func replayKeystroke(hotkeyStr string) error {
+	_, key, err := parseHotkey(hotkeyStr)
+	if err != nil {
+		return err
+	}
+
+	parts := strings.Split(strings.ToLower(hotkeyStr), "+")
+	var keys []string
+	for _, part := range parts[:len(parts)-1] {
+		switch strings.TrimSpace(part) {
+		case "ctrl":
+			keys = append(keys, "ctrl")
+		case "alt":
+			keys = append(keys, "alt")
+		case "shift":
+			keys = append(keys, "shift")
+		case "super", "win", "cmd":
+			keys = append(keys, "super")
+		}
+	}
+	// xdotool resolves keysyms written in hex, which avoids translating names
+	keys = append(keys, fmt.Sprintf("0x%x", uint32(key)))
+
+	out, err := exec.Command("xdotool", "key", "--clearmodifiers", strings.Join(keys, "+")).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("xdotool failed (is it installed?): %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
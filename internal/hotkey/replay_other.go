@@ -0,0 +1,10 @@
+//go:build !windows && !linux
+
+package hotkey
+
+import "fmt"
+
+// replayKeystroke is not implemented on this OS.
+func replayKeystroke(hotkeyStr string) error {
+	return fmt.Errorf("passing hotkeys through is not supported on this OS")
+}
@@ -0,0 +1,72 @@
+//go:build windows
+
+package hotkey
+
+import (
+	"fmt"
+	"syscall"
+
+	"golang.design/x/hotkey"
+)
+
+const (
+	keyeventfExtendedKey = 0x0001
+	keyeventfKeyUp       = 0x0002
+)
+
+var (
+	user32               = syscall.NewLazyDLL("user32.dll")
+	procKeybdEvent       = user32.NewProc("keybd_event")
+	procGetAsyncKeyState = user32.NewProc("GetAsyncKeyState")
+)
+
+// modifierVirtualKeys maps hotkey modifiers to the virtual keys to press.
+var modifierVirtualKeys = map[hotkey.Modifier]uintptr{
+	hotkey.ModCtrl:  0x11, // VK_CONTROL
+	hotkey.ModAlt:   0x12, // VK_MENU
+	hotkey.ModShift: 0x10, // VK_SHIFT
+	hotkey.ModWin:   0x5B, // VK_LWIN
+}
+
+// isExtendedKey reports whether vk needs KEYEVENTF_EXTENDEDKEY (navigation
+// keys, arrows and the numpad divide key).
+func isExtendedKey(vk uintptr) bool {
+	return (vk >= 0x21 && vk <= 0x28) || vk == 0x2D || vk == 0x2E || vk == 0x6F
+}
+
+// replayKeystroke sends hotkeyStr to the focused application. Modifiers the
+// user is still holding are not pressed or released again, so holding Ctrl
+// while tapping C twice keeps working. The hotkey must be unregistered
+// first, otherwise the injected keys trigger it again.
+func replayKeystroke(hotkeyStr string) error {
+	modifiers, key, err := parseHotkey(hotkeyStr)
+	if err != nil {
+		return err
+	}
+
+	var pressed []uintptr
+	for _, mod := range modifiers {
+		vk, ok := modifierVirtualKeys[mod]
+		if !ok {
+			return fmt.Errorf("unsupported modifier in '%s'", hotkeyStr)
+		}
+		if state, _, _ := procGetAsyncKeyState.Call(vk); state&0x8000 != 0 {
+			continue // Already held down by the user
+		}
+		procKeybdEvent.Call(vk, 0, 0, 0)
+		pressed = append(pressed, vk)
+	}
+
+	vk := uintptr(key)
+	var flags uintptr
+	if isExtendedKey(vk) {
+		flags = keyeventfExtendedKey
+	}
+	procKeybdEvent.Call(vk, 0, flags, 0)
+	procKeybdEvent.Call(vk, 0, flags|keyeventfKeyUp, 0)
+
+	for i := len(pressed) - 1; i >= 0; i-- {
+		procKeybdEvent.Call(pressed[i], 0, keyeventfKeyUp, 0)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package hotkey
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Hotkey sequences ("leader keys") are written as two space-separated steps,
+// e.g. "ctrl+alt+r 1". Pressing the leader (ctrl+alt+r) arms the sequence and
+// the next key selects the action. Only the leader is registered permanently,
+// so many profiles can share one global combination.
+
+// splitSequence returns the steps of a hotkey string. Plain hotkeys have a
+// single step.
+func splitSequence(hotkeyStr string) []string {
+	return strings.Fields(hotkeyStr)
+}
+
+// isSequence reports whether hotkeyStr is a leader key sequence.
+func isSequence(hotkeyStr string) bool {
+	return len(splitSequence(hotkeyStr)) > 1
+}
+
+// leaderGroup collects all sequences that start with the same leader key.
+type leaderGroup struct {
+	leader string // Leader as written by the first sequence using it
+	steps  []sequenceStep
+}
+
+// sequenceStep is the second key of one sequence and what it triggers.
+type sequenceStep struct {
+	key      string // Second key as written in the config
+	sequence string // Full hotkey string, e.g. "ctrl+alt+r 1"
+	label    string // Binding description for logging
+	action   func()
+}
+
+// sequenceMachine is the state machine behind hotkey sequences. It is idle
+// until a leader is pressed, then temporarily registers the leader's second
+// keys with the backend and waits for one of them, the timeout, or the
+// leader again (which cancels). Either way it unregisters the second keys
+// and returns to idle.
+type sequenceMachine struct {
+	mu         sync.Mutex
+	backend    Backend
+	timeout    time.Duration
+	active     *leaderGroup // nil while idle
+	armed      []string     // Second keys registered while active
+	quitCh     chan struct{}
+	timer      *time.Timer
+	generation int // Incremented on every state change to drop stale events
+}
+
+func newSequenceMachine(backend Backend, timeout time.Duration) *sequenceMachine {
+	return &sequenceMachine{
+		backend: backend,
+		timeout: timeout,
+	}
+}
+
+// start is called when the leader of group is pressed.
+func (s *sequenceMachine) start(group *leaderGroup) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.active != nil {
+		sameLeader := s.active == group
+		s.disarmLocked()
+		if sameLeader {
+			log.Printf("Hotkey sequence '%s' cancelled (leader pressed again)", group.leader)
+			return
+		}
+	}
+
+	s.generation++
+	gen := s.generation
+	s.active = group
+	s.quitCh = make(chan struct{})
+
+	var waiting []string
+	for _, step := range group.steps {
+		registered, err := s.backend.Register(step.key)
+		if err != nil {
+			log.Printf("Hotkey sequence '%s': could not register second key '%s': %v", step.sequence, step.key, err)
+			continue
+		}
+		s.armed = append(s.armed, step.key)
+		waiting = append(waiting, step.key)
+		go s.await(registered, step, gen, s.quitCh)
+	}
+
+	if len(s.armed) == 0 {
+		log.Printf("Hotkey sequence '%s': no second key could be registered", group.leader)
+		s.disarmLocked()
+		return
+	}
+
+	s.timer = time.AfterFunc(s.timeout, func() { s.expire(gen) })
+	log.Printf("Hotkey sequence '%s' started, waiting %v for: %s", group.leader, s.timeout, strings.Join(waiting, ", "))
+}
+
+// await waits for one second key and completes the sequence with it.
+func (s *sequenceMachine) await(registered RegisteredHotkey, step sequenceStep, gen int, quitCh chan struct{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("RECOVERED FROM PANIC IN HOTKEY SEQUENCE LISTENER (%s): %v", step.sequence, r)
+		}
+	}()
+
+	select {
+	case <-quitCh:
+		return
+	case _, ok := <-registered.Keydown():
+		if !ok {
+			return
+		}
+	}
+
+	s.mu.Lock()
+	if s.active == nil || s.generation != gen {
+		s.mu.Unlock()
+		return // Sequence already completed, cancelled or timed out
+	}
+	s.disarmLocked()
+	s.mu.Unlock()
+
+	log.Printf("Hotkey sequence '%s' completed for %s", step.sequence, step.label)
+	if step.action != nil {
+		step.action()
+	}
+}
+
+// expire ends the sequence started as generation gen if it is still waiting.
+func (s *sequenceMachine) expire(gen int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active == nil || s.generation != gen {
+		return
+	}
+	log.Printf("Hotkey sequence '%s' timed out", s.active.leader)
+	s.disarmLocked()
+}
+
+// stop cancels a waiting sequence, e.g. before the hotkeys are re-registered.
+func (s *sequenceMachine) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active != nil {
+		s.disarmLocked()
+	}
+}
+
+// disarmLocked unregisters the second keys and returns to idle. The caller
+// must hold s.mu.
+func (s *sequenceMachine) disarmLocked() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if s.quitCh != nil {
+		close(s.quitCh)
+		s.quitCh = nil
+	}
+	for _, key := range s.armed {
+		if err := s.backend.Unregister(key); err != nil {
+			log.Printf("Hotkey sequence: failed to unregister second key '%s': %v", key, err)
+		}
+	}
+	s.armed = nil
+	s.active = nil
+	s.generation++
+}
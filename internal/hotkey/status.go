@@ -0,0 +1,105 @@
+package hotkey
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+)
+
+// HotkeyStatus describes the registration result for one key combination.
+type HotkeyStatus struct {
+	Hotkey     string   // Hotkey as written in the config
+	Bindings   []string // What uses it, e.g. "profile 'Privacy'" or "revert"
+	Registered bool     // True if the OS accepted the registration
+	Error      string   // Registration error (often: another app owns the combo)
+	Conflicts  []string // Bindings that can't work because of an earlier one
+	Shared     bool     // Several profiles share the hotkey (supported)
+}
+
+// OK reports whether the hotkey works for every binding that uses it.
+func (s HotkeyStatus) OK() bool {
+	return s.Registered && len(s.Conflicts) == 0
+}
+
+type bindingKind int
+
+const (
+	bindingProfile bindingKind = iota
+	bindingReverse
+	bindingRevert
+	bindingType
+	bindingSmart
+	bindingCycle
+	bindingClear
+	bindingUndo
+	bindingRedo
+)
+
+// hotkeyBinding is one requested use of a hotkey.
+type hotkeyBinding struct {
+	hotkey  string
+	kind    bindingKind
+	profile config.ProfileConfig
+	group   string // Profile group of a cycle hotkey
+}
+
+func (b hotkeyBinding) describe() string {
+	switch b.kind {
+	case bindingProfile:
+		return fmt.Sprintf("profile '%s'", b.profile.Name)
+	case bindingReverse:
+		return fmt.Sprintf("profile '%s' (reverse)", b.profile.Name)
+	case bindingRevert:
+		return "revert"
+	case bindingType:
+		return "type clipboard"
+	case bindingSmart:
+		return "smart hotkey"
+	case bindingCycle:
+		return fmt.Sprintf("cycle group '%s'", b.group)
+	case bindingClear:
+		return "clear clipboard"
+	case bindingUndo:
+		return "undo"
+	case bindingRedo:
+		return "redo"
+	default:
+		return "unknown"
+	}
+}
+
+// normalizeHotkey returns a canonical form so "Alt+Ctrl+V" and "ctrl+alt+v"
+// are recognized as the same combination. Each step of a sequence is
+// normalized on its own, and "double:x" is the same as "x x".
+func normalizeHotkey(hotkeyStr string) string {
+	switch kind, combo := parseGesture(hotkeyStr); kind {
+	case gestureDouble:
+		step := normalizeStep(combo)
+		return step + " " + step
+	case gestureLong:
+		return longPressPrefix + normalizeStep(combo)
+	}
+
+	steps := splitSequence(hotkeyStr)
+	for i, step := range steps {
+		steps[i] = normalizeStep(step)
+	}
+	return strings.Join(steps, " ")
+}
+
+// normalizeStep normalizes a single modifier+key combination.
+func normalizeStep(hotkeyStr string) string {
+	parts := strings.Split(strings.ToLower(hotkeyStr), "+")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	parts[len(parts)-1] = canonicalKeyName(parts[len(parts)-1])
+	if len(parts) <= 1 {
+		return strings.Join(parts, "+")
+	}
+	modifiers := parts[:len(parts)-1]
+	sort.Strings(modifiers)
+	return strings.Join(append(modifiers, parts[len(parts)-1]), "+")
+}
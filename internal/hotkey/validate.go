@@ -0,0 +1,48 @@
+package hotkey
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidateHotkey checks that a hotkey string - a plain combination, a
+// two-key sequence, a double/long press or a mouse trigger - only uses
+// modifiers and keys supported on this platform. Nothing is registered.
+func ValidateHotkey(hotkeyStr string) error {
+	if strings.TrimSpace(hotkeyStr) == "" {
+		return fmt.Errorf("hotkey is empty")
+	}
+
+	steps := splitSequence(hotkeyStr)
+	kind, combo := parseGesture(hotkeyStr)
+	if kind != gestureNone {
+		if combo == "" || isSequence(combo) {
+			return fmt.Errorf("a double or long press needs a single combination (e.g. 'double:ctrl+c' or 'long:ctrl+c')")
+		}
+		steps = []string{combo}
+	} else if len(steps) > 2 {
+		return fmt.Errorf("only sequences of two keys are supported (e.g. 'ctrl+alt+r 1')")
+	}
+
+	for _, step := range steps {
+		if isMouseTrigger(step) {
+			if len(steps) > 1 || kind != gestureNone {
+				return fmt.Errorf("mouse triggers can't be part of a sequence or a double or long press")
+			}
+			if _, err := parseMouseTrigger(step); err != nil {
+				return err
+			}
+			if !mouseHookSupported() {
+				return fmt.Errorf("mouse triggers are only supported on Windows and Linux (X11)")
+			}
+			continue
+		}
+		if _, _, err := parseHotkey(step); err != nil {
+			if len(steps) > 1 {
+				return fmt.Errorf("'%s': %w", step, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package i18n
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// translatedArgs lists the functions outside package i18n whose string
+// arguments are translated, by package and name, with the positions of those
+// arguments. Functions of package ui starting with Show are matched by prefix.
+var translatedArgs = map[string]map[string][]int{
+	"i18n": {"T": {0}, "Tf": {0}},
+	"ui": {
+		"ShowAdminNotification":                  {1, 2},
+		"ShowAdminNotificationWithLink":          {1, 2, 3},
+		"ShowReplacementNotification":            {0, 1},
+		"ShowReplacementNotificationWithActions": {0, 1},
+		"SendTestNotification":                   {0, 1},
+	},
+}
+
+// messages returns the string literals passed as translated arguments in the
+// Go files of the module, with where they were found.
+func messages(t *testing.T) map[string]string {
+	t.Helper()
+	root := filepath.Join("..", "..")
+	found := make(map[string]string)
+	fset := token.NewFileSet()
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && (d.Name() == "testdata" || strings.HasPrefix(d.Name(), ".")) && path != root {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			selector, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			pkg, ok := selector.X.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			for _, i := range translatedArgs[pkg.Name][selector.Sel.Name] {
+				if i >= len(call.Args) {
+					continue
+				}
+				if lit, ok := call.Args[i].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+					if text, err := strconv.Unquote(lit.Value); err == nil && text != "" {
+						found[text] = fset.Position(lit.Pos()).String()
+					}
+				}
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return found
+}
+
+// TestCatalogsComplete checks that every message passed to i18n.T, i18n.Tf or
+// a notification has an entry in each built-in catalog.
+func TestCatalogsComplete(t *testing.T) {
+	found := messages(t)
+	if len(found) == 0 {
+		t.Fatal("no messages found")
+	}
+	entries, err := builtin.ReadDir("locales")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		data, err := builtin.ReadFile("locales/" + entry.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var catalog map[string]string
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			t.Fatalf("%s: %v", entry.Name(), err)
+		}
+		var missing []string
+		for text, where := range found {
+			if _, ok := catalog[text]; !ok {
+				missing = append(missing, where+": "+strconv.Quote(text))
+			}
+		}
+		sort.Strings(missing)
+		for _, m := range missing {
+			t.Errorf("%s has no entry for %s", entry.Name(), m)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package i18n
+
+import (
+	"os"
+	"strings"
+)
+
+// DetectLanguage returns the user's language, e.g. "de_DE.UTF-8", from the
+// LANGUAGE, LC_ALL, LC_MESSAGES and LANG environment variables, or else from
+// the operating system. It returns DefaultLanguage if none is set.
+func DetectLanguage() string {
+	if list := os.Getenv("LANGUAGE"); list != "" {
+		// A colon-separated list of preferences, e.g. "de:en"
+		if first := strings.Split(list, ":")[0]; first != "" {
+			return first
+		}
+	}
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" && value != "C" && value != "POSIX" {
+			return value
+		}
+	}
+	if lang := systemLanguage(); lang != "" {
+		return lang
+	}
+	return DefaultLanguage
+}
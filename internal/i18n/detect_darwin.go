@@ -0,0 +1,30 @@
+//go:build darwin
+
+package i18n
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// systemLanguage returns the first of the user's preferred macOS languages,
+// e.g. "de-DE". Apps started from Finder have no LANG variable, so it is read
+// from the user defaults, which print the list as
+//
+//	(
+//	    "de-DE",
+//	    "en-US"
+//	)
+func systemLanguage() string {
+	out, err := exec.Command("defaults", "read", "-g", "AppleLanguages").Output()
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.Trim(strings.TrimSpace(line), `",`)
+		if line != "" && line != "(" && line != ")" {
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,9 @@
+//go:build !windows && !darwin
+
+package i18n
+
+// systemLanguage returns "": on Linux and BSD the environment variables
+// checked by DetectLanguage are the system setting.
+func systemLanguage() string {
+	return ""
+}
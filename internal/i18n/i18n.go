@@ -0,0 +1,212 @@
+// Package i18n translates the text of the tray menu, notifications and
+// dialogs. Messages are looked up by their English text, so a message missing
+// from a catalog simply stays English. A catalog is a JSON object mapping the
+// English text to the translation; the built-in catalogs can be extended or
+// overridden by files in the "locales" folder next to config.json.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DirName is the folder next to config.json holding user catalogs, e.g.
+// locales/de.json.
+const DirName = "locales"
+
+// DefaultLanguage is the language the messages are written in.
+const DefaultLanguage = "en"
+
+// LanguageAuto selects the language of the operating system.
+const LanguageAuto = "auto"
+
+//go:embed locales/*.json
+var builtin embed.FS
+
+var (
+	mu       sync.RWMutex
+	language = DefaultLanguage
+	catalog  map[string]string // English text -> translation; nil for English
+)
+
+// Load activates the catalog for lang, or for the system language if lang is
+// empty or "auto". A region such as "de-AT" falls back to "de". Catalogs in
+// configDir/locales override the built-in ones entry by entry. It returns the
+// language used; if no catalog exists for it, messages stay English and an
+// error is returned.
+func Load(lang, configDir string) (string, error) {
+	if lang == "" || strings.EqualFold(lang, LanguageAuto) {
+		lang = DetectLanguage()
+	}
+	tags := candidates(lang)
+	merged := make(map[string]string)
+	found := false
+	// The base language first, so a regional catalog only needs the differences
+	for i := len(tags) - 1; i >= 0; i-- {
+		for _, load := range []func(string) (map[string]string, error){loadBuiltin, userLoader(configDir)} {
+			entries, err := load(tags[i])
+			if err != nil {
+				log.Printf("Warning: %v", err)
+				continue
+			}
+			if entries == nil {
+				continue
+			}
+			found = true
+			for text, translation := range entries {
+				if translation != "" { // Empty entries of a template stay English
+					merged[text] = translation
+				}
+			}
+		}
+	}
+
+	used := DefaultLanguage
+	if len(tags) > 0 {
+		used = tags[0]
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !found {
+		language, catalog = DefaultLanguage, nil
+		if used == DefaultLanguage {
+			return DefaultLanguage, nil
+		}
+		return DefaultLanguage, fmt.Errorf("no translation found for language '%s', using English", used)
+	}
+	language, catalog = used, merged
+	log.Printf("Language '%s' loaded (%d translated message(s)).", used, len(merged))
+	return used, nil
+}
+
+// Language returns the active language.
+func Language() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return language
+}
+
+// T returns the translation of text, or text itself if it isn't translated.
+func T(text string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	if translation, ok := catalog[text]; ok {
+		return translation
+	}
+	return text
+}
+
+// Tf translates format and formats it like fmt.Sprintf. Translations may
+// reorder the arguments with explicit indexes such as %[2]s.
+func Tf(format string, args ...any) string {
+	return fmt.Sprintf(T(format), args...)
+}
+
+// Available returns the languages with a built-in catalog or a catalog in
+// configDir/locales, sorted.
+func Available(configDir string) []string {
+	seen := make(map[string]bool)
+	if entries, err := builtin.ReadDir("locales"); err == nil {
+		for _, entry := range entries {
+			seen[strings.TrimSuffix(entry.Name(), ".json")] = true
+		}
+	}
+	if configDir != "" {
+		files, _ := filepath.Glob(filepath.Join(configDir, DirName, "*.json"))
+		for _, file := range files {
+			seen[strings.ToLower(strings.TrimSuffix(filepath.Base(file), ".json"))] = true
+		}
+	}
+	languages := make([]string, 0, len(seen))
+	for lang := range seen {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Template returns the messages of the built-in English catalog, each mapped
+// to its translation in lang or to "" if it has none, for translators to
+// start a catalog from.
+func Template(lang string) (map[string]string, error) {
+	english, err := loadBuiltin(DefaultLanguage)
+	if err != nil {
+		return nil, err
+	}
+	template := make(map[string]string, len(english))
+	for text := range english {
+		template[text] = ""
+	}
+	for _, tag := range candidates(lang) {
+		entries, err := loadBuiltin(tag)
+		if err != nil {
+			return nil, err
+		}
+		for text, translation := range entries {
+			if _, ok := template[text]; ok && template[text] == "" {
+				template[text] = translation
+			}
+		}
+	}
+	return template, nil
+}
+
+// candidates returns the catalog names to try for a language tag, most
+// specific first: "de_AT.UTF-8" yields "de-at" and "de".
+func candidates(lang string) []string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, ".@"); i >= 0 {
+		lang = lang[:i] // Drop the encoding and modifier of POSIX locales
+	}
+	lang = strings.ReplaceAll(lang, "_", "-")
+	if lang == "" || lang == "c" || lang == "posix" {
+		return []string{DefaultLanguage}
+	}
+	tags := []string{lang}
+	if i := strings.IndexByte(lang, '-'); i > 0 {
+		tags = append(tags, lang[:i])
+	}
+	return tags
+}
+
+// loadBuiltin returns the built-in catalog for tag, or nil if there is none.
+func loadBuiltin(tag string) (map[string]string, error) {
+	data, err := builtin.ReadFile("locales/" + tag + ".json")
+	if err != nil {
+		return nil, nil
+	}
+	var entries map[string]string
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("built-in catalog '%s' is invalid: %w", tag, err)
+	}
+	return entries, nil
+}
+
+// userLoader returns a loader for the catalogs in configDir/locales.
+func userLoader(configDir string) func(string) (map[string]string, error) {
+	return func(tag string) (map[string]string, error) {
+		if configDir == "" {
+			return nil, nil
+		}
+		path := filepath.Join(configDir, DirName, tag+".json")
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			return nil, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read catalog '%s': %w", path, err)
+		}
+		var entries map[string]string
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("catalog '%s' is not a JSON object of strings: %w", path, err)
+		}
+		log.Printf("Loaded %d message(s) from %s", len(entries), path)
+		return entries, nil
+	}
+}
@@ -0,0 +1,184 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// hotstringRegex matches ":options:trigger::replacement". The replacement may
+// be empty when it continues in a "( ... )" section on the following lines.
+var hotstringRegex = regexp.MustCompile(`^:([^:]*):(.+?)::(.*)$`)
+
+// ahkKeyNames maps the Send key names we can represent as plain text.
+var ahkKeyNames = map[string]string{
+	"{enter}": "\n",
+	"{tab}":   "\t",
+	"{space}": " ",
+	"{{}":     "{",
+	"{}}":     "}",
+	"{!}":     "!",
+	"{#}":     "#",
+	"{+}":     "+",
+	"{^}":     "^",
+}
+
+var ahkKeyRegex = regexp.MustCompile(`\{[^{}]*\}|\{\{\}|\{\}\}`)
+
+// ImportAutoHotkey converts the simple hotstrings in an AutoHotkey script into
+// replacement rules. Hotstrings that run code (X option or a body with
+// commands) are skipped with a warning.
+//
+// Options are mapped as follows: without '?' the trigger must start at a word
+// boundary, without '*' it must end at one; the default (C0) matching is
+// case-insensitive with case conforming, C is case-sensitive and C1 is
+// case-insensitive without conforming.
+func ImportAutoHotkey(path string) (*Result, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open '%s': %w", path, err)
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read '%s': %w", path, err)
+	}
+
+	result := &Result{}
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+		m := hotstringRegex.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		where := fmt.Sprintf("line %d", i+1)
+		options := strings.ToUpper(m[1])
+		trigger := m[2]
+		body := m[3]
+		raw := strings.Contains(options, "R") || strings.Contains(options, "T")
+
+		if strings.Contains(options, "X") {
+			result.Skipped++
+			result.warnf("%s: hotstring '%s' executes code (X option), skipped", where, trigger)
+			continue
+		}
+
+		if strings.TrimSpace(body) == "" {
+			// Either a continuation section or a code block
+			if i+1 < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i+1]), "(") {
+				var section []string
+				j := i + 2
+				for ; j < len(lines); j++ {
+					if strings.HasPrefix(strings.TrimSpace(lines[j]), ")") {
+						break
+					}
+					section = append(section, lines[j])
+				}
+				i = j
+				body = strings.Join(section, "\n")
+				raw = true // Continuation sections are sent literally apart from escapes
+			} else {
+				result.Skipped++
+				result.warnf("%s: hotstring '%s' runs a code block, skipped", where, trigger)
+				continue
+			}
+		} else {
+			body = stripAHKComment(body)
+		}
+
+		text, ok := convertAHKText(body, raw)
+		if !ok {
+			result.Skipped++
+			result.warnf("%s: hotstring '%s' uses keys or commands that cannot be converted, skipped", where, trigger)
+			continue
+		}
+		if hasSecretPlaceholder(text) || hasSecretPlaceholder(trigger) {
+			result.Skipped++
+			result.warnf("%s: hotstring '%s' contains '{{...}}', which would be treated as a secret reference, skipped", where, trigger)
+			continue
+		}
+
+		caseSensitive := strings.Contains(options, "C") && !strings.Contains(options, "C1") && !strings.Contains(options, "C0")
+		conform := !strings.Contains(options, "C")
+		if strings.Contains(options, "C0") {
+			conform = true
+		}
+		result.Replacements = append(result.Replacements, literalRule(
+			unescapeAHK(trigger),
+			text,
+			!strings.Contains(options, "?"),
+			!strings.Contains(options, "*"),
+			!caseSensitive,
+			conform,
+		))
+	}
+	return result, nil
+}
+
+// stripAHKComment removes a trailing " ;comment" from a single-line body.
+func stripAHKComment(s string) string {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '`' {
+			i++ // Skip escaped character
+			continue
+		}
+		if s[i] == ';' && i > 0 && (s[i-1] == ' ' || s[i-1] == '\t') {
+			return strings.TrimRight(s[:i], " \t")
+		}
+	}
+	return s
+}
+
+// unescapeAHK resolves AutoHotkey escape sequences such as `n, `t and `;.
+func unescapeAHK(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '`' || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 's':
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// convertAHKText turns a hotstring body into plain text. In non-raw mode
+// {Key} names are translated; unknown keys make the body unconvertible.
+func convertAHKText(body string, raw bool) (string, bool) {
+	text := unescapeAHK(body)
+	if raw {
+		return text, true
+	}
+	ok := true
+	text = ahkKeyRegex.ReplaceAllStringFunc(text, func(key string) string {
+		if replacement, found := ahkKeyNames[strings.ToLower(key)]; found {
+			return replacement
+		}
+		ok = false
+		return key
+	})
+	return text, ok
+}
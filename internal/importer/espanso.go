@@ -0,0 +1,175 @@
+package importer
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// espansoFile mirrors the parts of an Espanso match file we understand.
+type espansoFile struct {
+	Matches []espansoMatch `yaml:"matches"`
+}
+
+type espansoMatch struct {
+	Trigger       string        `yaml:"trigger"`
+	Triggers      []string      `yaml:"triggers"`
+	Regex         string        `yaml:"regex"`
+	Replace       *string       `yaml:"replace"`
+	Word          bool          `yaml:"word"`
+	LeftWord      bool          `yaml:"left_word"`
+	RightWord     bool          `yaml:"right_word"`
+	PropagateCase bool          `yaml:"propagate_case"`
+	Vars          []interface{} `yaml:"vars"`
+	Form          string        `yaml:"form"`
+	ImagePath     string        `yaml:"image_path"`
+	HTML          string        `yaml:"html"`
+	Markdown      string        `yaml:"markdown"`
+}
+
+// ImportEspanso converts an Espanso match file, or every .yml/.yaml file in a
+// match directory, into replacement rules. Triggers become literal patterns;
+// regex triggers keep their pattern and named groups are mapped from
+// {{name}} to ${name}.
+func ImportEspanso(path string) (*Result, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access '%s': %w", path, err)
+	}
+
+	var files []string
+	if info.IsDir() {
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			ext := strings.ToLower(filepath.Ext(p))
+			if !d.IsDir() && (ext == ".yml" || ext == ".yaml") {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan '%s': %w", path, err)
+		}
+		sort.Strings(files)
+	} else {
+		files = []string{path}
+	}
+
+	result := &Result{}
+	for _, file := range files {
+		if err := importEspansoFile(file, result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func importEspansoFile(path string, result *Result) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read '%s': %w", path, err)
+	}
+	var file espansoFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse Espanso file '%s': %w", path, err)
+	}
+
+	name := filepath.Base(path)
+	for i, match := range file.Matches {
+		where := fmt.Sprintf("%s match #%d", name, i+1)
+
+		if match.Replace == nil {
+			result.Skipped++
+			switch {
+			case match.Form != "":
+				result.warnf("%s: forms are not supported, skipped", where)
+			case match.ImagePath != "":
+				result.warnf("%s: image matches are not supported, skipped", where)
+			case match.HTML != "" || match.Markdown != "":
+				result.warnf("%s: rich text matches are not supported, skipped", where)
+			default:
+				result.warnf("%s: no 'replace' text, skipped", where)
+			}
+			continue
+		}
+		replacement := *match.Replace
+
+		if match.Regex != "" {
+			rule, ok, err := espansoRegexRule(match.Regex, replacement)
+			if err != nil {
+				result.Skipped++
+				result.warnf("%s: regex '%s' is not valid in Go syntax (%v), skipped", where, match.Regex, err)
+				continue
+			}
+			if !ok {
+				result.Skipped++
+				result.warnf("%s: regex trigger uses variables that are not capture groups, skipped", where)
+				continue
+			}
+			result.Replacements = append(result.Replacements, rule)
+			continue
+		}
+
+		if len(match.Vars) > 0 || hasSecretPlaceholder(replacement) {
+			result.Skipped++
+			result.warnf("%s: variables ({{...}}) are not supported, skipped", where)
+			continue
+		}
+
+		triggers := match.Triggers
+		if match.Trigger != "" {
+			triggers = append([]string{match.Trigger}, triggers...)
+		}
+		if len(triggers) == 0 {
+			result.Skipped++
+			result.warnf("%s: no trigger, skipped", where)
+			continue
+		}
+
+		for _, trigger := range triggers {
+			wordStart := match.Word || match.LeftWord
+			wordEnd := match.Word || match.RightWord
+			result.Replacements = append(result.Replacements,
+				literalRule(trigger, replacement, wordStart, wordEnd, match.PropagateCase, match.PropagateCase))
+		}
+	}
+	return nil
+}
+
+// espansoRegexRule converts a regex match. Espanso injects named groups into
+// the replacement as {{name}}; those become ${name}. Any other variable can't
+// be represented and makes the match unconvertible. It returns an error if
+// pattern is not a valid Go regex.
+func espansoRegexRule(pattern, replacement string) (config.Replacement, bool, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return config.Replacement{}, false, err
+	}
+	groups := map[string]bool{}
+	for _, name := range re.SubexpNames() {
+		if name != "" {
+			groups[name] = true
+		}
+	}
+
+	ok := true
+	converted := placeholderRegex.ReplaceAllStringFunc(escapeReplacement(replacement), func(m string) string {
+		name := placeholderRegex.FindStringSubmatch(m)[1]
+		if !groups[name] {
+			ok = false
+			return m
+		}
+		return "${" + name + "}"
+	})
+
+	return config.Replacement{Regex: pattern, ReplaceWith: converted}, ok, nil
+}
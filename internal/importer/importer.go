@@ -0,0 +1,103 @@
+// Package importer converts rule sets from other text expansion tools into
+// replacement rules for Clipboard Regex Replace.
+package importer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+)
+
+// Supported import sources
+const (
+	SourceEspanso    = "espanso"
+	SourceAutoHotkey = "ahk"
+)
+
+// Sources lists the supported source names for help texts and menus.
+var Sources = []string{SourceEspanso, SourceAutoHotkey}
+
+// Result holds the converted rules plus warnings about entries that could not
+// be converted (forms, scripts, unsupported variables, ...).
+type Result struct {
+	Replacements []config.Replacement
+	Warnings     []string
+	Skipped      int
+}
+
+func (r *Result) warnf(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// Import converts the rules found at path using the given source format.
+func Import(source, path string) (*Result, error) {
+	switch strings.ToLower(source) {
+	case SourceEspanso:
+		return ImportEspanso(path)
+	case SourceAutoHotkey, "autohotkey":
+		return ImportAutoHotkey(path)
+	default:
+		return nil, fmt.Errorf("unknown import source '%s' (supported: %s)", source, strings.Join(Sources, ", "))
+	}
+}
+
+// literalRule builds a rule that replaces a literal trigger. wordStart and
+// wordEnd require the trigger to be separated from surrounding words.
+func literalRule(trigger, replacement string, wordStart, wordEnd, caseInsensitive, preserveCase bool) config.Replacement {
+	pattern := regexp.QuoteMeta(trigger)
+	if wordStart && trigger != "" {
+		pattern = wordBoundary(trigger[0]) + pattern
+	}
+	if wordEnd && trigger != "" {
+		pattern = pattern + wordBoundary(trigger[len(trigger)-1])
+	}
+	return config.Replacement{
+		Regex:           pattern,
+		ReplaceWith:     escapeReplacement(replacement),
+		PreserveCase:    preserveCase,
+		CaseInsensitive: caseInsensitive,
+	}
+}
+
+// wordBoundary returns the assertion that separates a trigger edge from an
+// adjacent word: \b next to a word character, \B next to punctuation such as
+// the ':' that commonly prefixes triggers. Go's \b only knows ASCII word
+// characters, so the check mirrors that.
+func wordBoundary(edge byte) string {
+	if edge == '_' || ('a' <= edge && edge <= 'z') || ('A' <= edge && edge <= 'Z') || ('0' <= edge && edge <= '9') {
+		return `\b`
+	}
+	return `\B`
+}
+
+// escapeReplacement escapes '$' so Go's regexp does not expand it.
+func escapeReplacement(s string) string {
+	return strings.ReplaceAll(s, "$", "$$")
+}
+
+// hasSecretPlaceholder reports whether s would be treated as a secret reference.
+func hasSecretPlaceholder(s string) bool {
+	return placeholderRegex.MatchString(s)
+}
+
+var placeholderRegex = regexp.MustCompile(`\{\{\s*([a-zA-Z0-9_.]+)\s*\}\}`)
+
+// DefaultHotkey is assigned to imported profiles when none is given. Imported
+// profiles start disabled, so it is only a placeholder until reviewed.
+const DefaultHotkey = "ctrl+alt+i"
+
+// NewProfile wraps imported rules in a disabled profile so they can be
+// reviewed before their hotkey is registered.
+func NewProfile(name, hotkey string, result *Result) config.ProfileConfig {
+	if hotkey == "" {
+		hotkey = DefaultHotkey
+	}
+	return config.ProfileConfig{
+		Name:         name,
+		Enabled:      false,
+		Hotkey:       hotkey,
+		Replacements: result.Replacements,
+	}
+}
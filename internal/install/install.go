@@ -0,0 +1,157 @@
+// Package install integrates the executable with the desktop for the
+// "clipregex install" and "clipregex uninstall" commands: a Start Menu
+// shortcut and the notification identity on Windows, a desktop entry on
+// Linux, and the cleanup of everything the application created.
+package install
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/autostart"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/resources"
+)
+
+// ErrUnsupported is returned for steps that do not exist on the platform,
+// e.g. shortcuts on macOS, where applications are installed as bundles.
+var ErrUnsupported = errors.New("not supported on this platform")
+
+// AppUserModelID identifies the application to Windows for notifications
+// once "clipregex install" registered it.
+const AppUserModelID = "TanaroSch.ClipboardRegexReplace"
+
+// iconFileName is the icon written to the config folder for the
+// notification registration and the desktop entry.
+const iconFileName = "clipregex.ico"
+
+// Options selects the steps of Install.
+type Options struct {
+	Shortcut  bool // Start Menu shortcut (Windows) or desktop entry (Linux)
+	Autostart bool // Start with the system, like the tray toggle
+}
+
+// UninstallOptions selects the steps of Uninstall.
+type UninstallOptions struct {
+	KeepSecrets bool   // Leave the secrets in the OS keychain
+	Purge       bool   // Delete the config folder (config.json, statistics, backups)
+	ConfigPath  string // Config whose secrets are removed and whose folder is purged
+}
+
+// Step is the result of one install or uninstall step, for reporting.
+type Step struct {
+	Name string
+	Err  error // nil on success, ErrUnsupported if skipped
+}
+
+// executable returns the absolute path of the running executable.
+func executable() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to get executable path: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	return exe, nil
+}
+
+// dataDir is the folder for files written by Install, such as the icon: the
+// folder of the default config file.
+func dataDir() (string, error) {
+	configPath, err := config.DefaultConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Dir(configPath), nil
+}
+
+// writeIcon stores the embedded application icon as name in dir and returns
+// its path.
+func writeIcon(dir, name string) (string, error) {
+	icon, err := resources.GetIcon()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, icon, 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Install runs the selected steps for the running executable. It continues
+// after a failed step and reports every step.
+func Install(opts Options) ([]Step, error) {
+	exe, err := executable()
+	if err != nil {
+		return nil, err
+	}
+
+	steps := []Step{{Name: "Register application for notifications", Err: registerApp(exe)}}
+	if opts.Shortcut {
+		steps = append(steps, Step{Name: "Create shortcut", Err: createShortcut(exe)})
+	}
+	if opts.Autostart {
+		steps = append(steps, Step{Name: "Start with system", Err: autostart.Enable(nil)})
+	}
+	return steps, nil
+}
+
+// Uninstall removes the shortcut, the notification registration and the
+// autostart entry, and the secrets and config folder as selected.
+func Uninstall(opts UninstallOptions) []Step {
+	steps := []Step{
+		{Name: "Remove shortcut", Err: removeShortcut()},
+		{Name: "Unregister application for notifications", Err: unregisterApp()},
+		{Name: "Remove autostart entry", Err: autostart.Disable()},
+	}
+
+	if !opts.KeepSecrets {
+		steps = append(steps, Step{Name: "Remove secrets from the OS keychain", Err: removeSecrets(opts.ConfigPath)})
+	}
+	if opts.Purge {
+		steps = append(steps, Step{Name: "Delete config folder", Err: purgeConfig(opts.ConfigPath)})
+	}
+	if dir, err := dataDir(); err == nil {
+		os.Remove(filepath.Join(dir, iconFileName)) // Written by Install
+	}
+	return steps
+}
+
+// removeSecrets deletes every secret declared in the config from the keychain.
+func removeSecrets(configPath string) error {
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		return nil // No config, so no secrets to look up
+	}
+	cfg, err := config.LoadWithoutSecrets(configPath)
+	if err != nil {
+		return fmt.Errorf("cannot read the secret names: %w", err)
+	}
+	var errs []error
+	for _, name := range cfg.GetSecretNames() {
+		if err := cfg.RemoveSecretReference(name); err != nil {
+			errs = append(errs, fmt.Errorf("secret '%s': %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// purgeConfig deletes the folder of configPath, but only if it is the
+// standard config folder, so a --config pointing into e.g. a project folder
+// never deletes unrelated files.
+func purgeConfig(configPath string) error {
+	dir, err := dataDir()
+	if err != nil {
+		return err
+	}
+	if filepath.Clean(filepath.Dir(configPath)) != filepath.Clean(dir) {
+		return fmt.Errorf("'%s' is not in the standard config folder '%s'; delete it by hand", configPath, dir)
+	}
+	return os.RemoveAll(dir)
+}
@@ -0,0 +1,28 @@
+//go:build darwin
+// +build darwin
+
+package install
+
+// On macOS, the application is installed by copying it to /Applications and
+// notifications are identified by the bundle, so there is nothing to register.
+
+// AppRegistered is always false on macOS.
+func AppRegistered() bool {
+	return false
+}
+
+func registerApp(exe string) error {
+	return ErrUnsupported
+}
+
+func unregisterApp() error {
+	return nil
+}
+
+func createShortcut(exe string) error {
+	return ErrUnsupported
+}
+
+func removeShortcut() error {
+	return nil
+}
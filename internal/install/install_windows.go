@@ -0,0 +1,173 @@
+//go:build windows
+// +build windows
+
+package install
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+)
+
+var (
+	advapi32            = syscall.NewLazyDLL("advapi32.dll")
+	procRegCreateKeyExW = advapi32.NewProc("RegCreateKeyExW")
+	procRegSetValueExW  = advapi32.NewProc("RegSetValueExW")
+	procRegDeleteKeyW   = advapi32.NewProc("RegDeleteKeyW")
+)
+
+const (
+	hkeyCurrentUser = 0x80000001
+	keyQueryValue   = 0x0001
+	keySetValue     = 0x0002
+	regSZ           = 1
+
+	errorFileNotFound = 2
+
+	// createNoWindow keeps PowerShell from flashing a console window.
+	createNoWindow = 0x08000000
+)
+
+// shortcutName is the Start Menu entry.
+const shortcutName = config.DefaultKeyringService + ".lnk"
+
+// aumidKey registers the AppUserModelID, so toasts show the application name
+// and icon instead of the raw ID. It needs no shortcut or package.
+const aumidKey = `Software\Classes\AppUserModelId\` + AppUserModelID
+
+// AppRegistered reports whether "clipregex install" registered the
+// AppUserModelID, so notifications can use it.
+func AppRegistered() bool {
+	path, err := syscall.UTF16PtrFromString(aumidKey)
+	if err != nil {
+		return false
+	}
+	var key syscall.Handle
+	if err := syscall.RegOpenKeyEx(hkeyCurrentUser, path, 0, keyQueryValue, &key); err != nil {
+		return false
+	}
+	syscall.RegCloseKey(key)
+	return true
+}
+
+// setString writes a REG_SZ value.
+func setString(key syscall.Handle, name, value string) error {
+	namePtr, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+	data, err := syscall.UTF16FromString(value)
+	if err != nil {
+		return err
+	}
+	ret, _, _ := procRegSetValueExW.Call(
+		uintptr(key),
+		uintptr(unsafe.Pointer(namePtr)),
+		0,
+		regSZ,
+		uintptr(unsafe.Pointer(&data[0])),
+		uintptr(len(data)*2), // Size in bytes, including the terminating zero
+	)
+	if ret != 0 {
+		return fmt.Errorf("failed to write registry value '%s': %w", name, syscall.Errno(ret))
+	}
+	return nil
+}
+
+func registerApp(exe string) error {
+	dir, err := dataDir()
+	if err != nil {
+		return err
+	}
+	iconPath, err := writeIcon(dir, iconFileName)
+	if err != nil {
+		return fmt.Errorf("failed to write icon: %w", err)
+	}
+
+	path, err := syscall.UTF16PtrFromString(aumidKey)
+	if err != nil {
+		return err
+	}
+	var key syscall.Handle
+	ret, _, _ := procRegCreateKeyExW.Call(
+		hkeyCurrentUser,
+		uintptr(unsafe.Pointer(path)),
+		0, 0, 0,
+		keySetValue,
+		0,
+		uintptr(unsafe.Pointer(&key)),
+		0,
+	)
+	if ret != 0 {
+		return fmt.Errorf("failed to create registry key HKCU\\%s: %w", aumidKey, syscall.Errno(ret))
+	}
+	defer syscall.RegCloseKey(key)
+
+	if err := setString(key, "DisplayName", config.DefaultKeyringService); err != nil {
+		return err
+	}
+	return setString(key, "IconUri", iconPath)
+}
+
+func unregisterApp() error {
+	path, err := syscall.UTF16PtrFromString(aumidKey)
+	if err != nil {
+		return err
+	}
+	ret, _, _ := procRegDeleteKeyW.Call(hkeyCurrentUser, uintptr(unsafe.Pointer(path)))
+	if ret != 0 && ret != errorFileNotFound {
+		return fmt.Errorf("failed to delete registry key HKCU\\%s: %w", aumidKey, syscall.Errno(ret))
+	}
+	return nil
+}
+
+// shortcutPath returns the per-user Start Menu shortcut, which needs no
+// administrator rights.
+func shortcutPath() (string, error) {
+	appData := os.Getenv("APPDATA")
+	if appData == "" {
+		return "", fmt.Errorf("APPDATA is not set")
+	}
+	return filepath.Join(appData, "Microsoft", "Windows", "Start Menu", "Programs", shortcutName), nil
+}
+
+// createShortcut creates the .lnk file through the WScript.Shell COM object.
+// The paths are passed as environment variables to avoid quoting them.
+func createShortcut(exe string) error {
+	path, err := shortcutPath()
+	if err != nil {
+		return err
+	}
+	script := `
+$shell = New-Object -ComObject WScript.Shell
+$link = $shell.CreateShortcut($env:CLIPREGEX_SHORTCUT)
+$link.TargetPath = $env:CLIPREGEX_TARGET
+$link.WorkingDirectory = Split-Path -Parent $env:CLIPREGEX_TARGET
+$link.Description = 'Apply regex replacements to the clipboard with global hotkeys'
+$link.Save()
+`
+	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	cmd.Env = append(os.Environ(), "CLIPREGEX_SHORTCUT="+path, "CLIPREGEX_TARGET="+exe)
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true, CreationFlags: createNoWindow}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to create shortcut '%s': %v: %s", path, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func removeShortcut() error {
+	path, err := shortcutPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove shortcut '%s': %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+//go:build !windows && !darwin
+// +build !windows,!darwin
+
+package install
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/autostart"
+)
+
+// AppRegistered is always false; notifications on Linux are identified by
+// the application name.
+func AppRegistered() bool {
+	return false
+}
+
+func registerApp(exe string) error {
+	return ErrUnsupported
+}
+
+func unregisterApp() error {
+	return nil
+}
+
+// desktopEntryPath returns the application launcher entry,
+// $XDG_DATA_HOME/applications/clipregex.desktop (usually in ~/.local/share).
+func desktopEntryPath() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to locate the home directory: %w", err)
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "applications", "clipregex.desktop"), nil
+}
+
+func createShortcut(exe string) error {
+	path, err := desktopEntryPath()
+	if err != nil {
+		return err
+	}
+	dir, err := dataDir()
+	if err != nil {
+		return err
+	}
+	iconPath, err := writeIcon(dir, iconFileName)
+	if err != nil {
+		return fmt.Errorf("failed to write icon: %w", err)
+	}
+
+	var b bytes.Buffer
+	b.WriteString("[Desktop Entry]\n")
+	b.WriteString("Type=Application\n")
+	b.WriteString("Name=Clipboard Regex Replace\n")
+	b.WriteString("Comment=Apply regex replacements to the clipboard with global hotkeys\n")
+	b.WriteString("Exec=" + autostart.DesktopExec(exe, nil) + "\n")
+	b.WriteString("Icon=" + iconPath + "\n")
+	b.WriteString("Terminal=false\n")
+	b.WriteString("Categories=Utility;\n")
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create applications folder: %w", err)
+	}
+	if err := os.WriteFile(path, b.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write desktop entry '%s': %w", path, err)
+	}
+	return nil
+}
+
+func removeShortcut() error {
+	path, err := desktopEntryPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove desktop entry '%s': %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+// Package ipc lets command line invocations control the running tray
+// instance, e.g. "clipregex trigger NAME" bound to a window manager shortcut
+// on Wayland, where global hotkeys don't work. The instance listens on a Unix
+// domain socket (supported by Windows 10 1803 and later, too) that only the
+// current user can reach. Each connection carries one JSON request and one
+// JSON response.
+package ipc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Commands understood by the running instance.
+const (
+	CommandTrigger = "trigger" // Run the profile named in Request.Profile on the clipboard
+	CommandPause   = "pause"   // Ignore hotkeys and triggers until resumed
+	CommandResume  = "resume"
+	CommandReload  = "reload" // Reload config.json, secrets and hotkeys
+)
+
+// ErrNotRunning is returned by Send when no instance listens for the config.
+var ErrNotRunning = errors.New("the application is not running for this config file")
+
+// timeout bounds a whole exchange. Triggers wait for the clipboard to be
+// processed, which the profiles' time budgets limit.
+const timeout = 2 * time.Minute
+
+// Request is sent by the command line to the running instance.
+type Request struct {
+	Command string `json:"command"`
+	Profile string `json:"profile,omitempty"` // For CommandTrigger
+	Reverse bool   `json:"reverse,omitempty"` // For CommandTrigger: apply the reverse replacements
+}
+
+// Response is the running instance's answer.
+type Response struct {
+	OK      bool   `json:"ok"`
+	Message string `json:"message,omitempty"`
+}
+
+// Handler runs a request in the running instance.
+type Handler func(Request) Response
+
+// SocketPath returns the socket of the instance using the config file at
+// configPath, so portable instances with their own config.json don't collide.
+// It lives in XDG_RUNTIME_DIR where set, otherwise in the temp folder.
+func SocketPath(configPath string) string {
+	if abs, err := filepath.Abs(configPath); err == nil {
+		configPath = abs
+	}
+	sum := sha256.Sum256([]byte(configPath))
+	name := "clipregex-" + hex.EncodeToString(sum[:4]) + ".sock"
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, name)
+	}
+	if uid := os.Getuid(); uid >= 0 { // -1 on Windows, where the temp folder is per user
+		name = strconv.Itoa(uid) + "-" + name // /tmp is shared on Unix
+	}
+	return filepath.Join(os.TempDir(), name)
+}
+
+// Server accepts requests until it is closed.
+type Server struct {
+	path     string
+	listener net.Listener
+	handler  Handler
+	wg       sync.WaitGroup
+}
+
+// Listen starts serving requests on the socket at path. A socket left behind
+// by an instance that crashed is replaced; one that still answers means
+// another instance runs with the same config file.
+func Listen(path string, handler Handler) (*Server, error) {
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("another instance is already listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove the stale socket %s: %w", path, err)
+		}
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		log.Printf("Warning: could not restrict access to %s: %v", path, err)
+	}
+
+	s := &Server{path: path, listener: listener, handler: handler}
+	s.wg.Add(1)
+	go s.serve()
+	log.Printf("Listening for commands on %s", path)
+	return s, nil
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Printf("Command socket stopped: %v", err)
+			}
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+// handle answers the request on conn.
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("RECOVERED FROM PANIC IN COMMAND HANDLER: %v", r)
+		}
+	}()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		json.NewEncoder(conn).Encode(Response{Message: fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	log.Printf("Command received: %s %q", req.Command, req.Profile)
+	if err := json.NewEncoder(conn).Encode(s.handler(req)); err != nil {
+		log.Printf("Failed to answer command '%s': %v", req.Command, err)
+	}
+}
+
+// Close stops accepting requests and removes the socket.
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	os.Remove(s.path) // Already gone on Unix, where closing unlinks it
+	return err
+}
+
+// Send passes req to the instance listening on path and returns its answer.
+func Send(path string, req Request) (Response, error) {
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err != nil {
+		return Response{}, ErrNotRunning
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return Response{}, fmt.Errorf("failed to send the command: %w", err)
+	}
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return Response{}, fmt.Errorf("no answer from the application: %w", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,270 @@
+// Package lint finds replacement rules that are probably mistakes: rules that
+// can never apply, duplicate regexes, rules shadowed by earlier rules and
+// patterns that are expensive on large input.
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+)
+
+// Checks reported in Finding.Check.
+const (
+	CheckEmptyProfile      = "empty-profile"
+	CheckEmptyMatch        = "empty-match"
+	CheckUnreachable       = "unreachable"
+	CheckUnreachableRevert = "unreachable-reverse"
+	CheckDuplicate         = "duplicate"
+	CheckShadowed          = "shadowed"
+	CheckNestedQuantifier  = "nested-quantifier"
+	CheckReverseGuess      = "reverse-guess"
+)
+
+// Finding is one problem found in a profile.
+type Finding struct {
+	Profile string
+	Rule    int // 1-based position in the profile, 0 for the profile itself
+	Check   string
+	Message string
+}
+
+func (f Finding) String() string {
+	if f.Rule == 0 {
+		return fmt.Sprintf("profile '%s' [%s]: %s", f.Profile, f.Check, f.Message)
+	}
+	return fmt.Sprintf("profile '%s' rule #%d [%s]: %s", f.Profile, f.Rule, f.Check, f.Message)
+}
+
+// placeholderRegex matches {{secret}} placeholders. They are replaced by the
+// quoted secret value at runtime, so they always match literal text.
+var placeholderRegex = regexp.MustCompile(`\{\{([a-zA-Z0-9_]+)\}\}`)
+
+// catchAllProbes are texts a rule must match completely to count as a
+// catch-all that replaces the whole clipboard.
+var catchAllProbes = []string{"", "a", "Hello, World!\n\tSecond line 123 äöü €"}
+
+// shadowContexts surround a literal to check that an earlier rule matches it
+// wherever it appears, not just on its own (e.g. "\bcat\b" vs. "cat").
+var shadowContexts = [][2]string{{"", ""}, {"x", "x"}, {"0", "0"}, {" ", " "}, {"\n", "\n"}, {"é", "é"}}
+
+// rule is a replacement prepared for linting.
+type rule struct {
+	config.Replacement
+	re           *regexp.Regexp // nil if the regex does not compile
+	hasSecrets   bool
+	literal      string // The text the regex matches if it is a plain literal
+	isLiteral    bool
+	plainText    bool // The regex is literal text, secret placeholders aside
+	unreachable  bool
+	constantText bool // The replacement ignores the matched text
+}
+
+// Profiles lints the rules of every profile, enabled or not, in order.
+func Profiles(profiles []config.ProfileConfig) []Finding {
+	var findings []Finding
+	for _, profile := range profiles {
+		findings = append(findings, Profile(profile)...)
+	}
+	return findings
+}
+
+// Profile lints the rules of a single profile.
+func Profile(profile config.ProfileConfig) []Finding {
+	var findings []Finding
+	report := func(index int, check, format string, args ...interface{}) {
+		findings = append(findings, Finding{Profile: profile.Name, Rule: index, Check: check, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(profile.Replacements) == 0 {
+		if profile.OutputMode != config.OutputModePlainText { // Pasting as plain text is useful on its own
+			report(0, CheckEmptyProfile, "the profile has no rules")
+		}
+		return findings
+	}
+
+	rules := make([]rule, len(profile.Replacements))
+	for i, rep := range profile.Replacements {
+		rules[i] = prepareRule(rep)
+	}
+
+	for i := range rules {
+		r := &rules[i]
+		index := i + 1
+
+		if r.ReverseWith != "" && strings.TrimSpace(profile.ReverseHotkey) == "" {
+			report(index, CheckUnreachableRevert, "reverse_with is set, but the profile has no reverse_hotkey")
+		}
+		if r.re == nil || (r.IsTransform() && r.Regex == "") || r.IsBidirectional() {
+			continue // Invalid regexes are reported by config validation; whole-text actions and bidirectional rules have none
+		}
+
+		if strings.TrimSpace(profile.ReverseHotkey) != "" && r.ReversesByGuess() && !r.plainText {
+			report(index, CheckReverseGuess, "the reverse hotkey guesses the original text of '%s' from the regex, which is deprecated "+
+				"and often wrong for patterns. Make it a bidirectional rule or set reverse_with", r.Regex)
+		}
+
+		if nested := nestedQuantifier(r.Regex); nested != "" {
+			report(index, CheckNestedQuantifier, "'%s' nests unbounded repetitions (%s). Go's regex engine still runs in linear time, "+
+				"but the pattern is slow on large clipboard content and backtracks catastrophically if used with other regex engines", r.Regex, nested)
+		}
+
+		if matchesEmpty(r.re) {
+			report(index, CheckEmptyMatch, "'%s' matches the empty string and inserts its replacement between every character", r.Regex)
+		}
+
+		if r.unreachable {
+			continue // Reported once by the catch-all rule below
+		}
+
+		for j := 0; j < i; j++ {
+			earlier := &rules[j]
+			if earlier.re == nil || earlier.unreachable || earlier.MaxCount > 0 || earlier.When != nil || earlier.IsTransform() || earlier.IsBidirectional() {
+				continue // Matches beyond max_count, skipped by when or kept by an action are left for later rules
+			}
+			if earlier.Regex == r.Regex {
+				report(index, CheckDuplicate, "same regex as rule #%d, which already replaced every match", j+1)
+				break
+			}
+			if shadows(earlier, r) {
+				report(index, CheckShadowed, "'%s' is always matched by rule #%d ('%s') first", r.Regex, j+1, earlier.Regex)
+				break
+			}
+		}
+
+		if isCatchAll(r) && r.constantText {
+			for j := i + 1; j < len(rules); j++ {
+				later := &rules[j]
+				if later.re != nil && later.re.MatchString(r.ReplaceWith) {
+					continue // Still applies to the text left by the catch-all
+				}
+				later.unreachable = true
+				report(j+1, CheckUnreachable, "rule #%d ('%s') replaces the whole text with a fixed value, so this rule never matches", index, r.Regex)
+			}
+		}
+	}
+	return findings
+}
+
+// prepareRule compiles a rule's regex with placeholders standing in for secrets.
+func prepareRule(rep config.Replacement) rule {
+	r := rule{Replacement: rep}
+	pattern := rep.Regex
+	if placeholderRegex.MatchString(pattern) {
+		r.hasSecrets = true
+		pattern = placeholderRegex.ReplaceAllLiteralString(pattern, "secret")
+	}
+	pattern = rep.Pattern(pattern)
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return r
+	}
+	r.re = re
+
+	if parsed, err := syntax.Parse(pattern, syntax.Perl); err == nil {
+		parsed = parsed.Simplify()
+		if parsed.Op == syntax.OpLiteral && parsed.Flags&syntax.FoldCase == 0 {
+			r.plainText = true
+			if !r.hasSecrets {
+				r.literal, r.isLiteral = string(parsed.Rune), true
+			}
+		}
+	}
+	r.constantText = !rep.IsTransform() && !rep.PreserveCase && !strings.Contains(rep.ReplaceWith, "$") && !placeholderRegex.MatchString(rep.ReplaceWith)
+	return r
+}
+
+// matchesEmpty reports whether re produces empty matches inside text, where
+// Go inserts the replacement. A catch-all like "(?s).*" does not, because an
+// empty match right after a match is skipped.
+func matchesEmpty(re *regexp.Regexp) bool {
+	for _, loc := range re.FindAllStringIndex("ab", -1) {
+		if loc[0] == loc[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// isCatchAll reports whether a rule matches any text completely.
+func isCatchAll(r *rule) bool {
+	if r.hasSecrets || r.When != nil {
+		return false
+	}
+	for _, probe := range catchAllProbes {
+		loc := r.re.FindStringIndex(probe)
+		if loc == nil || loc[0] != 0 || loc[1] != len(probe) {
+			return false
+		}
+	}
+	return true
+}
+
+// shadows reports whether earlier consumes every occurrence of the literal
+// matched by later, without putting it back with its replacement.
+func shadows(earlier, later *rule) bool {
+	if !later.isLiteral || later.literal == "" || earlier.hasSecrets {
+		return false
+	}
+	if strings.Contains(earlier.ReplaceWith, "$") ||
+		strings.Contains(strings.ToLower(earlier.ReplaceWith), strings.ToLower(later.literal)) {
+		return false // The replacement may reproduce the literal
+	}
+	for _, ctx := range shadowContexts {
+		text := ctx[0] + later.literal + ctx[1]
+		start, end := len(ctx[0]), len(ctx[0])+len(later.literal)
+		covered := false
+		for _, loc := range earlier.re.FindAllStringIndex(text, -1) {
+			if loc[0] <= start && loc[1] >= end {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+// nestedQuantifier returns the first unbounded repetition that contains
+// another unbounded repetition, such as "(a+)+", or "" if there is none.
+func nestedQuantifier(pattern string) string {
+	pattern = placeholderRegex.ReplaceAllLiteralString(pattern, "secret")
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return ""
+	}
+	return findNested(parsed, nil)
+}
+
+// findNested implements nestedQuantifier. outer is the enclosing unbounded
+// repetition, if any.
+func findNested(re, outer *syntax.Regexp) string {
+	if isUnbounded(re) {
+		if outer != nil {
+			return outer.String()
+		}
+		outer = re
+	}
+	for _, sub := range re.Sub {
+		if found := findNested(sub, outer); found != "" {
+			return found
+		}
+	}
+	return ""
+}
+
+// isUnbounded reports whether re repeats its operand without an upper limit.
+func isUnbounded(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpStar, syntax.OpPlus:
+		return true
+	case syntax.OpRepeat:
+		return re.Max == -1
+	}
+	return false
+}
@@ -7,10 +7,52 @@ import (
 //go:embed icon.ico
 var iconData []byte
 
+// Icon variants shown in the system tray to indicate the application state
+const (
+	IconNormal     = "normal"
+	IconPaused     = "paused"     // No profile is enabled
+	IconProcessing = "processing" // A replacement is running
+	IconPending    = "pending"    // The original clipboard can be reverted
+	IconError      = "error"      // Hotkeys failed to register
+)
+
+var (
+	//go:embed icon_paused.ico
+	iconPausedData []byte
+	//go:embed icon_processing.ico
+	iconProcessingData []byte
+	//go:embed icon_pending.ico
+	iconPendingData []byte
+	//go:embed icon_error.ico
+	iconErrorData []byte
+)
+
 // GetIcon returns the bytes of the embedded icon
 func GetIcon() ([]byte, error) {
 	if len(iconData) == 0 {
 		return nil, ErrIconNotFound
 	}
 	return iconData, nil
-}
\ No newline at end of file
+}
+
+// GetIconVariant returns the bytes of the embedded icon for a state variant
+// (IconNormal, IconPaused, ...).
+func GetIconVariant(variant string) ([]byte, error) {
+	var data []byte
+	switch variant {
+	case IconNormal:
+		data = iconData
+	case IconPaused:
+		data = iconPausedData
+	case IconProcessing:
+		data = iconProcessingData
+	case IconPending:
+		data = iconPendingData
+	case IconError:
+		data = iconErrorData
+	}
+	if len(data) == 0 {
+		return nil, ErrIconNotFound
+	}
+	return data, nil
+}
@@ -0,0 +1,169 @@
+// Package router detects what kind of content the clipboard holds (URL,
+// email, JSON, code, ...) and picks the profiles for the smart hotkey.
+package router
+
+import (
+	"encoding/json"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/table"
+)
+
+var (
+	emailPattern     = regexp.MustCompile(`^(?:[^<>\n]*<)?[^\s@<>]+@[^\s@<>]+\.[^\s@<>]+>?$`)
+	windowsPath      = regexp.MustCompile(`^(?:[A-Za-z]:[\\/]|\\\\[^\\\s]+\\)`)
+	unixPath         = regexp.MustCompile(`^(?:~|\.{1,2})?/[^/\s]`)
+	markdownFeatures = []*regexp.Regexp{
+		regexp.MustCompile(`(?m)^#{1,6} \S`),              // Heading
+		regexp.MustCompile(`(?m)^\s*(?:[-*+]|\d+\.) \S`),  // List item
+		regexp.MustCompile(`(?m)^` + "```"),               // Code fence
+		regexp.MustCompile(`\[[^\]\n]+\]\([^)\s]+\)`),     // Link
+		regexp.MustCompile(`\*\*[^*\n]+\*\*|__[^_\n]+__`), // Bold
+		regexp.MustCompile(`(?m)^> \S`),                   // Quote
+		regexp.MustCompile(`(?m)^\|.*\|\s*$`),             // Table row
+	}
+	codeKeywords = regexp.MustCompile(`(?m)^\s*(?:func|def|class|import|from \S+ import|package|return|public|private|static|const|let|var|fn|if \(|for \(|while \(|#include|using|namespace|SELECT|INSERT|UPDATE)\b`)
+	codeLineEnd  = regexp.MustCompile(`(?m)[;{}]\s*$`)
+	codeSyntax   = regexp.MustCompile(`=>|->|::|&&|\|\||!=|==|\+\+|\w+\([^()\n]*\)`)
+)
+
+// detectors are the built-in content types in detection order, without
+// "text", which always matches.
+var detectors = []struct {
+	name   string
+	detect func(text string) bool
+}{
+	{config.ContentTypeJSON, isJSON},
+	{config.ContentTypeURL, isURL},
+	{config.ContentTypeEmail, isEmail},
+	{config.ContentTypePath, isPath},
+	{config.ContentTypeMarkdown, isMarkdown},
+	{config.ContentTypeCode, isCode},
+	{config.ContentTypeTable, isTable},
+}
+
+func isJSON(text string) bool {
+	if !strings.HasPrefix(text, "{") && !strings.HasPrefix(text, "[") {
+		return false
+	}
+	return json.Valid([]byte(text))
+}
+
+func isURL(text string) bool {
+	if strings.ContainsAny(text, " \t\n") {
+		return false
+	}
+	if strings.HasPrefix(strings.ToLower(text), "www.") {
+		text = "https://" + text
+	}
+	u, err := url.Parse(text)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "ftp", "ftps", "ws", "wss":
+		return u.Host != ""
+	case "file":
+		return u.Path != ""
+	}
+	return false
+}
+
+func isEmail(text string) bool {
+	return !strings.Contains(text, "\n") && emailPattern.MatchString(text)
+}
+
+func isPath(text string) bool {
+	if strings.Contains(text, "\n") || strings.Contains(text, "://") {
+		return false
+	}
+	return windowsPath.MatchString(text) || unixPath.MatchString(text)
+}
+
+// isMarkdown requires two different Markdown features, as a single list item
+// or bold word also occurs in plain text.
+func isMarkdown(text string) bool {
+	features := 0
+	for _, feature := range markdownFeatures {
+		if feature.MatchString(text) {
+			features++
+			if features >= 2 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isCode requires keywords or statement endings plus typical syntax, or
+// statement endings on a good share of the lines.
+func isCode(text string) bool {
+	lines := strings.Count(text, "\n") + 1
+	endings := len(codeLineEnd.FindAllStringIndex(text, -1))
+	structure := codeKeywords.MatchString(text) || endings > 0
+	if structure && codeSyntax.MatchString(text) {
+		return true
+	}
+	return lines >= 3 && endings*3 >= lines
+}
+
+// isTable requires a table package.table can read. A CSV table needs a
+// header and two rows, as two lines with a comma each are often prose.
+func isTable(text string) bool {
+	t, format, err := table.Parse(text, "", 0)
+	return err == nil && (format != table.CSV || len(t.Rows) >= 3)
+}
+
+// Detect returns the content types of text, most specific first: the
+// matching rules in their order, then the matching built-in types. "text" is
+// always last. A rule named like a built-in type replaces its detection.
+func Detect(text string, rules []config.ContentTypeRule) []string {
+	trimmed := strings.TrimSpace(text)
+	var types []string
+	seen := make(map[string]bool)
+	for _, rule := range rules {
+		name := strings.ToLower(strings.TrimSpace(rule.Name))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true // Also replaces the built-in type of that name
+		ok, err := rule.Holds(text)
+		if err != nil {
+			log.Printf("Error evaluating content type rule '%s': %v", rule.Name, err)
+			continue
+		}
+		if ok {
+			types = append(types, name)
+		}
+	}
+	if trimmed != "" {
+		for _, detector := range detectors {
+			if !seen[detector.name] && detector.detect(trimmed) {
+				types = append(types, detector.name)
+			}
+		}
+	}
+	if !seen[config.ContentTypeText] {
+		types = append(types, config.ContentTypeText)
+	}
+	return types
+}
+
+// Route returns the content type the smart hotkey handles text as: the
+// first detected type that an enabled profile handles. contentType is empty
+// if no profile handles any of the detected types.
+func Route(text string, profiles []config.ProfileConfig, rules []config.ContentTypeRule) (contentType string, detected []string) {
+	detected = Detect(text, rules)
+	for _, candidate := range detected {
+		for _, profile := range profiles {
+			if profile.Enabled && profile.HandlesContentType(candidate) {
+				return candidate, detected
+			}
+		}
+	}
+	return "", detected
+}
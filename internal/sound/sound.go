@@ -0,0 +1,30 @@
+// Package sound plays short audio cues: the system's own sounds for
+// success, no match and error, or a sound file chosen by the user.
+package sound
+
+import (
+	"log"
+
+	"github.com/gen2brain/beeep"
+)
+
+// Play plays the cue for event (config.SoundSuccess, SoundNoMatch or
+// SoundError), or file if it is not empty, without waiting for it to end.
+// If the platform player fails, it falls back to a beep.
+func Play(event, file string) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("RECOVERED FROM PANIC IN SOUND PLAYER: %v", r)
+			}
+		}()
+		err := play(event, file)
+		if err == nil {
+			return
+		}
+		log.Printf("Could not play %s sound: %v. Beeping instead.", event, err)
+		if err := beeep.Beep(beeep.DefaultFreq, 150); err != nil {
+			log.Printf("Beep failed: %v", err)
+		}
+	}()
+}
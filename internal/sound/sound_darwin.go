@@ -0,0 +1,33 @@
+//go:build darwin
+// +build darwin
+
+package sound
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+)
+
+// systemSounds maps events to sounds in /System/Library/Sounds.
+var systemSounds = map[string]string{
+	config.SoundSuccess: "Glass",
+	config.SoundNoMatch: "Tink",
+	config.SoundError:   "Basso",
+}
+
+func play(event, file string) error {
+	if file == "" {
+		name, ok := systemSounds[event]
+		if !ok {
+			name = "Funk"
+		}
+		file = "/System/Library/Sounds/" + name + ".aiff"
+	}
+	if output, err := exec.Command("afplay", file).CombinedOutput(); err != nil {
+		return fmt.Errorf("afplay failed: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
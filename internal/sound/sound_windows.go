@@ -0,0 +1,60 @@
+//go:build windows
+// +build windows
+
+package sound
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+)
+
+var (
+	user32          = syscall.NewLazyDLL("user32.dll")
+	procMessageBeep = user32.NewProc("MessageBeep")
+	winmm           = syscall.NewLazyDLL("winmm.dll")
+	procPlaySoundW  = winmm.NewProc("PlaySoundW")
+)
+
+const (
+	mbOK              = 0x00 // "Default Beep" sound
+	mbIconHand        = 0x10 // "Critical Stop" sound
+	mbIconExclamation = 0x30 // "Exclamation" sound
+	mbIconAsterisk    = 0x40 // "Asterisk" sound
+
+	sndSync      = 0x0000
+	sndNoDefault = 0x0002 // Play nothing instead of the default sound if the file is missing
+	sndFilename  = 0x00020000
+)
+
+// systemSounds maps events to the sounds of the Windows sound scheme.
+var systemSounds = map[string]uintptr{
+	config.SoundSuccess: mbIconAsterisk,
+	config.SoundNoMatch: mbOK,
+	config.SoundError:   mbIconHand,
+}
+
+func play(event, file string) error {
+	if file == "" {
+		sound, ok := systemSounds[event]
+		if !ok {
+			sound = mbIconExclamation
+		}
+		if ret, _, err := procMessageBeep.Call(sound); ret == 0 {
+			return fmt.Errorf("MessageBeep failed: %w", err)
+		}
+		return nil
+	}
+
+	path, err := syscall.UTF16PtrFromString(file)
+	if err != nil {
+		return err
+	}
+	// Synchronous, as Play already runs on its own goroutine
+	if ret, _, _ := procPlaySoundW.Call(uintptr(unsafe.Pointer(path)), 0, sndFilename|sndNoDefault|sndSync); ret == 0 {
+		return fmt.Errorf("cannot play '%s' (PlaySound supports .wav files)", file)
+	}
+	return nil
+}
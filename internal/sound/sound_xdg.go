@@ -0,0 +1,65 @@
+//go:build !windows && !darwin
+// +build !windows,!darwin
+
+package sound
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+)
+
+// systemSounds maps events to names of the freedesktop sound theme.
+var systemSounds = map[string]string{
+	config.SoundSuccess: "complete",
+	config.SoundNoMatch: "dialog-information",
+	config.SoundError:   "dialog-error",
+}
+
+// themeDir holds the freedesktop theme's sound files, for systems without
+// libcanberra.
+const themeDir = "/usr/share/sounds/freedesktop/stereo/"
+
+// filePlayers play a sound file; the first installed one is used.
+var filePlayers = [][]string{
+	{"paplay"},      // PulseAudio, also with PipeWire's PulseAudio server
+	{"pw-play"},     // PipeWire
+	{"aplay", "-q"}, // ALSA, .wav only
+	{"ffplay", "-nodisp", "-autoexit", "-loglevel", "quiet"},
+}
+
+func play(event, file string) error {
+	if file == "" {
+		name, ok := systemSounds[event]
+		if !ok {
+			name = "bell"
+		}
+		// canberra-gtk-play plays the sound of the user's theme
+		if _, err := exec.LookPath("canberra-gtk-play"); err == nil {
+			if err := exec.Command("canberra-gtk-play", "-i", name).Run(); err == nil {
+				return nil
+			}
+		}
+		file = themeDir + name + ".oga"
+	}
+	return playFile(file)
+}
+
+// playFile plays file with the first available player.
+func playFile(file string) error {
+	for _, player := range filePlayers {
+		if _, err := exec.LookPath(player[0]); err != nil {
+			continue
+		}
+		args := append(append([]string{}, player[1:]...), file)
+		output, err := exec.Command(player[0], args...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("%s failed: %v: %s", player[0], err, strings.TrimSpace(string(output)))
+		}
+		return nil
+	}
+	return errors.New("no sound player found (install pulseaudio-utils, pipewire or alsa-utils)")
+}
@@ -0,0 +1,69 @@
+package stats
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Export formats
+const (
+	FormatCSV  = "csv"  // One row per profile and per rule
+	FormatJSON = "json" // The snapshot as written to stats.json
+)
+
+// FormatForPath returns the export format matching the extension of path,
+// JSON unless it ends in .csv.
+func FormatForPath(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return FormatCSV
+	}
+	return FormatJSON
+}
+
+// Export writes the snapshot to w in format. A snapshot that is still being
+// counted is exported as of now.
+func (snapshot Snapshot) Export(w io.Writer, format string) error {
+	if snapshot.Until.IsZero() {
+		snapshot.Until = time.Now()
+	}
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(snapshot)
+	case FormatCSV:
+		return snapshot.writeCSV(w)
+	default:
+		return fmt.Errorf("unknown export format '%s' (must be %s or %s)", format, FormatCSV, FormatJSON)
+	}
+}
+
+// writeCSV writes one row per profile and per rule. Profile rows have no
+// regex and no match count.
+func (snapshot Snapshot) writeCSV(w io.Writer) error {
+	since, until := formatTime(snapshot.Since), formatTime(snapshot.Until)
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"type", "profile", "regex", "hits", "matches", "chars_changed", "last_fired", "since", "until"})
+	for _, profile := range snapshot.Profiles {
+		cw.Write([]string{"profile", profile.Profile, "", strconv.Itoa(profile.Hits), "", strconv.Itoa(profile.CharsChanged), formatTime(profile.LastFired), since, until})
+	}
+	for _, rule := range snapshot.Rules {
+		cw.Write([]string{"rule", rule.Profile, rule.Regex, strconv.Itoa(rule.Hits), strconv.Itoa(rule.Matches), strconv.Itoa(rule.CharsChanged), formatTime(rule.LastFired), since, until})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// formatTime formats t as RFC 3339, or "" for the zero time.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
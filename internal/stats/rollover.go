@@ -0,0 +1,158 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// archivePrefix starts the names of the archived monthly statistics, which
+// are stored next to the statistics file as stats-YYYY-MM.json.
+const archivePrefix = "stats-"
+
+// monthLayout formats the month of an archive.
+const monthLayout = "2006-01"
+
+// ArchivePath returns the path of the archived statistics of month, next to
+// the statistics file at path.
+func ArchivePath(path string, month time.Time) string {
+	return filepath.Join(filepath.Dir(path), archivePrefix+month.Format(monthLayout)+".json")
+}
+
+// ParseMonth parses a month given as YYYY-MM.
+func ParseMonth(month string) (time.Time, error) {
+	t, err := time.ParseInLocation(monthLayout, strings.TrimSpace(month), time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid month '%s' (expected YYYY-MM)", month)
+	}
+	return t, nil
+}
+
+// ListArchives returns the months archived next to the statistics file at
+// path as YYYY-MM, oldest first.
+func ListArchives(path string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(filepath.Dir(path), archivePrefix+"*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var months []string
+	for _, match := range matches {
+		month := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(match), archivePrefix), ".json")
+		if _, err := ParseMonth(month); err == nil {
+			months = append(months, month)
+		}
+	}
+	sort.Strings(months)
+	return months, nil
+}
+
+// SetMonthlyRollover turns the monthly rollover on or off. With it on, the
+// counters of a past month are archived and reset at the first use in a new
+// month, so the statistics always cover the current month.
+func (s *Store) SetMonthlyRollover(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.monthly = enabled
+	s.rolloverLocked(time.Now())
+}
+
+// rolloverLocked archives and resets the counters if they were started in an
+// earlier month than now. The caller must hold s.mu.
+func (s *Store) rolloverLocked(now time.Time) {
+	if !s.monthly {
+		return
+	}
+	start := monthStart(now)
+	if !s.since.Before(start) {
+		return
+	}
+
+	if len(s.rules) > 0 || len(s.profiles) > 0 {
+		snapshot := s.snapshotLocked()
+		snapshot.Until = start
+		archive := ArchivePath(s.path, s.since)
+		if err := writeArchive(archive, snapshot); err != nil {
+			log.Printf("Error archiving statistics, keeping the counters: %v", err)
+			return
+		}
+		log.Printf("Statistics since %s archived to %s.", s.since.Format("2006-01-02"), archive)
+	}
+
+	s.since = start
+	s.rules = make(map[ruleKey]*RuleStats)
+	s.profiles = make(map[string]*ProfileStats)
+	if err := s.saveLocked(); err != nil {
+		log.Printf("Error saving statistics after the monthly rollover: %v", err)
+	}
+}
+
+// writeArchive writes an archived snapshot. An existing archive of the same
+// month, e.g. if an older stats.json was copied back, is added to rather than
+// overwritten.
+func writeArchive(path string, snapshot Snapshot) error {
+	if data, err := os.ReadFile(path); err == nil {
+		var earlier Snapshot
+		if err := json.Unmarshal(data, &earlier); err != nil {
+			return fmt.Errorf("failed to parse statistics archive '%s': %w", path, err)
+		}
+		snapshot = mergeSnapshots(earlier, snapshot)
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read statistics archive '%s': %w", path, err)
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write statistics archive '%s': %w", path, err)
+	}
+	return nil
+}
+
+// mergeSnapshots adds the counters of later to earlier.
+func mergeSnapshots(earlier, later Snapshot) Snapshot {
+	merged := NewStore("")
+	merged.since = earlier.Since
+	for _, snapshot := range []Snapshot{earlier, later} {
+		for _, rule := range snapshot.Rules {
+			key := ruleKey{rule.Profile, rule.Regex}
+			if existing, ok := merged.rules[key]; ok {
+				existing.Hits += rule.Hits
+				existing.Matches += rule.Matches
+				existing.CharsChanged += rule.CharsChanged
+				if rule.LastFired.After(existing.LastFired) {
+					existing.LastFired = rule.LastFired
+				}
+			} else {
+				rule := rule
+				merged.rules[key] = &rule
+			}
+		}
+		for _, profile := range snapshot.Profiles {
+			if existing, ok := merged.profiles[profile.Profile]; ok {
+				existing.Hits += profile.Hits
+				existing.CharsChanged += profile.CharsChanged
+				if profile.LastFired.After(existing.LastFired) {
+					existing.LastFired = profile.LastFired
+				}
+			} else {
+				profile := profile
+				merged.profiles[profile.Profile] = &profile
+			}
+		}
+	}
+	result := merged.snapshotLocked()
+	result.Until = later.Until
+	return result
+}
+
+// monthStart returns the first moment of the month of t in local time.
+func monthStart(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.Local)
+}
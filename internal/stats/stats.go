@@ -0,0 +1,275 @@
+// Package stats keeps usage statistics of replacement rules and profiles:
+// how often they changed the clipboard, when they last did and how many
+// characters they changed. Only counts are stored, never clipboard content.
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// FileName is the statistics file stored next to config.json.
+const FileName = "stats.json"
+
+// saveDelay batches statistics of rapid replacements into one write.
+const saveDelay = 5 * time.Second
+
+// RuleStats are the statistics of one rule. Rules are identified by their
+// profile and regex, so reordering rules keeps their statistics.
+type RuleStats struct {
+	Profile      string    `json:"profile"`
+	Regex        string    `json:"regex"`
+	Hits         int       `json:"hits"`          // Replacements in which the rule changed the text
+	Matches      int       `json:"matches"`       // Regex matches replaced in total
+	CharsChanged int       `json:"chars_changed"` // Characters changed in total
+	LastFired    time.Time `json:"last_fired"`
+}
+
+// ProfileStats are the statistics of one profile.
+type ProfileStats struct {
+	Profile      string    `json:"profile"`
+	Hits         int       `json:"hits"`          // Replacements in which the profile changed the text
+	CharsChanged int       `json:"chars_changed"` // Characters changed in total
+	LastFired    time.Time `json:"last_fired"`
+}
+
+// Snapshot is a copy of all statistics, sorted by profile and regex.
+type Snapshot struct {
+	Since    time.Time      `json:"since"`           // When collection started or was last reset
+	Until    time.Time      `json:"until,omitempty"` // When the counters were archived or exported; zero while counting
+	Profiles []ProfileStats `json:"profiles"`
+	Rules    []RuleStats    `json:"rules"`
+}
+
+type ruleKey struct {
+	profile string
+	regex   string
+}
+
+// Store collects statistics and persists them to a file.
+type Store struct {
+	mu        sync.Mutex
+	path      string
+	since     time.Time
+	until     time.Time // Set for an archived month
+	rules     map[ruleKey]*RuleStats
+	profiles  map[string]*ProfileStats
+	dirty     bool
+	saveTimer *time.Timer
+	monthly   bool // Archive and reset the counters when a new month starts
+}
+
+// NewStore creates an empty store that saves to path.
+func NewStore(path string) *Store {
+	return &Store{
+		path:     path,
+		since:    time.Now(),
+		rules:    make(map[ruleKey]*RuleStats),
+		profiles: make(map[string]*ProfileStats),
+	}
+}
+
+// PathForConfig returns the statistics file path for a config file path.
+func PathForConfig(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), FileName)
+}
+
+// Load reads the statistics file at path. A missing file yields an empty store.
+func Load(path string) (*Store, error) {
+	s := NewStore(path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("failed to read statistics file '%s': %w", path, err)
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse statistics file '%s': %w", path, err)
+	}
+	if !snapshot.Since.IsZero() {
+		s.since = snapshot.Since
+	}
+	s.until = snapshot.Until
+	for i := range snapshot.Rules {
+		rule := snapshot.Rules[i]
+		s.rules[ruleKey{rule.Profile, rule.Regex}] = &rule
+	}
+	for i := range snapshot.Profiles {
+		profile := snapshot.Profiles[i]
+		s.profiles[profile.Profile] = &profile
+	}
+	log.Printf("Loaded statistics for %d rule(s) from %s", len(s.rules), path)
+	return s, nil
+}
+
+// RecordRule records that a rule of profile changed before into after with
+// the given number of regex matches.
+func (s *Store) RecordRule(profile, regex string, matches int, before, after string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rolloverLocked(time.Now())
+
+	key := ruleKey{profile, regex}
+	rule, ok := s.rules[key]
+	if !ok {
+		rule = &RuleStats{Profile: profile, Regex: regex}
+		s.rules[key] = rule
+	}
+	rule.Hits++
+	rule.Matches += matches
+	rule.CharsChanged += CharsChanged(before, after)
+	rule.LastFired = time.Now()
+	s.scheduleSaveLocked()
+}
+
+// RecordProfile records that profile changed before into after.
+func (s *Store) RecordProfile(profile, before, after string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rolloverLocked(time.Now())
+
+	stats, ok := s.profiles[profile]
+	if !ok {
+		stats = &ProfileStats{Profile: profile}
+		s.profiles[profile] = stats
+	}
+	stats.Hits++
+	stats.CharsChanged += CharsChanged(before, after)
+	stats.LastFired = time.Now()
+	s.scheduleSaveLocked()
+}
+
+// Snapshot returns a copy of the current statistics.
+func (s *Store) Snapshot() Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rolloverLocked(time.Now())
+	return s.snapshotLocked()
+}
+
+func (s *Store) snapshotLocked() Snapshot {
+	snapshot := Snapshot{
+		Since:    s.since,
+		Until:    s.until,
+		Profiles: make([]ProfileStats, 0, len(s.profiles)),
+		Rules:    make([]RuleStats, 0, len(s.rules)),
+	}
+	for _, profile := range s.profiles {
+		snapshot.Profiles = append(snapshot.Profiles, *profile)
+	}
+	for _, rule := range s.rules {
+		snapshot.Rules = append(snapshot.Rules, *rule)
+	}
+	sort.Slice(snapshot.Profiles, func(i, j int) bool {
+		return snapshot.Profiles[i].Profile < snapshot.Profiles[j].Profile
+	})
+	sort.Slice(snapshot.Rules, func(i, j int) bool {
+		if snapshot.Rules[i].Profile != snapshot.Rules[j].Profile {
+			return snapshot.Rules[i].Profile < snapshot.Rules[j].Profile
+		}
+		return snapshot.Rules[i].Regex < snapshot.Rules[j].Regex
+	})
+	return snapshot
+}
+
+// Reset clears all statistics and saves the empty state.
+func (s *Store) Reset() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.since = time.Now()
+	s.rules = make(map[ruleKey]*RuleStats)
+	s.profiles = make(map[string]*ProfileStats)
+	log.Println("Statistics reset.")
+	return s.saveLocked()
+}
+
+// Close writes pending statistics, e.g. when the application quits.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.saveTimer != nil {
+		s.saveTimer.Stop()
+		s.saveTimer = nil
+	}
+	if !s.dirty {
+		return nil
+	}
+	return s.saveLocked()
+}
+
+// scheduleSaveLocked marks the statistics as changed and saves them after
+// saveDelay. The caller must hold s.mu.
+func (s *Store) scheduleSaveLocked() {
+	s.dirty = true
+	if s.saveTimer != nil {
+		return
+	}
+	s.saveTimer = time.AfterFunc(saveDelay, func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("RECOVERED FROM PANIC IN STATISTICS SAVE: %v", r)
+			}
+		}()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.saveTimer = nil
+		if err := s.saveLocked(); err != nil {
+			log.Printf("Error saving statistics: %v", err)
+		}
+	})
+}
+
+// saveLocked writes the statistics file. The caller must hold s.mu.
+func (s *Store) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.snapshotLocked(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write statistics file '%s': %w", s.path, err)
+	}
+	s.dirty = false
+	return nil
+}
+
+// CharsChanged returns the number of characters that differ between before
+// and after: the length of the longer side once the common prefix and
+// suffix are removed.
+func CharsChanged(before, after string) int {
+	prefix := 0
+	for prefix < len(before) && prefix < len(after) && before[prefix] == after[prefix] {
+		prefix++
+	}
+	for prefix > 0 && prefix < len(before) && !utf8.RuneStart(before[prefix]) {
+		prefix-- // Don't split a multi-byte character
+	}
+	before, after = before[prefix:], after[prefix:]
+	suffix := 0
+	for suffix < len(before) && suffix < len(after) && before[len(before)-1-suffix] == after[len(after)-1-suffix] {
+		suffix++
+	}
+	for suffix > 0 && suffix < len(before) && !utf8.RuneStart(before[len(before)-suffix]) {
+		suffix--
+	}
+	before, after = before[:len(before)-suffix], after[:len(after)-suffix]
+
+	removed, added := utf8.RuneCountInString(before), utf8.RuneCountInString(after)
+	if removed > added {
+		return removed
+	}
+	return added
+}
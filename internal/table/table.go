@@ -0,0 +1,266 @@
+// Package table reads and writes the tables found on the clipboard: CSV with
+// a comma or semicolon as delimiter, TSV as spreadsheets copy their cells,
+// Markdown tables and HTML tables. Reading handles quoted cells with
+// delimiters and line breaks, which a regex can't do reliably, and writing
+// pads and aligns the columns of Markdown tables.
+package table
+
+import (
+	"cmp"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Formats of tables
+const (
+	CSV      = "csv"
+	TSV      = "tsv"
+	Markdown = "markdown"
+	HTML     = "html"
+)
+
+// Formats returns the formats tables can be read and written in.
+func Formats() []string {
+	return []string{CSV, TSV, Markdown, HTML}
+}
+
+// Alignment is the alignment of a column.
+type Alignment int
+
+const (
+	AlignNone Alignment = iota // Up to the reader; Markdown renders it left-aligned
+	AlignLeft
+	AlignCenter
+	AlignRight
+)
+
+// Table is a table read from text. All rows have the same number of cells.
+type Table struct {
+	Rows   [][]string  // The header row first if Header is set
+	Header bool        // The first row holds the column names
+	Align  []Alignment // Alignment of each column, if the source had one
+	Comma  rune        // Delimiter of a CSV source
+}
+
+// ErrNoTable is returned by Parse if the text is not a table of the format.
+var ErrNoTable = errors.New("no table found")
+
+// Parse reads the table in text, in the given format or, if format is empty,
+// in the format Detect finds. It returns the table and its format. Cells of
+// CSV tables are separated by comma, which is detected if it is 0, or else a
+// comma. Rows shorter than others are filled up with empty cells.
+func Parse(text, format string, comma rune) (*Table, string, error) {
+	if format == "" {
+		if format = Detect(text); format == "" {
+			return nil, "", ErrNoTable
+		}
+	}
+	var t *Table
+	var err error
+	switch format {
+	case CSV:
+		if comma == 0 {
+			comma = cmp.Or(detectComma(text), ',')
+		}
+		t, err = parseDelimited(text, comma, false)
+	case TSV:
+		t, err = parseDelimited(text, '\t', false)
+	case Markdown:
+		t, err = parseMarkdown(text)
+	case HTML:
+		t, err = parseHTML(text)
+	default:
+		return nil, "", fmt.Errorf("unknown table format '%s' (use %s)", format, strings.Join(Formats(), ", "))
+	}
+	if err != nil {
+		return nil, format, err
+	}
+	t.pad()
+	return t, format, nil
+}
+
+// Detect returns the format of the table in text, or "" if it isn't one. A
+// CSV table needs at least two rows of the same number of cells, so that a
+// sentence with a comma isn't taken for one.
+func Detect(text string) string {
+	text = strings.TrimSpace(text)
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	switch {
+	case htmlTable.MatchString(text):
+		return HTML
+	case len(lines) >= 2 && strings.Contains(lines[0], "|") && markdownSeparator.MatchString(lines[1]):
+		return Markdown
+	case strings.Contains(lines[0], "\t"):
+		if _, err := parseDelimited(text, '\t', false); err == nil {
+			return TSV
+		}
+	}
+	if comma := detectComma(text); comma != 0 {
+		return CSV
+	}
+	return ""
+}
+
+// detectComma returns the delimiter of the CSV table in text, or 0 if no
+// delimiter gives at least two rows of the same number of cells.
+func detectComma(text string) rune {
+	best, bestCells := rune(0), 1
+	for _, comma := range []rune{',', ';'} {
+		t, err := parseDelimited(text, comma, true)
+		if err == nil && len(t.Rows) >= 2 && len(t.Rows[0]) > bestCells {
+			best, bestCells = comma, len(t.Rows[0])
+		}
+	}
+	return best
+}
+
+// parseDelimited reads CSV or TSV text. If strict, all rows must have the
+// same number of cells. Quotes within TSV cells are taken literally, as
+// spreadsheets copy them.
+func parseDelimited(text string, comma rune, strict bool) (*Table, error) {
+	r := csv.NewReader(strings.NewReader(strings.TrimRight(text, "\r\n")))
+	r.Comma, r.LazyQuotes = comma, comma == '\t'
+	if !strict {
+		r.FieldsPerRecord = -1
+	}
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrNoTable
+	}
+	return &Table{Rows: rows, Header: true, Comma: comma}, nil
+}
+
+var (
+	htmlTable         = regexp.MustCompile(`(?is)^(?:<[^>]*>\s*)*<(?:table|tr)\b`)
+	htmlRow           = regexp.MustCompile(`(?i)<tr\b[^>]*>`)
+	htmlCell          = regexp.MustCompile(`(?i)<t([hd])\b[^>]*>`)
+	htmlTableEnd      = regexp.MustCompile(`(?i)</table\s*>`)
+	htmlLineBreak     = regexp.MustCompile(`(?i)<br\s*/?>`)
+	htmlTag           = regexp.MustCompile(`(?s)<[^>]*>`)
+	htmlSpace         = regexp.MustCompile(`[ \t\r\n]+`)
+	markdownSeparator = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(?:\|\s*:?-+:?\s*)*\|?\s*$`)
+)
+
+// parseHTML reads the first table of an HTML fragment. Rows and cells end
+// where the next begins, so closing tags may be left out as HTML allows.
+// Markup within cells is dropped, except that line breaks are kept.
+func parseHTML(text string) (*Table, error) {
+	if end := htmlTableEnd.FindStringIndex(text); end != nil {
+		text = text[:end[0]]
+	}
+	t := &Table{Header: true}
+	for _, row := range htmlRow.Split(text, -1)[1:] {
+		var cells []string
+		header := true
+		tags := htmlCell.FindAllStringSubmatchIndex(row, -1)
+		for i, tag := range tags {
+			end := len(row)
+			if i+1 < len(tags) {
+				end = tags[i+1][0]
+			}
+			header = header && strings.EqualFold(row[tag[2]:tag[3]], "h")
+			lines := strings.Split(htmlLineBreak.ReplaceAllString(row[tag[1]:end], "\n"), "\n")
+			for j, line := range lines {
+				lines[j] = strings.TrimSpace(htmlSpace.ReplaceAllString(html.UnescapeString(htmlTag.ReplaceAllString(line, "")), " "))
+			}
+			cells = append(cells, strings.Join(lines, "\n"))
+		}
+		if len(cells) == 0 {
+			continue
+		}
+		if len(t.Rows) == 0 {
+			t.Header = header
+		}
+		t.Rows = append(t.Rows, cells)
+	}
+	if len(t.Rows) == 0 {
+		return nil, ErrNoTable
+	}
+	return t, nil
+}
+
+// parseMarkdown reads a Markdown table: a header row, the separator row with
+// the alignments, and the body rows. Escaped pipes and <br> are unescaped.
+func parseMarkdown(text string) (*Table, error) {
+	lines := strings.Split(strings.ReplaceAll(strings.TrimSpace(text), "\r\n", "\n"), "\n")
+	if len(lines) < 2 || !markdownSeparator.MatchString(lines[1]) {
+		return nil, ErrNoTable
+	}
+	t := &Table{Header: true}
+	for _, spec := range markdownCells(lines[1]) {
+		left, right := strings.HasPrefix(spec, ":"), strings.HasSuffix(spec, ":")
+		switch {
+		case left && right:
+			t.Align = append(t.Align, AlignCenter)
+		case right:
+			t.Align = append(t.Align, AlignRight)
+		case left:
+			t.Align = append(t.Align, AlignLeft)
+		default:
+			t.Align = append(t.Align, AlignNone)
+		}
+	}
+	for i, line := range lines {
+		if i == 1 || strings.TrimSpace(line) == "" {
+			continue
+		}
+		cells := markdownCells(line)
+		for j, cell := range cells {
+			cells[j] = htmlLineBreak.ReplaceAllString(strings.ReplaceAll(cell, `\|`, "|"), "\n")
+		}
+		t.Rows = append(t.Rows, cells)
+	}
+	return t, nil
+}
+
+// markdownCells splits a row of a Markdown table at the pipes that aren't
+// escaped, without the outer pipes, and trims the cells.
+func markdownCells(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+	var cells []string
+	start := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++ // Skips the escaped character
+		case '|':
+			cells = append(cells, strings.TrimSpace(line[start:i]))
+			start = i + 1
+		}
+	}
+	return append(cells, strings.TrimSpace(line[start:]))
+}
+
+// pad gives every row and the alignments the number of cells of the longest
+// row.
+func (t *Table) pad() {
+	columns := len(t.Align)
+	for _, row := range t.Rows {
+		columns = max(columns, len(row))
+	}
+	for i, row := range t.Rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		t.Rows[i] = row
+	}
+	for len(t.Align) < columns {
+		t.Align = append(t.Align, AlignNone)
+	}
+}
+
+// Columns returns the number of columns.
+func (t *Table) Columns() int {
+	return len(t.Align)
+}
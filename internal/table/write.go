@@ -0,0 +1,214 @@
+package table
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/width"
+)
+
+// Options controls how Write writes a table.
+type Options struct {
+	Comma     rune   // Delimiter of CSV (default ',')
+	Pad       bool   // Markdown: pads the cells to the width of their column
+	LineBreak string // Between rows (default "\n")
+}
+
+// numeric matches cells holding a number, amount or percentage.
+var numeric = regexp.MustCompile(`^[-+−]?[$€£¥]?\s?\d[\d.,'’ _\x{00A0}\x{202F}]*\s?(?:%|[$€£¥])?$`)
+
+// AutoAlign right-aligns the columns without an alignment whose cells below
+// the header are all numbers, as spreadsheets do.
+func (t *Table) AutoAlign() {
+	body := t.Rows
+	if t.Header && len(body) > 0 {
+		body = body[1:]
+	}
+	for col, align := range t.Align {
+		if align != AlignNone {
+			continue
+		}
+		numbers := 0
+		for _, row := range body {
+			if cell := strings.TrimSpace(row[col]); cell != "" {
+				if !numeric.MatchString(cell) {
+					numbers = -1
+					break
+				}
+				numbers++
+			}
+		}
+		if numbers > 0 {
+			t.Align[col] = AlignRight
+		}
+	}
+}
+
+// SetAlign sets the alignment of all columns.
+func (t *Table) SetAlign(align Alignment) {
+	for col := range t.Align {
+		t.Align[col] = align
+	}
+}
+
+// Write returns the table in format, without a line break after the last
+// row.
+func (t *Table) Write(format string, opts Options) (string, error) {
+	if opts.LineBreak == "" {
+		opts.LineBreak = "\n"
+	}
+	switch format {
+	case CSV:
+		if opts.Comma == 0 {
+			opts.Comma = ','
+		}
+		return t.writeDelimited(opts.Comma, opts.LineBreak)
+	case TSV:
+		return t.writeDelimited('\t', opts.LineBreak)
+	case Markdown:
+		return t.writeMarkdown(opts.Pad, opts.LineBreak), nil
+	case HTML:
+		return t.writeHTML(opts.LineBreak), nil
+	}
+	return "", fmt.Errorf("unknown table format '%s' (use %s)", format, strings.Join(Formats(), ", "))
+}
+
+// writeDelimited writes CSV or TSV, quoting cells that contain the
+// delimiter, quotes or line breaks.
+func (t *Table) writeDelimited(comma rune, lineBreak string) (string, error) {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Comma, w.UseCRLF = comma, lineBreak == "\r\n"
+	if err := w.WriteAll(t.Rows); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(b.String(), lineBreak), nil
+}
+
+// writeMarkdown writes a Markdown table. A table without a header gets an
+// empty one, since Markdown tables need one. Pipes in cells are escaped and
+// line breaks become <br>.
+func (t *Table) writeMarkdown(pad bool, lineBreak string) string {
+	rows := make([][]string, 0, len(t.Rows)+1)
+	if !t.Header {
+		rows = append(rows, make([]string, t.Columns()))
+	}
+	for _, row := range t.Rows {
+		cells := make([]string, len(row))
+		for col, cell := range row {
+			cell = strings.ReplaceAll(strings.ReplaceAll(cell, "\r\n", "\n"), "|", `\|`)
+			cells[col] = strings.ReplaceAll(cell, "\n", "<br>")
+		}
+		rows = append(rows, cells)
+	}
+
+	widths := make([]int, t.Columns())
+	for col := range widths {
+		widths[col] = 3 // The shortest separator, e.g. :-:
+		for _, row := range rows {
+			if pad {
+				widths[col] = max(widths[col], displayWidth(row[col]))
+			}
+		}
+	}
+
+	var lines []string
+	writeRow := func(cells []string) {
+		padded := make([]string, len(cells))
+		for col, cell := range cells {
+			padded[col] = cell
+			if pad {
+				padded[col] = align(cell, widths[col], t.Align[col])
+			}
+		}
+		lines = append(lines, "| "+strings.Join(padded, " | ")+" |")
+	}
+	writeRow(rows[0])
+	separators := make([]string, t.Columns())
+	for col, w := range widths {
+		switch t.Align[col] {
+		case AlignLeft:
+			separators[col] = ":" + strings.Repeat("-", w-1)
+		case AlignRight:
+			separators[col] = strings.Repeat("-", w-1) + ":"
+		case AlignCenter:
+			separators[col] = ":" + strings.Repeat("-", w-2) + ":"
+		default:
+			separators[col] = strings.Repeat("-", w)
+		}
+	}
+	lines = append(lines, "| "+strings.Join(separators, " | ")+" |")
+	for _, row := range rows[1:] {
+		writeRow(row)
+	}
+	return strings.Join(lines, lineBreak)
+}
+
+// align pads cell with spaces to w columns, placing it like a.
+func align(cell string, w int, a Alignment) string {
+	space := max(w-displayWidth(cell), 0)
+	switch a {
+	case AlignRight:
+		return strings.Repeat(" ", space) + cell
+	case AlignCenter:
+		return strings.Repeat(" ", space/2) + cell + strings.Repeat(" ", space-space/2)
+	}
+	return cell + strings.Repeat(" ", space)
+}
+
+// displayWidth returns the columns s takes in a monospaced font: wide and
+// full-width characters, such as those of Chinese and Japanese, take two,
+// combining marks and other zero-width characters none.
+func displayWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		switch {
+		case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		case width.LookupRune(r).Kind() == width.EastAsianWide, width.LookupRune(r).Kind() == width.EastAsianFullwidth:
+			n += 2
+		default:
+			n++
+		}
+	}
+	return n
+}
+
+// writeHTML writes an HTML table, with the header row in thead and the
+// alignments as text-align styles.
+func (t *Table) writeHTML(lineBreak string) string {
+	lines := []string{"<table>"}
+	writeRow := func(row []string, tag string) {
+		lines = append(lines, "    <tr>")
+		for col, cell := range row {
+			style := ""
+			switch t.Align[col] {
+			case AlignLeft:
+				style = ` style="text-align: left"`
+			case AlignCenter:
+				style = ` style="text-align: center"`
+			case AlignRight:
+				style = ` style="text-align: right"`
+			}
+			cell = strings.ReplaceAll(strings.ReplaceAll(html.EscapeString(cell), "\r\n", "\n"), "\n", "<br>")
+			lines = append(lines, fmt.Sprintf("      <%s%s>%s</%s>", tag, style, cell, tag))
+		}
+		lines = append(lines, "    </tr>")
+	}
+	body := t.Rows
+	if t.Header && len(body) > 0 {
+		lines = append(lines, "  <thead>")
+		writeRow(body[0], "th")
+		lines = append(lines, "  </thead>")
+		body = body[1:]
+	}
+	lines = append(lines, "  <tbody>")
+	for _, row := range body {
+		writeRow(row, "td")
+	}
+	lines = append(lines, "  </tbody>", "</table>")
+	return strings.Join(lines, lineBreak)
+}
@@ -0,0 +1,211 @@
+package tokenizer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Strategies of Fit, deciding which part of text over budget is kept
+const (
+	KeepHead = "head"    // The beginning (default)
+	KeepTail = "tail"    // The end, e.g. of a log
+	KeepEnds = "ends"    // The beginning and the end, dropping the middle
+	Compact  = "compact" // Blank lines and repeated lines squeezed out first, then like "ends"
+)
+
+// DefaultMarker replaces the trimmed text. {tokens} is the number of tokens
+// trimmed.
+const DefaultMarker = "[… {tokens} tokens trimmed …]"
+
+// Fitted is text fitted to a token budget.
+type Fitted struct {
+	Text     string
+	Original int // Tokens of the text before fitting
+	Tokens   int // Tokens of Text, including the marker
+	Trimmed  int // Tokens of the original text left out
+}
+
+// span is a piece of the text with its token estimate.
+type span struct {
+	end    int // Byte offset after the piece
+	tokens int
+}
+
+// Fit returns text cut to at most budget tokens by strategy. The cut is made
+// at a line break if one is close, otherwise between words, and marked with
+// marker, or DefaultMarker if it is empty. Text within the budget is
+// returned unchanged.
+func Fit(text string, budget int, strategy, marker string) (Fitted, error) {
+	if budget < 1 {
+		return Fitted{}, fmt.Errorf("token budget must be positive, got %d", budget)
+	}
+	if strategy == "" {
+		strategy = KeepHead
+	}
+	switch strategy {
+	case KeepHead, KeepTail, KeepEnds, Compact:
+	default:
+		return Fitted{}, fmt.Errorf("unknown strategy '%s' (must be %s, %s, %s or %s)", strategy, KeepHead, KeepTail, KeepEnds, Compact)
+	}
+	if marker == "" {
+		marker = DefaultMarker
+	}
+
+	original := Count(text)
+	if original <= budget {
+		return Fitted{Text: text, Original: original, Tokens: original}, nil
+	}
+	if strategy == Compact {
+		text = compact(text)
+		if tokens := Count(text); tokens <= budget {
+			return Fitted{Text: text, Original: original, Tokens: tokens, Trimmed: original - tokens}, nil
+		}
+		strategy = KeepEnds
+	}
+
+	spans := split(text)
+	// The marker is costed with the largest number it can show
+	available := budget - Count(expandMarker(marker, original)) - 1
+	if available < 1 {
+		marker, available = "", budget // No room for the marker, the text is just cut
+	}
+	for {
+		fitted := cut(text, spans, strategy, marker, available)
+		fitted.Original = original
+		fitted.Trimmed += original - Count(text) // What compacting saved
+		if fitted.Tokens <= budget || available <= 1 {
+			return fitted, nil
+		}
+		// Joining the parts merged pieces differently; cut a bit more
+		available = max(available-(fitted.Tokens-budget), 1)
+	}
+}
+
+// cut keeps the parts of text within available tokens by strategy and puts
+// marker in place of the rest.
+func cut(text string, spans []span, strategy, marker string, available int) Fitted {
+	var head, tail string
+	switch strategy {
+	case KeepHead:
+		head = text[:headEnd(spans, available)]
+	case KeepTail:
+		tail = text[tailStart(spans, available, 0):]
+	case KeepEnds:
+		end := headEnd(spans, available/2)
+		head = text[:end]
+		tail = text[tailStart(spans, available-Count(head), end):]
+	}
+	head, tail = cutAtLine(head, true), cutAtLine(tail, false)
+
+	trimmed := max(Count(text)-Count(head)-Count(tail), 0)
+	var b strings.Builder
+	b.WriteString(head)
+	if marker != "" {
+		if head != "" && !strings.HasSuffix(head, "\n") {
+			b.WriteString("\n")
+		}
+		b.WriteString(expandMarker(marker, trimmed))
+		if tail != "" {
+			b.WriteString("\n")
+		}
+	}
+	b.WriteString(tail)
+	return Fitted{Text: b.String(), Tokens: Count(b.String()), Trimmed: trimmed}
+}
+
+// expandMarker fills in the number of trimmed tokens.
+func expandMarker(marker string, trimmed int) string {
+	return strings.ReplaceAll(marker, "{tokens}", strconv.Itoa(trimmed))
+}
+
+// split returns the pieces of text with their tokens.
+func split(text string) []span {
+	locs := pieces.FindAllStringIndex(text, -1)
+	spans := make([]span, len(locs))
+	for i, loc := range locs {
+		spans[i] = span{end: loc[1], tokens: pieceTokens(text[loc[0]:loc[1]])}
+	}
+	return spans
+}
+
+// headEnd returns the byte offset after the leading pieces within budget.
+func headEnd(spans []span, budget int) int {
+	end, used := 0, 0
+	for _, s := range spans {
+		if used+s.tokens > budget {
+			break
+		}
+		used += s.tokens
+		end = s.end
+	}
+	return end
+}
+
+// tailStart returns the byte offset of the trailing pieces within budget,
+// not reaching before the offset limit.
+func tailStart(spans []span, budget, limit int) int {
+	used := 0
+	for i := len(spans) - 1; i >= 0; i-- {
+		start := 0
+		if i > 0 {
+			start = spans[i-1].end
+		}
+		if used+spans[i].tokens > budget || start < limit {
+			return spans[i].end
+		}
+		used += spans[i].tokens
+	}
+	return 0
+}
+
+// cutAtLine shortens the kept part to whole lines, unless that loses more
+// than half of it: the head to its last line break, the tail to after its
+// first.
+func cutAtLine(kept string, head bool) string {
+	if head {
+		if i := strings.LastIndexByte(kept, '\n'); i >= len(kept)/2 {
+			return kept[:i+1]
+		}
+		return kept
+	}
+	if i := strings.IndexByte(kept, '\n'); i >= 0 && i < len(kept)/2 {
+		return kept[i+1:]
+	}
+	return kept
+}
+
+// compact squeezes text without dropping content: trailing whitespace is
+// removed, runs of blank lines become one and a line repeated on the lines
+// after it is kept once with the number of repeats.
+func compact(text string) string {
+	lineBreak := "\n"
+	if strings.Contains(text, "\r\n") {
+		lineBreak = "\r\n"
+	}
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	var out []string
+	blank := false
+	for i := 0; i < len(lines); {
+		line := strings.TrimRight(lines[i], " \t")
+		j := i + 1
+		for j < len(lines) && strings.TrimRight(lines[j], " \t") == line {
+			j++
+		}
+		switch {
+		case line == "":
+			if !blank {
+				out = append(out, line)
+			}
+			blank = true
+		case j-i > 1:
+			out = append(out, line, fmt.Sprintf("[… repeated %d more times …]", j-i-1))
+			blank = false
+		default:
+			out = append(out, line)
+			blank = false
+		}
+		i = j
+	}
+	return strings.Join(out, lineBreak)
+}
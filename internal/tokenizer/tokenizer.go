@@ -0,0 +1,117 @@
+// Package tokenizer counts the tokens of text the way large language models
+// see it, so the clipboard can be fitted to a model's token budget before it
+// is pasted into a chat. The count is an estimate: the text is split like the
+// GPT byte-pair tokenizers split it before merging, into words with their
+// leading space, runs of up to three digits, punctuation and whitespace, and
+// each piece is costed by its length and script. It needs no vocabulary
+// files and tends to count slightly more than the models do, so text that
+// fits the estimate fits the model.
+package tokenizer
+
+import (
+	"regexp"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+// pieces splits text into the pieces the GPT tokenizers merge within:
+// English contractions, words with an optional leading space or symbol,
+// numbers of up to three digits, punctuation runs, line breaks and spaces.
+var pieces = regexp.MustCompile(`(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+`)
+
+// Count returns the estimated number of tokens of text.
+func Count(text string) int {
+	total := 0
+	for _, piece := range pieces.FindAllString(text, -1) {
+		total += pieceTokens(piece)
+	}
+	return total
+}
+
+// pieceTokens estimates the tokens of one piece. Common words are a single
+// token; longer ones add a token for about every four letters. Letters of
+// scripts other than Latin, and symbols outside ASCII such as emoji, take a
+// token or more each.
+func pieceTokens(piece string) int {
+	first, _ := utf8.DecodeRuneInString(piece)
+	switch {
+	case unicode.IsSpace(first) && isSpace(piece):
+		return (utf8.RuneCountInString(piece) + 7) / 8 // Indentation runs are merged
+	case unicode.IsNumber(first):
+		return 1
+	}
+	if len(piece) > 1 && piece[0] == ' ' {
+		piece = piece[1:] // The leading space is part of the word's token
+	}
+
+	latin, other, punct, symbols := 0, 0, 0, 0
+	for _, r := range piece {
+		switch {
+		case r < utf8.RuneSelf && unicode.IsLetter(r):
+			latin++
+		case unicode.IsLetter(r) && unicode.In(r, unicode.Latin):
+			latin += 2 // Accented letters take two bytes, which merge less often
+		case unicode.IsLetter(r):
+			other++
+		case r < utf8.RuneSelf:
+			punct++
+		default:
+			symbols++
+		}
+	}
+	tokens := other + 2*symbols + (punct+1)/2
+	if latin > 0 {
+		tokens++
+		if latin > 8 {
+			tokens += (latin - 8 + 3) / 4
+		}
+	}
+	if tokens == 0 {
+		tokens = 1
+	}
+	return tokens
+}
+
+// isSpace reports whether s consists of whitespace only.
+func isSpace(s string) bool {
+	for _, r := range s {
+		if !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// modelContextWindows are the context windows, in tokens, of common models
+// as published by their vendors. They are the budgets of the model presets.
+var modelContextWindows = map[string]int{
+	"gpt-3.5-turbo":    16385,
+	"gpt-4":            8192,
+	"gpt-4-turbo":      128000,
+	"gpt-4o":           128000,
+	"gpt-4o-mini":      128000,
+	"claude-3":         200000,
+	"gemini-1.5-flash": 1000000,
+	"gemini-1.5-pro":   2000000,
+	"llama-3":          8192,
+	"llama-3.1":        128000,
+	"mistral-7b":       32768,
+}
+
+// ModelBudget returns the token budget of the model preset called name: the
+// model's context window, which the prompt and the answer share.
+func ModelBudget(name string) (int, bool) {
+	budget, ok := modelContextWindows[name]
+	return budget, ok
+}
+
+// Models returns the names of the model presets in alphabetical order.
+func Models() []string {
+	names := make([]string, 0, len(modelContextWindows))
+	for name := range modelContextWindows {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// openAppWindow opens url in a standalone browser window without tabs or
+// address bar (Chromium/Edge "--app" mode), so local pages like the diff
+// viewer look like an application window. Falls back to the default browser
+// if no Chromium-based browser is installed.
+func openAppWindow(url string) error {
+	for _, browser := range appWindowBrowsers() {
+		cmd := exec.Command(browser, "--app="+url)
+		if err := cmd.Start(); err != nil {
+			log.Printf("Could not open app window with '%s': %v", browser, err)
+			continue
+		}
+		go func() { _ = cmd.Wait() }()
+		log.Printf("Opened app window with '%s'", browser)
+		return nil
+	}
+	log.Println("No Chromium-based browser found for an app window, using the default browser.")
+	if err := OpenFileInDefaultApp(url); err != nil {
+		return fmt.Errorf("failed to open window: %w", err)
+	}
+	return nil
+}
+
+// appWindowBrowsers returns the installed browsers that support "--app",
+// in order of preference.
+func appWindowBrowsers() []string {
+	var candidates []string
+	switch runtime.GOOS {
+	case "windows":
+		// Edge ships with Windows, but is usually not on the PATH
+		for _, env := range []string{"ProgramFiles(x86)", "ProgramFiles", "LocalAppData"} {
+			dir := os.Getenv(env)
+			if dir == "" {
+				continue
+			}
+			candidates = append(candidates,
+				filepath.Join(dir, "Microsoft", "Edge", "Application", "msedge.exe"),
+				filepath.Join(dir, "Google", "Chrome", "Application", "chrome.exe"))
+		}
+	case "darwin":
+		candidates = []string{
+			"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+			"/Applications/Microsoft Edge.app/Contents/MacOS/Microsoft Edge",
+			"/Applications/Chromium.app/Contents/MacOS/Chromium",
+		}
+	default:
+		for _, name := range []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable", "microsoft-edge"} {
+			if path, err := exec.LookPath(name); err == nil {
+				candidates = append(candidates, path)
+			}
+		}
+	}
+
+	var browsers []string
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			browsers = append(browsers, candidate)
+		}
+	}
+	return browsers
+}
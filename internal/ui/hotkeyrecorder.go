@@ -0,0 +1,176 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrRecordingCanceled is returned by RecordHotkey when the recorder window
+// was closed without picking a hotkey.
+var ErrRecordingCanceled = errors.New("hotkey recording canceled")
+
+// recordingTimeout ends a recording whose window was closed without telling,
+// e.g. because the browser crashed.
+const recordingTimeout = 5 * time.Minute
+
+// HotkeyCheck is the verdict on a pressed hotkey shown in the recorder.
+type HotkeyCheck struct {
+	Error  string   `json:"error,omitempty"`   // Why the hotkey can't be registered on this platform
+	UsedBy []string `json:"used_by,omitempty"` // What already uses it, e.g. "profile 'Privacy'"
+}
+
+// hotkeyRecorderState holds the hooks set by the application and the
+// recording in progress. At most one recorder window is open at a time.
+type hotkeyRecorderState struct {
+	mu      sync.Mutex
+	check   func(hotkey, profile string) HotkeyCheck
+	suspend func() (resume func())
+	session uint64      // Counts recordings; a page of an earlier one can't finish the current one
+	result  chan string // Receives the picked hotkey, "" if canceled; nil while no recording runs
+	prompt  string
+	profile string
+	current string
+}
+
+var (
+	hotkeyRecorder         = &hotkeyRecorderState{}
+	hotkeyRecorderHandlers sync.Once
+)
+
+// SetHotkeyRecorder sets how pressed hotkeys are checked and how the
+// application's own hotkeys are suspended while the recorder is open, so
+// pressing one of them reaches the window instead of triggering it.
+func SetHotkeyRecorder(check func(hotkey, profile string) HotkeyCheck, suspendHotkeys func() (resume func())) {
+	hotkeyRecorder.mu.Lock()
+	defer hotkeyRecorder.mu.Unlock()
+	hotkeyRecorder.check = check
+	hotkeyRecorder.suspend = suspendHotkeys
+}
+
+// RecordHotkey opens a window asking to press a hotkey and waits until one is
+// picked or the window is closed. profile names the profile the hotkey is
+// for, whose own hotkeys are not reported as conflicts; current is the
+// hotkey it replaces, if any.
+func RecordHotkey(prompt, profile, current string) (string, error) {
+	s := hotkeyRecorder
+	s.mu.Lock()
+	if s.result != nil {
+		s.mu.Unlock()
+		return "", errors.New("the hotkey recorder is already open")
+	}
+	s.session++
+	session := s.session
+	result := make(chan string, 1)
+	s.result = result
+	s.prompt, s.profile, s.current = prompt, profile, current
+	suspend := s.suspend
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.result = nil
+		s.mu.Unlock()
+	}()
+
+	hotkeyRecorderHandlers.Do(registerHotkeyRecorderHandlers)
+	url, err := globalWebServer.pageURL("/hotkey-recorder")
+	if err != nil {
+		return "", err
+	}
+	if suspend != nil {
+		resume := suspend()
+		defer resume()
+	}
+	if err := openAppWindow(fmt.Sprintf("%s&session=%d", url, session)); err != nil {
+		return "", err
+	}
+
+	select {
+	case hotkey := <-result:
+		if hotkey == "" {
+			return "", ErrRecordingCanceled
+		}
+		log.Printf("Hotkey recorder: picked '%s'.", hotkey)
+		return hotkey, nil
+	case <-time.After(recordingTimeout):
+		log.Println("Hotkey recorder: no hotkey picked in time.")
+		return "", ErrRecordingCanceled
+	}
+}
+
+// currentRecording returns the recorder state if session is the recording in
+// progress. s.mu must not be held.
+func (s *hotkeyRecorderState) currentRecording(session string) (prompt, profile, current string, result chan string, ok bool) {
+	id, err := strconv.ParseUint(session, 10, 64)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil || id != s.session || s.result == nil {
+		return "", "", "", nil, false
+	}
+	return s.prompt, s.profile, s.current, s.result, true
+}
+
+func registerHotkeyRecorderHandlers() {
+	globalWebServer.handle("/hotkey-recorder", func(rw http.ResponseWriter, r *http.Request) {
+		serveAsset(rw, "hotkeyrecorder.html")
+	})
+
+	globalWebServer.handle("/hotkey-recorder/api/session", func(rw http.ResponseWriter, r *http.Request) {
+		prompt, _, current, _, ok := hotkeyRecorder.currentRecording(r.URL.Query().Get("session"))
+		writeJSON(rw, http.StatusOK, map[string]interface{}{"active": ok, "prompt": prompt, "current": current})
+	})
+
+	globalWebServer.handle("/hotkey-recorder/api/check", func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var payload struct {
+			Session string `json:"session"`
+			Hotkey  string `json:"hotkey"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			writeJSON(rw, http.StatusBadRequest, HotkeyCheck{Error: "invalid request: " + err.Error()})
+			return
+		}
+		_, profile, _, _, _ := hotkeyRecorder.currentRecording(payload.Session)
+		hotkeyRecorder.mu.Lock()
+		check := hotkeyRecorder.check
+		hotkeyRecorder.mu.Unlock()
+		if check == nil {
+			writeJSON(rw, http.StatusOK, HotkeyCheck{})
+			return
+		}
+		writeJSON(rw, http.StatusOK, check(payload.Hotkey, profile))
+	})
+
+	// Also called through navigator.sendBeacon when the window is closed,
+	// which sends the token in the URL and the payload as plain text
+	globalWebServer.handle("/hotkey-recorder/api/done", func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var payload struct {
+			Session string `json:"session"`
+			Hotkey  string `json:"hotkey"` // Empty if canceled
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			writeJSON(rw, http.StatusBadRequest, map[string]interface{}{"error": "invalid request: " + err.Error()})
+			return
+		}
+		_, _, _, result, ok := hotkeyRecorder.currentRecording(payload.Session)
+		if ok {
+			select {
+			case result <- payload.Hotkey:
+			default: // Already finished, e.g. picked and then closed
+			}
+		}
+		writeJSON(rw, http.StatusOK, map[string]interface{}{"done": ok})
+	})
+}
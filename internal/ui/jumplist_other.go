@@ -0,0 +1,8 @@
+//go:build !windows
+
+package ui
+
+import "github.com/TanaroSch/clipboard-regex-replace/internal/config"
+
+// UpdateJumpList does nothing; jump lists only exist on Windows.
+func UpdateJumpList(cfg *config.Config) {}
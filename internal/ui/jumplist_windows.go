@@ -0,0 +1,297 @@
+//go:build windows
+
+package ui
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+)
+
+// maxJumpListProfiles limits the "Run" tasks; Windows shows about ten
+// entries per jump list by default.
+const maxJumpListProfiles = 8
+
+var (
+	ole32                = syscall.NewLazyDLL("ole32.dll")
+	procCoInitializeEx   = ole32.NewProc("CoInitializeEx")
+	procCoUninitialize   = ole32.NewProc("CoUninitialize")
+	procCoCreateInstance = ole32.NewProc("CoCreateInstance")
+)
+
+const (
+	coinitApartmentThreaded = 0x2
+	clsctxInprocServer      = 0x1
+	rpcEChangedMode         = 0x80010106 // COM was initialized differently on this thread
+	vtLPWStr                = 31
+)
+
+type guid struct {
+	Data1 uint32
+	Data2 uint16
+	Data3 uint16
+	Data4 [8]byte
+}
+
+var (
+	clsidDestinationList            = guid{0x77f10cf0, 0x3db5, 0x4966, [8]byte{0xb5, 0x20, 0xb7, 0xc5, 0x4f, 0xd3, 0x5e, 0xd6}}
+	iidICustomDestinationList       = guid{0x6332debf, 0x87b5, 0x4670, [8]byte{0x90, 0xc0, 0x5e, 0x57, 0xb4, 0x08, 0xa4, 0x9e}}
+	clsidEnumerableObjectCollection = guid{0x2d3468c1, 0x36a7, 0x43b6, [8]byte{0xac, 0x24, 0xd3, 0xf0, 0x2f, 0xd9, 0x60, 0x7a}}
+	iidIObjectCollection            = guid{0x5632b1a4, 0xe38a, 0x400a, [8]byte{0x92, 0x8a, 0xd4, 0xcd, 0x63, 0x23, 0x02, 0x95}}
+	iidIObjectArray                 = guid{0x92ca9dcd, 0x5622, 0x4bba, [8]byte{0xa8, 0x05, 0x5e, 0x9f, 0x54, 0x1b, 0xd8, 0xc9}}
+	clsidShellLink                  = guid{0x00021401, 0x0000, 0x0000, [8]byte{0xc0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x46}}
+	iidIShellLinkW                  = guid{0x000214f9, 0x0000, 0x0000, [8]byte{0xc0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x46}}
+	iidIPropertyStore               = guid{0x886d8eeb, 0x8cf2, 0x4446, [8]byte{0x8d, 0x02, 0xcd, 0xba, 0x1d, 0xbd, 0xcf, 0x99}}
+	pkeyTitle                       = propertyKey{guid{0xf29f85e0, 0x4ff9, 0x1068, [8]byte{0xab, 0x91, 0x08, 0x00, 0x2b, 0x27, 0xb3, 0xd9}}, 2}
+)
+
+// Method indexes in the COM vtables, after IUnknown's QueryInterface (0),
+// AddRef (1) and Release (2).
+const (
+	methodQueryInterface = 0
+	methodRelease        = 2
+
+	customDestinationListBeginList    = 4
+	customDestinationListAddUserTasks = 7
+	customDestinationListCommitList   = 8
+
+	objectCollectionAddObject = 5
+
+	shellLinkSetDescription  = 7
+	shellLinkSetArguments    = 11
+	shellLinkSetIconLocation = 17
+	shellLinkSetPath         = 20
+
+	propertyStoreSetValue = 6
+	propertyStoreCommit   = 7
+)
+
+type propertyKey struct {
+	fmtid guid
+	pid   uint32
+}
+
+// propVariant is a PROPVARIANT holding a string.
+type propVariant struct {
+	vt       uint16
+	reserved [3]uint16
+	val      *uint16
+	pad      uintptr
+}
+
+// comObject is a COM interface pointer.
+type comObject struct {
+	ptr unsafe.Pointer
+}
+
+// call invokes the method at index of the interface's vtable.
+func (o comObject) call(index int, args ...uintptr) error {
+	vtbl := *(*unsafe.Pointer)(o.ptr)
+	method := *(*uintptr)(unsafe.Add(vtbl, uintptr(index)*unsafe.Sizeof(uintptr(0))))
+	hr, _, _ := syscall.SyscallN(method, append([]uintptr{uintptr(o.ptr)}, args...)...)
+	if int32(hr) < 0 {
+		return fmt.Errorf("COM call failed with HRESULT 0x%08X", uint32(hr))
+	}
+	return nil
+}
+
+func (o comObject) release() {
+	if o.ptr != nil {
+		o.call(methodRelease)
+	}
+}
+
+func (o comObject) queryInterface(iid *guid) (comObject, error) {
+	var out comObject
+	err := o.call(methodQueryInterface, uintptr(unsafe.Pointer(iid)), uintptr(unsafe.Pointer(&out.ptr)))
+	return out, err
+}
+
+func createInstance(clsid, iid *guid) (comObject, error) {
+	var out comObject
+	hr, _, _ := procCoCreateInstance.Call(uintptr(unsafe.Pointer(clsid)), 0, clsctxInprocServer,
+		uintptr(unsafe.Pointer(iid)), uintptr(unsafe.Pointer(&out.ptr)))
+	if int32(hr) < 0 {
+		return comObject{}, fmt.Errorf("CoCreateInstance failed with HRESULT 0x%08X", uint32(hr))
+	}
+	return out, nil
+}
+
+// jumpListTask is a task in the taskbar button's jump list.
+type jumpListTask struct {
+	title       string
+	description string
+	path        string // Program or file to open
+	arguments   string
+	icon        string // File whose first icon is shown
+}
+
+// UpdateJumpList replaces the tasks of the taskbar button's and Start Menu
+// entry's jump list: a "Trigger" task for the first profiles, "Pause Hotkeys",
+// "Resume Hotkeys" and "Open Config File". The tasks run "clipregex trigger",
+// "pause" and "resume", which reach the running instance through its command
+// socket. Windows shows them when the executable is pinned or was started
+// recently.
+func UpdateJumpList(cfg *config.Config) {
+	if cfg == nil || IsDevMode() {
+		return
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		log.Printf("Jump list not updated: %v", err)
+		return
+	}
+	configArgs := " --config " + syscall.EscapeArg(cfg.GetConfigPath())
+
+	var tasks []jumpListTask
+	for _, profile := range cfg.Profiles {
+		if len(tasks) == maxJumpListProfiles {
+			break
+		}
+		tasks = append(tasks, jumpListTask{
+			title:       i18n.Tf("Run '%s'", profile.Name),
+			description: i18n.Tf("Apply the profile '%s' to the clipboard", profile.Name),
+			path:        exe,
+			arguments:   "trigger " + syscall.EscapeArg(profile.Name) + configArgs,
+			icon:        exe,
+		})
+	}
+	tasks = append(tasks,
+		jumpListTask{title: i18n.T("Pause Hotkeys"), description: i18n.T("Ignore hotkeys until resumed"), path: exe, arguments: "pause" + configArgs, icon: exe},
+		jumpListTask{title: i18n.T("Resume Hotkeys"), description: i18n.T("Handle hotkeys again"), path: exe, arguments: "resume" + configArgs, icon: exe},
+		jumpListTask{title: i18n.T("Open Config File"), description: i18n.T("Open config.json in default editor"), path: cfg.GetConfigPath(), icon: exe},
+	)
+
+	// COM objects belong to the thread that created them
+	go func() {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		if err := setJumpListTasks(tasks); err != nil {
+			log.Printf("Failed to update the jump list: %v", err)
+			return
+		}
+		titles := make([]string, len(tasks))
+		for i, task := range tasks {
+			titles[i] = task.title
+		}
+		log.Printf("Jump list tasks: %s", strings.Join(titles, ", "))
+	}()
+}
+
+// setJumpListTasks commits tasks as the user tasks of the process's jump
+// list. It must run on a locked OS thread.
+func setJumpListTasks(tasks []jumpListTask) error {
+	hr, _, _ := procCoInitializeEx.Call(0, coinitApartmentThreaded)
+	if int32(hr) < 0 && uint32(hr) != rpcEChangedMode {
+		return fmt.Errorf("CoInitializeEx failed with HRESULT 0x%08X", uint32(hr))
+	}
+	if int32(hr) >= 0 {
+		defer procCoUninitialize.Call()
+	}
+
+	list, err := createInstance(&clsidDestinationList, &iidICustomDestinationList)
+	if err != nil {
+		return err
+	}
+	defer list.release()
+
+	var maxSlots uint32
+	var removed comObject
+	if err := list.call(customDestinationListBeginList, uintptr(unsafe.Pointer(&maxSlots)),
+		uintptr(unsafe.Pointer(&iidIObjectArray)), uintptr(unsafe.Pointer(&removed.ptr))); err != nil {
+		return fmt.Errorf("BeginList: %w", err)
+	}
+	removed.release()
+
+	collection, err := createInstance(&clsidEnumerableObjectCollection, &iidIObjectCollection)
+	if err != nil {
+		return err
+	}
+	defer collection.release()
+
+	for _, task := range tasks {
+		link, err := newTaskLink(task)
+		if err != nil {
+			return fmt.Errorf("task '%s': %w", task.title, err)
+		}
+		err = collection.call(objectCollectionAddObject, uintptr(link.ptr))
+		link.release() // The collection holds its own reference
+		if err != nil {
+			return fmt.Errorf("task '%s': %w", task.title, err)
+		}
+	}
+
+	tasksArray, err := collection.queryInterface(&iidIObjectArray)
+	if err != nil {
+		return err
+	}
+	defer tasksArray.release()
+	if err := list.call(customDestinationListAddUserTasks, uintptr(tasksArray.ptr)); err != nil {
+		return fmt.Errorf("AddUserTasks: %w", err)
+	}
+	if err := list.call(customDestinationListCommitList); err != nil {
+		return fmt.Errorf("CommitList: %w", err)
+	}
+	return nil
+}
+
+// newTaskLink creates the shell link for a task. Its title is the link's
+// System.Title property.
+func newTaskLink(task jumpListTask) (comObject, error) {
+	link, err := createInstance(&clsidShellLink, &iidIShellLinkW)
+	if err != nil {
+		return comObject{}, err
+	}
+	setString := func(method int, value string) error {
+		ptr, err := syscall.UTF16PtrFromString(value)
+		if err != nil {
+			return err
+		}
+		return link.call(method, uintptr(unsafe.Pointer(ptr)))
+	}
+	if err := setString(shellLinkSetPath, task.path); err != nil {
+		link.release()
+		return comObject{}, err
+	}
+	if err := setString(shellLinkSetArguments, task.arguments); err != nil {
+		link.release()
+		return comObject{}, err
+	}
+	if err := setString(shellLinkSetDescription, task.description); err != nil {
+		link.release()
+		return comObject{}, err
+	}
+	if icon, err := syscall.UTF16PtrFromString(task.icon); err == nil {
+		link.call(shellLinkSetIconLocation, uintptr(unsafe.Pointer(icon)), 0)
+	}
+
+	store, err := link.queryInterface(&iidIPropertyStore)
+	if err != nil {
+		link.release()
+		return comObject{}, err
+	}
+	defer store.release()
+	title, err := syscall.UTF16PtrFromString(task.title)
+	if err != nil {
+		link.release()
+		return comObject{}, err
+	}
+	value := propVariant{vt: vtLPWStr, val: title}
+	if err := store.call(propertyStoreSetValue, uintptr(unsafe.Pointer(&pkeyTitle)), uintptr(unsafe.Pointer(&value))); err != nil {
+		link.release()
+		return comObject{}, err
+	}
+	if err := store.call(propertyStoreCommit); err != nil {
+		link.release()
+		return comObject{}, err
+	}
+	runtime.KeepAlive(title)
+	return link, nil
+}
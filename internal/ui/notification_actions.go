@@ -0,0 +1,154 @@
+package ui
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+)
+
+// Notification actions are buttons on a notification. A Windows toast button
+// can only launch a URI, so every action gets a one-off URL on the local web
+// UI server: clicking the button opens it in the default browser, which runs
+// the action and shows its result.
+
+// ReplacementActions are the optional buttons of the post-replacement
+// notification. They are only shown where the platform supports buttons
+// (Windows); elsewhere the plain notification is shown.
+type ReplacementActions struct {
+	Undo         func() string // Reverts the replacement and returns a result message; nil hides "Undo"
+	ShowDiff     bool          // Show "View changes" for Original -> Modified
+	Original     string
+	Modified     string
+	ContextLines int
+	Granularity  string // Initial diff granularity ("line", "word" or "char")
+}
+
+// notificationLink is a button label and the URL it opens.
+type notificationLink struct {
+	Label string
+	URL   string
+}
+
+// maxNotificationActions limits how many actions are kept for old notifications.
+const maxNotificationActions = 20
+
+type notificationAction struct {
+	run  func(rw http.ResponseWriter)
+	once bool // Remove after the first use (e.g. Undo)
+}
+
+var (
+	notificationActionsMu      sync.Mutex
+	notificationActions        = make(map[string]notificationAction)
+	notificationActionOrder    []string
+	notificationActionHandlers sync.Once
+)
+
+func registerNotificationActionHandlers() {
+	globalWebServer.handle("/notification-action", func(rw http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("id")
+
+		notificationActionsMu.Lock()
+		action, ok := notificationActions[id]
+		if ok && action.once {
+			delete(notificationActions, id)
+		}
+		notificationActionsMu.Unlock()
+
+		if !ok {
+			writeActionResult(rw, "Action Expired", "This notification action has already been used or has expired.")
+			return
+		}
+		action.run(rw)
+	})
+}
+
+// registerNotificationAction stores an action and returns the URL that runs it.
+func registerNotificationAction(run func(rw http.ResponseWriter), once bool) (string, error) {
+	notificationActionHandlers.Do(registerNotificationActionHandlers)
+
+	idBytes := make([]byte, 8)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", fmt.Errorf("failed to generate notification action id: %w", err)
+	}
+	id := hex.EncodeToString(idBytes)
+
+	pageURL, err := globalWebServer.pageURL("/notification-action")
+	if err != nil {
+		return "", err
+	}
+
+	notificationActionsMu.Lock()
+	defer notificationActionsMu.Unlock()
+	notificationActions[id] = notificationAction{run: run, once: once}
+	notificationActionOrder = append(notificationActionOrder, id)
+	for len(notificationActionOrder) > maxNotificationActions {
+		delete(notificationActions, notificationActionOrder[0])
+		notificationActionOrder = notificationActionOrder[1:]
+	}
+
+	return pageURL + "&id=" + id, nil
+}
+
+// replacementLinks registers the actions of a replacement notification and
+// returns the buttons to show.
+func replacementLinks(actions ReplacementActions) []notificationLink {
+	var links []notificationLink
+
+	if actions.Undo != nil {
+		undo := actions.Undo
+		url, err := registerNotificationAction(func(rw http.ResponseWriter) {
+			writeActionResult(rw, "Undo", undo())
+		}, true)
+		if err != nil {
+			log.Printf("Could not add Undo action to notification: %v", err)
+		} else {
+			links = append(links, notificationLink{Label: i18n.T("Undo"), URL: url})
+		}
+	}
+
+	if actions.ShowDiff {
+		state := newDiffWindowState(actions.Original, actions.Modified, actions.ContextLines, actions.Granularity)
+		url, err := registerNotificationAction(func(rw http.ResponseWriter) {
+			// Show this notification's change in the diff viewer
+			setDiffWindow(state)
+			pageURL, err := globalWebServer.pageURL("/diff")
+			if err != nil {
+				writeActionResult(rw, "View changes", i18n.Tf("Could not open the diff viewer: %v", err))
+				return
+			}
+			rw.Header().Set("Location", pageURL)
+			rw.WriteHeader(http.StatusSeeOther)
+		}, false)
+		if err != nil {
+			log.Printf("Could not add View changes action to notification: %v", err)
+		} else {
+			links = append(links, notificationLink{Label: i18n.T("View changes"), URL: url})
+		}
+	}
+
+	return links
+}
+
+// writeActionResult shows the outcome of a notification action in the browser tab.
+// Title and message are translated.
+func writeActionResult(rw http.ResponseWriter, title, message string) {
+	title, message = i18n.T(title), i18n.T(message)
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	rw.Header().Set("Cache-Control", "no-store")
+	fmt.Fprintf(rw, `<!DOCTYPE html>
+<html lang="%s">
+<head><meta charset="UTF-8"><title>%s - %s</title>
+<style>body{font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",Roboto,Arial,sans-serif;margin:40px;color:#212529}h1{color:#0d6efd;font-size:1.4em}</style>
+</head>
+<body><h1>%s</h1><p>%s</p><p style="color:#6c757d">%s</p></body>
+</html>
+`, html.EscapeString(i18n.Language()), html.EscapeString(title), html.EscapeString(config.DefaultKeyringService), html.EscapeString(title), html.EscapeString(message), html.EscapeString(i18n.T("You can close this tab.")))
+}
@@ -0,0 +1,94 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+)
+
+// replacementThrottle aggregates replacement notifications. The first
+// replacement of a throttle window is shown right away; the ones after it are
+// only counted and summarised in a single notification when the window ends,
+// so auto-apply or rapid hotkey presses don't flood the screen with toasts.
+type replacementThrottle struct {
+	mu              sync.Mutex
+	windowStart     time.Time
+	window          time.Duration
+	transformations int // Replacements in the current window, including the one shown
+	replacements    int // Regex matches replaced in the current window
+	suppressed      int // Notifications held back in the current window
+	timer           *time.Timer
+	showSummary     func(title, message string)
+}
+
+// admit records a replacement notification and reports whether it should be
+// shown now. window is the configured throttle window (0 disables throttling).
+func (t *replacementThrottle) admit(window time.Duration, replacements int) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if window <= 0 {
+		return true
+	}
+
+	now := time.Now()
+	if t.timer == nil && (t.windowStart.IsZero() || now.Sub(t.windowStart) >= t.window) {
+		// Nothing is held back, so this one starts a new window
+		t.windowStart = now
+		t.window = window
+		t.transformations = 1
+		t.replacements = replacements
+		t.suppressed = 0
+		return true
+	}
+
+	t.transformations++
+	t.replacements += replacements
+	t.suppressed++
+	if t.timer == nil {
+		t.timer = time.AfterFunc(t.windowStart.Add(t.window).Sub(now), t.flush)
+	}
+	log.Printf("Replacement Notification throttled (%d held back in the current %v window)", t.suppressed, t.window)
+	return false
+}
+
+// flush shows the summary of the window that just ended. The summary opens
+// a new window, so a steady stream of replacements produces one
+// notification per window.
+func (t *replacementThrottle) flush() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("RECOVERED FROM PANIC IN NOTIFICATION THROTTLE: %v", r)
+		}
+	}()
+
+	t.mu.Lock()
+	t.timer = nil
+	if t.suppressed == 0 {
+		t.mu.Unlock()
+		return
+	}
+	message := i18n.Tf("%d transformation(s) in the last %s, %d replacement(s).",
+		t.transformations, formatThrottleWindow(t.window), t.replacements)
+	t.windowStart = time.Now()
+	t.transformations = 0
+	t.replacements = 0
+	t.suppressed = 0
+	t.mu.Unlock()
+
+	log.Printf("Showing aggregated Replacement Notification: %s", message)
+	if t.showSummary != nil {
+		t.showSummary("Clipboard Updated", message)
+	}
+}
+
+// formatThrottleWindow formats a window as "30s" or "2m".
+func formatThrottleWindow(window time.Duration) string {
+	if window >= time.Minute && window%time.Minute == 0 {
+		return fmt.Sprintf("%dm", int(window/time.Minute))
+	}
+	return fmt.Sprintf("%ds", int(window/time.Second))
+}
@@ -3,8 +3,10 @@ package ui
 import (
 	"log"
 	"strings"
+	"time"
 
 	"github.com/TanaroSch/clipboard-regex-replace/internal/config" // Need config access
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
 )
 
 // NotificationLevel defines the verbosity levels for administrative notifications.
@@ -22,15 +24,31 @@ type NotificationManager struct {
 	config       *config.Config // Store config reference
 	appName      string
 	embeddedIcon []byte
+	throttle     *replacementThrottle
 }
 
 // NewNotificationManager creates a new notification manager
 func NewNotificationManager(cfg *config.Config, appName string, embeddedIcon []byte) *NotificationManager {
-	return &NotificationManager{
+	n := &NotificationManager{
 		config:       cfg, // Store the config
 		appName:      appName,
 		embeddedIcon: embeddedIcon,
 	}
+	n.throttle = &replacementThrottle{showSummary: n.showPlatformNotification}
+	return n
+}
+
+// UpdateConfig updates the config reference used by the notification manager
+func (n *NotificationManager) UpdateConfig(newCfg *config.Config) {
+	n.config = newCfg
+}
+
+// throttleWindow returns the configured replacement notification throttle window.
+func (n *NotificationManager) throttleWindow() time.Duration {
+	if n.config == nil {
+		return 0
+	}
+	return time.Duration(n.config.GetNotificationThrottle()) * time.Second
 }
 
 // getConfiguredAdminLevel parses the level string from config and returns the enum value.
@@ -62,7 +80,9 @@ func (n *NotificationManager) showPlatformNotification(title, message string) {
 }
 
 // ShowAdminNotification displays an administrative notification if the configured level allows it.
+// Title and message are translated; formatted messages are translated by the caller with i18n.Tf.
 func (n *NotificationManager) ShowAdminNotification(requiredLevel NotificationLevel, title, message string) {
+	title, message = i18n.T(title), i18n.T(message)
 	configuredLevel := n.getConfiguredAdminLevel()
 
 	if configuredLevel >= requiredLevel && requiredLevel != LevelNone {
@@ -73,16 +93,56 @@ func (n *NotificationManager) ShowAdminNotification(requiredLevel NotificationLe
 	}
 }
 
+// ShowAdminNotificationWithLink is ShowAdminNotification with a button
+// labeled label that opens url in the browser, where the platform supports
+// buttons (Windows). The URL is opened directly rather than through the local
+// web UI, so the button still works after the application exited.
+func (n *NotificationManager) ShowAdminNotificationWithLink(requiredLevel NotificationLevel, title, message, label, url string) {
+	title, message = i18n.T(title), i18n.T(message)
+	configuredLevel := n.getConfiguredAdminLevel()
+	if configuredLevel < requiredLevel || requiredLevel == LevelNone {
+		log.Printf("Admin Notification suppressed by level (Level: %v < Required: %v): %s - %s", configuredLevel, requiredLevel, title, message)
+		return
+	}
+	log.Printf("Showing Admin Notification with link (Level: %v >= Required: %v): %s - %s", configuredLevel, requiredLevel, title, message)
+	if err := n.platformNotifyWithLinks(title, message, []notificationLink{{Label: i18n.T(label), URL: url}}); err != nil {
+		log.Printf("Error showing notification: %v", err)
+	}
+}
+
 // ShowReplacementNotification displays a notification after a clipboard replacement, if enabled.
 func (n *NotificationManager) ShowReplacementNotification(title, message string) {
+	title = i18n.T(title)
 	if n.config == nil || !n.config.NotifyOnReplacement {
 		log.Printf("Replacement Notification suppressed by config: %s - %s", title, message)
 		return
 	}
+	if !n.throttle.admit(n.throttleWindow(), 0) {
+		return
+	}
 	log.Printf("Showing Replacement Notification: %s - %s", title, message)
 	n.showPlatformNotification(title, message)
 }
 
+// ShowReplacementNotificationWithActions is ShowReplacementNotification with
+// "Undo" / "View changes" buttons where the platform supports them.
+// replacements is the number of regex matches replaced, used when the
+// notification is aggregated by the throttle.
+func (n *NotificationManager) ShowReplacementNotificationWithActions(title, message string, replacements int, actions ReplacementActions) {
+	title = i18n.T(title)
+	if n.config == nil || !n.config.NotifyOnReplacement {
+		log.Printf("Replacement Notification suppressed by config: %s - %s", title, message)
+		return
+	}
+	if !n.throttle.admit(n.throttleWindow(), replacements) {
+		return
+	}
+	log.Printf("Showing Replacement Notification with actions: %s - %s", title, message)
+	if err := n.platformNotifyWithActions(title, message, actions); err != nil {
+		log.Printf("Error showing notification: %v", err)
+	}
+}
+
 // --- Global Access ---
 
 var globalNotificationManager *NotificationManager
@@ -94,6 +154,13 @@ func InitGlobalNotifications(cfg *config.Config, appName string, embeddedIcon []
 		cfg.AdminNotificationLevel, cfg.NotifyOnReplacement)
 }
 
+// UpdateNotificationConfig updates the config used by the global notification manager
+func UpdateNotificationConfig(cfg *config.Config) {
+	if globalNotificationManager != nil {
+		globalNotificationManager.UpdateConfig(cfg)
+	}
+}
+
 // ShowAdminNotification is a convenience function for showing administrative notifications
 func ShowAdminNotification(requiredLevel NotificationLevel, title, message string) {
 	if globalNotificationManager != nil {
@@ -103,6 +170,16 @@ func ShowAdminNotification(requiredLevel NotificationLevel, title, message strin
 	}
 }
 
+// ShowAdminNotificationWithLink is a convenience function for showing
+// administrative notifications with a link button
+func ShowAdminNotificationWithLink(requiredLevel NotificationLevel, title, message, label, url string) {
+	if globalNotificationManager != nil {
+		globalNotificationManager.ShowAdminNotificationWithLink(requiredLevel, title, message, label, url)
+	} else {
+		log.Printf("Admin Notification not shown (manager not initialized): %s - %s", title, message)
+	}
+}
+
 // ShowReplacementNotification is a convenience function for showing replacement notifications
 func ShowReplacementNotification(title, message string) {
 	if globalNotificationManager != nil {
@@ -111,3 +188,25 @@ func ShowReplacementNotification(title, message string) {
 		log.Printf("Replacement Notification not shown (manager not initialized): %s - %s", title, message)
 	}
 }
+
+// ShowReplacementNotificationWithActions is a convenience function for showing
+// replacement notifications with action buttons
+func ShowReplacementNotificationWithActions(title, message string, replacements int, actions ReplacementActions) {
+	if globalNotificationManager != nil {
+		globalNotificationManager.ShowReplacementNotificationWithActions(title, message, replacements, actions)
+	} else {
+		log.Printf("Replacement Notification not shown (manager not initialized): %s - %s", title, message)
+	}
+}
+
+// SendTestNotification shows a notification regardless of the configured
+// levels and returns the platform's error, to check that notifications are
+// delivered. Without the global manager, e.g. in a command line command, it
+// uses one without an embedded icon.
+func SendTestNotification(title, message string) error {
+	n := globalNotificationManager
+	if n == nil {
+		n = NewNotificationManager(nil, config.DefaultKeyringService, nil)
+	}
+	return n.platformNotify(i18n.T(title), i18n.T(message))
+}
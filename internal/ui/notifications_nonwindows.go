@@ -8,3 +8,13 @@ func (n *NotificationManager) platformNotify(title, message string) error {
 	// Icon path left empty on non-Windows.
 	return beeep.Notify(title, message, "")
 }
+
+// platformNotifyWithActions shows a plain notification; beeep has no buttons.
+func (n *NotificationManager) platformNotifyWithActions(title, message string, actions ReplacementActions) error {
+	return n.platformNotify(title, message)
+}
+
+// platformNotifyWithLinks shows a plain notification; beeep has no buttons.
+func (n *NotificationManager) platformNotifyWithLinks(title, message string, links []notificationLink) error {
+	return n.platformNotify(title, message)
+}
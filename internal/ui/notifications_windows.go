@@ -10,10 +10,34 @@ import (
 	"strings"
 	"time"
 
+	"github.com/TanaroSch/clipboard-regex-replace/internal/install"
 	"github.com/go-toast/toast"
 )
 
 func (n *NotificationManager) platformNotify(title, message string) error {
+	return n.pushToast(title, message, nil)
+}
+
+// platformNotifyWithActions shows a toast with "Undo" / "View changes"
+// buttons. The buttons open local web UI URLs that run the action.
+func (n *NotificationManager) platformNotifyWithActions(title, message string, actions ReplacementActions) error {
+	return n.platformNotifyWithLinks(title, message, replacementLinks(actions))
+}
+
+// platformNotifyWithLinks shows a toast with a button for each link.
+func (n *NotificationManager) platformNotifyWithLinks(title, message string, links []notificationLink) error {
+	var toastActions []toast.Action
+	for _, link := range links {
+		toastActions = append(toastActions, toast.Action{
+			Type:      "protocol",
+			Label:     link.Label,
+			Arguments: link.URL,
+		})
+	}
+	return n.pushToast(title, message, toastActions)
+}
+
+func (n *NotificationManager) pushToast(title, message string, actions []toast.Action) error {
 	var iconPathForToast string
 
 	// Try to use external icon.png for better quality.
@@ -48,11 +72,18 @@ func (n *NotificationManager) platformNotify(title, message string) error {
 		}
 	}
 
+	// After "clipregex install", the registered ID shows the name and icon
+	appID := n.appName
+	if install.AppRegistered() {
+		appID = install.AppUserModelID
+	}
+
 	notification := toast.Notification{
-		AppID:   n.appName,
+		AppID:   appID,
 		Title:   title,
 		Message: message,
 		Icon:    iconPathForToast,
+		Actions: actions,
 	}
 
 	err := notification.Push()
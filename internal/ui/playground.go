@@ -0,0 +1,159 @@
+// internal/ui/playground.go
+package ui
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+)
+
+// PlaygroundCallbacks connects the regex playground window to the application.
+type PlaygroundCallbacks struct {
+	// ReadClipboard returns the current clipboard text used as sample input.
+	ReadClipboard func() (string, error)
+	// Preview applies a rule to text and returns the result plus match byte ranges.
+	Preview func(text string, rep config.Replacement) (string, [][]int, error)
+	// ProfileNames lists the profiles a tested rule can be added to.
+	ProfileNames func() []string
+	// AddRule appends the rule to the named profile and saves the configuration.
+	AddRule func(profileName string, rep config.Replacement) error
+}
+
+// playgroundSegment is a piece of the sample text, flagged when it is part of a match.
+type playgroundSegment struct {
+	Text  string `json:"text"`
+	Match bool   `json:"match"`
+}
+
+var (
+	playgroundMu        sync.Mutex
+	playgroundCallbacks PlaygroundCallbacks
+	playgroundHandlers  sync.Once
+)
+
+// ShowRulePlayground opens the "Test Rules" window in the default browser.
+func ShowRulePlayground(callbacks PlaygroundCallbacks) error {
+	playgroundMu.Lock()
+	playgroundCallbacks = callbacks
+	playgroundMu.Unlock()
+
+	playgroundHandlers.Do(registerPlaygroundHandlers)
+
+	return openWebPage("/playground")
+}
+
+// splitMatches cuts text into alternating plain and matched segments.
+func splitMatches(text string, matches [][]int) []playgroundSegment {
+	segments := []playgroundSegment{}
+	last := 0
+	for _, m := range matches {
+		if len(m) != 2 || m[0] < last || m[1] > len(text) {
+			continue
+		}
+		if m[0] > last {
+			segments = append(segments, playgroundSegment{Text: text[last:m[0]]})
+		}
+		if m[1] > m[0] {
+			segments = append(segments, playgroundSegment{Text: text[m[0]:m[1]], Match: true})
+		}
+		last = m[1]
+	}
+	if last < len(text) {
+		segments = append(segments, playgroundSegment{Text: text[last:]})
+	}
+	return segments
+}
+
+func registerPlaygroundHandlers() {
+	getCallbacks := func() PlaygroundCallbacks {
+		playgroundMu.Lock()
+		defer playgroundMu.Unlock()
+		return playgroundCallbacks
+	}
+
+	globalWebServer.handle("/playground", func(rw http.ResponseWriter, r *http.Request) {
+		serveAsset(rw, "playground.html")
+	})
+
+	globalWebServer.handle("/playground/api/clipboard", func(rw http.ResponseWriter, r *http.Request) {
+		cb := getCallbacks()
+		text := ""
+		if cb.ReadClipboard != nil {
+			var err error
+			text, err = cb.ReadClipboard()
+			if err != nil {
+				log.Printf("Playground: failed to read clipboard: %v", err)
+				writeJSON(rw, http.StatusOK, map[string]interface{}{"text": "", "error": err.Error()})
+				return
+			}
+		}
+		profiles := []string{}
+		if cb.ProfileNames != nil {
+			profiles = append(profiles, cb.ProfileNames()...)
+		}
+		writeJSON(rw, http.StatusOK, map[string]interface{}{"text": text, "profiles": profiles})
+	})
+
+	globalWebServer.handle("/playground/api/preview", func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var payload struct {
+			Text string             `json:"text"`
+			Rule config.Replacement `json:"rule"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			writeJSON(rw, http.StatusBadRequest, map[string]interface{}{"error": "invalid request: " + err.Error()})
+			return
+		}
+		cb := getCallbacks()
+		if cb.Preview == nil || payload.Rule.Regex == "" {
+			writeJSON(rw, http.StatusOK, map[string]interface{}{
+				"segments": splitMatches(payload.Text, nil),
+				"result":   payload.Text,
+				"matches":  0,
+			})
+			return
+		}
+		result, matches, err := cb.Preview(payload.Text, payload.Rule)
+		response := map[string]interface{}{
+			"segments": splitMatches(payload.Text, matches),
+			"result":   result,
+			"matches":  len(matches),
+		}
+		if err != nil {
+			response["error"] = err.Error()
+		}
+		writeJSON(rw, http.StatusOK, response)
+	})
+
+	globalWebServer.handle("/playground/api/add", func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var payload struct {
+			Profile string             `json:"profile"`
+			Rule    config.Replacement `json:"rule"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			writeJSON(rw, http.StatusBadRequest, map[string]interface{}{"error": "invalid request: " + err.Error()})
+			return
+		}
+		cb := getCallbacks()
+		if cb.AddRule == nil {
+			writeJSON(rw, http.StatusServiceUnavailable, map[string]interface{}{"error": "adding rules is not available"})
+			return
+		}
+		if err := cb.AddRule(payload.Profile, payload.Rule); err != nil {
+			writeJSON(rw, http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+			return
+		}
+		log.Printf("Playground: added rule to profile '%s'.", payload.Profile)
+		writeJSON(rw, http.StatusOK, map[string]interface{}{"added": true})
+	})
+}
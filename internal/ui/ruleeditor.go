@@ -0,0 +1,144 @@
+// internal/ui/ruleeditor.go
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sync"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+)
+
+// ruleEditorState holds the callbacks used by the rule editor API handlers.
+// They are replaced every time the editor is opened so the handlers always
+// work against the application's current configuration.
+type ruleEditorState struct {
+	mu           sync.Mutex
+	getProfiles  func() []config.ProfileConfig
+	saveProfiles func([]config.ProfileConfig) error
+}
+
+var (
+	ruleEditor         = &ruleEditorState{}
+	ruleEditorHandlers sync.Once
+)
+
+// ShowRuleEditor opens the rule editor window in the default browser.
+// getProfiles returns the profiles to edit; saveProfiles persists the edited
+// profiles and is only called after they passed validation.
+func ShowRuleEditor(getProfiles func() []config.ProfileConfig, saveProfiles func([]config.ProfileConfig) error) error {
+	ruleEditor.mu.Lock()
+	ruleEditor.getProfiles = getProfiles
+	ruleEditor.saveProfiles = saveProfiles
+	ruleEditor.mu.Unlock()
+
+	ruleEditorHandlers.Do(registerRuleEditorHandlers)
+
+	return openWebPage("/rule-editor")
+}
+
+func registerRuleEditorHandlers() {
+	globalWebServer.handle("/rule-editor", func(rw http.ResponseWriter, r *http.Request) {
+		serveAsset(rw, "ruleeditor.html")
+	})
+
+	globalWebServer.handle("/rule-editor/api/profiles", func(rw http.ResponseWriter, r *http.Request) {
+		ruleEditor.mu.Lock()
+		getProfiles := ruleEditor.getProfiles
+		saveProfiles := ruleEditor.saveProfiles
+		ruleEditor.mu.Unlock()
+
+		switch r.Method {
+		case http.MethodGet:
+			profiles := []config.ProfileConfig{}
+			if getProfiles != nil {
+				if current := getProfiles(); current != nil {
+					profiles = current
+				}
+			}
+			writeJSON(rw, http.StatusOK, map[string]interface{}{"profiles": profiles})
+
+		case http.MethodPut:
+			var payload struct {
+				Profiles []config.ProfileConfig `json:"profiles"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				writeJSON(rw, http.StatusBadRequest, map[string]interface{}{"errors": []string{"invalid request: " + err.Error()}})
+				return
+			}
+			if validationErrors := config.ValidateProfiles(payload.Profiles); len(validationErrors) > 0 {
+				log.Printf("Rule editor: rejected save with %d validation error(s).", len(validationErrors))
+				writeJSON(rw, http.StatusBadRequest, map[string]interface{}{"errors": validationErrors})
+				return
+			}
+			if saveProfiles == nil {
+				writeJSON(rw, http.StatusServiceUnavailable, map[string]interface{}{"errors": []string{"saving is not available"}})
+				return
+			}
+			if err := saveProfiles(payload.Profiles); err != nil {
+				log.Printf("Rule editor: failed to save profiles: %v", err)
+				writeJSON(rw, http.StatusInternalServerError, map[string]interface{}{"errors": []string{err.Error()}})
+				return
+			}
+			log.Printf("Rule editor: saved %d profile(s).", len(payload.Profiles))
+			writeJSON(rw, http.StatusOK, map[string]interface{}{"saved": true})
+
+		default:
+			http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	globalWebServer.handle("/rule-editor/api/validate", func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var payload struct {
+			Regex string `json:"regex"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			writeJSON(rw, http.StatusBadRequest, map[string]interface{}{"valid": false, "error": "invalid request: " + err.Error()})
+			return
+		}
+		if _, err := regexp.Compile(payload.Regex); err != nil {
+			writeJSON(rw, http.StatusOK, map[string]interface{}{"valid": false, "error": err.Error()})
+			return
+		}
+		writeJSON(rw, http.StatusOK, map[string]interface{}{"valid": true})
+	})
+
+	// Waits until the hotkey recorder window is closed
+	globalWebServer.handle("/rule-editor/api/record-hotkey", func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var payload struct {
+			Profile string `json:"profile"`
+			Current string `json:"current"`
+			Reverse bool   `json:"reverse"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			writeJSON(rw, http.StatusBadRequest, map[string]interface{}{"error": "invalid request: " + err.Error()})
+			return
+		}
+		prompt := fmt.Sprintf("Press the hotkey for '%s'", payload.Profile)
+		if payload.Reverse {
+			prompt = fmt.Sprintf("Press the reverse hotkey for '%s'", payload.Profile)
+		}
+		hotkey, err := RecordHotkey(prompt, payload.Profile, payload.Current)
+		switch {
+		case errors.Is(err, ErrRecordingCanceled):
+			writeJSON(rw, http.StatusOK, map[string]interface{}{"canceled": true})
+		case err != nil:
+			log.Printf("Rule editor: failed to record a hotkey: %v", err)
+			writeJSON(rw, http.StatusOK, map[string]interface{}{"error": err.Error()})
+		default:
+			writeJSON(rw, http.StatusOK, map[string]interface{}{"hotkey": hotkey})
+		}
+	})
+}
@@ -0,0 +1,172 @@
+package ui
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/stats"
+)
+
+// StatisticsCallbacks connects the statistics window to the application.
+type StatisticsCallbacks struct {
+	// Snapshot returns the collected statistics.
+	Snapshot func() stats.Snapshot
+	// Profiles returns the configured profiles, used to list rules that never fired.
+	Profiles func() []config.ProfileConfig
+	// Reset clears all statistics.
+	Reset func() error
+}
+
+// statisticsRule is a configured rule and its statistics.
+type statisticsRule struct {
+	Index        int        `json:"index"` // 1-based position in the profile, 0 if no longer configured
+	Regex        string     `json:"regex"`
+	Hits         int        `json:"hits"`
+	Matches      int        `json:"matches"`
+	CharsChanged int        `json:"charsChanged"`
+	LastFired    *time.Time `json:"lastFired"`
+}
+
+// statisticsProfile is a profile and the statistics of its rules.
+type statisticsProfile struct {
+	Name         string           `json:"name"`
+	Enabled      bool             `json:"enabled"`
+	Configured   bool             `json:"configured"` // False for profiles that were removed or renamed
+	Hits         int              `json:"hits"`
+	CharsChanged int              `json:"charsChanged"`
+	LastFired    *time.Time       `json:"lastFired"`
+	Rules        []statisticsRule `json:"rules"`
+}
+
+var (
+	statisticsMu        sync.Mutex
+	statisticsCallbacks StatisticsCallbacks
+	statisticsHandlers  sync.Once
+)
+
+// ShowStatistics opens the statistics window in the default browser.
+func ShowStatistics(callbacks StatisticsCallbacks) error {
+	statisticsMu.Lock()
+	statisticsCallbacks = callbacks
+	statisticsMu.Unlock()
+
+	statisticsHandlers.Do(registerStatisticsHandlers)
+
+	return openWebPage("/statistics")
+}
+
+// firedAt returns a pointer to t, or nil if the rule never fired.
+func firedAt(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// buildStatisticsView merges the statistics into the configured profiles so
+// rules that never fired are listed too. Statistics of rules that are no
+// longer configured are kept at the end of their profile.
+func buildStatisticsView(snapshot stats.Snapshot, profiles []config.ProfileConfig) []statisticsProfile {
+	type ruleKey struct{ profile, regex string }
+	ruleStats := make(map[ruleKey]stats.RuleStats)
+	for _, rule := range snapshot.Rules {
+		ruleStats[ruleKey{rule.Profile, rule.Regex}] = rule
+	}
+	profileStats := make(map[string]stats.ProfileStats)
+	for _, profile := range snapshot.Profiles {
+		profileStats[profile.Profile] = profile
+	}
+
+	view := []statisticsProfile{}
+	seenProfiles := make(map[string]int) // Profile name -> index in view
+	used := make(map[ruleKey]bool)
+
+	for _, profile := range profiles {
+		if _, dup := seenProfiles[profile.Name]; dup {
+			continue
+		}
+		entry := statisticsProfile{Name: profile.Name, Enabled: profile.Enabled, Configured: true, Rules: []statisticsRule{}}
+		if ps, ok := profileStats[profile.Name]; ok {
+			entry.Hits, entry.CharsChanged, entry.LastFired = ps.Hits, ps.CharsChanged, firedAt(ps.LastFired)
+		}
+		for i, rep := range profile.Replacements {
+			key := ruleKey{profile.Name, rep.Summary()}
+			rule := statisticsRule{Index: i + 1, Regex: rep.Summary()}
+			if rs, ok := ruleStats[key]; ok && !used[key] {
+				rule.Hits, rule.Matches, rule.CharsChanged, rule.LastFired = rs.Hits, rs.Matches, rs.CharsChanged, firedAt(rs.LastFired)
+			}
+			used[key] = true
+			entry.Rules = append(entry.Rules, rule)
+		}
+		seenProfiles[profile.Name] = len(view)
+		view = append(view, entry)
+	}
+
+	for _, rs := range snapshot.Rules {
+		key := ruleKey{rs.Profile, rs.Regex}
+		if used[key] {
+			continue
+		}
+		idx, ok := seenProfiles[rs.Profile]
+		if !ok {
+			entry := statisticsProfile{Name: rs.Profile, Rules: []statisticsRule{}}
+			if ps, ok := profileStats[rs.Profile]; ok {
+				entry.Hits, entry.CharsChanged, entry.LastFired = ps.Hits, ps.CharsChanged, firedAt(ps.LastFired)
+			}
+			idx = len(view)
+			seenProfiles[rs.Profile] = idx
+			view = append(view, entry)
+		}
+		view[idx].Rules = append(view[idx].Rules, statisticsRule{
+			Regex: rs.Regex, Hits: rs.Hits, Matches: rs.Matches, CharsChanged: rs.CharsChanged, LastFired: firedAt(rs.LastFired),
+		})
+	}
+	return view
+}
+
+func registerStatisticsHandlers() {
+	getCallbacks := func() StatisticsCallbacks {
+		statisticsMu.Lock()
+		defer statisticsMu.Unlock()
+		return statisticsCallbacks
+	}
+
+	globalWebServer.handle("/statistics", func(rw http.ResponseWriter, r *http.Request) {
+		serveAsset(rw, "statistics.html")
+	})
+
+	globalWebServer.handle("/statistics/api/data", func(rw http.ResponseWriter, r *http.Request) {
+		cb := getCallbacks()
+		var snapshot stats.Snapshot
+		if cb.Snapshot != nil {
+			snapshot = cb.Snapshot()
+		}
+		var profiles []config.ProfileConfig
+		if cb.Profiles != nil {
+			profiles = cb.Profiles()
+		}
+		writeJSON(rw, http.StatusOK, map[string]interface{}{
+			"since":    snapshot.Since,
+			"profiles": buildStatisticsView(snapshot, profiles),
+		})
+	})
+
+	globalWebServer.handle("/statistics/api/reset", func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		cb := getCallbacks()
+		if cb.Reset == nil {
+			writeJSON(rw, http.StatusServiceUnavailable, map[string]interface{}{"error": "resetting statistics is not available"})
+			return
+		}
+		if err := cb.Reset(); err != nil {
+			writeJSON(rw, http.StatusInternalServerError, map[string]interface{}{"error": err.Error()})
+			return
+		}
+		writeJSON(rw, http.StatusOK, map[string]interface{}{"reset": true})
+	})
+}
@@ -9,15 +9,16 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/getlantern/systray"
 	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/resources"
 )
 
 // SystrayManager handles the system tray icon and menu
 type SystrayManager struct {
-	mu               sync.RWMutex // Protects config and profileMenuItems
+	mu               sync.RWMutex // Protects config and profileMenu
 	config           *config.Config
 	version          string
 	onReloadConfig   func()
@@ -26,14 +27,53 @@ type SystrayManager struct {
 	onRevert         func()
 	onOpenConfig     func()
 	onViewLastDiff   func()
-	onAddSecret      func() // Callback for Add/Update Secret
-	onListSecrets    func() // Callback for List Secrets
-	onRemoveSecret   func() // Callback for Remove Secret
-	onAddSimpleRule  func() // <-- Add callback for simple rule
+	onAddSecret      func()    // Callback for Add/Update Secret
+	onListSecrets    func()    // Callback for List Secrets
+	onRemoveSecret   func()    // Callback for Remove Secret
+	onImportSecrets  func()    // Callback for importing a batch of secrets from a file
+	onExportSecrets  func()    // Callback for exporting secret names (and values) to a file
+	onAuditSecrets   func()    // Callback for the report of which rules use which secrets
+	onAddSimpleRule  func()    // <-- Add callback for simple rule
+	onOpenRuleEditor func()    // Callback for the rule editor window
+	onOpenPlayground func()    // Callback for the regex playground window
+	onExportProfile  func()    // Callback for exporting a profile to a file
+	onImportProfile  func()    // Callback for importing a profile from a file
+	onImportExternal func()    // Callback for importing Espanso/AutoHotkey rules
+	onHotkeyStatus   func()    // Callback for the hotkey status view
+	onStatistics     func()    // Callback for the rule statistics view
+	onRunRuleTests   func()    // Callback for running the rules' embedded test cases
+	onRestoreConfig  func()    // Callback for restoring the previous config.json from a backup
+	onCheckUpdates   func()    // Callback for checking for and installing a new release
+	onClearHistory   func()    // Callback for deleting the clipboard history
+	onExportStats    func()    // Callback for exporting the rule statistics to CSV or JSON
+	onDiagnostics    func()    // Callback for the self-diagnostics report
+	onTogglePause    func()    // Callback for pausing or resuming hotkeys
+	onExplain        func()    // Callback for the dry run of a hotkey
+	onRestoreUndo    func(int) // Callback for the Undo History items, given the state's index
 	embeddedIcon     []byte
 	miRevert         *systray.MenuItem
 	miViewLastDiff   *systray.MenuItem
-	profileMenuItems map[int]*systray.MenuItem
+	miHotkeyStatus   *systray.MenuItem
+	miAutostart      *systray.MenuItem
+	miSounds         *systray.MenuItem
+	miPause          *systray.MenuItem
+	miUpdates        *systray.MenuItem
+	updateAvailable  string          // Tag of a newer release found by the update checker
+	hotkeyProblems   int             // Number of hotkey problems reported before the menu was built
+	profileMenu      *profileMenu    // Built by onReady, rebuilt by UpdateConfig
+	presetMenu       *presetMenu     // Built by onReady, refreshed with profileMenu
+	undoMenu         *undoMenu       // Built by onReady, refreshed by UpdateUndoHistory
+	undoEntries      []UndoMenuEntry // States listed in the Undo History submenu, newest first
+
+	// Tray icon state (protected by mu)
+	ready         bool   // onReady has run and the icon can be changed
+	processing    int    // Number of replacements currently running
+	revertPending bool   // The original clipboard can be reverted
+	paused        bool   // Hotkeys are ignored until resumed
+	diffAvailable bool   // A last change can be shown, e.g. one restored from the history
+	currentIcon   string // Icon variant currently shown
+
+	onRunProfile func(name string, reverse bool) // Callback for the "Run Now" items of the Profiles submenu
 }
 
 // NewSystrayManager creates a new system tray manager
@@ -50,7 +90,27 @@ func NewSystrayManager(
 	onAddSecret func(),
 	onListSecrets func(),
 	onRemoveSecret func(),
+	onImportSecrets func(),
+	onExportSecrets func(),
+	onAuditSecrets func(),
 	onAddSimpleRule func(), // <-- Add parameter for simple rule callback
+	onOpenRuleEditor func(),
+	onOpenPlayground func(),
+	onExportProfile func(),
+	onImportProfile func(),
+	onImportExternal func(),
+	onHotkeyStatus func(),
+	onStatistics func(),
+	onRunRuleTests func(),
+	onRestoreConfig func(),
+	onCheckUpdates func(),
+	onClearHistory func(),
+	onExportStats func(),
+	onDiagnostics func(),
+	onTogglePause func(),
+	onExplain func(),
+	onRestoreUndo func(int),
+	onRunProfile func(string, bool),
 ) *SystrayManager {
 	return &SystrayManager{
 		config:           cfg,
@@ -62,64 +122,79 @@ func NewSystrayManager(
 		onRevert:         onRevert,
 		onOpenConfig:     onOpenConfig,
 		onViewLastDiff:   onViewLastDiff,
-		profileMenuItems: make(map[int]*systray.MenuItem),
 		onAddSecret:      onAddSecret,
 		onListSecrets:    onListSecrets,
 		onRemoveSecret:   onRemoveSecret,
+		onImportSecrets:  onImportSecrets,
+		onExportSecrets:  onExportSecrets,
+		onAuditSecrets:   onAuditSecrets,
 		onAddSimpleRule:  onAddSimpleRule, // <-- Store the callback
+		onOpenRuleEditor: onOpenRuleEditor,
+		onOpenPlayground: onOpenPlayground,
+		onExportProfile:  onExportProfile,
+		onImportProfile:  onImportProfile,
+		onImportExternal: onImportExternal,
+		onHotkeyStatus:   onHotkeyStatus,
+		onStatistics:     onStatistics,
+		onRunRuleTests:   onRunRuleTests,
+		onRestoreConfig:  onRestoreConfig,
+		onCheckUpdates:   onCheckUpdates,
+		onClearHistory:   onClearHistory,
+		onExportStats:    onExportStats,
+		onDiagnostics:    onDiagnostics,
+		onTogglePause:    onTogglePause,
+		onExplain:        onExplain,
+		onRestoreUndo:    onRestoreUndo,
+		onRunProfile:     onRunProfile,
+	}
+}
+
+// SetUpdateAvailable shows in the menu that a newer release was found.
+func (s *SystrayManager) SetUpdateAvailable(tag string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updateAvailable = tag
+	s.applyUpdateTitleLocked()
+}
+
+// applyUpdateTitleLocked sets the title of the update menu item. s.mu must be held.
+func (s *SystrayManager) applyUpdateTitleLocked() {
+	if s.miUpdates == nil {
+		return
+	}
+	if s.updateAvailable != "" {
+		s.miUpdates.SetTitle(i18n.Tf("Update Available: %s...", s.updateAvailable))
+	} else {
+		s.miUpdates.SetTitle(i18n.T("Check for Updates..."))
 	}
 }
 
 // UpdateConfig updates the configuration used by the systray manager
-// and adjusts relevant UI elements like Revert status and Profile checkmarks.
+// and adjusts relevant UI elements like Revert status and the Profiles menu.
 func (s *SystrayManager) UpdateConfig(newCfg *config.Config) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	log.Println("SystrayManager: Updating config reference.")
 	s.config = newCfg // Update internal reference
+	defer s.refreshIconLocked()
+	s.applySoundsTitleLocked()
 
-	// Update Revert menu item state based on config flag
+	// Show the Revert menu item only while the feature is on
 	if s.miRevert != nil {
-		if s.config != nil && s.config.TemporaryClipboard {
+		if s.config != nil && s.config.KeepsOriginals() {
 			log.Println("SystrayManager: TemporaryClipboard is enabled in new config.")
 			// Enable/disable state is handled by UpdateRevertStatus based on clipboard content
+			s.miRevert.Show()
 		} else {
-			log.Println("SystrayManager: TemporaryClipboard disabled or config nil. Disabling Revert menu item.")
-			s.miRevert.Disable() // Permanently disable if feature is off
-		}
-	} else if s.config != nil && s.config.TemporaryClipboard {
-		// If the item didn't exist but should now, it requires a restart to add it.
-		log.Println("SystrayManager: TemporaryClipboard is now enabled, but Revert item cannot be added without restart.")
-	}
-
-	// Update checkmarks on existing profile menu items
-	if s.profileMenuItems != nil && s.config != nil && s.config.Profiles != nil {
-		log.Printf("SystrayManager: Updating profile menu item checkmarks (%d items, %d profiles)", len(s.profileMenuItems), len(s.config.Profiles))
-		for i, menuItem := range s.profileMenuItems {
-			if menuItem == nil {
-				log.Printf("SystrayManager: Skipping nil profile menu item at index %d", i)
-				continue
-			}
-			if i < len(s.config.Profiles) { // Check index bounds against NEW config
-				profile := s.config.Profiles[i] // Get profile from NEW config
-				log.Printf("SystrayManager: Updating checkmark for profile '%s' (index %d, enabled: %t)", profile.Name, i, profile.Enabled)
-				newText := "  " + profile.Name
-				if profile.Enabled {
-					newText = "✓ " + profile.Name
-				}
-				// Update Title AND Checked status for clarity if API supports it (getlantern/systray typically uses title prefix)
-				menuItem.SetTitle(newText)
-				// menuItem.SetChecked(profile.Enabled) // Use if SetChecked is available and preferred
-
-			} else {
-				log.Printf("SystrayManager: Profile index %d is out of bounds after reload. Disabling related menu item.", i)
-				menuItem.Disable()
-			}
+			log.Println("SystrayManager: TemporaryClipboard disabled or config nil. Hiding Revert menu item.")
+			s.miRevert.Disable()
+			s.miRevert.Hide()
 		}
-	} else {
-		log.Println("SystrayManager: Skipping profile menu item update (no items or no profiles in config).")
 	}
+
+	// Added, removed or renamed profiles appear without a restart
+	s.rebuildProfileMenuLocked()
 }
 
 // Run initializes and starts the system tray
@@ -129,9 +204,14 @@ func (s *SystrayManager) Run() {
 
 // UpdateRevertStatus enables or disables the revert menu item based on clipboard state
 func (s *SystrayManager) UpdateRevertStatus(enabled bool) {
+	s.mu.Lock()
+	s.revertPending = enabled && s.config != nil && s.config.KeepsOriginals()
+	s.refreshIconLocked()
+	s.mu.Unlock()
+
 	if s.miRevert != nil {
 		// Only allow enabling if the feature itself is enabled in the config
-		if enabled && s.config != nil && s.config.TemporaryClipboard {
+		if enabled && s.config != nil && s.config.KeepsOriginals() {
 			log.Println("SystrayManager: Enabling Revert menu item.")
 			s.miRevert.Enable()
 		} else {
@@ -144,19 +224,118 @@ func (s *SystrayManager) UpdateRevertStatus(enabled bool) {
 
 // UpdateViewLastDiffStatus enables or disables the view diff menu item
 func (s *SystrayManager) UpdateViewLastDiffStatus(enabled bool) {
+	s.mu.Lock()
+	s.diffAvailable = enabled // Applied by onReady if the menu isn't built yet
+	s.mu.Unlock()
+
 	if s.miViewLastDiff != nil {
 		if enabled {
 			log.Println("SystrayManager: Enabling View Last Change Details menu item.")
-			s.miViewLastDiff.SetTitle("View Last Change Details") // Ensure title is correct
+			s.miViewLastDiff.SetTitle(i18n.T("View Last Change Details")) // Ensure title is correct
 			s.miViewLastDiff.Enable()
 		} else {
 			log.Println("SystrayManager: Disabling View Last Change Details menu item.")
-			s.miViewLastDiff.SetTitle("View Last Change Details") // Keep title consistent
+			s.miViewLastDiff.SetTitle(i18n.T("View Last Change Details")) // Keep title consistent
 			s.miViewLastDiff.Disable()
 		}
 	}
 }
 
+// UpdateHotkeyStatus flags the "Hotkey Status" menu item when hotkeys failed
+// to register or conflict with each other.
+func (s *SystrayManager) UpdateHotkeyStatus(problemCount int) {
+	s.mu.Lock()
+	s.hotkeyProblems = problemCount
+	s.refreshIconLocked()
+	s.mu.Unlock()
+	s.applyHotkeyStatusTitle()
+}
+
+// SetProcessing shows the "processing" icon while a replacement runs. Calls
+// must be paired: SetProcessing(true) before and SetProcessing(false) after.
+func (s *SystrayManager) SetProcessing(active bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if active {
+		s.processing++
+	} else if s.processing > 0 {
+		s.processing--
+	}
+	s.refreshIconLocked()
+}
+
+// iconStateLocked picks the icon variant for the current state. A running
+// replacement is shown first, then pausing, hotkey problems, a pending revert
+// and finally whether any profile is enabled. The caller must hold s.mu.
+func (s *SystrayManager) iconStateLocked() (variant string, tooltip string) {
+	switch {
+	case s.processing > 0:
+		return resources.IconProcessing, i18n.T("Processing clipboard...")
+	case s.paused:
+		return resources.IconPaused, i18n.T("Paused: hotkeys are ignored")
+	case s.hotkeyProblems > 0:
+		return resources.IconError, i18n.Tf("%d hotkey problem(s), see Hotkey Status", s.hotkeyProblems)
+	case s.revertPending:
+		return resources.IconPending, i18n.T("Original clipboard can be reverted")
+	}
+	if s.config != nil {
+		for _, profile := range s.config.Profiles {
+			if profile.Enabled {
+				return resources.IconNormal, ""
+			}
+		}
+	}
+	return resources.IconPaused, i18n.T("Paused: no profile is enabled")
+}
+
+// refreshIconLocked switches the tray icon and tooltip if the state changed.
+// The caller must hold s.mu.
+func (s *SystrayManager) refreshIconLocked() {
+	if !s.ready {
+		return // onReady sets the initial icon
+	}
+	variant, state := s.iconStateLocked()
+	if variant == s.currentIcon {
+		return
+	}
+
+	icon := s.embeddedIcon
+	if variant != resources.IconNormal {
+		data, err := resources.GetIconVariant(variant)
+		if err != nil {
+			log.Printf("SystrayManager: Icon variant '%s' unavailable: %v", variant, err)
+		} else {
+			icon = data
+		}
+	}
+	if len(icon) > 0 {
+		systray.SetIcon(icon)
+	}
+
+	tooltip := fmt.Sprintf("Clipboard Regex Replace %s", s.version)
+	if state != "" {
+		tooltip += " - " + state
+	}
+	systray.SetTooltip(tooltip)
+	s.currentIcon = variant
+	log.Printf("SystrayManager: Tray icon state changed to '%s'", variant)
+}
+
+func (s *SystrayManager) applyHotkeyStatusTitle() {
+	s.mu.RLock()
+	problems := s.hotkeyProblems
+	item := s.miHotkeyStatus
+	s.mu.RUnlock()
+	if item == nil {
+		return // Menu not built yet; onReady applies the stored count
+	}
+	if problems > 0 {
+		item.SetTitle(i18n.Tf("⚠ Hotkey Status (%d problem(s))", problems))
+	} else {
+		item.SetTitle(i18n.T("Hotkey Status"))
+	}
+}
+
 // onReady is called by systray once the tray is ready.
 func (s *SystrayManager) onReady() {
 	// Set title and tooltip
@@ -168,45 +347,97 @@ func (s *SystrayManager) onReady() {
 	} else {
 		log.Println("Warning: No embedded icon data to set for systray.")
 	}
+	s.mu.Lock()
+	s.ready = true
+	s.currentIcon = resources.IconNormal
+	s.refreshIconLocked() // Switch to a state variant if one applies already
+	s.mu.Unlock()
 
 	// Add version info (disabled)
-	miVersion := systray.AddMenuItem(fmt.Sprintf("Version: %s", s.version), "Clipboard Regex Replace version")
+	miVersion := systray.AddMenuItem(i18n.Tf("Version: %s", s.version), i18n.T("Clipboard Regex Replace version"))
 	miVersion.Disable()
 	systray.AddSeparator()
 
 	// Build the profile submenu
-	s.updateProfileMenuItems() // This already has "Add New Profile"
+	s.buildProfileMenu() // This already has "Add New Profile"
+	s.buildPresetMenu()
+	s.miPause = systray.AddMenuItem("  "+i18n.T(pauseTitle), i18n.T("Ignore hotkeys until this is clicked again"))
+	s.mu.Lock()
+	s.applyPauseTitleLocked()
+	s.mu.Unlock()
 	systray.AddSeparator()
 
 	// --- Add Secret Management Menu ---
-	miManageSecrets := systray.AddMenuItem("Manage Secrets", "Add/Remove sensitive values")
-	miAddSecret := miManageSecrets.AddSubMenuItem("Add/Update Secret...", "Store a new sensitive value")
-	miListSecrets := miManageSecrets.AddSubMenuItem("List Secret Names", "Show names of stored secrets")
-	miRemoveSecret := miManageSecrets.AddSubMenuItem("Remove Secret...", "Delete a stored secret")
+	miManageSecrets := systray.AddMenuItem(i18n.T("Manage Secrets"), i18n.T("Add/Remove sensitive values"))
+	miAddSecret := miManageSecrets.AddSubMenuItem(i18n.T("Add/Update Secret..."), i18n.T("Store a new sensitive value"))
+	miListSecrets := miManageSecrets.AddSubMenuItem(i18n.T("List Secret Names"), i18n.T("Show names of stored secrets"))
+	miRemoveSecret := miManageSecrets.AddSubMenuItem(i18n.T("Remove Secret..."), i18n.T("Delete a stored secret"))
+	miAuditSecrets := miManageSecrets.AddSubMenuItem(i18n.T("Secret Usage..."), i18n.T("Show which rules use which secrets, and unused or missing secrets"))
+	miImportSecrets := miManageSecrets.AddSubMenuItem(i18n.T("Import Secrets..."), i18n.T("Store a batch of secrets from a JSON or CSV file"))
+	miExportSecrets := miManageSecrets.AddSubMenuItem(i18n.T("Export Secrets..."), i18n.T("Save the secret names, and optionally encrypted values, to a file"))
 
 	// --- Add Simple Rule Menu Item ---
-	miAddSimpleRule := systray.AddMenuItem("Add Simple Rule...", "Add a 1:1 text replacement rule to a profile") // <-- New Item
+	miAddSimpleRule := systray.AddMenuItem(i18n.T("Add Simple Rule..."), i18n.T("Add a 1:1 text replacement rule to a profile")) // <-- New Item
+	miEditRules := systray.AddMenuItem(i18n.T("Edit Rules..."), i18n.T("Open the rule editor window"))
+	miTestRules := systray.AddMenuItem(i18n.T("Test Rules..."), i18n.T("Try a regex against the current clipboard text"))
+	miExplain := systray.AddMenuItem(i18n.T("Explain Hotkey..."), i18n.T("Show which rule of which profile would change which part of the clipboard"))
+	miRunRuleTests := systray.AddMenuItem(i18n.T("Run Rule Tests"), i18n.T("Check every rule against the test cases in its \"tests\" list"))
+
+	// --- Profile Sharing Menu ---
+	miShareProfiles := systray.AddMenuItem(i18n.T("Share Profiles"), i18n.T("Export or import profiles as files"))
+	miExportProfile := miShareProfiles.AddSubMenuItem(i18n.T("Export Profile..."), i18n.T("Save a profile and its rules to a .json/.yaml file"))
+	miImportProfile := miShareProfiles.AddSubMenuItem(i18n.T("Import Profile..."), i18n.T("Add a profile from a .json/.yaml file"))
+	miImportExternal := miShareProfiles.AddSubMenuItem(i18n.T("Import from Espanso/AutoHotkey..."), i18n.T("Convert Espanso matches or AutoHotkey hotstrings into a new profile"))
 
 	systray.AddSeparator()
 
 	// Config & App Control - Update tooltips for restart requirement
-	miReloadConfig := systray.AddMenuItem("Reload Configuration", "Reload config (manual restart needed for new secrets/hotkeys)")
-	miOpenConfig := systray.AddMenuItem("Open Config File", "Open config.json in default editor")
-	s.miViewLastDiff = systray.AddMenuItem("View Last Change Details", "Show differences from the last replacement")
-	s.miViewLastDiff.Disable()
-	miRestartApp := systray.AddMenuItem("Restart Application", "Restart (needed after adding/removing secrets or profiles)")
-
-	// Revert Option
-	if s.config != nil && s.config.TemporaryClipboard {
-		log.Println("SystrayManager: TemporaryClipboard enabled, adding Revert menu item.")
-		s.miRevert = systray.AddMenuItem("Revert to Original", "Revert to original clipboard text")
+	miReloadConfig := systray.AddMenuItem(i18n.T("Reload Configuration"), i18n.T("Reload config.json, secrets and hotkeys"))
+	miOpenConfig := systray.AddMenuItem(i18n.T("Open Config File"), i18n.T("Open config.json in default editor"))
+	miRestoreConfig := systray.AddMenuItem(i18n.T("Restore Previous Config"), i18n.T("Replace config.json with its latest backup and reload it"))
+	miHotkeyStatus := systray.AddMenuItem(i18n.T("Hotkey Status"), i18n.T("Show which hotkeys are registered and any conflicts"))
+	s.mu.Lock()
+	s.miHotkeyStatus = miHotkeyStatus // Read by applyHotkeyStatusTitle from other goroutines
+	s.mu.Unlock()
+	s.applyHotkeyStatusTitle()
+	miDiagnostics := systray.AddMenuItem(i18n.T("Run Diagnostics"), i18n.T("Check clipboard access, hotkeys, pasting, the secret store, notifications and the config file"))
+	miStatistics := systray.AddMenuItem(i18n.T("Statistics..."), i18n.T("Show how often each rule and profile fired"))
+	miExportStats := systray.AddMenuItem(i18n.T("Export Statistics..."), i18n.T("Save the rule and profile counters as CSV or JSON"))
+	s.miViewLastDiff = systray.AddMenuItem(i18n.T("View Last Change Details"), i18n.T("Show differences from the last replacement"))
+	s.mu.RLock()
+	diffAvailable, revertPending := s.diffAvailable, s.revertPending
+	s.mu.RUnlock()
+	if !diffAvailable {
+		s.miViewLastDiff.Disable()
+	}
+	s.buildUndoMenu()
+	miClearHistory := systray.AddMenuItem(i18n.T("Clear History"), i18n.T("Delete the saved history of clipboard changes"))
+	s.miAutostart = systray.AddMenuItem("  "+i18n.T(autostartTitle), i18n.T("Start Clipboard Regex Replace when you log in"))
+	s.refreshAutostartItem()
+	s.miSounds = systray.AddMenuItem("  "+i18n.T(soundsTitle), i18n.T("Play a sound after each hotkey press (success, no match, error)"))
+	s.mu.Lock()
+	s.applySoundsTitleLocked()
+	s.mu.Unlock()
+	s.miUpdates = systray.AddMenuItem(i18n.T("Check for Updates..."), i18n.T("Look for a newer release on GitHub"))
+	s.mu.Lock()
+	s.applyUpdateTitleLocked()
+	s.mu.Unlock()
+	miRestartApp := systray.AddMenuItem(i18n.T("Restart Application"), i18n.T("Restart the application"))
+
+	// Revert Option, hidden while TemporaryClipboard is off so a reload can show it
+	s.miRevert = systray.AddMenuItem(i18n.T("Revert to Original"), i18n.T("Revert to original clipboard text"))
+	if !revertPending {
 		s.miRevert.Disable()
-	} else {
-		log.Println("SystrayManager: TemporaryClipboard disabled or config nil, skipping Revert menu item creation.")
 	}
+	s.mu.RLock()
+	if s.config == nil || !s.config.KeepsOriginals() {
+		log.Println("SystrayManager: TemporaryClipboard disabled or config nil, hiding Revert menu item.")
+		s.miRevert.Hide()
+	}
+	s.mu.RUnlock()
 
 	systray.AddSeparator()
-	miQuit := systray.AddMenuItem("Quit", "Exit the application")
+	miQuit := systray.AddMenuItem(i18n.T("Quit"), i18n.T("Exit the application"))
 
 	// --- Set up menu handlers in goroutines ---
 
@@ -234,6 +465,42 @@ func (s *SystrayManager) onReady() {
 			}
 		}()
 	}
+	if s.onClearHistory != nil {
+		go func() {
+			for range miClearHistory.ClickedCh {
+				log.Println("Clear History menu item clicked.")
+				s.onClearHistory()
+			}
+		}()
+	}
+	go func() {
+		for range s.miAutostart.ClickedCh {
+			log.Println("'Start with System' menu item clicked.")
+			s.toggleAutostart()
+		}
+	}()
+	if s.onTogglePause != nil {
+		go func() {
+			for range s.miPause.ClickedCh {
+				log.Println("'Pause Hotkeys' menu item clicked.")
+				s.onTogglePause()
+			}
+		}()
+	}
+	go func() {
+		for range s.miSounds.ClickedCh {
+			log.Println("'Sound Feedback' menu item clicked.")
+			s.toggleSounds()
+		}
+	}()
+	if s.onCheckUpdates != nil {
+		go func() {
+			for range s.miUpdates.ClickedCh {
+				log.Println("'Check for Updates...' menu item clicked.")
+				s.onCheckUpdates()
+			}
+		}()
+	}
 	go func() {
 		for range miRestartApp.ClickedCh {
 			log.Println("Restart Application menu item clicked.")
@@ -276,6 +543,30 @@ func (s *SystrayManager) onReady() {
 			}
 		}()
 	}
+	if s.onAuditSecrets != nil {
+		go func() {
+			for range miAuditSecrets.ClickedCh {
+				log.Println("Secret Usage menu item triggered.")
+				s.onAuditSecrets()
+			}
+		}()
+	}
+	if s.onImportSecrets != nil {
+		go func() {
+			for range miImportSecrets.ClickedCh {
+				log.Println("Import Secrets menu item triggered.")
+				s.onImportSecrets()
+			}
+		}()
+	}
+	if s.onExportSecrets != nil {
+		go func() {
+			for range miExportSecrets.ClickedCh {
+				log.Println("Export Secrets menu item triggered.")
+				s.onExportSecrets()
+			}
+		}()
+	}
 
 	// Add Simple Rule Handler <-- New Handler
 	if s.onAddSimpleRule != nil {
@@ -287,192 +578,137 @@ func (s *SystrayManager) onReady() {
 		}()
 	}
 
-	// Quit Handler
-	go func() {
-		<-miQuit.ClickedCh
-		log.Println("Quit menu item clicked.")
-		if s.onQuit != nil {
-			s.onQuit()
-		}
-		systray.Quit()
-	}()
-
-	log.Println("Systray ready and menu configured.")
-}
+	// Rule Editor Handler
+	if s.onOpenRuleEditor != nil {
+		go func() {
+			for range miEditRules.ClickedCh {
+				log.Println("'Edit Rules...' menu item triggered.")
+				s.onOpenRuleEditor()
+			}
+		}()
+	}
 
-// onExit is called when the systray is exiting
-func (s *SystrayManager) onExit() {
-	log.Println("Systray exiting.")
-}
+	// Regex Playground Handler
+	if s.onOpenPlayground != nil {
+		go func() {
+			for range miTestRules.ClickedCh {
+				log.Println("'Test Rules...' menu item triggered.")
+				s.onOpenPlayground()
+			}
+		}()
+	}
 
-// updateProfileMenuItems creates submenu items for each profile
-func (s *SystrayManager) updateProfileMenuItems() {
-	s.profileMenuItems = make(map[int]*systray.MenuItem)
-	miProfiles := systray.AddMenuItem("Profiles", "Manage replacement profiles")
-	if s.config != nil && len(s.config.Profiles) > 0 {
-		for i := range s.config.Profiles {
-			profileIndex := i // Capture index for goroutine closure
-			if profileIndex >= len(s.config.Profiles) {
-				continue // Safety check in case config changes during loop setup
+	// Profile Sharing Handlers
+	if s.onExportProfile != nil {
+		go func() {
+			for range miExportProfile.ClickedCh {
+				log.Println("'Export Profile...' menu item triggered.")
+				s.onExportProfile()
 			}
-			profile := s.config.Profiles[profileIndex]
-			menuText := "  " + profile.Name
-			if profile.Enabled {
-				menuText = "✓ " + profile.Name
+		}()
+	}
+	if s.onImportProfile != nil {
+		go func() {
+			for range miImportProfile.ClickedCh {
+				log.Println("'Import Profile...' menu item triggered.")
+				s.onImportProfile()
 			}
-			var tooltip string
-			if profile.ReverseHotkey != "" {
-				tooltip = fmt.Sprintf("Toggle profile: %s (Hotkey: %s, Reverse: %s)", profile.Name, profile.Hotkey, profile.ReverseHotkey)
-			} else {
-				tooltip = fmt.Sprintf("Toggle profile: %s (Hotkey: %s)", profile.Name, profile.Hotkey)
+		}()
+	}
+
+	if s.onImportExternal != nil {
+		go func() {
+			for range miImportExternal.ClickedCh {
+				log.Println("'Import from Espanso/AutoHotkey...' menu item triggered.")
+				s.onImportExternal()
 			}
-			menuItem := miProfiles.AddSubMenuItem(menuText, tooltip)
-			s.profileMenuItems[profileIndex] = menuItem
-
-			go func(item *systray.MenuItem, idx int) {
-				defer func() {
-					if r := recover(); r != nil {
-						log.Printf("RECOVERED FROM PANIC IN PROFILE TOGGLE HANDLER (index %d): %v", idx, r)
-					}
-				}()
-
-				for range item.ClickedCh {
-					// --- Safely access config and profile under lock ---
-					s.mu.Lock()
-					if s.config == nil || s.config.Profiles == nil || idx >= len(s.config.Profiles) {
-						s.mu.Unlock()
-						errMsg := "Profile list changed unexpectedly. Please use Reload or Restart."
-						log.Printf("Error: Profile index %d out of bounds or config nil after config change. Cannot toggle.", idx)
-						ShowAdminNotification(LevelWarn, "Menu Inconsistency", errMsg)
-						continue
-					}
-					p := &s.config.Profiles[idx] // Get pointer to modify directly
-
-					// --- Toggle State ---
-					p.Enabled = !p.Enabled
-					profileName := p.Name
-					profileEnabled := p.Enabled
-					log.Printf("Toggled profile '%s' to enabled=%t", profileName, profileEnabled)
-
-					// --- Update Menu Item Visual ---
-					newText := "  " + profileName
-					if profileEnabled {
-						newText = "✓ " + profileName
-					}
-					item.SetTitle(newText)
-
-					// --- Save Config ---
-					err := s.config.Save()
-					s.mu.Unlock()
-
-					if err != nil {
-						errMsg := fmt.Sprintf("Failed to save config after toggling '%s'. Error: %v", profileName, err)
-						log.Printf("Failed to save config after toggling profile '%s': %v", profileName, err)
-						ShowAdminNotification(LevelError, "Save Error", errMsg)
-
-						// Revert in-memory state
-						s.mu.Lock()
-						if idx < len(s.config.Profiles) {
-							s.config.Profiles[idx].Enabled = !profileEnabled
-							revertedText := "  " + profileName
-							if !profileEnabled {
-								revertedText = "✓ " + profileName
-							}
-							item.SetTitle(revertedText)
-						}
-						s.mu.Unlock()
-					} else {
-						// --- Notify & Reload ---
-						status := map[bool]string{true: "enabled", false: "disabled"}[profileEnabled]
-						msg := fmt.Sprintf("Profile '%s' has been %s. Reloading...", profileName, status)
-						ShowAdminNotification(LevelInfo, "Profile Updated", msg)
-						if s.onReloadConfig != nil {
-							log.Println("Triggering internal config reload after profile toggle to update hotkeys.")
-							// Slight delay to allow notification to potentially show first
-							time.Sleep(150 * time.Millisecond)
-							s.onReloadConfig()
-						}
-					}
-				}
-			}(menuItem, profileIndex)
-		}
-	} else {
-		log.Println("No profiles defined in config or config is nil.")
-		noProfilesItem := miProfiles.AddSubMenuItem("(No profiles defined)", "Add profiles in config.json")
-		noProfilesItem.Disable()
+		}()
 	}
-	sepItem := miProfiles.AddSubMenuItem("----------", "Separator")
-	sepItem.Disable()
-	miAddProfile := miProfiles.AddSubMenuItem("➕ Add New Profile", "Adds a template profile to config.json (Restart Recommended)")
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				log.Printf("RECOVERED FROM PANIC IN ADD PROFILE HANDLER: %v", r)
+
+	// Hotkey Status Handler
+	if s.onHotkeyStatus != nil {
+		go func() {
+			for range miHotkeyStatus.ClickedCh {
+				log.Println("'Hotkey Status' menu item triggered.")
+				s.onHotkeyStatus()
 			}
 		}()
+	}
 
-		for range miAddProfile.ClickedCh {
-			log.Println("'Add New Profile' clicked.")
+	// Run Diagnostics Handler
+	if s.onDiagnostics != nil {
+		go func() {
+			for range miDiagnostics.ClickedCh {
+				log.Println("'Run Diagnostics' menu item triggered.")
+				s.onDiagnostics()
+			}
+		}()
+	}
 
-			s.mu.Lock()
-			if s.config == nil {
-				s.mu.Unlock()
-				log.Println("Error: Cannot add profile, config is nil.")
-				ShowAdminNotification(LevelError, "Internal Error", "Application configuration not loaded.")
-				continue
+	// Explain Hotkey Handler
+	if s.onExplain != nil {
+		go func() {
+			for range miExplain.ClickedCh {
+				log.Println("'Explain Hotkey' menu item triggered.")
+				s.onExplain()
 			}
+		}()
+	}
 
-			// Ensure unique name generation remains robust even with frequent additions
-			baseName := "New_Profile"
-			existingNames := make(map[string]bool)
-			if s.config.Profiles != nil {
-				for _, p := range s.config.Profiles {
-					existingNames[p.Name] = true
-				}
+	// Run Rule Tests Handler
+	if s.onRunRuleTests != nil {
+		go func() {
+			for range miRunRuleTests.ClickedCh {
+				log.Println("'Run Rule Tests' menu item triggered.")
+				s.onRunRuleTests()
 			}
+		}()
+	}
 
-			newProfileName := baseName
-			counter := 1
-			for existingNames[newProfileName] {
-				newProfileName = fmt.Sprintf("%s_%d", baseName, counter)
-				counter++
+	// Restore Previous Config Handler
+	if s.onRestoreConfig != nil {
+		go func() {
+			for range miRestoreConfig.ClickedCh {
+				log.Println("'Restore Previous Config' menu item triggered.")
+				s.onRestoreConfig()
 			}
+		}()
+	}
 
-			newProfile := config.ProfileConfig{
-				Name:    newProfileName,
-				Enabled: true,
-				Hotkey:  "ctrl+alt+n", // Default new hotkey, might need adjustment by user
-				Replacements: []config.Replacement{
-					{
-						Regex:       fmt.Sprintf("text_for_%s", newProfileName),
-						ReplaceWith: "replacement_text",
-					},
-				},
+	// Statistics Handler
+	if s.onStatistics != nil {
+		go func() {
+			for range miStatistics.ClickedCh {
+				log.Println("'Statistics...' menu item triggered.")
+				s.onStatistics()
 			}
-			s.config.Profiles = append(s.config.Profiles, newProfile)
-
-			err := s.config.Save()
-			s.mu.Unlock()
-
-			if err != nil {
-				errMsg := fmt.Sprintf("Failed to save updated config file. Error: %v", err)
-				log.Printf("Failed to save config after adding new profile template: %v", err)
-				ShowAdminNotification(LevelError, "Error Adding Profile", errMsg)
-
-				// Roll back in-memory change on save failure
-				s.mu.Lock()
-				if len(s.config.Profiles) > 0 {
-					s.config.Profiles = s.config.Profiles[:len(s.config.Profiles)-1]
-				}
-				s.mu.Unlock()
-			} else {
-				msg := fmt.Sprintf("Template '%s' added. Edit config.json and use 'Reload' or 'Restart Application'.", newProfile.Name)
-				log.Printf("Added new profile template '%s' and saved config.", newProfile.Name)
-				ShowAdminNotification(LevelInfo, "Profile Template Added", msg)
-				// Note: The menu won't update automatically without a restart or explicit refresh logic
+		}()
+	}
+	if s.onExportStats != nil {
+		go func() {
+			for range miExportStats.ClickedCh {
+				log.Println("'Export Statistics...' menu item triggered.")
+				s.onExportStats()
 			}
+		}()
+	}
+
+	// Quit Handler
+	go func() {
+		<-miQuit.ClickedCh
+		log.Println("Quit menu item clicked.")
+		if s.onQuit != nil {
+			s.onQuit()
 		}
+		systray.Quit()
 	}()
+
+	log.Println("Systray ready and menu configured.")
+}
+
+// onExit is called when the systray is exiting
+func (s *SystrayManager) onExit() {
+	log.Println("Systray exiting.")
 }
 
 // IsDevMode checks if the application is running in development mode
@@ -514,7 +750,7 @@ func RestartApplication() {
 	}
 	execPath, err := os.Executable()
 	if err != nil {
-		errMsg := fmt.Sprintf("Failed to get executable path. Error: %v", err)
+		errMsg := i18n.Tf("Failed to get executable path. Error: %v", err)
 		log.Printf("Error getting executable path for restart: %v", err)
 		ShowAdminNotification(LevelError, "Restart Error", errMsg) // <<< CHANGED (Error Level)
 		return
@@ -537,7 +773,7 @@ func RestartApplication() {
 		cmd.Dir = cwd
 	}
 	if err := cmd.Start(); err != nil {
-		errMsg := fmt.Sprintf("Failed to start new application process: %v", err)
+		errMsg := i18n.Tf("Failed to start new application process: %v", err)
 		log.Printf("Error starting new process during restart: %v", err)
 		ShowAdminNotification(LevelError, "Restart Error", errMsg) // <<< CHANGED (Error Level)
 		return
@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/autostart"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+)
+
+const autostartTitle = "Start with System"
+
+// refreshAutostartItem shows the current autostart state in the menu item.
+func (s *SystrayManager) refreshAutostartItem() {
+	if s.miAutostart == nil {
+		return
+	}
+	enabled, err := autostart.Enabled()
+	if err != nil {
+		log.Printf("Warning: Could not check autostart state: %v", err)
+	}
+	if enabled {
+		s.miAutostart.SetTitle("✓ " + i18n.T(autostartTitle))
+	} else {
+		s.miAutostart.SetTitle("  " + i18n.T(autostartTitle))
+	}
+}
+
+// autostartArgs returns the arguments for the autostart entry: --portable if
+// the application was started with it, and --config for a config file other
+// than the one found without flags.
+func (s *SystrayManager) autostartArgs() []string {
+	s.mu.RLock()
+	configPath := ""
+	if s.config != nil {
+		configPath = s.config.GetConfigPath()
+	}
+	s.mu.RUnlock()
+
+	var args []string
+	portable := false
+	for _, arg := range os.Args[1:] {
+		if arg == "--portable" || arg == "-portable" || arg == "--portable=true" || arg == "-portable=true" {
+			portable = true
+			args = append(args, "--portable")
+			break
+		}
+	}
+	if configPath == "" {
+		return args
+	}
+	defaultPath := ""
+	if dir, ok := config.PortableDir(portable); ok {
+		defaultPath = filepath.Join(dir, config.FileName)
+	} else if path, err := config.DefaultConfigPath(); err == nil {
+		defaultPath = path
+	}
+	if defaultPath == configPath {
+		return args
+	}
+	return append(args, "--config", configPath)
+}
+
+// toggleAutostart adds or removes the autostart entry.
+func (s *SystrayManager) toggleAutostart() {
+	defer s.refreshAutostartItem()
+
+	enabled, err := autostart.Enabled()
+	if err != nil {
+		log.Printf("Error checking autostart state: %v", err)
+		ShowAdminNotification(LevelError, "Autostart Error", i18n.Tf("Could not check the autostart entry: %v", err))
+		return
+	}
+
+	if enabled {
+		if err := autostart.Disable(); err != nil {
+			log.Printf("Error disabling autostart: %v", err)
+			ShowAdminNotification(LevelError, "Autostart Error", i18n.Tf("Could not remove the autostart entry: %v", err))
+			return
+		}
+		log.Println("Autostart disabled.")
+		ShowAdminNotification(LevelInfo, "Autostart Disabled", "Clipboard Regex Replace no longer starts when you log in.")
+		return
+	}
+
+	if IsDevMode() {
+		ShowAdminNotification(LevelWarn, "Autostart Not Available", "App running in dev mode. Build the executable to start it with the system.")
+		return
+	}
+	if err := autostart.Enable(s.autostartArgs()); err != nil {
+		log.Printf("Error enabling autostart: %v", err)
+		ShowAdminNotification(LevelError, "Autostart Error", i18n.Tf("Could not create the autostart entry: %v", err))
+		return
+	}
+	log.Println("Autostart enabled.")
+	ShowAdminNotification(LevelInfo, "Autostart Enabled", "Clipboard Regex Replace starts when you log in.")
+}
@@ -0,0 +1,28 @@
+package ui
+
+import "github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+
+const pauseTitle = "Pause Hotkeys"
+
+// SetPaused checks the "Pause Hotkeys" item and shows the paused icon while
+// hotkeys are ignored, whether paused from the menu or "clipregex pause".
+func (s *SystrayManager) SetPaused(paused bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = paused
+	s.applyPauseTitleLocked()
+	s.refreshIconLocked()
+}
+
+// applyPauseTitleLocked shows whether hotkeys are paused. The caller must
+// hold s.mu.
+func (s *SystrayManager) applyPauseTitleLocked() {
+	if s.miPause == nil {
+		return
+	}
+	if s.paused {
+		s.miPause.SetTitle("✓ " + i18n.T(pauseTitle))
+	} else {
+		s.miPause.SetTitle("  " + i18n.T(pauseTitle))
+	}
+}
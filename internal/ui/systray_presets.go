@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"log"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+	"github.com/getlantern/systray"
+)
+
+// presetMenu is the "Presets" submenu with one item per built-in preset.
+type presetMenu struct {
+	parent *systray.MenuItem
+	items  []*systray.MenuItem // Item i shows config.Presets()[i]
+}
+
+// presetMenuTitle returns the title of a preset in the Presets submenu: a
+// plus sign while it is not in config.json, then a checkmark while its
+// profile is enabled.
+func presetMenuTitle(cfg *config.Config, name string) string {
+	idx := -1
+	if cfg != nil {
+		idx = cfg.FindProfile(name)
+	}
+	switch {
+	case idx < 0:
+		return "➕ " + name
+	case cfg.Profiles[idx].Enabled:
+		return "✓ " + name
+	default:
+		return "  " + name
+	}
+}
+
+// buildPresetMenu adds the "Presets" submenu at the current end of the menu.
+func (s *SystrayManager) buildPresetMenu() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pm := &presetMenu{parent: systray.AddMenuItem(i18n.T("Presets"), i18n.T("Ready-made profiles you can add to config.json and edit"))}
+	for _, preset := range config.Presets() {
+		item := pm.parent.AddSubMenuItem("", i18n.T(preset.Description))
+		pm.items = append(pm.items, item)
+		go s.handlePresetClicks(item, preset.Profile.Name)
+	}
+	s.presetMenu = pm
+	s.refreshPresetMenuLocked()
+}
+
+// refreshPresetMenuLocked updates the titles of the preset items, e.g. after
+// a reload or a profile toggle. s.mu must be held.
+func (s *SystrayManager) refreshPresetMenuLocked() {
+	if s.presetMenu == nil {
+		return // Built by onReady
+	}
+	for i, preset := range config.Presets() {
+		if i < len(s.presetMenu.items) {
+			s.presetMenu.items[i].SetTitle(presetMenuTitle(s.config, preset.Profile.Name))
+		}
+	}
+}
+
+// handlePresetClicks adds the preset called name to config.json the first
+// time item is clicked; later clicks enable or disable its profile like the
+// Profiles submenu does.
+func (s *SystrayManager) handlePresetClicks(item *systray.MenuItem, name string) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("RECOVERED FROM PANIC IN PRESET HANDLER (%s): %v", name, r)
+		}
+	}()
+
+	for range item.ClickedCh {
+		s.mu.Lock()
+		if s.config == nil {
+			s.mu.Unlock()
+			log.Println("Error: Cannot add preset, config is nil.")
+			ShowAdminNotification(LevelError, "Internal Error", "Application configuration not loaded.")
+			continue
+		}
+		if idx := s.config.FindProfile(name); idx >= 0 {
+			s.mu.Unlock()
+			s.toggleProfile(idx)
+			continue
+		}
+
+		preset, ok := config.FindPreset(name)
+		if !ok {
+			s.mu.Unlock()
+			continue
+		}
+		profile := preset.Profile
+		hotkeyTaken := s.config.HotkeyInUse(profile.Hotkey)
+		if hotkeyTaken {
+			profile.Enabled = false // Enabling it would run both on the same hotkey
+		}
+		s.config.Profiles = append(s.config.Profiles, profile)
+
+		err := s.config.Save()
+		if err != nil {
+			s.config.Profiles = s.config.Profiles[:len(s.config.Profiles)-1] // Roll back the in-memory change
+		}
+		s.mu.Unlock()
+
+		if err != nil {
+			log.Printf("Failed to save config after adding preset '%s': %v", name, err)
+			ShowAdminNotification(LevelError, "Error Adding Profile", i18n.Tf("Failed to save updated config file. Error: %v", err))
+			continue
+		}
+		log.Printf("Added preset '%s' to the config (hotkey %s, enabled=%t).", name, profile.Hotkey, profile.Enabled)
+		msg := i18n.Tf("Preset '%s' added to config.json with the hotkey %s. Edit it like any other profile.", name, profile.Hotkey)
+		if hotkeyTaken {
+			msg = i18n.Tf("Preset '%s' added to config.json, but disabled: its hotkey %s is already in use. Change the hotkey, then enable it.", name, profile.Hotkey)
+		}
+		ShowAdminNotification(LevelInfo, "Preset Added", msg)
+		if s.onReloadConfig != nil {
+			s.onReloadConfig() // Adds the profile to the menu and registers its hotkey
+		}
+	}
+}
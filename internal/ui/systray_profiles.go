@@ -0,0 +1,383 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+	"github.com/getlantern/systray"
+)
+
+// profileMenu is the "Profiles" submenu. The systray library cannot remove
+// menu items, so profile items are reused by index and hidden while there are
+// fewer profiles. New items are appended, so "Add New Profile" is recreated
+// after them to stay last.
+type profileMenu struct {
+	parent     *systray.MenuItem
+	profiles   []*profileMenuSlot // Slot i shows profile i; slots beyond the profiles are hidden
+	noProfiles *systray.MenuItem  // "(No profiles defined)", created when first needed
+	separator  *systray.MenuItem
+	addProfile *systray.MenuItem
+}
+
+// profileMenuSlot is the submenu of one profile: an item toggling the
+// profile, items running it on the clipboard and, below a separator, one item
+// per rule.
+type profileMenuSlot struct {
+	item       *systray.MenuItem
+	toggle     *systray.MenuItem
+	run        *systray.MenuItem
+	runReverse *systray.MenuItem // Hidden unless the profile has reverse rules
+	separator  *systray.MenuItem
+	rules      []*systray.MenuItem // Item j toggles rule j; items beyond the rules are hidden
+}
+
+// profileMenuTitle returns the title of a profile in the Profiles submenu: a
+// checkmark, or for profiles of a group a radio button, and the name.
+func profileMenuTitle(profile config.ProfileConfig) string {
+	switch {
+	case profile.Group != "" && profile.Enabled:
+		return "◉ " + profile.Name
+	case profile.Group != "":
+		return "○ " + profile.Name
+	case profile.Enabled:
+		return "✓ " + profile.Name
+	default:
+		return "  " + profile.Name
+	}
+}
+
+// profileMenuTooltip describes a profile's hotkeys in the Profiles submenu.
+func profileMenuTooltip(profile config.ProfileConfig) string {
+	var tooltip string
+	if profile.Hotkey == "" {
+		tooltip = i18n.Tf("Profile: %s (Smart hotkey: %s)", profile.Name, strings.Join(profile.ContentTypes, ", "))
+	} else if profile.ReverseHotkey != "" {
+		tooltip = i18n.Tf("Profile: %s (Hotkey: %s, Reverse: %s)", profile.Name, profile.Hotkey, profile.ReverseHotkey)
+	} else {
+		tooltip = i18n.Tf("Profile: %s (Hotkey: %s)", profile.Name, profile.Hotkey)
+	}
+	return tooltip
+}
+
+// profileToggleTitle returns the title of the item enabling a profile.
+func profileToggleTitle(profile config.ProfileConfig) string {
+	if profile.Enabled {
+		return "✓ " + i18n.T("Enabled")
+	}
+	return "  " + i18n.T("Enabled")
+}
+
+// profileToggleTooltip explains the item enabling a profile.
+func profileToggleTooltip(profile config.ProfileConfig) string {
+	tooltip := i18n.Tf("Toggle profile: %s", profile.Name)
+	if profile.Group != "" {
+		tooltip += i18n.Tf(" - enabling it disables the other profiles of group '%s'", profile.Group)
+	}
+	return tooltip
+}
+
+// profileHasReverse reports whether a profile can be run in reverse: it has a
+// reverse hotkey or a rule with a reverse replacement.
+func profileHasReverse(profile config.ProfileConfig) bool {
+	if profile.ReverseHotkey != "" {
+		return true
+	}
+	for _, rule := range profile.Replacements {
+		if rule.ReverseWith != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// buildProfileMenu adds the "Profiles" submenu at the current end of the menu.
+func (s *SystrayManager) buildProfileMenu() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.profileMenu = &profileMenu{parent: systray.AddMenuItem(i18n.T("Profiles"), i18n.T("Manage replacement profiles and their rules"))}
+	s.rebuildProfileMenuLocked()
+}
+
+// rebuildProfileMenuLocked makes the Profiles submenu match the profiles of
+// the current config, e.g. after a reload added, removed or renamed
+// profiles. s.mu must be held.
+func (s *SystrayManager) rebuildProfileMenuLocked() {
+	pm := s.profileMenu
+	if pm == nil {
+		return // Built by onReady
+	}
+	var profiles []config.ProfileConfig
+	if s.config != nil {
+		profiles = s.config.Profiles
+	}
+
+	added := false
+	for i, profile := range profiles {
+		if i == len(pm.profiles) {
+			slot := &profileMenuSlot{item: pm.parent.AddSubMenuItem("", "")}
+			slot.toggle = slot.item.AddSubMenuItem("", "")
+			slot.run = slot.item.AddSubMenuItem(i18n.T("▶ Run Now"), "")
+			slot.runReverse = slot.item.AddSubMenuItem(i18n.T("◀ Run Reverse Now"), "")
+			slot.separator = slot.item.AddSubMenuItem("----------", "Separator")
+			slot.separator.Disable()
+			pm.profiles = append(pm.profiles, slot)
+			go s.handleProfileClicks(slot.toggle, i)
+			go s.handleRunProfileClicks(slot.run, i, false)
+			go s.handleRunProfileClicks(slot.runReverse, i, true)
+			added = true
+		}
+		slot := pm.profiles[i]
+		slot.item.SetTitle(profileMenuTitle(profile))
+		slot.item.SetTooltip(profileMenuTooltip(profile))
+		slot.item.Show()
+		slot.toggle.SetTitle(profileToggleTitle(profile))
+		slot.toggle.SetTooltip(profileToggleTooltip(profile))
+		slot.run.SetTooltip(i18n.Tf("Apply profile '%s' to the clipboard now, even without a hotkey", profile.Name))
+		slot.runReverse.SetTooltip(i18n.Tf("Apply the reverse rules of profile '%s' to the clipboard now", profile.Name))
+		if profileHasReverse(profile) {
+			slot.runReverse.Show()
+		} else {
+			slot.runReverse.Hide()
+		}
+		s.rebuildRuleItemsLocked(slot, i, profile)
+	}
+	for _, slot := range pm.profiles[len(profiles):] {
+		slot.item.Hide()
+	}
+
+	if len(profiles) == 0 {
+		log.Println("No profiles defined in config or config is nil.")
+		if pm.noProfiles == nil {
+			pm.noProfiles = pm.parent.AddSubMenuItem(i18n.T("(No profiles defined)"), i18n.T("Add profiles in config.json"))
+			pm.noProfiles.Disable()
+			added = true
+		}
+		pm.noProfiles.Show()
+	} else if pm.noProfiles != nil {
+		pm.noProfiles.Hide()
+	}
+
+	if added {
+		if pm.addProfile != nil {
+			pm.separator.Hide() // Replaced below, after the new items
+			pm.addProfile.Hide()
+		}
+		pm.separator = pm.parent.AddSubMenuItem("----------", "Separator")
+		pm.separator.Disable()
+		pm.addProfile = pm.parent.AddSubMenuItem(i18n.T("➕ Add New Profile"), i18n.T("Adds a template profile to config.json"))
+		go s.handleAddProfileClicks(pm.addProfile)
+	}
+	s.refreshPresetMenuLocked() // Presets show whether their profile exists
+	log.Printf("SystrayManager: Profiles menu shows %d profile(s) (%d slot(s) created).", len(profiles), len(pm.profiles))
+}
+
+// refreshProfileTitlesLocked updates the titles of all profile menu items,
+// e.g. after enabling a profile disabled the others of its group. s.mu must
+// be held.
+func (s *SystrayManager) refreshProfileTitlesLocked() {
+	if s.profileMenu == nil {
+		return
+	}
+	for i, slot := range s.profileMenu.profiles {
+		if i < len(s.config.Profiles) {
+			slot.item.SetTitle(profileMenuTitle(s.config.Profiles[i]))
+			slot.toggle.SetTitle(profileToggleTitle(s.config.Profiles[i]))
+		}
+	}
+	s.refreshPresetMenuLocked()
+}
+
+// handleProfileClicks toggles profile idx each time item is clicked.
+func (s *SystrayManager) handleProfileClicks(item *systray.MenuItem, idx int) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("RECOVERED FROM PANIC IN PROFILE TOGGLE HANDLER (index %d): %v", idx, r)
+		}
+	}()
+
+	for range item.ClickedCh {
+		s.toggleProfile(idx)
+	}
+}
+
+// handleRunProfileClicks runs profile idx on the clipboard, in reverse if
+// reverse is set, each time item is clicked.
+func (s *SystrayManager) handleRunProfileClicks(item *systray.MenuItem, idx int, reverse bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("RECOVERED FROM PANIC IN RUN PROFILE HANDLER (index %d): %v", idx, r)
+		}
+	}()
+
+	for range item.ClickedCh {
+		s.mu.RLock()
+		var name string
+		if s.config != nil && idx < len(s.config.Profiles) {
+			name = s.config.Profiles[idx].Name
+		}
+		s.mu.RUnlock()
+		if name == "" {
+			log.Printf("Error: Profile index %d out of bounds after config change. Cannot run.", idx)
+			ShowAdminNotification(LevelWarn, "Menu Inconsistency", "Profile list changed unexpectedly. Please use Reload Configuration.")
+			continue
+		}
+		if s.onRunProfile != nil {
+			s.onRunProfile(name, reverse)
+		}
+	}
+}
+
+// toggleProfile enables or disables profile idx, saves the config and
+// reloads it to update the hotkeys.
+func (s *SystrayManager) toggleProfile(idx int) {
+	// --- Safely access config and profile under lock ---
+	s.mu.Lock()
+	if s.config == nil || s.config.Profiles == nil || idx >= len(s.config.Profiles) {
+		s.mu.Unlock()
+		errMsg := "Profile list changed unexpectedly. Please use Reload Configuration."
+		log.Printf("Error: Profile index %d out of bounds or config nil after config change. Cannot toggle.", idx)
+		ShowAdminNotification(LevelWarn, "Menu Inconsistency", errMsg)
+		return
+	}
+	p := &s.config.Profiles[idx] // Get pointer to modify directly
+	before := make([]bool, len(s.config.Profiles))
+	for i := range s.config.Profiles {
+		before[i] = s.config.Profiles[i].Enabled
+	}
+
+	// --- Toggle State ---
+	if p.Enabled {
+		p.Enabled = false
+	} else {
+		// Like a radio button: the other profiles of its group are disabled
+		for _, name := range s.config.EnableProfileExclusive(idx) {
+			log.Printf("Disabled profile '%s' of group '%s'", name, p.Group)
+		}
+	}
+	profileName := p.Name
+	profileEnabled := p.Enabled
+	log.Printf("Toggled profile '%s' to enabled=%t", profileName, profileEnabled)
+
+	// --- Update Menu Item Visual ---
+	s.refreshProfileTitlesLocked()
+
+	// --- Save Config ---
+	err := s.config.Save()
+	s.mu.Unlock()
+
+	if err != nil {
+		errMsg := i18n.Tf("Failed to save config after toggling '%s'. Error: %v", profileName, err)
+		log.Printf("Failed to save config after toggling profile '%s': %v", profileName, err)
+		ShowAdminNotification(LevelError, "Save Error", errMsg)
+
+		// Revert in-memory state
+		s.mu.Lock()
+		if len(before) == len(s.config.Profiles) {
+			for i := range s.config.Profiles {
+				s.config.Profiles[i].Enabled = before[i]
+			}
+			s.refreshProfileTitlesLocked()
+		}
+		s.mu.Unlock()
+	} else {
+		// --- Notify & Reload ---
+		status := map[bool]string{true: i18n.T("enabled"), false: i18n.T("disabled")}[profileEnabled]
+		msg := i18n.Tf("Profile '%s' has been %s. Reloading...", profileName, status)
+		ShowAdminNotification(LevelInfo, "Profile Updated", msg)
+		if s.onReloadConfig != nil {
+			log.Println("Triggering internal config reload after profile toggle to update hotkeys.")
+			// Slight delay to allow notification to potentially show first
+			time.Sleep(150 * time.Millisecond)
+			s.onReloadConfig()
+		}
+	}
+}
+
+// handleAddProfileClicks adds a template profile each time item is clicked.
+func (s *SystrayManager) handleAddProfileClicks(item *systray.MenuItem) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("RECOVERED FROM PANIC IN ADD PROFILE HANDLER: %v", r)
+		}
+	}()
+
+	for range item.ClickedCh {
+		log.Println("'Add New Profile' clicked.")
+
+		// Let the user press the new profile's hotkey rather than type it
+		hotkey, err := RecordHotkey("Press the hotkey for the new profile", "", "")
+		if errors.Is(err, ErrRecordingCanceled) {
+			log.Println("Adding a profile canceled in the hotkey recorder.")
+			continue
+		}
+		if err != nil {
+			log.Printf("Could not record a hotkey for the new profile, using the default: %v", err)
+			hotkey = "ctrl+alt+n"
+		}
+
+		s.mu.Lock()
+		if s.config == nil {
+			s.mu.Unlock()
+			log.Println("Error: Cannot add profile, config is nil.")
+			ShowAdminNotification(LevelError, "Internal Error", "Application configuration not loaded.")
+			continue
+		}
+
+		// Ensure unique name generation remains robust even with frequent additions
+		baseName := "New_Profile"
+		existingNames := make(map[string]bool)
+		if s.config.Profiles != nil {
+			for _, p := range s.config.Profiles {
+				existingNames[p.Name] = true
+			}
+		}
+
+		newProfileName := baseName
+		counter := 1
+		for existingNames[newProfileName] {
+			newProfileName = fmt.Sprintf("%s_%d", baseName, counter)
+			counter++
+		}
+
+		newProfile := config.ProfileConfig{
+			Name:    newProfileName,
+			Enabled: true,
+			Hotkey:  hotkey,
+			Replacements: []config.Replacement{
+				{
+					Regex:       fmt.Sprintf("text_for_%s", newProfileName),
+					ReplaceWith: "replacement_text",
+				},
+			},
+		}
+		s.config.Profiles = append(s.config.Profiles, newProfile)
+
+		err = s.config.Save()
+		s.mu.Unlock()
+
+		if err != nil {
+			errMsg := i18n.Tf("Failed to save updated config file. Error: %v", err)
+			log.Printf("Failed to save config after adding new profile template: %v", err)
+			ShowAdminNotification(LevelError, "Error Adding Profile", errMsg)
+
+			// Roll back in-memory change on save failure
+			s.mu.Lock()
+			if len(s.config.Profiles) > 0 {
+				s.config.Profiles = s.config.Profiles[:len(s.config.Profiles)-1]
+			}
+			s.mu.Unlock()
+		} else {
+			msg := i18n.Tf("Template '%s' added. Edit it in config.json or with 'Edit Rules...'.", newProfile.Name)
+			log.Printf("Added new profile template '%s' and saved config.", newProfile.Name)
+			ShowAdminNotification(LevelInfo, "Profile Template Added", msg)
+			if s.onReloadConfig != nil {
+				s.onReloadConfig() // Adds the profile to the menu and registers its hotkey
+			}
+		}
+	}
+}
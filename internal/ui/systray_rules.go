@@ -0,0 +1,118 @@
+package ui
+
+import (
+	"log"
+	"time"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/config"
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+	"github.com/getlantern/systray"
+)
+
+// ruleMenuMaxLen is the number of characters of a rule's description or
+// regex shown in the menu.
+const ruleMenuMaxLen = 40
+
+// ruleMenuLabel returns the rule's description, or its regex, truncated.
+func ruleMenuLabel(rep config.Replacement) string {
+	label := rep.Description
+	if label == "" {
+		label = rep.Summary()
+	}
+	if label == "" {
+		return i18n.T("(empty rule)")
+	}
+	if runes := []rune(label); len(runes) > ruleMenuMaxLen {
+		label = string(runes[:ruleMenuMaxLen-1]) + "…"
+	}
+	return label
+}
+
+// ruleMenuTitle returns the title of a rule in its profile's submenu.
+func ruleMenuTitle(rep config.Replacement) string {
+	if rep.Disabled {
+		return "  " + ruleMenuLabel(rep)
+	}
+	return "✓ " + ruleMenuLabel(rep)
+}
+
+// ruleMenuTooltip shows the whole rule, since the title may be truncated.
+func ruleMenuTooltip(ruleIdx int, rep config.Replacement) string {
+	summary := rep.Summary()
+	if rep.Description != "" {
+		summary = rep.Description + ": " + summary
+	}
+	return i18n.Tf("Toggle rule #%d (%s)", ruleIdx+1, summary)
+}
+
+// rebuildRuleItemsLocked makes the rule items of a profile's submenu match
+// its rules. New items are appended, which keeps them in order since the
+// rules are the last items of the submenu. s.mu must be held.
+func (s *SystrayManager) rebuildRuleItemsLocked(slot *profileMenuSlot, profileIdx int, profile config.ProfileConfig) {
+	for j, rep := range profile.Replacements {
+		if j == len(slot.rules) {
+			item := slot.item.AddSubMenuItem("", "")
+			slot.rules = append(slot.rules, item)
+			go s.handleRuleClicks(item, profileIdx, j)
+		}
+		item := slot.rules[j]
+		item.SetTitle(ruleMenuTitle(rep))
+		item.SetTooltip(ruleMenuTooltip(j, rep))
+		item.Show()
+	}
+	for _, item := range slot.rules[len(profile.Replacements):] {
+		item.Hide()
+	}
+	if len(profile.Replacements) == 0 {
+		slot.separator.Hide()
+	} else {
+		slot.separator.Show()
+	}
+}
+
+// handleRuleClicks enables or disables rule ruleIdx of profile profileIdx
+// each time item is clicked.
+func (s *SystrayManager) handleRuleClicks(item *systray.MenuItem, profileIdx, ruleIdx int) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("RECOVERED FROM PANIC IN RULE TOGGLE HANDLER (profile %d, rule %d): %v", profileIdx, ruleIdx, r)
+		}
+	}()
+
+	for range item.ClickedCh {
+		s.mu.Lock()
+		if s.config == nil || profileIdx >= len(s.config.Profiles) || ruleIdx >= len(s.config.Profiles[profileIdx].Replacements) {
+			s.mu.Unlock()
+			log.Printf("Error: Rule %d of profile %d out of bounds after config change. Cannot toggle.", ruleIdx, profileIdx)
+			ShowAdminNotification(LevelWarn, "Menu Inconsistency", "Rule list changed unexpectedly. Please use Reload Configuration.")
+			continue
+		}
+		profileName := s.config.Profiles[profileIdx].Name
+		rep := &s.config.Profiles[profileIdx].Replacements[ruleIdx]
+		rep.Disabled = !rep.Disabled
+		disabled := rep.Disabled
+		label := ruleMenuLabel(*rep)
+		log.Printf("Toggled rule #%d (%s) of profile '%s' to disabled=%t", ruleIdx+1, rep.Summary(), profileName, disabled)
+		item.SetTitle(ruleMenuTitle(*rep))
+
+		err := s.config.Save()
+		if err != nil {
+			rep.Disabled = !disabled // Revert in-memory state
+			item.SetTitle(ruleMenuTitle(*rep))
+		}
+		s.mu.Unlock()
+
+		if err != nil {
+			log.Printf("Failed to save config after toggling rule #%d of profile '%s': %v", ruleIdx+1, profileName, err)
+			ShowAdminNotification(LevelError, "Save Error", i18n.Tf("Failed to save config after toggling rule '%s'. Error: %v", label, err))
+			continue
+		}
+		status := map[bool]string{true: i18n.T("disabled"), false: i18n.T("enabled")}[disabled]
+		ShowAdminNotification(LevelInfo, "Rule Updated", i18n.Tf("Rule '%s' of profile '%s' has been %s. Reloading...", label, profileName, status))
+		if s.onReloadConfig != nil {
+			// Slight delay to allow notification to potentially show first
+			time.Sleep(150 * time.Millisecond)
+			s.onReloadConfig()
+		}
+	}
+}
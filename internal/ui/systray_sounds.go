@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"log"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+)
+
+const soundsTitle = "Sound Feedback"
+
+// applySoundsTitleLocked shows whether sounds are enabled globally. The
+// caller must hold s.mu.
+func (s *SystrayManager) applySoundsTitleLocked() {
+	if s.miSounds == nil {
+		return
+	}
+	if s.config != nil && s.config.SoundsEnabled() {
+		s.miSounds.SetTitle("✓ " + i18n.T(soundsTitle))
+	} else {
+		s.miSounds.SetTitle("  " + i18n.T(soundsTitle))
+	}
+}
+
+// toggleSounds turns the global "sounds.enabled" setting on or off and saves
+// the config. Profiles with their own "sounds" setting are not affected.
+func (s *SystrayManager) toggleSounds() {
+	s.mu.Lock()
+	if s.config == nil {
+		s.mu.Unlock()
+		return
+	}
+	enabled := !s.config.SoundsEnabled()
+	s.config.SetSoundsEnabled(enabled)
+	err := s.config.Save()
+	if err != nil {
+		s.config.SetSoundsEnabled(!enabled) // Keep the menu and config.json in sync
+	}
+	s.applySoundsTitleLocked()
+	s.mu.Unlock()
+
+	if err != nil {
+		log.Printf("Failed to save config after toggling sounds: %v", err)
+		ShowAdminNotification(LevelError, "Save Error", i18n.Tf("Failed to save config after toggling sound feedback. Error: %v", err))
+		return
+	}
+	log.Printf("Sound feedback enabled=%t", enabled)
+}
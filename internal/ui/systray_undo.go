@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"log"
+
+	"github.com/TanaroSch/clipboard-regex-replace/internal/i18n"
+	"github.com/getlantern/systray"
+)
+
+// UndoMenuSlots is the number of states the "Undo History" submenu lists.
+const UndoMenuSlots = 10
+
+// UndoMenuEntry is a clipboard state listed in the "Undo History" submenu.
+type UndoMenuEntry struct {
+	Title   string
+	Current bool // The clipboard holds this state
+	Index   int  // Passed to the restore callback when the entry is clicked
+}
+
+// undoMenu is the "Undo History" submenu with a fixed number of slots;
+// slots beyond the entries are hidden.
+type undoMenu struct {
+	parent *systray.MenuItem
+	items  []*systray.MenuItem
+	empty  *systray.MenuItem // Shown while there is nothing to list
+}
+
+// buildUndoMenu adds the "Undo History" submenu at the current end of the menu.
+func (s *SystrayManager) buildUndoMenu() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	um := &undoMenu{parent: systray.AddMenuItem(i18n.T("Undo History"), i18n.T("Set the clipboard to one of its recent states"))}
+	um.empty = um.parent.AddSubMenuItem(i18n.T("(No changes yet)"), i18n.T("Changes made by hotkeys are listed here"))
+	um.empty.Disable()
+	for i := 0; i < UndoMenuSlots; i++ {
+		item := um.parent.AddSubMenuItem("", i18n.T("Set the clipboard to this state"))
+		um.items = append(um.items, item)
+		go s.handleUndoClicks(item, i)
+	}
+	s.undoMenu = um
+	s.applyUndoHistoryLocked()
+}
+
+// UpdateUndoHistory lists entries, newest first, in the "Undo History"
+// submenu. Only the first UndoMenuSlots are shown.
+func (s *SystrayManager) UpdateUndoHistory(entries []UndoMenuEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.undoEntries = entries // Applied by onReady if the menu isn't built yet
+	s.applyUndoHistoryLocked()
+}
+
+// applyUndoHistoryLocked shows s.undoEntries in the submenu. s.mu must be held.
+func (s *SystrayManager) applyUndoHistoryLocked() {
+	if s.undoMenu == nil {
+		return
+	}
+	if len(s.undoEntries) == 0 {
+		s.undoMenu.empty.Show()
+	} else {
+		s.undoMenu.empty.Hide()
+	}
+	for i, item := range s.undoMenu.items {
+		if i >= len(s.undoEntries) {
+			item.Hide()
+			continue
+		}
+		entry := s.undoEntries[i]
+		if entry.Current {
+			item.SetTitle("✓ " + entry.Title)
+			item.Disable()
+		} else {
+			item.SetTitle("  " + entry.Title)
+			item.Enable()
+		}
+		item.Show()
+	}
+}
+
+// handleUndoClicks restores the state listed in slot when its item is clicked.
+func (s *SystrayManager) handleUndoClicks(item *systray.MenuItem, slot int) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("RECOVERED FROM PANIC IN UNDO HISTORY HANDLER (%d): %v", slot, r)
+		}
+	}()
+
+	for range item.ClickedCh {
+		s.mu.RLock()
+		index, ok := -1, slot < len(s.undoEntries)
+		if ok {
+			index = s.undoEntries[slot].Index
+		}
+		s.mu.RUnlock()
+		if !ok || s.onRestoreUndo == nil {
+			continue
+		}
+		log.Printf("Undo History item %d clicked.", slot+1)
+		s.onRestoreUndo(index)
+	}
+}
@@ -0,0 +1,129 @@
+package ui
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+)
+
+//go:embed web/*.html
+var webAssets embed.FS
+
+// webServer is a lazily started HTTP server bound to the loopback interface.
+// It backs the browser-based windows (rule editor, ...) so they can talk to the
+// running application. Every request must carry the per-session token, which
+// keeps other local web pages from driving the API.
+type webServer struct {
+	mu       sync.Mutex
+	mux      *http.ServeMux
+	listener net.Listener
+	token    string
+	baseURL  string
+}
+
+var globalWebServer = &webServer{mux: http.NewServeMux()}
+
+// ensureStarted starts the server on first use and returns its base URL.
+func (w *webServer) ensureStarted() (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.listener != nil {
+		return w.baseURL, nil
+	}
+
+	tokenBytes := make([]byte, 16)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", fmt.Errorf("failed to generate web UI token: %w", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("failed to start local web UI server: %w", err)
+	}
+
+	w.token = hex.EncodeToString(tokenBytes)
+	w.listener = listener
+	w.baseURL = "http://" + listener.Addr().String()
+	log.Printf("Local web UI server listening on %s", w.baseURL)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("RECOVERED FROM PANIC IN WEB UI SERVER: %v", r)
+			}
+		}()
+		if err := http.Serve(listener, w.mux); err != nil {
+			log.Printf("Local web UI server stopped: %v", err)
+		}
+	}()
+
+	return w.baseURL, nil
+}
+
+// handle registers a handler that is only reachable with the session token.
+func (w *webServer) handle(pattern string, handler http.HandlerFunc) {
+	w.mux.HandleFunc(pattern, func(rw http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("X-ClipRegex-Token")
+		if token == "" {
+			token = r.URL.Query().Get("token")
+		}
+		w.mu.Lock()
+		expected := w.token
+		w.mu.Unlock()
+		if expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+			http.Error(rw, "forbidden", http.StatusForbidden)
+			return
+		}
+		handler(rw, r)
+	})
+}
+
+// pageURL returns the tokenized URL for a page path such as "/rule-editor".
+func (w *webServer) pageURL(path string) (string, error) {
+	baseURL, err := w.ensureStarted()
+	if err != nil {
+		return "", err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return fmt.Sprintf("%s%s?token=%s", baseURL, path, w.token), nil
+}
+
+// serveAsset writes an embedded HTML page.
+func serveAsset(rw http.ResponseWriter, name string) {
+	data, err := webAssets.ReadFile("web/" + name)
+	if err != nil {
+		http.Error(rw, "page not found", http.StatusNotFound)
+		return
+	}
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	rw.Header().Set("Cache-Control", "no-store")
+	_, _ = rw.Write(data)
+}
+
+// writeJSON encodes v as the JSON response body.
+func writeJSON(rw http.ResponseWriter, status int, v interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	if err := json.NewEncoder(rw).Encode(v); err != nil {
+		log.Printf("Error writing web UI JSON response: %v", err)
+	}
+}
+
+// openWebPage starts the server if needed and opens the page in the default browser.
+func openWebPage(path string) error {
+	url, err := globalWebServer.pageURL(path)
+	if err != nil {
+		return err
+	}
+	log.Printf("Opening web UI page: %s", path)
+	return OpenFileInDefaultApp(url)
+}
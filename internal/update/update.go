@@ -0,0 +1,303 @@
+// Package update checks the GitHub releases of the project for a newer
+// version and replaces the running executable with a release asset.
+package update
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Repository is the GitHub repository whose releases are checked.
+const Repository = "TanaroSch/Clipboard-Regex-Replace-2"
+
+// ReleasesPage is the web page listing all releases.
+const ReleasesPage = "https://github.com/" + Repository + "/releases"
+
+// latestReleaseURL returns the newest non-prerelease release.
+const latestReleaseURL = "https://api.github.com/repos/" + Repository + "/releases/latest"
+
+// maxAssetBytes limits the size of a downloaded executable.
+const maxAssetBytes = 200 * 1024 * 1024
+
+// oldSuffix is appended to the replaced executable. Windows cannot delete a
+// running executable, only rename it, so it is removed on the next start.
+const oldSuffix = ".old"
+
+// ErrNoAsset is returned by Release.Asset if the release has no executable
+// for the current platform, e.g. because it only ships archives.
+var ErrNoAsset = errors.New("the release has no executable for this platform")
+
+// client is used for all requests; downloads can take a while.
+var client = &http.Client{Timeout: 5 * time.Minute}
+
+// Asset is a file attached to a release.
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+	Size int64  `json:"size"`
+}
+
+// Release is a GitHub release.
+type Release struct {
+	TagName string  `json:"tag_name"`
+	Name    string  `json:"name"`
+	HTMLURL string  `json:"html_url"`
+	Assets  []Asset `json:"assets"`
+}
+
+// Latest fetches the newest published release.
+func Latest(ctx context.Context) (*Release, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, latestReleaseURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query GitHub releases: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GitHub releases returned %s", resp.Status)
+	}
+
+	var release Release
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 10*1024*1024)).Decode(&release); err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub release: %w", err)
+	}
+	if release.TagName == "" {
+		return nil, errors.New("GitHub release has no tag")
+	}
+	return &release, nil
+}
+
+// parseVersion parses "v1.8.0" or "1.8" into its numeric parts. Anything
+// after a "-" or "+" (pre-release, build metadata) is ignored.
+func parseVersion(version string) ([3]int, bool) {
+	var parts [3]int
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	fields := strings.Split(version, ".")
+	if len(fields) == 0 || len(fields) > 3 {
+		return parts, false
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
+
+// Newer reports whether the release tag is a higher version than current.
+// Versions that cannot be parsed, like development builds, are never older.
+func Newer(current, tag string) bool {
+	cur, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+	latest, ok := parseVersion(tag)
+	if !ok {
+		return false
+	}
+	for i := range cur {
+		if latest[i] != cur[i] {
+			return latest[i] > cur[i]
+		}
+	}
+	return false
+}
+
+// platformNames lists the spellings of the current OS and architecture used
+// in asset names.
+func platformNames() (osNames, archNames []string) {
+	switch runtime.GOOS {
+	case "windows":
+		osNames = []string{"windows", "win"}
+	case "darwin":
+		osNames = []string{"darwin", "macos", "mac"}
+	default:
+		osNames = []string{runtime.GOOS}
+	}
+	switch runtime.GOARCH {
+	case "amd64":
+		archNames = []string{"amd64", "x86_64", "x64"}
+	case "arm64":
+		archNames = []string{"arm64", "aarch64"}
+	case "386":
+		archNames = []string{"386", "x86", "i386"}
+	default:
+		archNames = []string{runtime.GOARCH}
+	}
+	return osNames, archNames
+}
+
+func containsAny(s string, subs []string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// Asset returns the executable for the current platform. Archives and
+// checksum files are skipped. On Windows, a single ".exe" without platform
+// in its name is accepted as well, as earlier releases only shipped that.
+func (r *Release) Asset() (Asset, error) {
+	osNames, archNames := platformNames()
+	var fallback []Asset
+	for _, asset := range r.Assets {
+		name := strings.ToLower(asset.Name)
+		if containsAny(name, []string{".zip", ".tar", ".gz", ".dmg", ".msi", ".sha256", "checksums", "sha256sums", ".txt", ".sig", ".asc"}) {
+			continue
+		}
+		if runtime.GOOS == "windows" && !strings.HasSuffix(name, ".exe") {
+			continue
+		}
+		if runtime.GOOS != "windows" && strings.HasSuffix(name, ".exe") {
+			continue
+		}
+		if containsAny(name, osNames) {
+			if containsAny(name, archNames) {
+				return asset, nil
+			}
+			continue
+		}
+		fallback = append(fallback, asset)
+	}
+	if runtime.GOOS == "windows" && len(fallback) == 1 {
+		return fallback[0], nil
+	}
+	return Asset{}, ErrNoAsset
+}
+
+// download fetches url into w, reading at most limit bytes.
+func download(ctx context.Context, url string, w io.Writer, limit int64) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download of %s returned %s", url, resp.Status)
+	}
+	n, err := io.Copy(w, io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	if n > limit {
+		return fmt.Errorf("download of %s exceeds %d bytes", url, limit)
+	}
+	return nil
+}
+
+// expectedChecksum looks for the SHA-256 of asset in a "<asset>.sha256" file
+// or a checksums file of the release. It returns "" if there is none.
+func (r *Release) expectedChecksum(ctx context.Context, asset Asset) (string, error) {
+	for _, candidate := range r.Assets {
+		name := strings.ToLower(candidate.Name)
+		if name != strings.ToLower(asset.Name)+".sha256" && !strings.Contains(name, "checksums") && !strings.Contains(name, "sha256sums") {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := download(ctx, candidate.URL, &buf, 1024*1024); err != nil {
+			return "", err
+		}
+		// Lines are "<hex>  <file name>" or just "<hex>" for single-file sums
+		scanner := bufio.NewScanner(&buf)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) == 0 {
+				continue
+			}
+			if len(fields) == 1 || strings.TrimPrefix(fields[len(fields)-1], "*") == asset.Name {
+				return strings.ToLower(fields[0]), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// Install downloads asset, verifies its checksum if the release publishes
+// one, and replaces the executable at exe with it. The previous executable
+// is kept as exe+".old" until CleanupOld runs on the next start. The caller
+// restarts the application to run the new version.
+func Install(ctx context.Context, release *Release, asset Asset, exe string) error {
+	if asset.Size > maxAssetBytes {
+		return fmt.Errorf("%s is too large (%d bytes)", asset.Name, asset.Size)
+	}
+	expected, err := release.expectedChecksum(ctx, asset)
+	if err != nil {
+		return fmt.Errorf("failed to get checksum: %w", err)
+	}
+
+	// The new file must be in the same folder to be renamed over exe
+	tmp, err := os.CreateTemp(filepath.Dir(exe), "."+filepath.Base(exe)+".update-*")
+	if err != nil {
+		return fmt.Errorf("failed to create file next to the executable: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op once renamed
+
+	hash := sha256.New()
+	if err := download(ctx, asset.URL, io.MultiWriter(tmp, hash), maxAssetBytes); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if expected != "" {
+		if actual := hex.EncodeToString(hash.Sum(nil)); actual != expected {
+			return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", asset.Name, expected, actual)
+		}
+	}
+	if err := os.Chmod(tmpPath, 0755); err != nil {
+		return err
+	}
+
+	old := exe + oldSuffix
+	os.Remove(old) // Left over from an earlier update
+	if err := os.Rename(exe, old); err != nil {
+		return fmt.Errorf("failed to move the current executable aside: %w", err)
+	}
+	if err := os.Rename(tmpPath, exe); err != nil {
+		if rollbackErr := os.Rename(old, exe); rollbackErr != nil {
+			return fmt.Errorf("failed to install the new executable (%v) and to restore the old one: %w", err, rollbackErr)
+		}
+		return fmt.Errorf("failed to install the new executable: %w", err)
+	}
+	return nil
+}
+
+// CleanupOld removes the executable replaced by the last update, if any.
+func CleanupOld(exe string) error {
+	err := os.Remove(exe + oldSuffix)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}